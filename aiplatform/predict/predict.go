@@ -0,0 +1,256 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package predict sends online prediction and explanation requests to Vertex
+// AI endpoints with typed instances and predictions, using the aiplatform/apiv1
+// PredictionClient.
+//
+// An Endpoint converts Go values of its instance type to prediction instances
+// and the predictions back to Go values of its prediction type, with their
+// JSON encoding, so struct fields are named by their json tags. It retries the
+// requests that fail while the traffic split of the endpoint changes, as
+// during a rolling deployment.
+//
+// Example:
+//
+//	type Flower struct {
+//		SepalLength float64 `json:"sepal_length"`
+//		SepalWidth  float64 `json:"sepal_width"`
+//	}
+//	type Class struct {
+//		Classes []string  `json:"classes"`
+//		Scores  []float64 `json:"scores"`
+//	}
+//
+//	client, err := aiplatform.NewPredictionClient(ctx, option.WithEndpoint("us-central1-aiplatform.googleapis.com:443"))
+//	if err != nil {
+//		// TODO: Handle error.
+//	}
+//	e := predict.NewEndpoint[Flower, Class](client, "projects/my-project/locations/us-central1/endpoints/123")
+//	res, err := e.Predict(ctx, []Flower{{SepalLength: 5.1, SepalWidth: 3.5}})
+//	if err != nil {
+//		// TODO: Handle error.
+//	}
+//	fmt.Println(res.Predictions[0].Classes, res.DeployedModelID)
+package predict // import "cloud.google.com/go/aiplatform/predict"
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/aiplatform/apiv1/aiplatformpb"
+	gax "github.com/googleapis/gax-go/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// DefaultMaxAttempts is the default number of times a request is sent
+// before its error is returned.
+const DefaultMaxAttempts = 5
+
+// retryBackoff is the backoff between attempts at sending a request.
+var retryBackoff = gax.Backoff{
+	Initial:    500 * time.Millisecond,
+	Max:        10 * time.Second,
+	Multiplier: 2,
+}
+
+// Client is the subset of the methods of the aiplatform/apiv1
+// PredictionClient used by an Endpoint.
+type Client interface {
+	Predict(context.Context, *aiplatformpb.PredictRequest, ...gax.CallOption) (*aiplatformpb.PredictResponse, error)
+	Explain(context.Context, *aiplatformpb.ExplainRequest, ...gax.CallOption) (*aiplatformpb.ExplainResponse, error)
+}
+
+// An Endpoint sends requests with instances of type I to a Vertex AI
+// endpoint, whose predictions are of type P.
+type Endpoint[I, P any] struct {
+	client Client
+	name   string
+
+	// Parameters, if not nil, are the parameters of every request, encoded
+	// like the instances.
+	Parameters any
+
+	// MaxAttempts is the maximum number of times a request is sent. If it is
+	// not positive, DefaultMaxAttempts is used.
+	MaxAttempts int
+}
+
+// NewEndpoint returns an Endpoint for the endpoint with the given full name,
+// of the form "projects/PROJECT/locations/LOCATION/endpoints/ENDPOINT".
+func NewEndpoint[I, P any](client Client, name string) *Endpoint[I, P] {
+	return &Endpoint[I, P]{client: client, name: name}
+}
+
+// Result is the result of a prediction request.
+type Result[P any] struct {
+	// Predictions are the predictions for the instances, in order.
+	Predictions []P
+
+	// DeployedModelID is the ID of the deployed model that served the
+	// request. It tells which model of the traffic split of the endpoint
+	// made the predictions.
+	DeployedModelID string
+
+	// Model is the resource name of the model of the deployed model.
+	Model string
+
+	// ModelVersionID is the version of the model.
+	ModelVersionID string
+}
+
+// ExplainResult is the result of an explanation request.
+type ExplainResult[P any] struct {
+	Result[P]
+
+	// Explanations are the explanations of the predictions, in order.
+	Explanations []*aiplatformpb.Explanation
+}
+
+// Predict returns the predictions for instances.
+func (e *Endpoint[I, P]) Predict(ctx context.Context, instances []I) (*Result[P], error) {
+	req := &aiplatformpb.PredictRequest{Endpoint: e.name}
+	var err error
+	if req.Instances, req.Parameters, err = e.encodeRequest(instances); err != nil {
+		return nil, err
+	}
+	var resp *aiplatformpb.PredictResponse
+	err = e.retry(ctx, func() (err error) {
+		resp, err = e.client.Predict(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	preds, err := decodeValues[P](resp.Predictions)
+	if err != nil {
+		return nil, err
+	}
+	return &Result[P]{
+		Predictions:     preds,
+		DeployedModelID: resp.DeployedModelId,
+		Model:           resp.Model,
+		ModelVersionID:  resp.ModelVersionId,
+	}, nil
+}
+
+// Explain returns the predictions for instances with their explanations. If
+// deployedModelID is not empty, the request is served by that deployed model
+// rather than according to the traffic split, which requires the deployed
+// model to have an explanation spec.
+func (e *Endpoint[I, P]) Explain(ctx context.Context, instances []I, deployedModelID string) (*ExplainResult[P], error) {
+	req := &aiplatformpb.ExplainRequest{Endpoint: e.name, DeployedModelId: deployedModelID}
+	var err error
+	if req.Instances, req.Parameters, err = e.encodeRequest(instances); err != nil {
+		return nil, err
+	}
+	var resp *aiplatformpb.ExplainResponse
+	err = e.retry(ctx, func() (err error) {
+		resp, err = e.client.Explain(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	preds, err := decodeValues[P](resp.Predictions)
+	if err != nil {
+		return nil, err
+	}
+	return &ExplainResult[P]{
+		Result:       Result[P]{Predictions: preds, DeployedModelID: resp.DeployedModelId},
+		Explanations: resp.Explanations,
+	}, nil
+}
+
+func (e *Endpoint[I, P]) encodeRequest(instances []I) ([]*structpb.Value, *structpb.Value, error) {
+	vs := make([]*structpb.Value, len(instances))
+	for i, in := range instances {
+		v, err := encodeValue(in)
+		if err != nil {
+			return nil, nil, fmt.Errorf("predict: instance %d: %w", i, err)
+		}
+		vs[i] = v
+	}
+	if e.Parameters == nil {
+		return vs, nil, nil
+	}
+	params, err := encodeValue(e.Parameters)
+	if err != nil {
+		return nil, nil, fmt.Errorf("predict: parameters: %w", err)
+	}
+	return vs, params, nil
+}
+
+// encodeValue converts x to a Value with its JSON encoding.
+func encodeValue(x any) (*structpb.Value, error) {
+	b, err := json.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+	v := &structpb.Value{}
+	if err := protojson.Unmarshal(b, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func decodeValues[P any](vs []*structpb.Value) ([]P, error) {
+	out := make([]P, len(vs))
+	for i, v := range vs {
+		b, err := protojson.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, &out[i]); err != nil {
+			return nil, fmt.Errorf("predict: prediction %d: %w", i, err)
+		}
+	}
+	return out, nil
+}
+
+// retry calls f until it succeeds, fails with an error that is not
+// retryable, or has been called MaxAttempts times.
+func (e *Endpoint[I, P]) retry(ctx context.Context, f func() error) error {
+	max := e.MaxAttempts
+	if max <= 0 {
+		max = DefaultMaxAttempts
+	}
+	bo := retryBackoff
+	for attempt := 1; ; attempt++ {
+		err := f()
+		if err == nil || attempt >= max || !retryable(err) {
+			return err
+		}
+		if err := gax.Sleep(ctx, bo.Pause()); err != nil {
+			return err
+		}
+	}
+}
+
+// retryable reports whether a request that failed with err may succeed if
+// sent again. Besides transient errors, a request may fail with
+// FailedPrecondition when it is routed to a deployed model that is being
+// undeployed while the traffic split of the endpoint changes.
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted, codes.FailedPrecondition:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package predict
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"cloud.google.com/go/aiplatform/apiv1/aiplatformpb"
+	gax "github.com/googleapis/gax-go/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func init() {
+	retryBackoff = gax.Backoff{Initial: 1, Max: 1}
+}
+
+type instance struct {
+	Text string `json:"text"`
+}
+
+type prediction struct {
+	Label string  `json:"label"`
+	Score float64 `json:"score"`
+}
+
+type params struct {
+	TopK int `json:"top_k"`
+}
+
+// fakeClient labels each instance with its text and a score of 0.5. It fails
+// the first len(errs) requests with those errors.
+type fakeClient struct {
+	errs     []error
+	calls    int
+	requests []*aiplatformpb.PredictRequest
+	explains []*aiplatformpb.ExplainRequest
+}
+
+func (c *fakeClient) fail() error {
+	c.calls++
+	if c.calls <= len(c.errs) {
+		return c.errs[c.calls-1]
+	}
+	return nil
+}
+
+func (c *fakeClient) predictions(instances []*structpb.Value) []*structpb.Value {
+	var out []*structpb.Value
+	for _, in := range instances {
+		text := in.GetStructValue().GetFields()["text"].GetStringValue()
+		out = append(out, structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+			"label": structpb.NewStringValue(text),
+			"score": structpb.NewNumberValue(0.5),
+		}}))
+	}
+	return out
+}
+
+func (c *fakeClient) Predict(_ context.Context, req *aiplatformpb.PredictRequest, _ ...gax.CallOption) (*aiplatformpb.PredictResponse, error) {
+	c.requests = append(c.requests, req)
+	if err := c.fail(); err != nil {
+		return nil, err
+	}
+	return &aiplatformpb.PredictResponse{
+		Predictions:     c.predictions(req.Instances),
+		DeployedModelId: "42",
+		Model:           "projects/p/locations/l/models/m",
+		ModelVersionId:  "3",
+	}, nil
+}
+
+func (c *fakeClient) Explain(_ context.Context, req *aiplatformpb.ExplainRequest, _ ...gax.CallOption) (*aiplatformpb.ExplainResponse, error) {
+	c.explains = append(c.explains, req)
+	if err := c.fail(); err != nil {
+		return nil, err
+	}
+	resp := &aiplatformpb.ExplainResponse{
+		Predictions:     c.predictions(req.Instances),
+		DeployedModelId: req.DeployedModelId,
+	}
+	for range req.Instances {
+		resp.Explanations = append(resp.Explanations, &aiplatformpb.Explanation{
+			Attributions: []*aiplatformpb.Attribution{{BaselineOutputValue: 0.1}},
+		})
+	}
+	return resp, nil
+}
+
+const endpoint = "projects/p/locations/l/endpoints/e"
+
+func TestPredict(t *testing.T) {
+	c := &fakeClient{}
+	e := NewEndpoint[instance, prediction](c, endpoint)
+	e.Parameters = params{TopK: 2}
+	res, err := e.Predict(context.Background(), []instance{{"a"}, {"b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Result[prediction]{
+		Predictions:     []prediction{{"a", 0.5}, {"b", 0.5}},
+		DeployedModelID: "42",
+		Model:           "projects/p/locations/l/models/m",
+		ModelVersionID:  "3",
+	}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("got %+v, want %+v", res, want)
+	}
+	req := c.requests[0]
+	if req.Endpoint != endpoint {
+		t.Errorf("got endpoint %q, want %q", req.Endpoint, endpoint)
+	}
+	if got := req.Parameters.GetStructValue().GetFields()["top_k"].GetNumberValue(); got != 2 {
+		t.Errorf("got top_k %v, want 2", got)
+	}
+}
+
+func TestPredictRetry(t *testing.T) {
+	for _, test := range []struct {
+		errs        []error
+		maxAttempts int
+		wantCalls   int
+		wantCode    codes.Code
+	}{
+		{nil, 0, 1, codes.OK},
+		{[]error{status.Error(codes.Unavailable, ""), status.Error(codes.FailedPrecondition, "")}, 0, 3, codes.OK},
+		{[]error{status.Error(codes.InvalidArgument, "")}, 0, 1, codes.InvalidArgument},
+		{[]error{
+			status.Error(codes.Unavailable, ""),
+			status.Error(codes.Unavailable, ""),
+			status.Error(codes.Unavailable, ""),
+		}, 2, 2, codes.Unavailable},
+	} {
+		c := &fakeClient{errs: test.errs}
+		e := NewEndpoint[instance, prediction](c, endpoint)
+		e.MaxAttempts = test.maxAttempts
+		_, err := e.Predict(context.Background(), []instance{{"a"}})
+		if got := status.Code(err); got != test.wantCode {
+			t.Errorf("%v: got code %v, want %v", test.errs, got, test.wantCode)
+		}
+		if c.calls != test.wantCalls {
+			t.Errorf("%v: got %d calls, want %d", test.errs, c.calls, test.wantCalls)
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	c := &fakeClient{errs: []error{status.Error(codes.Aborted, "")}}
+	e := NewEndpoint[instance, prediction](c, endpoint)
+	res, err := e.Explain(context.Background(), []instance{{"a"}}, "7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []prediction{{"a", 0.5}}; !reflect.DeepEqual(res.Predictions, want) {
+		t.Errorf("got predictions %+v, want %+v", res.Predictions, want)
+	}
+	if res.DeployedModelID != "7" {
+		t.Errorf("got deployed model %q, want 7", res.DeployedModelID)
+	}
+	if len(res.Explanations) != 1 {
+		t.Errorf("got %d explanations, want 1", len(res.Explanations))
+	}
+	if got := c.explains[1].DeployedModelId; got != "7" {
+		t.Errorf("got request deployed model %q, want 7", got)
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	_, err := decodeValues[prediction]([]*structpb.Value{structpb.NewStringValue("x")})
+	if err == nil {
+		t.Error("got nil, want error")
+	}
+}
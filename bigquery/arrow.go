@@ -25,6 +25,7 @@ import (
 	"cloud.google.com/go/civil"
 	"github.com/apache/arrow/go/v14/arrow"
 	"github.com/apache/arrow/go/v14/arrow/array"
+	"github.com/apache/arrow/go/v14/arrow/arrio"
 	"github.com/apache/arrow/go/v14/arrow/ipc"
 	"github.com/apache/arrow/go/v14/arrow/memory"
 	"google.golang.org/api/iterator"
@@ -95,6 +96,63 @@ func (r *arrowIteratorReader) Read(p []byte) (int, error) {
 	return n, err
 }
 
+// ArrowRecordReader reads the record batches of an ArrowIterator as decoded
+// Arrow records. It implements arrio.Reader.
+// Experimental: this interface is experimental and may be modified or removed in future versions,
+// regardless of any other documented package stability guarantees.
+type ArrowRecordReader struct {
+	it        ArrowIterator
+	schema    *arrow.Schema
+	allocator memory.Allocator
+	cur       *ipc.Reader
+}
+
+var _ arrio.Reader = &ArrowRecordReader{}
+
+// Schema returns the Arrow schema of the records.
+func (r *ArrowRecordReader) Schema() *arrow.Schema {
+	return r.schema
+}
+
+// Read returns the next record, or io.EOF when there are no more records.
+// The record is only valid until the next call to Read or Release; call its
+// Retain method to keep it longer.
+func (r *ArrowRecordReader) Read() (arrow.Record, error) {
+	for {
+		if r.cur != nil {
+			if r.cur.Next() {
+				return r.cur.Record(), nil
+			}
+			err := r.cur.Err()
+			r.cur.Release()
+			r.cur = nil
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+		}
+		batch, err := r.it.Next()
+		if err == iterator.Done {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		r.cur, err = ipc.NewReader(batch, ipc.WithSchema(r.schema), ipc.WithAllocator(r.allocator))
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Release releases the resources held by the reader, including the last
+// record returned by Read.
+func (r *ArrowRecordReader) Release() {
+	if r.cur != nil {
+		r.cur.Release()
+		r.cur = nil
+	}
+}
+
 type arrowDecoder struct {
 	allocator   memory.Allocator
 	tableSchema Schema
@@ -270,6 +328,23 @@ func convertArrowValue(col arrow.Array, i int, ft arrow.DataType, fs *FieldSchem
 		return values, nil
 	case *arrow.StructType:
 		arr := col.(*array.Struct)
+		if fs.Type == RangeFieldType {
+			// RANGE values are structs of their start and end.
+			if fs.RangeElementType == nil || arr.NumField() != 2 {
+				return nil, fmt.Errorf("invalid RANGE field %s", fs.Name)
+			}
+			efs := &FieldSchema{Name: fs.Name, Type: fs.RangeElementType.Type}
+			fields := ft.(*arrow.StructType).Fields()
+			start, err := convertArrowValue(arr.Field(0), i, fields[0].Type, efs)
+			if err != nil {
+				return nil, err
+			}
+			end, err := convertArrowValue(arr.Field(1), i, fields[1].Type, efs)
+			if err != nil {
+				return nil, err
+			}
+			return &RangeValue{Start: start, End: end}, nil
+		}
 		nestedValues := []Value{}
 		fields := ft.(*arrow.StructType).Fields()
 		for fIndex, f := range fields {
@@ -0,0 +1,260 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bigquery
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/bigquery/storage/apiv1/storagepb"
+	"github.com/apache/arrow/go/v14/arrow"
+	"github.com/apache/arrow/go/v14/arrow/array"
+	"github.com/apache/arrow/go/v14/arrow/ipc"
+	"github.com/apache/arrow/go/v14/arrow/memory"
+	gax "github.com/googleapis/gax-go/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	testArrowSchema = arrow.NewSchema([]arrow.Field{
+		{Name: "name", Type: arrow.BinaryTypes.String},
+		{Name: "n", Type: arrow.PrimitiveTypes.Int64},
+	}, nil)
+	testArrowTableSchema = Schema{
+		{Name: "name", Type: StringFieldType},
+		{Name: "n", Type: IntegerFieldType},
+	}
+)
+
+// ipcEOS is the end-of-stream marker written by ipc.Writer.Close.
+var ipcEOS = []byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}
+
+// serializeArrow returns the serialized schema of testArrowSchema, and the
+// serialized record batches holding the given rows, as the Storage Read API
+// sends them.
+func serializeArrow(t *testing.T, batches ...[]string) (schema []byte, data [][]byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := ipc.NewWriter(&buf, ipc.WithSchema(testArrowSchema))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	schema = bytes.TrimSuffix(buf.Bytes(), ipcEOS)
+
+	mem := memory.NewGoAllocator()
+	for i, names := range batches {
+		b := array.NewRecordBuilder(mem, testArrowSchema)
+		for j, name := range names {
+			b.Field(0).(*array.StringBuilder).Append(name)
+			b.Field(1).(*array.Int64Builder).Append(int64(10*i + j))
+		}
+		rec := b.NewRecord()
+		b.Release()
+
+		var buf bytes.Buffer
+		w := ipc.NewWriter(&buf, ipc.WithSchema(testArrowSchema), ipc.WithAllocator(mem))
+		if err := w.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		rec.Release()
+		d := bytes.TrimPrefix(buf.Bytes(), schema)
+		data = append(data, bytes.TrimSuffix(d, ipcEOS))
+	}
+	return schema, data
+}
+
+// fakeReadRowsStream is a ReadRows stream that sends responses, then fails
+// with err, or ends if err is nil.
+type fakeReadRowsStream struct {
+	storagepb.BigQueryRead_ReadRowsClient
+	responses []*storagepb.ReadRowsResponse
+	err       error
+}
+
+func (s *fakeReadRowsStream) Recv() (*storagepb.ReadRowsResponse, error) {
+	if len(s.responses) > 0 {
+		r := s.responses[0]
+		s.responses = s.responses[1:]
+		return r, nil
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	return nil, io.EOF
+}
+
+// newTestArrowRowIterator returns a RowIterator reading a session of a single
+// stream, whose ReadRows calls are handled by readRows.
+func newTestArrowRowIterator(ctx context.Context, t *testing.T, schema []byte, readRows func(context.Context, *storagepb.ReadRowsRequest, ...gax.CallOption) (storagepb.BigQueryRead_ReadRowsClient, error)) *RowIterator {
+	t.Helper()
+	it, err := newStorageRowIterator(&readSession{
+		ctx:          ctx,
+		settings:     defaultReadClientSettings(),
+		readRowsFunc: readRows,
+		bqSession: &storagepb.ReadSession{
+			Streams: []*storagepb.ReadStream{{Name: "stream"}},
+			Schema: &storagepb.ReadSession_ArrowSchema{
+				ArrowSchema: &storagepb.ArrowSchema{SerializedSchema: schema},
+			},
+		},
+	}, testArrowTableSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	it.arrowDecoder, err = newArrowDecoder(schema, testArrowTableSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return it
+}
+
+func arrowBatchResponse(data []byte, rows int64) *storagepb.ReadRowsResponse {
+	return &storagepb.ReadRowsResponse{
+		RowCount: rows,
+		Rows: &storagepb.ReadRowsResponse_ArrowRecordBatch{
+			ArrowRecordBatch: &storagepb.ArrowRecordBatch{SerializedRecordBatch: data},
+		},
+	}
+}
+
+func TestArrowRecordReader(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	schema, data := serializeArrow(t, []string{"a", "b"}, []string{"c"})
+	it := newTestArrowRowIterator(ctx, t, schema, func(context.Context, *storagepb.ReadRowsRequest, ...gax.CallOption) (storagepb.BigQueryRead_ReadRowsClient, error) {
+		return &fakeReadRowsStream{responses: []*storagepb.ReadRowsResponse{
+			arrowBatchResponse(data[0], 2),
+			arrowBatchResponse(data[1], 1),
+		}}, nil
+	})
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	it.arrowDecoder.allocator = mem
+
+	r, err := it.ArrowRecordReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Schema().Equal(testArrowSchema) {
+		t.Errorf("got schema %v, want %v", r.Schema(), testArrowSchema)
+	}
+	var names []string
+	var ns []int64
+	var records int
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		records++
+		if !rec.Schema().Equal(testArrowSchema) {
+			t.Errorf("got record schema %v, want %v", rec.Schema(), testArrowSchema)
+		}
+		for i := 0; i < int(rec.NumRows()); i++ {
+			names = append(names, rec.Column(0).(*array.String).Value(i))
+			ns = append(ns, rec.Column(1).(*array.Int64).Value(i))
+		}
+	}
+	if records != 2 {
+		t.Errorf("got %d records, want 2", records)
+	}
+	if got, want := strings.Join(names, ","), "a,b,c"; got != want {
+		t.Errorf("got names %s, want %s", got, want)
+	}
+	if got, want := ns, []int64{0, 1, 10}; len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("got values %v, want %v", got, want)
+	}
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("got %v after the last record, want io.EOF", err)
+	}
+	r.Release()
+	mem.AssertSize(t, 0)
+}
+
+func TestArrowRecordReaderErrors(t *testing.T) {
+	schema, data := serializeArrow(t, []string{"a"})
+	for _, tc := range []struct {
+		desc    string
+		stream  *fakeReadRowsStream
+		wantErr string
+	}{
+		{
+			desc:    "stream error",
+			stream:  &fakeReadRowsStream{err: status.Error(codes.InvalidArgument, "bad request")},
+			wantErr: "bad request",
+		},
+		{
+			desc: "stream error after a batch",
+			stream: &fakeReadRowsStream{
+				responses: []*storagepb.ReadRowsResponse{arrowBatchResponse(data[0], 1)},
+				err:       status.Error(codes.PermissionDenied, "denied"),
+			},
+			wantErr: "denied",
+		},
+		{
+			desc: "corrupt batch",
+			stream: &fakeReadRowsStream{
+				responses: []*storagepb.ReadRowsResponse{arrowBatchResponse([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9}, 1)},
+			},
+			wantErr: "arrow",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			calls := 0
+			it := newTestArrowRowIterator(ctx, t, schema, func(ctx context.Context, _ *storagepb.ReadRowsRequest, _ ...gax.CallOption) (storagepb.BigQueryRead_ReadRowsClient, error) {
+				calls++
+				if calls > 1 {
+					// Failed streams are reopened; keep them open
+					// until the test ends.
+					<-ctx.Done()
+					return nil, ctx.Err()
+				}
+				return tc.stream, nil
+			})
+			r, err := it.ArrowRecordReader()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Release()
+			for {
+				_, err = r.Read()
+				if err != nil {
+					break
+				}
+			}
+			if err == io.EOF || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("got error %v, want one containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestArrowRecordReaderNotAccelerated(t *testing.T) {
+	it := &RowIterator{}
+	if _, err := it.ArrowRecordReader(); err == nil {
+		t.Error("got no error for an iterator without the Storage Read API")
+	}
+}
@@ -63,6 +63,9 @@ type Client struct {
 
 	// governs use of preview query features.
 	enableQueryPreview bool
+
+	// caches Table.Metadata results, if enabled.
+	tableMetadataCache *metadataCache
 }
 
 // DetectProjectID is a sentinel value that instructs NewClient to detect the
@@ -117,6 +120,20 @@ func NewClient(ctx context.Context, projectID string, opts ...option.ClientOptio
 	return c, nil
 }
 
+// EnableStatelessQueries enables the short query optimized mode, in which
+// Query.Read runs eligible queries without creating a job when possible.
+// This saves a round trip for small interactive queries. For queries whose
+// results can't be returned at once, the service still creates a job, which
+// Query.Read reads the results from.
+// Rows read from queries that ran without a job are identified by
+// RowIterator.QueryID, and RowIterator.SourceJob returns nil for them.
+//
+// It has the same effect as setting the QUERY_PREVIEW_ENABLED environment
+// variable to "TRUE" before calling NewClient.
+func (c *Client) EnableStatelessQueries() {
+	c.enableQueryPreview = true
+}
+
 // EnableStorageReadClient sets up Storage API connection to be used when fetching
 // large datasets from tables, jobs or queries.
 // Currently out of pagination methods like PageInfo().Token and RowIterator.StartIndex
@@ -465,6 +465,14 @@ func (d *Dataset) Tables(ctx context.Context) *TableIterator {
 
 // A TableIterator is an iterator over Tables.
 type TableIterator struct {
+	// Filter restricts the tables returned by label, using the syntax of
+	// DatasetIterator.Filter: a space-separated list of "labels.key" and
+	// "labels.key:value" terms, all of which must match. As the API doesn't
+	// support filtering tables, the filter is applied to the listed tables
+	// by the client.
+	// Set before the first call to Next.
+	Filter string
+
 	ctx      context.Context
 	dataset  *Dataset
 	tables   []*Table
@@ -512,11 +520,40 @@ func (it *TableIterator) fetch(pageSize int, pageToken string) (string, error) {
 		return "", err
 	}
 	for _, t := range res.Tables {
+		if it.Filter != "" {
+			ok, err := matchLabelFilter(it.Filter, t.Labels)
+			if err != nil {
+				return "", err
+			}
+			if !ok {
+				continue
+			}
+		}
 		it.tables = append(it.tables, bqToTable(t.TableReference, it.dataset.c))
 	}
 	return res.NextPageToken, nil
 }
 
+// matchLabelFilter reports whether labels satisfy filter, a space-separated
+// list of "labels.key" terms, which require the label to be present, and
+// "labels.key:value" terms, which require the label to have the given value.
+func matchLabelFilter(filter string, labels map[string]string) (bool, error) {
+	for _, term := range strings.Fields(filter) {
+		if !strings.HasPrefix(term, "labels.") {
+			return false, fmt.Errorf("bigquery: invalid label filter term %q", term)
+		}
+		key, value, hasValue := strings.Cut(strings.TrimPrefix(term, "labels."), ":")
+		if key == "" {
+			return false, fmt.Errorf("bigquery: invalid label filter term %q", term)
+		}
+		v, ok := labels[key]
+		if !ok || (hasValue && v != value) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 func bqToTable(tr *bq.TableReference, c *Client) *Table {
 	if tr == nil {
 		return nil
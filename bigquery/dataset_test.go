@@ -26,6 +26,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	bq "google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/iterator"
 	itest "google.golang.org/api/iterator/testing"
 )
 
@@ -98,6 +99,58 @@ func TestTables(t *testing.T) {
 	}
 }
 
+func TestTablesFilter(t *testing.T) {
+	c := &Client{projectID: "p1"}
+	inTables := []*bq.TableListTables{
+		{TableReference: &bq.TableReference{ProjectId: "p1", DatasetId: "d1", TableId: "t1"}, Labels: map[string]string{"env": "prod", "team": "a"}},
+		{TableReference: &bq.TableReference{ProjectId: "p1", DatasetId: "d1", TableId: "t2"}, Labels: map[string]string{"env": "dev"}},
+		{TableReference: &bq.TableReference{ProjectId: "p1", DatasetId: "d1", TableId: "t3"}},
+		{TableReference: &bq.TableReference{ProjectId: "p1", DatasetId: "d1", TableId: "t4"}, Labels: map[string]string{"env": "prod"}},
+	}
+	lts := &listTablesStub{
+		expectedProject: "p1",
+		expectedDataset: "d1",
+		tables:          inTables,
+	}
+	old := listTables
+	listTables = lts.listTables // cannot use t.Parallel with this test
+	defer func() { listTables = old }()
+
+	for _, tc := range []struct {
+		filter string
+		want   []string
+	}{
+		{"", []string{"t1", "t2", "t3", "t4"}},
+		{"labels.env", []string{"t1", "t2", "t4"}},
+		{"labels.env:prod", []string{"t1", "t4"}},
+		{"labels.env:prod labels.team", []string{"t1"}},
+		{"labels.missing", nil},
+	} {
+		it := c.Dataset("d1").Tables(context.Background())
+		it.Filter = tc.filter
+		var got []string
+		for {
+			tbl, err := it.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				t.Fatalf("filter %q: %v", tc.filter, err)
+			}
+			got = append(got, tbl.TableID)
+		}
+		if diff := testutil.Diff(got, tc.want); diff != "" {
+			t.Errorf("filter %q: -got +want:\n%s", tc.filter, diff)
+		}
+	}
+
+	it := c.Dataset("d1").Tables(context.Background())
+	it.Filter = "env:prod"
+	if _, err := it.Next(); err == nil {
+		t.Error("invalid filter: got nil error")
+	}
+}
+
 // listModelsStub services list requests by returning data from an in-memory list of values.
 type listModelsStub struct {
 	expectedProject, expectedDataset string
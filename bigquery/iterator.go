@@ -135,11 +135,17 @@ type pageFetcher func(ctx context.Context, _ *rowSource, _ Schema, startIndex ui
 //	DATETIME    civil.DateTime
 //	NUMERIC     *big.Rat
 //	BIGNUMERIC  *big.Rat
+//	JSON        string, []byte, or any type the JSON text can be unmarshaled into
+//	INTERVAL    *IntervalValue, IntervalValue
+//	RANGE       *RangeValue, RangeValue
 //
 // The big.Rat type supports numbers of arbitrary size and precision.
 // See https://cloud.google.com/bigquery/docs/reference/standard-sql/data-types#numeric-type
 // for more on NUMERIC.
 //
+// A struct field whose type, or pointer to type, implements FieldValueLoader is
+// set by its LoadFieldValue method instead, whatever the column type.
+//
 // A repeated field corresponds to a slice or array of the element type. A STRUCT
 // type (RECORD or nested schema) corresponds to a nested struct or struct pointer.
 // All calls to Next on the same iterator must use the same struct type.
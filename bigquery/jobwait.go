@@ -0,0 +1,245 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bigquery
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cloud.google.com/go/internal"
+	"cloud.google.com/go/internal/trace"
+	gax "github.com/googleapis/gax-go/v2"
+)
+
+// defaultMaxJobAttempts is the default number of times RunAndWait runs a job
+// that fails with a retryable error.
+const defaultMaxJobAttempts = 3
+
+// JobProgress describes the progress of a job, as observed by one poll of
+// its status.
+type JobProgress struct {
+	// Job is the job being waited on. When a job is retried, it is a new job
+	// for each attempt.
+	Job *Job
+
+	// Attempt is the number of the attempt at running the job, starting at 1.
+	Attempt int
+
+	// Status is the status of the job.
+	Status *JobStatus
+
+	// PreviousState is the state of the job at the previous poll, or
+	// StateUnspecified at the first poll of the job. The state of the job
+	// changed since the previous poll if it differs from Status.State.
+	PreviousState State
+
+	// BytesProcessed is the number of bytes processed by the job so far: the
+	// total bytes processed by a query job, or the input bytes of a load job.
+	BytesProcessed int64
+}
+
+// A JobError is returned by RunAndWait when a job completes unsuccessfully.
+type JobError struct {
+	// Job is the job that failed. When a job was retried, it is the last
+	// attempt.
+	Job *Job
+
+	// Err is the error that caused the job to fail.
+	Err *Error
+
+	// Errors lists all the errors encountered while running the job. Not all
+	// of them are fatal.
+	Errors []*Error
+}
+
+func (e *JobError) Error() string {
+	if e.Err == nil {
+		return "bigquery: job " + e.Job.ID() + " failed"
+	}
+	return "bigquery: job " + e.Job.ID() + " failed: " + e.Err.Error()
+}
+
+// Unwrap returns the error that caused the job to fail.
+func (e *JobError) Unwrap() error {
+	if e.Err == nil {
+		return nil
+	}
+	return e.Err
+}
+
+// A JobRunOption configures RunAndWait.
+type JobRunOption func(*jobRunSettings)
+
+type jobRunSettings struct {
+	progress    func(JobProgress)
+	maxAttempts int
+}
+
+// WithJobProgress sets a function that RunAndWait calls each time it polls the
+// status of the job.
+func WithJobProgress(f func(JobProgress)) JobRunOption {
+	return func(s *jobRunSettings) {
+		s.progress = f
+	}
+}
+
+// WithMaxJobAttempts sets the maximum number of times RunAndWait runs a job
+// that fails with a rateLimitExceeded or backendError error. It defaults to 3;
+// a value of 1 disables retries.
+func WithMaxJobAttempts(n int) JobRunOption {
+	return func(s *jobRunSettings) {
+		s.maxAttempts = n
+	}
+}
+
+// WaitWithProgress is like Wait, but calls progress, if not nil, each time it
+// polls the status of the job.
+func (j *Job) WaitWithProgress(ctx context.Context, progress func(JobProgress)) (js *JobStatus, err error) {
+	ctx = trace.StartSpan(ctx, "cloud.google.com/go/bigquery.Job.WaitWithProgress")
+	defer func() { trace.EndSpan(ctx, err) }()
+
+	return j.waitWithProgress(ctx, 1, progress)
+}
+
+func (j *Job) waitWithProgress(ctx context.Context, attempt int, progress func(JobProgress)) (*JobStatus, error) {
+	var js *JobStatus
+	prev := StateUnspecified
+	err := internal.Retry(ctx, gax.Backoff{}, func() (stop bool, err error) {
+		js, err = j.Status(ctx)
+		if err != nil {
+			return true, err
+		}
+		if progress != nil {
+			progress(JobProgress{
+				Job:            j,
+				Attempt:        attempt,
+				Status:         js,
+				PreviousState:  prev,
+				BytesProcessed: bytesProcessed(js.Statistics),
+			})
+		}
+		prev = js.State
+		return js.Done(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return js, nil
+}
+
+func bytesProcessed(s *JobStatistics) int64 {
+	if s == nil {
+		return 0
+	}
+	if ls, ok := s.Details.(*LoadStatistics); ok {
+		return ls.InputFileBytes
+	}
+	return s.TotalBytesProcessed
+}
+
+// waitForJob waits for j to complete. It exists to aid testing.
+var waitForJob = func(ctx context.Context, j *Job, attempt int, progress func(JobProgress)) (*JobStatus, error) {
+	return j.waitWithProgress(ctx, attempt, progress)
+}
+
+// jobRunBackoff is the backoff between attempts at running a job. These
+// parameters match the suggestions in https://cloud.google.com/bigquery/sla.
+var jobRunBackoff = gax.Backoff{
+	Initial:    1 * time.Second,
+	Max:        32 * time.Second,
+	Multiplier: 2,
+}
+
+// runAndWait runs a job with run and waits for it to complete. If the job
+// fails with a retryable error and canRetry is true, it is run again after a
+// randomized, exponentially increasing delay.
+func runAndWait(ctx context.Context, run func(context.Context) (*Job, error), canRetry bool, opts []JobRunOption) (*JobStatus, error) {
+	s := &jobRunSettings{maxAttempts: defaultMaxJobAttempts}
+	for _, o := range opts {
+		o(s)
+	}
+	if s.maxAttempts < 1 {
+		return nil, errors.New("bigquery: maximum number of job attempts must be positive")
+	}
+	backoff := jobRunBackoff
+	for attempt := 1; ; attempt++ {
+		job, err := run(ctx)
+		if err != nil {
+			return nil, err
+		}
+		js, err := waitForJob(ctx, job, attempt, s.progress)
+		if err != nil {
+			return nil, err
+		}
+		if js.Err() == nil {
+			return js, nil
+		}
+		jobErr := &JobError{Job: job, Errors: js.Errors}
+		errors.As(js.Err(), &jobErr.Err)
+		if !canRetry || attempt >= s.maxAttempts || !retryableJobError(jobErr.Err) {
+			return js, jobErr
+		}
+		if err := gax.Sleep(ctx, backoff.Pause()); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryableJobError reports whether a job that failed with err may succeed if
+// run again.
+func retryableJobError(err *Error) bool {
+	if err == nil {
+		return false
+	}
+	for _, r := range defaultRetryReasons {
+		if err.Reason == r {
+			return true
+		}
+	}
+	return false
+}
+
+// retryableJobID reports whether a job can be run again with the same
+// configuration, which requires a new job ID for each run.
+func retryableJobID(c JobIDConfig) bool {
+	return c.JobID == "" || c.AddJobIDSuffix
+}
+
+// RunAndWait runs the copy job, waits for it to complete, and returns its
+// final status. If the job fails with a rateLimitExceeded or backendError
+// error, it is run again, unless its job ID was set without AddJobIDSuffix.
+// If the job fails, the error is a *JobError.
+func (c *Copier) RunAndWait(ctx context.Context, opts ...JobRunOption) (*JobStatus, error) {
+	return runAndWait(ctx, c.Run, retryableJobID(c.JobIDConfig), opts)
+}
+
+// RunAndWait runs the extract job, waits for it to complete, and returns its
+// final status. If the job fails with a rateLimitExceeded or backendError
+// error, it is run again, unless its job ID was set without AddJobIDSuffix.
+// If the job fails, the error is a *JobError.
+func (e *Extractor) RunAndWait(ctx context.Context, opts ...JobRunOption) (*JobStatus, error) {
+	return runAndWait(ctx, e.Run, retryableJobID(e.JobIDConfig), opts)
+}
+
+// RunAndWait runs the load job, waits for it to complete, and returns its
+// final status. If the job fails with a rateLimitExceeded or backendError
+// error, it is run again, unless its job ID was set without AddJobIDSuffix or
+// it loads data from a ReaderSource, which can only be read once.
+// If the job fails, the error is a *JobError.
+func (l *Loader) RunAndWait(ctx context.Context, opts ...JobRunOption) (*JobStatus, error) {
+	_, media := l.newJob()
+	return runAndWait(ctx, l.Run, media == nil && retryableJobID(l.JobIDConfig), opts)
+}
@@ -0,0 +1,143 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bigquery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	gax "github.com/googleapis/gax-go/v2"
+)
+
+func failedStatus(reason string) *JobStatus {
+	e := &Error{Reason: reason, Message: "failed"}
+	return &JobStatus{State: Done, err: e, Errors: []*Error{e}}
+}
+
+func TestRunAndWait(t *testing.T) {
+	oldWait, oldBackoff := waitForJob, jobRunBackoff
+	defer func() { waitForJob, jobRunBackoff = oldWait, oldBackoff }()
+	jobRunBackoff = gax.Backoff{Initial: time.Millisecond, Max: time.Millisecond}
+
+	for _, tc := range []struct {
+		desc         string
+		statuses     []*JobStatus
+		canRetry     bool
+		opts         []JobRunOption
+		wantAttempts int
+		wantReason   string // empty if the job should succeed
+	}{
+		{
+			desc:         "success",
+			statuses:     []*JobStatus{{State: Done}},
+			canRetry:     true,
+			wantAttempts: 1,
+		},
+		{
+			desc:         "retry rate limit",
+			statuses:     []*JobStatus{failedStatus("rateLimitExceeded"), failedStatus("backendError"), {State: Done}},
+			canRetry:     true,
+			wantAttempts: 3,
+		},
+		{
+			desc:         "too many attempts",
+			statuses:     []*JobStatus{failedStatus("rateLimitExceeded"), failedStatus("rateLimitExceeded"), {State: Done}},
+			canRetry:     true,
+			opts:         []JobRunOption{WithMaxJobAttempts(2)},
+			wantAttempts: 2,
+			wantReason:   "rateLimitExceeded",
+		},
+		{
+			desc:         "not retryable",
+			statuses:     []*JobStatus{failedStatus("invalid"), {State: Done}},
+			canRetry:     true,
+			wantAttempts: 1,
+			wantReason:   "invalid",
+		},
+		{
+			desc:         "retry not allowed",
+			statuses:     []*JobStatus{failedStatus("rateLimitExceeded"), {State: Done}},
+			canRetry:     false,
+			wantAttempts: 1,
+			wantReason:   "rateLimitExceeded",
+		},
+	} {
+		var runs int
+		run := func(context.Context) (*Job, error) {
+			runs++
+			return &Job{jobID: fmt.Sprintf("job%d", runs)}, nil
+		}
+		var progress []JobProgress
+		waitForJob = func(_ context.Context, j *Job, attempt int, f func(JobProgress)) (*JobStatus, error) {
+			js := tc.statuses[attempt-1]
+			f(JobProgress{Job: j, Attempt: attempt, Status: js})
+			return js, nil
+		}
+		opts := append([]JobRunOption{WithJobProgress(func(p JobProgress) { progress = append(progress, p) })}, tc.opts...)
+		_, err := runAndWait(context.Background(), run, tc.canRetry, opts)
+		if runs != tc.wantAttempts {
+			t.Errorf("%s: got %d attempts, want %d", tc.desc, runs, tc.wantAttempts)
+		}
+		if len(progress) != runs {
+			t.Errorf("%s: got %d progress calls, want %d", tc.desc, len(progress), runs)
+		}
+		if tc.wantReason == "" {
+			if err != nil {
+				t.Errorf("%s: got error %v, want nil", tc.desc, err)
+			}
+			continue
+		}
+		var jobErr *JobError
+		if !errors.As(err, &jobErr) {
+			t.Errorf("%s: got error %v, want a *JobError", tc.desc, err)
+			continue
+		}
+		if got, want := jobErr.Job.ID(), fmt.Sprintf("job%d", runs); got != want {
+			t.Errorf("%s: got job %q, want %q", tc.desc, got, want)
+		}
+		var e *Error
+		if !errors.As(err, &e) || e.Reason != tc.wantReason {
+			t.Errorf("%s: got error %v, want reason %q", tc.desc, err, tc.wantReason)
+		}
+	}
+}
+
+func TestRunAndWaitInvalidAttempts(t *testing.T) {
+	run := func(context.Context) (*Job, error) {
+		t.Fatal("job run with invalid options")
+		return nil, nil
+	}
+	if _, err := runAndWait(context.Background(), run, true, []JobRunOption{WithMaxJobAttempts(0)}); err == nil {
+		t.Error("got nil error, want error")
+	}
+}
+
+func TestBytesProcessed(t *testing.T) {
+	for _, tc := range []struct {
+		stats *JobStatistics
+		want  int64
+	}{
+		{nil, 0},
+		{&JobStatistics{TotalBytesProcessed: 10}, 10},
+		{&JobStatistics{TotalBytesProcessed: 10, Details: &LoadStatistics{InputFileBytes: 20}}, 20},
+	} {
+		if got := bytesProcessed(tc.stats); got != tc.want {
+			t.Errorf("bytesProcessed(%+v): got %d, want %d", tc.stats, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bigquery
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	bq "google.golang.org/api/bigquery/v2"
+)
+
+// EnableTableMetadataCache makes Table.Metadata calls without options return
+// metadata retrieved by an earlier call for the same table, as long as it was
+// retrieved less than ttl ago. This reduces the number of API calls, and the
+// quota used, by applications that frequently look up the metadata of the same
+// tables, at the cost of possibly returning stale metadata.
+//
+// Entries are invalidated when the table is updated or deleted through this
+// client, but not when it is modified by other means. A ttl of zero or less
+// disables the cache.
+//
+// EnableTableMetadataCache must be called before the client is used.
+func (c *Client) EnableTableMetadataCache(ttl time.Duration) {
+	if ttl <= 0 {
+		c.tableMetadataCache = nil
+		return
+	}
+	c.tableMetadataCache = newMetadataCache(ttl)
+}
+
+// metadataCache holds table metadata for a limited time. It holds the JSON
+// encoding of the tables returned by the API, which it decodes and converts on
+// each get, so that the metadata it returns shares no slices, maps or
+// pointers with the cache or with metadata returned by other calls.
+type metadataCache struct {
+	ttl time.Duration
+	now func() time.Time // can be replaced for testing
+
+	mu      sync.Mutex
+	entries map[string]metadataCacheEntry
+}
+
+type metadataCacheEntry struct {
+	table   []byte // JSON encoding of a *bq.Table
+	expires time.Time
+}
+
+func newMetadataCache(ttl time.Duration) *metadataCache {
+	return &metadataCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[string]metadataCacheEntry{},
+	}
+}
+
+// get returns the metadata of the table cached under key, if it hasn't
+// expired.
+func (mc *metadataCache) get(key string, c *Client) (*TableMetadata, bool) {
+	mc.mu.Lock()
+	e, ok := mc.entries[key]
+	if ok && !mc.now().Before(e.expires) {
+		delete(mc.entries, key)
+		ok = false
+	}
+	mc.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	t := &bq.Table{}
+	if err := json.Unmarshal(e.table, t); err != nil {
+		return nil, false
+	}
+	md, err := bqToTableMetadata(t, c)
+	if err != nil {
+		return nil, false
+	}
+	return md, true
+}
+
+// put caches t under key.
+func (mc *metadataCache) put(key string, t *bq.Table) {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return
+	}
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	now := mc.now()
+	// Drop expired entries, so that the cache doesn't grow with tables that
+	// are no longer looked up.
+	for k, e := range mc.entries {
+		if !now.Before(e.expires) {
+			delete(mc.entries, k)
+		}
+	}
+	mc.entries[key] = metadataCacheEntry{table: b, expires: now.Add(mc.ttl)}
+}
+
+// invalidate removes the metadata cached under key.
+func (mc *metadataCache) invalidate(key string) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	delete(mc.entries, key)
+}
@@ -0,0 +1,89 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bigquery
+
+import (
+	"testing"
+	"time"
+
+	bq "google.golang.org/api/bigquery/v2"
+)
+
+func TestMetadataCache(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mc := newMetadataCache(time.Minute)
+	mc.now = func() time.Time { return now }
+	c := &Client{}
+
+	if _, ok := mc.get("p:d.t", c); ok {
+		t.Fatal("got entry from empty cache")
+	}
+	table := &bq.Table{
+		FriendlyName: "a",
+		Labels:       map[string]string{"k": "v"},
+		Schema:       &bq.TableSchema{Fields: []*bq.TableFieldSchema{{Name: "f", Type: "STRING"}}},
+		Clustering:   &bq.Clustering{Fields: []string{"f"}},
+	}
+	mc.put("p:d.t", table)
+	md, ok := mc.get("p:d.t", c)
+	if !ok || md.Name != "a" {
+		t.Fatalf("got (%v, %t), want cached metadata", md, ok)
+	}
+	// Callers get metadata they can modify, including its slices and maps.
+	md.Name = "b"
+	md.Labels["k"] = "changed"
+	md.Schema[0].Name = "changed"
+	md.Clustering.Fields[0] = "changed"
+	md, _ = mc.get("p:d.t", c)
+	if md.Name != "a" || md.Labels["k"] != "v" || md.Schema[0].Name != "f" || md.Clustering.Fields[0] != "f" {
+		t.Errorf("cached metadata was modified: got %+v", md)
+	}
+	// Nor does the cache share the table it was given.
+	table.Labels["k"] = "changed"
+	if md, _ := mc.get("p:d.t", c); md.Labels["k"] != "v" {
+		t.Errorf("cached labels were modified: got %v", md.Labels)
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := mc.get("p:d.t", c); ok {
+		t.Error("got expired entry")
+	}
+
+	mc.put("p:d.t", &bq.Table{FriendlyName: "a"})
+	mc.invalidate("p:d.t")
+	if _, ok := mc.get("p:d.t", c); ok {
+		t.Error("got invalidated entry")
+	}
+
+	// Expired entries are dropped when new entries are added.
+	mc.put("p:d.t1", &bq.Table{})
+	now = now.Add(2 * time.Minute)
+	mc.put("p:d.t2", &bq.Table{})
+	if _, ok := mc.entries["p:d.t1"]; ok {
+		t.Error("expired entry was not dropped")
+	}
+}
+
+func TestEnableTableMetadataCache(t *testing.T) {
+	c := &Client{}
+	c.EnableTableMetadataCache(time.Minute)
+	if c.tableMetadataCache == nil || c.tableMetadataCache.ttl != time.Minute {
+		t.Fatalf("got cache %+v, want one with a TTL of a minute", c.tableMetadataCache)
+	}
+	c.EnableTableMetadataCache(0)
+	if c.tableMetadataCache != nil {
+		t.Error("cache is still enabled with a zero TTL")
+	}
+}
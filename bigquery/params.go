@@ -21,6 +21,7 @@ import (
 	"math/big"
 	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -92,6 +93,7 @@ var (
 	typeOfGoTime              = reflect.TypeOf(time.Time{})
 	typeOfRat                 = reflect.TypeOf(&big.Rat{})
 	typeOfIntervalValue       = reflect.TypeOf(&IntervalValue{})
+	typeOfRangeValue          = reflect.TypeOf(&RangeValue{})
 	typeOfQueryParameterValue = reflect.TypeOf(&QueryParameterValue{})
 )
 
@@ -116,7 +118,11 @@ type QueryParameter struct {
 	// *big.Rat: NUMERIC
 	// *IntervalValue: INTERVAL
 	// Arrays and slices of the above.
-	// Structs of the above. Only the exported fields are used.
+	// Structs of the above. Only the exported fields are used, and fields
+	// are named after their "bigquery" tag, if any.
+	// Maps with string keys of the above: STRUCT, with one field per key in
+	// sorted order. The field types are inferred from the values, so maps
+	// must not be empty or hold nil values.
 	//
 	// For scalar values, you can supply the Null types within this library
 	// to send the appropriate NULL values (e.g. NullInt64, NullString, etc).
@@ -342,6 +348,9 @@ func paramType(t reflect.Type, v reflect.Value) (*bq.QueryParameterType, error)
 	case typeOfNullJSON:
 		return jsonParamType, nil
 	case typeOfQueryParameterValue:
+		if !v.IsValid() {
+			return nil, errors.New("bigquery: cannot infer the type of a QueryParameterValue without a value")
+		}
 		return v.Interface().(*QueryParameterValue).toBQParamType(), nil
 	}
 	switch t.Kind() {
@@ -364,17 +373,66 @@ func paramType(t reflect.Type, v reflect.Value) (*bq.QueryParameterType, error)
 		fallthrough
 
 	case reflect.Array:
-		et, err := paramType(t.Elem(), v)
+		// The element type of some arrays, such as arrays of maps, can only be
+		// inferred from an element.
+		et := t.Elem()
+		var ev reflect.Value
+		if v.IsValid() && (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Len() > 0 {
+			ev = v.Index(0)
+			if ev.Kind() == reflect.Interface {
+				ev = ev.Elem()
+				if !ev.IsValid() {
+					return nil, errors.New("bigquery: nil array element")
+				}
+				et = ev.Type()
+			}
+		}
+		pt, err := paramType(et, ev)
 		if err != nil {
 			return nil, err
 		}
-		return &bq.QueryParameterType{Type: "ARRAY", ArrayType: et}, nil
+		return &bq.QueryParameterType{Type: "ARRAY", ArrayType: pt}, nil
+
+	case reflect.Interface:
+		if v.IsValid() && v.Kind() == reflect.Interface && !v.IsNil() {
+			return paramType(v.Elem().Type(), v.Elem())
+		}
+
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			break
+		}
+		if !v.IsValid() || v.Kind() != reflect.Map || v.Len() == 0 {
+			return nil, fmt.Errorf("bigquery: cannot infer the type of a parameter of Go type %s without a non-empty value", t)
+		}
+		var fts []*bq.QueryParameterTypeStructTypes
+		for _, k := range sortedMapKeys(v) {
+			fv := v.MapIndex(k)
+			if fv.Kind() == reflect.Interface {
+				fv = fv.Elem()
+			}
+			if !fv.IsValid() {
+				return nil, fmt.Errorf("bigquery: nil value for key %q of map parameter", k.String())
+			}
+			pt, err := paramType(fv.Type(), fv)
+			if err != nil {
+				return nil, err
+			}
+			fts = append(fts, &bq.QueryParameterTypeStructTypes{
+				Name: k.String(),
+				Type: pt,
+			})
+		}
+		return &bq.QueryParameterType{Type: "STRUCT", StructTypes: fts}, nil
 
 	case reflect.Ptr:
 		if t.Elem().Kind() != reflect.Struct {
 			break
 		}
 		t = t.Elem()
+		if v.IsValid() && v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
 		fallthrough
 
 	case reflect.Struct:
@@ -390,7 +448,11 @@ func paramType(t reflect.Type, v reflect.Value) (*bq.QueryParameterType, error)
 					return nil, fmt.Errorf("bigquery: Go type %s cannot be represented as a parameter due to an attribute cycle/recursion detected", t)
 				}
 			}
-			pt, err := paramType(f.Type, v)
+			var fv reflect.Value
+			if v.IsValid() && v.Kind() == reflect.Struct && v.Type() == t {
+				fv = v.FieldByIndex(f.Index)
+			}
+			pt, err := paramType(f.Type, fv)
 			if err != nil {
 				return nil, err
 			}
@@ -511,6 +573,23 @@ func paramValue(v reflect.Value) (*bq.QueryParameterValue, error) {
 		}
 		return &bq.QueryParameterValue{ArrayValues: vals}, nil
 
+	case reflect.Interface:
+		return paramValue(v.Elem())
+
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return res, fmt.Errorf("bigquery: Go type %s cannot be represented as a parameter value", t)
+		}
+		res.StructValues = map[string]bq.QueryParameterValue{}
+		for _, k := range sortedMapKeys(v) {
+			fp, err := paramValue(v.MapIndex(k))
+			if err != nil {
+				return nil, err
+			}
+			res.StructValues[k.String()] = *fp
+		}
+		return res, nil
+
 	case reflect.Ptr:
 		if t.Elem().Kind() != reflect.Struct {
 			return res, fmt.Errorf("bigquery: Go type %s cannot be represented as a parameter value", t)
@@ -549,6 +628,13 @@ func paramValue(v reflect.Value) (*bq.QueryParameterValue, error) {
 	return res, nil
 }
 
+// sortedMapKeys returns the keys of v, a map with string keys, in sorted order.
+func sortedMapKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	return keys
+}
+
 func bqToQueryParameter(q *bq.QueryParameter) (QueryParameter, error) {
 	p := QueryParameter{Name: q.Name}
 	val, err := convertParamValue(q.ParameterValue, q.ParameterType)
@@ -282,6 +282,79 @@ func TestParamType(t *testing.T) {
 		}
 	}
 }
+func TestParamMap(t *testing.T) {
+	type withMap struct {
+		Attrs map[string]interface{} `bigquery:"attrs"`
+	}
+	attrsType := &bq.QueryParameterType{
+		Type: "STRUCT",
+		StructTypes: []*bq.QueryParameterTypeStructTypes{
+			{Name: "a", Type: int64ParamType},
+			{Name: "b", Type: stringParamType},
+		},
+	}
+	attrsValue := bq.QueryParameterValue{
+		StructValues: map[string]bq.QueryParameterValue{
+			"a": sval("1"),
+			"b": sval("x"),
+		},
+	}
+	attrs := map[string]interface{}{"b": "x", "a": 1}
+	for _, test := range []struct {
+		val       interface{}
+		wantType  *bq.QueryParameterType
+		wantValue *bq.QueryParameterValue
+	}{
+		{attrs, attrsType, &attrsValue},
+		{
+			[]map[string]interface{}{attrs, attrs},
+			&bq.QueryParameterType{Type: "ARRAY", ArrayType: attrsType},
+			&bq.QueryParameterValue{ArrayValues: []*bq.QueryParameterValue{&attrsValue, &attrsValue}},
+		},
+		{
+			[]interface{}{"x", "y"},
+			&bq.QueryParameterType{Type: "ARRAY", ArrayType: stringParamType},
+			&bq.QueryParameterValue{ArrayValues: []*bq.QueryParameterValue{{Value: "x"}, {Value: "y"}}},
+		},
+		{
+			&withMap{Attrs: attrs},
+			&bq.QueryParameterType{
+				Type:        "STRUCT",
+				StructTypes: []*bq.QueryParameterTypeStructTypes{{Name: "attrs", Type: attrsType}},
+			},
+			&bq.QueryParameterValue{
+				StructValues: map[string]bq.QueryParameterValue{"attrs": attrsValue},
+			},
+		},
+	} {
+		gotType, err := paramType(reflect.TypeOf(test.val), reflect.ValueOf(test.val))
+		if err != nil {
+			t.Fatalf("%v (%T): %v", test.val, test.val, err)
+		}
+		if !testutil.Equal(gotType, test.wantType) {
+			t.Errorf("%v (%T): got type %v, want %v", test.val, test.val, gotType, test.wantType)
+		}
+		gotValue, err := paramValue(reflect.ValueOf(test.val))
+		if err != nil {
+			t.Fatalf("%v (%T): %v", test.val, test.val, err)
+		}
+		if !testutil.Equal(gotValue, test.wantValue) {
+			t.Errorf("%v (%T): got value %+v, want %+v", test.val, test.val, gotValue, test.wantValue)
+		}
+	}
+
+	for _, val := range []interface{}{
+		map[string]interface{}{"a": nil},
+		[]map[string]interface{}{},
+		map[int]string{1: "a"},
+		withMap{},
+	} {
+		if _, err := paramType(reflect.TypeOf(val), reflect.ValueOf(val)); err == nil {
+			t.Errorf("%v (%T): got nil, want error", val, val)
+		}
+	}
+}
+
 func TestParamTypeErrors(t *testing.T) {
 	for _, val := range []interface{}{
 		nil, uint(0), new([]int), make(chan int), map[string]interface{}{},
@@ -403,6 +403,14 @@ func (q *Query) Read(ctx context.Context) (it *RowIterator, err error) {
 		}
 	}
 
+	if minimalJob == nil && (!resp.JobComplete || resp.PageToken != "") {
+		// The service creates a job for a query whose results can't be
+		// returned at once, so this shouldn't happen. The rest of the results
+		// can't be fetched without a job, and running the query again could
+		// repeat its side effects, so report an error.
+		return nil, fmt.Errorf("bigquery: query %s returned incomplete results without a job reference", resp.QueryId)
+	}
+
 	if resp.JobComplete {
 		// If more pages are available, discard and use the Storage API instead
 		if resp.PageToken != "" && q.client.isStorageReadAvailable() {
@@ -15,6 +15,11 @@
 package bigquery
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -22,6 +27,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	bq "google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/option"
 )
 
 func defaultQueryJob() *bq.Job {
@@ -522,6 +528,60 @@ func TestProbeFastPath(t *testing.T) {
 	}
 }
 
+func TestProbeFastPathStateless(t *testing.T) {
+	c := &Client{
+		projectID: "client-project-id",
+	}
+	q := c.Query("foo")
+	req, err := q.probeFastPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.JobCreationMode != "" {
+		t.Errorf("got JobCreationMode %q, want empty", req.JobCreationMode)
+	}
+	c.EnableStatelessQueries()
+	req, err = q.probeFastPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := req.JobCreationMode, "JOB_CREATION_OPTIONAL"; got != want {
+		t.Errorf("got JobCreationMode %q, want %q", got, want)
+	}
+}
+
+func TestReadStatelessQueryWithoutJob(t *testing.T) {
+	var inserts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/queries"):
+			// The query didn't complete, yet the service didn't create a job.
+			json.NewEncoder(w).Encode(&bq.QueryResponse{QueryId: "qid", JobComplete: false})
+		case strings.HasSuffix(r.URL.Path, "/jobs"):
+			inserts++
+			http.Error(w, "unexpected job insertion", http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c, err := NewClient(ctx, "client-project-id", option.WithEndpoint(srv.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.EnableStatelessQueries()
+
+	if _, err := c.Query("SELECT 1").Read(ctx); err == nil || !strings.Contains(err.Error(), "qid") {
+		t.Errorf("got error %v, want one about query qid", err)
+	}
+	if inserts != 0 {
+		t.Errorf("the query was run again as a job %d times", inserts)
+	}
+}
+
 func TestConfiguringQuery(t *testing.T) {
 	c := &Client{
 		projectID: "project-id",
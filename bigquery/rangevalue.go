@@ -0,0 +1,91 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bigquery
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+)
+
+// RangeValue is a go type for representing BigQuery RANGE values.
+// A range is the half-open interval [Start, End) of values of its element
+// type. Start and End hold a civil.Date, civil.DateTime or time.Time, matching
+// the element type of the range, or nil when that end of the range is
+// unbounded.
+//
+// More information about BigQuery RANGE types can be found at:
+// https://cloud.google.com/bigquery/docs/reference/standard-sql/data-types#range_type
+//
+// RangeValue is EXPERIMENTAL and subject to change or removal without notice.
+type RangeValue struct {
+	Start Value
+	End   Value
+}
+
+const rangeUnbounded = "UNBOUNDED"
+
+// parseRangeValue parses a RANGE value in the "[start, end)" form returned by
+// the BigQuery API.
+func parseRangeValue(s string, elemType FieldType) (*RangeValue, error) {
+	if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, ")") {
+		return nil, fmt.Errorf("bigquery: invalid RANGE value %q", s)
+	}
+	parts := strings.Split(s[1:len(s)-1], ",")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("bigquery: invalid RANGE value %q", s)
+	}
+	rv := &RangeValue{}
+	for i, dst := range []*Value{&rv.Start, &rv.End} {
+		v, err := parseRangeElement(strings.TrimSpace(parts[i]), elemType)
+		if err != nil {
+			return nil, fmt.Errorf("bigquery: invalid RANGE value %q: %w", s, err)
+		}
+		*dst = v
+	}
+	return rv, nil
+}
+
+func parseRangeElement(s string, elemType FieldType) (Value, error) {
+	if strings.EqualFold(s, rangeUnbounded) || strings.EqualFold(s, "NULL") {
+		return nil, nil
+	}
+	switch elemType {
+	case DateFieldType:
+		return civil.ParseDate(s)
+	case DateTimeFieldType:
+		if strings.Contains(s, " ") {
+			return parseCivilDateTime(s)
+		}
+		return civil.ParseDateTime(s)
+	case TimestampFieldType:
+		// Timestamps are sent as microseconds since the epoch when
+		// UseInt64Timestamp is set, and in SQL format otherwise.
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return time.UnixMicro(i).UTC(), nil
+		}
+		for _, layout := range []string{timestampFormat, "2006-01-02 15:04:05.999999 MST", time.RFC3339Nano} {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t.UTC(), nil
+			}
+		}
+		return nil, fmt.Errorf("bad TIMESTAMP value %q", s)
+	default:
+		return nil, fmt.Errorf("unsupported RANGE element type %q", elemType)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bigquery
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/internal/testutil"
+)
+
+func TestParseRangeValue(t *testing.T) {
+	date := civil.Date{Year: 2024, Month: 3, Day: 1}
+	dateTime := civil.DateTime{Date: date, Time: civil.Time{Hour: 12, Minute: 30}}
+	ts := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		in       string
+		elemType FieldType
+		want     *RangeValue
+		wantErr  bool
+	}{
+		{in: "[2024-03-01, 2024-03-02)", elemType: DateFieldType, want: &RangeValue{Start: date, End: civil.Date{Year: 2024, Month: 3, Day: 2}}},
+		{in: "[UNBOUNDED, 2024-03-01)", elemType: DateFieldType, want: &RangeValue{End: date}},
+		{in: "[UNBOUNDED, UNBOUNDED)", elemType: DateFieldType, want: &RangeValue{}},
+		{in: "[2024-03-01 12:30:00, UNBOUNDED)", elemType: DateTimeFieldType, want: &RangeValue{Start: dateTime}},
+		{in: "[2024-03-01T12:30:00, UNBOUNDED)", elemType: DateTimeFieldType, want: &RangeValue{Start: dateTime}},
+		{in: "[1709296200000000, UNBOUNDED)", elemType: TimestampFieldType, want: &RangeValue{Start: ts}},
+		{in: "[2024-03-01 12:30:00+00:00, UNBOUNDED)", elemType: TimestampFieldType, want: &RangeValue{Start: ts}},
+		{in: "2024-03-01, 2024-03-02", elemType: DateFieldType, wantErr: true},
+		{in: "[2024-03-01)", elemType: DateFieldType, wantErr: true},
+		{in: "[bad, UNBOUNDED)", elemType: DateFieldType, wantErr: true},
+		{in: "[1, 2)", elemType: IntegerFieldType, wantErr: true},
+	} {
+		got, err := parseRangeValue(tc.in, tc.elemType)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("parseRangeValue(%q, %s): got nil error", tc.in, tc.elemType)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseRangeValue(%q, %s): %v", tc.in, tc.elemType, err)
+			continue
+		}
+		if !testutil.Equal(got, tc.want) {
+			t.Errorf("parseRangeValue(%q, %s): got %v, want %v", tc.in, tc.elemType, got, tc.want)
+		}
+	}
+}
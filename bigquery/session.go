@@ -0,0 +1,132 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bigquery
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/internal/trace"
+)
+
+// sessionIDKey is the connection property that attaches a job to a session.
+const sessionIDKey = "session_id"
+
+// A Session groups queries that share temporary tables, variables and
+// transactions. Sessions are created with Client.CreateSession, and end when
+// they are closed or after 24 hours of inactivity.
+//
+// More information about sessions can be found at
+// https://cloud.google.com/bigquery/docs/sessions-intro.
+type Session struct {
+	// ID is the ID of the session.
+	ID string
+
+	// Location is the location of the session. All the queries of a session
+	// run in its location.
+	Location string
+
+	c *Client
+}
+
+// CreateSession creates a new session by running a trivial query job.
+func (c *Client) CreateSession(ctx context.Context) (s *Session, err error) {
+	ctx = trace.StartSpan(ctx, "cloud.google.com/go/bigquery.Client.CreateSession")
+	defer func() { trace.EndSpan(ctx, err) }()
+
+	q := c.Query("SELECT 1")
+	q.CreateSession = true
+	job, err := q.Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status, err := job.Wait(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := status.Err(); err != nil {
+		return nil, err
+	}
+	if status.Statistics == nil || status.Statistics.SessionInfo == nil || status.Statistics.SessionInfo.SessionID == "" {
+		return nil, fmt.Errorf("bigquery: job %s did not create a session", job.ID())
+	}
+	return &Session{
+		ID:       status.Statistics.SessionInfo.SessionID,
+		Location: job.Location(),
+		c:        c,
+	}, nil
+}
+
+// ConnectionProperties returns the connection properties that run a job in
+// the session. They can be set on a QueryConfig or LoadConfig.
+func (s *Session) ConnectionProperties() []*ConnectionProperty {
+	return []*ConnectionProperty{{Key: sessionIDKey, Value: s.ID}}
+}
+
+// Query creates a query with string q that runs in the session.
+// The returned Query may optionally be further configured before its Run
+// method is called, but its ConnectionProperties must keep the session ID.
+func (s *Session) Query(q string) *Query {
+	query := s.c.Query(q)
+	query.Location = s.Location
+	query.ConnectionProperties = s.ConnectionProperties()
+	return query
+}
+
+// exec runs the statements in q in the session and waits for them to
+// complete.
+func (s *Session) exec(ctx context.Context, q string) error {
+	job, err := s.Query(q).Run(ctx)
+	if err != nil {
+		return err
+	}
+	status, err := job.Wait(ctx)
+	if err != nil {
+		return err
+	}
+	return status.Err()
+}
+
+// RunInTransaction runs f in a multi-statement transaction in the session.
+// It starts the transaction with BEGIN TRANSACTION, then calls f, which should
+// run its queries with s.Query. If f returns nil, the transaction is committed
+// with COMMIT TRANSACTION; otherwise it is rolled back with ROLLBACK
+// TRANSACTION and f's error is returned.
+//
+// A session can run only one transaction at a time.
+func (s *Session) RunInTransaction(ctx context.Context, f func(context.Context) error) (err error) {
+	ctx = trace.StartSpan(ctx, "cloud.google.com/go/bigquery.Session.RunInTransaction")
+	defer func() { trace.EndSpan(ctx, err) }()
+
+	if err := s.exec(ctx, "BEGIN TRANSACTION"); err != nil {
+		return err
+	}
+	if err := f(ctx); err != nil {
+		if rerr := s.exec(ctx, "ROLLBACK TRANSACTION"); rerr != nil {
+			return fmt.Errorf("%w (rolling back transaction: %v)", err, rerr)
+		}
+		return err
+	}
+	return s.exec(ctx, "COMMIT TRANSACTION")
+}
+
+// Close terminates the session. Temporary tables of the session are deleted
+// and any transaction in progress is rolled back.
+func (s *Session) Close(ctx context.Context) (err error) {
+	ctx = trace.StartSpan(ctx, "cloud.google.com/go/bigquery.Session.Close")
+	defer func() { trace.EndSpan(ctx, err) }()
+
+	return s.exec(ctx, "CALL BQ.ABORT_SESSION()")
+}
@@ -139,6 +139,35 @@ an error.
 			// TODO: Handle error.
 		}
 
+# Writing Go Values
+
+For cases where maintaining a protocol buffer schema isn't desirable, a RowWriter appends
+Go structs or maps directly.  It derives the descriptor from the destination table's schema,
+and picks up changes to the table schema while writing.
+
+	type Event struct {
+		UserName string    `bigquery:"user_name"`
+		Seen     time.Time `bigquery:"seen"`
+	}
+
+	rowWriter, err := client.NewRowWriter(ctx, tableName)
+	if err != nil {
+		// TODO: Handle error.
+	}
+	defer rowWriter.Close()
+
+	result, err := rowWriter.AppendRows(ctx, []Event{{UserName: "johndoe", Seen: time.Now()}})
+	if err != nil {
+		// TODO: Handle error.
+	}
+	if _, err := result.GetResult(ctx); err != nil {
+		// TODO: Handle error.
+	}
+
+When a stream type other than the default stream is requested with the WithType option,
+the RowWriter tracks the offset of each append, so that retried appends don't write
+duplicate rows.
+
 # Buffered Stream Management
 
 For Buffered streams, users control when data is made visible in the destination table/stream
@@ -0,0 +1,604 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package managedwriter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/bigquery/storage/apiv1/storagepb"
+	"cloud.google.com/go/bigquery/storage/managedwriter/adapt"
+	"cloud.google.com/go/civil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// errUnknownColumn is returned when a row has a value for a column that is not
+// part of the table schema.
+var errUnknownColumn = errors.New("no such column in table schema")
+
+// RowWriter appends Go values to a BigQuery table. It derives the protocol
+// buffer descriptor from the table schema, so that no descriptor needs to be
+// maintained by hand.
+//
+// Rows can be structs, pointers to structs, or maps with string keys. Struct
+// fields are matched to columns by the name given in their "bigquery" tag, or
+// by their field name, ignoring case; fields tagged with "-" are skipped. Map
+// keys are matched to columns ignoring case as well. Nil pointers, nil
+// interfaces and invalid bigquery.NullInt64, bigquery.NullString, etc. values
+// are written as NULL. Values are converted according to the column type:
+//
+//   - INT64: signed and unsigned integers
+//   - FLOAT64: floats and integers
+//   - BOOL: bool
+//   - STRING, GEOGRAPHY, JSON: string
+//   - BYTES: []byte
+//   - NUMERIC, BIGNUMERIC: *big.Rat, or a string holding a decimal number
+//   - DATE: civil.Date or time.Time
+//   - DATETIME: civil.DateTime or time.Time
+//   - TIME: civil.Time
+//   - TIMESTAMP: time.Time
+//   - STRUCT: the same kinds of values as rows
+//   - REPEATED columns: slices or arrays of any of the above
+//
+// The schema is refreshed when the service reports that the table schema
+// changed, or when a row has a value for a column that is not part of the known
+// schema, so that rows using newly added columns can be written without
+// recreating the RowWriter.
+//
+// A RowWriter is safe for concurrent use by multiple goroutines.
+type RowWriter struct {
+	ms    *ManagedStream
+	table string
+
+	// fetchSchema returns the current schema of the table. It can be
+	// replaced for testing.
+	fetchSchema func(ctx context.Context) (*storagepb.TableSchema, error)
+
+	mu         sync.Mutex
+	schema     *storagepb.TableSchema
+	desc       protoreflect.MessageDescriptor
+	descProto  *descriptorpb.DescriptorProto
+	columns    map[string]*rowColumn
+	sendSchema bool // whether the next append must carry descProto
+	// offset is the offset of the next append, for streams other than the
+	// default stream. It is moved back to the offset of an append that
+	// failed once the failure is known, since the rows from that offset on
+	// were not written.
+	offset int64
+	// epoch counts the times offset was moved back.
+	epoch   int
+	pending []*rowAppend
+}
+
+// rowAppend is an append whose outcome is not yet known to the RowWriter.
+type rowAppend struct {
+	result *AppendResult
+	offset int64 // offset of its first row, or NoStreamOffset
+	epoch  int   // epoch of the RowWriter when it was sent
+}
+
+// rowColumn associates a column of the table schema with the field that holds
+// its values in the derived message.
+type rowColumn struct {
+	field  protoreflect.FieldDescriptor
+	typ    storagepb.TableFieldSchema_Type
+	fields map[string]*rowColumn // for STRUCT columns
+}
+
+// NewRowWriter returns a RowWriter that appends rows to destTable, which is
+// of the form "projects/{project}/datasets/{dataset}/tables/{table}".
+//
+// The RowWriter writes to the default stream of the table, unless a different
+// stream type is requested with the WithType option; in that case a new stream
+// is created and the RowWriter sets the offset of each append, so that a
+// retried append can't write its rows twice. Write retries are enabled unless
+// disabled with the EnableWriteRetries option. Other WriterOptions are passed
+// on to the underlying ManagedStream, except WithStreamName and
+// WithSchemaDescriptor, which must not be used.
+func (c *Client) NewRowWriter(ctx context.Context, destTable string, opts ...WriterOption) (*RowWriter, error) {
+	w := &RowWriter{table: destTable}
+	w.fetchSchema = func(ctx context.Context) (*storagepb.TableSchema, error) {
+		info, err := c.getWriteStream(ctx, fmt.Sprintf("%s/streams/_default", destTable), true)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't get table schema: %w", err)
+		}
+		if info.GetTableSchema() == nil {
+			return nil, fmt.Errorf("no table schema returned for %q", destTable)
+		}
+		return info.GetTableSchema(), nil
+	}
+	schema, err := w.fetchSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.setSchema(schema); err != nil {
+		return nil, err
+	}
+	wopts := append([]WriterOption{WithType(DefaultStream), EnableWriteRetries(true)}, opts...)
+	wopts = append(wopts, WithDestinationTable(destTable), WithSchemaDescriptor(w.descProto))
+	ms, err := c.NewManagedStream(ctx, wopts...)
+	if err != nil {
+		return nil, err
+	}
+	w.ms = ms
+	w.sendSchema = false
+	return w, nil
+}
+
+// ManagedStream returns the stream the RowWriter appends to, for instance to
+// finalize or flush it.
+func (w *RowWriter) ManagedStream() *ManagedStream {
+	return w.ms
+}
+
+// Close closes the underlying ManagedStream.
+func (w *RowWriter) Close() error {
+	return w.ms.Close()
+}
+
+// AppendRows encodes rows, which must be a slice or an array, and appends
+// them to the stream. See RowWriter for the supported row types.
+//
+// Like ManagedStream.AppendRows, AppendRows returns once the rows are sent;
+// use the returned AppendResult to wait for the outcome of the append.
+//
+// When the RowWriter sets offsets, an append that fails leaves a gap that
+// makes the appends sent after it fail as well. Once a later call to
+// AppendRows observes the failure, it appends at the offset of the failed
+// rows again, so that they can be retried.
+func (w *RowWriter) AppendRows(ctx context.Context, rows interface{}) (*AppendResult, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.processCompleted(); err != nil {
+		return nil, err
+	}
+	data, err := w.encodeRows(rows)
+	if errors.Is(err, errUnknownColumn) {
+		// The table may have gained columns since the schema was retrieved.
+		schema, ferr := w.fetchSchema(ctx)
+		if ferr != nil {
+			return nil, ferr
+		}
+		if !proto.Equal(schema, w.schema) {
+			if err := w.setSchema(schema); err != nil {
+				return nil, err
+			}
+			data, err = w.encodeRows(rows)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []AppendOption
+	if w.sendSchema {
+		opts = append(opts, UpdateSchemaDescriptor(w.descProto))
+	}
+	trackOffsets := w.ms.StreamType() != DefaultStream
+	if trackOffsets {
+		opts = append(opts, WithOffset(w.offset))
+	}
+	ar, err := w.ms.AppendRows(ctx, data, opts...)
+	if err != nil {
+		return nil, err
+	}
+	w.sendSchema = false
+	pa := &rowAppend{result: ar, offset: NoStreamOffset, epoch: w.epoch}
+	if trackOffsets {
+		pa.offset = w.offset
+		w.offset += int64(len(data))
+	}
+	w.pending = append(w.pending, pa)
+	return ar, nil
+}
+
+// processCompleted drops the completed appends from w.pending, and switches
+// to the most recent schema they report, if any. If appends sent at the
+// current offsets failed, it moves w.offset back to the first of them, so
+// that the next append, which may retry the failed rows, is not rejected for
+// leaving a gap in the stream.
+//
+// w.mu must be held.
+func (w *RowWriter) processCompleted() error {
+	var latest *storagepb.TableSchema
+	failed := NoStreamOffset
+	kept := w.pending[:0]
+	for _, pa := range w.pending {
+		ar := pa.result
+		select {
+		case <-ar.Ready():
+			if ar.response != nil {
+				if s := ar.response.GetUpdatedSchema(); s != nil {
+					latest = s
+				}
+			}
+			// Appends sent before w.offset last moved back fail as out of
+			// range, which says nothing of the current offsets.
+			if pa.offset != NoStreamOffset && pa.epoch == w.epoch && appendFailed(ar) &&
+				(failed == NoStreamOffset || pa.offset < failed) {
+				failed = pa.offset
+			}
+		default:
+			kept = append(kept, pa)
+		}
+	}
+	if failed != NoStreamOffset {
+		w.offset = failed
+		w.epoch++
+	}
+	for i := len(kept); i < len(w.pending); i++ {
+		w.pending[i] = nil
+	}
+	w.pending = kept
+	if latest == nil || proto.Equal(latest, w.schema) {
+		return nil
+	}
+	return w.setSchema(proto.Clone(latest).(*storagepb.TableSchema))
+}
+
+// appendFailed reports whether the completed append ar failed to write its
+// rows. An append reported as already existing was written by an earlier
+// attempt.
+func appendFailed(ar *AppendResult) bool {
+	if ar.err != nil {
+		return status.Code(ar.err) != codes.AlreadyExists
+	}
+	st := ar.response.GetError()
+	return st != nil && codes.Code(st.GetCode()) != codes.AlreadyExists
+}
+
+// setSchema derives the message descriptor used to encode rows from schema.
+//
+// w.mu must be held, if w is shared.
+func (w *RowWriter) setSchema(schema *storagepb.TableSchema) error {
+	d, err := adapt.StorageSchemaToProto2Descriptor(schema, "root")
+	if err != nil {
+		return fmt.Errorf("couldn't convert table schema: %w", err)
+	}
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return fmt.Errorf("converted table schema is not a message descriptor")
+	}
+	dp, err := adapt.NormalizeDescriptor(md)
+	if err != nil {
+		return fmt.Errorf("couldn't normalize descriptor: %w", err)
+	}
+	w.schema = schema
+	w.desc = md
+	w.descProto = dp
+	w.columns = rowColumns(schema.GetFields(), md)
+	w.sendSchema = true
+	return nil
+}
+
+// encodeRows returns the serialized messages for rows.
+//
+// w.mu must be held.
+func (w *RowWriter) encodeRows(rows interface{}) ([][]byte, error) {
+	rv := reflect.ValueOf(rows)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("rows must be a slice or an array, got %T", rows)
+	}
+	data := make([][]byte, rv.Len())
+	for i := range data {
+		msg := dynamicpb.NewMessage(w.desc)
+		if err := encodeMessage(msg, w.columns, rv.Index(i)); err != nil {
+			return nil, fmt.Errorf("row %d: %w", i, err)
+		}
+		b, err := proto.Marshal(msg)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i, err)
+		}
+		data[i] = b
+	}
+	return data, nil
+}
+
+// rowColumns indexes fields by lowercase column name. The fields of md are
+// numbered after the position of the column in fields.
+func rowColumns(fields []*storagepb.TableFieldSchema, md protoreflect.MessageDescriptor) map[string]*rowColumn {
+	cols := make(map[string]*rowColumn, len(fields))
+	for i, f := range fields {
+		fd := md.Fields().ByNumber(protoreflect.FieldNumber(i + 1))
+		col := &rowColumn{field: fd, typ: f.GetType()}
+		if f.GetType() == storagepb.TableFieldSchema_STRUCT {
+			col.fields = rowColumns(f.GetFields(), fd.Message())
+		}
+		cols[strings.ToLower(f.GetName())] = col
+	}
+	return cols
+}
+
+// encodeMessage sets the fields of msg from v, a struct or a map with string
+// keys.
+func encodeMessage(msg protoreflect.Message, cols map[string]*rowColumn, v reflect.Value) error {
+	v = indirect(v)
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("map keys must be strings, got %s", v.Type().Key())
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := encodeField(msg, cols, iter.Key().String(), iter.Value()); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			name := sf.Name
+			if tag, ok := sf.Tag.Lookup("bigquery"); ok {
+				tagName := strings.Split(tag, ",")[0]
+				if tagName == "-" {
+					continue
+				}
+				if tagName != "" {
+					name = tagName
+				}
+			}
+			if err := encodeField(msg, cols, name, v.Field(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Invalid:
+		return errors.New("row or struct value is nil")
+	default:
+		return fmt.Errorf("expected a struct or a map, got %s", v.Type())
+	}
+}
+
+// encodeField sets the field of msg for the column name from v.
+func encodeField(msg protoreflect.Message, cols map[string]*rowColumn, name string, v reflect.Value) error {
+	col, ok := cols[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("%w: %q", errUnknownColumn, name)
+	}
+	v = indirect(v)
+	if !v.IsValid() {
+		// NULL is represented by an unset field.
+		return nil
+	}
+	fd := col.field
+	if fd.IsList() {
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return fmt.Errorf("column %q: expected a slice or an array, got %s", name, v.Type())
+		}
+		list := msg.Mutable(fd).List()
+		for i := 0; i < v.Len(); i++ {
+			e := indirect(v.Index(i))
+			if !e.IsValid() {
+				return fmt.Errorf("column %q: NULL elements are not allowed in arrays", name)
+			}
+			if fd.Kind() == protoreflect.MessageKind {
+				ev := list.NewElement()
+				if err := encodeMessage(ev.Message(), col.fields, e); err != nil {
+					return fmt.Errorf("column %q: %w", name, err)
+				}
+				list.Append(ev)
+				continue
+			}
+			pv, err := scalarValue(col.typ, e)
+			if err != nil {
+				return fmt.Errorf("column %q: %w", name, err)
+			}
+			list.Append(pv)
+		}
+		return nil
+	}
+	if fd.Kind() == protoreflect.MessageKind {
+		if err := encodeMessage(msg.Mutable(fd).Message(), col.fields, v); err != nil {
+			return fmt.Errorf("column %q: %w", name, err)
+		}
+		return nil
+	}
+	pv, err := scalarValue(col.typ, v)
+	if err != nil {
+		return fmt.Errorf("column %q: %w", name, err)
+	}
+	msg.Set(fd, pv)
+	return nil
+}
+
+// indirect follows pointers and interfaces, and unwraps the bigquery.Null
+// types. It returns the zero Value for nil and NULL values.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		if _, ok := v.Interface().(*big.Rat); ok {
+			return v
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() || !v.CanInterface() {
+		return v
+	}
+	var (
+		x     interface{}
+		valid bool
+	)
+	switch n := v.Interface().(type) {
+	case bigquery.NullInt64:
+		x, valid = n.Int64, n.Valid
+	case bigquery.NullFloat64:
+		x, valid = n.Float64, n.Valid
+	case bigquery.NullBool:
+		x, valid = n.Bool, n.Valid
+	case bigquery.NullString:
+		x, valid = n.StringVal, n.Valid
+	case bigquery.NullGeography:
+		x, valid = n.GeographyVal, n.Valid
+	case bigquery.NullJSON:
+		x, valid = n.JSONVal, n.Valid
+	case bigquery.NullTimestamp:
+		x, valid = n.Timestamp, n.Valid
+	case bigquery.NullDate:
+		x, valid = n.Date, n.Valid
+	case bigquery.NullTime:
+		x, valid = n.Time, n.Valid
+	case bigquery.NullDateTime:
+		x, valid = n.DateTime, n.Valid
+	default:
+		return v
+	}
+	if !valid {
+		return reflect.Value{}
+	}
+	return reflect.ValueOf(x)
+}
+
+// scalarValue converts v to the protocol buffer representation of a value of
+// a column of type typ.
+func scalarValue(typ storagepb.TableFieldSchema_Type, v reflect.Value) (protoreflect.Value, error) {
+	switch typ {
+	case storagepb.TableFieldSchema_INT64:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return protoreflect.ValueOfInt64(v.Int()), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if v.Uint() > math.MaxInt64 {
+				return protoreflect.Value{}, fmt.Errorf("value %d overflows INT64", v.Uint())
+			}
+			return protoreflect.ValueOfInt64(int64(v.Uint())), nil
+		}
+	case storagepb.TableFieldSchema_DOUBLE:
+		switch v.Kind() {
+		case reflect.Float32, reflect.Float64:
+			return protoreflect.ValueOfFloat64(v.Float()), nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return protoreflect.ValueOfFloat64(float64(v.Int())), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return protoreflect.ValueOfFloat64(float64(v.Uint())), nil
+		}
+	case storagepb.TableFieldSchema_BOOL:
+		if v.Kind() == reflect.Bool {
+			return protoreflect.ValueOfBool(v.Bool()), nil
+		}
+	case storagepb.TableFieldSchema_STRING, storagepb.TableFieldSchema_GEOGRAPHY, storagepb.TableFieldSchema_JSON:
+		if v.Kind() == reflect.String {
+			return protoreflect.ValueOfString(v.String()), nil
+		}
+	case storagepb.TableFieldSchema_BYTES:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return protoreflect.ValueOfBytes(v.Bytes()), nil
+		}
+	case storagepb.TableFieldSchema_NUMERIC, storagepb.TableFieldSchema_BIGNUMERIC:
+		scale := bigquery.NumericScaleDigits
+		if typ == storagepb.TableFieldSchema_BIGNUMERIC {
+			scale = bigquery.BigNumericScaleDigits
+		}
+		switch x := v.Interface().(type) {
+		case *big.Rat:
+			return protoreflect.ValueOfBytes(numericBytes(x, scale)), nil
+		case string:
+			r, ok := new(big.Rat).SetString(x)
+			if !ok {
+				return protoreflect.Value{}, fmt.Errorf("invalid numeric value %q", x)
+			}
+			return protoreflect.ValueOfBytes(numericBytes(r, scale)), nil
+		}
+	case storagepb.TableFieldSchema_DATE:
+		switch x := v.Interface().(type) {
+		case civil.Date:
+			return protoreflect.ValueOfInt32(int32(x.In(time.UTC).Unix() / 86400)), nil
+		case time.Time:
+			return protoreflect.ValueOfInt32(int32(civil.DateOf(x).In(time.UTC).Unix() / 86400)), nil
+		}
+	case storagepb.TableFieldSchema_DATETIME:
+		switch x := v.Interface().(type) {
+		case civil.DateTime:
+			return protoreflect.ValueOfInt64(packDateTime(x)), nil
+		case time.Time:
+			return protoreflect.ValueOfInt64(packDateTime(civil.DateTimeOf(x))), nil
+		}
+	case storagepb.TableFieldSchema_TIME:
+		if x, ok := v.Interface().(civil.Time); ok {
+			return protoreflect.ValueOfInt64(packTime(x)), nil
+		}
+	case storagepb.TableFieldSchema_TIMESTAMP:
+		if x, ok := v.Interface().(time.Time); ok {
+			return protoreflect.ValueOfInt64(x.UnixMicro()), nil
+		}
+	default:
+		return protoreflect.Value{}, fmt.Errorf("unsupported column type %s", typ)
+	}
+	return protoreflect.Value{}, fmt.Errorf("can't convert %s to %s", v.Type(), typ)
+}
+
+// numericBytes returns the encoding of r expected by the service for NUMERIC
+// and BIGNUMERIC values: the little-endian two's complement representation of
+// r scaled by 10^scale.
+func numericBytes(r *big.Rat, scale int) []byte {
+	n := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)
+	n.Mul(n, r.Num())
+	n.Quo(n, r.Denom())
+
+	var b []byte
+	if n.Sign() >= 0 {
+		b = n.Bytes()
+		if len(b) == 0 || b[0]&0x80 != 0 {
+			b = append([]byte{0}, b...)
+		}
+	} else {
+		// Find the smallest size whose two's complement range holds n, and
+		// add 2^(8*size) to n to obtain its two's complement.
+		m := new(big.Int).Neg(n)
+		m.Sub(m, big.NewInt(1))
+		size := m.BitLen()/8 + 1
+		m.Lsh(big.NewInt(1), uint(8*size))
+		m.Add(m, n)
+		b = m.Bytes()
+	}
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return b
+}
+
+// packTime returns the packed representation of t used for TIME values.
+func packTime(t civil.Time) int64 {
+	secs := int64(t.Hour)<<12 | int64(t.Minute)<<6 | int64(t.Second)
+	return secs<<20 | int64(t.Nanosecond/1000)
+}
+
+// packDateTime returns the packed representation of dt used for DATETIME
+// values.
+func packDateTime(dt civil.DateTime) int64 {
+	secs := int64(dt.Date.Year)<<26 | int64(dt.Date.Month)<<22 | int64(dt.Date.Day)<<17 |
+		int64(dt.Time.Hour)<<12 | int64(dt.Time.Minute)<<6 | int64(dt.Time.Second)
+	return secs<<20 | int64(dt.Time.Nanosecond/1000)
+}
@@ -0,0 +1,276 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package managedwriter
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/bigquery/storage/apiv1/storagepb"
+	"cloud.google.com/go/civil"
+	statuspb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+var rowWriterTestSchema = &storagepb.TableSchema{
+	Fields: []*storagepb.TableFieldSchema{
+		{Name: "name", Type: storagepb.TableFieldSchema_STRING, Mode: storagepb.TableFieldSchema_REQUIRED},
+		{Name: "count", Type: storagepb.TableFieldSchema_INT64, Mode: storagepb.TableFieldSchema_NULLABLE},
+		{Name: "ts", Type: storagepb.TableFieldSchema_TIMESTAMP, Mode: storagepb.TableFieldSchema_NULLABLE},
+		{Name: "day", Type: storagepb.TableFieldSchema_DATE, Mode: storagepb.TableFieldSchema_NULLABLE},
+		{Name: "tags", Type: storagepb.TableFieldSchema_STRING, Mode: storagepb.TableFieldSchema_REPEATED},
+		{
+			Name: "inner",
+			Type: storagepb.TableFieldSchema_STRUCT,
+			Mode: storagepb.TableFieldSchema_NULLABLE,
+			Fields: []*storagepb.TableFieldSchema{
+				{Name: "score", Type: storagepb.TableFieldSchema_DOUBLE, Mode: storagepb.TableFieldSchema_NULLABLE},
+			},
+		},
+	},
+}
+
+func decodeTestRow(t *testing.T, w *RowWriter, b []byte) protoreflect.Message {
+	t.Helper()
+	msg := dynamicpb.NewMessage(w.desc)
+	if err := proto.Unmarshal(b, msg); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	return msg
+}
+
+func TestRowWriterEncodeRows(t *testing.T) {
+	w := &RowWriter{}
+	if err := w.setSchema(rowWriterTestSchema); err != nil {
+		t.Fatalf("setSchema: %v", err)
+	}
+	type inner struct {
+		Score float64
+	}
+	type row struct {
+		Name    string `bigquery:"name"`
+		Count   bigquery.NullInt64
+		TS      time.Time `bigquery:"ts"`
+		Day     civil.Date
+		Tags    []string
+		Inner   *inner
+		Ignored string `bigquery:"-"`
+	}
+	ts := time.Date(2024, 3, 1, 12, 0, 0, 5000, time.UTC)
+	rows := []interface{}{
+		row{
+			Name:  "a",
+			Count: bigquery.NullInt64{Int64: 7, Valid: true},
+			TS:    ts,
+			Day:   civil.Date{Year: 1970, Month: 1, Day: 3},
+			Tags:  []string{"x", "y"},
+			Inner: &inner{Score: 1.5},
+		},
+		map[string]interface{}{
+			"NAME":  "b",
+			"count": nil,
+			"inner": map[string]interface{}{"score": 2},
+		},
+	}
+	data, err := w.encodeRows(rows)
+	if err != nil {
+		t.Fatalf("encodeRows: %v", err)
+	}
+	if len(data) != 2 {
+		t.Fatalf("got %d rows, want 2", len(data))
+	}
+	fields := w.desc.Fields()
+
+	m := decodeTestRow(t, w, data[0])
+	if got := m.Get(fields.ByName("name")).String(); got != "a" {
+		t.Errorf("name: got %q, want %q", got, "a")
+	}
+	if got := m.Get(fields.ByName("count")).Int(); got != 7 {
+		t.Errorf("count: got %d, want 7", got)
+	}
+	if got, want := m.Get(fields.ByName("ts")).Int(), ts.UnixMicro(); got != want {
+		t.Errorf("ts: got %d, want %d", got, want)
+	}
+	if got := m.Get(fields.ByName("day")).Int(); got != 2 {
+		t.Errorf("day: got %d, want 2", got)
+	}
+	if got := m.Get(fields.ByName("tags")).List().Len(); got != 2 {
+		t.Errorf("tags: got %d elements, want 2", got)
+	}
+	innerMsg := m.Get(fields.ByName("inner")).Message()
+	if got := innerMsg.Get(innerMsg.Descriptor().Fields().ByName("score")).Float(); got != 1.5 {
+		t.Errorf("inner.score: got %v, want 1.5", got)
+	}
+
+	m = decodeTestRow(t, w, data[1])
+	if got := m.Get(fields.ByName("name")).String(); got != "b" {
+		t.Errorf("name: got %q, want %q", got, "b")
+	}
+	if m.Has(fields.ByName("count")) {
+		t.Error("count: got a value, want NULL")
+	}
+	innerMsg = m.Get(fields.ByName("inner")).Message()
+	if got := innerMsg.Get(innerMsg.Descriptor().Fields().ByName("score")).Float(); got != 2 {
+		t.Errorf("inner.score: got %v, want 2", got)
+	}
+}
+
+func TestRowWriterEncodeRowsErrors(t *testing.T) {
+	w := &RowWriter{}
+	if err := w.setSchema(rowWriterTestSchema); err != nil {
+		t.Fatalf("setSchema: %v", err)
+	}
+	for _, tc := range []struct {
+		desc        string
+		rows        interface{}
+		wantUnknown bool
+	}{
+		{"not a slice", map[string]interface{}{"name": "a"}, false},
+		{"unknown column", []map[string]interface{}{{"other": 1}}, true},
+		{"wrong type", []map[string]interface{}{{"count": "one"}}, false},
+		{"nil row", []interface{}{nil}, false},
+		{"NULL array element", []map[string]interface{}{{"tags": []*string{nil}}}, false},
+	} {
+		_, err := w.encodeRows(tc.rows)
+		if err == nil {
+			t.Errorf("%s: got nil error", tc.desc)
+			continue
+		}
+		if got := errors.Is(err, errUnknownColumn); got != tc.wantUnknown {
+			t.Errorf("%s: got unknown column %t, want %t (%v)", tc.desc, got, tc.wantUnknown, err)
+		}
+	}
+}
+
+func TestRowWriterProcessCompletedSchema(t *testing.T) {
+	w := &RowWriter{}
+	if err := w.setSchema(rowWriterTestSchema); err != nil {
+		t.Fatalf("setSchema: %v", err)
+	}
+	w.sendSchema = false
+
+	updated := proto.Clone(rowWriterTestSchema).(*storagepb.TableSchema)
+	updated.Fields = append(updated.Fields, &storagepb.TableFieldSchema{
+		Name: "extra", Type: storagepb.TableFieldSchema_BOOL, Mode: storagepb.TableFieldSchema_NULLABLE,
+	})
+	done := newAppendResult()
+	done.response = &storagepb.AppendRowsResponse{UpdatedSchema: updated}
+	close(done.ready)
+	inflight := newAppendResult()
+	w.pending = []*rowAppend{{result: done, offset: NoStreamOffset}, {result: inflight, offset: NoStreamOffset}}
+
+	if err := w.processCompleted(); err != nil {
+		t.Fatalf("processCompleted: %v", err)
+	}
+	if len(w.pending) != 1 || w.pending[0].result != inflight {
+		t.Errorf("got %d pending results, want only the incomplete one", len(w.pending))
+	}
+	if !w.sendSchema {
+		t.Error("sendSchema is false after a schema update")
+	}
+	if _, err := w.encodeRows([]map[string]interface{}{{"name": "a", "extra": true}}); err != nil {
+		t.Errorf("encodeRows with new column: %v", err)
+	}
+}
+
+func TestRowWriterProcessCompletedOffsets(t *testing.T) {
+	w := &RowWriter{}
+	if err := w.setSchema(rowWriterTestSchema); err != nil {
+		t.Fatalf("setSchema: %v", err)
+	}
+	completed := func(err error, st *statuspb.Status) *AppendResult {
+		ar := newAppendResult()
+		ar.err = err
+		ar.response = &storagepb.AppendRowsResponse{}
+		if st != nil {
+			ar.response.Response = &storagepb.AppendRowsResponse_Error{Error: st}
+		}
+		close(ar.ready)
+		return ar
+	}
+	ok := completed(nil, nil)
+	exists := completed(nil, &statuspb.Status{Code: int32(codes.AlreadyExists)})
+	invalid := completed(nil, &statuspb.Status{Code: int32(codes.InvalidArgument)})
+	outOfRange := completed(status.Error(codes.OutOfRange, "offset beyond the end of the stream"), nil)
+
+	// Rows 0 to 9 were written, possibly by an earlier attempt, but the
+	// append at 10 failed, and the one at 15 was rejected because of it.
+	w.offset = 20
+	w.pending = []*rowAppend{
+		{result: ok, offset: 0},
+		{result: exists, offset: 5},
+		{result: invalid, offset: 10},
+		{result: outOfRange, offset: 15},
+	}
+	if err := w.processCompleted(); err != nil {
+		t.Fatalf("processCompleted: %v", err)
+	}
+	if w.offset != 10 {
+		t.Errorf("got offset %d after a failed append, want 10", w.offset)
+	}
+
+	// An append sent before the offset moved back fails as well, but the
+	// offset stays put.
+	w.offset = 13
+	w.pending = []*rowAppend{{result: outOfRange, offset: 20}, {result: ok, offset: 10, epoch: w.epoch}}
+	if err := w.processCompleted(); err != nil {
+		t.Fatalf("processCompleted: %v", err)
+	}
+	if w.offset != 13 {
+		t.Errorf("got offset %d after a stale failure, want 13", w.offset)
+	}
+}
+
+func TestNumericBytes(t *testing.T) {
+	for _, tc := range []struct {
+		in    string
+		scale int
+		want  []byte
+	}{
+		{"0", 0, []byte{0}},
+		{"1", 0, []byte{1}},
+		{"127", 0, []byte{127}},
+		{"128", 0, []byte{128, 0}},
+		{"-1", 0, []byte{0xff}},
+		{"-128", 0, []byte{0x80}},
+		{"-129", 0, []byte{0x7f, 0xff}},
+		{"1.5", 1, []byte{15}},
+		{"-0.000000001", 9, []byte{0xff}},
+	} {
+		r, _ := new(big.Rat).SetString(tc.in)
+		if got := numericBytes(r, tc.scale); !bytes.Equal(got, tc.want) {
+			t.Errorf("numericBytes(%s, %d): got %v, want %v", tc.in, tc.scale, got, tc.want)
+		}
+	}
+}
+
+func TestPackedCivilTimes(t *testing.T) {
+	tm := civil.Time{Hour: 12, Minute: 34, Second: 56, Nanosecond: 789000}
+	if got, want := packTime(tm), int64(12<<12|34<<6|56)<<20|789; got != want {
+		t.Errorf("packTime: got %d, want %d", got, want)
+	}
+	dt := civil.DateTime{Date: civil.Date{Year: 2024, Month: 3, Day: 1}, Time: tm}
+	if got, want := packDateTime(dt), int64(2024<<26|3<<22|1<<17|12<<12|34<<6|56)<<20|789; got != want {
+		t.Errorf("packDateTime: got %d, want %d", got, want)
+	}
+}
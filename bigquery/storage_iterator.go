@@ -369,3 +369,19 @@ func (it *RowIterator) ArrowIterator() (ArrowIterator, error) {
 	}
 	return it.arrowIterator, nil
 }
+
+// ArrowRecordReader gives access to the results as a stream of decoded Arrow
+// records, for consumption by columnar processing or dataframe libraries.
+// Experimental: this interface is experimental and may be modified or removed in future versions,
+// regardless of any other documented package stability guarantees.
+// Don't try to mix RowIterator.Next and ArrowRecordReader.Read calls.
+func (it *RowIterator) ArrowRecordReader() (*ArrowRecordReader, error) {
+	if !it.IsAccelerated() {
+		return nil, errors.New("bigquery: require storage read API to be enabled")
+	}
+	return &ArrowRecordReader{
+		it:        it.arrowIterator,
+		schema:    it.arrowDecoder.arrowSchema,
+		allocator: it.arrowDecoder.allocator,
+	}, nil
+}
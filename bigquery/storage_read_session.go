@@ -0,0 +1,410 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bigquery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/bigquery/storage/apiv1/storagepb"
+	"cloud.google.com/go/internal/trace"
+	gax "github.com/googleapis/gax-go/v2"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// TableReadOptions configures a read session created with Table.ReadSessions.
+type TableReadOptions struct {
+	// SelectedFields lists the names of the columns to read. Nested fields
+	// are selected with a dot-separated path, e.g. "address.city". If empty,
+	// all the columns are read.
+	SelectedFields []string
+
+	// RowRestriction is a SQL filter, such as "state = 'WA' AND age > 21",
+	// that is applied by the server. Only the rows matching it are read.
+	RowRestriction string
+
+	// SnapshotTime, if not zero, reads the table as it was at that time.
+	SnapshotTime time.Time
+
+	// MaxStreamCount is the maximum number of streams of the session. If
+	// zero, the server chooses a number of streams that gives reasonable
+	// throughput.
+	MaxStreamCount int
+
+	// MinStreamCount is the number of streams the session should have to
+	// keep the readers busy. If the server creates fewer streams, streams are
+	// split until there are MinStreamCount of them or they cannot be split
+	// anymore.
+	MinStreamCount int
+}
+
+// A TableReadSession is a Storage Read API session over a table. Its streams
+// partition the rows of the table and can be read in parallel.
+//
+// TableReadSession is EXPERIMENTAL and subject to change or removal without notice.
+type TableReadSession struct {
+	// Name is the resource name of the session.
+	Name string
+
+	// Schema is the schema of the rows read, restricted to the selected
+	// fields.
+	Schema Schema
+
+	// EstimatedRowCount is an estimate of the number of rows to be read,
+	// computed before the row restriction is applied.
+	EstimatedRowCount int64
+
+	// Streams are the readers of the streams of the session.
+	Streams []*TableStreamReader
+}
+
+// A TableStreamReader reads the Arrow record batches of one stream of a
+// TableReadSession. It implements ArrowIterator. A TableStreamReader is not
+// safe for concurrent use, but the readers of a session can be used
+// concurrently.
+//
+// TableStreamReader is EXPERIMENTAL and subject to change or removal without notice.
+type TableStreamReader struct {
+	ctx       context.Context
+	name      string
+	schema    Schema
+	rawSchema []byte
+
+	offset    int64
+	rowStream storagepb.BigQueryRead_ReadRowsClient
+	cancel    context.CancelFunc
+	done      bool
+
+	// decouple from readClient to enable testing
+	readRowsFunc        func(context.Context, *storagepb.ReadRowsRequest, ...gax.CallOption) (storagepb.BigQueryRead_ReadRowsClient, error)
+	splitReadStreamFunc func(context.Context, *storagepb.SplitReadStreamRequest, ...gax.CallOption) (*storagepb.SplitReadStreamResponse, error)
+}
+
+var _ ArrowIterator = &TableStreamReader{}
+
+// ReadSessions creates a Storage Read API session over the table, reading
+// only the selected fields of the rows matching the row restriction of opts,
+// which may be nil. The rows are not decoded as they are read: use the
+// readers of the returned session directly as ArrowIterators, or call their
+// Rows method.
+//
+// The Storage Read API client must be enabled with
+// Client.EnableStorageReadClient. The context is used by the readers of the
+// session for their whole lifetime.
+//
+// ReadSessions is EXPERIMENTAL and subject to change or removal without notice.
+func (t *Table) ReadSessions(ctx context.Context, opts *TableReadOptions) (s *TableReadSession, err error) {
+	ctx = trace.StartSpan(ctx, "cloud.google.com/go/bigquery.Table.ReadSessions")
+	defer func() { trace.EndSpan(ctx, err) }()
+
+	if t.c.rc == nil {
+		return nil, errors.New("bigquery: Storage Read API client is not enabled; call Client.EnableStorageReadClient")
+	}
+	if opts == nil {
+		opts = &TableReadOptions{}
+	}
+	md, err := t.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := selectSchemaFields(md.Schema, opts.SelectedFields)
+	if err != nil {
+		return nil, err
+	}
+	tableID, err := t.Identifier(StorageAPIResourceID)
+	if err != nil {
+		return nil, err
+	}
+	rc := t.c.rc
+	req := newCreateReadSessionRequest(t.ProjectID, tableID, opts)
+	if req.MaxStreamCount == 0 && req.PreferredMinStreamCount == 0 {
+		req.PreferredMinStreamCount = int32(rc.settings.maxWorkerCount)
+	}
+	session, err := rc.rawClient.CreateReadSession(ctx, req, gax.WithGRPCOptions(
+		// Read API can send batches up to 128MB
+		// https://cloud.google.com/bigquery/quotas#storage-limits
+		grpc.MaxCallRecvMsgSize(1024*1024*129),
+	))
+	if err != nil {
+		return nil, err
+	}
+	s = &TableReadSession{
+		Name:              session.Name,
+		Schema:            schema,
+		EstimatedRowCount: session.EstimatedRowCount,
+	}
+	rawSchema := session.GetArrowSchema().GetSerializedSchema()
+	for _, stream := range session.Streams {
+		s.Streams = append(s.Streams, &TableStreamReader{
+			ctx:                 ctx,
+			name:                stream.Name,
+			schema:              schema,
+			rawSchema:           rawSchema,
+			readRowsFunc:        rc.rawClient.ReadRows,
+			splitReadStreamFunc: rc.rawClient.SplitReadStream,
+		})
+	}
+	if err := s.splitStreams(ctx, opts.MinStreamCount); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func newCreateReadSessionRequest(projectID, tableID string, opts *TableReadOptions) *storagepb.CreateReadSessionRequest {
+	rs := &storagepb.ReadSession{
+		Table:      tableID,
+		DataFormat: storagepb.DataFormat_ARROW,
+	}
+	if len(opts.SelectedFields) > 0 || opts.RowRestriction != "" {
+		rs.ReadOptions = &storagepb.ReadSession_TableReadOptions{
+			SelectedFields: opts.SelectedFields,
+			RowRestriction: opts.RowRestriction,
+		}
+	}
+	if !opts.SnapshotTime.IsZero() {
+		rs.TableModifiers = &storagepb.ReadSession_TableModifiers{
+			SnapshotTime: timestamppb.New(opts.SnapshotTime),
+		}
+	}
+	return &storagepb.CreateReadSessionRequest{
+		Parent:                  fmt.Sprintf("projects/%s", projectID),
+		ReadSession:             rs,
+		MaxStreamCount:          int32(opts.MaxStreamCount),
+		PreferredMinStreamCount: int32(opts.MinStreamCount),
+	}
+}
+
+// splitStreams splits the streams of the session in halves until there are at
+// least n of them, or none of them can be split.
+func (s *TableReadSession) splitStreams(ctx context.Context, n int) error {
+	for len(s.Streams) > 0 && len(s.Streams) < n {
+		streams := make([]*TableStreamReader, 0, n)
+		for i, r := range s.Streams {
+			streams = append(streams, r)
+			if len(streams)+len(s.Streams)-i-1 >= n {
+				streams = append(streams, s.Streams[i+1:]...)
+				break
+			}
+			rest, err := r.Split(ctx, 0.5)
+			if err != nil {
+				return err
+			}
+			if rest != nil {
+				streams = append(streams, rest)
+			}
+		}
+		if len(streams) == len(s.Streams) {
+			return nil
+		}
+		s.Streams = streams
+	}
+	return nil
+}
+
+// Name returns the resource name of the stream read by r.
+func (r *TableStreamReader) Name() string {
+	return r.name
+}
+
+// Split splits the rows of the stream that have not been read yet in two.
+// The reader keeps reading the first part, roughly fraction of the rows, and
+// a new reader is returned for the rest. Split returns a nil reader if the
+// stream is too small to be split. Split must not be called while Next is
+// running.
+func (r *TableStreamReader) Split(ctx context.Context, fraction float64) (*TableStreamReader, error) {
+	if fraction <= 0 || fraction >= 1 {
+		return nil, fmt.Errorf("bigquery: split fraction must be between 0 and 1, got %v", fraction)
+	}
+	resp, err := r.splitReadStreamFunc(ctx, &storagepb.SplitReadStreamRequest{
+		Name:     r.name,
+		Fraction: fraction,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.GetPrimaryStream() == nil || resp.GetRemainderStream() == nil {
+		return nil, nil
+	}
+	// The primary stream starts with the rows of the original stream, so
+	// reading can resume at the same offset.
+	r.closeRowStream()
+	r.name = resp.PrimaryStream.Name
+	return &TableStreamReader{
+		ctx:                 r.ctx,
+		name:                resp.RemainderStream.Name,
+		schema:              r.schema,
+		rawSchema:           r.rawSchema,
+		readRowsFunc:        r.readRowsFunc,
+		splitReadStreamFunc: r.splitReadStreamFunc,
+	}, nil
+}
+
+// Next returns the next record batch of the stream, or iterator.Done when the
+// stream has been read completely.
+func (r *TableStreamReader) Next() (*ArrowRecordBatch, error) {
+	bo := gax.Backoff{}
+	for {
+		if r.done {
+			return nil, iterator.Done
+		}
+		if r.rowStream == nil {
+			ctx, cancel := context.WithCancel(r.ctx)
+			rowStream, err := r.readRowsFunc(ctx, &storagepb.ReadRowsRequest{
+				ReadStream: r.name,
+				Offset:     r.offset,
+			})
+			if err != nil {
+				cancel()
+				if err := r.retry(bo, err); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			r.rowStream, r.cancel = rowStream, cancel
+		}
+		resp, err := r.rowStream.Recv()
+		if err == io.EOF {
+			r.closeRowStream()
+			r.done = true
+			return nil, iterator.Done
+		}
+		if err != nil {
+			r.closeRowStream()
+			if err := r.retry(bo, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if resp.RowCount == 0 {
+			continue
+		}
+		r.offset += resp.RowCount
+		return &ArrowRecordBatch{
+			PartitionID: r.name,
+			Schema:      r.rawSchema,
+			Data:        resp.GetArrowRecordBatch().GetSerializedRecordBatch(),
+		}, nil
+	}
+}
+
+// retry waits before reading the stream again if err is transient, and
+// returns an error otherwise.
+func (r *TableStreamReader) retry(bo gax.Backoff, err error) error {
+	if r.ctx.Err() != nil {
+		return r.ctx.Err()
+	}
+	pause, shouldRetry := retryReadRows(bo, err)
+	if !shouldRetry {
+		return fmt.Errorf("failed to read rows on stream %s: %w", r.name, err)
+	}
+	return gax.Sleep(r.ctx, pause)
+}
+
+func (r *TableStreamReader) closeRowStream() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.rowStream, r.cancel = nil, nil
+}
+
+// Schema returns the schema of the rows of the stream.
+func (r *TableStreamReader) Schema() Schema {
+	return r.schema
+}
+
+// SerializedArrowSchema returns the serialized Arrow schema of the record
+// batches of the stream.
+func (r *TableStreamReader) SerializedArrowSchema() []byte {
+	return r.rawSchema
+}
+
+// Rows returns an iterator that decodes the rows of the stream. The reader
+// must not be used directly once Rows has been called.
+func (r *TableStreamReader) Rows() (*RowIterator, error) {
+	dec, err := newArrowDecoder(r.rawSchema, r.schema)
+	if err != nil {
+		return nil, err
+	}
+	it := &RowIterator{
+		ctx:           r.ctx,
+		arrowIterator: r,
+		arrowDecoder:  dec,
+		Schema:        r.schema,
+		rows:          [][]Value{},
+	}
+	it.nextFunc = nextFuncForStorageIterator(it)
+	it.pageInfo = &iterator.PageInfo{}
+	return it, nil
+}
+
+// selectSchemaFields returns the fields of schema named by selected, in the
+// order of schema, which is the order of the columns returned by the Storage
+// Read API. Nested fields are named with dot-separated paths. If selected is
+// empty, schema is returned.
+func selectSchemaFields(schema Schema, selected []string) (Schema, error) {
+	if len(selected) == 0 {
+		return schema, nil
+	}
+	// children maps the name of each selected top-level field to the paths of
+	// its selected subfields, or to nil if the whole field is selected.
+	children := map[string][]string{}
+	whole := map[string]bool{}
+	for _, path := range selected {
+		name, rest, nested := strings.Cut(path, ".")
+		key := strings.ToLower(name)
+		if !nested {
+			whole[key] = true
+			continue
+		}
+		children[key] = append(children[key], rest)
+	}
+	for key := range whole {
+		delete(children, key)
+	}
+	var out Schema
+	found := 0
+	for _, fs := range schema {
+		key := strings.ToLower(fs.Name)
+		sub, hasChildren := children[key]
+		switch {
+		case whole[key]:
+			out = append(out, fs)
+		case hasChildren:
+			if fs.Type != RecordFieldType {
+				return nil, fmt.Errorf("bigquery: selected field %s.%s: %s is not a RECORD", fs.Name, sub[0], fs.Name)
+			}
+			nested, err := selectSchemaFields(fs.Schema, sub)
+			if err != nil {
+				return nil, err
+			}
+			cp := *fs
+			cp.Schema = nested
+			out = append(out, &cp)
+		default:
+			continue
+		}
+		found++
+	}
+	if found != len(whole)+len(children) {
+		return nil, fmt.Errorf("bigquery: selected fields %q are not all in the table schema", selected)
+	}
+	return out, nil
+}
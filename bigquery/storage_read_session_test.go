@@ -0,0 +1,209 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bigquery
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigquery/storage/apiv1/storagepb"
+	"cloud.google.com/go/internal/testutil"
+	gax "github.com/googleapis/gax-go/v2"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestSelectSchemaFields(t *testing.T) {
+	schema := Schema{
+		{Name: "name", Type: StringFieldType},
+		{Name: "age", Type: IntegerFieldType},
+		{Name: "address", Type: RecordFieldType, Schema: Schema{
+			{Name: "city", Type: StringFieldType},
+			{Name: "zip", Type: StringFieldType},
+		}},
+	}
+	for _, tc := range []struct {
+		selected []string
+		want     Schema
+	}{
+		{nil, schema},
+		{[]string{"age", "NAME"}, Schema{schema[0], schema[1]}},
+		{[]string{"address.zip"}, Schema{{Name: "address", Type: RecordFieldType, Schema: Schema{schema[2].Schema[1]}}}},
+		{[]string{"address", "address.zip"}, Schema{schema[2]}},
+	} {
+		got, err := selectSchemaFields(schema, tc.selected)
+		if err != nil {
+			t.Fatalf("%v: %v", tc.selected, err)
+		}
+		if diff := testutil.Diff(got, tc.want); diff != "" {
+			t.Errorf("%v: got=-, want=+:\n%s", tc.selected, diff)
+		}
+	}
+	for _, selected := range [][]string{{"other"}, {"name.first"}, {"address.country"}} {
+		if _, err := selectSchemaFields(schema, selected); err == nil {
+			t.Errorf("%v: got nil error, want error", selected)
+		}
+	}
+}
+
+func TestNewCreateReadSessionRequest(t *testing.T) {
+	snapshot := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	got := newCreateReadSessionRequest("p", "projects/p/datasets/d/tables/t", &TableReadOptions{
+		SelectedFields: []string{"a"},
+		RowRestriction: "a > 1",
+		SnapshotTime:   snapshot,
+		MaxStreamCount: 10,
+		MinStreamCount: 2,
+	})
+	want := &storagepb.CreateReadSessionRequest{
+		Parent: "projects/p",
+		ReadSession: &storagepb.ReadSession{
+			Table:      "projects/p/datasets/d/tables/t",
+			DataFormat: storagepb.DataFormat_ARROW,
+			ReadOptions: &storagepb.ReadSession_TableReadOptions{
+				SelectedFields: []string{"a"},
+				RowRestriction: "a > 1",
+			},
+			TableModifiers: &storagepb.ReadSession_TableModifiers{
+				SnapshotTime: timestamppb.New(snapshot),
+			},
+		},
+		MaxStreamCount:          10,
+		PreferredMinStreamCount: 2,
+	}
+	if diff := testutil.Diff(got, want); diff != "" {
+		t.Errorf("got=-, want=+:\n%s", diff)
+	}
+}
+
+func TestTableReadSessionSplitStreams(t *testing.T) {
+	var splits int
+	split := func(_ context.Context, req *storagepb.SplitReadStreamRequest, _ ...gax.CallOption) (*storagepb.SplitReadStreamResponse, error) {
+		splits++
+		if req.Name == "small" {
+			return &storagepb.SplitReadStreamResponse{}, nil
+		}
+		return &storagepb.SplitReadStreamResponse{
+			PrimaryStream:   &storagepb.ReadStream{Name: req.Name + "/0"},
+			RemainderStream: &storagepb.ReadStream{Name: req.Name + "/1"},
+		}, nil
+	}
+	s := &TableReadSession{Streams: []*TableStreamReader{
+		{name: "s", splitReadStreamFunc: split},
+		{name: "small", splitReadStreamFunc: split},
+	}}
+	if err := s.splitStreams(context.Background(), 5); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range s.Streams {
+		got = append(got, r.Name())
+	}
+	want := []string{"s/0/0", "s/0/1", "s/1/0", "s/1/1", "small"}
+	if diff := testutil.Diff(got, want); diff != "" {
+		t.Errorf("got=-, want=+:\n%s", diff)
+	}
+
+	// Streams that cannot be split are left alone.
+	s = &TableReadSession{Streams: []*TableStreamReader{{name: "small", splitReadStreamFunc: split}}}
+	splits = 0
+	if err := s.splitStreams(context.Background(), 4); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Streams) != 1 || splits != 1 {
+		t.Errorf("got %d streams after %d splits, want 1 stream after 1 split", len(s.Streams), splits)
+	}
+}
+
+type fakeReadRowsClient struct {
+	storagepb.BigQueryRead_ReadRowsClient
+	responses []*storagepb.ReadRowsResponse
+	err       error
+}
+
+func (c *fakeReadRowsClient) Recv() (*storagepb.ReadRowsResponse, error) {
+	if len(c.responses) == 0 {
+		if c.err != nil {
+			return nil, c.err
+		}
+		return nil, io.EOF
+	}
+	r := c.responses[0]
+	c.responses = c.responses[1:]
+	return r, nil
+}
+
+func TestTableStreamReaderNext(t *testing.T) {
+	batch := func(n int64) *storagepb.ReadRowsResponse {
+		return &storagepb.ReadRowsResponse{
+			RowCount: n,
+			Rows: &storagepb.ReadRowsResponse_ArrowRecordBatch{
+				ArrowRecordBatch: &storagepb.ArrowRecordBatch{SerializedRecordBatch: []byte(fmt.Sprint(n))},
+			},
+		}
+	}
+	var offsets []int64
+	calls := 0
+	r := &TableStreamReader{
+		ctx:       context.Background(),
+		name:      "s",
+		rawSchema: []byte("schema"),
+		readRowsFunc: func(_ context.Context, req *storagepb.ReadRowsRequest, _ ...gax.CallOption) (storagepb.BigQueryRead_ReadRowsClient, error) {
+			offsets = append(offsets, req.Offset)
+			calls++
+			if calls == 1 {
+				// The first stream fails after one batch with a transient error.
+				return &fakeReadRowsClient{
+					responses: []*storagepb.ReadRowsResponse{batch(2)},
+					err:       status.Error(codes.Unavailable, "try again"),
+				}, nil
+			}
+			return &fakeReadRowsClient{responses: []*storagepb.ReadRowsResponse{batch(3)}}, nil
+		},
+	}
+	var got []string
+	for {
+		b, err := r.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, string(b.Data))
+	}
+	if diff := testutil.Diff(got, []string{"2", "3"}); diff != "" {
+		t.Errorf("batches: got=-, want=+:\n%s", diff)
+	}
+	if diff := testutil.Diff(offsets, []int64{0, 2}); diff != "" {
+		t.Errorf("offsets: got=-, want=+:\n%s", diff)
+	}
+
+	r = &TableStreamReader{
+		ctx:  context.Background(),
+		name: "s",
+		readRowsFunc: func(context.Context, *storagepb.ReadRowsRequest, ...gax.CallOption) (storagepb.BigQueryRead_ReadRowsClient, error) {
+			return nil, status.Error(codes.PermissionDenied, "denied")
+		},
+	}
+	if _, err := r.Next(); err == nil {
+		t.Error("got nil error for a permanent failure, want error")
+	}
+}
@@ -867,6 +867,18 @@ func (t *Table) Metadata(ctx context.Context, opts ...TableMetadataOption) (md *
 	ctx = trace.StartSpan(ctx, "cloud.google.com/go/bigquery.Table.Metadata")
 	defer func() { trace.EndSpan(ctx, err) }()
 
+	// Options change the returned metadata, so only calls without options
+	// use the cache.
+	cache := t.c.tableMetadataCache
+	if len(opts) > 0 {
+		cache = nil
+	}
+	if cache != nil {
+		if md, ok := cache.get(t.FullyQualifiedName(), t.c); ok {
+			return md, nil
+		}
+	}
+
 	tgc := &tableGetCall{
 		call: t.c.bqs.Tables.Get(t.ProjectID, t.DatasetID, t.TableID).Context(ctx),
 	}
@@ -885,7 +897,11 @@ func (t *Table) Metadata(ctx context.Context, opts ...TableMetadataOption) (md *
 	}); err != nil {
 		return nil, err
 	}
-	return bqToTableMetadata(res, t.c)
+	md, err = bqToTableMetadata(res, t.c)
+	if err == nil && cache != nil {
+		cache.put(t.FullyQualifiedName(), res)
+	}
+	return md, err
 }
 
 func bqToTableMetadata(t *bq.Table, c *Client) (*TableMetadata, error) {
@@ -956,6 +972,9 @@ func (t *Table) Delete(ctx context.Context) (err error) {
 	ctx = trace.StartSpan(ctx, "cloud.google.com/go/bigquery.Table.Delete")
 	defer func() { trace.EndSpan(ctx, err) }()
 
+	if t.c.tableMetadataCache != nil {
+		defer t.c.tableMetadataCache.invalidate(t.FullyQualifiedName())
+	}
 	call := t.c.bqs.Tables.Delete(t.ProjectID, t.DatasetID, t.TableID).Context(ctx)
 	setClientHeader(call.Header())
 
@@ -1033,6 +1052,9 @@ func (t *Table) Update(ctx context.Context, tm TableMetadataToUpdate, etag strin
 	}); err != nil {
 		return nil, err
 	}
+	if t.c.tableMetadataCache != nil {
+		t.c.tableMetadataCache.invalidate(t.FullyQualifiedName())
+	}
 	return bqToTableMetadata(res, t.c)
 }
 
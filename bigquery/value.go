@@ -16,6 +16,7 @@ package bigquery
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
@@ -37,6 +38,23 @@ type ValueLoader interface {
 	Load(v []Value, s Schema) error
 }
 
+// A FieldValueLoader is implemented by the types of struct fields that set
+// themselves from the values of a result row, instead of following the
+// column type correspondences documented at RowIterator.Next. It allows
+// custom conversions, for instance to decode a STRING column into an
+// enumeration.
+//
+// LoadFieldValue is called on a pointer to the field with the value of the
+// column, which is nil for NULL, and the schema of the column. For a repeated
+// column, it is called for each element when the element type of the slice or
+// array field implements FieldValueLoader, and with the []Value of the column
+// otherwise.
+type FieldValueLoader interface {
+	LoadFieldValue(v Value, fs *FieldSchema) error
+}
+
+var typeOfFieldValueLoader = reflect.TypeOf((*FieldValueLoader)(nil)).Elem()
+
 // valueList converts a []Value to implement ValueLoader.
 type valueList []Value
 
@@ -185,6 +203,29 @@ func setJSON(v reflect.Value, x interface{}) error {
 	return nil
 }
 
+// setJSONValue unmarshals the JSON text x into v.
+func setJSONValue(v reflect.Value, x interface{}) error {
+	if x == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	p := reflect.New(v.Type())
+	if err := json.Unmarshal([]byte(x.(string)), p.Interface()); err != nil {
+		return fmt.Errorf("bigquery: decoding JSON value into %s: %w", v.Type(), err)
+	}
+	v.Set(p.Elem())
+	return nil
+}
+
+// setPointee sets v, which is not a pointer, to the value pointed at by x.
+func setPointee(v reflect.Value, x interface{}) error {
+	if x == nil {
+		return errNoNulls
+	}
+	v.Set(reflect.ValueOf(x).Elem())
+	return nil
+}
+
 func setBytes(v reflect.Value, x interface{}) error {
 	if x == nil {
 		v.SetBytes(nil)
@@ -249,6 +290,11 @@ func compileToOps(structType reflect.Type, schema Schema) ([]structLoaderOp, err
 			valueIndex: i,
 		}
 		t := structField.Type
+		if setFunc := fieldValueLoaderSetFunc(t, schemaField); setFunc != nil {
+			op.setFunc = setFunc
+			ops = append(ops, op)
+			continue
+		}
 		if schemaField.Repeated {
 			if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
 				return nil, fmt.Errorf("bigquery: repeated schema field %s requires slice or array, but struct field %s has type %s",
@@ -257,7 +303,9 @@ func compileToOps(structType reflect.Type, schema Schema) ([]structLoaderOp, err
 			t = t.Elem()
 			op.repeated = true
 		}
-		if schemaField.Type == RecordFieldType {
+		if setFunc := fieldValueLoaderSetFunc(t, schemaField); setFunc != nil {
+			op.setFunc = setFunc
+		} else if schemaField.Type == RecordFieldType {
 			// Field can be a struct or a pointer to a struct.
 			if t.Kind() == reflect.Ptr {
 				t = t.Elem()
@@ -285,6 +333,17 @@ func compileToOps(structType reflect.Type, schema Schema) ([]structLoaderOp, err
 	return ops, nil
 }
 
+// fieldValueLoaderSetFunc returns a function that sets fields of type t with
+// their LoadFieldValue method, or nil if t doesn't implement FieldValueLoader.
+func fieldValueLoaderSetFunc(t reflect.Type, fs *FieldSchema) setFunc {
+	if !reflect.PtrTo(t).Implements(typeOfFieldValueLoader) {
+		return nil
+	}
+	return func(v reflect.Value, x interface{}) error {
+		return v.Addr().Interface().(FieldValueLoader).LoadFieldValue(x, fs)
+	}
+}
+
 // determineSetFunc chooses the best function for setting a field of type ftype
 // to a value whose schema field type is stype. It returns nil if stype
 // is not assignable to ftype.
@@ -327,6 +386,35 @@ func determineSetFunc(ftype reflect.Type, stype FieldType) setFunc {
 				})
 			}
 		}
+		if ftype.Kind() == reflect.Slice && ftype.Elem().Kind() == reflect.Uint8 {
+			// []byte and json.RawMessage receive the JSON text as is.
+			return func(v reflect.Value, x interface{}) error {
+				return setNull(v, x, func() interface{} {
+					return reflect.ValueOf([]byte(x.(string))).Convert(ftype).Interface()
+				})
+			}
+		}
+		return setJSONValue
+
+	case IntervalFieldType:
+		if ftype == typeOfIntervalValue {
+			return func(v reflect.Value, x interface{}) error {
+				return setNull(v, x, func() interface{} { return x.(*IntervalValue) })
+			}
+		}
+		if ftype == typeOfIntervalValue.Elem() {
+			return setPointee
+		}
+
+	case RangeFieldType:
+		if ftype == typeOfRangeValue {
+			return func(v reflect.Value, x interface{}) error {
+				return setNull(v, x, func() interface{} { return x.(*RangeValue) })
+			}
+		}
+		if ftype == typeOfRangeValue.Elem() {
+			return setPointee
+		}
 
 	case BytesFieldType:
 		if ftype == typeOfByteSlice {
@@ -879,7 +967,7 @@ func convertRow(r *bq.TableRow, schema Schema) ([]Value, error) {
 	var values []Value
 	for i, cell := range r.F {
 		fs := schema[i]
-		v, err := convertValue(cell.V, fs.Type, fs.Schema)
+		v, err := convertValue(cell.V, fs)
 		if err != nil {
 			return nil, err
 		}
@@ -888,27 +976,33 @@ func convertRow(r *bq.TableRow, schema Schema) ([]Value, error) {
 	return values, nil
 }
 
-func convertValue(val interface{}, typ FieldType, schema Schema) (Value, error) {
+func convertValue(val interface{}, fs *FieldSchema) (Value, error) {
 	switch val := val.(type) {
 	case nil:
 		return nil, nil
 	case []interface{}:
-		return convertRepeatedRecord(val, typ, schema)
+		return convertRepeatedRecord(val, fs)
 	case map[string]interface{}:
-		return convertNestedRecord(val, schema)
+		return convertNestedRecord(val, fs.Schema)
 	case string:
-		return convertBasicType(val, typ)
+		if fs.Type == RangeFieldType {
+			if fs.RangeElementType == nil {
+				return nil, errors.New("bigquery: RANGE field has no element type")
+			}
+			return parseRangeValue(val, fs.RangeElementType.Type)
+		}
+		return convertBasicType(val, fs.Type)
 	default:
-		return nil, fmt.Errorf("got value %v; expected a value of type %s", val, typ)
+		return nil, fmt.Errorf("got value %v; expected a value of type %s", val, fs.Type)
 	}
 }
 
-func convertRepeatedRecord(vals []interface{}, typ FieldType, schema Schema) (Value, error) {
+func convertRepeatedRecord(vals []interface{}, fs *FieldSchema) (Value, error) {
 	var values []Value
 	for _, cell := range vals {
 		// each cell contains a single entry, keyed by "v"
 		val := cell.(map[string]interface{})["v"]
-		v, err := convertValue(val, typ, schema)
+		v, err := convertValue(val, fs)
 		if err != nil {
 			return nil, err
 		}
@@ -931,7 +1025,7 @@ func convertNestedRecord(val map[string]interface{}, schema Schema) (Value, erro
 		// each cell contains a single entry, keyed by "v"
 		val := cell.(map[string]interface{})["v"]
 		fs := schema[i]
-		v, err := convertValue(val, fs.Type, fs.Schema)
+		v, err := convertValue(val, fs)
 		if err != nil {
 			return nil, err
 		}
@@ -16,11 +16,13 @@ package bigquery
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
 	"math/big"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -371,6 +373,32 @@ func TestRepeatedRecordContainingRecord(t *testing.T) {
 	}
 }
 
+func TestConvertRange(t *testing.T) {
+	schema := Schema{
+		{Name: "r", Type: RangeFieldType, RangeElementType: &RangeElementType{Type: DateFieldType}},
+		{Name: "rs", Type: RangeFieldType, Repeated: true, RangeElementType: &RangeElementType{Type: TimestampFieldType}},
+	}
+	row := &bq.TableRow{
+		F: []*bq.TableCell{
+			{V: "[2024-01-01, UNBOUNDED)"},
+			{V: []interface{}{
+				map[string]interface{}{"v": "[0, 1000000)"},
+			}},
+		},
+	}
+	got, err := convertRow(row, schema)
+	if err != nil {
+		t.Fatalf("error converting: %v", err)
+	}
+	want := []Value{
+		&RangeValue{Start: civil.Date{Year: 2024, Month: 1, Day: 1}},
+		[]Value{&RangeValue{Start: time.Unix(0, 0).UTC(), End: time.Unix(1, 0).UTC()}},
+	}
+	if !testutil.Equal(got, want) {
+		t.Errorf("converting RANGE values: got:%v, want:%v", got, want)
+	}
+}
+
 func TestConvertRowErrors(t *testing.T) {
 	// mismatched lengths
 	if _, err := convertRow(&bq.TableRow{F: []*bq.TableCell{{V: ""}}}, Schema{}); err == nil {
@@ -1167,6 +1195,82 @@ func TestStructLoaderFieldOverlap(t *testing.T) {
 	}
 }
 
+// upperString is a FieldValueLoader that uppercases STRING values.
+type upperString string
+
+func (u *upperString) LoadFieldValue(v Value, fs *FieldSchema) error {
+	if v == nil {
+		*u = ""
+		return nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("unexpected value %v for column %s", v, fs.Name)
+	}
+	*u = upperString(strings.ToUpper(s))
+	return nil
+}
+
+func TestStructLoaderNewTypes(t *testing.T) {
+	schema := Schema{
+		{Name: "json_str", Type: JSONFieldType},
+		{Name: "json_raw", Type: JSONFieldType},
+		{Name: "json_obj", Type: JSONFieldType},
+		{Name: "json_null", Type: JSONFieldType},
+		{Name: "interval", Type: IntervalFieldType},
+		{Name: "interval_val", Type: IntervalFieldType},
+		{Name: "range", Type: RangeFieldType, RangeElementType: &RangeElementType{Type: DateFieldType}},
+		{Name: "range_val", Type: RangeFieldType, RangeElementType: &RangeElementType{Type: DateFieldType}},
+		{Name: "custom", Type: StringFieldType},
+		{Name: "customs", Type: StringFieldType, Repeated: true},
+	}
+	type obj struct {
+		A int    `json:"a"`
+		B string `json:"b"`
+	}
+	type row struct {
+		JSONStr     string          `bigquery:"json_str"`
+		JSONRaw     json.RawMessage `bigquery:"json_raw"`
+		JSONObj     obj             `bigquery:"json_obj"`
+		JSONNull    *obj            `bigquery:"json_null"`
+		Interval    *IntervalValue  `bigquery:"interval"`
+		IntervalVal IntervalValue   `bigquery:"interval_val"`
+		Range       *RangeValue     `bigquery:"range"`
+		RangeVal    RangeValue      `bigquery:"range_val"`
+		Custom      upperString
+		Customs     []upperString
+	}
+	iv := &IntervalValue{Years: 1, Days: 2}
+	rv := &RangeValue{Start: testDate, End: nil}
+	vals := []Value{
+		`{"a":1}`, `{"a":2}`, `{"a":3,"b":"x"}`, nil,
+		iv, iv, rv, rv,
+		"abc", []Value{"x", "y"},
+	}
+	var got row
+	mustLoad(t, &got, schema, vals)
+	want := row{
+		JSONStr:     `{"a":1}`,
+		JSONRaw:     json.RawMessage(`{"a":2}`),
+		JSONObj:     obj{A: 3, B: "x"},
+		Interval:    iv,
+		IntervalVal: *iv,
+		Range:       rv,
+		RangeVal:    *rv,
+		Custom:      "ABC",
+		Customs:     []upperString{"X", "Y"},
+	}
+	if diff := testutil.Diff(got, want); diff != "" {
+		t.Errorf("-got +want:\n%s", diff)
+	}
+
+	// NULL can't be assigned to IntervalValue and RangeValue fields.
+	vals[5] = nil
+	if err := load(&got, schema, vals); err == nil {
+		t.Error("loading NULL into IntervalValue: got nil, want error")
+	}
+}
+
 func TestStructLoaderErrors(t *testing.T) {
 	check := func(sp interface{}) {
 		var sl structLoader
@@ -0,0 +1,206 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AdaptiveTimeouts configures deadlines for the first attempt of some data
+// operations, derived from the recent latencies of their successful
+// attempts, so that the slowest attempts are abandoned and retried instead
+// of waited for. Only operations that are retried anyway get such a
+// deadline: Apply with a mutation that is retryable, as it is when it sets
+// no cell with ServerTime, and the reads of a single row, as with ReadRow.
+// Later attempts, if any, have no deadline but that of their context.
+//
+// The zero value of each field selects its default.
+//
+// AdaptiveTimeouts is EXPERIMENTAL and subject to change or removal without
+// notice.
+type AdaptiveTimeouts struct {
+	// Percentile is the percentile of the latencies, between 0 and 1, from
+	// which the deadline is derived. The default is 0.99.
+	Percentile float64
+
+	// Multiplier is the ratio of the deadline to the percentile latency. The
+	// default is 3.
+	Multiplier float64
+
+	// MinTimeout is the shortest deadline. The default is 10ms.
+	MinTimeout time.Duration
+
+	// MinSamples is the number of latencies that must have been observed in
+	// the window before attempts get a deadline. The default is 100.
+	MinSamples int
+
+	// Window is the period of the latencies from which the percentile is
+	// computed. The default is a minute. The deadlines are recomputed at most
+	// every tenth of the window.
+	Window time.Duration
+}
+
+// adaptiveTimeouts tracks the latencies of the attempts of operations and
+// sets the deadlines of their first attempts.
+type adaptiveTimeouts struct {
+	cfg       AdaptiveTimeouts
+	latencies *statsRegistry // keyed by operation
+	ops       sync.Map       // of operation name to *adaptiveOp
+	disabled  atomic.Bool
+}
+
+// adaptiveOp holds the latencies of an operation and the deadline of its
+// first attempts derived from them, which is refreshed at most once per slot
+// of the latencies.
+type adaptiveOp struct {
+	ts      *tableStats
+	slot    atomic.Int64 // the slot of timeout plus one, or 0 before the first refresh
+	timeout atomic.Int64 // 0 if there are too few latencies
+}
+
+func newAdaptiveTimeouts(cfg AdaptiveTimeouts) *adaptiveTimeouts {
+	if cfg.Percentile <= 0 || cfg.Percentile >= 1 {
+		cfg.Percentile = 0.99
+	}
+	if cfg.Multiplier <= 0 {
+		cfg.Multiplier = 3
+	}
+	if cfg.MinTimeout <= 0 {
+		cfg.MinTimeout = 10 * time.Millisecond
+	}
+	if cfg.MinSamples <= 0 {
+		cfg.MinSamples = 100
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = time.Minute
+	}
+	return &adaptiveTimeouts{cfg: cfg, latencies: newStatsRegistry(cfg.Window)}
+}
+
+// SetAdaptiveTimeouts turns the adaptive deadlines of a client created with
+// ClientConfig.AdaptiveTimeouts off or back on, for example as a kill switch
+// when they cause too many retries. Latencies are still tracked while they
+// are off. It has no effect on other clients.
+//
+// It is EXPERIMENTAL and subject to change or removal without notice.
+func (c *Client) SetAdaptiveTimeouts(enabled bool) {
+	if c.adaptive != nil {
+		c.adaptive.disabled.Store(!enabled)
+	}
+}
+
+// op returns the adaptiveOp of the operation named name.
+func (a *adaptiveTimeouts) op(name string) *adaptiveOp {
+	if op, ok := a.ops.Load(name); ok {
+		return op.(*adaptiveOp)
+	}
+	op, _ := a.ops.LoadOrStore(name, &adaptiveOp{ts: a.latencies.table(name)})
+	return op.(*adaptiveOp)
+}
+
+// start starts an attempt of the operation named name, and returns its
+// context, with an adaptive deadline if deadline is true and enough
+// latencies are known. The returned attempt must be ended with done. It is
+// nil if a is nil.
+func (a *adaptiveTimeouts) start(ctx context.Context, name string, deadline bool) (context.Context, *adaptiveAttempt) {
+	if a == nil {
+		return ctx, nil
+	}
+	op := a.op(name)
+	at := &adaptiveAttempt{ts: op.ts, start: time.Now(), cancel: func() {}}
+	if timeout, ok := a.timeout(op); ok && deadline {
+		ctx, at.cancel = context.WithCancel(ctx)
+		at.timer = time.AfterFunc(timeout, func() {
+			at.expired.Store(true)
+			at.cancel()
+		})
+	}
+	return ctx, at
+}
+
+// timeout returns the deadline of a first attempt of op, if adaptive
+// deadlines apply.
+func (a *adaptiveTimeouts) timeout(op *adaptiveOp) (time.Duration, bool) {
+	if a.disabled.Load() {
+		return 0, false
+	}
+	slot := op.ts.now().UnixNano()/int64(op.ts.slot) + 1
+	if prev := op.slot.Load(); prev != slot && op.slot.CompareAndSwap(prev, slot) {
+		var timeout time.Duration
+		if q, n := op.ts.latencyQuantile(a.cfg.Percentile); n >= int64(a.cfg.MinSamples) {
+			timeout = time.Duration(float64(q) * a.cfg.Multiplier)
+			if timeout < a.cfg.MinTimeout {
+				timeout = a.cfg.MinTimeout
+			}
+		}
+		op.timeout.Store(int64(timeout))
+	}
+	timeout := time.Duration(op.timeout.Load())
+	return timeout, timeout > 0
+}
+
+// An adaptiveAttempt is an attempt of an operation started by
+// adaptiveTimeouts.start. Its methods do nothing on a nil attempt.
+type adaptiveAttempt struct {
+	ts      *tableStats
+	start   time.Time
+	cancel  context.CancelFunc
+	timer   *time.Timer // nil without deadline
+	expired atomic.Bool
+	stopped bool
+	latency time.Duration // set by stop
+}
+
+// stop ends the deadline and the latency of the attempt, for example before
+// results are passed to a callback of the user, whose time must count for
+// neither.
+func (at *adaptiveAttempt) stop() {
+	if at == nil || at.stopped {
+		return
+	}
+	at.stopped = true
+	at.latency = time.Since(at.start)
+	if at.timer != nil {
+		at.timer.Stop()
+	}
+}
+
+// done ends the attempt with its error err, and returns the error of the
+// attempt: a DeadlineExceeded error if the attempt was abandoned at its
+// deadline, or else err.
+func (at *adaptiveAttempt) done(err error) error {
+	if at == nil {
+		return err
+	}
+	at.stop()
+	at.cancel()
+	if err == nil {
+		at.ts.record(at.latency, 1, nil)
+		return nil
+	}
+	if at.expired.Load() && (status.Code(err) == codes.Canceled || errors.Is(err, context.Canceled)) {
+		return status.Errorf(codes.DeadlineExceeded, "bigtable: attempt abandoned after its adaptive deadline: %v", err)
+	}
+	return err
+}
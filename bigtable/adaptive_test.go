@@ -0,0 +1,158 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+func TestAdaptiveTimeout(t *testing.T) {
+	now := time.Date(2024, 6, 15, 10, 0, 0, 0, time.UTC)
+	a := newAdaptiveTimeouts(AdaptiveTimeouts{})
+	op := a.op("MutateRow")
+	op.ts.now = func() time.Time { return now }
+
+	if _, ok := a.timeout(op); ok {
+		t.Error("got a timeout without latencies")
+	}
+	for i := 0; i < 100; i++ {
+		op.ts.record(10*time.Millisecond, 1, nil)
+	}
+	if _, ok := a.timeout(op); ok {
+		t.Error("got a timeout refreshed within the slot")
+	}
+	now = now.Add(a.latencies.slot)
+	timeout, ok := a.timeout(op)
+	if !ok || timeout < 27*time.Millisecond || timeout > 33*time.Millisecond {
+		t.Errorf("got timeout (%v, %t), want about 30ms", timeout, ok)
+	}
+
+	c := &Client{adaptive: a}
+	c.SetAdaptiveTimeouts(false)
+	if _, ok := a.timeout(op); ok {
+		t.Error("got a timeout while disabled")
+	}
+	c.SetAdaptiveTimeouts(true)
+	if _, ok := a.timeout(op); !ok {
+		t.Error("got no timeout once enabled again")
+	}
+}
+
+func TestAdaptiveTimeoutRetriesSlowAttempt(t *testing.T) {
+	ctx := context.Background()
+	var calls, slow int32
+	delay := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.CompareAndSwapInt32(&slow, 1, 0) {
+			select {
+			case <-time.After(5 * time.Second):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		return handler(ctx, req)
+	}
+	tbl, cleanup, err := setupFakeServer(grpc.UnaryInterceptor(delay))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	tbl.c.adaptive = newAdaptiveTimeouts(AdaptiveTimeouts{MinSamples: 5, MinTimeout: 50 * time.Millisecond})
+	now := time.Now()
+	tbl.c.adaptive.op("MutateRow").ts.now = func() time.Time { return now }
+
+	mut := NewMutation()
+	mut.Set("cf", "col", 1000, []byte("v"))
+	for i := 0; i < 5; i++ {
+		if err := tbl.Apply(ctx, "row", mut); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now = now.Add(tbl.c.adaptive.latencies.slot)
+	atomic.StoreInt32(&calls, 0)
+	atomic.StoreInt32(&slow, 1)
+	start := time.Now()
+	if err := tbl.Apply(ctx, "row", mut); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Apply took %v, want the slow attempt abandoned early", elapsed)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("got %d attempts, want 2", got)
+	}
+}
+
+func TestAdaptiveTimeoutExcludesReadRowCallback(t *testing.T) {
+	ctx := context.Background()
+	var calls int32
+	count := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		atomic.AddInt32(&calls, 1)
+		return handler(srv, ss)
+	}
+	tbl, cleanup, err := setupFakeServer(grpc.StreamInterceptor(count))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	tbl.c.adaptive = newAdaptiveTimeouts(AdaptiveTimeouts{MinSamples: 5, MinTimeout: 50 * time.Millisecond})
+	now := time.Now()
+	op := tbl.c.adaptive.op("ReadRow")
+	op.ts.now = func() time.Time { return now }
+
+	mut := NewMutation()
+	mut.Set("cf", "col", 1000, []byte("v"))
+	if err := tbl.Apply(ctx, "row", mut); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := tbl.ReadRow(ctx, "row"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Scans are not tracked.
+	if err := tbl.ReadRows(ctx, InfiniteRange(""), func(Row) bool { return true }); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(tbl.c.adaptive.latencies.slot)
+	if _, ok := tbl.c.adaptive.timeout(op); !ok {
+		t.Fatal("got no timeout after reading rows")
+	}
+
+	atomic.StoreInt32(&calls, 0)
+	err = tbl.ReadRows(ctx, RowList{"row"}, func(Row) bool {
+		time.Sleep(200 * time.Millisecond)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("got %d attempts, want 1", got)
+	}
+	if _, n := op.ts.latencyQuantile(0.5); n != 6 {
+		t.Errorf("got %d ReadRow latencies, want 6", n)
+	}
+	if q, _ := op.ts.latencyQuantile(1); q >= 200*time.Millisecond {
+		t.Errorf("got maximum ReadRow latency %v, want the callback excluded", q)
+	}
+}
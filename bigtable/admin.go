@@ -505,6 +505,9 @@ func (ac *AdminClient) SetGCPolicy(ctx context.Context, table, family string, po
 }
 
 // DropRowRange permanently deletes a row range from the specified table.
+// It is not available on replicated tables, for which it returns an error
+// matching ErrReplicatedTable. See StartDropRowRange for a variant that
+// checks the prefix and does not block until the rows are deleted.
 func (ac *AdminClient) DropRowRange(ctx context.Context, table, rowKeyPrefix string) error {
 	ctx = mergeOutgoingMetadata(ctx, ac.md)
 	prefix := ac.instancePrefix()
@@ -513,7 +516,7 @@ func (ac *AdminClient) DropRowRange(ctx context.Context, table, rowKeyPrefix str
 		Target: &btapb.DropRowRangeRequest_RowKeyPrefix{RowKeyPrefix: []byte(rowKeyPrefix)},
 	}
 	_, err := ac.tClient.DropRowRange(ctx, req)
-	return err
+	return ac.dropRowRangeError(ctx, table, err)
 }
 
 // DropAllRows permanently deletes all rows from the specified table.
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io"
 	"net/url"
+	"os"
 	"strconv"
 	"time"
 
@@ -34,6 +35,7 @@ import (
 	btpb "google.golang.org/genproto/googleapis/bigtable/v2"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
@@ -55,6 +57,22 @@ type Client struct {
 	client            btpb.BigtableClient
 	project, instance string
 	appProfile        string
+
+	minDeadline        time.Duration
+	minDeadlineLogOnly bool
+
+	slowOperationThreshold time.Duration
+	slowOperationFunc      func(SlowOperation)
+	logger                 Logger
+
+	// callOpts are added to the RPCs of data operations.
+	callOpts []grpc.CallOption
+
+	// stats, if not nil, holds the stats of the tables.
+	stats *statsRegistry
+
+	// adaptive, if not nil, sets the adaptive deadlines of attempts.
+	adaptive *adaptiveTimeouts
 }
 
 // ClientConfig has configurations for the client.
@@ -62,6 +80,60 @@ type ClientConfig struct {
 	// The id of the app profile to associate with all data operations sent from this client.
 	// If unspecified, the default app profile for the instance will be used.
 	AppProfile string
+
+	// MinDeadline, if positive, is the minimum time that must remain until the
+	// deadline of the context of a data operation. Operations with less time
+	// remaining fail with a DeadlineExceeded error before any RPC is sent.
+	MinDeadline time.Duration
+
+	// MinDeadlineLogOnly makes operations with less than MinDeadline
+	// remaining until their deadline be logged to Logger and sent anyway,
+	// rather than rejected.
+	MinDeadlineLogOnly bool
+
+	// SlowOperationFunc, if not nil, is called after each data operation
+	// that takes at least SlowOperationThreshold, including its retries,
+	// to log or otherwise report it. It is called synchronously, before the
+	// operation returns.
+	SlowOperationFunc func(SlowOperation)
+
+	// SlowOperationThreshold is the latency from which operations are
+	// reported to SlowOperationFunc.
+	SlowOperationThreshold time.Duration
+
+	// Logger, if not nil, receives debug messages about retries and resumed
+	// streams of data operations, and about operations sent with less than
+	// MinDeadline remaining in MinDeadlineLogOnly mode.
+	Logger Logger
+
+	// TableStatsWindow, if positive, makes the client keep statistics of the
+	// data operations of each table over a sliding window of that length,
+	// which Table.Stats returns.
+	TableStatsWindow time.Duration
+
+	// AdaptiveTimeouts, if not nil, enables deadlines for the first attempt
+	// of some operations derived from the recent latencies of the client.
+	// Client.SetAdaptiveTimeouts turns them off.
+	//
+	// It is EXPERIMENTAL and subject to change or removal without notice.
+	AdaptiveTimeouts *AdaptiveTimeouts
+
+	// EmulatorHost, if not empty, is the address of a Bigtable emulator to
+	// connect to, as with the BIGTABLE_EMULATOR_HOST environment variable,
+	// which it overrides. The connection is insecure, and no credentials,
+	// mTLS endpoint or DirectPath are used.
+	EmulatorHost string
+
+	// RequestCompressor, if not empty, is the name of the gRPC compressor
+	// with which the requests of data operations are compressed, such as
+	// "gzip". It must be registered with encoding.RegisterCompressor, as
+	// importing google.golang.org/grpc/encoding/gzip does for gzip.
+	//
+	// Responses are compressed at the discretion of the server, which gRPC
+	// tells about all the compressors registered in the process. Registering
+	// a compressor is thus enough to accept responses compressed with it,
+	// without compressing requests.
+	RequestCompressor string
 }
 
 // NewClient creates a new Client for a given project and instance.
@@ -72,9 +144,34 @@ func NewClient(ctx context.Context, project, instance string, opts ...option.Cli
 
 // NewClientWithConfig creates a new client with the given config.
 func NewClientWithConfig(ctx context.Context, project, instance string, config ClientConfig, opts ...option.ClientOption) (*Client, error) {
-	o, err := btopt.DefaultClientOptions(prodAddr, mtlsProdAddr, Scope, clientUserAgent)
-	if err != nil {
-		return nil, err
+	emulator := config.EmulatorHost
+	if emulator == "" {
+		emulator = os.Getenv("BIGTABLE_EMULATOR_HOST")
+	}
+	var o []option.ClientOption
+	if emulator != "" {
+		o = btopt.EmulatorClientOptions(emulator)
+	} else {
+		var err error
+		o, err = btopt.DefaultClientOptions(prodAddr, mtlsProdAddr, Scope, clientUserAgent)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var stats *statsRegistry
+	if config.TableStatsWindow > 0 {
+		stats = newStatsRegistry(config.TableStatsWindow)
+	}
+	var adaptive *adaptiveTimeouts
+	if config.AdaptiveTimeouts != nil {
+		adaptive = newAdaptiveTimeouts(*config.AdaptiveTimeouts)
+	}
+	var callOpts []grpc.CallOption
+	if name := config.RequestCompressor; name != "" {
+		if encoding.GetCompressor(name) == nil {
+			return nil, fmt.Errorf("bigtable: no gRPC compressor registered with name %q", name)
+		}
+		callOpts = append(callOpts, grpc.UseCompressor(name))
 	}
 	// Add gRPC client interceptors to supply Google client information. No external interceptors are passed.
 	o = append(o, btopt.ClientInterceptorOptions(nil, nil)...)
@@ -87,7 +184,9 @@ func NewClientWithConfig(ctx context.Context, project, instance string, config C
 	)
 	// Attempts direct access to spanner service over gRPC to improve throughput,
 	// whether the attempt is allowed is totally controlled by service owner.
-	o = append(o, internaloption.EnableDirectPath(true))
+	if emulator == "" {
+		o = append(o, internaloption.EnableDirectPath(true))
+	}
 	o = append(o, opts...)
 	connPool, err := gtransport.DialPool(ctx, o...)
 	if err != nil {
@@ -100,9 +199,25 @@ func NewClientWithConfig(ctx context.Context, project, instance string, config C
 		project:    project,
 		instance:   instance,
 		appProfile: config.AppProfile,
+
+		minDeadline:        config.MinDeadline,
+		minDeadlineLogOnly: config.MinDeadlineLogOnly,
+
+		slowOperationThreshold: config.SlowOperationThreshold,
+		slowOperationFunc:      config.SlowOperationFunc,
+		logger:                 config.Logger,
+		callOpts:               callOpts,
+		stats:                  stats,
+		adaptive:               adaptive,
 	}, nil
 }
 
+// rpcOptions returns the call options of an RPC of a data operation, given
+// those of the operation.
+func (c *Client) rpcOptions(opts ...grpc.CallOption) []grpc.CallOption {
+	return append(opts, c.callOpts...)
+}
+
 // Close closes the Client.
 func (c *Client) Close() error {
 	return c.connPool.Close()
@@ -132,8 +247,8 @@ func (c *Client) fullTableName(table string) string {
 	return fmt.Sprintf("projects/%s/instances/%s/tables/%s", c.project, c.instance, table)
 }
 
-func (c *Client) requestParamsHeaderValue(table string) string {
-	return fmt.Sprintf("table_name=%s&app_profile_id=%s", url.QueryEscape(c.fullTableName(table)), url.QueryEscape(c.appProfile))
+func (c *Client) requestParamsHeaderValue(table, appProfile string) string {
+	return fmt.Sprintf("table_name=%s&app_profile_id=%s", url.QueryEscape(c.fullTableName(table)), url.QueryEscape(appProfile))
 }
 
 // mergeOutgoingMetadata returns a context populated by the existing outgoing
@@ -149,8 +264,14 @@ func mergeOutgoingMetadata(ctx context.Context, mds ...metadata.MD) context.Cont
 //
 // A Table is safe to use concurrently.
 type Table struct {
-	c     *Client
-	table string
+	c          *Client
+	table      string
+	appProfile string
+
+	// Defaults set by TableOptions.
+	filter   Filter
+	readOpts []ReadOption
+	dedup    *writeDeduper
 
 	// Metadata to be sent with each request.
 	md metadata.MD
@@ -158,16 +279,64 @@ type Table struct {
 
 // Open opens a table.
 func (c *Client) Open(table string) *Table {
-	return &Table{
-		c:     c,
-		table: table,
-		md: metadata.Join(metadata.Pairs(
-			resourcePrefixHeader, c.fullTableName(table),
-			requestParamsHeader, c.requestParamsHeaderValue(table),
-		), btopt.WithFeatureFlags()),
+	return c.OpenTable(table)
+}
+
+// OpenTable opens a table with the given options, which set defaults for
+// all the operations on the returned Table.
+func (c *Client) OpenTable(table string, opts ...TableOption) *Table {
+	t := &Table{
+		c:          c,
+		table:      table,
+		appProfile: c.appProfile,
+	}
+	for _, o := range opts {
+		o.setTable(t)
 	}
+	t.md = metadata.Join(metadata.Pairs(
+		resourcePrefixHeader, c.fullTableName(table),
+		requestParamsHeader, c.requestParamsHeaderValue(table, t.appProfile),
+	), btopt.WithFeatureFlags())
+	return t
+}
+
+// A TableOption is an optional argument to OpenTable.
+type TableOption interface {
+	setTable(t *Table)
 }
 
+// TableAppProfile returns a TableOption that sends the operations on the
+// table with the given app profile, instead of the one of the client.
+func TableAppProfile(appProfile string) TableOption { return tableAppProfile(appProfile) }
+
+type tableAppProfile string
+
+func (o tableAppProfile) setTable(t *Table) { t.appProfile = string(o) }
+
+// TableRowFilter returns a TableOption that applies f to the contents of all
+// the rows read from the table. The filter of a RowFilter read option is
+// chained after f, so it further limits the cells returned.
+//
+// For example, LatestNFilter(1) makes reads return only the latest cell of
+// each column.
+func TableRowFilter(f Filter) TableOption { return tableRowFilter{f} }
+
+type tableRowFilter struct{ f Filter }
+
+func (o tableRowFilter) setTable(t *Table) { t.filter = o.f }
+
+// TableReadOptions returns a TableOption that applies opts to all the reads
+// from the table, before the options passed to the read. For example,
+// a default LimitRows is overridden by a LimitRows passed to ReadRows.
+//
+// Use TableRowFilter rather than a RowFilter option for a default filter,
+// which a RowFilter passed to a read would replace.
+func TableReadOptions(opts ...ReadOption) TableOption { return tableReadOptions(opts) }
+
+type tableReadOptions []ReadOption
+
+func (o tableReadOptions) setTable(t *Table) { t.readOpts = append(t.readOpts, o...) }
+
 // TODO(dsymonds): Read method that returns a sequence of ReadItems.
 
 // ReadRows reads rows from a table. f is called for each row.
@@ -181,9 +350,33 @@ func (t *Table) ReadRows(ctx context.Context, arg RowSet, f func(Row) bool, opts
 	ctx = trace.StartSpan(ctx, "cloud.google.com/go/bigtable.ReadRows")
 	defer func() { trace.EndSpan(ctx, err) }()
 
+	if err := t.c.checkDeadline(ctx, "ReadRows"); err != nil {
+		return err
+	}
+	// arg is narrowed by retries; report the rows requested.
+	requested := arg
+	op := t.startOperation("ReadRows", func() string { return summarizeRowSet(requested) })
+	defer func() { op.end(err) }()
+	if len(t.readOpts) > 0 {
+		opts = append(append([]ReadOption(nil), t.readOpts...), opts...)
+	}
+
 	var prevRowKey string
 	attrMap := make(map[string]interface{})
-	err = gax.Invoke(ctx, func(ctx context.Context, _ gax.CallSettings) error {
+	// Only the reads of a single row are tracked and get adaptive deadlines,
+	// since the latency of other reads depends on the number of rows they
+	// return. The time of the callbacks counts for neither.
+	rl, single := requested.(RowList)
+	single = single && len(rl) == 1
+	first := true
+	err = gax.Invoke(ctx, func(ctx context.Context, _ gax.CallSettings) (err error) {
+		t.c.traceAttempt(ctx, "ReadRows")
+		var at *adaptiveAttempt
+		if single {
+			ctx, at = t.c.adaptive.start(ctx, "ReadRow", first)
+			defer func() { err = at.done(err) }()
+		}
+		first = false
 		if !arg.valid() {
 			// Empty row set, no need to make an API call.
 			// NOTE: we must return early if arg == RowList{} because reading
@@ -192,18 +385,21 @@ func (t *Table) ReadRows(ctx context.Context, arg RowSet, f func(Row) bool, opts
 		}
 		req := &btpb.ReadRowsRequest{
 			TableName:    t.c.fullTableName(t.table),
-			AppProfileId: t.c.appProfile,
+			AppProfileId: t.appProfile,
 			Rows:         arg.proto(),
 		}
 		settings := makeReadSettings(req)
 		for _, opt := range opts {
 			opt.set(&settings)
 		}
+		if t.filter != nil {
+			req.Filter = chainFilterProtos(t.filter.proto(), req.Filter)
+		}
 		ctx, cancel := context.WithCancel(ctx) // for aborting the stream
 		defer cancel()
 
 		startTime := time.Now()
-		stream, err := t.c.client.ReadRows(ctx, req)
+		stream, err := t.c.client.ReadRows(ctx, req, t.c.rpcOptions(op.attempt())...)
 		if err != nil {
 			return err
 		}
@@ -214,6 +410,10 @@ func (t *Table) ReadRows(ctx context.Context, arg RowSet, f func(Row) bool, opts
 		} else {
 			cr = newChunkReader()
 		}
+		if settings.columnsFunc != nil {
+			cr.cols = &RowColumns{}
+		}
+		cr.pooled = settings.pooledValues
 
 		for {
 			res, err := stream.Recv()
@@ -227,6 +427,9 @@ func (t *Table) ReadRows(ctx context.Context, arg RowSet, f func(Row) bool, opts
 				} else {
 					arg = arg.retainRowsAfter(prevRowKey)
 				}
+				if prevRowKey != "" {
+					t.c.debugf(ctx, "bigtable: ReadRows: stream reset after row %q: %v", prevRowKey, err)
+				}
 				attrMap["rowKey"] = prevRowKey
 				attrMap["error"] = err.Error()
 				attrMap["time_secs"] = time.Since(startTime).Seconds()
@@ -238,16 +441,34 @@ func (t *Table) ReadRows(ctx context.Context, arg RowSet, f func(Row) bool, opts
 			trace.TracePrintf(ctx, attrMap, "Details in ReadRows")
 
 			for _, cc := range res.Chunks {
-				row, err := cr.Process(cc)
-				if err != nil {
-					// No need to prepare for a retry, this is an unretryable error.
-					return err
-				}
-				if row == nil {
-					continue
+				var more bool
+				if settings.columnsFunc != nil {
+					cols, err := cr.ProcessColumns(cc)
+					if err != nil {
+						// No need to prepare for a retry, this is an unretryable error.
+						return err
+					}
+					if cols == nil {
+						continue
+					}
+					prevRowKey = cols.Key
+					at.stop()
+					more = settings.columnsFunc(cols)
+				} else {
+					row, err := cr.Process(cc)
+					if err != nil {
+						// No need to prepare for a retry, this is an unretryable error.
+						return err
+					}
+					if row == nil {
+						continue
+					}
+					prevRowKey = row.Key()
+					at.stop()
+					more = f(row)
 				}
-				prevRowKey = row.Key()
-				if !f(row) {
+				cr.release()
+				if !more {
 					// Cancel and drain stream.
 					cancel()
 					for {
@@ -262,6 +483,9 @@ func (t *Table) ReadRows(ctx context.Context, arg RowSet, f func(Row) bool, opts
 
 			if res.LastScannedRowKey != nil {
 				prevRowKey = string(res.LastScannedRowKey)
+				if settings.scanProgressFunc != nil {
+					settings.scanProgressFunc(ScanProgress{LastScannedRowKey: prevRowKey})
+				}
 			}
 
 			// Handle any incoming RequestStats. This should happen at most once.
@@ -276,12 +500,12 @@ func (t *Table) ReadRows(ctx context.Context, arg RowSet, f func(Row) bool, opts
 			}
 		}
 		return err
-	}, retryOptions...)
+	}, t.c.withRetryLogging(ctx, "ReadRows", withCallOptions(retryOptions, opts))...)
 
 	// Convert error to grpc status error
 	if err != nil {
 		if errStatus, ok := status.FromError(err); ok {
-			return status.Error(errStatus.Code(), errStatus.Message())
+			return wrapError(errStatus.Err(), t.c.fullTableName(t.table))
 		}
 
 		ctxStatus := status.FromContextError(err)
@@ -298,7 +522,9 @@ func (t *Table) ReadRows(ctx context.Context, arg RowSet, f func(Row) bool, opts
 func (t *Table) ReadRow(ctx context.Context, row string, opts ...ReadOption) (Row, error) {
 	var r Row
 
-	opts = append([]ReadOption{LimitRows(1)}, opts...)
+	// The row outlives the call of the function, so its values can't be
+	// pooled.
+	opts = append(append([]ReadOption{LimitRows(1)}, opts...), pooledValues(false))
 	err := t.ReadRows(ctx, SingleRow(row), func(rr Row) bool {
 		r = rr
 		return true
@@ -723,10 +949,13 @@ type FullReadStatsFunc func(*FullReadStats)
 type readSettings struct {
 	req               *btpb.ReadRowsRequest
 	fullReadStatsFunc FullReadStatsFunc
+	columnsFunc       func(*RowColumns) bool
+	pooledValues      bool
+	scanProgressFunc  func(ScanProgress)
 }
 
 func makeReadSettings(req *btpb.ReadRowsRequest) readSettings {
-	return readSettings{req, nil}
+	return readSettings{req: req}
 }
 
 // A ReadOption is an optional argument to ReadRows.
@@ -734,6 +963,34 @@ type ReadOption interface {
 	set(settings *readSettings)
 }
 
+// An OperationOption is an optional argument to both ReadRows and Apply.
+type OperationOption interface {
+	ReadOption
+	ApplyOption
+}
+
+// WithCallOptions returns an option that passes opts to gax.Invoke for the
+// operation, after the call options of the client, which they override. For
+// example, a gax.WithRetry option replaces the retry policy of the client.
+func WithCallOptions(opts ...gax.CallOption) OperationOption { return gaxCallOptions(opts) }
+
+type gaxCallOptions []gax.CallOption
+
+func (gaxCallOptions) set(settings *readSettings) {}
+
+func (gaxCallOptions) after(res proto.Message) {}
+
+// withCallOptions returns defaults followed by the call options of opts.
+func withCallOptions[O any](defaults []gax.CallOption, opts []O) []gax.CallOption {
+	res := defaults
+	for _, o := range opts {
+		if co, ok := any(o).(gaxCallOptions); ok {
+			res = append(res[:len(res):len(res)], co...)
+		}
+	}
+	return res
+}
+
 // RowFilter returns a ReadOption that applies f to the contents of read rows.
 //
 // If multiple RowFilters are provided, only the last is used. To combine filters,
@@ -764,6 +1021,52 @@ func (wrs withFullReadStats) set(settings *readSettings) {
 	settings.fullReadStatsFunc = wrs.f
 }
 
+// ColumnarRows returns a ReadOption that makes ReadRows deliver each row to f
+// as a RowColumns instead of calling its own function with a Row, which may
+// then be nil. Reading stops if f returns false. The RowColumns and its
+// slices are reused for the next row, so f must copy any of them it retains;
+// the values themselves are not overwritten unless PooledValues is also
+// given. ColumnarRows is meant for
+// ReadRows: ReadRow ignores the rows it delivers.
+func ColumnarRows(f func(*RowColumns) bool) ReadOption { return columnarRows{f} }
+
+type columnarRows struct{ f func(*RowColumns) bool }
+
+func (cr columnarRows) set(settings *readSettings) { settings.columnsFunc = cr.f }
+
+// PooledValues returns a ReadOption that makes ReadRows assemble the values
+// of the cells that the server splits across responses, which are the large
+// ones, in buffers that are reused once the function passed to ReadRows or
+// ColumnarRows returns. The values of a row are then only valid until that
+// function returns, unless it calls Retain on the row. ReadRow ignores
+// PooledValues.
+func PooledValues() ReadOption { return pooledValues(true) }
+
+type pooledValues bool
+
+func (pv pooledValues) set(settings *readSettings) { settings.pooledValues = bool(pv) }
+
+// ScanProgress reports how far the server has scanned the rows of a ReadRows
+// call.
+type ScanProgress struct {
+	// LastScannedRowKey is the key of the last row the server scanned. All
+	// the rows up to and including it, or down to it in a reverse scan, have
+	// either been delivered or been filtered out, so a scan that is resumed
+	// after it reads no row twice.
+	LastScannedRowKey string
+}
+
+// WithScanProgress returns a ReadOption that calls f each time the server
+// reports the last row it scanned, which it does when it has scanned rows
+// that the filter of the read removes entirely. It lets a scanner checkpoint
+// its progress through sparse ranges between matching rows. The keys of
+// delivered rows are not reported, and f is not called after reading stops.
+func WithScanProgress(f func(ScanProgress)) ReadOption { return withScanProgress{f} }
+
+type withScanProgress struct{ f func(ScanProgress) }
+
+func (wsp withScanProgress) set(settings *readSettings) { settings.scanProgressFunc = wsp.f }
+
 // ReverseScan returns a RadOption that will reverse the results of a Scan.
 // The rows will be streamed in reverse lexiographic order of the keys. The row key ranges of the RowSet are
 // still expected to be oriented the same way as forwards. ie [a,c] where a <= c. The row content
@@ -797,15 +1100,51 @@ func mutationsAreRetryable(muts []*btpb.Mutation) bool {
 	return true
 }
 
-const maxMutations = 100000
+const (
+	maxMutations = 100000
+
+	// maxCellValueSize is the largest value a cell can hold.
+	maxCellValueSize = 100 << 20
+
+	// maxRequestSize is the largest request the server accepts.
+	maxRequestSize = 256 << 20
+)
+
+// validateMutations checks ops against the limits the server enforces on
+// the mutations of a row, so that violations are reported before the RPC
+// instead of after retries.
+func validateMutations(ops []*btpb.Mutation) error {
+	if len(ops) > maxMutations {
+		return requestTooLarge("mutation has %d operations, more than the maximum of %d", len(ops), maxMutations)
+	}
+	size := 0
+	for _, op := range ops {
+		if sc := op.GetSetCell(); sc != nil && len(sc.Value) > maxCellValueSize {
+			return requestTooLarge("value of cell %s:%s is %d bytes, more than the maximum of %d",
+				sc.FamilyName, sc.ColumnQualifier, len(sc.Value), maxCellValueSize)
+		}
+		size += proto.Size(op)
+	}
+	if size > maxRequestSize {
+		return requestTooLarge("mutation is %d bytes, more than the request size limit of %d", size, maxRequestSize)
+	}
+	return nil
+}
 
 // Apply mutates a row atomically. A mutation must contain at least one
-// operation and at most 100000 operations.
+// operation and at most 100000 operations, and the value of each cell it
+// sets must be at most 100MB.
 func (t *Table) Apply(ctx context.Context, row string, m *Mutation, opts ...ApplyOption) (err error) {
 	ctx = mergeOutgoingMetadata(ctx, t.md)
 	ctx = trace.StartSpan(ctx, "cloud.google.com/go/bigtable/Apply")
 	defer func() { trace.EndSpan(ctx, err) }()
+	defer func() { err = wrapError(err, t.c.fullTableName(t.table)) }()
 
+	if err := t.c.checkDeadline(ctx, "Apply"); err != nil {
+		return err
+	}
+	op := t.startOperation("Apply", func() string { return strconv.Quote(row) })
+	defer func() { op.end(err) }()
 	after := func(res proto.Message) {
 		for _, o := range opts {
 			o.after(res)
@@ -814,9 +1153,20 @@ func (t *Table) Apply(ctx context.Context, row string, m *Mutation, opts ...Appl
 
 	var callOptions []gax.CallOption
 	if m.cond == nil {
+		if err := validateMutations(m.ops); err != nil {
+			return fmt.Errorf("bigtable: %w", err)
+		}
+		var dedupKey mutationHash
+		var dedup bool
+		if t.dedup != nil {
+			if dedupKey, dedup = t.dedup.key(m.ops); dedup && t.dedup.seen(row, dedupKey) {
+				return nil
+			}
+			t.dedup.forget(row)
+		}
 		req := &btpb.MutateRowRequest{
 			TableName:    t.c.fullTableName(t.table),
-			AppProfileId: t.c.appProfile,
+			AppProfileId: t.appProfile,
 			RowKey:       []byte(row),
 			Mutations:    m.ops,
 		}
@@ -824,20 +1174,31 @@ func (t *Table) Apply(ctx context.Context, row string, m *Mutation, opts ...Appl
 			callOptions = retryOptions
 		}
 		var res *btpb.MutateRowResponse
+		first := callOptions != nil
 		err := gax.Invoke(ctx, func(ctx context.Context, _ gax.CallSettings) error {
+			t.c.traceAttempt(ctx, "Apply")
+			ctx, at := t.c.adaptive.start(ctx, "MutateRow", first)
+			first = false
 			var err error
-			res, err = t.c.client.MutateRow(ctx, req)
-			return err
-		}, callOptions...)
+			res, err = t.c.client.MutateRow(ctx, req, t.c.rpcOptions(op.attempt())...)
+			return at.done(err)
+		}, t.c.withRetryLogging(ctx, "Apply", withCallOptions(callOptions, opts))...)
 		if err == nil {
+			if dedup {
+				t.dedup.add(row, dedupKey)
+			}
 			after(res)
 		}
 		return err
 	}
 
+	if err := validateFilter(m.cond, "predicate"); err != nil {
+		return status.Errorf(codes.InvalidArgument, "bigtable: invalid condition filter at %v", err)
+	}
+	t.dedup.forget(row)
 	req := &btpb.CheckAndMutateRowRequest{
 		TableName:       t.c.fullTableName(t.table),
-		AppProfileId:    t.c.appProfile,
+		AppProfileId:    t.appProfile,
 		RowKey:          []byte(row),
 		PredicateFilter: m.cond.proto(),
 	}
@@ -845,23 +1206,33 @@ func (t *Table) Apply(ctx context.Context, row string, m *Mutation, opts ...Appl
 		if m.mtrue.cond != nil {
 			return errors.New("bigtable: conditional mutations cannot be nested")
 		}
+		if err := validateMutations(m.mtrue.ops); err != nil {
+			return fmt.Errorf("bigtable: %w", err)
+		}
 		req.TrueMutations = m.mtrue.ops
 	}
 	if m.mfalse != nil {
 		if m.mfalse.cond != nil {
 			return errors.New("bigtable: conditional mutations cannot be nested")
 		}
+		if err := validateMutations(m.mfalse.ops); err != nil {
+			return fmt.Errorf("bigtable: %w", err)
+		}
 		req.FalseMutations = m.mfalse.ops
 	}
 	if mutationsAreRetryable(req.TrueMutations) && mutationsAreRetryable(req.FalseMutations) {
 		callOptions = retryOptions
 	}
 	var cmRes *btpb.CheckAndMutateRowResponse
+	first := callOptions != nil
 	err = gax.Invoke(ctx, func(ctx context.Context, _ gax.CallSettings) error {
+		t.c.traceAttempt(ctx, "Apply")
+		ctx, at := t.c.adaptive.start(ctx, "CheckAndMutateRow", first)
+		first = false
 		var err error
-		cmRes, err = t.c.client.CheckAndMutateRow(ctx, req)
-		return err
-	}, callOptions...)
+		cmRes, err = t.c.client.CheckAndMutateRow(ctx, req, t.c.rpcOptions(op.attempt())...)
+		return at.done(err)
+	}, t.c.withRetryLogging(ctx, "Apply", withCallOptions(callOptions, opts))...)
 	if err == nil {
 		after(cmRes)
 	}
@@ -981,10 +1352,17 @@ func (t *Table) ApplyBulk(ctx context.Context, rowKeys []string, muts []*Mutatio
 	ctx = mergeOutgoingMetadata(ctx, t.md)
 	ctx = trace.StartSpan(ctx, "cloud.google.com/go/bigtable/ApplyBulk")
 	defer func() { trace.EndSpan(ctx, err) }()
+	defer func() { err = wrapError(err, t.c.fullTableName(t.table)) }()
 
+	if err := t.c.checkDeadline(ctx, "ApplyBulk"); err != nil {
+		return nil, err
+	}
+	op := t.startOperation("ApplyBulk", func() string { return fmt.Sprintf("%d rows", len(rowKeys)) })
+	defer func() { op.end(err) }()
 	if len(rowKeys) != len(muts) {
 		return nil, fmt.Errorf("mismatched rowKeys and mutation array lengths: %d, %d", len(rowKeys), len(muts))
 	}
+	t.dedup.forget(rowKeys...)
 
 	origEntries := make([]*entryErr, len(rowKeys))
 	for i, key := range rowKeys {
@@ -992,6 +1370,9 @@ func (t *Table) ApplyBulk(ctx context.Context, rowKeys []string, muts []*Mutatio
 		if mut.cond != nil {
 			return nil, errors.New("conditional mutations cannot be applied in bulk")
 		}
+		if err := validateMutations(mut.ops); err != nil {
+			return nil, fmt.Errorf("bigtable: mutation %d for row %q: %w", i, key, err)
+		}
 		origEntries[i] = &entryErr{Entry: &btpb.MutateRowsRequest_Entry{RowKey: []byte(key), Mutations: mut.ops}}
 	}
 
@@ -1000,7 +1381,8 @@ func (t *Table) ApplyBulk(ctx context.Context, rowKeys []string, muts []*Mutatio
 		err = gax.Invoke(ctx, func(ctx context.Context, _ gax.CallSettings) error {
 			attrMap["rowCount"] = len(group)
 			trace.TracePrintf(ctx, attrMap, "Row count in ApplyBulk")
-			err := t.doApplyBulk(ctx, group, opts...)
+			t.c.traceAttempt(ctx, "ApplyBulk")
+			err := t.doApplyBulk(ctx, group, op.attempt(), opts...)
 			if err != nil {
 				// We want to retry the entire request with the current group
 				return err
@@ -1012,7 +1394,7 @@ func (t *Table) ApplyBulk(ctx context.Context, rowKeys []string, muts []*Mutatio
 				return status.Errorf(idempotentRetryCodes[0], "Synthetic error: partial failure of ApplyBulk")
 			}
 			return nil
-		}, retryOptions...)
+		}, t.c.withRetryLogging(ctx, "ApplyBulk", withCallOptions(retryOptions, opts))...)
 		if err != nil {
 			return nil, err
 		}
@@ -1047,7 +1429,7 @@ func (t *Table) getApplyBulkRetries(entries []*entryErr) []*entryErr {
 }
 
 // doApplyBulk does the work of a single ApplyBulk invocation
-func (t *Table) doApplyBulk(ctx context.Context, entryErrs []*entryErr, opts ...ApplyOption) error {
+func (t *Table) doApplyBulk(ctx context.Context, entryErrs []*entryErr, callOpt grpc.CallOption, opts ...ApplyOption) error {
 	after := func(res proto.Message) {
 		for _, o := range opts {
 			o.after(res)
@@ -1060,10 +1442,10 @@ func (t *Table) doApplyBulk(ctx context.Context, entryErrs []*entryErr, opts ...
 	}
 	req := &btpb.MutateRowsRequest{
 		TableName:    t.c.fullTableName(t.table),
-		AppProfileId: t.c.appProfile,
+		AppProfileId: t.appProfile,
 		Entries:      entries,
 	}
-	stream, err := t.c.client.MutateRows(ctx, req)
+	stream, err := t.c.client.MutateRows(ctx, req, t.c.rpcOptions(callOpt)...)
 	if err != nil {
 		return err
 	}
@@ -1142,20 +1524,27 @@ func (ts Timestamp) TruncateToMilliseconds() Timestamp {
 
 // ApplyReadModifyWrite applies a ReadModifyWrite to a specific row.
 // It returns the newly written cells.
-func (t *Table) ApplyReadModifyWrite(ctx context.Context, row string, m *ReadModifyWrite) (Row, error) {
+func (t *Table) ApplyReadModifyWrite(ctx context.Context, row string, m *ReadModifyWrite) (_ Row, err error) {
 	ctx = mergeOutgoingMetadata(ctx, t.md)
+	if err := t.c.checkDeadline(ctx, "ApplyReadModifyWrite"); err != nil {
+		return nil, err
+	}
+	op := t.startOperation("ApplyReadModifyWrite", func() string { return strconv.Quote(row) })
+	defer func() { op.end(err) }()
 	req := &btpb.ReadModifyWriteRowRequest{
 		TableName:    t.c.fullTableName(t.table),
-		AppProfileId: t.c.appProfile,
+		AppProfileId: t.appProfile,
 		RowKey:       []byte(row),
 		Rules:        m.ops,
 	}
-	res, err := t.c.client.ReadModifyWriteRow(ctx, req)
+	t.dedup.forget(row)
+	t.c.traceAttempt(ctx, "ApplyReadModifyWrite")
+	res, err := t.c.client.ReadModifyWriteRow(ctx, req, t.c.rpcOptions(op.attempt())...)
 	if err != nil {
-		return nil, err
+		return nil, wrapError(err, t.c.fullTableName(t.table))
 	}
 	if res.Row == nil {
-		return nil, errors.New("unable to apply ReadModifyWrite: res.Row=nil")
+		return nil, fmt.Errorf("unable to apply ReadModifyWrite: res.Row=nil: %w", ErrRowNotFound)
 	}
 	r := make(Row)
 	for _, fam := range res.Row.Families { // res is *btpb.Row, fam is *btpb.Family
@@ -1203,19 +1592,25 @@ func (m *ReadModifyWrite) Increment(family, column string, delta int64) {
 
 // SampleRowKeys returns a sample of row keys in the table. The returned row keys will delimit contiguous sections of
 // the table of approximately equal size, which can be used to break up the data for distributed tasks like mapreduces.
-func (t *Table) SampleRowKeys(ctx context.Context) ([]string, error) {
+func (t *Table) SampleRowKeys(ctx context.Context) (_ []string, err error) {
 	ctx = mergeOutgoingMetadata(ctx, t.md)
+	if err := t.c.checkDeadline(ctx, "SampleRowKeys"); err != nil {
+		return nil, err
+	}
+	op := t.startOperation("SampleRowKeys", nil)
+	defer func() { op.end(err) }()
 	var sampledRowKeys []string
-	err := gax.Invoke(ctx, func(ctx context.Context, _ gax.CallSettings) error {
+	err = gax.Invoke(ctx, func(ctx context.Context, _ gax.CallSettings) error {
+		t.c.traceAttempt(ctx, "SampleRowKeys")
 		sampledRowKeys = nil
 		req := &btpb.SampleRowKeysRequest{
 			TableName:    t.c.fullTableName(t.table),
-			AppProfileId: t.c.appProfile,
+			AppProfileId: t.appProfile,
 		}
 		ctx, cancel := context.WithCancel(ctx) // for aborting the stream
 		defer cancel()
 
-		stream, err := t.c.client.SampleRowKeys(ctx, req)
+		stream, err := t.c.client.SampleRowKeys(ctx, req, t.c.rpcOptions(op.attempt())...)
 		if err != nil {
 			return err
 		}
@@ -1236,6 +1631,6 @@ func (t *Table) SampleRowKeys(ctx context.Context) ([]string, error) {
 			sampledRowKeys = append(sampledRowKeys, key)
 		}
 		return nil
-	}, retryOptions...)
-	return sampledRowKeys, err
+	}, t.c.withRetryLogging(ctx, "SampleRowKeys", retryOptions)...)
+	return sampledRowKeys, wrapError(err, t.c.fullTableName(t.table))
 }
@@ -17,15 +17,23 @@ limitations under the License.
 package bigtable
 
 import (
+	"compress/gzip"
 	"context"
+	"io"
 	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"cloud.google.com/go/bigtable/bttest"
 	"github.com/google/go-cmp/cmp"
 	"google.golang.org/api/option"
 	btpb "google.golang.org/genproto/googleapis/bigtable/v2"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 func TestPrefix(t *testing.T) {
@@ -270,6 +278,46 @@ func TestApplyErrors(t *testing.T) {
 	}
 }
 
+func TestApplyValidation(t *testing.T) {
+	ctx := context.Background()
+	table := &Table{
+		c: &Client{
+			project:  "P",
+			instance: "I",
+		},
+		table: "t",
+	}
+	tooMany := NewMutation()
+	for i := 0; i <= maxMutations; i++ {
+		tooMany.DeleteCellsInColumn("cf", "col")
+	}
+	tooLarge := NewMutation()
+	tooLarge.Set("cf", "col", 0, make([]byte, maxCellValueSize+1))
+
+	for _, test := range []struct {
+		desc string
+		m    *Mutation
+		want string
+	}{
+		{"too many operations", tooMany, "100001 operations"},
+		{"cell value too large", tooLarge, "value of cell cf:col"},
+		{"conditional true mutation", NewCondMutation(ColumnFilter("C"), tooMany, nil), "100001 operations"},
+		{"conditional false mutation", NewCondMutation(ColumnFilter("C"), nil, tooLarge), "value of cell cf:col"},
+	} {
+		err := table.Apply(ctx, "x", test.m)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: Apply: got %v, want error containing %q", test.desc, err, test.want)
+		}
+	}
+
+	ok := NewMutation()
+	ok.DeleteRow()
+	_, err := table.ApplyBulk(ctx, []string{"a", "b"}, []*Mutation{ok, tooLarge})
+	if err == nil || !strings.Contains(err.Error(), `mutation 1 for row "b"`) {
+		t.Errorf("ApplyBulk: got %v, want error for mutation 1", err)
+	}
+}
+
 func TestGroupEntries(t *testing.T) {
 	for _, test := range []struct {
 		desc string
@@ -748,3 +796,293 @@ func TestHeaderPopulatedWithAppProfile(t *testing.T) {
 		t.Errorf("Incorrect value in resourcePrefixHeader. Got %s, want %s", got, want)
 	}
 }
+
+// countingCompressor is a gzip gRPC compressor that counts the messages it
+// compresses.
+type countingCompressor struct{ compressed atomic.Int32 }
+
+func (c *countingCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	c.compressed.Add(1)
+	return gzip.NewWriter(w), nil
+}
+
+func (c *countingCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	return gzip.NewReader(r)
+}
+
+func (c *countingCompressor) Name() string { return "bigtable-test-gzip" }
+
+func TestRequestCompressor(t *testing.T) {
+	ctx := context.Background()
+	comp := &countingCompressor{}
+	encoding.RegisterCompressor(comp)
+	tbl, cleanup, err := setupFakeServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	opt := option.WithGRPCConn(tbl.c.connPool.Conn())
+	if _, err := NewClientWithConfig(ctx, "client", "instance", ClientConfig{RequestCompressor: "no-such-compressor"}, opt); err == nil {
+		t.Error("NewClientWithConfig with an unregistered compressor: got nil, want error")
+	}
+	// The client shares the connection of tbl, so it is not closed.
+	client, err := NewClientWithConfig(ctx, "client", "instance", ClientConfig{RequestCompressor: comp.Name()}, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mut := NewMutation()
+	mut.Set("cf", "col", 1000, []byte(strings.Repeat("v", 1000)))
+	if err := client.Open("table").Apply(ctx, "row", mut); err != nil {
+		t.Fatal(err)
+	}
+	if comp.compressed.Load() == 0 {
+		t.Error("request was not compressed")
+	}
+	if _, err := client.Open("table").ReadRow(ctx, "row"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEmulatorHost(t *testing.T) {
+	ctx := context.Background()
+	srv, err := bttest.NewServer("localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	t.Setenv("BIGTABLE_EMULATOR_HOST", srv.Addr)
+	adminClient, err := NewAdminClient(ctx, "client", "instance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer adminClient.Close()
+	if err := adminClient.CreateTable(ctx, "table"); err != nil {
+		t.Fatal(err)
+	}
+	if err := adminClient.CreateColumnFamily(ctx, "table", "cf"); err != nil {
+		t.Fatal(err)
+	}
+	envClient, err := NewClient(ctx, "client", "instance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer envClient.Close()
+
+	// EmulatorHost overrides the environment.
+	t.Setenv("BIGTABLE_EMULATOR_HOST", "localhost:1")
+	client, err := NewClientWithConfig(ctx, "client", "instance", ClientConfig{EmulatorHost: srv.Addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	mut := NewMutation()
+	mut.Set("cf", "col", 1000, []byte("v"))
+	if err := client.Open("table").Apply(ctx, "row", mut); err != nil {
+		t.Fatal(err)
+	}
+	row, err := envClient.Open("table").ReadRow(ctx, "row")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := row.Key(); got != "row" {
+		t.Errorf("got row %q, want %q", got, "row")
+	}
+}
+
+func TestOpenTable(t *testing.T) {
+	ctx := context.Background()
+	tbl, cleanup, err := setupFakeServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	for _, k := range []string{"a", "b"} {
+		mut := NewMutation()
+		mut.Set("cf", "x", 1000, []byte("old"))
+		mut.Set("cf", "x", 2000, []byte("new"))
+		mut.Set("cf", "y", 1000, []byte("y"))
+		if err := tbl.Apply(ctx, k, mut); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opened := tbl.c.OpenTable("table",
+		TableAppProfile("my-app-profile"),
+		TableRowFilter(LatestNFilter(1)),
+		TableReadOptions(LimitRows(1)))
+	if got, want := opened.md.Get(requestParamsHeader)[0], "table_name=projects%2Fclient%2Finstances%2Finstance%2Ftables%2Ftable&app_profile_id=my-app-profile"; got != want {
+		t.Errorf("requestParamsHeader: got %s, want %s", got, want)
+	}
+
+	count := func(opts ...ReadOption) (rows, cells int) {
+		err := opened.ReadRows(ctx, InfiniteRange(""), func(r Row) bool {
+			rows++
+			cells += len(r["cf"])
+			return true
+		}, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rows, cells
+	}
+	for _, test := range []struct {
+		opts      []ReadOption
+		wantRows  int
+		wantCells int
+	}{
+		// The default filter and row limit apply: the latest x and y of a.
+		{nil, 1, 2},
+		// A per-call LimitRows overrides the default.
+		{[]ReadOption{LimitRows(2)}, 2, 4},
+		// A per-call filter is chained after the default filter.
+		{[]ReadOption{RowFilter(ColumnFilter("x"))}, 1, 1},
+	} {
+		rows, cells := count(test.opts...)
+		if rows != test.wantRows || cells != test.wantCells {
+			t.Errorf("%v: got %d rows and %d cells, want %d and %d", test.opts, rows, cells, test.wantRows, test.wantCells)
+		}
+	}
+}
+
+func TestWithScanProgress(t *testing.T) {
+	ctx := context.Background()
+	interceptor := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !strings.HasSuffix(info.FullMethod, "ReadRows") {
+			return handler(srv, ss)
+		}
+		if err := ss.RecvMsg(new(btpb.ReadRowsRequest)); err != nil {
+			return err
+		}
+		for _, msg := range []func() error{
+			func() error { return ss.SendMsg(&btpb.ReadRowsResponse{LastScannedRowKey: []byte("c")}) },
+			func() error { return writeReadRowsResponse(ss, "d") },
+			func() error { return ss.SendMsg(&btpb.ReadRowsResponse{LastScannedRowKey: []byte("k")}) },
+			func() error { return writeReadRowsResponse(ss, "m") },
+			func() error { return ss.SendMsg(&btpb.ReadRowsResponse{LastScannedRowKey: []byte("x")}) },
+		} {
+			if err := msg(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	tbl, cleanup, err := setupFakeServer(grpc.StreamInterceptor(interceptor))
+	defer cleanup()
+	if err != nil {
+		t.Fatalf("fake server setup: %v", err)
+	}
+
+	var events []string
+	progress := WithScanProgress(func(p ScanProgress) {
+		events = append(events, "scanned "+p.LastScannedRowKey)
+	})
+	err = tbl.ReadRows(ctx, InfiniteRange(""), func(r Row) bool {
+		events = append(events, "row "+r.Key())
+		return true
+	}, progress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"scanned c", "row d", "scanned k", "row m", "scanned x"}
+	if !cmp.Equal(events, want) {
+		t.Errorf("got %q, want %q", events, want)
+	}
+
+	// No progress is reported once reading stops.
+	events = nil
+	err = tbl.ReadRows(ctx, InfiniteRange(""), func(r Row) bool {
+		events = append(events, "row "+r.Key())
+		return false
+	}, progress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"scanned c", "row d"}; !cmp.Equal(events, want) {
+		t.Errorf("stopped read: got %q, want %q", events, want)
+	}
+}
+
+// writeSplitValueRows writes rows whose values are split across two chunks,
+// which are the values assembled in pooled buffers with PooledValues.
+func writeSplitValueRows(ss grpc.ServerStream, rowKeys ...string) error {
+	for _, key := range rowKeys {
+		v := []byte("value of " + key)
+		chunks := []*btpb.ReadRowsResponse_CellChunk{{
+			RowKey:     []byte(key),
+			FamilyName: &wrapperspb.StringValue{Value: "cf"},
+			Qualifier:  &wrapperspb.BytesValue{Value: []byte("col")},
+			Value:      v[:4],
+			ValueSize:  int32(len(v)),
+		}, {
+			Value:     v[4:],
+			RowStatus: &btpb.ReadRowsResponse_CellChunk_CommitRow{CommitRow: true},
+		}}
+		if err := ss.SendMsg(&btpb.ReadRowsResponse{Chunks: chunks}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestPooledValuesBufferedRows(t *testing.T) {
+	ctx := context.Background()
+	interceptor := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !strings.HasSuffix(info.FullMethod, "ReadRows") {
+			return handler(srv, ss)
+		}
+		req := new(btpb.ReadRowsRequest)
+		if err := ss.RecvMsg(req); err != nil {
+			return err
+		}
+		var keys []string
+		for _, k := range req.Rows.RowKeys {
+			keys = append(keys, string(k))
+		}
+		if len(keys) == 0 {
+			keys = []string{"0#a", "0#b", "0#c"}
+		}
+		sort.Strings(keys)
+		return writeSplitValueRows(ss, keys...)
+	}
+	fake, cleanup, err := setupFakeServer(grpc.StreamInterceptor(interceptor))
+	defer cleanup()
+	if err != nil {
+		t.Fatalf("fake server setup: %v", err)
+	}
+	// The helpers must ignore PooledValues, wherever it comes from.
+	tbl := fake.c.OpenTable("table", TableReadOptions(PooledValues()))
+
+	var got []string
+	err = tbl.ReadRowsOrdered(ctx, []string{"c", "b", "a"}, func(key string, r Row) bool {
+		got = append(got, string(r["cf"][0].Value))
+		return true
+	}, PooledValues())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"value of c", "value of b", "value of a"}; !cmp.Equal(got, want) {
+		t.Errorf("ReadRowsOrdered: got %q, want %q", got, want)
+	}
+
+	keys, err := NewSaltedKeys(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	err = tbl.ReadSaltedRows(ctx, keys, InfiniteRange(""), func(key string, r Row) bool {
+		// Let the reader queue the other rows first.
+		time.Sleep(10 * time.Millisecond)
+		got = append(got, string(r["cf"][0].Value))
+		return true
+	}, PooledValues())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"value of 0#a", "value of 0#b", "value of 0#c"}; !cmp.Equal(got, want) {
+		t.Errorf("ReadSaltedRows: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,267 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package bttestutil sets up an in-memory Bigtable server for the unit tests
+// of applications that use the cloud.google.com/go/bigtable package.
+//
+// New starts a bttest server, creates the tables and column families of a
+// schema, loads fixture rows and returns a client connected to the server.
+// The server and client are closed when the test completes.
+//
+//	func TestUsers(t *testing.T) {
+//		client := bttestutil.New(t, bttestutil.Schema{"users": {"profile"}},
+//			bttestutil.FixtureFile("testdata/users.json"))
+//		tbl := client.Open("users")
+//		...
+//	}
+//
+// Fixture files are JSON or YAML encodings of a Fixture.
+package bttestutil // import "cloud.google.com/go/bigtable/bttestutil"
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"cloud.google.com/go/bigtable"
+	"cloud.google.com/go/bigtable/bttest"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// Project is the project of the clients returned by New.
+	Project = "project"
+
+	// Instance is the instance of the clients returned by New.
+	Instance = "instance"
+)
+
+// Schema maps the names of tables to the names of their column families.
+type Schema map[string][]string
+
+// A Fixture holds tables and the rows to load into them.
+type Fixture struct {
+	// Schema holds the tables to create, in addition to those passed to New.
+	Schema Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+
+	// Rows holds the rows to load.
+	Rows []Row `json:"rows" yaml:"rows"`
+}
+
+// A Row is a fixture row.
+type Row struct {
+	// Table is the name of the table of the row.
+	Table string `json:"table" yaml:"table"`
+
+	// Key is the key of the row.
+	Key string `json:"key" yaml:"key"`
+
+	// Cells holds the cells of the row.
+	Cells []Cell `json:"cells" yaml:"cells"`
+}
+
+// A Cell is a cell of a fixture row.
+type Cell struct {
+	Family string `json:"family" yaml:"family"`
+	Column string `json:"column" yaml:"column"`
+
+	// Timestamp is the timestamp of the cell, in microseconds since the
+	// epoch. It must be a multiple of 1000, as tables have millisecond
+	// granularity. It defaults to 0.
+	Timestamp int64 `json:"timestamp,omitempty" yaml:"timestamp,omitempty"`
+
+	// Value is the value of the cell. Set Bytes instead for binary values.
+	Value string `json:"value,omitempty" yaml:"value,omitempty"`
+
+	// Bytes is the value of the cell if Value is empty. In fixture files, it
+	// is base64 encoded.
+	Bytes []byte `json:"bytes,omitempty" yaml:"bytes,omitempty"`
+}
+
+// An Option configures New.
+type Option interface {
+	set(*settings) error
+}
+
+type settings struct {
+	schema Schema
+	rows   []Row
+}
+
+func (s *settings) add(f Fixture) {
+	for tbl, fams := range f.Schema {
+		s.schema[tbl] = append(s.schema[tbl], fams...)
+	}
+	s.rows = append(s.rows, f.Rows...)
+}
+
+// Rows loads rows into the tables created by New.
+func Rows(rows ...Row) Option { return fixtureOption{Rows: rows} }
+
+// WithFixture creates the tables of f and loads its rows.
+func WithFixture(f Fixture) Option { return fixtureOption(f) }
+
+type fixtureOption Fixture
+
+func (o fixtureOption) set(s *settings) error {
+	s.add(Fixture(o))
+	return nil
+}
+
+// FixtureFile creates the tables of the Fixture in the named file and loads
+// its rows. The Fixture is decoded from YAML if the name of the file ends in
+// ".yaml" or ".yml", and from JSON otherwise. YAML fixtures are converted to
+// JSON first, so both are decoded the same way.
+func FixtureFile(name string) Option { return fixtureFile(name) }
+
+type fixtureFile string
+
+func (o fixtureFile) set(s *settings) error {
+	data, err := os.ReadFile(string(o))
+	if err != nil {
+		return err
+	}
+	if ext := filepath.Ext(string(o)); ext == ".yaml" || ext == ".yml" {
+		if data, err = yamlToJSON(data); err != nil {
+			return fmt.Errorf("bttestutil: reading fixture %s: %w", o, err)
+		}
+	}
+	var f Fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("bttestutil: reading fixture %s: %w", o, err)
+	}
+	s.add(f)
+	return nil
+}
+
+func yamlToJSON(data []byte) ([]byte, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// New starts an in-memory Bigtable server, creates the tables of schema and
+// of the fixtures among opts, loads the rows of the fixtures and returns a
+// client for instance Instance of project Project on the server. It fails t
+// if any of these steps fails. The client and server are closed when t
+// completes.
+func New(t testing.TB, schema Schema, opts ...Option) *bigtable.Client {
+	t.Helper()
+	ctx := context.Background()
+	s := &settings{schema: Schema{}}
+	s.add(Fixture{Schema: schema})
+	for _, o := range opts {
+		if err := o.set(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	srv, err := bttest.NewServer("localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(srv.Close)
+	conn, err := grpc.Dial(srv.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	admin, err := bigtable.NewAdminClient(ctx, Project, Instance, option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := createTables(ctx, admin, s.schema); err != nil {
+		t.Fatal(err)
+	}
+	client, err := bigtable.NewClient(ctx, Project, Instance, option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := loadRows(ctx, client, s.rows); err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func createTables(ctx context.Context, admin *bigtable.AdminClient, schema Schema) error {
+	for tbl, fams := range schema {
+		families := map[string]bigtable.GCPolicy{}
+		for _, fam := range fams {
+			families[fam] = bigtable.NoGcPolicy()
+		}
+		if err := admin.CreateTableFromConf(ctx, &bigtable.TableConf{TableID: tbl, Families: families}); err != nil {
+			return fmt.Errorf("bttestutil: creating table %q: %w", tbl, err)
+		}
+	}
+	return nil
+}
+
+// loadRows writes rows with one ApplyBulk call per table. Cells of rows with
+// the same table and key are written to the same row.
+func loadRows(ctx context.Context, client *bigtable.Client, rows []Row) error {
+	muts := map[string]map[string]*bigtable.Mutation{}
+	for _, r := range rows {
+		if r.Table == "" {
+			return fmt.Errorf("bttestutil: fixture row %q has no table", r.Key)
+		}
+		if muts[r.Table] == nil {
+			muts[r.Table] = map[string]*bigtable.Mutation{}
+		}
+		m := muts[r.Table][r.Key]
+		if m == nil {
+			m = bigtable.NewMutation()
+			muts[r.Table][r.Key] = m
+		}
+		for _, c := range r.Cells {
+			v := c.Bytes
+			if c.Value != "" {
+				v = []byte(c.Value)
+			}
+			m.Set(c.Family, c.Column, bigtable.Timestamp(c.Timestamp), v)
+		}
+	}
+	for tbl, byKey := range muts {
+		keys := make([]string, 0, len(byKey))
+		for k := range byKey {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		ms := make([]*bigtable.Mutation, len(keys))
+		for i, k := range keys {
+			ms[i] = byKey[k]
+		}
+		errs, err := client.Open(tbl).ApplyBulk(ctx, keys, ms)
+		if err != nil {
+			return fmt.Errorf("bttestutil: loading rows into table %q: %w", tbl, err)
+		}
+		for i, err := range errs {
+			if err != nil {
+				return fmt.Errorf("bttestutil: loading row %q into table %q: %w", keys[i], tbl, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bttestutil
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cloud.google.com/go/bigtable"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+	client := New(t, Schema{"users": {"profile"}},
+		FixtureFile("testdata/users.json"),
+		Rows(Row{Table: "users", Key: "bob", Cells: []Cell{{Family: "profile", Column: "name", Value: "Bob"}}}),
+	)
+
+	row, err := client.Open("users").ReadRow(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := bigtable.Row{"profile": {
+		{Row: "alice", Column: "profile:age", Timestamp: 1000, Value: []byte{0, 30}},
+		{Row: "alice", Column: "profile:name", Timestamp: 0, Value: []byte("Alice")},
+	}}
+	if !cmp.Equal(row, want) {
+		t.Errorf("users/alice: got %v, want %v", row, want)
+	}
+
+	var keys []string
+	err = client.Open("users").ReadRows(ctx, bigtable.InfiniteRange(""), func(r bigtable.Row) bool {
+		keys = append(keys, r.Key())
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"alice", "bob"}; !cmp.Equal(keys, want) {
+		t.Errorf("users keys: got %q, want %q", keys, want)
+	}
+
+	row, err = client.Open("events").ReadRow(ctx, "e1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(row["e"][0].Value); got != "login" {
+		t.Errorf("events/e1: got %q, want %q", got, "login")
+	}
+}
+
+func TestFixtureFileYAML(t *testing.T) {
+	load := func(name string) *settings {
+		t.Helper()
+		s := &settings{schema: Schema{}}
+		if err := FixtureFile(name).set(s); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	want := load("testdata/users.json")
+	data, err := os.ReadFile("testdata/users.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	yml := filepath.Join(t.TempDir(), "users.yml")
+	if err := os.WriteFile(yml, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"testdata/users.yaml", yml} {
+		if got := load(name); !cmp.Equal(got.schema, want.schema) || !cmp.Equal(got.rows, want.rows) {
+			t.Errorf("%s: got %+v, want %+v", name, got, want)
+		}
+	}
+}
+
+func TestFixtureErrors(t *testing.T) {
+	s := &settings{schema: Schema{}}
+	if err := FixtureFile("testdata/missing.json").set(s); err == nil {
+		t.Error("missing fixture file: got nil, want error")
+	}
+	if err := loadRows(context.Background(), nil, []Row{{Key: "k"}}); err == nil {
+		t.Error("row without table: got nil, want error")
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Loadtest sends a mix of reads and writes to a Cloud Bigtable table and reports
+the latencies of the operations. Set -emulator to test against the emulator,
+or -project and -instance to test against Cloud Bigtable. The table and its
+column family must exist.
+
+	loadtest -project=p -instance=i -table=t -duration=5m -concurrency=32 -reads=0.9 -dist=zipf
+*/
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"cloud.google.com/go/bigtable"
+	"cloud.google.com/go/bigtable/loadtest"
+)
+
+var (
+	project     = flag.String("project", "", "project ID")
+	instance    = flag.String("instance", "", "instance ID")
+	table       = flag.String("table", "", "table to load")
+	emulator    = flag.String("emulator", "", "address of an emulator to use instead of Cloud Bigtable")
+	duration    = flag.Duration("duration", time.Minute, "duration of the test")
+	operations  = flag.Int64("operations", 0, "number of operations to send, if positive")
+	concurrency = flag.Int("concurrency", 16, "number of concurrent workers")
+	reads       = flag.Float64("reads", 0.5, "fraction of the operations that are reads")
+	keys        = flag.Int64("keys", 1000000, "number of distinct rows")
+	dist        = flag.String("dist", "uniform", "distribution of the row keys: uniform, sequential or zipf")
+	zipfS       = flag.Float64("zipf_s", 1.1, "exponent of the zipf distribution, greater than 1")
+	prefix      = flag.String("key_prefix", "loadtest-", "prefix of the row keys")
+	family      = flag.String("family", "cf", "column family written to")
+	valueSize   = flag.Int("value_size", 1024, "size of the values written, in bytes")
+	csvOutput   = flag.String("csv_output", "", "file to write the results to as CSV, in addition to stdout")
+)
+
+func main() {
+	flag.Parse()
+	if *table == "" || (*emulator == "" && (*project == "" || *instance == "")) {
+		log.Fatal("-table, and -emulator or -project and -instance, are required")
+	}
+	if *emulator != "" {
+		os.Setenv("BIGTABLE_EMULATOR_HOST", *emulator)
+		if *project == "" {
+			*project = "project"
+		}
+		if *instance == "" {
+			*instance = "instance"
+		}
+	}
+
+	var kd loadtest.KeyDistribution
+	switch *dist {
+	case "uniform":
+		kd = loadtest.Uniform(*keys)
+	case "sequential":
+		kd = loadtest.Sequential(*keys)
+	case "zipf":
+		if *zipfS <= 1 {
+			log.Fatal("-zipf_s must be greater than 1")
+		}
+		kd = loadtest.Zipfian(*keys, *zipfS)
+	default:
+		log.Fatalf("unknown -dist %q", *dist)
+	}
+
+	ctx := context.Background()
+	client, err := bigtable.NewClient(ctx, *project, *instance)
+	if err != nil {
+		log.Fatalf("creating client: %v", err)
+	}
+	defer client.Close()
+
+	report, err := loadtest.Run(ctx, client.Open(*table), loadtest.Config{
+		Duration:     *duration,
+		Operations:   *operations,
+		Concurrency:  *concurrency,
+		ReadFraction: *reads,
+		Keys:         kd,
+		KeyPrefix:    *prefix,
+		Family:       *family,
+		ValueSize:    *valueSize,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(report)
+	if *csvOutput != "" {
+		f, err := os.Create(*csvOutput)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := report.WriteCSV(f); err != nil {
+			log.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/internal/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// checkDeadline returns a DeadlineExceeded error if the deadline of ctx is
+// closer than the MinDeadline of the client, since such an operation is
+// unlikely to complete and its retries waste quota. In MinDeadlineLogOnly
+// mode, the operation is logged instead and allowed to proceed.
+func (c *Client) checkDeadline(ctx context.Context, method string) error {
+	if c.minDeadline <= 0 {
+		return nil
+	}
+	d, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+	remaining := time.Until(d)
+	if remaining >= c.minDeadline {
+		return nil
+	}
+	if c.minDeadlineLogOnly {
+		c.debugf(ctx, "bigtable: %s called with %v until its deadline, less than the minimum of %v", method, remaining, c.minDeadline)
+		return nil
+	}
+	return status.Errorf(codes.DeadlineExceeded, "bigtable: %s called with %v until its deadline, less than the minimum of %v", method, remaining, c.minDeadline)
+}
+
+// traceAttempt adds an event for an attempt at an RPC to the trace of ctx,
+// with the time remaining until the deadline of ctx, if it has one.
+func (c *Client) traceAttempt(ctx context.Context, method string) {
+	attrs := map[string]interface{}{}
+	if d, ok := ctx.Deadline(); ok {
+		attrs["remainingDeadlineMs"] = time.Until(d).Milliseconds()
+	}
+	trace.TracePrintf(ctx, attrs, "Attempt in %s", method)
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMinDeadline(t *testing.T) {
+	tbl, cleanup, err := setupFakeServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	tbl.c.minDeadline = time.Minute
+
+	mut := NewMutation()
+	mut.Set("cf", "col", 1000, []byte("v"))
+	ops := map[string]func(context.Context) error{
+		"ReadRows": func(ctx context.Context) error {
+			return tbl.ReadRows(ctx, RowList{"row"}, func(Row) bool { return true })
+		},
+		"Apply": func(ctx context.Context) error {
+			return tbl.Apply(ctx, "row", mut)
+		},
+		"ApplyBulk": func(ctx context.Context) error {
+			_, err := tbl.ApplyBulk(ctx, []string{"row"}, []*Mutation{mut})
+			return err
+		},
+		"SampleRowKeys": func(ctx context.Context) error {
+			_, err := tbl.SampleRowKeys(ctx)
+			return err
+		},
+	}
+
+	short, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	long, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	for name, op := range ops {
+		if err := op(short); status.Code(err) != codes.DeadlineExceeded || !strings.Contains(err.Error(), name) {
+			t.Errorf("%s with a short deadline: got %v, want DeadlineExceeded", name, err)
+		}
+		if err := op(long); err != nil {
+			t.Errorf("%s with a long deadline: %v", name, err)
+		}
+		if err := op(context.Background()); err != nil {
+			t.Errorf("%s without a deadline: %v", name, err)
+		}
+	}
+
+	logger := &recordingLogger{}
+	tbl.c.logger = logger
+	tbl.c.minDeadlineLogOnly = true
+	for name, op := range ops {
+		logger.msgs = nil
+		if err := op(short); err != nil {
+			t.Errorf("%s with a short deadline in log-only mode: %v", name, err)
+		}
+		if len(logger.msgs) != 1 || !strings.Contains(logger.msgs[0], name) {
+			t.Errorf("%s with a short deadline: got log %q, want one message naming the operation", name, logger.msgs)
+		}
+	}
+}
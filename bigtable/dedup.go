@@ -0,0 +1,148 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	btpb "google.golang.org/genproto/googleapis/bigtable/v2"
+	"google.golang.org/protobuf/proto"
+)
+
+// TableWriteDedup returns a TableOption that makes Apply skip an
+// unconditional mutation identical to the last one applied successfully to
+// the same row, if that was within the last ttl, and return nil. It is meant
+// for at-least-once pipelines, in which a consumer that retries an event
+// would otherwise apply its mutations twice.
+//
+// Any other write to the row through the Table, including a failed one, makes
+// the next mutation of the row apply, even if it is identical to an earlier
+// one. Mutations are remembered by the client, so duplicates sent by
+// different clients, or concurrently, are not detected. Mutations that set
+// cells to the ServerTime, or that ApplyBulk applies, are not deduplicated.
+func TableWriteDedup(ttl time.Duration) TableOption { return tableWriteDedup(ttl) }
+
+type tableWriteDedup time.Duration
+
+func (o tableWriteDedup) setTable(t *Table) {
+	if o > 0 {
+		t.dedup = newWriteDeduper(time.Duration(o))
+	}
+}
+
+// mutationHash identifies the mutation of a row.
+type mutationHash [sha256.Size]byte
+
+// writeDeduper remembers the last mutation applied to each row, for a time.
+type writeDeduper struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	applied map[string]dedupEntry // by row key
+	// queue holds the rows of applied in the order they were added, with the
+	// time they expire, which is the order in which they expire.
+	queue []dedupQueued
+}
+
+type dedupEntry struct {
+	key     mutationHash
+	expires time.Time
+}
+
+type dedupQueued struct {
+	row     string
+	expires time.Time
+}
+
+func newWriteDeduper(ttl time.Duration) *writeDeduper {
+	return &writeDeduper{
+		ttl:     ttl,
+		now:     time.Now,
+		applied: map[string]dedupEntry{},
+	}
+}
+
+// key returns the key identifying a mutation with ops, and whether the
+// mutation can be deduplicated.
+func (d *writeDeduper) key(ops []*btpb.Mutation) (mutationHash, bool) {
+	h := sha256.New()
+	for _, op := range ops {
+		if sc := op.GetSetCell(); sc != nil && sc.TimestampMicros == int64(ServerTime) {
+			return mutationHash{}, false
+		}
+		b, err := proto.MarshalOptions{Deterministic: true}.Marshal(op)
+		if err != nil {
+			return mutationHash{}, false
+		}
+		// Length-prefix each operation so that the hash is unambiguous.
+		h.Write([]byte{byte(len(b) >> 24), byte(len(b) >> 16), byte(len(b) >> 8), byte(len(b))})
+		h.Write(b)
+	}
+	var key mutationHash
+	copy(key[:], h.Sum(nil))
+	return key, true
+}
+
+// seen reports whether the mutation with key was the last one applied to row,
+// within the TTL.
+func (d *writeDeduper) seen(row string, key mutationHash) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.expire()
+	e, ok := d.applied[row]
+	return ok && e.key == key
+}
+
+// add records that the mutation with key was applied to row.
+func (d *writeDeduper) add(row string, key mutationHash) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.expire()
+	expires := d.now().Add(d.ttl)
+	d.applied[row] = dedupEntry{key, expires}
+	d.queue = append(d.queue, dedupQueued{row, expires})
+}
+
+// forget forgets the mutation applied to each of rows, which are about to be
+// written otherwise. It is safe to call on a nil *writeDeduper.
+func (d *writeDeduper) forget(rows ...string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, row := range rows {
+		delete(d.applied, row)
+	}
+}
+
+// expire forgets the mutations applied before the TTL. d.mu must be held.
+func (d *writeDeduper) expire() {
+	now := d.now()
+	i := 0
+	for ; i < len(d.queue) && !d.queue[i].expires.After(now); i++ {
+		q := d.queue[i]
+		// The row may have been written again since.
+		if e, ok := d.applied[q.row]; ok && e.expires == q.expires {
+			delete(d.applied, q.row)
+		}
+	}
+	d.queue = d.queue[i:]
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+func TestTableWriteDedup(t *testing.T) {
+	ctx := context.Background()
+	var mutateRows int32
+	countMutateRow := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if strings.HasSuffix(info.FullMethod, "/MutateRow") {
+			atomic.AddInt32(&mutateRows, 1)
+		}
+		return handler(ctx, req)
+	}
+	tbl, cleanup, err := setupFakeServer(grpc.UnaryInterceptor(countMutateRow))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	tbl = tbl.c.OpenTable("table", TableWriteDedup(time.Minute))
+	now := time.Now()
+	tbl.dedup.now = func() time.Time { return now }
+
+	set := func(value string, ts Timestamp) *Mutation {
+		m := NewMutation()
+		m.Set("cf", "col", ts, []byte(value))
+		return m
+	}
+	deleteRow := func() *Mutation {
+		m := NewMutation()
+		m.DeleteRow()
+		return m
+	}
+	for _, test := range []struct {
+		desc    string
+		row     string
+		m       *Mutation
+		advance time.Duration
+		wantRPC bool
+	}{
+		{"first write", "r1", set("a", 1000), 0, true},
+		{"duplicate", "r1", set("a", 1000), 0, false},
+		{"other row", "r2", set("a", 1000), 0, true},
+		{"duplicate within the TTL", "r1", set("a", 1000), 59 * time.Second, false},
+		{"duplicate after the TTL", "r1", set("a", 1000), time.Second, true},
+		{"other value", "r1", set("b", 1000), 0, true},
+		{"earlier value again", "r1", set("a", 1000), 0, true},
+		{"duplicate of the earlier value", "r1", set("a", 1000), 0, false},
+		{"delete row", "r1", deleteRow(), 0, true},
+		{"value again after the delete", "r1", set("a", 1000), 0, true},
+		{"server time", "r1", set("a", ServerTime), 0, true},
+		{"server time again", "r1", set("a", ServerTime), 0, true},
+		{"value again after server time", "r1", set("a", 1000), 0, true},
+	} {
+		now = now.Add(test.advance)
+		before := atomic.LoadInt32(&mutateRows)
+		if err := tbl.Apply(ctx, test.row, test.m); err != nil {
+			t.Fatalf("%s: %v", test.desc, err)
+		}
+		if gotRPC := atomic.LoadInt32(&mutateRows) > before; gotRPC != test.wantRPC {
+			t.Errorf("%s: sent MutateRow: got %t, want %t", test.desc, gotRPC, test.wantRPC)
+		}
+	}
+
+	// Other kinds of writes to the row are not deduplicated, but make the
+	// next mutation apply.
+	rmw := NewReadModifyWrite()
+	rmw.AppendValue("cf", "col", []byte("c"))
+	if _, err := tbl.ApplyReadModifyWrite(ctx, "r1", rmw); err != nil {
+		t.Fatal(err)
+	}
+	before := atomic.LoadInt32(&mutateRows)
+	if err := tbl.Apply(ctx, "r1", set("a", 1000)); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&mutateRows) == before {
+		t.Error("value again after ReadModifyWrite: sent MutateRow: got false, want true")
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"context"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	btapb "google.golang.org/genproto/googleapis/bigtable/admin/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// defaultMinDropPrefixLength is the minimum length of the row key prefixes
+// accepted by ConfirmDropPrefix when it is given no minimum.
+const defaultMinDropPrefixLength = 3
+
+// A DropRowRangeOption is an optional argument to StartDropRowRange.
+type DropRowRangeOption interface {
+	setDrop(*dropRowRangeSettings)
+}
+
+type dropRowRangeSettings struct {
+	confirm   *string
+	minLength int
+}
+
+// ConfirmDropPrefix returns a DropRowRangeOption that makes
+// StartDropRowRange check the row key prefix before dropping any rows, to
+// catch prefixes that were computed wrongly, such as empty or truncated ones.
+// The prefix must equal confirm, be at least minLength bytes long, or 3 bytes
+// if minLength is zero, and consist of printable UTF-8 characters without
+// leading or trailing spaces. Otherwise StartDropRowRange returns an error
+// matching ErrUnsafeRowKeyPrefix.
+func ConfirmDropPrefix(confirm string, minLength int) DropRowRangeOption {
+	return confirmDropPrefix{confirm, minLength}
+}
+
+type confirmDropPrefix struct {
+	confirm   string
+	minLength int
+}
+
+func (c confirmDropPrefix) setDrop(s *dropRowRangeSettings) {
+	s.confirm = &c.confirm
+	s.minLength = c.minLength
+	if s.minLength <= 0 {
+		s.minLength = defaultMinDropPrefixLength
+	}
+}
+
+// checkPrefix checks prefix against the confirmation of s, if any.
+func (s *dropRowRangeSettings) checkPrefix(prefix string) error {
+	if s.confirm == nil {
+		return nil
+	}
+	switch {
+	case prefix != *s.confirm:
+		return unsafeRowKeyPrefix("bigtable: row key prefix %q does not match confirmation %q", prefix, *s.confirm)
+	case len(prefix) < s.minLength:
+		return unsafeRowKeyPrefix("bigtable: row key prefix %q is shorter than %d bytes", prefix, s.minLength)
+	case !utf8.ValidString(prefix) || strings.IndexFunc(prefix, func(r rune) bool { return !unicode.IsPrint(r) }) >= 0:
+		return unsafeRowKeyPrefix("bigtable: row key prefix %q has unprintable characters", prefix)
+	case strings.TrimSpace(prefix) != prefix:
+		return unsafeRowKeyPrefix("bigtable: row key prefix %q has leading or trailing spaces", prefix)
+	}
+	return nil
+}
+
+// unsafeRowKeyPrefix returns an InvalidArgument status error that matches
+// ErrUnsafeRowKeyPrefix.
+func unsafeRowKeyPrefix(format string, args ...interface{}) error {
+	return &statusError{ErrUnsafeRowKeyPrefix, status.Errorf(codes.InvalidArgument, format, args...)}
+}
+
+// dropRowRangeError wraps err, returned by the DropRowRange RPC on table, so
+// that it matches ErrReplicatedTable if the failed precondition is that the
+// table is replicated. The error doesn't say which precondition failed, so
+// dropRowRangeError reads the replication state of the table to tell.
+func (ac *AdminClient) dropRowRangeError(ctx context.Context, table string, err error) error {
+	if status.Code(err) != codes.FailedPrecondition {
+		return err
+	}
+	tbl, terr := ac.getTable(ctx, table, btapb.Table_REPLICATION_VIEW)
+	if terr == nil && len(tbl.ClusterStates) > 1 {
+		return &statusError{ErrReplicatedTable, err}
+	}
+	return err
+}
+
+// A DropRowRangeOperation tracks the deletion of the rows of a table with a
+// row key prefix, started by StartDropRowRange.
+type DropRowRangeOperation struct {
+	// Table is the table whose rows are deleted.
+	Table string
+
+	// RowKeyPrefix is the prefix of the keys of the deleted rows.
+	RowKeyPrefix string
+
+	done chan struct{}
+	err  error
+}
+
+// Done reports whether the deletion has completed, successfully or not.
+func (op *DropRowRangeOperation) Done() bool {
+	select {
+	case <-op.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Wait waits for the deletion to complete and returns its error, or returns
+// the error of ctx if it is done first. The deletion continues in that case.
+func (op *DropRowRangeOperation) Wait(ctx context.Context) error {
+	select {
+	case <-op.done:
+		return op.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// StartDropRowRange starts deleting the rows of a table whose keys have the
+// given prefix, and returns an operation to await the deletion, which can
+// take minutes on large tables. The deletion is abandoned if ctx is done
+// before it completes.
+//
+// The rows are deleted permanently, so StartDropRowRange first checks the
+// prefix with the ConfirmDropPrefix option, if given. Dropping row ranges is
+// not available on tables replicated to more than one cluster, so it also
+// reads the replication state of the table and returns an error matching
+// ErrReplicatedTable for those tables.
+func (ac *AdminClient) StartDropRowRange(ctx context.Context, table, rowKeyPrefix string, opts ...DropRowRangeOption) (*DropRowRangeOperation, error) {
+	var s dropRowRangeSettings
+	for _, o := range opts {
+		o.setDrop(&s)
+	}
+	if err := s.checkPrefix(rowKeyPrefix); err != nil {
+		return nil, err
+	}
+	tbl, err := ac.getTable(ctx, table, btapb.Table_REPLICATION_VIEW)
+	if err != nil {
+		return nil, err
+	}
+	if len(tbl.ClusterStates) > 1 {
+		return nil, &statusError{ErrReplicatedTable, status.Errorf(codes.FailedPrecondition,
+			"bigtable: cannot drop row range of table %q, which is replicated to %d clusters", table, len(tbl.ClusterStates))}
+	}
+	op := &DropRowRangeOperation{Table: table, RowKeyPrefix: rowKeyPrefix, done: make(chan struct{})}
+	go func() {
+		defer close(op.done)
+		op.err = ac.DropRowRange(ctx, table, rowKeyPrefix)
+	}()
+	return op, nil
+}
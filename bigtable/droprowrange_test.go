@@ -0,0 +1,131 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	btapb "google.golang.org/genproto/googleapis/bigtable/admin/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type mockDropRowRangeClient struct {
+	btapb.BigtableTableAdminClient
+
+	clusters int
+	dropErr  error
+	release  chan struct{}
+	dropReqs []*btapb.DropRowRangeRequest
+}
+
+func (c *mockDropRowRangeClient) GetTable(ctx context.Context, in *btapb.GetTableRequest, opts ...grpc.CallOption) (*btapb.Table, error) {
+	tbl := &btapb.Table{Name: in.Name, ClusterStates: map[string]*btapb.Table_ClusterState{}}
+	for i := 0; i < c.clusters; i++ {
+		tbl.ClusterStates[string(rune('a'+i))] = &btapb.Table_ClusterState{}
+	}
+	return tbl, nil
+}
+
+func (c *mockDropRowRangeClient) DropRowRange(ctx context.Context, in *btapb.DropRowRangeRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	c.dropReqs = append(c.dropReqs, in)
+	if c.release != nil {
+		<-c.release
+	}
+	return &emptypb.Empty{}, c.dropErr
+}
+
+func TestStartDropRowRange(t *testing.T) {
+	ctx := context.Background()
+	mock := &mockDropRowRangeClient{clusters: 1, release: make(chan struct{})}
+	c := setupTableClient(t, mock)
+
+	op, err := c.StartDropRowRange(ctx, "tbl", "user#42#", ConfirmDropPrefix("user#42#", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if op.Done() {
+		t.Error("Done before the drop completed: got true, want false")
+	}
+	close(mock.release)
+	if err := op.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !op.Done() {
+		t.Error("Done after Wait: got false, want true")
+	}
+	if len(mock.dropReqs) != 1 || string(mock.dropReqs[0].GetRowKeyPrefix()) != "user#42#" {
+		t.Errorf("DropRowRange requests: got %v, want one for prefix %q", mock.dropReqs, "user#42#")
+	}
+}
+
+func TestStartDropRowRangeErrors(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		desc     string
+		clusters int
+		dropErr  error
+		prefix   string
+		opts     []DropRowRangeOption
+		want     error
+	}{
+		{desc: "mismatched confirmation", clusters: 1, prefix: "user#4", opts: []DropRowRangeOption{ConfirmDropPrefix("user#42", 0)}, want: ErrUnsafeRowKeyPrefix},
+		{desc: "short prefix", clusters: 1, prefix: "u", opts: []DropRowRangeOption{ConfirmDropPrefix("u", 0)}, want: ErrUnsafeRowKeyPrefix},
+		{desc: "below minimum length", clusters: 1, prefix: "user", opts: []DropRowRangeOption{ConfirmDropPrefix("user", 8)}, want: ErrUnsafeRowKeyPrefix},
+		{desc: "unprintable prefix", clusters: 1, prefix: "us\x00er", opts: []DropRowRangeOption{ConfirmDropPrefix("us\x00er", 0)}, want: ErrUnsafeRowKeyPrefix},
+		{desc: "trailing space", clusters: 1, prefix: "user ", opts: []DropRowRangeOption{ConfirmDropPrefix("user ", 0)}, want: ErrUnsafeRowKeyPrefix},
+		{desc: "replicated table", clusters: 2, prefix: "user", want: ErrReplicatedTable},
+	} {
+		mock := &mockDropRowRangeClient{clusters: test.clusters, dropErr: test.dropErr}
+		c := setupTableClient(t, mock)
+		op, err := c.StartDropRowRange(ctx, "tbl", test.prefix, test.opts...)
+		if err == nil {
+			err = op.Wait(ctx)
+		}
+		if !errors.Is(err, test.want) {
+			t.Errorf("%s: got %v, want an error matching %v", test.desc, err, test.want)
+		}
+		if len(mock.dropReqs) != 0 {
+			t.Errorf("%s: rows were dropped", test.desc)
+		}
+	}
+}
+
+func TestDropRowRangeFailedPrecondition(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		desc     string
+		clusters int
+		want     bool
+	}{
+		// The table was replicated after StartDropRowRange checked it, or
+		// DropRowRange was called directly.
+		{desc: "replicated table", clusters: 2, want: true},
+		{desc: "other precondition", clusters: 1, want: false},
+	} {
+		mock := &mockDropRowRangeClient{clusters: test.clusters, dropErr: status.Error(codes.FailedPrecondition, "precondition failed")}
+		c := setupTableClient(t, mock)
+		err := c.DropRowRange(ctx, "tbl", "user")
+		if got := errors.Is(err, ErrReplicatedTable); got != test.want || status.Code(err) != codes.FailedPrecondition {
+			t.Errorf("%s: got %v, want FailedPrecondition matching ErrReplicatedTable %t", test.desc, err, test.want)
+		}
+	}
+}
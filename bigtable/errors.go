@@ -0,0 +1,109 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// ErrRowNotFound is returned by ApplyReadModifyWrite when the server
+	// does not return the modified row.
+	ErrRowNotFound = errors.New("bigtable: row not found")
+
+	// ErrTableNotFound matches the errors of operations on a table that does
+	// not exist.
+	ErrTableNotFound = errors.New("bigtable: table not found")
+
+	// ErrRequestTooLarge matches the errors of requests that the client
+	// refuses to send because they exceed the limits of the server on their
+	// size or number of mutations.
+	ErrRequestTooLarge = errors.New("bigtable: request too large")
+
+	// ErrUnsafeRowKeyPrefix is returned by StartDropRowRange when the row
+	// key prefix fails the checks of ConfirmDropPrefix.
+	ErrUnsafeRowKeyPrefix = errors.New("bigtable: unsafe row key prefix")
+
+	// ErrReplicatedTable matches the errors of operations that are not
+	// available on tables replicated to more than one cluster.
+	ErrReplicatedTable = errors.New("bigtable: operation not available on replicated table")
+)
+
+// statusError is a gRPC status error that matches a sentinel error with
+// errors.Is. Its status is still available to status.FromError and
+// status.Code.
+type statusError struct {
+	sentinel error
+	err      error
+}
+
+func (e *statusError) Error() string { return e.err.Error() }
+
+func (e *statusError) Unwrap() error { return e.err }
+
+func (e *statusError) Is(target error) bool { return target == e.sentinel }
+
+func (e *statusError) GRPCStatus() *status.Status { return status.Convert(e.err) }
+
+// requestTooLarge returns an InvalidArgument status error that matches
+// ErrRequestTooLarge.
+func requestTooLarge(format string, args ...interface{}) error {
+	return &statusError{ErrRequestTooLarge, status.Error(codes.InvalidArgument, fmt.Sprintf(format, args...))}
+}
+
+// wrapError wraps err, returned by a data API call on the table with the
+// full name table, so that it matches the sentinel error of its failure mode,
+// if any. Errors are classified by their code, their details and the call
+// they come from, not by their messages, which the service may change.
+func wrapError(err error, table string) error {
+	if err == nil {
+		return nil
+	}
+	var se *statusError
+	if errors.As(err, &se) {
+		return err
+	}
+	s, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	if s.Code() == codes.NotFound {
+		// The table is the resource that the call addresses, so it is the
+		// missing one, unless the details name another resource, such as an
+		// app profile or an authorized view.
+		if r := resourceInfo(s); r == nil || r.GetResourceName() == table || strings.HasSuffix(r.GetResourceType(), "Table") {
+			return &statusError{ErrTableNotFound, err}
+		}
+	}
+	return err
+}
+
+// resourceInfo returns the ResourceInfo detail of s, or nil if it has none.
+func resourceInfo(s *status.Status) *errdetails.ResourceInfo {
+	for _, d := range s.Details() {
+		if r, ok := d.(*errdetails.ResourceInfo); ok {
+			return r
+		}
+	}
+	return nil
+}
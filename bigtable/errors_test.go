@@ -0,0 +1,97 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestTypedErrors(t *testing.T) {
+	ctx := context.Background()
+	tbl, cleanup, err := setupFakeServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	missing := tbl.c.Open("missing")
+	mut := NewMutation()
+	mut.Set("cf", "col", 1000, []byte("v"))
+	err = missing.Apply(ctx, "row", mut)
+	if !errors.Is(err, ErrTableNotFound) || status.Code(err) != codes.NotFound {
+		t.Errorf("Apply to a missing table: got %v, want ErrTableNotFound with code NotFound", err)
+	}
+	err = missing.ReadRows(ctx, InfiniteRange(""), func(Row) bool { return true })
+	if !errors.Is(err, ErrTableNotFound) {
+		t.Errorf("ReadRows of a missing table: got %v, want ErrTableNotFound", err)
+	}
+
+	large := NewMutation()
+	large.Set("cf", "col", 1000, make([]byte, maxCellValueSize+1))
+	err = tbl.Apply(ctx, "row", large)
+	if !errors.Is(err, ErrRequestTooLarge) || status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Apply of a large value: got %v, want ErrRequestTooLarge with code InvalidArgument", err)
+	}
+	if errors.Is(err, ErrTableNotFound) {
+		t.Error("Apply of a large value: error matches ErrTableNotFound")
+	}
+
+	if err := tbl.Apply(ctx, "row", mut); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWrapError(t *testing.T) {
+	const table = "projects/p/instances/i/tables/t"
+	withResource := func(typ, name string) error {
+		s, err := status.New(codes.NotFound, "not found").WithDetails(&errdetails.ResourceInfo{ResourceType: typ, ResourceName: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s.Err()
+	}
+	for _, test := range []struct {
+		err  error
+		want error
+	}{
+		{status.Error(codes.NotFound, "Table not found: "+table), ErrTableNotFound},
+		{status.Error(codes.NotFound, "no such thing"), ErrTableNotFound},
+		{withResource("bigtable.googleapis.com/Table", table), ErrTableNotFound},
+		{withResource("", table), ErrTableNotFound},
+		{withResource("bigtable.googleapis.com/AppProfile", "projects/p/instances/i/appProfiles/a"), nil},
+		// Messages don't classify errors.
+		{status.Error(codes.InvalidArgument, "Table is too large"), nil},
+		{status.Error(codes.ResourceExhausted, "trying to send message larger than max (300 vs. 200)"), nil},
+		{errors.New("other"), nil},
+	} {
+		got := wrapError(test.err, table)
+		for _, sentinel := range []error{ErrTableNotFound, ErrRequestTooLarge} {
+			if errors.Is(got, sentinel) != (sentinel == test.want) {
+				t.Errorf("wrapError(%v): errors.Is(%v) = %t", test.err, sentinel, !(sentinel == test.want))
+			}
+		}
+		if status.Code(got) != status.Code(test.err) || got.Error() != test.err.Error() {
+			t.Errorf("wrapError(%v) = %v, want the same status and message", test.err, got)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/bigtable"
+)
+
+// rowSchema is the Avro schema of the exported rows. It is the schema used
+// by the Cloud Bigtable to Avro Dataflow template, so that exports can be
+// imported with the Avro to Cloud Bigtable template.
+const rowSchema = `{"type":"record","name":"BigtableRow","namespace":"com.google.cloud.teleport.bigtable","fields":[` +
+	`{"name":"key","type":"bytes"},` +
+	`{"name":"cells","type":{"type":"array","items":{"type":"record","name":"BigtableCell","fields":[` +
+	`{"name":"family","type":"string"},` +
+	`{"name":"qualifier","type":"bytes"},` +
+	`{"name":"timestamp","type":"long"},` +
+	`{"name":"value","type":"bytes"}]}}}]}`
+
+// avroBlockSize is the size of the encoded rows at which an Avro block is
+// flushed.
+const avroBlockSize = 1 << 20
+
+// avroWriter writes rows to an Avro object container file with the null
+// codec. See https://avro.apache.org/docs/1.11.1/specification/#object-container-files.
+type avroWriter struct {
+	w     *bufio.Writer
+	sync  [16]byte
+	block []byte
+	count int64
+}
+
+func newAvroWriter(w io.Writer) (*avroWriter, error) {
+	aw := &avroWriter{w: bufio.NewWriter(w)}
+	if _, err := rand.Read(aw.sync[:]); err != nil {
+		return nil, err
+	}
+	hdr := []byte("Obj\x01")
+	// The file metadata is a map with a single block of two entries.
+	hdr = binary.AppendVarint(hdr, 2)
+	hdr = appendAvroBytes(hdr, []byte("avro.schema"))
+	hdr = appendAvroBytes(hdr, []byte(rowSchema))
+	hdr = appendAvroBytes(hdr, []byte("avro.codec"))
+	hdr = appendAvroBytes(hdr, []byte("null"))
+	hdr = binary.AppendVarint(hdr, 0)
+	hdr = append(hdr, aw.sync[:]...)
+	if _, err := aw.w.Write(hdr); err != nil {
+		return nil, err
+	}
+	return aw, nil
+}
+
+// write adds r to the current block, and flushes the block once it is large
+// enough.
+func (aw *avroWriter) write(r bigtable.Row) error {
+	b := appendAvroBytes(aw.block, []byte(r.Key()))
+	fams := make([]string, 0, len(r))
+	for fam := range r {
+		fams = append(fams, fam)
+	}
+	sort.Strings(fams)
+	var n int
+	for _, fam := range fams {
+		n += len(r[fam])
+	}
+	if n > 0 {
+		b = binary.AppendVarint(b, int64(n))
+		for _, fam := range fams {
+			for _, item := range r[fam] {
+				b = appendAvroBytes(b, []byte(fam))
+				b = appendAvroBytes(b, []byte(strings.TrimPrefix(item.Column, fam+":")))
+				b = binary.AppendVarint(b, int64(item.Timestamp))
+				b = appendAvroBytes(b, item.Value)
+			}
+		}
+	}
+	aw.block = binary.AppendVarint(b, 0)
+	aw.count++
+	if len(aw.block) >= avroBlockSize {
+		return aw.flush()
+	}
+	return nil
+}
+
+// flush writes the current block, if it is not empty.
+func (aw *avroWriter) flush() error {
+	if aw.count == 0 {
+		return nil
+	}
+	var hdr []byte
+	hdr = binary.AppendVarint(hdr, aw.count)
+	hdr = binary.AppendVarint(hdr, int64(len(aw.block)))
+	for _, b := range [][]byte{hdr, aw.block, aw.sync[:]} {
+		if _, err := aw.w.Write(b); err != nil {
+			return err
+		}
+	}
+	aw.block, aw.count = aw.block[:0], 0
+	return nil
+}
+
+// close flushes the last block and the buffered data. It does not close the
+// underlying writer.
+func (aw *avroWriter) close() error {
+	if err := aw.flush(); err != nil {
+		return err
+	}
+	return aw.w.Flush()
+}
+
+// avroReader reads rows from an Avro object container file with the schema
+// of rowSchema, compressed with the null or deflate codec.
+type avroReader struct {
+	r       *bufio.Reader
+	sync    [16]byte
+	deflate bool
+
+	// block holds the rows of the current block, of which n remain.
+	block *bufio.Reader
+	n     int64
+}
+
+func newAvroReader(r io.Reader) (*avroReader, error) {
+	ar := &avroReader{r: bufio.NewReader(r)}
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(ar.r, magic); err != nil {
+		return nil, err
+	}
+	if string(magic) != "Obj\x01" {
+		return nil, errors.New("not an Avro object container file")
+	}
+	meta := map[string]string{}
+	for {
+		n, err := readAvroCount(ar.r)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			break
+		}
+		for ; n > 0; n-- {
+			k, err := readAvroBytes(ar.r)
+			if err != nil {
+				return nil, err
+			}
+			v, err := readAvroBytes(ar.r)
+			if err != nil {
+				return nil, err
+			}
+			meta[string(k)] = string(v)
+		}
+	}
+	if _, err := io.ReadFull(ar.r, ar.sync[:]); err != nil {
+		return nil, err
+	}
+
+	var schema struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(meta["avro.schema"]), &schema); err != nil {
+		return nil, fmt.Errorf("bad Avro schema: %w", err)
+	}
+	if schema.Name != "BigtableRow" && !strings.HasSuffix(schema.Name, ".BigtableRow") {
+		return nil, fmt.Errorf("Avro records are %q, not BigtableRow", schema.Name)
+	}
+	switch codec := meta["avro.codec"]; codec {
+	case "", "null":
+	case "deflate":
+		ar.deflate = true
+	default:
+		return nil, fmt.Errorf("unsupported Avro codec %q", codec)
+	}
+	return ar, nil
+}
+
+// next returns the next row, or io.EOF after the last one.
+func (ar *avroReader) next() (*record, error) {
+	for ar.n == 0 {
+		if err := ar.nextBlock(); err != nil {
+			return nil, err
+		}
+	}
+	ar.n--
+	key, err := readAvroBytes(ar.block)
+	if err != nil {
+		return nil, err
+	}
+	rec := &record{key: string(key), mut: bigtable.NewMutation()}
+	for {
+		n, err := readAvroCount(ar.block)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return rec, nil
+		}
+		for ; n > 0; n-- {
+			fam, err := readAvroBytes(ar.block)
+			if err != nil {
+				return nil, err
+			}
+			qual, err := readAvroBytes(ar.block)
+			if err != nil {
+				return nil, err
+			}
+			ts, err := binary.ReadVarint(ar.block)
+			if err != nil {
+				return nil, err
+			}
+			val, err := readAvroBytes(ar.block)
+			if err != nil {
+				return nil, err
+			}
+			rec.mut.Set(string(fam), string(qual), bigtable.Timestamp(ts), val)
+			rec.cells++
+		}
+	}
+}
+
+// nextBlock reads the next block of rows.
+func (ar *avroReader) nextBlock() error {
+	n, err := binary.ReadVarint(ar.r)
+	if err != nil {
+		// The file ends between blocks.
+		return err
+	}
+	size, err := binary.ReadVarint(ar.r)
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(ar.r, data); err != nil {
+		return unexpectedEOF(err)
+	}
+	var marker [16]byte
+	if _, err := io.ReadFull(ar.r, marker[:]); err != nil {
+		return unexpectedEOF(err)
+	}
+	if marker != ar.sync {
+		return errors.New("bad Avro sync marker")
+	}
+	var r io.Reader = bytes.NewReader(data)
+	if ar.deflate {
+		r = flate.NewReader(r)
+	}
+	ar.block, ar.n = bufio.NewReader(r), n
+	return nil
+}
+
+// readAvroCount reads the count of items of a block of an array or map. A
+// negative count is followed by the size of the block in bytes.
+func readAvroCount(r *bufio.Reader) (int64, error) {
+	n, err := binary.ReadVarint(r)
+	if err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	if n < 0 {
+		if _, err := binary.ReadVarint(r); err != nil {
+			return 0, unexpectedEOF(err)
+		}
+		n = -n
+	}
+	return n, nil
+}
+
+func readAvroBytes(r *bufio.Reader) ([]byte, error) {
+	n, err := binary.ReadVarint(r)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("bad Avro length %d", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return b, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// appendAvroBytes appends the Avro encoding of a bytes or string value: its
+// length as a zig-zag varint, which binary.AppendVarint produces, followed by
+// its contents.
+func appendAvroBytes(b, v []byte) []byte {
+	b = binary.AppendVarint(b, int64(len(v)))
+	return append(b, v...)
+}
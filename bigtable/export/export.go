@@ -0,0 +1,318 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package export snapshots Cloud Bigtable tables to Cloud Storage, and
+// imports them back, without running a Dataflow pipeline.
+//
+// Export splits a table into shards at the keys returned by
+// Table.SampleRowKeys, scans the shards concurrently and writes each one to
+// its own object as an Avro object container file. The files use the schema
+// of the Cloud Bigtable to Avro Dataflow template, so they can be imported
+// with the Avro to Cloud Bigtable template.
+//
+// The progress of an export is checkpointed to an object next to the data
+// files. An export that fails or is interrupted can be resumed by calling
+// Export again with the same prefix; shards that were completed are not
+// exported again.
+//
+// Example:
+//
+//	tbl := btClient.Open("my-table")
+//	bkt := gcsClient.Bucket("my-bucket")
+//	res, err := export.Export(ctx, tbl, bkt, "backups/my-table/", nil)
+//	if err != nil {
+//		// TODO: Handle error; call Export again to resume.
+//	}
+//	fmt.Printf("exported %d rows to %d objects\n", res.Rows, len(res.Objects))
+//
+// Import writes the rows of Avro files, such as those written by Export, or
+// of CSV files to a table, at a limited rate. It can be used with Export to
+// migrate a table between instances or projects:
+//
+//	res, err := export.Import(ctx, dstTbl, bkt, "backups/my-table/", &export.ImportOptions{
+//		RowsPerSecond: 5000,
+//	})
+//
+// This package is EXPERIMENTAL and subject to change or removal without notice.
+package export // import "cloud.google.com/go/bigtable/export"
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"cloud.google.com/go/bigtable"
+	"cloud.google.com/go/storage"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/api/iterator"
+)
+
+// DefaultConcurrency is the number of shards exported at once when
+// Options.Concurrency is not set.
+const DefaultConcurrency = 4
+
+// checkpointName is the name of the checkpoint object, relative to the
+// prefix of an export.
+const checkpointName = "checkpoint.json"
+
+// Options configures an export.
+type Options struct {
+	// Concurrency is the maximum number of shards exported at once. It
+	// defaults to DefaultConcurrency.
+	Concurrency int
+
+	// ReadOptions are passed to Table.ReadRows for each shard, for example to
+	// export only the latest cell of each column with a filter. LimitRows
+	// applies to each shard separately.
+	ReadOptions []bigtable.ReadOption
+}
+
+// Result describes a completed export.
+type Result struct {
+	// Objects are the names of the objects holding the exported rows, one
+	// for each shard, in the order of the keys of their rows.
+	Objects []string
+
+	// Rows is the number of exported rows.
+	Rows int64
+}
+
+// Export writes the rows of tbl to objects of bkt whose names start with
+// prefix, and returns when all of them have been written. If a checkpoint of
+// an earlier export with the same prefix exists, Export resumes it.
+// opts may be nil.
+func Export(ctx context.Context, tbl *bigtable.Table, bkt *storage.BucketHandle, prefix string, opts *Options) (*Result, error) {
+	return export(ctx, tbl, bucketStore{bkt}, prefix, opts)
+}
+
+// objectStore is the subset of a bucket used by an export. It exists to aid
+// testing.
+type objectStore interface {
+	// newWriter returns a writer for the object with the given name. The
+	// object is written when the writer is closed.
+	newWriter(ctx context.Context, name string) io.WriteCloser
+
+	// read returns the contents of the object with the given name, or
+	// errNotExist.
+	read(ctx context.Context, name string) ([]byte, error)
+
+	// newReader returns a reader of the object with the given name.
+	newReader(ctx context.Context, name string) (io.ReadCloser, error)
+
+	// list returns the names of the objects that start with prefix, sorted.
+	list(ctx context.Context, prefix string) ([]string, error)
+}
+
+var errNotExist = errors.New("object does not exist")
+
+type bucketStore struct {
+	bkt *storage.BucketHandle
+}
+
+func (s bucketStore) newWriter(ctx context.Context, name string) io.WriteCloser {
+	return s.bkt.Object(name).NewWriter(ctx)
+}
+
+func (s bucketStore) read(ctx context.Context, name string) ([]byte, error) {
+	r, err := s.bkt.Object(name).NewReader(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, errNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func (s bucketStore) newReader(ctx context.Context, name string) (io.ReadCloser, error) {
+	return s.bkt.Object(name).NewReader(ctx)
+}
+
+func (s bucketStore) list(ctx context.Context, prefix string) ([]string, error) {
+	var names []string
+	it := s.bkt.Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, attrs.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// checkpoint records the progress of an export.
+type checkpoint struct {
+	// Boundaries are the keys at which the table is split into shards.
+	Boundaries []string `json:"boundaries"`
+
+	// Done maps the index of each completed shard to its number of rows.
+	Done map[int]int64 `json:"done"`
+}
+
+func export(ctx context.Context, tbl *bigtable.Table, store objectStore, prefix string, opts *Options) (*Result, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	cp, err := readCheckpoint(ctx, store, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if cp == nil {
+		keys, err := tbl.SampleRowKeys(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("export: sampling row keys: %w", err)
+		}
+		cp = &checkpoint{Boundaries: boundaries(keys), Done: map[int]int64{}}
+	}
+
+	shards := shardRanges(cp.Boundaries)
+	res := &Result{Objects: make([]string, len(shards))}
+	var mu sync.Mutex // guards cp
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for i, rr := range shards {
+		i, rr := i, rr
+		res.Objects[i] = fmt.Sprintf("%s%05d.avro", prefix, i)
+		if _, ok := cp.Done[i]; ok {
+			continue
+		}
+		g.Go(func() error {
+			n, err := exportShard(gctx, tbl, store, res.Objects[i], rr, opts.ReadOptions)
+			if err != nil {
+				return fmt.Errorf("export: shard %d (%v): %w", i, rr, err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			cp.Done[i] = n
+			return writeCheckpoint(gctx, store, prefix, cp)
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	for _, n := range cp.Done {
+		res.Rows += n
+	}
+	return res, nil
+}
+
+// exportShard writes the rows of rr to the named object and returns their
+// number.
+func exportShard(ctx context.Context, tbl *bigtable.Table, store objectStore, name string, rr bigtable.RowRange, opts []bigtable.ReadOption) (int64, error) {
+	// Cancelling the context of a storage writer discards the object.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w := store.newWriter(ctx, name)
+	aw, err := newAvroWriter(w)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	var werr error
+	err = tbl.ReadRows(ctx, rr, func(r bigtable.Row) bool {
+		if werr = aw.write(r); werr != nil {
+			return false
+		}
+		n++
+		return true
+	}, opts...)
+	if err == nil {
+		err = werr
+	}
+	if err == nil {
+		err = aw.close()
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := w.Close(); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// boundaries returns the sorted, distinct non-empty keys of keys.
+func boundaries(keys []string) []string {
+	var res []string
+	for _, k := range keys {
+		if k == "" || (len(res) > 0 && k <= res[len(res)-1]) {
+			continue
+		}
+		res = append(res, k)
+	}
+	return res
+}
+
+// shardRanges returns the ranges between consecutive boundaries, which cover
+// the whole table.
+func shardRanges(bounds []string) []bigtable.RowRange {
+	var res []bigtable.RowRange
+	start := ""
+	for _, b := range bounds {
+		res = append(res, bigtable.NewRange(start, b))
+		start = b
+	}
+	return append(res, bigtable.InfiniteRange(start))
+}
+
+func readCheckpoint(ctx context.Context, store objectStore, prefix string) (*checkpoint, error) {
+	b, err := store.read(ctx, prefix+checkpointName)
+	if err == errNotExist {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("export: reading checkpoint: %w", err)
+	}
+	cp := &checkpoint{}
+	if err := json.Unmarshal(b, cp); err != nil {
+		return nil, fmt.Errorf("export: reading checkpoint: %w", err)
+	}
+	if cp.Done == nil {
+		cp.Done = map[int]int64{}
+	}
+	return cp, nil
+}
+
+func writeCheckpoint(ctx context.Context, store objectStore, prefix string, cp *checkpoint) error {
+	b, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	w := store.newWriter(ctx, prefix+checkpointName)
+	if _, err := w.Write(b); err != nil {
+		w.Close()
+		return fmt.Errorf("export: writing checkpoint: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("export: writing checkpoint: %w", err)
+	}
+	return nil
+}
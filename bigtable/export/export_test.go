@@ -0,0 +1,287 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/bigtable"
+	"cloud.google.com/go/bigtable/bttest"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+)
+
+func setupTable(t *testing.T, rows int) *bigtable.Table {
+	ctx := context.Background()
+	srv, err := bttest.NewServer("localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(srv.Close)
+	conn, err := grpc.Dial(srv.Addr, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	adminClient, err := bigtable.NewAdminClient(ctx, "project", "instance", option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := adminClient.CreateTable(ctx, "table"); err != nil {
+		t.Fatal(err)
+	}
+	for _, fam := range []string{"a", "b"} {
+		if err := adminClient.CreateColumnFamily(ctx, "table", fam); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client, err := bigtable.NewClient(ctx, "project", "instance", option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tbl := client.Open("table")
+
+	for i := 0; i < rows; i++ {
+		mut := bigtable.NewMutation()
+		mut.Set("a", "x", 1000, []byte("ax"))
+		mut.Set("b", "y", 2000, []byte(fmt.Sprint(i)))
+		if err := tbl.Apply(ctx, fmt.Sprintf("row%03d", i), mut); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tbl
+}
+
+// memStore is an in-memory objectStore.
+type memStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	writes  map[string]int
+	// fail, if it returns true for the name of an object, fails the write of
+	// that object.
+	fail func(name string) bool
+}
+
+func newMemStore() *memStore {
+	return &memStore{objects: map[string][]byte{}, writes: map[string]int{}}
+}
+
+func (s *memStore) newWriter(_ context.Context, name string) io.WriteCloser {
+	return &memWriter{s: s, name: name}
+}
+
+func (s *memStore) read(_ context.Context, name string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.objects[name]
+	if !ok {
+		return nil, errNotExist
+	}
+	return b, nil
+}
+
+func (s *memStore) newReader(_ context.Context, name string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.objects[name]
+	if !ok {
+		return nil, errNotExist
+	}
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+
+func (s *memStore) list(_ context.Context, prefix string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var names []string
+	for name := range s.objects {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+type memWriter struct {
+	s    *memStore
+	name string
+	buf  bytes.Buffer
+}
+
+func (w *memWriter) Write(p []byte) (int, error) { return w.buf.Write(p) }
+
+func (w *memWriter) Close() error {
+	w.s.mu.Lock()
+	defer w.s.mu.Unlock()
+	if w.s.fail != nil && w.s.fail(w.name) {
+		return errors.New("write failed")
+	}
+	w.s.objects[w.name] = w.buf.Bytes()
+	w.s.writes[w.name]++
+	return nil
+}
+
+type cell struct {
+	family, qualifier string
+	timestamp         int64
+	value             string
+}
+
+type exportedRow struct {
+	key   string
+	cells []cell
+}
+
+// readAvro decodes an Avro object container file written by avroWriter.
+func readAvro(t *testing.T, b []byte) []exportedRow {
+	t.Helper()
+	r := bytes.NewReader(b)
+	readLong := func() int64 {
+		n, err := binary.ReadVarint(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	readBytes := func() string {
+		buf := make([]byte, readLong())
+		if _, err := io.ReadFull(r, buf); err != nil {
+			t.Fatal(err)
+		}
+		return string(buf)
+	}
+
+	magic := make([]byte, 4)
+	io.ReadFull(r, magic)
+	if string(magic) != "Obj\x01" {
+		t.Fatalf("bad magic %q", magic)
+	}
+	meta := map[string]string{}
+	for n := readLong(); n != 0; n = readLong() {
+		for i := int64(0); i < n; i++ {
+			k := readBytes()
+			meta[k] = readBytes()
+		}
+	}
+	if meta["avro.schema"] != rowSchema || meta["avro.codec"] != "null" {
+		t.Fatalf("bad metadata %q", meta)
+	}
+	syncMarker := make([]byte, 16)
+	io.ReadFull(r, syncMarker)
+
+	var rows []exportedRow
+	for r.Len() > 0 {
+		count := readLong()
+		readLong() // size
+		for i := int64(0); i < count; i++ {
+			row := exportedRow{key: readBytes()}
+			for n := readLong(); n != 0; n = readLong() {
+				for j := int64(0); j < n; j++ {
+					row.cells = append(row.cells, cell{readBytes(), readBytes(), readLong(), readBytes()})
+				}
+			}
+			rows = append(rows, row)
+		}
+		marker := make([]byte, 16)
+		io.ReadFull(r, marker)
+		if !bytes.Equal(marker, syncMarker) {
+			t.Fatal("bad sync marker")
+		}
+	}
+	return rows
+}
+
+func checkExport(t *testing.T, store *memStore, res *Result, wantRows int) {
+	t.Helper()
+	if res.Rows != int64(wantRows) {
+		t.Errorf("got %d rows, want %d", res.Rows, wantRows)
+	}
+	var rows []exportedRow
+	for _, name := range res.Objects {
+		rows = append(rows, readAvro(t, store.objects[name])...)
+	}
+	if len(rows) != wantRows {
+		t.Fatalf("got %d rows in the objects, want %d", len(rows), wantRows)
+	}
+	for i, row := range rows {
+		want := exportedRow{
+			key: fmt.Sprintf("row%03d", i),
+			cells: []cell{
+				{"a", "x", 1000, "ax"},
+				{"b", "y", 2000, fmt.Sprint(i)},
+			},
+		}
+		if fmt.Sprint(row) != fmt.Sprint(want) {
+			t.Fatalf("row %d: got %v, want %v", i, row, want)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	const numRows = 300
+	tbl := setupTable(t, numRows)
+	store := newMemStore()
+	res, err := export(context.Background(), tbl, store, "backup/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Objects) < 2 {
+		t.Errorf("got %d objects, want the table split into shards", len(res.Objects))
+	}
+	for _, name := range res.Objects {
+		if !strings.HasPrefix(name, "backup/") || !strings.HasSuffix(name, ".avro") {
+			t.Errorf("bad object name %q", name)
+		}
+	}
+	checkExport(t, store, res, numRows)
+}
+
+func TestExportResume(t *testing.T) {
+	const numRows = 300
+	tbl := setupTable(t, numRows)
+	store := newMemStore()
+	failing := "backup/00001.avro"
+	store.fail = func(name string) bool { return name == failing }
+	opts := &Options{Concurrency: 1}
+	if _, err := export(context.Background(), tbl, store, "backup/", opts); err == nil {
+		t.Fatal("got nil, want error")
+	}
+	if _, ok := store.objects["backup/"+checkpointName]; !ok {
+		t.Fatal("no checkpoint written for the completed shard")
+	}
+
+	store.fail = nil
+	res, err := export(context.Background(), tbl, store, "backup/", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := store.writes["backup/00000.avro"]; got != 1 {
+		t.Errorf("first shard written %d times, want once", got)
+	}
+	checkExport(t, store, res, numRows)
+}
@@ -0,0 +1,262 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/bigtable"
+	"cloud.google.com/go/storage"
+	"golang.org/x/time/rate"
+)
+
+// DefaultBatchSize is the number of rows written by each ApplyBulk call of
+// an import when ImportOptions.BatchSize is not set.
+const DefaultBatchSize = 1000
+
+// ImportOptions configures an import.
+type ImportOptions struct {
+	// RowsPerSecond, if positive, limits the rate at which rows are written.
+	// Rows are written in batches, so the rate may be exceeded over periods
+	// of time shorter than a batch.
+	RowsPerSecond float64
+
+	// BatchSize is the maximum number of rows written by each ApplyBulk
+	// call. It defaults to DefaultBatchSize.
+	BatchSize int
+
+	// DryRun makes Import read and decode the files without writing to the
+	// table, to check them and count their rows.
+	DryRun bool
+}
+
+// ImportResult describes a completed import.
+type ImportResult struct {
+	// Objects are the names of the imported objects.
+	Objects []string
+
+	// Rows and Cells are the numbers of rows and cells imported, or that
+	// would be imported by a dry run.
+	Rows, Cells int64
+}
+
+// Import writes the rows of the objects of bkt whose names start with
+// prefix to tbl, and returns when all of them have been written. opts may be
+// nil.
+//
+// Objects whose names end in ".avro" are Avro object container files with
+// the schema of the files written by Export, uncompressed or compressed with
+// the deflate codec. Objects whose names end in ".csv" are CSV files without
+// a header, with one cell per record in the form
+//
+//	row key,family,qualifier,timestamp,value
+//
+// where timestamp is in microseconds since the epoch, or empty to use the
+// time of the server. Consecutive records with the same row key are written
+// to the same row atomically. Other objects are ignored.
+func Import(ctx context.Context, tbl *bigtable.Table, bkt *storage.BucketHandle, prefix string, opts *ImportOptions) (*ImportResult, error) {
+	return importRows(ctx, tbl, bucketStore{bkt}, prefix, opts)
+}
+
+// record is a row read from an imported file.
+type record struct {
+	key   string
+	mut   *bigtable.Mutation
+	cells int
+}
+
+// recordReader reads the rows of an imported file.
+type recordReader interface {
+	// next returns the next row, or io.EOF after the last one.
+	next() (*record, error)
+}
+
+func importRows(ctx context.Context, tbl *bigtable.Table, store objectStore, prefix string, opts *ImportOptions) (*ImportResult, error) {
+	if opts == nil {
+		opts = &ImportOptions{}
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	limiter := rate.NewLimiter(rate.Inf, batchSize)
+	if opts.RowsPerSecond > 0 {
+		limiter = rate.NewLimiter(rate.Limit(opts.RowsPerSecond), batchSize)
+	}
+
+	names, err := store.list(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("export: listing objects: %w", err)
+	}
+	res := &ImportResult{}
+	im := &importer{tbl: tbl, limiter: limiter, dryRun: opts.DryRun, batchSize: batchSize, res: res}
+	for _, name := range names {
+		if !strings.HasSuffix(name, ".avro") && !strings.HasSuffix(name, ".csv") {
+			continue
+		}
+		if err := im.importObject(ctx, store, name); err != nil {
+			return nil, fmt.Errorf("export: importing %s: %w", name, err)
+		}
+		res.Objects = append(res.Objects, name)
+	}
+	return res, nil
+}
+
+type importer struct {
+	tbl       *bigtable.Table
+	limiter   *rate.Limiter
+	dryRun    bool
+	batchSize int
+	res       *ImportResult
+
+	keys  []string
+	muts  []*bigtable.Mutation
+	cells int
+}
+
+func (im *importer) importObject(ctx context.Context, store objectStore, name string) error {
+	r, err := store.newReader(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	var rr recordReader
+	if strings.HasSuffix(name, ".csv") {
+		rr = newCSVReader(r)
+	} else if rr, err = newAvroReader(r); err != nil {
+		return err
+	}
+	for {
+		rec, err := rr.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := im.add(ctx, rec); err != nil {
+			return err
+		}
+	}
+	return im.flush(ctx)
+}
+
+// add adds rec to the current batch, and writes the batch once it is full.
+func (im *importer) add(ctx context.Context, rec *record) error {
+	if rec.cells == 0 {
+		return nil
+	}
+	if len(im.keys) == im.batchSize || im.cells+rec.cells > maxBatchCells {
+		if err := im.flush(ctx); err != nil {
+			return err
+		}
+	}
+	im.keys = append(im.keys, rec.key)
+	im.muts = append(im.muts, rec.mut)
+	im.cells += rec.cells
+	im.res.Rows++
+	im.res.Cells += int64(rec.cells)
+	return nil
+}
+
+// maxBatchCells is the maximum number of cells written by an ApplyBulk call,
+// which matches the limit of the server on the mutations of a request.
+const maxBatchCells = 100000
+
+// flush writes the current batch.
+func (im *importer) flush(ctx context.Context) error {
+	if len(im.keys) == 0 {
+		return nil
+	}
+	keys, muts := im.keys, im.muts
+	im.keys, im.muts, im.cells = nil, nil, 0
+	if im.dryRun {
+		return nil
+	}
+	if err := im.limiter.WaitN(ctx, len(keys)); err != nil {
+		return err
+	}
+	errs, err := im.tbl.ApplyBulk(ctx, keys, muts)
+	if err != nil {
+		return err
+	}
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("writing row %q: %w", keys[i], err)
+		}
+	}
+	return nil
+}
+
+// csvReader reads the rows of a CSV file in the format documented by Import.
+type csvReader struct {
+	r    *csv.Reader
+	line int
+
+	// pending is the first record of the next row.
+	pending []string
+}
+
+func newCSVReader(r io.Reader) *csvReader {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 5
+	return &csvReader{r: cr}
+}
+
+func (cr *csvReader) next() (*record, error) {
+	var rec *record
+	for {
+		fields := cr.pending
+		cr.pending = nil
+		if fields == nil {
+			var err error
+			fields, err = cr.r.Read()
+			if err == io.EOF && rec != nil {
+				return rec, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			cr.line++
+		}
+		if rec != nil && fields[0] != rec.key {
+			cr.pending = fields
+			return rec, nil
+		}
+		if rec == nil {
+			if fields[0] == "" {
+				return nil, fmt.Errorf("record %d: empty row key", cr.line)
+			}
+			rec = &record{key: fields[0], mut: bigtable.NewMutation()}
+		}
+		ts := bigtable.ServerTime
+		if fields[3] != "" {
+			n, err := strconv.ParseInt(fields[3], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("record %d: bad timestamp: %w", cr.line, err)
+			}
+			ts = bigtable.Timestamp(n)
+		}
+		rec.mut.Set(fields[1], fields[2], ts, []byte(fields[4]))
+		rec.cells++
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"cloud.google.com/go/bigtable"
+)
+
+// readTable returns the rows of tbl formatted as strings.
+func readTable(t *testing.T, tbl *bigtable.Table) []string {
+	t.Helper()
+	var rows []string
+	err := tbl.ReadRows(context.Background(), bigtable.InfiniteRange(""), func(r bigtable.Row) bool {
+		rows = append(rows, fmt.Sprint(r))
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func TestImportAvro(t *testing.T) {
+	const numRows = 100
+	ctx := context.Background()
+	src := setupTable(t, numRows)
+	store := newMemStore()
+	if _, err := export(ctx, src, store, "backup/", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := setupTable(t, 0)
+	res, err := importRows(ctx, dst, store, "backup/", &ImportOptions{BatchSize: 7, RowsPerSecond: 1e6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Rows != numRows || res.Cells != 2*numRows {
+		t.Errorf("got %d rows and %d cells, want %d and %d", res.Rows, res.Cells, numRows, 2*numRows)
+	}
+	got, want := readTable(t, dst), readTable(t, src)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("imported rows differ from the exported rows:\ngot  %v\nwant %v", got, want)
+	}
+}
+
+func TestImportCSV(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	store.objects["data/1.csv"] = []byte("r1,a,x,1000,v1\nr1,b,y,2000,v2\nr2,a,x,1000,\"v,3\"\n")
+	store.objects["data/2.csv"] = []byte("r3,a,x,,v4\n")
+	store.objects["data/README"] = []byte("not imported")
+	dst := setupTable(t, 0)
+
+	res, err := importRows(ctx, dst, store, "data/", &ImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Rows != 3 || res.Cells != 4 || len(res.Objects) != 2 {
+		t.Errorf("dry run: got %+v, want 3 rows and 4 cells in 2 objects", res)
+	}
+	if rows := readTable(t, dst); len(rows) != 0 {
+		t.Fatalf("dry run wrote %d rows", len(rows))
+	}
+
+	if _, err := importRows(ctx, dst, store, "data/", nil); err != nil {
+		t.Fatal(err)
+	}
+	row, err := dst.ReadRow(ctx, "r2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(row["a"][0].Value); got != "v,3" {
+		t.Errorf("r2: got value %q, want %q", got, "v,3")
+	}
+	row, err = dst.ReadRow(ctx, "r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(row["a"]) != 1 || len(row["b"]) != 1 || row["b"][0].Timestamp != 2000 {
+		t.Errorf("r1: got %v", row)
+	}
+	if rows := readTable(t, dst); len(rows) != 3 {
+		t.Errorf("got %d rows, want 3", len(rows))
+	}
+
+	store.objects["data/3.csv"] = []byte("r4,a,x,notatime,v\n")
+	if _, err := importRows(ctx, dst, store, "data/", nil); err == nil {
+		t.Error("bad timestamp: got nil, want error")
+	}
+}
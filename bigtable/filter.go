@@ -17,11 +17,14 @@ limitations under the License.
 package bigtable
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 
 	btpb "google.golang.org/genproto/googleapis/bigtable/v2"
+	"rsc.io/binaryregexp"
 )
 
 // A Filter represents a row filter.
@@ -362,3 +365,151 @@ func (baf blockAllFilter) String() string { return "blockAllFilter()" }
 func (baf blockAllFilter) proto() *btpb.RowFilter {
 	return &btpb.RowFilter{Filter: &btpb.RowFilter_BlockAllFilter{BlockAllFilter: true}}
 }
+
+// chainFilterProtos returns the proto of a chain of the filters base and f,
+// or base if f is nil.
+func chainFilterProtos(base, f *btpb.RowFilter) *btpb.RowFilter {
+	if f == nil {
+		return base
+	}
+	return &btpb.RowFilter{
+		Filter: &btpb.RowFilter_Chain_{Chain: &btpb.RowFilter_Chain{Filters: []*btpb.RowFilter{base, f}}},
+	}
+}
+
+// validLabel matches the labels the server accepts.
+var validLabel = regexp.MustCompile(`^[a-z0-9\-]{1,15}$`)
+
+// validateFilter checks f and its sub-filters against the rules the server
+// enforces, so that a conditional mutation with an invalid filter fails with
+// an error that locates the offending filter. path is the location of f, to
+// which those of its sub-filters are appended.
+func validateFilter(f Filter, path string) error {
+	if f == nil {
+		return fmt.Errorf("%s: filter is nil", path)
+	}
+	var err error
+	switch f := f.(type) {
+	case chainFilter:
+		if len(f.sub) < 2 {
+			return fmt.Errorf("%s: a chain must hold at least two filters, got %d", path, len(f.sub))
+		}
+		labeled := -1
+		for i, sf := range f.sub {
+			p := fmt.Sprintf("%s.chain[%d]", path, i)
+			if err := validateFilter(sf, p); err != nil {
+				return err
+			}
+			if hasLabel(sf) {
+				if labeled >= 0 {
+					return fmt.Errorf("%s (%s): a chain can hold only one filter that applies a label, and chain[%d] already does", p, sf, labeled)
+				}
+				labeled = i
+			}
+		}
+		return nil
+	case interleaveFilter:
+		if len(f.sub) < 2 {
+			return fmt.Errorf("%s: an interleave must hold at least two filters, got %d", path, len(f.sub))
+		}
+		for i, sf := range f.sub {
+			if err := validateFilter(sf, fmt.Sprintf("%s.interleave[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case conditionFilter:
+		if err := validateFilter(f.predicateFilter, path+".condition.predicate"); err != nil {
+			return err
+		}
+		for _, b := range []struct {
+			name string
+			f    Filter
+		}{{"true", f.trueFilter}, {"false", f.falseFilter}} {
+			if b.f == nil {
+				continue
+			}
+			if err := validateFilter(b.f, path+".condition."+b.name); err != nil {
+				return err
+			}
+		}
+		return nil
+	case rowKeyFilter:
+		if f == "" {
+			err = errors.New("row key pattern must not be empty")
+		} else {
+			err = validatePattern(string(f))
+		}
+	case familyFilter:
+		err = validatePattern(string(f))
+	case columnFilter:
+		err = validatePattern(string(f))
+	case valueFilter:
+		err = validatePattern(string(f))
+	case latestNFilter:
+		if f <= 0 {
+			err = fmt.Errorf("number of cells per column must be positive, got %d", f)
+		}
+	case cellsPerRowLimitFilter:
+		if f <= 0 {
+			err = fmt.Errorf("number of cells per row must be positive, got %d", f)
+		}
+	case cellsPerRowOffsetFilter:
+		if f < 0 {
+			err = fmt.Errorf("offset of cells per row must not be negative, got %d", f)
+		}
+	case rowSampleFilter:
+		if f <= 0 || f >= 1 {
+			err = fmt.Errorf("row sample probability must be between 0 and 1, exclusive, got %v", float64(f))
+		}
+	case labelFilter:
+		if !validLabel.MatchString(string(f)) {
+			err = fmt.Errorf("label %q must be 1 to 15 characters among a-z, 0-9 and -", string(f))
+		}
+	case columnRangeFilter:
+		if f.family == "" {
+			err = errors.New("column range must have a family")
+		}
+	case timestampRangeFilter:
+		if f.endTime != 0 && f.startTime.TruncateToMilliseconds() > f.endTime.TruncateToMilliseconds() {
+			err = fmt.Errorf("timestamp range start %d is after its end %d", f.startTime, f.endTime)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s (%s): %w", path, f, err)
+	}
+	return nil
+}
+
+// validatePattern checks that pattern is a valid regular expression. Like
+// the server, it treats the pattern and the input as bytes rather than
+// UTF-8.
+func validatePattern(pattern string) error {
+	if _, err := binaryregexp.Compile(pattern); err != nil {
+		return fmt.Errorf("invalid regular expression %q: %v", pattern, err)
+	}
+	return nil
+}
+
+// hasLabel reports whether f or one of its sub-filters applies a label.
+func hasLabel(f Filter) bool {
+	switch f := f.(type) {
+	case labelFilter:
+		return true
+	case chainFilter:
+		for _, sf := range f.sub {
+			if hasLabel(sf) {
+				return true
+			}
+		}
+	case interleaveFilter:
+		for _, sf := range f.sub {
+			if hasLabel(sf) {
+				return true
+			}
+		}
+	case conditionFilter:
+		return hasLabel(f.predicateFilter) || hasLabel(f.trueFilter) || hasLabel(f.falseFilter)
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestValidateFilter(t *testing.T) {
+	for _, test := range []struct {
+		f       Filter
+		wantErr string // substring of the error, or "" for a valid filter
+	}{
+		{ChainFilters(FamilyFilter("cf"), LatestNFilter(1)), ""},
+		{InterleaveFilters(LabelFilter("a"), LabelFilter("b")), ""},
+		{ConditionFilter(ValueFilter("x.*"), StripValueFilter(), nil), ""},
+		{CellsPerRowOffsetFilter(0), ""},
+		{ChainFilters(FamilyFilter("cf")), "p: a chain must hold at least two filters"},
+		{InterleaveFilters(), "p: an interleave must hold at least two filters"},
+		{ChainFilters(FamilyFilter("cf"), LatestNFilter(0)), "p.chain[1] (col(*,0)): number of cells per column"},
+		{
+			ChainFilters(LabelFilter("a"), InterleaveFilters(PassAllFilter(), LabelFilter("b"))),
+			"p.chain[1] ((passAllFilter() + apply_label(b))): a chain can hold only one filter that applies a label, and chain[0] already does",
+		},
+		{
+			ConditionFilter(PassAllFilter(), nil, InterleaveFilters(StripValueFilter(), RowKeyFilter("a(b"))),
+			"p.condition.false.interleave[1] (row(a(b)): invalid regular expression",
+		},
+		{ConditionFilter(nil, PassAllFilter(), nil), "p.condition.predicate: filter is nil"},
+		{RowKeyFilter(""), "row key pattern must not be empty"},
+		{CellsPerRowLimitFilter(0), "number of cells per row must be positive"},
+		{CellsPerRowOffsetFilter(-1), "offset of cells per row must not be negative"},
+		{RowSampleFilter(1), "row sample probability"},
+		{LabelFilter("Bad_Label"), `label "Bad_Label"`},
+		{ColumnRangeFilter("", "a", "b"), "column range must have a family"},
+		{TimestampRangeFilterMicros(2000, 1000), "timestamp range start 2000 is after its end 1000"},
+	} {
+		err := validateFilter(test.f, "p")
+		switch {
+		case test.wantErr == "" && err != nil:
+			t.Errorf("%v: got error %v, want nil", test.f, err)
+		case test.wantErr != "" && (err == nil || !strings.Contains(err.Error(), test.wantErr)):
+			t.Errorf("%v: got error %v, want one containing %q", test.f, err, test.wantErr)
+		}
+	}
+}
+
+func TestApplyInvalidConditionFilter(t *testing.T) {
+	ctx := context.Background()
+	tbl, cleanup, err := setupFakeServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	mut := NewMutation()
+	mut.Set("cf", "col", 1000, []byte("v"))
+	cond := NewCondMutation(ChainFilters(ColumnFilter("col")), mut, nil)
+	err = tbl.Apply(ctx, "row", cond)
+	if status.Code(err) != codes.InvalidArgument || !strings.Contains(err.Error(), "bigtable: invalid condition filter at predicate") {
+		t.Errorf("got error %v, want an InvalidArgument error locating the predicate", err)
+	}
+}
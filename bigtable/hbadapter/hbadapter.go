@@ -0,0 +1,271 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package hbadapter provides an HBase-style API of Gets, Scans, Puts and
+// Deletes on top of a Cloud Bigtable table, to ease the migration of code
+// written against the HBase client. The operations map to ReadRows and
+// Apply; they are not a complete emulation of HBase.
+//
+// As with HBase, Gets and Scans read the latest version of each column
+// unless asked for more, and cells are returned sorted by family, qualifier
+// and decreasing timestamp.
+//
+// Example:
+//
+//	tbl := hbadapter.NewTable(client.Open("my-table"))
+//	put := hbadapter.NewPut("row1").AddColumn("cf", "q", []byte("v"))
+//	if err := tbl.Put(ctx, put); err != nil {
+//		// TODO: Handle error.
+//	}
+//	res, err := tbl.Get(ctx, hbadapter.NewGet("row1").AddFamily("cf"))
+//	if err != nil {
+//		// TODO: Handle error.
+//	}
+//	fmt.Printf("%s\n", res.Value("cf", "q"))
+package hbadapter // import "cloud.google.com/go/bigtable/hbadapter"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/bigtable"
+	"google.golang.org/api/iterator"
+)
+
+// scannerBuffer is the number of rows a ResultScanner reads ahead.
+const scannerBuffer = 64
+
+// A Table performs HBase-style operations on a Bigtable table.
+type Table struct {
+	tbl *bigtable.Table
+}
+
+// NewTable returns a Table that operates on tbl.
+func NewTable(tbl *bigtable.Table) *Table {
+	return &Table{tbl: tbl}
+}
+
+// A Cell is a version of a column of a row.
+type Cell struct {
+	Row       string
+	Family    string
+	Qualifier string
+	Timestamp bigtable.Timestamp
+	Value     []byte
+}
+
+// A Result holds the cells read from a row. A Get of a missing row returns
+// an empty Result.
+type Result struct {
+	row   string
+	cells []Cell
+}
+
+// Row returns the key of the row, or "" if the result is empty.
+func (r *Result) Row() string { return r.row }
+
+// IsEmpty reports whether the result holds no cells.
+func (r *Result) IsEmpty() bool { return len(r.cells) == 0 }
+
+// Cells returns the cells of the row, sorted by family, qualifier and
+// decreasing timestamp.
+func (r *Result) Cells() []Cell { return r.cells }
+
+// ColumnCells returns the versions of the column family:qualifier, latest
+// first.
+func (r *Result) ColumnCells(family, qualifier string) []Cell {
+	i := sort.Search(len(r.cells), func(i int) bool {
+		c := r.cells[i]
+		return c.Family > family || c.Family == family && c.Qualifier >= qualifier
+	})
+	j := i
+	for j < len(r.cells) && r.cells[j].Family == family && r.cells[j].Qualifier == qualifier {
+		j++
+	}
+	return r.cells[i:j]
+}
+
+// Value returns the value of the latest version of the column
+// family:qualifier, or nil if the result does not hold the column.
+func (r *Result) Value(family, qualifier string) []byte {
+	if cells := r.ColumnCells(family, qualifier); len(cells) > 0 {
+		return cells[0].Value
+	}
+	return nil
+}
+
+// newResult converts a row read from Bigtable.
+func newResult(row bigtable.Row) *Result {
+	r := &Result{row: row.Key()}
+	for family, items := range row {
+		for _, item := range items {
+			r.cells = append(r.cells, Cell{
+				Row:       item.Row,
+				Family:    family,
+				Qualifier: strings.TrimPrefix(item.Column, family+":"),
+				Timestamp: item.Timestamp,
+				Value:     item.Value,
+			})
+		}
+	}
+	sort.SliceStable(r.cells, func(i, j int) bool {
+		a, b := r.cells[i], r.cells[j]
+		if a.Family != b.Family {
+			return a.Family < b.Family
+		}
+		if a.Qualifier != b.Qualifier {
+			return a.Qualifier < b.Qualifier
+		}
+		return a.Timestamp > b.Timestamp
+	})
+	return r
+}
+
+// Get reads the row of g.
+func (t *Table) Get(ctx context.Context, g *Get) (*Result, error) {
+	row, err := t.tbl.ReadRow(ctx, g.row, g.sel.readOptions()...)
+	if err != nil {
+		return nil, err
+	}
+	return newResult(row), nil
+}
+
+// Exists reports whether the row of g has any of the cells g selects.
+func (t *Table) Exists(ctx context.Context, g *Get) (bool, error) {
+	f := bigtable.ChainFilters(bigtable.CellsPerRowLimitFilter(1), bigtable.StripValueFilter())
+	if sf := g.sel.filter(); sf != nil {
+		f = bigtable.ChainFilters(sf, bigtable.CellsPerRowLimitFilter(1), bigtable.StripValueFilter())
+	}
+	row, err := t.tbl.ReadRow(ctx, g.row, bigtable.RowFilter(f))
+	if err != nil {
+		return false, err
+	}
+	return len(row) > 0, nil
+}
+
+// Put applies puts. A single Put is applied with Apply, several with
+// ApplyBulk; each Put is atomic, but they are not atomic together. If
+// several puts fail, the error is the first one.
+func (t *Table) Put(ctx context.Context, puts ...*Put) error {
+	keys := make([]string, len(puts))
+	muts := make([]*bigtable.Mutation, len(puts))
+	for i, p := range puts {
+		if p.n == 0 {
+			return fmt.Errorf("hbadapter: put of row %q has no columns", p.row)
+		}
+		keys[i], muts[i] = p.row, p.mut
+	}
+	return t.apply(ctx, keys, muts)
+}
+
+// Delete applies deletes, as Put applies puts.
+func (t *Table) Delete(ctx context.Context, deletes ...*Delete) error {
+	keys := make([]string, len(deletes))
+	muts := make([]*bigtable.Mutation, len(deletes))
+	for i, d := range deletes {
+		keys[i], muts[i] = d.row, d.mutation()
+	}
+	return t.apply(ctx, keys, muts)
+}
+
+func (t *Table) apply(ctx context.Context, keys []string, muts []*bigtable.Mutation) error {
+	switch len(muts) {
+	case 0:
+		return nil
+	case 1:
+		return t.tbl.Apply(ctx, keys[0], muts[0])
+	}
+	errs, err := t.tbl.ApplyBulk(ctx, keys, muts)
+	if err != nil {
+		return err
+	}
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("hbadapter: row %q: %w", keys[i], err)
+		}
+	}
+	return nil
+}
+
+// GetScanner starts reading the rows of s. The returned ResultScanner must be
+// closed once it is no longer used.
+func (t *Table) GetScanner(ctx context.Context, s *Scan) *ResultScanner {
+	ctx, cancel := context.WithCancel(ctx)
+	rs := &ResultScanner{
+		rows:   make(chan bigtable.Row, scannerBuffer),
+		done:   make(chan struct{}),
+		cancel: cancel,
+	}
+	opts := s.sel.readOptions()
+	if s.limit > 0 {
+		opts = append(opts, bigtable.LimitRows(s.limit))
+	}
+	go func() {
+		defer close(rs.done)
+		defer close(rs.rows)
+		rs.err = t.tbl.ReadRows(ctx, s.rowSet(), func(row bigtable.Row) bool {
+			// Rows are queued past the call of the function, so their
+			// values must not be pooled buffers, which the table may use
+			// if it was opened with TableReadOptions(PooledValues()).
+			row.Retain()
+			select {
+			case rs.rows <- row:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}, opts...)
+	}()
+	return rs
+}
+
+// A ResultScanner iterates over the rows of a Scan.
+type ResultScanner struct {
+	rows   chan bigtable.Row
+	done   chan struct{}
+	cancel context.CancelFunc
+	err    error // set before done is closed
+	closed bool
+}
+
+// Next returns the next row. Its second return value is iterator.Done if
+// there are no more rows, or the error that ended the scan.
+func (rs *ResultScanner) Next() (*Result, error) {
+	if rs.closed {
+		return nil, errors.New("hbadapter: ResultScanner is closed")
+	}
+	if row, ok := <-rs.rows; ok {
+		return newResult(row), nil
+	}
+	<-rs.done
+	if rs.err != nil {
+		return nil, rs.err
+	}
+	return nil, iterator.Done
+}
+
+// Close stops the scan and releases its resources.
+func (rs *ResultScanner) Close() {
+	if rs.closed {
+		return
+	}
+	rs.closed = true
+	rs.cancel()
+	<-rs.done
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hbadapter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"cloud.google.com/go/bigtable"
+	"cloud.google.com/go/bigtable/bttest"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+)
+
+func setupTable(t *testing.T) *Table {
+	ctx := context.Background()
+	srv, err := bttest.NewServer("localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(srv.Close)
+	conn, err := grpc.Dial(srv.Addr, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	adminClient, err := bigtable.NewAdminClient(ctx, "project", "instance", option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := adminClient.CreateTable(ctx, "table"); err != nil {
+		t.Fatal(err)
+	}
+	for _, fam := range []string{"a", "b"} {
+		if err := adminClient.CreateColumnFamily(ctx, "table", fam); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client, err := bigtable.NewClient(ctx, "project", "instance", option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewTable(client.Open("table"))
+}
+
+func TestGetPutDelete(t *testing.T) {
+	ctx := context.Background()
+	tbl := setupTable(t)
+
+	err := tbl.Put(ctx,
+		NewPut("r1").AddColumnAt("a", "x", 1000, []byte("x1")).AddColumnAt("a", "x", 2000, []byte("x2")).AddColumnAt("b", "y", 1000, []byte("y1")),
+		NewPut("r2").AddColumnAt("a", "x", 1000, []byte("other")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tbl.Put(ctx, NewPut("r3")); err == nil {
+		t.Error("Put without columns: got nil, want error")
+	}
+
+	res, err := tbl.Get(ctx, NewGet("r1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Cell{
+		{Row: "r1", Family: "a", Qualifier: "x", Timestamp: 2000, Value: []byte("x2")},
+		{Row: "r1", Family: "b", Qualifier: "y", Timestamp: 1000, Value: []byte("y1")},
+	}
+	if !cmp.Equal(res.Cells(), want) {
+		t.Errorf("Get: got %v, want %v", res.Cells(), want)
+	}
+
+	res, err = tbl.Get(ctx, NewGet("r1").AddColumn("a", "x").ReadAllVersions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(res.ColumnCells("a", "x")); got != 2 || len(res.Cells()) != 2 {
+		t.Errorf("Get of all versions of a:x: got %d of %d cells, want 2 of 2", got, len(res.Cells()))
+	}
+	if got := string(res.Value("a", "x")); got != "x2" {
+		t.Errorf("Value: got %q, want %q", got, "x2")
+	}
+	if res.Value("b", "y") != nil {
+		t.Error("Value of a column not read: got a value, want nil")
+	}
+
+	res, err = tbl.Get(ctx, NewGet("r1").AddColumn("a", "x").SetTimeRange(0, 2000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(res.Value("a", "x")); got != "x1" {
+		t.Errorf("Get in time range: got %q, want %q", got, "x1")
+	}
+
+	if err := tbl.Delete(ctx, NewDelete("r1").AddFamily("b")); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := tbl.Exists(ctx, NewGet("r1").AddFamily("b")); err != nil || ok {
+		t.Errorf("Exists after deleting the family: got (%t, %v), want false", ok, err)
+	}
+	if err := tbl.Delete(ctx, NewDelete("r1")); err != nil {
+		t.Fatal(err)
+	}
+	res, err = tbl.Get(ctx, NewGet("r1"))
+	if err != nil || !res.IsEmpty() || res.Row() != "" {
+		t.Errorf("Get of a deleted row: got (%v, %v), want an empty result", res, err)
+	}
+	if ok, err := tbl.Exists(ctx, NewGet("r2")); err != nil || !ok {
+		t.Errorf("Exists: got (%t, %v), want true", ok, err)
+	}
+}
+
+func TestScanner(t *testing.T) {
+	ctx := context.Background()
+	tbl := setupTable(t)
+	var puts []*Put
+	for i := 0; i < 20; i++ {
+		puts = append(puts, NewPut(fmt.Sprintf("row%02d", i)).AddColumnAt("a", "x", 1000, []byte{byte(i)}))
+	}
+	if err := tbl.Put(ctx, puts...); err != nil {
+		t.Fatal(err)
+	}
+
+	scan := func(s *Scan) []string {
+		rs := tbl.GetScanner(ctx, s)
+		defer rs.Close()
+		var keys []string
+		for {
+			res, err := rs.Next()
+			if err == iterator.Done {
+				return keys
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			keys = append(keys, res.Row())
+		}
+	}
+	for _, test := range []struct {
+		desc string
+		scan *Scan
+		want []string
+	}{
+		{"start and stop", NewScan().WithStartRow("row05").WithStopRow("row08"), []string{"row05", "row06", "row07"}},
+		{"prefix", NewScan().SetRowPrefixFilter("row1").WithStopRow("row12"), []string{"row10", "row11"}},
+		{"limit", NewScan().SetLimit(2), []string{"row00", "row01"}},
+		{"disjoint prefix", NewScan().WithStartRow("row05").SetRowPrefixFilter("row0").WithStopRow("row03"), nil},
+	} {
+		if got := scan(test.scan); !cmp.Equal(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.desc, got, test.want)
+		}
+	}
+
+	// Closing a scanner before the end stops it.
+	rs := tbl.GetScanner(ctx, NewScan())
+	if _, err := rs.Next(); err != nil {
+		t.Fatal(err)
+	}
+	rs.Close()
+	if _, err := rs.Next(); err == nil {
+		t.Error("Next after Close: got nil, want error")
+	}
+}
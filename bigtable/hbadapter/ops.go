@@ -0,0 +1,302 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hbadapter
+
+import (
+	"regexp"
+	"strings"
+
+	"cloud.google.com/go/bigtable"
+)
+
+// A Get reads a single row. By default it reads the latest version of every
+// column of the row.
+type Get struct {
+	row string
+	sel selection
+}
+
+// NewGet returns a Get of row.
+func NewGet(row string) *Get {
+	return &Get{row: row, sel: selection{versions: 1}}
+}
+
+// AddFamily makes g read the columns of family, in addition to the other
+// families and columns added. It returns g.
+func (g *Get) AddFamily(family string) *Get {
+	g.sel.addFamily(family)
+	return g
+}
+
+// AddColumn makes g read the column family:qualifier, in addition to the
+// other families and columns added. It returns g.
+func (g *Get) AddColumn(family, qualifier string) *Get {
+	g.sel.addColumn(family, qualifier)
+	return g
+}
+
+// SetTimeRange restricts g to the cells with timestamps in [min, max). A
+// zero max means no upper bound. As with TimestampRangeFilterMicros, the
+// bounds are truncated to milliseconds. It returns g.
+func (g *Get) SetTimeRange(min, max bigtable.Timestamp) *Get {
+	g.sel.setTimeRange(min, max)
+	return g
+}
+
+// ReadVersions makes g read the latest n versions of each column, instead of
+// the latest only. It returns g.
+func (g *Get) ReadVersions(n int) *Get {
+	g.sel.versions = n
+	return g
+}
+
+// ReadAllVersions makes g read all the versions of each column. It returns
+// g.
+func (g *Get) ReadAllVersions() *Get {
+	g.sel.versions = 0
+	return g
+}
+
+// A Scan reads a range of rows in key order. By default it reads the latest
+// version of every column of every row of the table.
+type Scan struct {
+	start, stop string
+	prefix      string
+	limit       int64
+	sel         selection
+}
+
+// NewScan returns a Scan of the whole table.
+func NewScan() *Scan {
+	return &Scan{sel: selection{versions: 1}}
+}
+
+// WithStartRow makes s start at row, inclusive. It returns s.
+func (s *Scan) WithStartRow(row string) *Scan {
+	s.start = row
+	return s
+}
+
+// WithStopRow makes s stop before row. An empty row means the end of the
+// table. It returns s.
+func (s *Scan) WithStopRow(row string) *Scan {
+	s.stop = row
+	return s
+}
+
+// SetRowPrefixFilter restricts s to the rows whose keys start with prefix,
+// within its start and stop rows. It returns s.
+func (s *Scan) SetRowPrefixFilter(prefix string) *Scan {
+	s.prefix = prefix
+	return s
+}
+
+// SetLimit makes s read at most n rows. It returns s.
+func (s *Scan) SetLimit(n int) *Scan {
+	s.limit = int64(n)
+	return s
+}
+
+// AddFamily makes s read the columns of family, in addition to the other
+// families and columns added. It returns s.
+func (s *Scan) AddFamily(family string) *Scan {
+	s.sel.addFamily(family)
+	return s
+}
+
+// AddColumn makes s read the column family:qualifier, in addition to the
+// other families and columns added. It returns s.
+func (s *Scan) AddColumn(family, qualifier string) *Scan {
+	s.sel.addColumn(family, qualifier)
+	return s
+}
+
+// SetTimeRange restricts s to the cells with timestamps in [min, max). A
+// zero max means no upper bound. As with TimestampRangeFilterMicros, the
+// bounds are truncated to milliseconds. It returns s.
+func (s *Scan) SetTimeRange(min, max bigtable.Timestamp) *Scan {
+	s.sel.setTimeRange(min, max)
+	return s
+}
+
+// ReadVersions makes s read the latest n versions of each column, instead of
+// the latest only. It returns s.
+func (s *Scan) ReadVersions(n int) *Scan {
+	s.sel.versions = n
+	return s
+}
+
+// ReadAllVersions makes s read all the versions of each column. It returns
+// s.
+func (s *Scan) ReadAllVersions() *Scan {
+	s.sel.versions = 0
+	return s
+}
+
+// rowSet returns the rows of s.
+func (s *Scan) rowSet() bigtable.RowSet {
+	r := bigtable.NewRange(s.start, s.stop)
+	if s.prefix == "" {
+		return r
+	}
+	if p, ok := r.Intersect(bigtable.PrefixRange(s.prefix)); ok {
+		return p
+	}
+	return bigtable.RowList{}
+}
+
+// selection holds the cells selected by a Get or a Scan.
+type selection struct {
+	families []string
+	columns  [][2]string
+	timed    bool
+	min, max bigtable.Timestamp
+	versions int // 0 for all
+}
+
+func (s *selection) addFamily(family string) { s.families = append(s.families, family) }
+
+func (s *selection) addColumn(family, qualifier string) {
+	s.columns = append(s.columns, [2]string{family, qualifier})
+}
+
+func (s *selection) setTimeRange(min, max bigtable.Timestamp) {
+	s.timed, s.min, s.max = true, min, max
+}
+
+// filter returns the filter of the selection, or nil if it selects all the
+// cells.
+func (s *selection) filter() bigtable.Filter {
+	var chain []bigtable.Filter
+	var columns []bigtable.Filter
+	if len(s.families) > 0 {
+		quoted := make([]string, len(s.families))
+		for i, f := range s.families {
+			quoted[i] = regexp.QuoteMeta(f)
+		}
+		columns = append(columns, bigtable.FamilyFilter(strings.Join(quoted, "|")))
+	}
+	for _, c := range s.columns {
+		columns = append(columns, bigtable.ChainFilters(
+			bigtable.FamilyFilter(regexp.QuoteMeta(c[0])),
+			bigtable.ColumnFilter(regexp.QuoteMeta(c[1])),
+		))
+	}
+	switch len(columns) {
+	case 0:
+	case 1:
+		chain = append(chain, columns[0])
+	default:
+		chain = append(chain, bigtable.InterleaveFilters(columns...))
+	}
+	if s.timed {
+		chain = append(chain, bigtable.TimestampRangeFilterMicros(s.min, s.max))
+	}
+	if s.versions > 0 {
+		chain = append(chain, bigtable.LatestNFilter(s.versions))
+	}
+	switch len(chain) {
+	case 0:
+		return nil
+	case 1:
+		return chain[0]
+	default:
+		return bigtable.ChainFilters(chain...)
+	}
+}
+
+// readOptions returns the options that apply the selection.
+func (s *selection) readOptions() []bigtable.ReadOption {
+	if f := s.filter(); f != nil {
+		return []bigtable.ReadOption{bigtable.RowFilter(f)}
+	}
+	return nil
+}
+
+// A Put writes cells of a single row.
+type Put struct {
+	row string
+	mut *bigtable.Mutation
+	n   int
+}
+
+// NewPut returns a Put of row.
+func NewPut(row string) *Put {
+	return &Put{row: row, mut: bigtable.NewMutation()}
+}
+
+// AddColumn writes value to the column family:qualifier with the time of the
+// server as timestamp, as HBase does for cells without a timestamp. Such
+// writes are not retried, since retrying them could create extra versions.
+// It returns p.
+func (p *Put) AddColumn(family, qualifier string, value []byte) *Put {
+	return p.AddColumnAt(family, qualifier, bigtable.ServerTime, value)
+}
+
+// AddColumnAt writes value to the column family:qualifier with timestamp ts.
+// It returns p.
+func (p *Put) AddColumnAt(family, qualifier string, ts bigtable.Timestamp, value []byte) *Put {
+	p.mut.Set(family, qualifier, ts, value)
+	p.n++
+	return p
+}
+
+// A Delete deletes cells of a single row. Without any family or column
+// added, it deletes the whole row.
+type Delete struct {
+	row string
+	mut *bigtable.Mutation
+	n   int
+}
+
+// NewDelete returns a Delete of row.
+func NewDelete(row string) *Delete {
+	return &Delete{row: row, mut: bigtable.NewMutation()}
+}
+
+// AddFamily deletes all the cells of family. It returns d.
+func (d *Delete) AddFamily(family string) *Delete {
+	d.mut.DeleteCellsInFamily(family)
+	d.n++
+	return d
+}
+
+// AddColumns deletes all the versions of the column family:qualifier. It
+// returns d.
+func (d *Delete) AddColumns(family, qualifier string) *Delete {
+	d.mut.DeleteCellsInColumn(family, qualifier)
+	d.n++
+	return d
+}
+
+// AddColumnsRange deletes the versions of the column family:qualifier with
+// timestamps in [min, max). A zero max means no upper bound. It returns d.
+func (d *Delete) AddColumnsRange(family, qualifier string, min, max bigtable.Timestamp) *Delete {
+	d.mut.DeleteTimestampRange(family, qualifier, min, max)
+	d.n++
+	return d
+}
+
+// mutation returns the mutation of d.
+func (d *Delete) mutation() *bigtable.Mutation {
+	if d.n == 0 {
+		m := bigtable.NewMutation()
+		m.DeleteRow()
+		return m
+	}
+	return d.mut
+}
@@ -20,7 +20,6 @@ package option
 import (
 	"context"
 	"encoding/base64"
-	"fmt"
 	"os"
 
 	btpb "google.golang.org/genproto/googleapis/bigtable/v2"
@@ -32,6 +31,7 @@ import (
 	"google.golang.org/api/option"
 	"google.golang.org/api/option/internaloption"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
 )
 
@@ -104,24 +104,28 @@ func unaryInterceptor(ctx context.Context, method string, req, reply interface{}
 // DefaultClientOptions returns the default client options to use for the
 // client's gRPC connection.
 func DefaultClientOptions(endpoint, mtlsEndpoint, scope, userAgent string) ([]option.ClientOption, error) {
-	var o []option.ClientOption
 	// Check the environment variables for the bigtable emulator.
-	// Dial it directly and don't pass any credentials.
 	if addr := os.Getenv("BIGTABLE_EMULATOR_HOST"); addr != "" {
-		conn, err := grpc.Dial(addr, grpc.WithInsecure())
-		if err != nil {
-			return nil, fmt.Errorf("emulator grpc.Dial: %w", err)
-		}
-		o = []option.ClientOption{option.WithGRPCConn(conn)}
-	} else {
-		o = []option.ClientOption{
-			internaloption.WithDefaultEndpoint(endpoint),
-			internaloption.WithDefaultMTLSEndpoint(mtlsEndpoint),
-			option.WithScopes(scope),
-			option.WithUserAgent(userAgent),
-		}
+		return EmulatorClientOptions(addr), nil
+	}
+	return []option.ClientOption{
+		internaloption.WithDefaultEndpoint(endpoint),
+		internaloption.WithDefaultMTLSEndpoint(mtlsEndpoint),
+		option.WithScopes(scope),
+		option.WithUserAgent(userAgent),
+	}, nil
+}
+
+// EmulatorClientOptions returns the client options to use for the client's
+// gRPC connection to the emulator at addr. The connection is insecure and
+// no credentials are looked up or passed.
+func EmulatorClientOptions(addr string) []option.ClientOption {
+	return []option.ClientOption{
+		option.WithEndpoint(addr),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+		option.WithoutAuthentication(),
+		internaloption.SkipDialSettingsValidation(),
 	}
-	return o, nil
 }
 
 // ClientInterceptorOptions returns client options to use for the client's gRPC
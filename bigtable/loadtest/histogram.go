@@ -0,0 +1,144 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loadtest
+
+import (
+	"math/bits"
+	"time"
+)
+
+// subBucketBits sets the precision of a Histogram: values are recorded with
+// a relative error of at most 1/2^(subBucketBits-1).
+const subBucketBits = 8
+
+// A Histogram records latencies in buckets whose width grows with their
+// value, in the manner of an HDR histogram: it uses little memory regardless
+// of the number of values recorded, and its quantiles are accurate to within
+// 1%. Latencies are recorded in microseconds.
+//
+// The zero value is an empty histogram. A Histogram is not safe for
+// concurrent use.
+type Histogram struct {
+	counts   []int64
+	count    int64
+	min, max int64
+}
+
+// Record adds a latency to h.
+func (h *Histogram) Record(d time.Duration) {
+	v := d.Microseconds()
+	if v < 0 {
+		v = 0
+	}
+	i := bucketOf(v)
+	if i >= len(h.counts) {
+		counts := make([]int64, i+1)
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+	h.counts[i]++
+	if h.count == 0 || v < h.min {
+		h.min = v
+	}
+	if v > h.max {
+		h.max = v
+	}
+	h.count++
+}
+
+// Merge adds the latencies recorded in o to h.
+func (h *Histogram) Merge(o *Histogram) {
+	if o.count == 0 {
+		return
+	}
+	if len(o.counts) > len(h.counts) {
+		counts := make([]int64, len(o.counts))
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+	for i, n := range o.counts {
+		h.counts[i] += n
+	}
+	if h.count == 0 || o.min < h.min {
+		h.min = o.min
+	}
+	if o.max > h.max {
+		h.max = o.max
+	}
+	h.count += o.count
+}
+
+// Count returns the number of latencies recorded.
+func (h *Histogram) Count() int64 { return h.count }
+
+// Min returns the smallest latency recorded.
+func (h *Histogram) Min() time.Duration { return time.Duration(h.min) * time.Microsecond }
+
+// Max returns the largest latency recorded.
+func (h *Histogram) Max() time.Duration { return time.Duration(h.max) * time.Microsecond }
+
+// Quantile returns the latency below which the fraction q of the recorded
+// latencies fall, or 0 if none were recorded.
+func (h *Histogram) Quantile(q float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	if q <= 0 {
+		return h.Min()
+	}
+	if q >= 1 {
+		return h.Max()
+	}
+	rank := int64(q*float64(h.count-1)) + 1
+	var seen int64
+	for i, n := range h.counts {
+		seen += n
+		if seen >= rank {
+			v := valueOf(i)
+			// Report the recorded extremes rather than the bucket bounds.
+			if v < h.min {
+				v = h.min
+			}
+			if v > h.max {
+				v = h.max
+			}
+			return time.Duration(v) * time.Microsecond
+		}
+	}
+	return h.Max()
+}
+
+// bucketOf returns the index of the bucket of v. Values below
+// 2^subBucketBits have a bucket each; above, each power of two is split into
+// 2^(subBucketBits-1) buckets.
+func bucketOf(v int64) int {
+	if v < 1<<subBucketBits {
+		return int(v)
+	}
+	shift := bits.Len64(uint64(v)) - subBucketBits
+	return shift<<(subBucketBits-1) + int(v>>shift)
+}
+
+// valueOf returns the middle of the range of values of bucket i.
+func valueOf(i int) int64 {
+	if i < 1<<subBucketBits {
+		return int64(i)
+	}
+	shift := i>>(subBucketBits-1) - 1
+	m := int64(i - shift<<(subBucketBits-1))
+	return m<<shift + (1<<shift)/2
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loadtest
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestHistogramQuantiles(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var h, h1, h2 Histogram
+	var all []time.Duration
+	for i := 0; i < 10000; i++ {
+		d := time.Duration(r.ExpFloat64()*float64(5*time.Millisecond)) + time.Microsecond
+		all = append(all, d)
+		h.Record(d)
+		if i%2 == 0 {
+			h1.Record(d)
+		} else {
+			h2.Record(d)
+		}
+	}
+	h1.Merge(&h2)
+	sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+
+	for _, hist := range []*Histogram{&h, &h1} {
+		if hist.Count() != int64(len(all)) {
+			t.Errorf("got count %d, want %d", hist.Count(), len(all))
+		}
+		if got, want := hist.Min(), all[0].Truncate(time.Microsecond); got != want {
+			t.Errorf("min: got %v, want %v", got, want)
+		}
+		if got, want := hist.Max(), all[len(all)-1].Truncate(time.Microsecond); got != want {
+			t.Errorf("max: got %v, want %v", got, want)
+		}
+		for _, q := range []float64{0.5, 0.9, 0.99} {
+			want := all[int(q*float64(len(all)-1))]
+			got := hist.Quantile(q)
+			if math.Abs(float64(got-want)) > 0.01*float64(want)+float64(time.Microsecond) {
+				t.Errorf("quantile %v: got %v, want %v within 1%%", q, got, want)
+			}
+		}
+	}
+
+	var empty Histogram
+	if empty.Quantile(0.5) != 0 || empty.Count() != 0 {
+		t.Error("empty histogram: want zero quantile and count")
+	}
+}
+
+func TestBuckets(t *testing.T) {
+	prev := -1
+	for v := int64(0); v < 1<<20; v++ {
+		i := bucketOf(v)
+		if i != prev && i != prev+1 {
+			t.Fatalf("bucketOf(%d) = %d after %d, want consecutive buckets", v, i, prev)
+		}
+		prev = i
+		if got := valueOf(i); math.Abs(float64(got-v)) > float64(v)/128+1 {
+			t.Fatalf("valueOf(bucketOf(%d)) = %d, want within 1/128", v, got)
+		}
+	}
+}
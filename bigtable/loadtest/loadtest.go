@@ -0,0 +1,357 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package loadtest generates load on a Cloud Bigtable table and measures the
+// latencies of its operations, to make capacity planning reproducible.
+//
+// Run sends a configurable mix of single-row reads and writes from
+// concurrent workers, choosing row keys with a KeyDistribution, and records
+// the latencies of each kind of operation in a Histogram. The table can be
+// served by the emulator or by Cloud Bigtable, depending on how its client
+// was created; the loadtest command in cmd/loadtest wraps Run with flags.
+//
+// Example:
+//
+//	report, err := loadtest.Run(ctx, tbl, loadtest.Config{
+//		Duration:     time.Minute,
+//		Concurrency:  16,
+//		ReadFraction: 0.9,
+//		Keys:         loadtest.Zipfian(1_000_000, 1.1),
+//	})
+//	if err != nil {
+//		// TODO: Handle error.
+//	}
+//	report.WriteCSV(os.Stdout)
+//
+// To export results to a metrics system such as OpenTelemetry, set
+// Config.OnResult.
+//
+// This package is EXPERIMENTAL and subject to change or removal without notice.
+package loadtest // import "cloud.google.com/go/bigtable/loadtest"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"cloud.google.com/go/bigtable"
+	"cloud.google.com/go/bigtable/internal/stat"
+)
+
+// Op is the kind of an operation sent by Run.
+type Op string
+
+const (
+	// Read reads a single row with Table.ReadRow.
+	Read Op = "read"
+
+	// Write sets a single cell with Table.Apply.
+	Write Op = "write"
+)
+
+// A KeyDistribution chooses the row keys of operations. It is called with a
+// random source owned by the calling worker, and must be safe for
+// concurrent use.
+type KeyDistribution interface {
+	// Key returns the index of the next row, in [0, n), where n is the
+	// number of keys returned by Count.
+	Key(r *rand.Rand) int64
+
+	// Count returns the number of distinct rows.
+	Count() int64
+}
+
+// Uniform returns a KeyDistribution that chooses each of n rows with the
+// same probability.
+func Uniform(n int64) KeyDistribution { return uniform(n) }
+
+type uniform int64
+
+func (u uniform) Key(r *rand.Rand) int64 { return r.Int63n(int64(u)) }
+func (u uniform) Count() int64           { return int64(u) }
+
+// Sequential returns a KeyDistribution that goes through n rows in order,
+// starting over after the last one. Its keys are shared by all workers.
+func Sequential(n int64) KeyDistribution { return &sequential{n: n} }
+
+type sequential struct {
+	n    int64
+	next int64
+}
+
+func (s *sequential) Key(*rand.Rand) int64 { return (atomic.AddInt64(&s.next, 1) - 1) % s.n }
+func (s *sequential) Count() int64         { return s.n }
+
+// Zipfian returns a KeyDistribution that chooses among n rows with a Zipf
+// distribution of exponent s > 1: the kth row is chosen with a probability
+// proportional to 1/k^s, which models hot spots. Larger exponents make the
+// first rows hotter. Zipfian panics if s <= 1.
+func Zipfian(n int64, s float64) KeyDistribution {
+	if !(s > 1) {
+		panic(fmt.Sprintf("loadtest: Zipf exponent %v is not greater than 1", s))
+	}
+	return zipfian{n: n, s: s}
+}
+
+type zipfian struct {
+	n int64
+	s float64
+}
+
+func (z zipfian) Key(r *rand.Rand) int64 {
+	// rand.Zipf holds no state other than its source, so one per call is
+	// cheap enough and keeps the distribution safe for concurrent use.
+	return int64(rand.NewZipf(r, z.s, 1, uint64(z.n-1)).Uint64())
+}
+
+func (z zipfian) Count() int64 { return z.n }
+
+// Config configures a load test.
+type Config struct {
+	// Duration is how long operations are sent for. Either Duration or
+	// Operations must be set; if both are, the test stops at the first limit
+	// reached.
+	Duration time.Duration
+
+	// Operations is the total number of operations to send.
+	Operations int64
+
+	// Concurrency is the number of workers sending operations. It defaults
+	// to 1.
+	Concurrency int
+
+	// ReadFraction is the fraction of the operations that are reads; the
+	// others are writes.
+	ReadFraction float64
+
+	// Keys chooses the rows of the operations. It defaults to Uniform(1e6).
+	Keys KeyDistribution
+
+	// KeyPrefix is prepended to the zero-padded index of each row to form its
+	// key.
+	KeyPrefix string
+
+	// Family and Column are the column written by writes. Family defaults to
+	// "cf" and Column to "col".
+	Family, Column string
+
+	// ValueSize is the size in bytes of the values written. It defaults to
+	// 1KB.
+	ValueSize int
+
+	// OnResult, if not nil, is called from the workers after each operation,
+	// for example to export its latency as a metric.
+	OnResult func(op Op, latency time.Duration, err error)
+}
+
+// A Report holds the results of a load test.
+type Report struct {
+	// Elapsed is the duration of the test.
+	Elapsed time.Duration
+
+	// Latencies holds the latencies of the successful operations of each
+	// kind.
+	Latencies map[Op]*Histogram
+
+	// Errors is the number of failed operations of each kind.
+	Errors map[Op]int64
+}
+
+// Throughput returns the number of operations of the given kind completed
+// per second, including failed ones.
+func (r *Report) Throughput(op Op) float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Latencies[op].Count()+r.Errors[op]) / r.Elapsed.Seconds()
+}
+
+// Aggregates returns the latencies of each kind of operation in the form
+// used by WriteCSV.
+func (r *Report) Aggregates() []*stat.Aggregate {
+	var aggs []*stat.Aggregate
+	for _, op := range []Op{Read, Write} {
+		h := r.Latencies[op]
+		if h.Count() == 0 && r.Errors[op] == 0 {
+			continue
+		}
+		aggs = append(aggs, &stat.Aggregate{
+			Name:   string(op),
+			Count:  int(h.Count()),
+			Errors: int(r.Errors[op]),
+			Min:    h.Min(),
+			Median: h.Quantile(0.5),
+			Max:    h.Max(),
+			P75:    h.Quantile(0.75),
+			P90:    h.Quantile(0.90),
+			P95:    h.Quantile(0.95),
+			P99:    h.Quantile(0.99),
+		})
+	}
+	return aggs
+}
+
+// WriteCSV writes the latencies of each kind of operation to w as CSV, with
+// a header row.
+func (r *Report) WriteCSV(w io.Writer) error {
+	return stat.WriteCSV(r.Aggregates(), w)
+}
+
+func (r *Report) String() string {
+	s := fmt.Sprintf("elapsed: %v\n", r.Elapsed)
+	for _, agg := range r.Aggregates() {
+		s += fmt.Sprintf("%s: %d ok, %d errors, %.1f/s\n%v", agg.Name, agg.Count, agg.Errors, r.Throughput(Op(agg.Name)), agg)
+	}
+	return s
+}
+
+// Run sends operations to tbl as configured by cfg, and returns a report of
+// their latencies when the test ends. Failed operations are counted, not
+// returned; Run returns an error only if cfg is invalid. Cancelling ctx ends
+// the test early.
+func Run(ctx context.Context, tbl *bigtable.Table, cfg Config) (*Report, error) {
+	if cfg.Duration <= 0 && cfg.Operations <= 0 {
+		return nil, errors.New("loadtest: Duration or Operations must be set")
+	}
+	if cfg.ReadFraction < 0 || cfg.ReadFraction > 1 {
+		return nil, fmt.Errorf("loadtest: ReadFraction %v is not in [0, 1]", cfg.ReadFraction)
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	if cfg.Keys == nil {
+		cfg.Keys = Uniform(1e6)
+	}
+	if cfg.Keys.Count() <= 0 {
+		return nil, errors.New("loadtest: the key distribution has no keys")
+	}
+	if cfg.Family == "" {
+		cfg.Family = "cf"
+	}
+	if cfg.Column == "" {
+		cfg.Column = "col"
+	}
+	if cfg.ValueSize <= 0 {
+		cfg.ValueSize = 1 << 10
+	}
+	keyWidth := len(fmt.Sprint(cfg.Keys.Count() - 1))
+
+	if cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+	var started int64
+	results := make([]*workerResult, cfg.Concurrency)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := range results {
+		w := &worker{
+			tbl:      tbl,
+			cfg:      &cfg,
+			keyWidth: keyWidth,
+			rand:     rand.New(rand.NewSource(start.UnixNano() + int64(i))),
+			res:      newWorkerResult(),
+		}
+		results[i] = w.res
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				if cfg.Operations > 0 && atomic.AddInt64(&started, 1) > cfg.Operations {
+					return
+				}
+				w.do(ctx)
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := &Report{
+		Elapsed:   time.Since(start),
+		Latencies: map[Op]*Histogram{Read: {}, Write: {}},
+		Errors:    map[Op]int64{},
+	}
+	for _, res := range results {
+		for op, h := range res.latencies {
+			report.Latencies[op].Merge(h)
+		}
+		for op, n := range res.errors {
+			report.Errors[op] += n
+		}
+	}
+	return report, nil
+}
+
+type workerResult struct {
+	latencies map[Op]*Histogram
+	errors    map[Op]int64
+}
+
+func newWorkerResult() *workerResult {
+	return &workerResult{
+		latencies: map[Op]*Histogram{Read: {}, Write: {}},
+		errors:    map[Op]int64{},
+	}
+}
+
+type worker struct {
+	tbl      *bigtable.Table
+	cfg      *Config
+	keyWidth int
+	rand     *rand.Rand
+	res      *workerResult
+}
+
+// do sends one operation and records its result.
+func (w *worker) do(ctx context.Context) {
+	key := fmt.Sprintf("%s%0*d", w.cfg.KeyPrefix, w.keyWidth, w.cfg.Keys.Key(w.rand))
+	op := Write
+	if w.rand.Float64() < w.cfg.ReadFraction {
+		op = Read
+	}
+
+	start := time.Now()
+	var err error
+	if op == Read {
+		_, err = w.tbl.ReadRow(ctx, key)
+	} else {
+		value := make([]byte, w.cfg.ValueSize)
+		w.rand.Read(value)
+		mut := bigtable.NewMutation()
+		mut.Set(w.cfg.Family, w.cfg.Column, bigtable.Now(), value)
+		err = w.tbl.Apply(ctx, key, mut)
+	}
+	latency := time.Since(start)
+	if ctx.Err() != nil && err != nil {
+		// The test ended during the operation.
+		return
+	}
+
+	if err != nil {
+		w.res.errors[op]++
+	} else {
+		w.res.latencies[op].Record(latency)
+	}
+	if w.cfg.OnResult != nil {
+		w.cfg.OnResult(op, latency, err)
+	}
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigtable"
+	"cloud.google.com/go/bigtable/bttest"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+)
+
+func setupTable(t *testing.T) *bigtable.Table {
+	ctx := context.Background()
+	srv, err := bttest.NewServer("localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(srv.Close)
+	conn, err := grpc.Dial(srv.Addr, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	adminClient, err := bigtable.NewAdminClient(ctx, "project", "instance", option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := adminClient.CreateTable(ctx, "table"); err != nil {
+		t.Fatal(err)
+	}
+	if err := adminClient.CreateColumnFamily(ctx, "table", "cf"); err != nil {
+		t.Fatal(err)
+	}
+	client, err := bigtable.NewClient(ctx, "project", "instance", option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client.Open("table")
+}
+
+func TestRun(t *testing.T) {
+	tbl := setupTable(t)
+	var mu sync.Mutex
+	results := map[Op]int{}
+	report, err := Run(context.Background(), tbl, Config{
+		Operations:   200,
+		Concurrency:  4,
+		ReadFraction: 0.5,
+		Keys:         Zipfian(100, 1.5),
+		ValueSize:    16,
+		OnResult: func(op Op, _ time.Duration, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				results[op]++
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reads, writes := report.Latencies[Read].Count(), report.Latencies[Write].Count()
+	if reads+writes != 200 || report.Errors[Read]+report.Errors[Write] != 0 {
+		t.Errorf("got %d reads, %d writes and %v errors, want 200 successful operations", reads, writes, report.Errors)
+	}
+	if reads == 0 || writes == 0 {
+		t.Errorf("got %d reads and %d writes, want both", reads, writes)
+	}
+	if int64(results[Read]) != reads || int64(results[Write]) != writes {
+		t.Errorf("OnResult: got %v, want %d reads and %d writes", results, reads, writes)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || records[1][0] != "read" || records[2][0] != "write" {
+		t.Errorf("got CSV %q, want a header and a row for reads and writes", records)
+	}
+}
+
+func TestRunDuration(t *testing.T) {
+	tbl := setupTable(t)
+	start := time.Now()
+	report, err := Run(context.Background(), tbl, Config{Duration: 200 * time.Millisecond, Keys: Sequential(10)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("test ran for %v, want about 200ms", elapsed)
+	}
+	if report.Latencies[Write].Count() == 0 {
+		t.Error("got no writes")
+	}
+}
+
+func TestRunInvalidConfig(t *testing.T) {
+	for _, cfg := range []Config{
+		{},
+		{Operations: 1, ReadFraction: 2},
+		{Operations: 1, Keys: Uniform(0)},
+	} {
+		if _, err := Run(context.Background(), nil, cfg); err == nil {
+			t.Errorf("Run(%+v): got nil, want error", cfg)
+		}
+	}
+}
+
+func TestKeyDistributions(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	seq := Sequential(3)
+	for i, want := range []int64{0, 1, 2, 0, 1} {
+		if got := seq.Key(r); got != want {
+			t.Errorf("sequential key %d: got %d, want %d", i, got, want)
+		}
+	}
+
+	zipf := Zipfian(1000, 1.5)
+	counts := map[int64]int{}
+	for i := 0; i < 10000; i++ {
+		k := zipf.Key(r)
+		if k < 0 || k >= 1000 {
+			t.Fatalf("zipfian key %d out of range", k)
+		}
+		counts[k]++
+	}
+	if counts[0] < counts[1] || counts[1] < counts[10] {
+		t.Errorf("zipfian: got counts %d, %d and %d for keys 0, 1 and 10, want decreasing", counts[0], counts[1], counts[10])
+	}
+
+	uni := Uniform(10)
+	for i := 0; i < 100; i++ {
+		if k := uni.Key(r); k < 0 || k >= 10 {
+			t.Fatalf("uniform key %d out of range", k)
+		}
+	}
+}
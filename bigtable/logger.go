@@ -0,0 +1,89 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"context"
+	"time"
+
+	gax "github.com/googleapis/gax-go/v2"
+)
+
+// A Logger receives debug messages about the internal behavior of a client,
+// such as retries, that are otherwise only visible in traces. Debugf is
+// called with the context of the operation, so that implementations can
+// include values it carries, such as request IDs, in their output.
+//
+// Implementations must be safe for concurrent use.
+type Logger interface {
+	Debugf(ctx context.Context, format string, args ...interface{})
+}
+
+// debugf logs a debug message to the logger of the client, if any.
+func (c *Client) debugf(ctx context.Context, format string, args ...interface{}) {
+	if c.logger != nil {
+		c.logger.Debugf(ctx, format, args...)
+	}
+}
+
+// withRetryLogging returns opts followed by an option that logs the
+// decisions of their retry policy, if the client has a logger.
+func (c *Client) withRetryLogging(ctx context.Context, method string, opts []gax.CallOption) []gax.CallOption {
+	if c.logger == nil {
+		return opts
+	}
+	return append(opts[:len(opts):len(opts)], logRetries{c: c, ctx: ctx, method: method})
+}
+
+// logRetries is a gax.CallOption that wraps the retry policy set by the
+// options before it.
+type logRetries struct {
+	c      *Client
+	ctx    context.Context
+	method string
+}
+
+func (o logRetries) Resolve(s *gax.CallSettings) {
+	if s.Retry == nil {
+		return
+	}
+	newRetryer := s.Retry
+	s.Retry = func() gax.Retryer {
+		r := newRetryer()
+		if r == nil {
+			return nil
+		}
+		return &loggingRetryer{r: r, o: o}
+	}
+}
+
+type loggingRetryer struct {
+	r       gax.Retryer
+	o       logRetries
+	attempt int
+}
+
+func (r *loggingRetryer) Retry(err error) (time.Duration, bool) {
+	r.attempt++
+	pause, retry := r.r.Retry(err)
+	if retry {
+		r.o.c.debugf(r.o.ctx, "bigtable: %s: attempt %d failed, retrying in %v: %v", r.o.method, r.attempt, pause, err)
+	} else {
+		r.o.c.debugf(r.o.ctx, "bigtable: %s: attempt %d failed, not retrying: %v", r.o.method, r.attempt, err)
+	}
+	return pause, retry
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type recordingLogger struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (l *recordingLogger) Debugf(_ context.Context, format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.msgs = append(l.msgs, fmt.Sprintf(format, args...))
+}
+
+func TestLoggerRetries(t *testing.T) {
+	ctx := context.Background()
+	var failures int32
+	fail := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			return nil, status.Error(codes.Unavailable, "try again")
+		}
+		return handler(ctx, req)
+	}
+	tbl, cleanup, err := setupFakeServer(grpc.UnaryInterceptor(fail))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	logger := &recordingLogger{}
+	tbl.c.logger = logger
+
+	mut := NewMutation()
+	mut.Set("cf", "col", 1000, []byte("v"))
+	atomic.StoreInt32(&failures, 2)
+	if err := tbl.Apply(ctx, "row", mut); err != nil {
+		t.Fatal(err)
+	}
+	if len(logger.msgs) != 2 {
+		t.Fatalf("got %d messages, want 2: %q", len(logger.msgs), logger.msgs)
+	}
+	for i, msg := range logger.msgs {
+		want := fmt.Sprintf("Apply: attempt %d failed, retrying", i+1)
+		if !strings.Contains(msg, want) || !strings.Contains(msg, "try again") {
+			t.Errorf("message %d: got %q, want it to contain %q and the error", i, msg, want)
+		}
+	}
+
+	// Mutations that are not idempotent are not retried.
+	logger.msgs = nil
+	atomic.StoreInt32(&failures, 1)
+	mut = NewMutation()
+	mut.Set("cf", "col", ServerTime, []byte("v"))
+	if err := tbl.Apply(ctx, "row", mut); status.Code(err) != codes.Unavailable {
+		t.Fatalf("got %v, want Unavailable", err)
+	}
+	if len(logger.msgs) != 0 {
+		t.Errorf("got messages for an operation without retries: %q", logger.msgs)
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import "context"
+
+// ReadRowsOrdered reads the rows with the given keys and calls f for each key,
+// in the order of keys. Reading a RowList with ReadRows returns the rows in
+// the order of their keys in the table instead; ReadRowsOrdered buffers the
+// rows that arrive before those of the keys listed ahead of them.
+//
+// f is called with a nil Row for a key without a row, and once for each
+// occurrence of a key listed more than once. If f returns false, the read
+// stops and ReadRowsOrdered returns nil. The options apply as for ReadRows;
+// the keys of the rows left out by LimitRows are reported as without a row.
+func (t *Table) ReadRowsOrdered(ctx context.Context, keys []string, f func(key string, r Row) bool, opts ...ReadOption) error {
+	o := &orderedReader{
+		keys:      keys,
+		remaining: map[string]int{},
+		buf:       map[string]Row{},
+		f:         f,
+	}
+	var unique RowList
+	for _, k := range keys {
+		if o.remaining[k] == 0 {
+			unique = append(unique, k)
+		}
+		o.remaining[k]++
+	}
+	for _, opt := range opts {
+		if _, ok := opt.(reverseScan); ok {
+			o.reversed = true
+		}
+	}
+	if len(unique) == 0 {
+		return nil
+	}
+	// Rows are buffered past the call of the function, so their values
+	// can't be pooled.
+	opts = append(append([]ReadOption(nil), opts...), pooledValues(false))
+	err := t.ReadRows(ctx, unique, func(r Row) bool {
+		k := r.Key()
+		if o.remaining[k] > 0 {
+			o.buf[k] = r
+		}
+		o.last, o.started = k, true
+		return o.flush(false)
+	}, opts...)
+	if err != nil || o.stopped {
+		return err
+	}
+	o.flush(true)
+	return nil
+}
+
+// orderedReader reorders the rows read by ReadRowsOrdered.
+type orderedReader struct {
+	keys      []string
+	next      int            // index in keys of the next key to report
+	remaining map[string]int // number of times each key is still to be reported
+	buf       map[string]Row // rows read but not reported for all their keys
+	f         func(string, Row) bool
+
+	reversed bool
+	started  bool   // whether a row was read
+	last     string // the key of the last row read
+	stopped  bool   // whether f returned false
+}
+
+// passed reports whether the rows read are past the row with key k, which
+// therefore does not exist if it is not buffered.
+func (o *orderedReader) passed(k string) bool {
+	if !o.started {
+		return false
+	}
+	if o.reversed {
+		return k > o.last
+	}
+	return k < o.last
+}
+
+// flush reports the keys whose row has been read or is known not to exist,
+// up to the first that is neither. If done is true, the read has completed,
+// so all the keys are reported. It returns false if f returned false.
+func (o *orderedReader) flush(done bool) bool {
+	for ; o.next < len(o.keys); o.next++ {
+		k := o.keys[o.next]
+		r, ok := o.buf[k]
+		if !ok && !done && !o.passed(k) {
+			return true
+		}
+		o.remaining[k]--
+		if o.remaining[k] == 0 {
+			delete(o.buf, k)
+		}
+		if !o.f(k, r) {
+			o.next++
+			o.stopped = true
+			return false
+		}
+	}
+	return true
+}
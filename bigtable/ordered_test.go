@@ -0,0 +1,116 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadRowsOrdered(t *testing.T) {
+	ctx := context.Background()
+	tbl, cleanup, err := setupFakeServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	for _, k := range []string{"a", "b", "c", "d"} {
+		mut := NewMutation()
+		mut.Set("cf", "col", 1000, []byte("v"+k))
+		if err := tbl.Apply(ctx, k, mut); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	read := func(keys []string, stopAfter int, opts ...ReadOption) []string {
+		var got []string
+		err := tbl.ReadRowsOrdered(ctx, keys, func(key string, r Row) bool {
+			if r == nil {
+				got = append(got, key+"=nil")
+			} else {
+				got = append(got, fmt.Sprintf("%s=%s", key, r["cf"][0].Value))
+			}
+			return len(got) != stopAfter
+		}, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	for _, test := range []struct {
+		keys      []string
+		stopAfter int
+		opts      []ReadOption
+		want      []string
+	}{
+		{
+			keys: []string{"d", "a", "c"},
+			want: []string{"d=vd", "a=va", "c=vc"},
+		},
+		{
+			keys: []string{"c", "x", "b", "0", "c"},
+			want: []string{"c=vc", "x=nil", "b=vb", "0=nil", "c=vc"},
+		},
+		{
+			keys:      []string{"d", "c", "b", "a"},
+			stopAfter: 2,
+			want:      []string{"d=vd", "c=vc"},
+		},
+		{
+			keys: []string{"b", "d", "a"},
+			opts: []ReadOption{LimitRows(2)},
+			want: []string{"b=vb", "d=nil", "a=va"},
+		},
+		{
+			keys: nil,
+			want: nil,
+		},
+	} {
+		got := read(test.keys, test.stopAfter, test.opts...)
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%v: mismatch (-want +got):\n%s", test.keys, diff)
+		}
+	}
+}
+
+func TestOrderedReaderReversed(t *testing.T) {
+	var got []string
+	o := &orderedReader{
+		keys:      []string{"a", "c", "b"},
+		remaining: map[string]int{"a": 1, "b": 1, "c": 1},
+		buf:       map[string]Row{},
+		f: func(k string, r Row) bool {
+			got = append(got, fmt.Sprintf("%s=%t", k, r != nil))
+			return true
+		},
+		reversed: true,
+	}
+	// Rows arrive in reverse order; the row of b does not exist.
+	for _, k := range []string{"c", "a"} {
+		o.buf[k] = Row{}
+		o.last, o.started = k, true
+		o.flush(false)
+	}
+	if want := []string{"a=true", "c=true", "b=false"}; !cmp.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/api/iterator"
+)
+
+// ReadRowPaged returns an iterator over the cells of a single row, in pages
+// of at most pageSize cells, so that a very wide row can be processed
+// without holding all its cells in memory. Each page is read with a separate
+// request that skips the cells of the previous pages with a
+// CellsPerRowOffsetFilter; if the row is modified during the iteration,
+// cells may be skipped or returned twice.
+//
+// The options apply to each page as for ReadRow. The filter of a RowFilter
+// option is applied before the cells are paginated.
+func (t *Table) ReadRowPaged(ctx context.Context, row string, pageSize int, opts ...ReadOption) *RowPageIterator {
+	it := &RowPageIterator{ctx: ctx, t: t, row: row, pageSize: pageSize}
+	for _, o := range opts {
+		if rf, ok := o.(rowFilter); ok {
+			it.filter = rf.f
+			continue
+		}
+		it.opts = append(it.opts, o)
+	}
+	return it
+}
+
+// RowPageIterator iterates over the pages of the cells of a row. It is
+// returned by Table.ReadRowPaged.
+type RowPageIterator struct {
+	ctx      context.Context
+	t        *Table
+	row      string
+	pageSize int
+	filter   Filter
+	opts     []ReadOption
+
+	offset int
+	done   bool
+}
+
+// Next returns the next page of cells of the row, as a Row holding only
+// those cells. Its second return value is iterator.Done if there are no more
+// cells. Once Next returns Done, all subsequent calls will return Done.
+func (it *RowPageIterator) Next() (Row, error) {
+	if it.done {
+		return nil, iterator.Done
+	}
+	if it.pageSize <= 0 {
+		it.done = true
+		return nil, errors.New("bigtable: page size must be positive")
+	}
+	filters := []Filter{CellsPerRowOffsetFilter(it.offset), CellsPerRowLimitFilter(it.pageSize)}
+	if it.filter != nil {
+		filters = append([]Filter{it.filter}, filters...)
+	}
+	opts := append(it.opts[:len(it.opts):len(it.opts)], RowFilter(ChainFilters(filters...)))
+	r, err := it.t.ReadRow(it.ctx, it.row, opts...)
+	if err != nil {
+		return nil, err
+	}
+	n := 0
+	for _, items := range r {
+		n += len(items)
+	}
+	if n < it.pageSize {
+		it.done = true
+	}
+	if n == 0 {
+		return nil, iterator.Done
+	}
+	it.offset += n
+	return r, nil
+}
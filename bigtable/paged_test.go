@@ -0,0 +1,76 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"google.golang.org/api/iterator"
+)
+
+func TestReadRowPaged(t *testing.T) {
+	ctx := context.Background()
+	tbl, cleanup, err := setupFakeServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	mut := NewMutation()
+	for i := 0; i < 25; i++ {
+		mut.Set("cf", fmt.Sprintf("q%02d", i), 1000, []byte{byte(i)})
+	}
+	if err := tbl.Apply(ctx, "wide", mut); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		desc  string
+		opts  []ReadOption
+		pages []int
+	}{
+		{"all cells", nil, []int{10, 10, 5}},
+		{"filtered cells", []ReadOption{RowFilter(ColumnRangeFilter("cf", "q00", "q20"))}, []int{10, 10}},
+	} {
+		it := tbl.ReadRowPaged(ctx, "wide", 10, test.opts...)
+		var pages []int
+		next := 0
+		for {
+			r, err := it.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: %v", test.desc, err)
+			}
+			pages = append(pages, len(r["cf"]))
+			for _, item := range r["cf"] {
+				if want := fmt.Sprintf("cf:q%02d", next); item.Column != want {
+					t.Errorf("%s: got column %q, want %q", test.desc, item.Column, want)
+				}
+				next++
+			}
+		}
+		if fmt.Sprint(pages) != fmt.Sprint(test.pages) {
+			t.Errorf("%s: got pages of %v cells, want %v", test.desc, pages, test.pages)
+		}
+		if _, err := it.Next(); err != iterator.Done {
+			t.Errorf("%s: Next after Done: got %v, want iterator.Done", test.desc, err)
+		}
+	}
+}
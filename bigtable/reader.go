@@ -19,7 +19,9 @@ package bigtable
 import (
 	"bytes"
 	"fmt"
+	"math/bits"
 	"strings"
+	"sync"
 
 	btpb "google.golang.org/genproto/googleapis/bigtable/v2"
 )
@@ -39,6 +41,16 @@ func (r Row) Key() string {
 	return ""
 }
 
+// Retain copies the values of the row, so that they remain valid after the
+// function passed to ReadRows returns when reading with PooledValues.
+func (r Row) Retain() {
+	for _, items := range r {
+		for i := range items {
+			items[i].Value = append([]byte(nil), items[i].Value...)
+		}
+	}
+}
+
 // A ReadItem is returned by Read. A ReadItem contains data from a specific row and column.
 type ReadItem struct {
 	Row, Column string
@@ -47,6 +59,39 @@ type ReadItem struct {
 	Labels      []string
 }
 
+// RowColumns is an alternative to Row that holds the cells of a row in
+// parallel slices, indexed by cell, in the order returned by the server. It
+// is delivered by the ColumnarRows option of ReadRows, for scans in which the
+// allocations of Row dominate.
+type RowColumns struct {
+	Key        string
+	Families   []string
+	Qualifiers [][]byte
+	Timestamps []Timestamp
+	Values     [][]byte
+	Labels     [][]string
+}
+
+// Len returns the number of cells in the row.
+func (rc *RowColumns) Len() int { return len(rc.Values) }
+
+// Retain copies the values of the row, so that they remain valid after the
+// function passed to ColumnarRows returns when reading with PooledValues.
+func (rc *RowColumns) Retain() {
+	for i, v := range rc.Values {
+		rc.Values[i] = append([]byte(nil), v...)
+	}
+}
+
+func (rc *RowColumns) reset(key string) {
+	rc.Key = key
+	rc.Families = rc.Families[:0]
+	rc.Qualifiers = rc.Qualifiers[:0]
+	rc.Timestamps = rc.Timestamps[:0]
+	rc.Values = rc.Values[:0]
+	rc.Labels = rc.Labels[:0]
+}
+
 // The current state of the read rows state machine.
 type rrState int64
 
@@ -69,6 +114,44 @@ type chunkReader struct {
 	curVal    []byte
 	curRow    Row
 	lastKey   string
+
+	// cols, if not nil, accumulates the cells of the current row instead of
+	// curRow. It is reused for each row.
+	cols *RowColumns
+
+	// If pooled is true, split values are assembled in buffers from
+	// valueBufPools, which are held in bufs until release is called.
+	pooled bool
+	bufs   []*[]byte
+}
+
+// valueBufPools holds the buffers in which split values are assembled,
+// indexed by the base 2 logarithm of their capacity.
+var valueBufPools [32]sync.Pool
+
+// getValueBuf returns an empty buffer with a capacity of at least n bytes.
+func getValueBuf(n int) *[]byte {
+	i := bits.Len(uint(n - 1))
+	if b, ok := valueBufPools[i].Get().(*[]byte); ok {
+		return b
+	}
+	b := make([]byte, 0, 1<<i)
+	return &b
+}
+
+func putValueBuf(b *[]byte) {
+	*b = (*b)[:0]
+	valueBufPools[bits.Len(uint(cap(*b)-1))].Put(b)
+}
+
+// release returns the buffers holding the values of the rows processed so
+// far to their pool. The values must no longer be used.
+func (cr *chunkReader) release() {
+	for i, b := range cr.bufs {
+		putValueBuf(b)
+		cr.bufs[i] = nil
+	}
+	cr.bufs = cr.bufs[:0]
 }
 
 // newChunkReader returns a new chunkReader for handling read rows responses.
@@ -83,28 +166,51 @@ func newReverseChunkReader() *chunkReader {
 // Process takes a cell chunk and returns a new Row if the given chunk
 // completes a Row, or nil otherwise.
 func (cr *chunkReader) Process(cc *btpb.ReadRowsResponse_CellChunk) (Row, error) {
-	var row Row
+	if commit, err := cr.process(cc); !commit {
+		return nil, err
+	}
+	return cr.commitRow(), nil
+}
+
+// ProcessColumns is like Process for a chunkReader that accumulates rows in
+// cols. The returned RowColumns is valid until the next call.
+func (cr *chunkReader) ProcessColumns(cc *btpb.ReadRowsResponse_CellChunk) (*RowColumns, error) {
+	if commit, err := cr.process(cc); !commit {
+		return nil, err
+	}
+	cr.lastKey = cr.cols.Key
+	cr.resetToNewRow()
+	return cr.cols, nil
+}
+
+// process handles a cell chunk and reports whether it completes a row.
+func (cr *chunkReader) process(cc *btpb.ReadRowsResponse_CellChunk) (bool, error) {
+	var commit bool
 	switch cr.state {
 	case newRow:
 		if err := cr.validateNewRow(cc); err != nil {
-			return nil, err
+			return false, err
 		}
 
-		cr.curRow = make(Row)
+		if cr.cols != nil {
+			cr.cols.reset(string(cc.RowKey))
+		} else {
+			cr.curRow = make(Row)
+		}
 		cr.curKey = cc.RowKey
 		cr.curFam = cc.FamilyName.Value
 		cr.curQual = cc.Qualifier.Value
 		cr.curTS = cc.TimestampMicros
-		row = cr.handleCellValue(cc)
+		commit = cr.handleCellValue(cc)
 
 	case rowInProgress:
 		if err := cr.validateRowInProgress(cc); err != nil {
-			return nil, err
+			return false, err
 		}
 
 		if cc.GetResetRow() {
 			cr.resetToNewRow()
-			return nil, nil
+			return false, nil
 		}
 
 		if cc.FamilyName != nil {
@@ -114,20 +220,20 @@ func (cr *chunkReader) Process(cc *btpb.ReadRowsResponse_CellChunk) (Row, error)
 			cr.curQual = cc.Qualifier.Value
 		}
 		cr.curTS = cc.TimestampMicros
-		row = cr.handleCellValue(cc)
+		commit = cr.handleCellValue(cc)
 
 	case cellInProgress:
 		if err := cr.validateCellInProgress(cc); err != nil {
-			return nil, err
+			return false, err
 		}
 		if cc.GetResetRow() {
 			cr.resetToNewRow()
-			return nil, nil
+			return false, nil
 		}
-		row = cr.handleCellValue(cc)
+		commit = cr.handleCellValue(cc)
 	}
 
-	return row, nil
+	return commit, nil
 }
 
 // Close must be called after all cell chunks from the response
@@ -140,12 +246,18 @@ func (cr *chunkReader) Close() error {
 	return nil
 }
 
-// handleCellValue returns a Row if the cell value includes a commit, otherwise nil.
-func (cr *chunkReader) handleCellValue(cc *btpb.ReadRowsResponse_CellChunk) Row {
+// handleCellValue reports whether the cell value includes a commit.
+func (cr *chunkReader) handleCellValue(cc *btpb.ReadRowsResponse_CellChunk) bool {
 	if cc.ValueSize > 0 {
 		// ValueSize is specified so expect a split value of ValueSize bytes
 		if cr.curVal == nil {
-			cr.curVal = make([]byte, 0, cc.ValueSize)
+			if cr.pooled {
+				b := getValueBuf(int(cc.ValueSize))
+				cr.bufs = append(cr.bufs, b)
+				cr.curVal = *b
+			} else {
+				cr.curVal = make([]byte, 0, cc.ValueSize)
+			}
 			cr.curLabels = cc.Labels
 		}
 		cr.curVal = append(cr.curVal, cc.Value...)
@@ -161,15 +273,25 @@ func (cr *chunkReader) handleCellValue(cc *btpb.ReadRowsResponse_CellChunk) Row
 		cr.finishCell()
 
 		if cc.GetCommitRow() {
-			return cr.commitRow()
+			return true
 		}
 		cr.state = rowInProgress
 	}
 
-	return nil
+	return false
 }
 
 func (cr *chunkReader) finishCell() {
+	if cr.cols != nil {
+		cr.cols.Families = append(cr.cols.Families, cr.curFam)
+		cr.cols.Qualifiers = append(cr.cols.Qualifiers, cr.curQual)
+		cr.cols.Timestamps = append(cr.cols.Timestamps, Timestamp(cr.curTS))
+		cr.cols.Values = append(cr.cols.Values, cr.curVal)
+		cr.cols.Labels = append(cr.cols.Labels, cr.curLabels)
+		cr.curVal = nil
+		cr.curLabels = nil
+		return
+	}
 	ri := ReadItem{
 		Row:       string(cr.curKey),
 		Column:    string(cr.curFam) + ":" + string(cr.curQual),
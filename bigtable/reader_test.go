@@ -220,6 +220,91 @@ func TestNewFamEmptyQualifier(t *testing.T) {
 	}
 }
 
+func TestProcessColumns(t *testing.T) {
+	cr := newChunkReader()
+	cr.cols = &RowColumns{}
+
+	for _, c := range []*btspb.ReadRowsResponse_CellChunk{
+		cc("rs1", "fm1", "col1", 1, "val1", 0, false, []string{}),
+		cc(nilStr, "fm2", "col2", 2, "va", 4, false, []string{"l"}),
+	} {
+		if cols, err := cr.ProcessColumns(c); err != nil || cols != nil {
+			t.Fatalf("ProcessColumns: got (%v, %v), want (nil, nil)", cols, err)
+		}
+	}
+	cols, err := cr.ProcessColumns(ccData("l2", 0, true))
+	if err != nil {
+		t.Fatalf("ProcessColumns: %v", err)
+	}
+	want := &RowColumns{
+		Key:        "rs1",
+		Families:   []string{"fm1", "fm2"},
+		Qualifiers: [][]byte{[]byte("col1"), []byte("col2")},
+		Timestamps: []Timestamp{1, 2},
+		Values:     [][]byte{[]byte("val1"), []byte("val2")},
+		Labels:     [][]string{{}, {"l"}},
+	}
+	if !testutil.Equal(cols, want) {
+		t.Fatalf("first row: got %+v, want %+v", cols, want)
+	}
+
+	// A reset row is discarded, and the RowColumns is reused for the next row.
+	if _, err := cr.ProcessColumns(cc("rs2", "fm1", "col1", 1, "gone", 0, false, []string{})); err != nil {
+		t.Fatalf("ProcessColumns: %v", err)
+	}
+	if _, err := cr.ProcessColumns(ccReset()); err != nil {
+		t.Fatalf("ProcessColumns: %v", err)
+	}
+	cols2, err := cr.ProcessColumns(cc("rs2", "fm1", "col1", 3, "val3", 0, true, []string{}))
+	if err != nil {
+		t.Fatalf("ProcessColumns: %v", err)
+	}
+	if cols2 != cols {
+		t.Error("RowColumns not reused for the next row")
+	}
+	if cols2.Key != "rs2" || cols2.Len() != 1 || string(cols2.Values[0]) != "val3" {
+		t.Errorf("second row: got %+v, want one cell with value val3 in row rs2", cols2)
+	}
+	if err := cr.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestPooledValues(t *testing.T) {
+	cr := newChunkReader()
+	cr.pooled = true
+
+	mustProcess(t, cr, cc("rs1", "fm1", "col1", 1, "va", 4, false, []string{}))
+	row := mustProcess(t, cr, ccData("l1", 0, true))
+	want := []ReadItem{ri("rs1", "fm1", "col1", 1, "val1", []string{})}
+	if !testutil.Equal(row["fm1"], want) {
+		t.Fatalf("Incorrect ReadItem: got: %v\nwant: %v\n", row["fm1"], want)
+	}
+	if len(cr.bufs) != 1 {
+		t.Fatalf("got %d pooled buffers, want 1", len(cr.bufs))
+	}
+	row.Retain()
+	cr.release()
+	if len(cr.bufs) != 0 {
+		t.Errorf("got %d pooled buffers after release, want 0", len(cr.bufs))
+	}
+
+	// Fill the buffer the first value was assembled in, if it is reused.
+	mustProcess(t, cr, cc("rs2", "fm1", "col1", 1, "xx", 4, false, []string{}))
+	mustProcess(t, cr, ccData("xx", 0, true))
+	if !testutil.Equal(row["fm1"], want) {
+		t.Errorf("retained ReadItem changed: got: %v\nwant: %v\n", row["fm1"], want)
+	}
+
+	for _, n := range []int{1, 4, 5, 1 << 20} {
+		b := getValueBuf(n)
+		if len(*b) != 0 || cap(*b) < n {
+			t.Errorf("getValueBuf(%d): got len %d, cap %d", n, len(*b), cap(*b))
+		}
+		putValueBuf(b)
+	}
+}
+
 func mustProcess(t *testing.T, cr *chunkReader, cc *btspb.ReadRowsResponse_CellChunk) Row {
 	row, err := cr.Process(cc)
 	if err != nil {
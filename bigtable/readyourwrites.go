@@ -0,0 +1,108 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"context"
+	"time"
+
+	gax "github.com/googleapis/gax-go/v2"
+)
+
+// consistencyBackoff is the backoff between checks of the replication of
+// writes by ReadYourWrites.
+var consistencyBackoff = gax.Backoff{
+	Initial:    100 * time.Millisecond,
+	Max:        2 * time.Second,
+	Multiplier: 1.5,
+}
+
+// ReadYourWrites applies mutations to a table and reads rows in a way that
+// guarantees that the reads observe the writes.
+//
+// With single-cluster routing, a read observes the writes that completed
+// before it, so ReadYourWrites simply applies the mutation and reads the
+// row. With multi-cluster routing, a read may be served by a cluster to which
+// the write has not yet replicated; ReadYourWrites then generates a
+// consistency token after the write and waits for the table to be consistent
+// before reading, which adds admin RPCs and at least the replication delay
+// to each call.
+type ReadYourWrites struct {
+	tbl          *Table
+	ac           *AdminClient
+	multiCluster bool
+}
+
+// NewReadYourWrites returns a ReadYourWrites for tbl, which must have been
+// opened on the instance of ac. It looks up the routing policy of the app
+// profile of tbl with iac; if iac is nil, the routing is assumed to be
+// multi-cluster.
+func NewReadYourWrites(ctx context.Context, tbl *Table, ac *AdminClient, iac *InstanceAdminClient) (*ReadYourWrites, error) {
+	rw := &ReadYourWrites{tbl: tbl, ac: ac, multiCluster: true}
+	if iac == nil {
+		return rw, nil
+	}
+	profile := tbl.appProfile
+	if profile == "" {
+		profile = "default"
+	}
+	ap, err := iac.GetAppProfile(ctx, tbl.c.instance, profile)
+	if err != nil {
+		return nil, err
+	}
+	rw.multiCluster = ap.GetMultiClusterRoutingUseAny() != nil
+	return rw, nil
+}
+
+// ApplyAndReadRow applies m to row, as Table.Apply does, and then reads the
+// row, as Table.ReadRow does, observing the mutation.
+func (rw *ReadYourWrites) ApplyAndReadRow(ctx context.Context, row string, m *Mutation, opts ...ReadOption) (Row, error) {
+	if err := rw.tbl.Apply(ctx, row, m); err != nil {
+		return nil, err
+	}
+	if err := rw.waitForWrites(ctx); err != nil {
+		return nil, err
+	}
+	return rw.tbl.ReadRow(ctx, row, opts...)
+}
+
+// waitForWrites waits until the writes completed so far have replicated to
+// all the clusters of the instance, if routing is multi-cluster.
+func (rw *ReadYourWrites) waitForWrites(ctx context.Context) error {
+	if !rw.multiCluster {
+		return nil
+	}
+	ctx = mergeOutgoingMetadata(ctx, rw.ac.md)
+	tableName := rw.ac.instancePrefix() + "/tables/" + rw.tbl.table
+	token, err := rw.ac.getConsistencyToken(ctx, tableName)
+	if err != nil {
+		return err
+	}
+	backoff := consistencyBackoff
+	for {
+		consistent, err := rw.ac.isConsistent(ctx, tableName, token)
+		if err != nil {
+			return err
+		}
+		if consistent {
+			return nil
+		}
+		if err := gax.Sleep(ctx, backoff.Pause()); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/bigtable/bttest"
+	gax "github.com/googleapis/gax-go/v2"
+	"google.golang.org/api/option"
+	btapb "google.golang.org/genproto/googleapis/bigtable/admin/v2"
+	"google.golang.org/grpc"
+)
+
+func TestReadYourWrites(t *testing.T) {
+	ctx := context.Background()
+	defer func(b gax.Backoff) { consistencyBackoff = b }(consistencyBackoff)
+	consistencyBackoff = gax.Backoff{Initial: time.Millisecond, Max: time.Millisecond}
+
+	// The first check of each write reports that it has not replicated yet.
+	var checks int32
+	inconsistentOnce := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		res, err := handler(ctx, req)
+		if strings.HasSuffix(info.FullMethod, "/CheckConsistency") && atomic.AddInt32(&checks, 1)%2 == 1 {
+			return &btapb.CheckConsistencyResponse{Consistent: false}, err
+		}
+		return res, err
+	}
+	srv, err := bttest.NewServer("localhost:0", grpc.UnaryInterceptor(inconsistentOnce))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	conn, err := grpc.Dial(srv.Addr, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	ac, err := NewAdminClient(ctx, "project", "instance", option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ac.CreateTable(ctx, "table"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ac.CreateColumnFamily(ctx, "table", "cf"); err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewClient(ctx, "project", "instance", option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tbl := client.Open("table")
+
+	rw, err := NewReadYourWrites(ctx, tbl, ac, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mut := NewMutation()
+	mut.Set("cf", "col", 1000, []byte("v"))
+	row, err := rw.ApplyAndReadRow(ctx, "row", mut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(row["cf"]) != 1 || string(row["cf"][0].Value) != "v" {
+		t.Errorf("got row %v, want the written cell", row)
+	}
+	if got := atomic.LoadInt32(&checks); got != 2 {
+		t.Errorf("got %d consistency checks, want 2", got)
+	}
+
+	// With single-cluster routing, the write is not checked.
+	rw = &ReadYourWrites{tbl: tbl, ac: ac}
+	if _, err := rw.ApplyAndReadRow(ctx, "row", mut); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt32(&checks); got != 2 {
+		t.Errorf("got %d consistency checks, want no more than 2", got)
+	}
+}
@@ -18,12 +18,14 @@ package bigtable
 import (
 	"context"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"cloud.google.com/go/bigtable/bttest"
 	"cloud.google.com/go/internal/testutil"
 	"github.com/google/go-cmp/cmp"
+	gax "github.com/googleapis/gax-go/v2"
 	"google.golang.org/api/option"
 	btpb "google.golang.org/genproto/googleapis/bigtable/v2"
 	rpcpb "google.golang.org/genproto/googleapis/rpc/status"
@@ -531,3 +533,33 @@ func must(err error) {
 		panic(err)
 	}
 }
+
+func TestWithCallOptions(t *testing.T) {
+	ctx := context.Background()
+	var failures int32
+	failOnce := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			return nil, status.Error(codes.Unavailable, "unavailable")
+		}
+		return handler(ctx, req)
+	}
+	tbl, cleanup, err := setupFakeServer(grpc.UnaryInterceptor(failOnce))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	mut := NewMutation()
+	mut.Set("cf", "col", 1000, []byte("v"))
+
+	atomic.StoreInt32(&failures, 1)
+	if err := tbl.Apply(ctx, "row", mut); err != nil {
+		t.Errorf("Apply with the default retries: %v", err)
+	}
+
+	atomic.StoreInt32(&failures, 1)
+	noRetry := WithCallOptions(gax.WithRetry(func() gax.Retryer { return nil }))
+	if err := tbl.Apply(ctx, "row", mut, noRetry); status.Code(err) != codes.Unavailable {
+		t.Errorf("Apply without retries: got %v, want Unavailable", err)
+	}
+}
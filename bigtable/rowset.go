@@ -0,0 +1,193 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"sort"
+	"strings"
+)
+
+// Intersect returns the range of the keys in both r and o, and reports
+// whether that range is non-empty.
+func (r RowRange) Intersect(o RowRange) (RowRange, bool) {
+	res := r
+	if compareStarts(o, r) > 0 {
+		res.startBound, res.start = o.startBound, o.start
+	}
+	if compareEnds(o, r) < 0 {
+		res.endBound, res.end = o.endBound, o.end
+	}
+	return res, res.valid()
+}
+
+// Normalize returns the ranges of r sorted by their start, with the empty
+// ranges removed and the ranges that overlap or adjoin merged, so that no two
+// ranges of the result share a key.
+func (r RowRangeList) Normalize() RowRangeList {
+	var ranges RowRangeList
+	for _, rr := range r {
+		if rr.valid() {
+			ranges = append(ranges, rr)
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool { return compareStarts(ranges[i], ranges[j]) < 0 })
+
+	var res RowRangeList
+	for _, rr := range ranges {
+		if n := len(res); n > 0 && joins(res[n-1], rr) {
+			if compareEnds(rr, res[n-1]) > 0 {
+				res[n-1].endBound, res[n-1].end = rr.endBound, rr.end
+			}
+			continue
+		}
+		res = append(res, rr)
+	}
+	return res
+}
+
+// Union returns the normalized list of the ranges of the keys in r or o.
+func (r RowRangeList) Union(o RowRangeList) RowRangeList {
+	return append(append(RowRangeList(nil), r...), o...).Normalize()
+}
+
+// Intersect returns the normalized list of the ranges of the keys in both r
+// and o.
+func (r RowRangeList) Intersect(o RowRangeList) RowRangeList {
+	var res RowRangeList
+	for _, a := range r.Normalize() {
+		for _, b := range o.Normalize() {
+			if rr, ok := a.Intersect(b); ok {
+				res = append(res, rr)
+			}
+		}
+	}
+	return res.Normalize()
+}
+
+// Subtract returns the normalized list of the ranges of the keys in r but
+// not in o. It is useful to compute what remains of a scan after the ranges
+// in o have been processed.
+func (r RowRangeList) Subtract(o RowRangeList) RowRangeList {
+	return r.Intersect(o.Normalize().complement())
+}
+
+// complement returns the ranges of the keys not in r, which must be
+// normalized.
+func (r RowRangeList) complement() RowRangeList {
+	var res RowRangeList
+	gap := RowRange{startBound: rangeUnbounded}
+	for _, rr := range r {
+		if rr.startBound != rangeUnbounded {
+			gap.endBound, gap.end = flipBound(rr.startBound), rr.start
+			if gap.valid() {
+				res = append(res, gap)
+			}
+		}
+		if rr.endBound == rangeUnbounded {
+			return res
+		}
+		gap = RowRange{startBound: flipBound(rr.endBound), start: rr.end}
+	}
+	gap.endBound, gap.end = rangeUnbounded, ""
+	return append(res, gap)
+}
+
+// IntersectPrefix returns the set of the rows in s whose keys start with
+// prefix.
+func IntersectPrefix(s RowSet, prefix string) RowSet {
+	switch s := s.(type) {
+	case RowList:
+		var keys RowList
+		for _, k := range s {
+			if strings.HasPrefix(k, prefix) {
+				keys = append(keys, k)
+			}
+		}
+		return keys
+	case RowRange:
+		if rr, ok := s.Intersect(PrefixRange(prefix)); ok {
+			return rr
+		}
+		return RowRangeList{}
+	case RowRangeList:
+		return s.Intersect(RowRangeList{PrefixRange(prefix)})
+	default:
+		return s
+	}
+}
+
+// compareStarts returns a negative number if a starts before b, a positive
+// number if it starts after b, and 0 if they start at the same key.
+func compareStarts(a, b RowRange) int {
+	switch {
+	case a.startBound == rangeUnbounded && b.startBound == rangeUnbounded:
+		return 0
+	case a.startBound == rangeUnbounded:
+		return -1
+	case b.startBound == rangeUnbounded:
+		return 1
+	}
+	if c := strings.Compare(a.start, b.start); c != 0 {
+		return c
+	}
+	// [k starts before (k.
+	return boundOrder(b.startBound) - boundOrder(a.startBound)
+}
+
+// compareEnds returns a negative number if a ends before b, a positive
+// number if it ends after b, and 0 if they end at the same key.
+func compareEnds(a, b RowRange) int {
+	switch {
+	case a.endBound == rangeUnbounded && b.endBound == rangeUnbounded:
+		return 0
+	case a.endBound == rangeUnbounded:
+		return 1
+	case b.endBound == rangeUnbounded:
+		return -1
+	}
+	if c := strings.Compare(a.end, b.end); c != 0 {
+		return c
+	}
+	// k) ends before k].
+	return boundOrder(a.endBound) - boundOrder(b.endBound)
+}
+
+func boundOrder(b rangeBoundType) int {
+	if b == rangeClosed {
+		return 1
+	}
+	return 0
+}
+
+// joins reports whether b, which does not start before a, overlaps or adjoins
+// a, so that their union is a single range.
+func joins(a, b RowRange) bool {
+	if a.endBound == rangeUnbounded || b.startBound == rangeUnbounded {
+		return true
+	}
+	if a.end != b.start {
+		return b.start < a.end
+	}
+	return a.endBound == rangeClosed || b.startBound == rangeClosed
+}
+
+func flipBound(b rangeBoundType) rangeBoundType {
+	if b == rangeClosed {
+		return rangeOpen
+	}
+	return rangeClosed
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRowRangeListNormalize(t *testing.T) {
+	for _, test := range []struct {
+		desc string
+		in   RowRangeList
+		want RowRangeList
+	}{
+		{"empty", nil, nil},
+		{
+			"sorted and merged",
+			RowRangeList{NewRange("m", "p"), NewRange("a", "c"), NewRange("b", "d")},
+			RowRangeList{NewRange("a", "d"), NewRange("m", "p")},
+		},
+		{
+			"adjoining",
+			RowRangeList{NewRange("a", "c"), NewClosedRange("c", "e"), NewOpenClosedRange("e", "g")},
+			RowRangeList{NewClosedRange("a", "g")},
+		},
+		{
+			"open ends at the same key are not merged",
+			RowRangeList{NewOpenRange("c", "e"), NewOpenRange("a", "c")},
+			RowRangeList{NewOpenRange("a", "c"), NewOpenRange("c", "e")},
+		},
+		{
+			"contained and empty ranges",
+			RowRangeList{InfiniteRange("b"), NewRange("c", "d"), NewRange("z", "a")},
+			RowRangeList{InfiniteRange("b")},
+		},
+	} {
+		if got := test.in.Normalize(); !cmp.Equal(got, test.want, cmp.AllowUnexported(RowRange{})) {
+			t.Errorf("%s: got %v, want %v", test.desc, got, test.want)
+		}
+	}
+}
+
+func TestRowRangeListAlgebra(t *testing.T) {
+	a := RowRangeList{NewRange("a", "f"), NewRange("m", "p")}
+	b := RowRangeList{NewRange("c", "n"), InfiniteRange("x")}
+	for _, test := range []struct {
+		desc      string
+		got, want RowRangeList
+	}{
+		{
+			"union",
+			a.Union(b),
+			RowRangeList{NewRange("a", "p"), InfiniteRange("x")},
+		},
+		{
+			"intersect",
+			a.Intersect(b),
+			RowRangeList{NewRange("c", "f"), NewRange("m", "n")},
+		},
+		{
+			"subtract",
+			a.Subtract(b),
+			RowRangeList{NewRange("a", "c"), NewRange("n", "p")},
+		},
+		{
+			"subtract open and closed ends",
+			RowRangeList{NewClosedRange("a", "z")}.Subtract(RowRangeList{NewOpenClosedRange("b", "c"), NewRange("x", "y")}),
+			RowRangeList{NewClosedRange("a", "b"), NewOpenRange("c", "x"), NewClosedRange("y", "z")},
+		},
+		{
+			"subtract everything",
+			a.Subtract(RowRangeList{InfiniteRange("")}),
+			nil,
+		},
+	} {
+		if !cmp.Equal(test.got, test.want, cmp.AllowUnexported(RowRange{})) {
+			t.Errorf("%s: got %v, want %v", test.desc, test.got, test.want)
+		}
+	}
+}
+
+func TestIntersectPrefix(t *testing.T) {
+	for _, test := range []struct {
+		desc string
+		in   RowSet
+		want RowSet
+	}{
+		{"row list", RowList{"ab", "b", "ac"}, RowList{"ab", "ac"}},
+		{"row range", NewRange("aa", "b"), NewRange("aa", "b")},
+		{"infinite range", InfiniteRange(""), PrefixRange("a")},
+		{"disjoint range", NewRange("c", "d"), RowRangeList{}},
+		{
+			"row range list",
+			RowRangeList{NewRange("", "ab"), NewRange("c", "d")},
+			RowRangeList{NewRange("a", "ab")},
+		},
+	} {
+		if got := IntersectPrefix(test.in, "a"); !cmp.Equal(got, test.want, cmp.AllowUnexported(RowRange{})) {
+			t.Errorf("%s: got %v, want %v", test.desc, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// saltedReadBuffer is the number of rows buffered for each salt by
+// ReadSaltedRows.
+const saltedReadBuffer = 16
+
+// SaltedKeys spreads rows with monotonically increasing keys, such as
+// timestamps or sequence numbers, across the key space, so that their writes
+// are not all served by the tablet that holds the end of the table. Each key
+// is prefixed with a salt in [0, n) derived from a hash of the key, as a
+// zero-padded decimal number followed by "#": with 16 salts, the key
+// "20240615103000" may become "07#20240615103000".
+//
+// Reading the rows of a range of keys requires one read for each salt, which
+// ReadSaltedRows performs and merges.
+type SaltedKeys struct {
+	n     int
+	width int
+}
+
+// NewSaltedKeys returns the SaltedKeys with n salts. The number of salts
+// must not be changed once rows have been written with it.
+func NewSaltedKeys(n int) (*SaltedKeys, error) {
+	if n <= 0 {
+		return nil, errors.New("bigtable: number of salts must be positive")
+	}
+	return &SaltedKeys{n: n, width: len(strconv.Itoa(n - 1))}, nil
+}
+
+// Key returns the salted row key of key.
+func (k *SaltedKeys) Key(key string) string {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return k.prefix(int(h.Sum32()%uint32(k.n))) + key
+}
+
+// prefix returns the prefix of the keys with salt s.
+func (k *SaltedKeys) prefix(s int) string {
+	return fmt.Sprintf("%0*d#", k.width, s)
+}
+
+// Unsalt returns the key of which salted is the salted row key. It returns
+// an error if salted does not start with a salt.
+func (k *SaltedKeys) Unsalt(salted string) (string, error) {
+	n := k.width + 1
+	if len(salted) < n || salted[n-1] != '#' {
+		return "", fmt.Errorf("bigtable: row key %q is not salted", salted)
+	}
+	if s, err := strconv.Atoi(salted[:n-1]); err != nil || s < 0 || s >= k.n {
+		return "", fmt.Errorf("bigtable: row key %q is not salted", salted)
+	}
+	return salted[n:], nil
+}
+
+// Ranges returns the ranges of the salted row keys of the keys in r, one for
+// each salt, in salt order.
+func (k *SaltedKeys) Ranges(r RowRange) RowRangeList {
+	ranges := make(RowRangeList, k.n)
+	for s := range ranges {
+		ranges[s] = k.saltRange(s, r)
+	}
+	return ranges
+}
+
+// saltRange returns the range of the salted row keys with salt s of the keys
+// in r.
+func (k *SaltedKeys) saltRange(s int, r RowRange) RowRange {
+	p := k.prefix(s)
+	startBound, start := rangeClosed, p
+	if r.startBound != rangeUnbounded {
+		startBound, start = r.startBound, p+r.start
+	}
+	endBound, end := rangeOpen, prefixSuccessor(p)
+	if r.endBound != rangeUnbounded {
+		endBound, end = r.endBound, p+r.end
+	}
+	return createRowRange(startBound, start, endBound, end)
+}
+
+// ReadSaltedRows reads the rows whose keys, salted with keys, are in r, and
+// calls f for each row with its unsalted key, in key order. The rows of the
+// salts are read concurrently, with one ReadRows call for each salt, to which
+// the options apply; a LimitRows option thus limits the rows of each salt.
+// ReverseScan is not supported.
+func (t *Table) ReadSaltedRows(ctx context.Context, keys *SaltedKeys, r RowRange, f func(key string, row Row) bool, opts ...ReadOption) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	g, gctx := errgroup.WithContext(ctx)
+	// Rows are queued past the call of the function, so their values can't
+	// be pooled.
+	opts = append(append([]ReadOption(nil), opts...), pooledValues(false))
+	streams := make([]chan Row, keys.n)
+	for s := range streams {
+		ch := make(chan Row, saltedReadBuffer)
+		streams[s] = ch
+		rr := keys.saltRange(s, r)
+		g.Go(func() error {
+			defer close(ch)
+			return t.ReadRows(gctx, rr, func(row Row) bool {
+				select {
+				case ch <- row:
+					return true
+				case <-gctx.Done():
+					return false
+				}
+			}, opts...)
+		})
+	}
+
+	// Merge the streams, keeping the next row of each with its unsalted key.
+	type head struct {
+		key string
+		row Row
+	}
+	heads := make([]*head, len(streams))
+	next := func(s int) error {
+		heads[s] = nil
+		row, ok := <-streams[s]
+		if !ok {
+			return nil
+		}
+		key, err := keys.Unsalt(row.Key())
+		if err != nil {
+			return err
+		}
+		heads[s] = &head{key, row}
+		return nil
+	}
+	for s := range streams {
+		if err := next(s); err != nil {
+			cancel()
+			g.Wait()
+			return err
+		}
+	}
+	for {
+		min := -1
+		for s, h := range heads {
+			if h != nil && (min < 0 || h.key < heads[min].key) {
+				min = s
+			}
+		}
+		if min < 0 {
+			return g.Wait()
+		}
+		if !f(heads[min].key, heads[min].row) {
+			// The reads are interrupted on purpose; their errors are moot.
+			cancel()
+			g.Wait()
+			return nil
+		}
+		if err := next(min); err != nil {
+			cancel()
+			g.Wait()
+			return err
+		}
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSaltedKeys(t *testing.T) {
+	keys, err := NewSaltedKeys(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	salts := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("%08d", i)
+		salted := keys.Key(key)
+		if salted != keys.Key(key) {
+			t.Fatalf("Key(%q) is not stable", key)
+		}
+		if !strings.HasSuffix(salted, "#"+key) || len(salted) != len(key)+3 {
+			t.Fatalf("Key(%q) = %q, want a two-digit salt", key, salted)
+		}
+		salts[salted[:2]] = true
+		got, err := keys.Unsalt(salted)
+		if err != nil || got != key {
+			t.Errorf("Unsalt(%q) = (%q, %v), want %q", salted, got, err, key)
+		}
+	}
+	if len(salts) != 16 {
+		t.Errorf("1000 keys got %d salts, want 16", len(salts))
+	}
+	for _, bad := range []string{"", "7#x", "16#x", "ab#x", "07x"} {
+		if _, err := keys.Unsalt(bad); err == nil {
+			t.Errorf("Unsalt(%q): got nil, want error", bad)
+		}
+	}
+
+	few, err := NewSaltedKeys(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := few.Ranges(NewRange("a", "c"))
+	want := RowRangeList{NewRange("0#a", "0#c"), NewRange("1#a", "1#c")}
+	if !cmp.Equal(got, want, cmp.AllowUnexported(RowRange{})) {
+		t.Errorf("Ranges: got %v, want %v", got, want)
+	}
+	got = few.Ranges(InfiniteRange(""))
+	want = RowRangeList{PrefixRange("0#"), PrefixRange("1#")}
+	if !cmp.Equal(got, want, cmp.AllowUnexported(RowRange{})) {
+		t.Errorf("Ranges: got %v, want %v", got, want)
+	}
+
+	if _, err := NewSaltedKeys(0); err == nil {
+		t.Error("NewSaltedKeys(0): got nil, want error")
+	}
+}
+
+func TestReadSaltedRows(t *testing.T) {
+	ctx := context.Background()
+	tbl, cleanup, err := setupFakeServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	keys, err := NewSaltedKeys(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		mut := NewMutation()
+		mut.Set("cf", "v", 1000, []byte{byte(i)})
+		if err := tbl.Apply(ctx, keys.Key(fmt.Sprintf("%04d", i)), mut); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	err = tbl.ReadSaltedRows(ctx, keys, NewRange("0010", "0040"), func(key string, row Row) bool {
+		if want := keys.Key(key); row.Key() != want {
+			t.Errorf("row key %q for key %q, want %q", row.Key(), key, want)
+		}
+		got = append(got, key)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []string
+	for i := 10; i < 40; i++ {
+		want = append(want, fmt.Sprintf("%04d", i))
+	}
+	if !cmp.Equal(got, want) {
+		t.Errorf("got keys %q, want %q", got, want)
+	}
+
+	got = nil
+	err = tbl.ReadSaltedRows(ctx, keys, InfiniteRange(""), func(key string, row Row) bool {
+		got = append(got, key)
+		return len(got) < 5
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"0000", "0001", "0002", "0003", "0004"}; !cmp.Equal(got, want) {
+		t.Errorf("stopped read: got keys %q, want %q", got, want)
+	}
+}
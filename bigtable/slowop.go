@@ -0,0 +1,160 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// A SlowOperation describes an operation that took longer than the
+// SlowOperationThreshold of its client.
+type SlowOperation struct {
+	// Method is the name of the Table method, such as "ReadRows" or "Apply".
+	Method string
+
+	// Table is the name of the table.
+	Table string
+
+	// Rows summarizes the rows of the operation: the key of the row of a
+	// single-row operation, or the row set read by ReadRows.
+	Rows string
+
+	// Latency is the time the operation took, including retries.
+	Latency time.Duration
+
+	// Attempts is the number of RPCs sent, which is one more than the number
+	// of retries.
+	Attempts int
+
+	// ServerLatency is the total time the server reported spending on the
+	// attempts, or 0 if it did not report it.
+	ServerLatency time.Duration
+}
+
+// operation tracks the latency and attempts of an operation, for reporting
+// it if it is slow and recording it in the table stats. A nil *operation
+// tracks nothing.
+type operation struct {
+	c       *Client
+	op      SlowOperation
+	rows    func() string
+	start   time.Time
+	header  metadata.MD
+	pending bool
+}
+
+// startOperation starts tracking an operation if the client reports slow
+// operations or keeps table stats. rows is called to summarize the rows of
+// a slow operation.
+func (t *Table) startOperation(method string, rows func() string) *operation {
+	if t.c.slowOperationFunc == nil && t.c.stats == nil {
+		return nil
+	}
+	return &operation{
+		c:     t.c,
+		op:    SlowOperation{Method: method, Table: t.table},
+		rows:  rows,
+		start: time.Now(),
+	}
+}
+
+// attempt records the start of an RPC, and returns the CallOption that
+// captures its response headers.
+func (op *operation) attempt() grpc.CallOption {
+	if op == nil {
+		return grpc.EmptyCallOption{}
+	}
+	op.addServerLatency()
+	op.op.Attempts++
+	op.pending = true
+	return grpc.Header(&op.header)
+}
+
+// addServerLatency adds the latency reported in the server-timing header of
+// the last attempt, if any.
+func (op *operation) addServerLatency() {
+	if !op.pending {
+		return
+	}
+	for _, v := range op.header.Get("server-timing") {
+		op.op.ServerLatency += parseServerTiming(v)
+	}
+	op.header, op.pending = nil, false
+}
+
+// end records the operation, which returned err, in the table stats, and
+// reports it to the client if it was slow.
+func (op *operation) end(err error) {
+	if op == nil {
+		return
+	}
+	op.op.Latency = time.Since(op.start)
+	if op.c.stats != nil {
+		op.c.stats.table(op.op.Table).record(op.op.Latency, op.op.Attempts, err)
+	}
+	if op.c.slowOperationFunc == nil || op.op.Latency < op.c.slowOperationThreshold {
+		return
+	}
+	op.addServerLatency()
+	if op.rows != nil {
+		op.op.Rows = op.rows()
+	}
+	op.c.slowOperationFunc(op.op)
+}
+
+// parseServerTiming returns the duration of a server-timing header value of
+// the form "gfet4t7; dur=12.5", whose duration is in milliseconds.
+func parseServerTiming(v string) time.Duration {
+	for _, p := range strings.Split(v, ";") {
+		p = strings.TrimSpace(p)
+		if !strings.HasPrefix(p, "dur=") {
+			continue
+		}
+		ms, err := strconv.ParseFloat(p[len("dur="):], 64)
+		if err != nil {
+			return 0
+		}
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	return 0
+}
+
+// summarizeRowSet returns a short description of rs.
+func summarizeRowSet(rs RowSet) string {
+	switch rs := rs.(type) {
+	case RowList:
+		if len(rs) <= 3 {
+			return fmt.Sprintf("%q", []string(rs))
+		}
+		return fmt.Sprintf("%d keys from %q to %q", len(rs), rs[0], rs[len(rs)-1])
+	case RowRange:
+		return rs.String()
+	case RowRangeList:
+		if len(rs) == 1 {
+			return rs[0].String()
+		}
+		return fmt.Sprintf("%d ranges", len(rs))
+	default:
+		return fmt.Sprint(rs)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestSlowOperations(t *testing.T) {
+	ctx := context.Background()
+	delay := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		time.Sleep(20 * time.Millisecond)
+		grpc.SetHeader(ctx, metadata.Pairs("server-timing", "gfet4t7; dur=12.5"))
+		return handler(ctx, req)
+	}
+	tbl, cleanup, err := setupFakeServer(grpc.UnaryInterceptor(delay))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	var got []SlowOperation
+	tbl.c.slowOperationFunc = func(op SlowOperation) { got = append(got, op) }
+	tbl.c.slowOperationThreshold = 10 * time.Millisecond
+
+	mut := NewMutation()
+	mut.Set("cf", "col", 1000, []byte("v"))
+	if err := tbl.Apply(ctx, "row", mut); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d slow operations, want 1: %+v", len(got), got)
+	}
+	op := got[0]
+	if op.Method != "Apply" || op.Table != "table" || op.Rows != `"row"` || op.Attempts != 1 {
+		t.Errorf("got %+v, want Apply of \"row\" to table in 1 attempt", op)
+	}
+	if op.Latency < 20*time.Millisecond {
+		t.Errorf("got latency %v, want at least 20ms", op.Latency)
+	}
+	if op.ServerLatency != 12500*time.Microsecond {
+		t.Errorf("got server latency %v, want 12.5ms", op.ServerLatency)
+	}
+}
+
+func TestSummarizeRowSet(t *testing.T) {
+	for _, test := range []struct {
+		rs   RowSet
+		want string
+	}{
+		{RowList{"a", "b"}, `["a" "b"]`},
+		{RowList{"a", "b", "c", "d"}, `4 keys from "a" to "d"`},
+		{NewRange("a", "b"), NewRange("a", "b").String()},
+		{RowRangeList{NewRange("a", "b"), NewRange("c", "d")}, "2 ranges"},
+	} {
+		if got := summarizeRowSet(test.rs); got != test.want {
+			t.Errorf("summarizeRowSet(%v): got %q, want %q", test.rs, got, test.want)
+		}
+	}
+}
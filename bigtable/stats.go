@@ -0,0 +1,253 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"context"
+	"errors"
+	"math/bits"
+	"sync"
+	"time"
+)
+
+// statsSlots is the number of slots in which the window of table stats is
+// divided. Operations leave the stats in steps of a slot.
+const statsSlots = 10
+
+// TableStats holds client-side statistics of the data operations of a
+// table, as returned by Table.Stats.
+type TableStats struct {
+	// Window is the period the statistics cover, which ends at the time they
+	// were taken. It is shorter than the TableStatsWindow of the client
+	// until the client has been in use for that long.
+	Window time.Duration
+
+	// Operations is the number of operations that ended in the window.
+	Operations int64
+
+	// QPS is the number of operations per second.
+	QPS float64
+
+	// ErrorRate is the fraction of the operations that failed.
+	ErrorRate float64
+
+	// RetryRate is the average number of retries per operation.
+	RetryRate float64
+
+	// P50 and P99 are the median and 99th percentile latencies of the
+	// operations, including their retries, with a precision of about 6%.
+	P50, P99 time.Duration
+}
+
+// Stats returns the statistics of the operations of the table over the last
+// TableStatsWindow, as seen by its client. They cover the operations of all
+// the Tables of the client with the same name. It returns an error if the
+// client was not created with a positive TableStatsWindow.
+func (t *Table) Stats(ctx context.Context) (TableStats, error) {
+	if t.c.stats == nil {
+		return TableStats{}, errors.New("bigtable: table stats are disabled; set ClientConfig.TableStatsWindow to enable them")
+	}
+	return t.c.stats.table(t.table).snapshot(), nil
+}
+
+// statsRegistry holds the stats of the tables of a client.
+type statsRegistry struct {
+	slot time.Duration
+
+	mu     sync.Mutex
+	tables map[string]*tableStats
+}
+
+func newStatsRegistry(window time.Duration) *statsRegistry {
+	slot := window / statsSlots
+	if slot <= 0 {
+		slot = 1
+	}
+	return &statsRegistry{slot: slot, tables: map[string]*tableStats{}}
+}
+
+func (r *statsRegistry) table(name string) *tableStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ts, ok := r.tables[name]
+	if !ok {
+		ts = &tableStats{
+			slot:    r.slot,
+			created: time.Now(),
+			now:     time.Now,
+		}
+		r.tables[name] = ts
+	}
+	return ts
+}
+
+// tableStats aggregates the operations of a table in a ring of slots, each
+// covering a fixed part of the window.
+type tableStats struct {
+	slot    time.Duration
+	created time.Time
+	now     func() time.Time // for testing
+
+	mu    sync.Mutex
+	slots [statsSlots]statsSlot
+}
+
+type statsSlot struct {
+	n                int64 // the number of the slot since the zero time
+	ops, errs, tries int64
+	latencies        latencyHistogram
+}
+
+// record adds an operation that took latency with the given number of
+// attempts.
+func (ts *tableStats) record(latency time.Duration, attempts int, err error) {
+	n := ts.now().UnixNano() / int64(ts.slot)
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	s := &ts.slots[n%statsSlots]
+	if s.n != n {
+		*s = statsSlot{n: n}
+	}
+	s.ops++
+	if err != nil {
+		s.errs++
+	}
+	if attempts > 1 {
+		s.tries += int64(attempts - 1)
+	}
+	s.latencies.record(latency)
+}
+
+func (ts *tableStats) snapshot() TableStats {
+	now := ts.now()
+	n := now.UnixNano() / int64(ts.slot)
+	var ops, errs, retries int64
+	var latencies latencyHistogram
+	ts.mu.Lock()
+	for i := range ts.slots {
+		s := &ts.slots[i]
+		if s.n <= n-statsSlots || s.n > n {
+			continue
+		}
+		ops += s.ops
+		errs += s.errs
+		retries += s.tries
+		latencies.merge(&s.latencies)
+	}
+	ts.mu.Unlock()
+
+	// The window ends now and starts with the oldest slot, which may be
+	// partly past.
+	window := now.Sub(time.Unix(0, (n-statsSlots+1)*int64(ts.slot)))
+	if age := now.Sub(ts.created); age < window {
+		window = age
+	}
+	st := TableStats{Window: window, Operations: ops}
+	if window > 0 {
+		st.QPS = float64(ops) / window.Seconds()
+	}
+	if ops > 0 {
+		st.ErrorRate = float64(errs) / float64(ops)
+		st.RetryRate = float64(retries) / float64(ops)
+		st.P50 = latencies.quantile(0.5)
+		st.P99 = latencies.quantile(0.99)
+	}
+	return st
+}
+
+// latencyQuantile returns the latency below which a fraction q of the
+// latencies recorded in the window fall, and the number of latencies.
+func (ts *tableStats) latencyQuantile(q float64) (time.Duration, int64) {
+	n := ts.now().UnixNano() / int64(ts.slot)
+	var ops int64
+	var latencies latencyHistogram
+	ts.mu.Lock()
+	for i := range ts.slots {
+		s := &ts.slots[i]
+		if s.n <= n-statsSlots || s.n > n {
+			continue
+		}
+		ops += s.ops
+		latencies.merge(&s.latencies)
+	}
+	ts.mu.Unlock()
+	if ops == 0 {
+		return 0, 0
+	}
+	return latencies.quantile(q), ops
+}
+
+// latencySubBits is the number of bits of a latency in microseconds, after
+// its leading one, that distinguish its bucket in a latencyHistogram.
+const latencySubBits = 3
+
+// latencyHistogram counts latencies in buckets whose width grows with the
+// latency, so that they have a relative precision of 1/2^latencySubBits.
+type latencyHistogram [64 << latencySubBits]uint32
+
+func latencyBucket(d time.Duration) int {
+	us := uint64(0)
+	if d > 0 {
+		us = uint64(d / time.Microsecond)
+	}
+	const sub = 1 << latencySubBits
+	if us < sub {
+		return int(us)
+	}
+	shift := bits.Len64(us) - latencySubBits - 1
+	return shift<<latencySubBits + int(us>>shift)
+}
+
+// bucketMidpoint returns the latency in the middle of bucket i.
+func bucketMidpoint(i int) time.Duration {
+	const sub = 1 << latencySubBits
+	if i < sub {
+		return time.Duration(i) * time.Microsecond
+	}
+	shift := i>>latencySubBits - 1
+	low := uint64(i%sub+sub) << shift
+	return time.Duration(low+(uint64(1)<<shift)/2) * time.Microsecond
+}
+
+func (h *latencyHistogram) record(d time.Duration) { h[latencyBucket(d)]++ }
+
+func (h *latencyHistogram) merge(o *latencyHistogram) {
+	for i, c := range o {
+		h[i] += c
+	}
+}
+
+// quantile returns the latency below which a fraction q of the recorded
+// latencies fall.
+func (h *latencyHistogram) quantile(q float64) time.Duration {
+	var total uint64
+	for _, c := range h {
+		total += uint64(c)
+	}
+	rank := uint64(q*float64(total) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	var seen uint64
+	for i, c := range h {
+		seen += uint64(c)
+		if seen >= rank {
+			return bucketMidpoint(i)
+		}
+	}
+	return 0
+}
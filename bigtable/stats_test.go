@@ -0,0 +1,117 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTableStatsWindow(t *testing.T) {
+	now := time.Date(2024, 6, 15, 10, 0, 0, 0, time.UTC)
+	r := newStatsRegistry(10 * time.Second)
+	ts := r.table("t")
+	ts.created = now.Add(-time.Hour)
+	ts.now = func() time.Time { return now }
+
+	for i := 1; i <= 100; i++ {
+		var err error
+		if i%10 == 0 {
+			err = errors.New("failed")
+		}
+		ts.record(time.Duration(i)*time.Millisecond, 1+i%2, err)
+	}
+	st := ts.snapshot()
+	if st.Operations != 100 || st.ErrorRate != 0.1 || st.RetryRate != 0.5 {
+		t.Errorf("got %d operations, error rate %v, retry rate %v; want 100, 0.1, 0.5", st.Operations, st.ErrorRate, st.RetryRate)
+	}
+	if st.Window < 9*time.Second || st.Window > 10*time.Second {
+		t.Errorf("got window %v, want between 9s and 10s", st.Window)
+	}
+	for _, q := range []struct {
+		got, want time.Duration
+	}{{st.P50, 50 * time.Millisecond}, {st.P99, 99 * time.Millisecond}} {
+		if math.Abs(float64(q.got-q.want)) > 0.07*float64(q.want) {
+			t.Errorf("got quantile %v, want about %v", q.got, q.want)
+		}
+	}
+
+	// Operations leave the window once their slot is more than a window
+	// old.
+	now = now.Add(5 * time.Second)
+	ts.record(time.Millisecond, 1, nil)
+	if got := ts.snapshot().Operations; got != 101 {
+		t.Errorf("after 5s: got %d operations, want 101", got)
+	}
+	now = now.Add(6 * time.Second)
+	if got := ts.snapshot().Operations; got != 1 {
+		t.Errorf("after 11s: got %d operations, want 1", got)
+	}
+}
+
+func TestLatencyBuckets(t *testing.T) {
+	prev := -1
+	for us := 0; us < 1<<20; us += 1 + us/100 {
+		d := time.Duration(us) * time.Microsecond
+		i := latencyBucket(d)
+		if i < prev {
+			t.Fatalf("latencyBucket(%v) = %d, less than for a smaller latency", d, i)
+		}
+		prev = i
+		if mid := bucketMidpoint(i); math.Abs(float64(mid-d)) > float64(d)/8+float64(time.Microsecond) {
+			t.Errorf("bucketMidpoint(latencyBucket(%v)) = %v", d, mid)
+		}
+	}
+}
+
+func TestTableStats(t *testing.T) {
+	ctx := context.Background()
+	tbl, cleanup, err := setupFakeServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if _, err := tbl.Stats(ctx); err == nil {
+		t.Error("Stats without TableStatsWindow: got nil, want error")
+	}
+
+	tbl.c.stats = newStatsRegistry(time.Minute)
+	for _, family := range []string{"cf", "cf", "cf", "nosuchfamily"} {
+		mut := NewMutation()
+		mut.Set(family, "col", 1000, []byte("v"))
+		tbl.Apply(ctx, "row", mut)
+	}
+	if _, err := tbl.ReadRow(ctx, "row"); err != nil {
+		t.Fatal(err)
+	}
+	st, err := tbl.Stats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Operations != 5 || st.ErrorRate != 0.2 {
+		t.Errorf("got %d operations with error rate %v, want 5 with 0.2", st.Operations, st.ErrorRate)
+	}
+	if st.P99 <= 0 || st.QPS <= 0 {
+		t.Errorf("got P99 %v and QPS %v, want positive values", st.P99, st.QPS)
+	}
+	if st, err := tbl.c.Open("other").Stats(ctx); err != nil || st.Operations != 0 {
+		t.Errorf("other table: got (%d operations, %v), want no operations", st.Operations, err)
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeBucketKeys describes the row keys of a time-series schema in which
+// each key embeds the time bucket of its data, such as "cpu#2024061510" for
+// the hour starting at 10:00 on 15 June 2024.
+type TimeBucketKeys struct {
+	prefix, layout, suffix string
+	bucket                 time.Duration
+}
+
+// NewTimeBucketKeys returns the TimeBucketKeys of the keys described by
+// template, which holds the time layout of the bucket, as accepted by
+// time.Time.Format, between braces. For example, the template
+// "cpu#{2006010215}" with an hour bucket describes keys such as
+// "cpu#2024061510", possibly followed by more characters, such as
+// "cpu#2024061510#host1". Buckets are aligned to multiples of bucket since
+// the zero time and formatted in UTC.
+func NewTimeBucketKeys(template string, bucket time.Duration) (*TimeBucketKeys, error) {
+	i, j := strings.Index(template, "{"), strings.Index(template, "}")
+	if i < 0 || j < i || strings.Count(template, "{") != 1 || strings.Count(template, "}") != 1 {
+		return nil, fmt.Errorf("bigtable: time bucket template %q must hold a single {layout}", template)
+	}
+	if bucket <= 0 {
+		return nil, errors.New("bigtable: time bucket must be positive")
+	}
+	k := &TimeBucketKeys{
+		prefix: template[:i],
+		layout: template[i+1 : j],
+		suffix: template[j+1:],
+		bucket: bucket,
+	}
+	if k.layout == "" {
+		return nil, fmt.Errorf("bigtable: time bucket template %q has an empty layout", template)
+	}
+	return k, nil
+}
+
+// Key returns the key, or the prefix of the keys, of the bucket of t.
+func (k *TimeBucketKeys) Key(t time.Time) string {
+	return k.prefix + t.Truncate(k.bucket).UTC().Format(k.layout) + k.suffix
+}
+
+// Ranges returns the ranges of the keys of the buckets that overlap the
+// interval [start, end), as few as possible. If the formatted buckets sort
+// in time order, as they do with fixed-width layouts such as "2006010215"
+// and no text follows the layout in the template, the keys of the interval
+// form a single range.
+func (k *TimeBucketKeys) Ranges(start, end time.Time) RowRangeList {
+	if !start.Before(end) {
+		return RowRangeList{}
+	}
+	var buckets []string
+	for b := start.Truncate(k.bucket); b.Before(end); b = b.Add(k.bucket) {
+		buckets = append(buckets, k.Key(b))
+	}
+	if len(buckets) == 0 {
+		return RowRangeList{}
+	}
+	if k.suffix == "" && sortedStrictly(buckets) {
+		return RowRangeList{NewRange(buckets[0], prefixSuccessor(buckets[len(buckets)-1]))}
+	}
+	ranges := make(RowRangeList, len(buckets))
+	for i, b := range buckets {
+		ranges[i] = PrefixRange(b)
+	}
+	return ranges.Normalize()
+}
+
+func sortedStrictly(s []string) bool {
+	for i := 1; i < len(s); i++ {
+		if s[i-1] >= s[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ReadTimeBuckets reads the rows of the buckets of keys that overlap the
+// interval [start, end), with a single ReadRows call, and calls f for each
+// row in key order. The options apply as for ReadRows.
+func (t *Table) ReadTimeBuckets(ctx context.Context, keys *TimeBucketKeys, start, end time.Time, f func(Row) bool, opts ...ReadOption) error {
+	return t.ReadRows(ctx, keys.Ranges(start, end), f, opts...)
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bigtable
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTimeBucketKeysRanges(t *testing.T) {
+	at := func(s string) time.Time {
+		tm, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+	hourly, err := NewTimeBucketKeys("cpu#{2006010215}", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostly, err := NewTimeBucketKeys("cpu#{2006010215}#host1", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unordered, err := NewTimeBucketKeys("cpu#{15-02-01-2006}", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := hourly.Key(at("2024-06-15T10:42:00Z")), "cpu#2024061510"; got != want {
+		t.Errorf("Key: got %q, want %q", got, want)
+	}
+	for _, test := range []struct {
+		desc       string
+		keys       *TimeBucketKeys
+		start, end string
+		want       RowRangeList
+	}{
+		{
+			"single range across buckets",
+			hourly, "2024-06-15T09:30:00Z", "2024-06-15T12:00:00Z",
+			RowRangeList{NewRange("cpu#2024061509", "cpu#2024061512")},
+		},
+		{
+			"empty interval",
+			hourly, "2024-06-15T09:30:00Z", "2024-06-15T09:30:00Z",
+			RowRangeList{},
+		},
+		{
+			"suffix after the bucket",
+			hostly, "2024-06-15T09:00:00Z", "2024-06-15T11:00:00Z",
+			RowRangeList{PrefixRange("cpu#2024061509#host1"), PrefixRange("cpu#2024061510#host1")},
+		},
+		{
+			"buckets out of order",
+			unordered, "2024-06-15T23:00:00Z", "2024-06-16T01:00:00Z",
+			RowRangeList{PrefixRange("cpu#00-16-06-2024"), PrefixRange("cpu#23-15-06-2024")},
+		},
+	} {
+		got := test.keys.Ranges(at(test.start), at(test.end))
+		if !cmp.Equal(got, test.want, cmp.AllowUnexported(RowRange{})) {
+			t.Errorf("%s: got %v, want %v", test.desc, got, test.want)
+		}
+	}
+
+	for _, template := range []string{"cpu#", "cpu#{}", "{2006}{01}", "cpu#}2006{"} {
+		if _, err := NewTimeBucketKeys(template, time.Hour); err == nil {
+			t.Errorf("NewTimeBucketKeys(%q): got nil, want error", template)
+		}
+	}
+}
+
+func TestReadTimeBuckets(t *testing.T) {
+	ctx := context.Background()
+	tbl, cleanup, err := setupFakeServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	keys, err := NewTimeBucketKeys("m#{2006010215}", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 6, 15, 8, 0, 0, 0, time.UTC)
+	for h := 0; h < 5; h++ {
+		mut := NewMutation()
+		mut.Set("cf", "v", 1000, []byte{byte(h)})
+		if err := tbl.Apply(ctx, keys.Key(start.Add(time.Duration(h)*time.Hour))+"#a", mut); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	err = tbl.ReadTimeBuckets(ctx, keys, start.Add(90*time.Minute), start.Add(3*time.Hour), func(r Row) bool {
+		got = append(got, r.Key())
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"m#2024061509#a", "m#2024061510#a"}
+	if !cmp.Equal(got, want) {
+		t.Errorf("got rows %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,276 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package httptask enqueues HTTP target tasks to Cloud Tasks queues with the
+// cloudtasks/apiv2 Client.
+//
+// An Enqueuer turns HTTPTask values into CreateTask requests. Tasks with a
+// DedupKey are named after a hash of the key, so that Cloud Tasks rejects
+// duplicates of them; creating such a task again, as when retrying a request
+// whose response was lost, is not an error.
+//
+// Example:
+//
+//	client, err := cloudtasks.NewClient(ctx)
+//	if err != nil {
+//		// TODO: Handle error.
+//	}
+//	e := httptask.NewEnqueuer(client, "projects/my-project/locations/us-central1/queues/my-queue")
+//	_, err = e.Enqueue(ctx, &httptask.HTTPTask{
+//		URL:      "https://example.com/handle",
+//		Body:     []byte(`{"order": 123}`),
+//		Auth:     httptask.OIDC("invoker@my-project.iam.gserviceaccount.com", ""),
+//		DedupKey: "order-123",
+//	})
+package httptask // import "cloud.google.com/go/cloudtasks/httptask"
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/cloudtasks/apiv2/cloudtaskspb"
+	gax "github.com/googleapis/gax-go/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// DefaultMaxConcurrency is the default number of CreateTask requests that
+// EnqueueBatch has in flight at a time.
+const DefaultMaxConcurrency = 10
+
+// Client is the subset of the methods of the cloudtasks/apiv2 Client used by
+// an Enqueuer.
+type Client interface {
+	CreateTask(context.Context, *cloudtaskspb.CreateTaskRequest, ...gax.CallOption) (*cloudtaskspb.Task, error)
+	GetTask(context.Context, *cloudtaskspb.GetTaskRequest, ...gax.CallOption) (*cloudtaskspb.Task, error)
+}
+
+// Auth configures the token that Cloud Tasks adds to the requests of a task.
+type Auth interface {
+	apply(*cloudtaskspb.HttpRequest)
+}
+
+type oidcAuth cloudtaskspb.OidcToken
+
+func (a *oidcAuth) apply(r *cloudtaskspb.HttpRequest) {
+	r.AuthorizationHeader = &cloudtaskspb.HttpRequest_OidcToken{
+		OidcToken: &cloudtaskspb.OidcToken{ServiceAccountEmail: a.ServiceAccountEmail, Audience: a.Audience},
+	}
+}
+
+// OIDC makes Cloud Tasks sign the requests of a task with an OpenID Connect
+// token of the given service account, as required by Cloud Run and Cloud
+// Functions targets. If audience is empty, the URL of the task is used.
+func OIDC(serviceAccountEmail, audience string) Auth {
+	return &oidcAuth{ServiceAccountEmail: serviceAccountEmail, Audience: audience}
+}
+
+type oauthAuth cloudtaskspb.OAuthToken
+
+func (a *oauthAuth) apply(r *cloudtaskspb.HttpRequest) {
+	r.AuthorizationHeader = &cloudtaskspb.HttpRequest_OauthToken{
+		OauthToken: &cloudtaskspb.OAuthToken{ServiceAccountEmail: a.ServiceAccountEmail, Scope: a.Scope},
+	}
+}
+
+// OAuth makes Cloud Tasks sign the requests of a task with an OAuth access
+// token of the given service account, as required by Google APIs targets. If
+// scope is empty, "https://www.googleapis.com/auth/cloud-platform" is used.
+func OAuth(serviceAccountEmail, scope string) Auth {
+	return &oauthAuth{ServiceAccountEmail: serviceAccountEmail, Scope: scope}
+}
+
+// HTTPTask describes a task with an HTTP target.
+type HTTPTask struct {
+	// URL is the full URL the task request is sent to.
+	URL string
+
+	// Method is the HTTP method of the request. It defaults to POST.
+	Method string
+
+	// Headers are the headers of the request.
+	Headers map[string]string
+
+	// Body is the body of the request, which requires a POST, PUT or PATCH
+	// method.
+	Body []byte
+
+	// Auth, if not nil, configures the token added to the request.
+	Auth Auth
+
+	// ScheduleTime is when the task is dispatched. If it is zero, the task is
+	// dispatched immediately.
+	ScheduleTime time.Time
+
+	// DispatchDeadline is the deadline of the request. If it is zero, the
+	// default of Cloud Tasks applies.
+	DispatchDeadline time.Duration
+
+	// DedupKey, if not empty, names the task after a hash of the key and the
+	// queue, so that at most one task with the key is created in the queue
+	// within the deduplication window of Cloud Tasks. Hashing spreads the
+	// names over the key space, as Cloud Tasks recommends for performance.
+	DedupKey string
+}
+
+// TaskName returns the name of the task with the given DedupKey in queue.
+func TaskName(queue, dedupKey string) string {
+	sum := sha256.Sum256([]byte(queue + "\x00" + dedupKey))
+	return queue + "/tasks/" + hex.EncodeToString(sum[:16])
+}
+
+// An Enqueuer creates HTTP target tasks in a queue.
+type Enqueuer struct {
+	client Client
+	queue  string
+
+	// MaxConcurrency is the maximum number of CreateTask requests that
+	// EnqueueBatch has in flight at a time. If it is not positive,
+	// DefaultMaxConcurrency is used.
+	MaxConcurrency int
+}
+
+// NewEnqueuer returns an Enqueuer that creates tasks in the queue with the
+// given full name, of the form
+// "projects/PROJECT_ID/locations/LOCATION_ID/queues/QUEUE_ID".
+func NewEnqueuer(client Client, queue string) *Enqueuer {
+	return &Enqueuer{client: client, queue: queue}
+}
+
+// Enqueue creates a task from t.
+//
+// If t has a DedupKey and a task with its name already exists, Enqueue
+// returns that task and no error. If that task was already dispatched and
+// deleted, the returned task has only its name set.
+func (e *Enqueuer) Enqueue(ctx context.Context, t *HTTPTask) (*cloudtaskspb.Task, error) {
+	task, err := e.newTask(t)
+	if err != nil {
+		return nil, err
+	}
+	created, err := e.client.CreateTask(ctx, &cloudtaskspb.CreateTaskRequest{Parent: e.queue, Task: task})
+	if err == nil || task.Name == "" || status.Code(err) != codes.AlreadyExists {
+		return created, err
+	}
+	existing, err := e.client.GetTask(ctx, &cloudtaskspb.GetTaskRequest{Name: task.Name})
+	if status.Code(err) == codes.NotFound {
+		return &cloudtaskspb.Task{Name: task.Name}, nil
+	}
+	return existing, err
+}
+
+func (e *Enqueuer) newTask(t *HTTPTask) (*cloudtaskspb.Task, error) {
+	if t.URL == "" {
+		return nil, errors.New("httptask: missing URL")
+	}
+	method := cloudtaskspb.HttpMethod_POST
+	if t.Method != "" {
+		m, ok := cloudtaskspb.HttpMethod_value[strings.ToUpper(t.Method)]
+		if !ok || m == 0 {
+			return nil, fmt.Errorf("httptask: unsupported HTTP method %q", t.Method)
+		}
+		method = cloudtaskspb.HttpMethod(m)
+	}
+	req := &cloudtaskspb.HttpRequest{
+		Url:        t.URL,
+		HttpMethod: method,
+		Headers:    t.Headers,
+		Body:       t.Body,
+	}
+	if t.Auth != nil {
+		t.Auth.apply(req)
+	}
+	task := &cloudtaskspb.Task{
+		MessageType: &cloudtaskspb.Task_HttpRequest{HttpRequest: req},
+	}
+	if t.DedupKey != "" {
+		task.Name = TaskName(e.queue, t.DedupKey)
+	}
+	if !t.ScheduleTime.IsZero() {
+		task.ScheduleTime = timestamppb.New(t.ScheduleTime)
+	}
+	if t.DispatchDeadline != 0 {
+		task.DispatchDeadline = durationpb.New(t.DispatchDeadline)
+	}
+	return task, nil
+}
+
+// BatchError is returned by EnqueueBatch when some tasks could not be
+// created. It holds the error of each task, in the order of the tasks, with
+// nil for the tasks that were created.
+type BatchError []error
+
+func (e BatchError) Error() string {
+	n := 0
+	var first error
+	for _, err := range e {
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			n++
+		}
+	}
+	if n == 1 {
+		return first.Error()
+	}
+	return fmt.Sprintf("%v (and %d other errors)", first, n-1)
+}
+
+// EnqueueBatch creates a task from each of ts with Enqueue, with at most
+// MaxConcurrency requests in flight. It returns the created tasks in the order
+// of ts, with nil for the tasks that could not be created, in which case the
+// error is a BatchError.
+func (e *Enqueuer) EnqueueBatch(ctx context.Context, ts []*HTTPTask) ([]*cloudtaskspb.Task, error) {
+	n := e.MaxConcurrency
+	if n <= 0 {
+		n = DefaultMaxConcurrency
+	}
+	tasks := make([]*cloudtaskspb.Task, len(ts))
+	errs := make(BatchError, len(ts))
+	failed := false
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, n)
+	for i, t := range ts {
+		i, t := i, t
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			task, err := e.Enqueue(ctx, t)
+			mu.Lock()
+			defer mu.Unlock()
+			tasks[i], errs[i] = task, err
+			if err != nil {
+				failed = true
+			}
+		}()
+	}
+	wg.Wait()
+	if failed {
+		return tasks, errs
+	}
+	return tasks, nil
+}
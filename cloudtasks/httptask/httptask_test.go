@@ -0,0 +1,205 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httptask
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/cloudtasks/apiv2/cloudtaskspb"
+	gax "github.com/googleapis/gax-go/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const testQueue = "projects/p/locations/l/queues/q"
+
+type fakeClient struct {
+	mu       sync.Mutex
+	tasks    map[string]*cloudtaskspb.Task
+	inFlight int
+	maxIn    int
+	fail     func(*cloudtaskspb.Task) error
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{tasks: map[string]*cloudtaskspb.Task{}}
+}
+
+func (c *fakeClient) CreateTask(_ context.Context, req *cloudtaskspb.CreateTaskRequest, _ ...gax.CallOption) (*cloudtaskspb.Task, error) {
+	c.mu.Lock()
+	c.inFlight++
+	if c.inFlight > c.maxIn {
+		c.maxIn = c.inFlight
+	}
+	c.mu.Unlock()
+	time.Sleep(time.Millisecond)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inFlight--
+	if req.Parent != testQueue {
+		return nil, fmt.Errorf("bad parent %q", req.Parent)
+	}
+	if c.fail != nil {
+		if err := c.fail(req.Task); err != nil {
+			return nil, err
+		}
+	}
+	t := req.Task
+	if t.Name == "" {
+		t.Name = fmt.Sprintf("%s/tasks/%d", testQueue, len(c.tasks))
+	} else if _, ok := c.tasks[t.Name]; ok {
+		return nil, status.Error(codes.AlreadyExists, "exists")
+	}
+	c.tasks[t.Name] = t
+	return t, nil
+}
+
+func (c *fakeClient) GetTask(_ context.Context, req *cloudtaskspb.GetTaskRequest, _ ...gax.CallOption) (*cloudtaskspb.Task, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t, ok := c.tasks[req.Name]; ok {
+		return t, nil
+	}
+	return nil, status.Error(codes.NotFound, "not found")
+}
+
+func TestEnqueue(t *testing.T) {
+	ctx := context.Background()
+	fc := newFakeClient()
+	e := NewEnqueuer(fc, testQueue)
+	sched := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	task, err := e.Enqueue(ctx, &HTTPTask{
+		URL:              "https://example.com",
+		Method:           "put",
+		Body:             []byte("b"),
+		Auth:             OIDC("sa@p.iam.gserviceaccount.com", "aud"),
+		ScheduleTime:     sched,
+		DispatchDeadline: time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := task.GetHttpRequest()
+	if req.Url != "https://example.com" || req.HttpMethod != cloudtaskspb.HttpMethod_PUT || string(req.Body) != "b" {
+		t.Errorf("got request %v", req)
+	}
+	if tok := req.GetOidcToken(); tok.GetServiceAccountEmail() != "sa@p.iam.gserviceaccount.com" || tok.GetAudience() != "aud" {
+		t.Errorf("got OIDC token %v", tok)
+	}
+	if !task.ScheduleTime.AsTime().Equal(sched) || task.DispatchDeadline.AsDuration() != time.Minute {
+		t.Errorf("got schedule time %v and deadline %v", task.ScheduleTime, task.DispatchDeadline)
+	}
+
+	task, err = e.Enqueue(ctx, &HTTPTask{URL: "https://example.com", Auth: OAuth("sa@p.iam.gserviceaccount.com", "")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req := task.GetHttpRequest(); req.HttpMethod != cloudtaskspb.HttpMethod_POST || req.GetOauthToken() == nil {
+		t.Errorf("got request %v, want POST with OAuth token", req)
+	}
+
+	for _, bad := range []*HTTPTask{{}, {URL: "https://example.com", Method: "CONNECT"}} {
+		if _, err := e.Enqueue(ctx, bad); err == nil {
+			t.Errorf("%+v: got no error", bad)
+		}
+	}
+}
+
+func TestEnqueueDedup(t *testing.T) {
+	ctx := context.Background()
+	fc := newFakeClient()
+	e := NewEnqueuer(fc, testQueue)
+	ht := &HTTPTask{URL: "https://example.com", DedupKey: "k"}
+
+	first, err := e.Enqueue(ctx, ht)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Name != TaskName(testQueue, "k") {
+		t.Errorf("got name %q, want %q", first.Name, TaskName(testQueue, "k"))
+	}
+	// A duplicate returns the existing task.
+	again, err := e.Enqueue(ctx, ht)
+	if err != nil || again != first {
+		t.Errorf("got %v, %v; want the first task", again, err)
+	}
+	// A duplicate of a deleted task returns its name only.
+	fc.fail = func(t *cloudtaskspb.Task) error {
+		if t.Name == TaskName(testQueue, "gone") {
+			return status.Error(codes.AlreadyExists, "tombstone")
+		}
+		return nil
+	}
+	gone, err := e.Enqueue(ctx, &HTTPTask{URL: "https://example.com", DedupKey: "gone"})
+	if err != nil || gone.Name != TaskName(testQueue, "gone") {
+		t.Errorf("got %v, %v; want a task named after the key", gone, err)
+	}
+	// Tasks without a DedupKey surface AlreadyExists errors as is.
+	fc.fail = func(*cloudtaskspb.Task) error { return status.Error(codes.AlreadyExists, "x") }
+	if _, err := e.Enqueue(ctx, &HTTPTask{URL: "https://example.com"}); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("got %v, want AlreadyExists", err)
+	}
+}
+
+func TestTaskName(t *testing.T) {
+	if TaskName(testQueue, "a") == TaskName(testQueue, "b") {
+		t.Error("different keys have the same name")
+	}
+	if TaskName(testQueue, "a") == TaskName(testQueue+"2", "a") {
+		t.Error("different queues have the same name")
+	}
+}
+
+func TestEnqueueBatch(t *testing.T) {
+	fc := newFakeClient()
+	errBad := errors.New("bad")
+	fc.fail = func(t *cloudtaskspb.Task) error {
+		if t.GetHttpRequest().Url == "https://example.com/3" {
+			return errBad
+		}
+		return nil
+	}
+	e := NewEnqueuer(fc, testQueue)
+	e.MaxConcurrency = 3
+	var ts []*HTTPTask
+	for i := 0; i < 20; i++ {
+		ts = append(ts, &HTTPTask{URL: fmt.Sprintf("https://example.com/%d", i)})
+	}
+	tasks, err := e.EnqueueBatch(context.Background(), ts)
+	var be BatchError
+	if !errors.As(err, &be) {
+		t.Fatalf("got %v, want a BatchError", err)
+	}
+	for i, task := range tasks {
+		if i == 3 {
+			if task != nil || be[i] != errBad {
+				t.Errorf("task 3: got %v, %v; want %v", task, be[i], errBad)
+			}
+			continue
+		}
+		if be[i] != nil || task.GetHttpRequest().Url != ts[i].URL {
+			t.Errorf("task %d: got %v, %v", i, task, be[i])
+		}
+	}
+	if fc.maxIn > 3 {
+		t.Errorf("got %d concurrent requests, want at most 3", fc.maxIn)
+	}
+}
@@ -289,7 +289,11 @@ func NewClient(c *http.Client) *Client {
 // getETag returns a value from the metadata service as well as the associated ETag.
 // This func is otherwise equivalent to Get.
 func (c *Client) getETag(suffix string) (value, etag string, err error) {
-	ctx := context.TODO()
+	return c.getETagWithContext(context.TODO(), suffix)
+}
+
+// getETagWithContext is like getETag, but the request is made with ctx.
+func (c *Client) getETagWithContext(ctx context.Context, suffix string) (value, etag string, err error) {
 	// Using a fixed IP makes it very difficult to spoof the metadata service in
 	// a container, which is an important use-case for local testing of cloud
 	// deployments. To enable spoofing of the metadata service, the environment
@@ -310,6 +314,7 @@ func (c *Client) getETag(suffix string) (value, etag string, err error) {
 	if err != nil {
 		return "", "", err
 	}
+	req = req.WithContext(ctx)
 	req.Header.Set("Metadata-Flavor", "Google")
 	req.Header.Set("User-Agent", userAgent)
 	var res *http.Response
@@ -0,0 +1,118 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenExpiryDelta is how long before its expiry a cached token is
+// refreshed, so that it does not expire while a request made with it is in
+// flight.
+const tokenExpiryDelta = 10 * time.Second
+
+// timeNow is time.Now. It is a variable so tests can replace it.
+var timeNow = time.Now
+
+// Token is an OAuth2 access token of a service account of the instance.
+type Token struct {
+	// AccessToken is the token that authorizes requests.
+	AccessToken string
+
+	// TokenType is the type of the token, usually "Bearer".
+	TokenType string
+
+	// Expiry is when the token expires.
+	Expiry time.Time
+}
+
+// ServiceAccountToken calls Client.ServiceAccountToken on the default client.
+func ServiceAccountToken(ctx context.Context, serviceAccount string, scopes ...string) (*Token, error) {
+	return defaultClient.ServiceAccountToken(ctx, serviceAccount, scopes...)
+}
+
+// ServiceAccountToken returns an access token of the given service account.
+// The account may be empty or the string "default" to use the instance's
+// main account. If scopes are given, the token is requested for them
+// rather than for the scopes of the account.
+func (c *Client) ServiceAccountToken(ctx context.Context, serviceAccount string, scopes ...string) (*Token, error) {
+	if serviceAccount == "" {
+		serviceAccount = "default"
+	}
+	suffix := "instance/service-accounts/" + serviceAccount + "/token"
+	if len(scopes) > 0 {
+		suffix += "?scopes=" + url.QueryEscape(strings.Join(scopes, ","))
+	}
+	j, _, err := c.getETagWithContext(ctx, suffix)
+	if err != nil {
+		return nil, err
+	}
+	var res struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+		TokenType   string `json:"token_type"`
+	}
+	if err := json.Unmarshal([]byte(j), &res); err != nil {
+		return nil, fmt.Errorf("metadata: invalid token response: %w", err)
+	}
+	if res.AccessToken == "" {
+		return nil, fmt.Errorf("metadata: token response for %q has no access token", serviceAccount)
+	}
+	return &Token{
+		AccessToken: res.AccessToken,
+		TokenType:   res.TokenType,
+		Expiry:      timeNow().Add(time.Duration(res.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// A TokenRefresher returns access tokens of a service account of the
+// instance, caching each token until shortly before it expires. It is safe
+// for concurrent use.
+type TokenRefresher struct {
+	c              *Client
+	serviceAccount string
+	scopes         []string
+
+	mu  sync.Mutex
+	tok *Token
+}
+
+// NewTokenRefresher returns a TokenRefresher for the given service account
+// and scopes, with the same meaning as for ServiceAccountToken.
+func (c *Client) NewTokenRefresher(serviceAccount string, scopes ...string) *TokenRefresher {
+	return &TokenRefresher{c: c, serviceAccount: serviceAccount, scopes: scopes}
+}
+
+// Token returns the cached token, or a new one if the cached token is about
+// to expire.
+func (r *TokenRefresher) Token(ctx context.Context) (*Token, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.tok != nil && timeNow().Add(tokenExpiryDelta).Before(r.tok.Expiry) {
+		return r.tok, nil
+	}
+	tok, err := r.c.ServiceAccountToken(ctx, r.serviceAccount, r.scopes...)
+	if err != nil {
+		return nil, err
+	}
+	r.tok = tok
+	return tok, nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTokenRefresher(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	oldNow := timeNow
+	defer func() { timeNow = oldNow }()
+	timeNow = func() time.Time { return now }
+
+	calls := 0
+	c := NewClient(&http.Client{Transport: funcTransport(func(req *http.Request) (*http.Response, error) {
+		calls++
+		if got, want := req.URL.Path, "/computeMetadata/v1/instance/service-accounts/sa@p.iam.gserviceaccount.com/token"; got != want {
+			t.Errorf("got path %q, want %q", got, want)
+		}
+		if got, want := req.URL.Query().Get("scopes"), "s1,s2"; got != want {
+			t.Errorf("got scopes %q, want %q", got, want)
+		}
+		body := fmt.Sprintf(`{"access_token":"tok%d","expires_in":60,"token_type":"Bearer"}`, calls)
+		return response(200, body, ""), nil
+	})})
+	r := c.NewTokenRefresher("sa@p.iam.gserviceaccount.com", "s1", "s2")
+	ctx := context.Background()
+	for _, test := range []struct {
+		elapsed time.Duration
+		want    string
+	}{
+		{0, "tok1"},
+		{40 * time.Second, "tok1"},
+		{55 * time.Second, "tok2"},
+		{60 * time.Second, "tok2"},
+	} {
+		now = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Add(test.elapsed)
+		tok, err := r.Token(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tok.AccessToken != test.want || tok.TokenType != "Bearer" {
+			t.Errorf("after %v: got %+v, want %s", test.elapsed, tok, test.want)
+		}
+	}
+}
+
+func TestServiceAccountTokenErrors(t *testing.T) {
+	for _, body := range []string{"not json", `{"expires_in":60}`} {
+		c := NewClient(&http.Client{Transport: funcTransport(func(req *http.Request) (*http.Response, error) {
+			if req.URL.RawQuery != "" {
+				t.Errorf("got query %q, want none", req.URL.RawQuery)
+			}
+			return response(200, body, ""), nil
+		})})
+		if _, err := c.ServiceAccountToken(context.Background(), ""); err == nil {
+			t.Errorf("%s: got nil, want error", body)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+)
+
+// newWatchBackoff returns the backoff between the requests of WatchKey after
+// a failed request, or while the key is not defined. It is a variable so
+// tests can shorten it.
+var newWatchBackoff = func() backoff {
+	return &defaultBackoff{
+		cur: time.Second,
+		max: 30 * time.Second,
+		mul: 2,
+	}
+}
+
+// WatchKey calls Client.WatchKey on the default client.
+func WatchKey(ctx context.Context, key string, fn func(old, new string)) error {
+	return defaultClient.WatchKey(ctx, key, fn)
+}
+
+// WatchKey watches a value of the metadata service, such as
+// "instance/attributes/my-config", and calls fn each time it changes, with
+// its previous and new values. An undefined value is reported as the empty
+// string. WatchKey does not call fn for the value the key has when it starts.
+//
+// WatchKey waits for changes with hanging GET requests, passing the ETag of
+// the last value seen so that no change is missed between requests. Failed
+// requests are retried with exponential backoff, and a request that times
+// out, as one made with an http.Client with a Timeout does while the value
+// does not change, is made again. The key must not contain query
+// parameters.
+//
+// WatchKey blocks until ctx is done, and then returns ctx.Err().
+func (c *Client) WatchKey(ctx context.Context, key string, fn func(old, new string)) error {
+	var last, lastETag string
+	bo := newWatchBackoff()
+	wait := func() error { return sleep(ctx, bo.Pause()) }
+
+	// Get the current value, so that only changes are reported.
+	for {
+		v, etag, err := c.getETagWithContext(ctx, key)
+		if err == nil || isNotDefined(err) {
+			last, lastETag = v, etag
+			break
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := wait(); err != nil {
+			return err
+		}
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		suffix := key
+		if lastETag != "" {
+			suffix += "?wait_for_change=true&last_etag=" + url.QueryEscape(lastETag)
+		}
+		v, etag, err := c.getETagWithContext(ctx, suffix)
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err == nil:
+			bo = newWatchBackoff()
+			lastETag = etag
+			if v != last {
+				old := last
+				last = v
+				fn(old, v)
+			}
+			if lastETag != "" {
+				// The request returned a change or timed out on the
+				// server; wait for the next change right away.
+				continue
+			}
+		case isNotDefined(err):
+			// The value was deleted, or is not defined yet. The server
+			// does not hang requests for undefined values, so poll for
+			// it to be defined.
+			lastETag = ""
+			if last != "" {
+				old := last
+				last = ""
+				fn(old, "")
+			}
+		case isTimeout(err):
+			continue
+		}
+		if err := wait(); err != nil {
+			return err
+		}
+	}
+}
+
+func isNotDefined(err error) bool {
+	var nde NotDefinedError
+	return errors.As(err, &nde)
+}
+
+// isTimeout reports whether err is the error of a request that timed out on
+// the client.
+func isTimeout(err error) bool {
+	var te interface{ Timeout() bool }
+	return errors.As(err, &te) && te.Timeout()
+}
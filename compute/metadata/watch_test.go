@@ -0,0 +1,132 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// funcTransport is an http.RoundTripper that calls a function.
+type funcTransport func(*http.Request) (*http.Response, error)
+
+func (f funcTransport) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func response(code int, body, etag string) *http.Response {
+	res := &http.Response{
+		StatusCode: code,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+	if etag != "" {
+		res.Header.Set("Etag", etag)
+	}
+	return res
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string { return "timeout" }
+func (timeoutError) Timeout() bool { return true }
+
+func TestWatchKey(t *testing.T) {
+	old := newWatchBackoff
+	defer func() { newWatchBackoff = old }()
+	newWatchBackoff = func() backoff { return constantBackoff{} }
+
+	const key = "/computeMetadata/v1/instance/attributes/config"
+	var requests []string
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var changes [][2]string
+	c := NewClient(&http.Client{Transport: funcTransport(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path != key {
+			t.Errorf("got path %q, want %q", req.URL.Path, key)
+		}
+		q := req.URL.Query()
+		requests = append(requests, q.Get("last_etag"))
+		switch q.Get("last_etag") {
+		case "":
+			if len(requests) == 1 {
+				return response(200, "a", "1"), nil
+			}
+			return response(200, "c", "3"), nil
+		case "1":
+			if q.Get("wait_for_change") != "true" {
+				t.Errorf("got query %q, want wait_for_change", req.URL.RawQuery)
+			}
+			return response(200, "b", "2"), nil
+		case "2":
+			if len(requests) == 3 {
+				return nil, timeoutError{}
+			}
+			return response(404, "", ""), nil
+		default:
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}
+	})})
+	err := c.WatchKey(ctx, "instance/attributes/config", func(old, new string) {
+		changes = append(changes, [2]string{old, new})
+		if new == "c" {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+	want := [][2]string{{"a", "b"}, {"b", ""}, {"", "c"}}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("got changes %v, want %v", changes, want)
+	}
+	if want := []string{"", "1", "2", "2", ""}; !reflect.DeepEqual(requests, want) {
+		t.Errorf("got requests with ETags %q, want %q", requests, want)
+	}
+}
+
+func TestWatchKeyRetriesInitialGet(t *testing.T) {
+	old := newWatchBackoff
+	defer func() { newWatchBackoff = old }()
+	newWatchBackoff = func() backoff { return constantBackoff{} }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := 0
+	c := NewClient(&http.Client{Transport: funcTransport(func(req *http.Request) (*http.Response, error) {
+		calls++
+		switch {
+		case calls == 1:
+			return response(403, "denied", ""), nil
+		case calls == 2:
+			return response(200, "a", "1"), nil
+		default:
+			cancel()
+			return nil, req.Context().Err()
+		}
+	})})
+	var changes int
+	err := c.WatchKey(ctx, "instance/attributes/config", func(old, new string) { changes++ })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+	if calls != 3 || changes != 0 {
+		t.Errorf("got %d calls and %d changes, want 3 and 0", calls, changes)
+	}
+}
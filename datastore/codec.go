@@ -0,0 +1,80 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// A PropertyCodec converts the values of a Go type to and from property
+// values. It lets struct fields of types that the datastore package does not
+// support natively, such as UUIDs or decimals, be saved and loaded, and can
+// change how supported types, such as time.Time, are stored.
+type PropertyCodec interface {
+	// Encode returns the property value to save for v, which has the type
+	// the codec was registered for. The returned value must be one of the
+	// types listed in the documentation of Property.Value, other than
+	// []interface{}.
+	Encode(v interface{}) (interface{}, error)
+
+	// Decode returns the value of the registered type to load for the
+	// property value pv. pv is nil if the property is null.
+	Decode(pv interface{}) (interface{}, error)
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[reflect.Type]PropertyCodec{}
+
+	jsonTagFallback atomic.Bool
+)
+
+// RegisterPropertyCodec registers c as the codec for the struct fields of
+// type t, and for the elements of slice fields of type t. Fields of type t are
+// no longer saved or loaded in the usual way: their values are passed to
+// c.Encode when saving and produced by c.Decode when loading. Registering a
+// nil codec removes the codec of t.
+//
+// RegisterPropertyCodec must be called before any struct containing a field
+// of type t is saved or loaded, typically from an init function.
+func RegisterPropertyCodec(t reflect.Type, c PropertyCodec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if c == nil {
+		delete(codecs, t)
+		return
+	}
+	codecs[t] = c
+}
+
+// codecFor returns the codec registered for t, or nil.
+func codecFor(t reflect.Type) PropertyCodec {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	return codecs[t]
+}
+
+// SetJSONTagFallback controls whether the names and the omitempty option of
+// struct fields without a "datastore" tag are taken from their "json" tag.
+// It eases the migration of models that only have json tags. Fields with a
+// "datastore" tag, even an empty one, are not affected. It is off by default.
+//
+// SetJSONTagFallback must be called before any struct is saved or loaded,
+// typically from an init function.
+func SetJSONTagFallback(enabled bool) {
+	jsonTagFallback.Store(enabled)
+}
@@ -0,0 +1,178 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"cloud.google.com/go/internal/testutil"
+)
+
+type codecUUID [4]byte
+
+type uuidCodec struct{}
+
+func (uuidCodec) Encode(v interface{}) (interface{}, error) {
+	u := v.(codecUUID)
+	return hex.EncodeToString(u[:]), nil
+}
+
+func (uuidCodec) Decode(pv interface{}) (interface{}, error) {
+	var u codecUUID
+	if pv == nil {
+		return u, nil
+	}
+	s, ok := pv.(string)
+	if !ok {
+		return nil, fmt.Errorf("want string, got %T", pv)
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	copy(u[:], b)
+	return u, nil
+}
+
+type codecDecimal struct {
+	units int64
+	cents int64
+}
+
+type decimalCodec struct{}
+
+func (decimalCodec) Encode(v interface{}) (interface{}, error) {
+	d := v.(codecDecimal)
+	return d.units*100 + d.cents, nil
+}
+
+func (decimalCodec) Decode(pv interface{}) (interface{}, error) {
+	n, _ := pv.(int64)
+	return codecDecimal{units: n / 100, cents: n % 100}, nil
+}
+
+func TestPropertyCodec(t *testing.T) {
+	RegisterPropertyCodec(reflect.TypeOf(codecUUID{}), uuidCodec{})
+	RegisterPropertyCodec(reflect.TypeOf(codecDecimal{}), decimalCodec{})
+	defer func() {
+		RegisterPropertyCodec(reflect.TypeOf(codecUUID{}), nil)
+		RegisterPropertyCodec(reflect.TypeOf(codecDecimal{}), nil)
+	}()
+
+	type order struct {
+		ID     codecUUID
+		Total  codecDecimal `datastore:",noindex"`
+		Others []codecUUID
+		N      int
+	}
+	src := &order{
+		ID:     codecUUID{1, 2, 3, 4},
+		Total:  codecDecimal{units: 12, cents: 34},
+		Others: []codecUUID{{5}, {6}},
+		N:      7,
+	}
+	props, err := SaveStruct(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantProps := []Property{
+		{Name: "ID", Value: "01020304"},
+		{Name: "Total", Value: int64(1234), NoIndex: true},
+		{Name: "Others", Value: []interface{}{"05000000", "06000000"}},
+		{Name: "N", Value: int64(7)},
+	}
+	if !testutil.Equal(props, wantProps) {
+		t.Errorf("SaveStruct:\ngot  %+v\nwant %+v", props, wantProps)
+	}
+
+	var got order
+	if err := LoadStruct(&got, props); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&got, src) {
+		t.Errorf("LoadStruct: got %+v, want %+v", got, src)
+	}
+
+	if err := LoadStruct(&got, []Property{{Name: "ID", Value: int64(1)}}); err == nil {
+		t.Error("got nil, want error decoding an int64 into a codecUUID")
+	}
+}
+
+type jsonTagged struct {
+	Name    string `json:"name"`
+	Skipped string `json:"-"`
+	Empty   string `json:"empty,omitempty"`
+	Both    string `json:"json_name" datastore:"ds_name"`
+	Plain   string
+}
+
+func TestJSONTagFallback(t *testing.T) {
+	SetJSONTagFallback(true)
+	defer SetJSONTagFallback(false)
+
+	src := &jsonTagged{Name: "n", Skipped: "s", Both: "b", Plain: "p"}
+	props, err := SaveStruct(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Property{
+		{Name: "name", Value: "n"},
+		{Name: "ds_name", Value: "b"},
+		{Name: "Plain", Value: "p"},
+	}
+	if !testutil.Equal(props, want) {
+		t.Errorf("SaveStruct:\ngot  %+v\nwant %+v", props, want)
+	}
+
+	var got jsonTagged
+	if err := LoadStruct(&got, props); err != nil {
+		t.Fatal(err)
+	}
+	if w := (jsonTagged{Name: "n", Both: "b", Plain: "p"}); got != w {
+		t.Errorf("LoadStruct: got %+v, want %+v", got, w)
+	}
+}
+
+func TestParseJSONTag(t *testing.T) {
+	for _, test := range []struct {
+		tag       string
+		wantName  string
+		wantKeep  bool
+		wantOther interface{}
+		wantErr   bool
+	}{
+		{tag: "-", wantKeep: false},
+		{tag: "a", wantName: "a", wantKeep: true},
+		{tag: ",omitempty", wantKeep: true, wantOther: saveOpts{omitEmpty: true}},
+		{tag: "a,string", wantName: "a", wantKeep: true},
+		{tag: "a-b", wantErr: true},
+	} {
+		name, keep, other, err := parseJSONTag(test.tag)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%q: got error %v, want error %t", test.tag, err, test.wantErr)
+			continue
+		}
+		if test.wantErr {
+			continue
+		}
+		if name != test.wantName || keep != test.wantKeep || other != test.wantOther {
+			t.Errorf("%q: got (%q, %t, %v), want (%q, %t, %v)", test.tag,
+				name, keep, other, test.wantName, test.wantKeep, test.wantOther)
+		}
+	}
+}
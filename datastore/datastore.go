@@ -21,6 +21,7 @@ import (
 	"log"
 	"os"
 	"reflect"
+	"sync"
 	"time"
 
 	"cloud.google.com/go/internal/trace"
@@ -29,6 +30,7 @@ import (
 	gtransport "google.golang.org/api/transport/grpc"
 	pb "google.golang.org/genproto/googleapis/datastore/v1"
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -37,6 +39,19 @@ const (
 	userAgent = "gcloud-golang-datastore/20160401"
 )
 
+const (
+	// maxEntitiesPerRequest is the maximum number of keys or entities
+	// GetMulti and PutMulti send in a single RPC.
+	maxEntitiesPerRequest = 500
+	// maxMutationBytesPerRequest is the maximum total size of the mutations
+	// PutMulti sends in a single RPC. The service limits requests to 10MiB;
+	// the difference leaves room for the rest of the request.
+	maxMutationBytesPerRequest = 9 << 20
+	// maxConcurrentRequests is the maximum number of RPCs a single GetMulti
+	// or PutMulti call has in flight at the same time.
+	maxConcurrentRequests = 8
+)
+
 // ScopeDatastore grants permissions to view and/or manage datastore entities
 const ScopeDatastore = "https://www.googleapis.com/auth/datastore"
 
@@ -403,6 +418,10 @@ func (c *Client) Get(ctx context.Context, key *Key, dst interface{}) (err error)
 
 // GetMulti is a batch version of Get.
 //
+// Calls with more than 500 keys are split into several concurrent lookups.
+// In that case, the failure of a lookup is reported in a MultiError, at the
+// indexes of all the keys of that lookup.
+//
 // dst must be a []S, []*S, []I or []P, for some struct type S, some interface
 // type I, or some non-interface non-pointer type P such that P or *P
 // implements PropertyLoadSaver. If an []I, each element must be a valid dst
@@ -427,9 +446,69 @@ func (c *Client) GetMulti(ctx context.Context, keys []*Key, dst interface{}) (er
 		}
 	}
 
-	// TODO: Use transaction ID returned by get
-	_, err = c.get(ctx, keys, dst, opts)
-	return err
+	v := reflect.ValueOf(dst)
+	if len(keys) <= maxEntitiesPerRequest || v.Kind() != reflect.Slice || v.Len() != len(keys) {
+		// TODO: Use transaction ID returned by get
+		_, err = c.get(ctx, keys, dst, opts)
+		return err
+	}
+	var bounds [][2]int
+	for lo := 0; lo < len(keys); lo += maxEntitiesPerRequest {
+		hi := lo + maxEntitiesPerRequest
+		if hi > len(keys) {
+			hi = len(keys)
+		}
+		bounds = append(bounds, [2]int{lo, hi})
+	}
+	return runChunks(len(keys), bounds, func(lo, hi int) error {
+		_, err := c.get(ctx, keys[lo:hi], v.Slice(lo, hi).Interface(), opts)
+		return err
+	})
+}
+
+// runChunks calls f concurrently, with at most maxConcurrentRequests calls in
+// flight, for each of the [lo, hi) bounds of a multi call of n elements. It
+// returns a MultiError of length n if any call fails: the errors of a call
+// returning a MultiError are copied to the indexes of its chunk, and any
+// other error is set at all the indexes of its chunk.
+func runChunks(n int, bounds [][2]int, f func(lo, hi int) error) error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		multiErr = make(MultiError, n)
+		hasErr   bool
+		sem      = make(chan struct{}, maxConcurrentRequests)
+	)
+	for _, b := range bounds {
+		lo, hi := b[0], b[1]
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			err := f(lo, hi)
+			if err == nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			hasErr = true
+			if me, ok := err.(MultiError); ok && len(me) == hi-lo {
+				copy(multiErr[lo:hi], me)
+				return
+			}
+			for i := lo; i < hi; i++ {
+				multiErr[i] = err
+			}
+		}()
+	}
+	wg.Wait()
+	if hasErr {
+		return multiErr
+	}
+	return nil
 }
 
 func (c *Client) get(ctx context.Context, keys []*Key, dst interface{}, opts *pb.ReadOptions) ([]byte, error) {
@@ -584,6 +663,13 @@ func (c *Client) Put(ctx context.Context, key *Key, src interface{}) (*Key, erro
 
 // PutMulti is a batch version of Put.
 //
+// Calls with more than 500 entities, or with entities whose total size
+// exceeds the request size limit, are split into several concurrent commits.
+// In that case, the commits are not applied atomically, and the failure of a
+// commit is reported in a MultiError, at the indexes of all the entities of
+// that commit. The returned keys of the entities of the failed commits are
+// nil.
+//
 // src must satisfy the same conditions as the dst argument to GetMulti.
 // err may be a MultiError. See ExampleMultiError to check it.
 func (c *Client) PutMulti(ctx context.Context, keys []*Key, src interface{}) (ret []*Key, err error) {
@@ -596,6 +682,41 @@ func (c *Client) PutMulti(ctx context.Context, keys []*Key, src interface{}) (re
 		return nil, err
 	}
 
+	bounds := mutationChunks(mutations)
+	if len(bounds) <= 1 {
+		return c.put(ctx, keys, mutations)
+	}
+	ret = make([]*Key, len(keys))
+	err = runChunks(len(keys), bounds, func(lo, hi int) error {
+		ks, err := c.put(ctx, keys[lo:hi], mutations[lo:hi])
+		copy(ret[lo:hi], ks)
+		return err
+	})
+	return ret, err
+}
+
+// mutationChunks splits mutations into consecutive [lo, hi) ranges that each
+// fit in a single commit.
+func mutationChunks(mutations []*pb.Mutation) [][2]int {
+	var bounds [][2]int
+	lo, size := 0, 0
+	for i, m := range mutations {
+		n := proto.Size(m)
+		if i > lo && (i-lo == maxEntitiesPerRequest || size+n > maxMutationBytesPerRequest) {
+			bounds = append(bounds, [2]int{lo, i})
+			lo, size = i, 0
+		}
+		size += n
+	}
+	if lo < len(mutations) {
+		bounds = append(bounds, [2]int{lo, len(mutations)})
+	}
+	return bounds
+}
+
+// put commits the put mutations for keys, and returns the keys of the
+// entities, including the keys allocated for incomplete keys.
+func (c *Client) put(ctx context.Context, keys []*Key, mutations []*pb.Mutation) (ret []*Key, err error) {
 	// Make the request.
 	req := &pb.CommitRequest{
 		ProjectId:  c.dataset,
@@ -19,6 +19,7 @@ import (
 	"errors"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -362,6 +363,155 @@ func TestPutMultiTypes(t *testing.T) {
 	}
 }
 
+func TestPutMultiChunks(t *testing.T) {
+	ctx := context.Background()
+	type ent struct {
+		A int
+	}
+	const n = 2*maxEntitiesPerRequest + 1
+	keys := make([]*Key, n)
+	src := make([]*ent, n)
+	for i := range keys {
+		if i%2 == 0 {
+			keys[i] = IncompleteKey("K", nil)
+		} else {
+			keys[i] = IDKey("K", int64(i), nil)
+		}
+		src[i] = &ent{A: i}
+	}
+
+	var (
+		mu        sync.Mutex
+		batchLens []int
+	)
+	client := &Client{
+		client: &fakeClient{
+			commitFn: func(req *pb.CommitRequest) (*pb.CommitResponse, error) {
+				mu.Lock()
+				batchLens = append(batchLens, len(req.Mutations))
+				mu.Unlock()
+				// Fail the commit holding the last entity.
+				if len(req.Mutations) == 1 {
+					return nil, errors.New("commit failed")
+				}
+				var res []*pb.MutationResult
+				for _, m := range req.Mutations {
+					ins := m.GetInsert()
+					if ins == nil {
+						res = append(res, &pb.MutationResult{})
+						continue
+					}
+					a := ins.Properties["A"].GetIntegerValue()
+					k := keyToProto(IDKey("K", 1000+a, nil))
+					res = append(res, &pb.MutationResult{Key: k})
+				}
+				return &pb.CommitResponse{MutationResults: res}, nil
+			},
+		},
+	}
+
+	got, err := client.PutMulti(ctx, keys, src)
+	me, ok := err.(MultiError)
+	if !ok {
+		t.Fatalf("got %v, want MultiError", err)
+	}
+	sort.Ints(batchLens)
+	if want := []int{1, maxEntitiesPerRequest, maxEntitiesPerRequest}; !testutil.Equal(batchLens, want) {
+		t.Errorf("got batch sizes %v, want %v", batchLens, want)
+	}
+	for i := range keys {
+		if i == n-1 {
+			if me[i] == nil || got[i] != nil {
+				t.Errorf("#%d: got (%v, %v), want (nil, error)", i, got[i], me[i])
+			}
+			continue
+		}
+		want := keys[i]
+		if i%2 == 0 {
+			want = IDKey("K", int64(1000+i), nil)
+		}
+		if me[i] != nil || !got[i].Equal(want) {
+			t.Errorf("#%d: got (%v, %v), want (%v, nil)", i, got[i], me[i], want)
+		}
+	}
+}
+
+func TestMutationChunksBySize(t *testing.T) {
+	big := &pb.Mutation{Operation: &pb.Mutation_Upsert{Upsert: &pb.Entity{
+		Properties: map[string]*pb.Value{
+			"B": {ValueType: &pb.Value_BlobValue{BlobValue: make([]byte, 4<<20)}},
+		},
+	}}}
+	got := mutationChunks([]*pb.Mutation{big, big, big, {}})
+	want := [][2]int{{0, 2}, {2, 4}}
+	if !testutil.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestGetMultiChunks(t *testing.T) {
+	ctx := context.Background()
+	type ent struct {
+		A int
+	}
+	const n = maxEntitiesPerRequest + 10
+	keys := make([]*Key, n)
+	for i := range keys {
+		keys[i] = IDKey("K", int64(i+1), nil)
+	}
+
+	var (
+		mu      sync.Mutex
+		lookups int
+	)
+	client := &Client{
+		client: &fakeClient{
+			lookupFn: func(req *pb.LookupRequest) (*pb.LookupResponse, error) {
+				mu.Lock()
+				lookups++
+				mu.Unlock()
+				res := &pb.LookupResponse{}
+				for _, k := range req.Keys {
+					id := k.Path[0].GetId()
+					if id == n {
+						res.Missing = append(res.Missing, &pb.EntityResult{Entity: &pb.Entity{Key: k}})
+						continue
+					}
+					res.Found = append(res.Found, &pb.EntityResult{Entity: &pb.Entity{
+						Key: k,
+						Properties: map[string]*pb.Value{
+							"A": {ValueType: &pb.Value_IntegerValue{IntegerValue: id}},
+						},
+					}})
+				}
+				return res, nil
+			},
+		},
+		readSettings: &readSettings{},
+	}
+
+	dst := make([]ent, n)
+	err := client.GetMulti(ctx, keys, dst)
+	if lookups != 2 {
+		t.Errorf("got %d lookups, want 2", lookups)
+	}
+	me, ok := err.(MultiError)
+	if !ok {
+		t.Fatalf("got %v, want MultiError", err)
+	}
+	for i := range keys {
+		if i == n-1 {
+			if me[i] != ErrNoSuchEntity {
+				t.Errorf("#%d: got %v, want ErrNoSuchEntity", i, me[i])
+			}
+			continue
+		}
+		if me[i] != nil || dst[i].A != i+1 {
+			t.Errorf("#%d: got (%v, %v), want (%d, nil)", i, dst[i].A, me[i], i+1)
+		}
+	}
+}
+
 func TestGetWithReadTime(t *testing.T) {
 	type ent struct {
 		A int
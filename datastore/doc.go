@@ -162,6 +162,18 @@ Example code:
 		J int `datastore:",noindex" json:"j"`
 	}
 
+Models that only have json tags can be saved and loaded without adding
+datastore tags by calling SetJSONTagFallback(true). The name and the
+"omitempty" option of a field without a datastore tag are then taken from its
+json tag.
+
+# Custom Field Types
+
+RegisterPropertyCodec registers a PropertyCodec for a Go type, such as a UUID
+or a decimal type. Struct fields of that type, and the elements of slice fields
+of that type, are saved as the property values returned by the codec's Encode
+method and loaded with its Decode method.
+
 # Slice Fields
 
 A field of slice type corresponds to a Datastore array property, except for []byte, which corresponds
@@ -130,6 +130,11 @@ func (l *propertyLoader) loadOneElement(codec fields.List, structValue reflect.V
 			return "cannot set struct field"
 		}
 
+		// A field with a registered codec is loaded by setVal.
+		if codecFor(field.Type) != nil {
+			break
+		}
+
 		// If field implements PLS, we delegate loading to the PLS's Load early,
 		// and stop iterating through fields.
 		ok, err := plsFieldLoad(v, p, fieldNames)
@@ -194,7 +199,7 @@ func (l *propertyLoader) loadOneElement(codec fields.List, structValue reflect.V
 	}
 
 	var slice reflect.Value
-	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 && codecFor(v.Type()) == nil {
 		slice = v
 		v = reflect.New(v.Type().Elem()).Elem()
 	} else if _, ok := prev[p.Name]; ok && !sliceOk {
@@ -265,6 +270,22 @@ func plsFieldLoad(v reflect.Value, p Property, subfields []string) (ok bool, err
 // setVal sets 'v' to the value of the Property 'p'.
 func setVal(v reflect.Value, p Property) (s string) {
 	pValue := p.Value
+	if c := codecFor(v.Type()); c != nil {
+		x, err := c.Decode(pValue)
+		if err != nil {
+			return fmt.Sprintf("decoding %v: %v", v.Type(), err)
+		}
+		xv := reflect.ValueOf(x)
+		if !xv.IsValid() {
+			v.Set(reflect.Zero(v.Type()))
+			return ""
+		}
+		if xv.Type() != v.Type() {
+			return fmt.Sprintf("codec for %v decoded a %v", v.Type(), xv.Type())
+		}
+		v.Set(xv)
+		return ""
+	}
 	switch v.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		x, ok := pValue.(int64)
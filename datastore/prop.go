@@ -136,7 +136,12 @@ func validPropertyName(name string) bool {
 
 // parseTag interprets datastore struct field tags
 func parseTag(t reflect.StructTag) (name string, keep bool, other interface{}, err error) {
-	s := t.Get("datastore")
+	s, ok := t.Lookup("datastore")
+	if !ok && jsonTagFallback.Load() {
+		if s, ok = t.Lookup("json"); ok {
+			return parseJSONTag(s)
+		}
+	}
 	parts := strings.Split(s, ",")
 	if parts[0] == "-" && len(parts) == 1 {
 		return "", false, nil, nil
@@ -166,6 +171,25 @@ func parseTag(t reflect.StructTag) (name string, keep bool, other interface{}, e
 	return parts[0], true, other, nil
 }
 
+// parseJSONTag interprets a json struct field tag in place of a missing
+// datastore tag. Only the name and the omitempty option are used.
+func parseJSONTag(s string) (name string, keep bool, other interface{}, err error) {
+	if s == "-" {
+		return "", false, nil, nil
+	}
+	parts := strings.Split(s, ",")
+	if parts[0] != "" && !validPropertyName(parts[0]) {
+		err = fmt.Errorf("datastore: json struct tag has invalid property name: %q", parts[0])
+		return "", false, nil, err
+	}
+	for _, p := range parts[1:] {
+		if p == "omitempty" {
+			other = saveOpts{omitEmpty: true}
+		}
+	}
+	return parts[0], true, other, nil
+}
+
 func validateType(t reflect.Type) error {
 	if t.Kind() != reflect.Struct {
 		return fmt.Errorf("datastore: validate called with non-struct type %s", t)
@@ -176,7 +200,7 @@ func validateType(t reflect.Type) error {
 
 // validateChildType is a recursion helper func for validateType
 func validateChildType(t reflect.Type, fieldName string, flatten, prevSlice bool, prevTypes map[reflect.Type]bool) error {
-	if prevTypes[t] {
+	if prevTypes[t] || codecFor(t) != nil {
 		return nil
 	}
 	prevTypes[t] = true
@@ -232,7 +256,7 @@ func validateChildType(t reflect.Type, fieldName string, flatten, prevSlice bool
 // isLeafType determines whether or not a type is a 'leaf type'
 // and should not be recursed into, but considered one field.
 func isLeafType(t reflect.Type) bool {
-	return t == typeOfTime || t == typeOfGeoPoint
+	return t == typeOfTime || t == typeOfGeoPoint || codecFor(t) != nil
 }
 
 // structCache collects the structs whose fields have already been calculated.
@@ -23,11 +23,13 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"cloud.google.com/go/internal/trace"
 	wrapperspb "github.com/golang/protobuf/ptypes/wrappers"
 	"google.golang.org/api/iterator"
 	pb "google.golang.org/genproto/googleapis/datastore/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type operator string
@@ -94,7 +96,9 @@ type EntityFilter interface {
 //
 // The operator parameter takes the following strings: ">", "<", ">=", "<=",
 // "=", "!=", "in", and "not-in".
-// Fields are compared against the provided value using the operator.
+// Fields are compared against the provided value using the operator. The
+// value of an "in" or "not-in" filter is a slice or an array of values, such
+// as a []string or a []*Key.
 // Field names which contain spaces, quote marks, or operator characters
 // should be passed as quoted Go string literals as returned by strconv.Quote
 // or the fmt package's %q verb.
@@ -109,12 +113,16 @@ func (pf PropertyFilter) toProto() (*pb.Filter, error) {
 	if pf.FieldName == "" {
 		return nil, errors.New("datastore: empty query filter field name")
 	}
-	v, err := interfaceToProto(reflect.ValueOf(pf.Value).Interface(), false)
+	op, isOp := stringToOperator[pf.Operator]
+	value := pf.Value
+	if isOp && (op == in || op == notIn) {
+		value = filterListValue(value)
+	}
+	v, err := interfaceToProto(reflect.ValueOf(value).Interface(), false)
 	if err != nil {
 		return nil, fmt.Errorf("datastore: bad query filter value type: %w", err)
 	}
 
-	op, isOp := stringToOperator[pf.Operator]
 	if !isOp {
 		return nil, fmt.Errorf("datastore: invalid operator %q in filter", pf.Operator)
 	}
@@ -133,6 +141,25 @@ func (pf PropertyFilter) toProto() (*pb.Filter, error) {
 	}, nil
 }
 
+// filterListValue converts the value of an "in" or "not-in" filter to a
+// []interface{} if it is a slice or an array of any supported type, so that
+// it is sent as an array value. Other values are returned unchanged.
+func filterListValue(value interface{}) interface{} {
+	switch value.(type) {
+	case []interface{}, []byte:
+		return value
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return value
+	}
+	vs := make([]interface{}, rv.Len())
+	for i := range vs {
+		vs[i] = rv.Index(i).Interface()
+	}
+	return vs
+}
+
 func (pf PropertyFilter) toValidFilter() (EntityFilter, error) {
 	op := strings.TrimSpace(pf.Operator)
 	_, isOp := stringToOperator[op]
@@ -162,6 +189,9 @@ type OrFilter struct {
 func (OrFilter) isCompositeFilter() {}
 
 func (of OrFilter) toProto() (*pb.Filter, error) {
+	if len(of.Filters) == 0 {
+		return nil, errors.New("datastore: OrFilter must contain at least one filter")
+	}
 
 	var pbFilters []*pb.Filter
 
@@ -199,6 +229,9 @@ type AndFilter struct {
 func (AndFilter) isCompositeFilter() {}
 
 func (af AndFilter) toProto() (*pb.Filter, error) {
+	if len(af.Filters) == 0 {
+		return nil, errors.New("datastore: AndFilter must contain at least one filter")
+	}
 
 	var pbFilters []*pb.Filter
 
@@ -252,6 +285,7 @@ type Query struct {
 	distinctOn []string
 	keysOnly   bool
 	eventual   bool
+	readTime   time.Time
 	limit      int32
 	offset     int32
 	start      []byte
@@ -299,6 +333,20 @@ func (q *Query) EventualConsistency() *Query {
 	return q
 }
 
+// ReadTime returns a derivative query that reads entities as they were at
+// time t, which must be within the database's version retention period. Such
+// stale reads see a consistent snapshot of the database, which is useful for
+// analytical jobs or for verifying backups.
+//
+// ReadTime cannot be used with EventualConsistency or in a transaction. A
+// ReadTime set on the query takes precedence over one set with
+// Client.WithReadOptions.
+func (q *Query) ReadTime(t time.Time) *Query {
+	q = q.clone()
+	q.readTime = t
+	return q
+}
+
 // Namespace returns a derivative query that is associated with the given
 // namespace.
 //
@@ -503,7 +551,7 @@ func (q *Query) toRunQueryRequest(req *pb.RunQueryRequest) error {
 		return err
 	}
 
-	req.ReadOptions, err = parseReadOptions(q.eventual, q.trans)
+	req.ReadOptions, err = parseReadOptions(q.eventual, q.trans, q.readTime)
 	if err != nil {
 		return err
 	}
@@ -718,6 +766,7 @@ func (c *Client) Run(ctx context.Context, q *Query) *Iterator {
 	if q.err != nil {
 		return &Iterator{err: q.err}
 	}
+	q = c.withDefaultReadTime(q)
 	t := &Iterator{
 		ctx:          ctx,
 		client:       c,
@@ -747,6 +796,11 @@ func (c *Client) Run(ctx context.Context, q *Query) *Iterator {
 }
 
 // RunAggregationQuery gets aggregation query (e.g. COUNT) results from the service.
+//
+// If the underlying query is part of a transaction, the aggregation is run in
+// that transaction, which may be read-only. Otherwise, if the underlying query
+// has a read time, or a ReadTime was set with Client.WithReadOptions, the
+// aggregation is run against the snapshot of the database at that time.
 func (c *Client) RunAggregationQuery(ctx context.Context, aq *AggregationQuery) (ar AggregationResult, err error) {
 	ctx = trace.StartSpan(ctx, "cloud.google.com/go/datastore.Query.RunAggregationQuery")
 	defer func() { trace.EndSpan(ctx, err) }()
@@ -763,7 +817,8 @@ func (c *Client) RunAggregationQuery(ctx context.Context, aq *AggregationQuery)
 		return nil, errors.New("datastore: aggregation query must contain one or more operators (e.g. count)")
 	}
 
-	q, err := aq.query.toProto()
+	query := c.withDefaultReadTime(aq.query)
+	q, err := query.toProto()
 	if err != nil {
 		return nil, err
 	}
@@ -781,14 +836,14 @@ func (c *Client) RunAggregationQuery(ctx context.Context, aq *AggregationQuery)
 		},
 	}
 
-	if aq.query.namespace != "" {
+	if query.namespace != "" {
 		req.PartitionId = &pb.PartitionId{
-			NamespaceId: aq.query.namespace,
+			NamespaceId: query.namespace,
 		}
 	}
 
 	// Parse the read options.
-	req.ReadOptions, err = parseReadOptions(aq.query.eventual, aq.query.trans)
+	req.ReadOptions, err = parseReadOptions(query.eventual, query.trans, query.readTime)
 	if err != nil {
 		return nil, err
 	}
@@ -810,6 +865,17 @@ func (c *Client) RunAggregationQuery(ctx context.Context, aq *AggregationQuery)
 	return ar, nil
 }
 
+// withDefaultReadTime returns q with the read time set with
+// Client.WithReadOptions, unless q already has a read time, is eventually
+// consistent or is part of a transaction.
+func (c *Client) withDefaultReadTime(q *Query) *Query {
+	if c.readSettings == nil || c.readSettings.readTime.IsZero() ||
+		!q.readTime.IsZero() || q.eventual || q.trans != nil {
+		return q
+	}
+	return q.ReadTime(c.readSettings.readTime)
+}
+
 func validateReadOptions(eventual bool, t *Transaction) error {
 	if t == nil {
 		return nil
@@ -824,12 +890,27 @@ func validateReadOptions(eventual bool, t *Transaction) error {
 }
 
 // parseReadOptions translates Query read options into protobuf format.
-func parseReadOptions(eventual bool, t *Transaction) (*pb.ReadOptions, error) {
+func parseReadOptions(eventual bool, t *Transaction, readTime time.Time) (*pb.ReadOptions, error) {
 	err := validateReadOptions(eventual, t)
 	if err != nil {
 		return nil, err
 	}
 
+	if !readTime.IsZero() {
+		if t != nil {
+			return nil, errors.New("datastore: cannot use a read time in a transaction")
+		}
+		if eventual {
+			return nil, errors.New("datastore: cannot use EventualConsistency query with a read time")
+		}
+		return &pb.ReadOptions{
+			ConsistencyType: &pb.ReadOptions_ReadTime{
+				// Timestamp cannot be less than microseconds accuracy. See #6938
+				ReadTime: &timestamppb.Timestamp{Seconds: readTime.Unix()},
+			},
+		}, nil
+	}
+
 	if t != nil {
 		return &pb.ReadOptions{
 			ConsistencyType: &pb.ReadOptions_Transaction{Transaction: t.id},
@@ -1124,3 +1205,63 @@ func (aq *AggregationQuery) WithAvg(fieldName string, alias string) *Aggregation
 
 // AggregationResult contains the results of an aggregation query.
 type AggregationResult map[string]interface{}
+
+// Count returns the number of entities counted by the WithCount aggregation
+// with the given alias.
+func (ar AggregationResult) Count(alias string) (int64, error) {
+	v, err := ar.propValue(alias)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := v.(int64)
+	if !ok {
+		return 0, fmt.Errorf("datastore: aggregation %q has a result of type %T, not a count", alias, v)
+	}
+	return n, nil
+}
+
+// Sum returns the result of the WithSum aggregation with the given alias, as a
+// float64. Datastore sums the property values as integers when they are all
+// integers and the sum does not overflow; the result in the map is then an
+// exact integer value.
+func (ar AggregationResult) Sum(alias string) (float64, error) {
+	return ar.number(alias)
+}
+
+// Avg returns the result of the WithAvg aggregation with the given alias. If
+// no entity matched by the query has a numeric value for the property, the
+// average is null and Avg returns an error.
+func (ar AggregationResult) Avg(alias string) (float64, error) {
+	return ar.number(alias)
+}
+
+// number returns the numeric result of the aggregation with the given alias.
+func (ar AggregationResult) number(alias string) (float64, error) {
+	v, err := ar.propValue(alias)
+	if err != nil {
+		return 0, err
+	}
+	switch x := v.(type) {
+	case int64:
+		return float64(x), nil
+	case float64:
+		return x, nil
+	case nil:
+		return 0, fmt.Errorf("datastore: aggregation %q has a null result", alias)
+	}
+	return 0, fmt.Errorf("datastore: aggregation %q has a result of type %T, not a number", alias, v)
+}
+
+// propValue returns the result of the aggregation with the given alias as the
+// Go value of a Property.
+func (ar AggregationResult) propValue(alias string) (interface{}, error) {
+	r, ok := ar[alias]
+	if !ok {
+		return nil, fmt.Errorf("datastore: no aggregation with alias %q", alias)
+	}
+	v, ok := r.(*pb.Value)
+	if !ok {
+		return nil, fmt.Errorf("datastore: aggregation %q has a result of unexpected type %T", alias, r)
+	}
+	return propToValue(v)
+}
@@ -21,12 +21,14 @@ import (
 	"reflect"
 	"sort"
 	"testing"
+	"time"
 
 	"cloud.google.com/go/internal/testutil"
 	"github.com/golang/protobuf/proto"
 	"github.com/google/go-cmp/cmp"
 	pb "google.golang.org/genproto/googleapis/datastore/v1"
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 var (
@@ -58,6 +60,7 @@ type fakeClient struct {
 	queryFn    func(*pb.RunQueryRequest) (*pb.RunQueryResponse, error)
 	commitFn   func(*pb.CommitRequest) (*pb.CommitResponse, error)
 	aggQueryFn func(*pb.RunAggregationQueryRequest) (*pb.RunAggregationQueryResponse, error)
+	lookupFn   func(*pb.LookupRequest) (*pb.LookupResponse, error)
 }
 
 func (c *fakeClient) RunQuery(_ context.Context, req *pb.RunQueryRequest, _ ...grpc.CallOption) (*pb.RunQueryResponse, error) {
@@ -68,6 +71,10 @@ func (c *fakeClient) Commit(_ context.Context, req *pb.CommitRequest, _ ...grpc.
 	return c.commitFn(req)
 }
 
+func (c *fakeClient) Lookup(_ context.Context, req *pb.LookupRequest, _ ...grpc.CallOption) (*pb.LookupResponse, error) {
+	return c.lookupFn(req)
+}
+
 func (c *fakeClient) RunAggregationQuery(_ context.Context, req *pb.RunAggregationQueryRequest, _ ...grpc.CallOption) (*pb.RunAggregationQueryResponse, error) {
 	return c.aggQueryFn(req)
 }
@@ -530,6 +537,57 @@ func TestPropertyFilterToProto(t *testing.T) {
 	}
 }
 
+func TestPropertyFilterListValue(t *testing.T) {
+	k := NameKey("K", "a", nil)
+	for _, tc := range []struct {
+		pf   PropertyFilter
+		want []*pb.Value
+	}{
+		{
+			PropertyFilter{FieldName: "x", Operator: "in", Value: []string{"a", "b"}},
+			[]*pb.Value{
+				{ValueType: &pb.Value_StringValue{StringValue: "a"}},
+				{ValueType: &pb.Value_StringValue{StringValue: "b"}},
+			},
+		},
+		{
+			PropertyFilter{FieldName: "x", Operator: "not-in", Value: [2]int{1, 2}},
+			[]*pb.Value{
+				{ValueType: &pb.Value_IntegerValue{IntegerValue: 1}},
+				{ValueType: &pb.Value_IntegerValue{IntegerValue: 2}},
+			},
+		},
+		{
+			PropertyFilter{FieldName: keyFieldName, Operator: "in", Value: []*Key{k}},
+			[]*pb.Value{
+				{ValueType: &pb.Value_KeyValue{KeyValue: keyToProto(k)}},
+			},
+		},
+		{
+			PropertyFilter{FieldName: "x", Operator: "in", Value: []interface{}{1, "a"}},
+			[]*pb.Value{
+				{ValueType: &pb.Value_IntegerValue{IntegerValue: 1}},
+				{ValueType: &pb.Value_StringValue{StringValue: "a"}},
+			},
+		},
+	} {
+		f, err := tc.pf.toProto()
+		if err != nil {
+			t.Errorf("%+v: %v", tc.pf, err)
+			continue
+		}
+		want := &pb.Value{ValueType: &pb.Value_ArrayValue{ArrayValue: &pb.ArrayValue{Values: tc.want}}}
+		if got := f.GetPropertyFilter().GetValue(); !proto.Equal(got, want) {
+			t.Errorf("%+v:\ngot  %v\nwant %v", tc.pf, got, want)
+		}
+	}
+
+	// Slices are only converted for "in" and "not-in" filters.
+	if _, err := (PropertyFilter{FieldName: "x", Operator: "=", Value: []string{"a"}}).toProto(); err == nil {
+		t.Error("got nil, want error for a []string equality filter")
+	}
+}
+
 func TestCompositeFilterToProto(t *testing.T) {
 	testCases := []struct {
 		cf         CompositeFilter
@@ -561,6 +619,10 @@ func TestCompositeFilterToProto(t *testing.T) {
 				PropertyFilter{FieldName: "y", Operator: "<", Value: 3},
 			},
 		}, "datastore: invalid operator \"==\" in filter"},
+
+		// Fail when there are no inner filters
+		{AndFilter{}, "datastore: AndFilter must contain at least one filter"},
+		{OrFilter{}, "datastore: OrFilter must contain at least one filter"},
 	}
 	for _, tc := range testCases {
 		_, gotErr := tc.cf.toProto()
@@ -722,6 +784,44 @@ func TestNamespaceQuery(t *testing.T) {
 	}
 }
 
+func TestQueryDefaultReadTime(t *testing.T) {
+	gotReadOptions := make(chan *pb.ReadOptions, 1)
+	ctx := context.Background()
+	client := &Client{
+		client: &fakeClient{
+			queryFn: func(req *pb.RunQueryRequest) (*pb.RunQueryResponse, error) {
+				gotReadOptions <- req.ReadOptions
+				return nil, errors.New("not implemented")
+			},
+		},
+		readSettings: &readSettings{},
+	}
+	readTime := time.Unix(1700000000, 0)
+	client.WithReadOptions(ReadTime(readTime))
+	want := &pb.ReadOptions{
+		ConsistencyType: &pb.ReadOptions_ReadTime{
+			ReadTime: &timestamppb.Timestamp{Seconds: readTime.Unix()},
+		},
+	}
+
+	var gs []Gopher
+
+	// Ignore errors for the rest of this test.
+	client.GetAll(ctx, NewQuery("gopher"), &gs)
+	if got := <-gotReadOptions; !proto.Equal(got, want) {
+		t.Errorf("GetAll: got %v, want %v", got, want)
+	}
+	client.Count(ctx, NewQuery("gopher"))
+	if got := <-gotReadOptions; !proto.Equal(got, want) {
+		t.Errorf("Count: got %v, want %v", got, want)
+	}
+	client.Run(ctx, NewQuery("gopher").EventualConsistency()).Next(nil)
+	want = &pb.ReadOptions{ConsistencyType: &pb.ReadOptions_ReadConsistency_{ReadConsistency: pb.ReadOptions_EVENTUAL}}
+	if got := <-gotReadOptions; !proto.Equal(got, want) {
+		t.Errorf("Run: got %v, want %v", got, want)
+	}
+}
+
 func TestReadOptions(t *testing.T) {
 	tid := []byte{1}
 	for _, test := range []struct {
@@ -752,6 +852,14 @@ func TestReadOptions(t *testing.T) {
 				},
 			},
 		},
+		{
+			q: NewQuery("").ReadTime(time.Unix(1700000000, 0)),
+			want: &pb.ReadOptions{
+				ConsistencyType: &pb.ReadOptions_ReadTime{
+					ReadTime: &timestamppb.Timestamp{Seconds: 1700000000},
+				},
+			},
+		},
 	} {
 		req := &pb.RunQueryRequest{}
 		if err := test.q.toRunQueryRequest(req); err != nil {
@@ -765,6 +873,8 @@ func TestReadOptions(t *testing.T) {
 	for _, q := range []*Query{
 		NewQuery("").Transaction(&Transaction{id: nil, state: transactionStateExpired}),
 		NewQuery("").Transaction(&Transaction{id: tid, state: transactionStateInProgress}).EventualConsistency(),
+		NewQuery("").Transaction(&Transaction{id: tid, state: transactionStateInProgress}).ReadTime(time.Now()),
+		NewQuery("").EventualConsistency().ReadTime(time.Now()),
 	} {
 		req := &pb.RunQueryRequest{}
 		if err := q.toRunQueryRequest(req); err == nil {
@@ -829,6 +939,98 @@ func TestAggregationQuery(t *testing.T) {
 	}
 }
 
+func TestAggregationQueryReadTime(t *testing.T) {
+	readTime := time.Unix(1700000000, 0)
+	var gotReq *pb.RunAggregationQueryRequest
+	client := &Client{
+		client: &fakeClient{
+			aggQueryFn: func(req *pb.RunAggregationQueryRequest) (*pb.RunAggregationQueryResponse, error) {
+				gotReq = req
+				return &pb.RunAggregationQueryResponse{Batch: &pb.AggregationResultBatch{}}, nil
+			},
+		},
+		readSettings: &readSettings{},
+	}
+	client.WithReadOptions(ReadTime(readTime))
+
+	aq := NewQuery("Gopher").NewAggregationQuery().WithCount(countAlias)
+	if _, err := client.RunAggregationQuery(context.Background(), aq); err != nil {
+		t.Fatal(err)
+	}
+	want := &pb.ReadOptions{
+		ConsistencyType: &pb.ReadOptions_ReadTime{
+			ReadTime: &timestamppb.Timestamp{Seconds: readTime.Unix()},
+		},
+	}
+	if !proto.Equal(gotReq.ReadOptions, want) {
+		t.Errorf("got %v, want %v", gotReq.ReadOptions, want)
+	}
+
+	// A transaction takes precedence over the read time.
+	tx := &Transaction{id: []byte("tid")}
+	aq = NewQuery("Gopher").Transaction(tx).NewAggregationQuery().WithCount(countAlias)
+	if _, err := client.RunAggregationQuery(context.Background(), aq); err != nil {
+		t.Fatal(err)
+	}
+	want = &pb.ReadOptions{ConsistencyType: &pb.ReadOptions_Transaction{Transaction: tx.id}}
+	if !proto.Equal(gotReq.ReadOptions, want) {
+		t.Errorf("got %v, want %v", gotReq.ReadOptions, want)
+	}
+
+	// The client's read time does not apply to eventually consistent queries.
+	aq = NewQuery("Gopher").EventualConsistency().NewAggregationQuery().WithCount(countAlias)
+	if _, err := client.RunAggregationQuery(context.Background(), aq); err != nil {
+		t.Fatal(err)
+	}
+	want = &pb.ReadOptions{ConsistencyType: &pb.ReadOptions_ReadConsistency_{ReadConsistency: pb.ReadOptions_EVENTUAL}}
+	if !proto.Equal(gotReq.ReadOptions, want) {
+		t.Errorf("got %v, want %v", gotReq.ReadOptions, want)
+	}
+
+	// A read time set on the query takes precedence.
+	queryReadTime := readTime.Add(time.Hour)
+	aq = NewQuery("Gopher").ReadTime(queryReadTime).NewAggregationQuery().WithCount(countAlias)
+	if _, err := client.RunAggregationQuery(context.Background(), aq); err != nil {
+		t.Fatal(err)
+	}
+	want = &pb.ReadOptions{
+		ConsistencyType: &pb.ReadOptions_ReadTime{
+			ReadTime: &timestamppb.Timestamp{Seconds: queryReadTime.Unix()},
+		},
+	}
+	if !proto.Equal(gotReq.ReadOptions, want) {
+		t.Errorf("got %v, want %v", gotReq.ReadOptions, want)
+	}
+}
+
+func TestAggregationResultTypedAccessors(t *testing.T) {
+	ar := AggregationResult{
+		"count":  &pb.Value{ValueType: &pb.Value_IntegerValue{IntegerValue: 3}},
+		"sumInt": &pb.Value{ValueType: &pb.Value_IntegerValue{IntegerValue: 7}},
+		"avg":    &pb.Value{ValueType: &pb.Value_DoubleValue{DoubleValue: 2.5}},
+		"null":   &pb.Value{ValueType: &pb.Value_NullValue{}},
+		"str":    &pb.Value{ValueType: &pb.Value_StringValue{StringValue: "x"}},
+		"raw":    3,
+	}
+	if got, err := ar.Count("count"); err != nil || got != 3 {
+		t.Errorf("Count: got (%d, %v), want (3, nil)", got, err)
+	}
+	if got, err := ar.Sum("sumInt"); err != nil || got != 7 {
+		t.Errorf("Sum: got (%v, %v), want (7, nil)", got, err)
+	}
+	if got, err := ar.Avg("avg"); err != nil || got != 2.5 {
+		t.Errorf("Avg: got (%v, %v), want (2.5, nil)", got, err)
+	}
+	if _, err := ar.Count("avg"); err == nil {
+		t.Error("Count of a double: got nil, want error")
+	}
+	for _, alias := range []string{"null", "str", "raw", "missing"} {
+		if _, err := ar.Avg(alias); err == nil {
+			t.Errorf("Avg(%q): got nil, want error", alias)
+		}
+	}
+}
+
 func TestAggregationQueryIsNil(t *testing.T) {
 	client := &Client{
 		client: &fakeClient{
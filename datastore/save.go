@@ -190,6 +190,17 @@ func saveStructProperty(props *[]Property, name string, opts saveOpts, v reflect
 		return nil
 	}
 
+	// A registered codec takes precedence over any other way of saving v.
+	if c := codecFor(v.Type()); c != nil {
+		val, err := c.Encode(v.Interface())
+		if err != nil {
+			return fmt.Errorf("datastore: encoding field %q: %w", name, err)
+		}
+		p.Value = val
+		*props = append(*props, p)
+		return nil
+	}
+
 	// First check if field type implements PLS. If so, use PLS to
 	// save.
 	ok, err := plsFieldSave(props, p, name, opts, v)
@@ -0,0 +1,181 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errorreporting
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// A MiddlewareOption configures HTTPMiddleware, UnaryServerInterceptor and
+// StreamServerInterceptor.
+type MiddlewareOption func(*middlewareConfig)
+
+type middlewareConfig struct {
+	repanic bool
+	user    func(*http.Request) string
+}
+
+// Repanic makes the middleware panic again with the recovered value after
+// reporting it, rather than responding with an error. Use it when another
+// handler further up the chain recovers panics, or to crash the program.
+func Repanic() MiddlewareOption {
+	return func(c *middlewareConfig) { c.repanic = true }
+}
+
+// ReportUser sets a function that returns the identifier of the user affected
+// by a panic from the request being handled, to be included in the report.
+// It is only used by HTTPMiddleware.
+func ReportUser(f func(*http.Request) string) MiddlewareOption {
+	return func(c *middlewareConfig) { c.user = f }
+}
+
+func newMiddlewareConfig(opts []MiddlewareOption) *middlewareConfig {
+	cfg := &middlewareConfig{}
+	for _, o := range opts {
+		o(cfg)
+	}
+	return cfg
+}
+
+// HTTPMiddleware returns a middleware that recovers panics in the handlers it
+// wraps and reports them to c with the stack trace of the panic and the
+// context of the request: its method, URL, user agent, referrer and remote
+// IP. It then responds with status 500 Internal Server Error, or panics again
+// if the Repanic option is given.
+//
+// Panics with http.ErrAbortHandler, which abort a response on purpose, are not
+// reported.
+func HTTPMiddleware(c *Client, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	cfg := newMiddlewareConfig(opts)
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				x := recover()
+				if x == nil {
+					return
+				}
+				if x == http.ErrAbortHandler {
+					panic(x)
+				}
+				e := Entry{
+					Error: panicError(x),
+					Req:   r,
+					Stack: []byte(chopPanicStack(debug.Stack())),
+				}
+				if cfg.user != nil {
+					e.User = cfg.user(r)
+				}
+				c.reportPanic(e, cfg, x)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}()
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+// UnaryServerInterceptor returns a gRPC server interceptor that recovers
+// panics in unary RPC handlers and reports them to c with the stack trace of
+// the panic and the name of the method. It then returns an error with code
+// Internal, or panics again if the Repanic option is given.
+func UnaryServerInterceptor(c *Client, opts ...MiddlewareOption) grpc.UnaryServerInterceptor {
+	cfg := newMiddlewareConfig(opts)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if x := recover(); x != nil {
+				err = c.recoverRPC(info.FullMethod, cfg, x)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a gRPC server interceptor that recovers
+// panics in streaming RPC handlers and reports them to c with the stack trace
+// of the panic and the name of the method. It then returns an error with code
+// Internal, or panics again if the Repanic option is given.
+func StreamServerInterceptor(c *Client, opts ...MiddlewareOption) grpc.StreamServerInterceptor {
+	cfg := newMiddlewareConfig(opts)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if x := recover(); x != nil {
+				err = c.recoverRPC(info.FullMethod, cfg, x)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// recoverRPC reports the panic x in the handler of method, and returns the
+// error of the RPC.
+func (c *Client) recoverRPC(method string, cfg *middlewareConfig, x interface{}) error {
+	err := panicError(x)
+	c.reportPanic(Entry{
+		Error: fmt.Errorf("%s: %w", method, err),
+		Stack: []byte(chopPanicStack(debug.Stack())),
+	}, cfg, x)
+	return status.Error(codes.Internal, err.Error())
+}
+
+// reportPanic reports e, the report of the panic x, and panics again with x if
+// cfg says so. The report is flushed first, since the program is likely to
+// crash.
+func (c *Client) reportPanic(e Entry, cfg *middlewareConfig, x interface{}) {
+	c.Report(e)
+	if cfg.repanic {
+		c.Flush()
+		panic(x)
+	}
+}
+
+// panicError returns the error describing the panic x.
+func panicError(x interface{}) error {
+	if err, ok := x.(error); ok {
+		return fmt.Errorf("panic: %w", err)
+	}
+	return errors.New("panic: " + fmt.Sprint(x))
+}
+
+// chopPanicStack trims a stack trace taken while recovering a panic so that
+// the function which panics is first.
+func chopPanicStack(s []byte) string {
+	lfFirst := bytes.IndexByte(s, '\n')
+	if lfFirst == -1 {
+		return string(s)
+	}
+	stack := s[lfFirst:]
+	panicLine := bytes.Index(stack, []byte("\npanic("))
+	if panicLine == -1 {
+		return string(s)
+	}
+	stack = stack[panicLine+1:]
+	// Skip the call to panic and its location.
+	for i := 0; i < 2; i++ {
+		nextLine := bytes.IndexByte(stack, '\n')
+		if nextLine == -1 {
+			return string(s)
+		}
+		stack = stack[nextLine+1:]
+	}
+	return string(s[:lfFirst+1]) + string(stack)
+}
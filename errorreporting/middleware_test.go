@@ -0,0 +1,161 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errorreporting
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func panickingHandler(w http.ResponseWriter, r *http.Request) {
+	panic("boom")
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	fc := newFakeReportErrorsClient()
+	c := newTestClient(fc, defaultConfig)
+	h := HTTPMiddleware(c, ReportUser(func(*http.Request) string { return "user" }))(http.HandlerFunc(panickingHandler))
+
+	req := httptest.NewRequest("POST", "/path?q=1", nil)
+	req.Header.Set("User-Agent", "test-agent")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if got, want := rec.Code, http.StatusInternalServerError; got != want {
+		t.Errorf("got status %d, want %d", got, want)
+	}
+	c.Flush()
+	<-fc.doneCh
+	r := fc.req
+	if r == nil {
+		t.Fatal("got no error report, expected one")
+	}
+	if !strings.HasPrefix(r.Event.Message, "panic: boom\n") {
+		t.Errorf("got message %q, want panic message first", r.Event.Message)
+	}
+	if !strings.Contains(r.Event.Message, "errorreporting.panickingHandler") {
+		t.Errorf("error report didn't contain the panicking function:\n%s", r.Event.Message)
+	}
+	hr := r.Event.Context.HttpRequest
+	if hr.Method != "POST" || hr.Url != "example.com/path?q=1" || hr.UserAgent != "test-agent" || hr.RemoteIp != req.RemoteAddr {
+		t.Errorf("got request context %+v", hr)
+	}
+	if got, want := r.Event.Context.User, "user"; got != want {
+		t.Errorf("got user %q, want %q", got, want)
+	}
+}
+
+func TestHTTPMiddlewareRepanic(t *testing.T) {
+	fc := newFakeReportErrorsClient()
+	c := newTestClient(fc, defaultConfig)
+	h := HTTPMiddleware(c, Repanic())(http.HandlerFunc(panickingHandler))
+	func() {
+		defer func() {
+			if x := recover(); x != "boom" {
+				t.Errorf("got panic %v, want boom", x)
+			}
+		}()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}()
+	// The report is flushed before panicking again.
+	<-fc.doneCh
+	if fc.req == nil {
+		t.Fatal("got no error report, expected one")
+	}
+}
+
+func TestHTTPMiddlewareAbortHandler(t *testing.T) {
+	fc := newFakeReportErrorsClient()
+	c := newTestClient(fc, defaultConfig)
+	h := HTTPMiddleware(c)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	func() {
+		defer func() {
+			if x := recover(); x != http.ErrAbortHandler {
+				t.Errorf("got panic %v, want http.ErrAbortHandler", x)
+			}
+		}()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}()
+	c.Flush()
+	if fc.req != nil {
+		t.Errorf("got error report %v, want none", fc.req)
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	fc := newFakeReportErrorsClient()
+	c := newTestClient(fc, defaultConfig)
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}
+	_, err := UnaryServerInterceptor(c)(context.Background(), nil, info, func(context.Context, interface{}) (interface{}, error) {
+		panic("boom")
+	})
+	if got, want := status.Code(err), codes.Internal; got != want {
+		t.Errorf("got code %v, want %v", got, want)
+	}
+	c.Flush()
+	<-fc.doneCh
+	if got, want := fc.req.Event.Message, "/test.Service/Method: panic: boom\n"; !strings.HasPrefix(got, want) {
+		t.Errorf("got message %q, want prefix %q", got, want)
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	fc := newFakeReportErrorsClient()
+	c := newTestClient(fc, defaultConfig)
+	info := &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"}
+	err := StreamServerInterceptor(c)(nil, nil, info, func(interface{}, grpc.ServerStream) error {
+		panic("boom")
+	})
+	if got, want := status.Code(err), codes.Internal; got != want {
+		t.Errorf("got code %v, want %v", got, want)
+	}
+	c.Flush()
+	<-fc.doneCh
+	if got, want := fc.req.Event.Message, "/test.Service/Stream: panic: boom\n"; !strings.HasPrefix(got, want) {
+		t.Errorf("got message %q, want prefix %q", got, want)
+	}
+}
+
+func TestChopPanicStack(t *testing.T) {
+	in := []byte(`goroutine 7 [running]:
+runtime/debug.Stack()
+	/goroot/src/runtime/debug/stack.go:24 +0x5e
+cloud.google.com/go/errorreporting.HTTPMiddleware.func1.1.1()
+	/gopath/cloud.google.com/go/errorreporting/middleware.go:80 +0x8d
+panic({0x6b1e60?, 0x7a4b10?})
+	/goroot/src/runtime/panic.go:914 +0x21f
+main.handler({0x7a9a38?, 0xc0000b4000?}, 0x0?)
+	/src/main.go:12 +0x25
+net/http.HandlerFunc.ServeHTTP(0x0?, {0x7a9a38?, 0xc0000b4000?}, 0x0?)
+	/goroot/src/net/http/server.go:2136 +0x29
+`)
+	want := `goroutine 7 [running]:
+main.handler({0x7a9a38?, 0xc0000b4000?}, 0x0?)
+	/src/main.go:12 +0x25
+net/http.HandlerFunc.ServeHTTP(0x0?, {0x7a9a38?, 0xc0000b4000?}, 0x0?)
+	/goroot/src/net/http/server.go:2136 +0x29
+`
+	if got := chopPanicStack(in); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
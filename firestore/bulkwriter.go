@@ -38,8 +38,80 @@ const (
 	defaultStartingMaximumOpsPerSecond = 500
 	// maxWritesPerSecond is the starting limit of writes allowed to callers per second
 	maxWritesPerSecond = maxBatchSize * defaultStartingMaximumOpsPerSecond
+	// rampUpInterval is how often the ops per second are increased, if ramping up is enabled
+	rampUpInterval = 5 * time.Minute
+	// rampUpFactor is the factor by which the ops per second are increased every rampUpInterval
+	rampUpFactor = 1.5
 )
 
+// A BulkWriterOption is an option passed to Client.BulkWriter.
+type BulkWriterOption interface {
+	config(bw *BulkWriter)
+}
+
+// BulkWriterInitialOpsPerSecond is a BulkWriterOption that configures the
+// number of requests the BulkWriter sends per second when it starts. Each
+// request contains up to 20 writes. It defaults to 500.
+func BulkWriterInitialOpsPerSecond(n int) BulkWriterOption { return initialOpsPerSecond(n) }
+
+type initialOpsPerSecond int
+
+func (n initialOpsPerSecond) config(bw *BulkWriter) { bw.maxOpsPerSecond = int(n) }
+
+// BulkWriterMaxOpsPerSecond is a BulkWriterOption that lets the BulkWriter
+// ramp up the number of requests it sends per second, by 50% every 5 minutes,
+// until n is reached. By default the BulkWriter does not ramp up.
+func BulkWriterMaxOpsPerSecond(n int) BulkWriterOption { return maxOpsPerSecond(n) }
+
+type maxOpsPerSecond int
+
+func (n maxOpsPerSecond) config(bw *BulkWriter) { bw.rampUpLimit = int(n) }
+
+// BulkWriterMaxConcurrency is a BulkWriterOption that configures the maximum
+// number of requests the BulkWriter has in flight at the same time. It
+// defaults to the initial number of requests per second.
+func BulkWriterMaxConcurrency(n int) BulkWriterOption { return maxConcurrency(n) }
+
+type maxConcurrency int
+
+func (n maxConcurrency) config(bw *BulkWriter) { bw.maxConcurrency = int(n) }
+
+// BulkWriterOnSuccess is a BulkWriterOption that registers f to be called
+// for every write that succeeds. f may be called from multiple goroutines at
+// the same time.
+func BulkWriterOnSuccess(f func(doc *DocumentRef, wr *WriteResult)) BulkWriterOption {
+	return onSuccess(f)
+}
+
+type onSuccess func(doc *DocumentRef, wr *WriteResult)
+
+func (f onSuccess) config(bw *BulkWriter) { bw.onSuccess = f }
+
+// BulkWriterOnError is a BulkWriterOption that registers f to be called for
+// every failed attempt of a write, along with the number of attempts made so
+// far. If f returns false, the write is not retried and err becomes its
+// result. Writes are retried at most 10 times, and writes that failed because
+// their whole request failed are never retried, regardless of the value
+// returned by f. f may be called from multiple goroutines at the same time.
+func BulkWriterOnError(f func(doc *DocumentRef, err error, attempts int) bool) BulkWriterOption {
+	return onError(f)
+}
+
+type onError func(doc *DocumentRef, err error, attempts int) bool
+
+func (f onError) config(bw *BulkWriter) { bw.onError = f }
+
+// BulkWriterResults contains the aggregate results of the writes completed
+// by a BulkWriter between two calls to FlushResults.
+type BulkWriterResults struct {
+	// Succeeded is the number of writes that succeeded.
+	Succeeded int
+	// Failed is the number of writes that failed after all their attempts.
+	Failed int
+	// Retried is the number of write attempts that failed and were retried.
+	Retried int
+}
+
 // bulkWriterResult contains the WriteResult or error results from an individual
 // write to the database.
 type bulkWriterResult struct {
@@ -50,6 +122,7 @@ type bulkWriterResult struct {
 // BulkWriterJob provides read-only access to the results of a BulkWriter write attempt.
 type BulkWriterJob struct {
 	resultChan  chan bulkWriterResult // send errors and results to this channel
+	doc         *DocumentRef          // the document written to
 	write       *pb.Write             // the writes to apply to the database
 	attempts    int                   // number of times this write has been attempted
 	resultsLock sync.Mutex            // guards the cached wr and e values for the job
@@ -109,15 +182,24 @@ type BulkWriter struct {
 	vc              *vkit.Client     // internal client
 	maxOpsPerSecond int              // number of requests that can be sent per second
 	docUpdatePaths  map[string]bool  // document paths with corresponding writes in the queue
-	limiter         rate.Limiter     // limit requests to server to <= 500 qps
+	limiter         *rate.Limiter    // limit requests to server to <= 500 qps
 	bundler         *bundler.Bundler // handle bundling up writes to Firestore
 	ctx             context.Context  // context for canceling all BulkWriter operations
 	isOpenLock      sync.RWMutex     // guards against setting isOpen concurrently
 	isOpen          bool             // flag that the BulkWriter is closed
+	rampUpLimit     int              // maximum for maxOpsPerSecond when ramping up; no ramp up if <= maxOpsPerSecond
+	maxConcurrency  int              // max number of requests in flight
+
+	onSuccess func(doc *DocumentRef, wr *WriteResult)
+	onError   func(doc *DocumentRef, err error, attempts int) bool
+
+	mu         sync.Mutex        // guards the fields below
+	lastRampUp time.Time         // when maxOpsPerSecond was last increased
+	results    BulkWriterResults // results since the last FlushResults
 }
 
 // newBulkWriter creates a new instance of the BulkWriter.
-func newBulkWriter(ctx context.Context, c *Client, database string) *BulkWriter {
+func newBulkWriter(ctx context.Context, c *Client, database string, opts ...BulkWriterOption) *BulkWriter {
 	// Although typically we shouldn't store Context objects, in this case we
 	// need to pass this Context through to the Bundler handler.
 	ctx = withResourceHeader(ctx, c.path())
@@ -130,12 +212,22 @@ func newBulkWriter(ctx context.Context, c *Client, database string) *BulkWriter
 		maxOpsPerSecond: defaultStartingMaximumOpsPerSecond,
 		docUpdatePaths:  make(map[string]bool),
 		ctx:             ctx,
-		limiter:         *rate.NewLimiter(rate.Limit(maxWritesPerSecond), 1),
 	}
+	for _, opt := range opts {
+		opt.config(bw)
+	}
+	if bw.maxOpsPerSecond <= 0 {
+		bw.maxOpsPerSecond = defaultStartingMaximumOpsPerSecond
+	}
+	if bw.maxConcurrency <= 0 {
+		bw.maxConcurrency = bw.maxOpsPerSecond
+	}
+	bw.lastRampUp = bw.start
+	bw.limiter = rate.NewLimiter(rate.Limit(maxBatchSize*bw.maxOpsPerSecond), 1)
 
 	// can't initialize within struct above; need instance reference to BulkWriter.send()
 	bw.bundler = bundler.NewBundler(&BulkWriterJob{}, bw.send)
-	bw.bundler.HandlerLimit = bw.maxOpsPerSecond
+	bw.bundler.HandlerLimit = bw.maxConcurrency
 	bw.bundler.BundleCountThreshold = maxBatchSize
 
 	return bw
@@ -158,6 +250,17 @@ func (bw *BulkWriter) Flush() {
 	bw.bundler.Flush()
 }
 
+// FlushResults is like Flush, but also returns the aggregate results of the
+// writes completed since the previous call to FlushResults.
+func (bw *BulkWriter) FlushResults() BulkWriterResults {
+	bw.bundler.Flush()
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	res := bw.results
+	bw.results = BulkWriterResults{}
+	return res
+}
+
 // Create adds a document creation write to the queue of writes to send.
 // Note: You cannot write to (Create, Update, Set, or Delete) the same document more than once.
 func (bw *BulkWriter) Create(doc *DocumentRef, datum interface{}) (*BulkWriterJob, error) {
@@ -177,7 +280,7 @@ func (bw *BulkWriter) Create(doc *DocumentRef, datum interface{}) (*BulkWriterJo
 		return nil, fmt.Errorf("firestore: too many document writes sent to bulkwriter")
 	}
 
-	j := bw.write(w[0])
+	j := bw.write(doc, w[0])
 	return j, nil
 }
 
@@ -200,7 +303,7 @@ func (bw *BulkWriter) Delete(doc *DocumentRef, preconds ...Precondition) (*BulkW
 		return nil, fmt.Errorf("firestore: too many document writes sent to bulkwriter")
 	}
 
-	j := bw.write(w[0])
+	j := bw.write(doc, w[0])
 	return j, nil
 }
 
@@ -223,7 +326,7 @@ func (bw *BulkWriter) Set(doc *DocumentRef, datum interface{}, opts ...SetOption
 		return nil, fmt.Errorf("firestore: too many writes sent to bulkwriter")
 	}
 
-	j := bw.write(w[0])
+	j := bw.write(doc, w[0])
 	return j, nil
 }
 
@@ -246,7 +349,7 @@ func (bw *BulkWriter) Update(doc *DocumentRef, updates []Update, preconds ...Pre
 		return nil, fmt.Errorf("firestore: too many writes sent to bulkwriter")
 	}
 
-	j := bw.write(w[0])
+	j := bw.write(doc, w[0])
 	return j, nil
 }
 
@@ -273,14 +376,16 @@ func (bw *BulkWriter) checkWriteConditions(doc *DocumentRef) error {
 }
 
 // write packages up write requests into bulkWriterJob objects.
-func (bw *BulkWriter) write(w *pb.Write) *BulkWriterJob {
+func (bw *BulkWriter) write(doc *DocumentRef, w *pb.Write) *BulkWriterJob {
 
 	j := &BulkWriterJob{
 		resultChan: make(chan bulkWriterResult, 1),
+		doc:        doc,
 		write:      w,
 		ctx:        bw.ctx,
 	}
 
+	bw.rampUp()
 	bw.limiter.Wait(bw.ctx)
 	// ignore operation size constraints and related errors; can't be inferred at compile time
 	// Bundler is set to accept an unlimited amount of bytes
@@ -289,6 +394,57 @@ func (bw *BulkWriter) write(w *pb.Write) *BulkWriterJob {
 	return j
 }
 
+// rampUp increases the number of requests sent per second by 50% if
+// rampUpInterval has passed since the last increase, up to rampUpLimit.
+func (bw *BulkWriter) rampUp() {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	if bw.maxOpsPerSecond >= bw.rampUpLimit || time.Since(bw.lastRampUp) < rampUpInterval {
+		return
+	}
+	bw.lastRampUp = time.Now()
+	bw.maxOpsPerSecond = int(float64(bw.maxOpsPerSecond) * rampUpFactor)
+	if bw.maxOpsPerSecond > bw.rampUpLimit {
+		bw.maxOpsPerSecond = bw.rampUpLimit
+	}
+	bw.limiter.SetLimit(rate.Limit(maxBatchSize * bw.maxOpsPerSecond))
+}
+
+// succeed sends the result of a successful write to its job.
+func (bw *BulkWriter) succeed(j *BulkWriterJob, res *pb.WriteResult) {
+	bw.mu.Lock()
+	bw.results.Succeeded++
+	bw.mu.Unlock()
+	if bw.onSuccess != nil {
+		if wr, err := writeResultFromProto(res); err == nil {
+			bw.onSuccess(j.doc, wr)
+		}
+	}
+	j.resultChan <- bulkWriterResult{err: nil, result: res}
+	close(j.resultChan)
+}
+
+// fail reports a failed attempt of a write. It returns whether the write
+// should be retried; if not, err is sent to the job.
+func (bw *BulkWriter) fail(j *BulkWriterJob, err error, retryable bool) bool {
+	j.attempts++
+	retry := retryable && j.attempts < maxRetryAttempts
+	if bw.onError != nil {
+		retry = bw.onError(j.doc, err, j.attempts) && retry
+	}
+	bw.mu.Lock()
+	if retry {
+		bw.results.Retried++
+	} else {
+		bw.results.Failed++
+	}
+	bw.mu.Unlock()
+	if !retry {
+		j.setError(err)
+	}
+	return retry
+}
+
 // send transmits writes to the service and matches response results to job channels.
 func (bw *BulkWriter) send(i interface{}) {
 	bwj := i.([]*BulkWriterJob)
@@ -316,7 +472,7 @@ func (bw *BulkWriter) send(i interface{}) {
 		if err != nil {
 			// Do we need to be selective about what kind of errors we send?
 			for _, j := range bwj {
-				j.setError(err)
+				bw.fail(j, err, false)
 			}
 			return
 		}
@@ -326,21 +482,17 @@ func (bw *BulkWriter) send(i interface{}) {
 			c := s.GetCode()
 			if c != 0 { // Should we do an explicit check against rpc.Code enum?
 				j := bwj[i]
-				j.attempts++
 
 				// Do we need separate retry bundler?
-				if j.attempts < maxRetryAttempts {
+				if bw.fail(j, status.Error(codes.Code(s.Code), s.Message), true) {
 					// ignore operation size constraints and related errors; job size can't be inferred at compile time
 					// Bundler is set to accept an unlimited amount of bytes
 					_ = bw.bundler.Add(j, 0)
-				} else {
-					j.setError(status.Error(codes.Code(s.Code), s.Message))
 				}
 				continue
 			}
 
-			bwj[i].resultChan <- bulkWriterResult{err: nil, result: res}
-			close(bwj[i].resultChan)
+			bw.succeed(bwj[i], res)
 		}
 	}
 }
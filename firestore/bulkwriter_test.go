@@ -17,6 +17,7 @@ package firestore
 import (
 	"context"
 	"testing"
+	"time"
 
 	pb "cloud.google.com/go/firestore/apiv1/firestorepb"
 	"google.golang.org/genproto/googleapis/rpc/status"
@@ -251,3 +252,90 @@ func TestBulkWriterErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestBulkWriterOptions(t *testing.T) {
+	c, srv, cleanup := newMock(t)
+	defer cleanup()
+
+	docPrefix := c.Collection("C").Path + "/"
+	srv.addRPC(
+		&pb.BatchWriteRequest{
+			Database: c.path(),
+			Writes: []*pb.Write{
+				{Operation: &pb.Write_Delete{Delete: docPrefix + "a"}},
+			},
+		},
+		&pb.BatchWriteResponse{
+			WriteResults: []*pb.WriteResult{{UpdateTime: aTimestamp}},
+			Status:       []*status.Status{{Code: int32(codes.OK)}},
+		},
+	)
+	srv.addRPC(
+		&pb.BatchWriteRequest{
+			Database: c.path(),
+			Writes: []*pb.Write{
+				{Operation: &pb.Write_Delete{Delete: docPrefix + "b"}},
+			},
+		},
+		&pb.BatchWriteResponse{
+			WriteResults: []*pb.WriteResult{{}},
+			Status:       []*status.Status{{Code: int32(codes.FailedPrecondition), Message: "failed"}},
+		},
+	)
+
+	var succeeded, failed []string
+	bw := c.BulkWriter(context.Background(),
+		BulkWriterInitialOpsPerSecond(10),
+		BulkWriterMaxOpsPerSecond(100),
+		BulkWriterMaxConcurrency(2),
+		BulkWriterOnSuccess(func(doc *DocumentRef, wr *WriteResult) {
+			succeeded = append(succeeded, doc.ID)
+		}),
+		BulkWriterOnError(func(doc *DocumentRef, err error, attempts int) bool {
+			failed = append(failed, doc.ID)
+			return false
+		}),
+	)
+	if got, want := bw.maxOpsPerSecond, 10; got != want {
+		t.Errorf("maxOpsPerSecond: got %d, want %d", got, want)
+	}
+	if got, want := bw.bundler.HandlerLimit, 2; got != want {
+		t.Errorf("HandlerLimit: got %d, want %d", got, want)
+	}
+
+	if _, err := bw.Delete(c.Doc("C/a")); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := bw.FlushResults(), (BulkWriterResults{Succeeded: 1}); got != want {
+		t.Errorf("FlushResults: got %+v, want %+v", got, want)
+	}
+	j, err := bw.Delete(c.Doc("C/b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := bw.FlushResults(), (BulkWriterResults{Failed: 1}); got != want {
+		t.Errorf("FlushResults: got %+v, want %+v", got, want)
+	}
+	if _, err := j.Results(); err == nil {
+		t.Error("got nil, want error for failed write")
+	}
+	if !testEqual(succeeded, []string{"a"}) || !testEqual(failed, []string{"b"}) {
+		t.Errorf("callbacks: got succeeded %v, failed %v; want [a], [b]", succeeded, failed)
+	}
+}
+
+func TestBulkWriterRampUp(t *testing.T) {
+	c, _, cleanup := newMock(t)
+	defer cleanup()
+
+	bw := c.BulkWriter(context.Background(), BulkWriterMaxOpsPerSecond(600))
+	bw.rampUp()
+	if got, want := bw.maxOpsPerSecond, defaultStartingMaximumOpsPerSecond; got != want {
+		t.Errorf("before ramp up interval: got %d, want %d", got, want)
+	}
+	bw.lastRampUp = time.Now().Add(-rampUpInterval)
+	bw.rampUp()
+	if got, want := bw.maxOpsPerSecond, 600; got != want {
+		t.Errorf("after ramp up interval: got %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firestore
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	pb "cloud.google.com/go/firestore/apiv1/firestorepb"
+	"cloud.google.com/go/internal/trace"
+)
+
+const (
+	// maxWritesPerCommit is the maximum number of writes the service accepts
+	// in a single commit.
+	maxWritesPerCommit = 500
+	// defaultChunkedBatchConcurrency is the default number of commits a
+	// ChunkedBatch has in flight at the same time.
+	defaultChunkedBatchConcurrency = 10
+)
+
+// A ChunkedBatch holds any number of database updates. Build it with the
+// Create, Set, Update and Delete methods, then run it with the Commit method.
+//
+// Unlike a WriteBatch, a ChunkedBatch is not limited to 500 writes: Commit
+// splits the writes into chunks that fit in a single commit and commits them
+// concurrently. Each chunk is applied atomically, but the batch as a whole is
+// not, and chunks may be applied in any order.
+type ChunkedBatch struct {
+	c   *Client
+	ops []chunkedOp
+}
+
+type chunkedOp struct {
+	writes []*pb.Write
+	err    error
+}
+
+// ChunkedBatch returns a ChunkedBatch.
+func (c *Client) ChunkedBatch() *ChunkedBatch {
+	return &ChunkedBatch{c: c}
+}
+
+func (b *ChunkedBatch) add(ws []*pb.Write, err error) *ChunkedBatch {
+	b.ops = append(b.ops, chunkedOp{writes: ws, err: err})
+	return b
+}
+
+// Create adds a Create operation to the batch.
+// See DocumentRef.Create for details.
+func (b *ChunkedBatch) Create(dr *DocumentRef, data interface{}) *ChunkedBatch {
+	return b.add(dr.newCreateWrites(data))
+}
+
+// Set adds a Set operation to the batch.
+// See DocumentRef.Set for details.
+func (b *ChunkedBatch) Set(dr *DocumentRef, data interface{}, opts ...SetOption) *ChunkedBatch {
+	return b.add(dr.newSetWrites(data, opts))
+}
+
+// Delete adds a Delete operation to the batch.
+// See DocumentRef.Delete for details.
+func (b *ChunkedBatch) Delete(dr *DocumentRef, opts ...Precondition) *ChunkedBatch {
+	return b.add(dr.newDeleteWrites(opts))
+}
+
+// Update adds an Update operation to the batch.
+// See DocumentRef.Update for details.
+func (b *ChunkedBatch) Update(dr *DocumentRef, data []Update, opts ...Precondition) *ChunkedBatch {
+	return b.add(dr.newUpdatePathWrites(data, opts))
+}
+
+// A ChunkedBatchOption is an option passed to ChunkedBatch.Commit.
+type ChunkedBatchOption interface {
+	config(s *chunkedBatchSettings)
+}
+
+type chunkedBatchSettings struct {
+	maxConcurrency int
+	chunkSize      int
+}
+
+// ChunkedBatchMaxConcurrency is a ChunkedBatchOption that configures the
+// maximum number of commits in flight at the same time. It defaults to 10.
+func ChunkedBatchMaxConcurrency(n int) ChunkedBatchOption { return chunkedBatchMaxConcurrency(n) }
+
+type chunkedBatchMaxConcurrency int
+
+func (n chunkedBatchMaxConcurrency) config(s *chunkedBatchSettings) { s.maxConcurrency = int(n) }
+
+// ChunkedBatchChunkSize is a ChunkedBatchOption that configures the maximum
+// number of writes committed together. It defaults to, and cannot exceed,
+// 500.
+func ChunkedBatchChunkSize(n int) ChunkedBatchOption { return chunkedBatchChunkSize(n) }
+
+type chunkedBatchChunkSize int
+
+func (n chunkedBatchChunkSize) config(s *chunkedBatchSettings) { s.chunkSize = int(n) }
+
+// A ChunkedBatchResult is the result of a single operation of a ChunkedBatch.
+type ChunkedBatchResult struct {
+	// Result is the result of the operation if it was applied.
+	Result *WriteResult
+	// Err is the error that prevented the operation from being applied, either
+	// because the operation was invalid or because its chunk failed to commit.
+	Err error
+}
+
+// Commit applies all the writes in the batch to the database, in chunks of at
+// most 500 writes. It returns one result for each operation, in the order in
+// which the operations were added to the batch. Operations that could not be
+// constructed are reported in their results and are not sent to the database.
+//
+// Commit returns an error if there are no operations in the batch or if the
+// options are invalid. The error of a failed chunk is reported in the results
+// of its operations only.
+func (b *ChunkedBatch) Commit(ctx context.Context, opts ...ChunkedBatchOption) (_ []ChunkedBatchResult, err error) {
+	ctx = trace.StartSpan(ctx, "cloud.google.com/go/firestore.ChunkedBatch.Commit")
+	defer func() { trace.EndSpan(ctx, err) }()
+
+	if len(b.ops) == 0 {
+		return nil, errors.New("firestore: cannot commit empty ChunkedBatch")
+	}
+	s := &chunkedBatchSettings{
+		maxConcurrency: defaultChunkedBatchConcurrency,
+		chunkSize:      maxWritesPerCommit,
+	}
+	for _, o := range opts {
+		o.config(s)
+	}
+	if s.maxConcurrency < 1 {
+		return nil, errors.New("firestore: ChunkedBatch concurrency must be positive")
+	}
+	if s.chunkSize < 1 || s.chunkSize > maxWritesPerCommit {
+		return nil, errors.New("firestore: ChunkedBatch chunk size must be between 1 and 500")
+	}
+
+	results := make([]ChunkedBatchResult, len(b.ops))
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, s.maxConcurrency)
+	)
+	for _, chunk := range b.chunks(s.chunkSize, results) {
+		chunk := chunk
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			b.commitChunk(ctx, chunk, results)
+		}()
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// chunks groups the indexes of the valid operations of b so that the writes
+// of each group fit in a single commit of at most size writes. The results of
+// invalid operations are recorded in results.
+func (b *ChunkedBatch) chunks(size int, results []ChunkedBatchResult) [][]int {
+	var (
+		chunks [][]int
+		cur    []int
+		n      int
+	)
+	for i, op := range b.ops {
+		err := op.err
+		if err == nil && len(op.writes) > size {
+			err = errors.New("firestore: operation has more writes than the ChunkedBatch chunk size")
+		}
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		if n+len(op.writes) > size {
+			chunks = append(chunks, cur)
+			cur, n = nil, 0
+		}
+		cur = append(cur, i)
+		n += len(op.writes)
+	}
+	if len(cur) > 0 {
+		chunks = append(chunks, cur)
+	}
+	return chunks
+}
+
+// commitChunk commits the operations of b at the indexes in chunk, and
+// records their results.
+func (b *ChunkedBatch) commitChunk(ctx context.Context, chunk []int, results []ChunkedBatchResult) {
+	var ws []*pb.Write
+	for _, i := range chunk {
+		ws = append(ws, b.ops[i].writes...)
+	}
+	wrs, err := b.c.commit(ctx, ws)
+	// The service returns one WriteResult per write; report the result of
+	// the first write of each operation.
+	pos := 0
+	for _, i := range chunk {
+		switch {
+		case err != nil:
+			results[i].Err = err
+		case pos < len(wrs):
+			results[i].Result = wrs[pos]
+		default:
+			results[i].Err = errors.New("firestore: missing WriteResult")
+		}
+		pos += len(b.ops[i].writes)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firestore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	pb "cloud.google.com/go/firestore/apiv1/firestorepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestChunkedBatch(t *testing.T) {
+	ctx := context.Background()
+	c, srv, cleanup := newMock(t)
+	defer cleanup()
+
+	const n = 5
+	b := c.ChunkedBatch()
+	var ws []*pb.Write
+	var wrs []*pb.WriteResult
+	for i := 0; i < n; i++ {
+		dr := c.Doc(fmt.Sprintf("C/d%d", i))
+		b.Delete(dr)
+		ws = append(ws, &pb.Write{Operation: &pb.Write_Delete{Delete: dr.Path}})
+		wrs = append(wrs, &pb.WriteResult{UpdateTime: aTimestamp})
+	}
+	// An invalid operation is reported but not sent.
+	b.Set(c.Doc("C/bad"), 1)
+
+	srv.addRPC(&pb.CommitRequest{Database: c.path(), Writes: ws[:2]},
+		&pb.CommitResponse{WriteResults: wrs[:2]})
+	srv.addRPC(&pb.CommitRequest{Database: c.path(), Writes: ws[2:4]},
+		status.Error(codes.Internal, "boom"))
+	srv.addRPC(&pb.CommitRequest{Database: c.path(), Writes: ws[4:]},
+		&pb.CommitResponse{WriteResults: wrs[4:]})
+
+	got, err := b.Commit(ctx, ChunkedBatchChunkSize(2), ChunkedBatchMaxConcurrency(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != n+1 {
+		t.Fatalf("got %d results, want %d", len(got), n+1)
+	}
+	for i, r := range got {
+		switch i {
+		case 2, 3:
+			if status.Code(r.Err) != codes.Internal {
+				t.Errorf("#%d: got %v, want Internal error", i, r.Err)
+			}
+		case n:
+			if r.Err == nil {
+				t.Errorf("#%d: got nil, want error", i)
+			}
+		default:
+			if r.Err != nil {
+				t.Errorf("#%d: %v", i, r.Err)
+			} else if !testEqual(r.Result, writeResultForSet) {
+				t.Errorf("#%d: got %v, want %v", i, r.Result, writeResultForSet)
+			}
+		}
+	}
+}
+
+func TestChunkedBatchErrors(t *testing.T) {
+	ctx := context.Background()
+	c, _, cleanup := newMock(t)
+	defer cleanup()
+
+	if _, err := c.ChunkedBatch().Commit(ctx); err == nil {
+		t.Error("got nil, want error for empty batch")
+	}
+	b := c.ChunkedBatch().Delete(c.Doc("C/a"))
+	for _, opt := range []ChunkedBatchOption{
+		ChunkedBatchChunkSize(0),
+		ChunkedBatchChunkSize(maxWritesPerCommit + 1),
+		ChunkedBatchMaxConcurrency(0),
+	} {
+		if _, err := b.Commit(ctx, opt); err == nil {
+			t.Errorf("%v: got nil, want error", opt)
+		}
+	}
+}
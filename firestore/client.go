@@ -154,10 +154,25 @@ func (c *Client) Close() error {
 	return c.c.Close()
 }
 
+// DatabaseID returns the ID of the database the client accesses, which is
+// DefaultDatabaseID unless the client was created with NewClientWithDatabase.
+func (c *Client) DatabaseID() string {
+	return c.databaseID
+}
+
+// DatabaseName returns the resource name of the database the client
+// accesses, of the form "projects/{project_id}/databases/{database_id}".
+func (c *Client) DatabaseName() string {
+	return c.path()
+}
+
 func (c *Client) path() string {
 	return fmt.Sprintf("projects/%s/databases/%s", c.projectID, c.databaseID)
 }
 
+// withResourceHeader adds the resource prefix header for resource to ctx. If
+// resource is a database path, it also adds the routing header that is
+// required to access named databases.
 func withResourceHeader(ctx context.Context, resource string) context.Context {
 	md, ok := metadata.FromOutgoingContext(ctx)
 	if !ok {
@@ -165,17 +180,18 @@ func withResourceHeader(ctx context.Context, resource string) context.Context {
 	}
 	md = md.Copy()
 	md[resourcePrefixHeader] = []string{resource}
+	if isDatabasePath(resource) {
+		md[reqParamsHeader] = []string{reqParamsHeaderVal(resource)}
+	}
 	return metadata.NewOutgoingContext(ctx, md)
 }
 
-func withRequestParamsHeader(ctx context.Context, requestParams string) context.Context {
-	md, ok := metadata.FromOutgoingContext(ctx)
-	if !ok {
-		md = metadata.New(nil)
-	}
-	md = md.Copy()
-	md[reqParamsHeader] = []string{requestParams}
-	return metadata.NewOutgoingContext(ctx, md)
+// isDatabasePath reports whether path is of the form
+// projects/{project_id}/databases/{database_id}, optionally followed by
+// further path segments.
+func isDatabasePath(path string) bool {
+	parts := strings.SplitN(path, "/", 5)
+	return len(parts) >= 4 && parts[0] == "projects" && parts[2] == "databases"
 }
 
 // Collection creates a reference to a collection with the given path.
@@ -247,10 +263,12 @@ func (c *Client) GetAll(ctx context.Context, docRefs []*DocumentRef) (_ []*Docum
 	ctx = trace.StartSpan(ctx, "cloud.google.com/go/firestore.GetAll")
 	defer func() { trace.EndSpan(ctx, err) }()
 
-	return c.getAll(ctx, docRefs, nil, nil)
+	return c.getAll(ctx, docRefs, nil, nil, nil)
 }
 
-func (c *Client) getAll(ctx context.Context, docRefs []*DocumentRef, tid []byte, rs *readSettings) (_ []*DocumentSnapshot, err error) {
+// getAll retrieves the documents of docRefs. If mask is not nil, only the
+// fields at the paths in mask are retrieved.
+func (c *Client) getAll(ctx context.Context, docRefs []*DocumentRef, tid []byte, rs *readSettings, mask []FieldPath) (_ []*DocumentSnapshot, err error) {
 	ctx = trace.StartSpan(ctx, "cloud.google.com/go/firestore.Client.BatchGetDocuments")
 	defer func() { trace.EndSpan(ctx, err) }()
 
@@ -267,6 +285,9 @@ func (c *Client) getAll(ctx context.Context, docRefs []*DocumentRef, tid []byte,
 		Database:  c.path(),
 		Documents: docNames,
 	}
+	if mask != nil {
+		req.Mask = &pb.DocumentMask{FieldPaths: toServiceFieldPaths(mask)}
+	}
 
 	// Note that transaction ID and other consistency selectors are mutually exclusive.
 	// We respect the transaction first, any read options passed by the caller second,
@@ -280,7 +301,6 @@ func (c *Client) getAll(ctx context.Context, docRefs []*DocumentRef, tid []byte,
 	}
 
 	batchGetDocsCtx := withResourceHeader(ctx, req.Database)
-	batchGetDocsCtx = withRequestParamsHeader(batchGetDocsCtx, reqParamsHeaderVal(c.path()))
 	streamClient, err := c.c.BatchGetDocuments(batchGetDocsCtx, req)
 	if err != nil {
 		return nil, err
@@ -360,8 +380,10 @@ func (c *Client) Batch() *WriteBatch {
 // BulkWriter returns a BulkWriter instance.
 // The context passed to the BulkWriter remains stored through the lifecycle
 // of the object. This context allows callers to cancel BulkWriter operations.
-func (c *Client) BulkWriter(ctx context.Context) *BulkWriter {
-	bw := newBulkWriter(ctx, c, c.path())
+// Use opts to configure the throughput of the BulkWriter and to be notified
+// of the results of individual writes.
+func (c *Client) BulkWriter(ctx context.Context, opts ...BulkWriterOption) *BulkWriter {
+	bw := newBulkWriter(ctx, c, c.path(), opts...)
 	return bw
 }
 
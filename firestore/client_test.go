@@ -22,6 +22,7 @@ import (
 	pb "cloud.google.com/go/firestore/apiv1/firestorepb"
 	tspb "github.com/golang/protobuf/ptypes/timestamp"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -71,6 +72,35 @@ func TestNewClientWithDatabase(t *testing.T) {
 	}
 }
 
+func TestClientDatabaseName(t *testing.T) {
+	c := &Client{projectID: "p1", databaseID: "db1"}
+	if got, want := c.DatabaseID(), "db1"; got != want {
+		t.Errorf("DatabaseID: got %q, want %q", got, want)
+	}
+	if got, want := c.DatabaseName(), "projects/p1/databases/db1"; got != want {
+		t.Errorf("DatabaseName: got %q, want %q", got, want)
+	}
+}
+
+func TestWithResourceHeader(t *testing.T) {
+	for _, tc := range []struct {
+		resource   string
+		wantParams []string
+	}{
+		{"projects/p1/databases/db1", []string{"project_id=p1&database_id=db1"}},
+		{"projects/p1/databases/(default)/documents/C", []string{"project_id=p1&database_id=%28default%29"}},
+		{"not/a/database", nil},
+	} {
+		md, _ := metadata.FromOutgoingContext(withResourceHeader(context.Background(), tc.resource))
+		if got, want := md.Get(resourcePrefixHeader), []string{tc.resource}; !testEqual(got, want) {
+			t.Errorf("%s: resource prefix header: got %v, want %v", tc.resource, got, want)
+		}
+		if got := md.Get(reqParamsHeader); !testEqual(got, tc.wantParams) {
+			t.Errorf("%s: request params header: got %v, want %v", tc.resource, got, tc.wantParams)
+		}
+	}
+}
+
 func TestClientCollectionAndDoc(t *testing.T) {
 	coll1 := testClient.Collection("X")
 	db := "projects/projectID/databases/(default)"
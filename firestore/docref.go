@@ -82,7 +82,7 @@ func (d *DocumentRef) Get(ctx context.Context) (_ *DocumentSnapshot, err error)
 		return nil, errNilDocRef
 	}
 
-	docsnaps, err := d.Parent.c.getAll(ctx, []*DocumentRef{d}, nil, d.readSettings)
+	docsnaps, err := d.Parent.c.getAll(ctx, []*DocumentRef{d}, nil, d.readSettings, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -93,6 +93,51 @@ func (d *DocumentRef) Get(ctx context.Context) (_ *DocumentSnapshot, err error)
 	return ds, nil
 }
 
+// GetFields is like Get, but only retrieves the fields of the document at the
+// given paths. The returned DocumentSnapshot contains only those fields; use
+// its DataTo method to decode them. If no paths are given, the snapshot
+// contains no fields.
+func (d *DocumentRef) GetFields(ctx context.Context, fps ...FieldPath) (_ *DocumentSnapshot, err error) {
+	ctx = trace.StartSpan(ctx, "cloud.google.com/go/firestore.DocumentRef.GetFields")
+	defer func() { trace.EndSpan(ctx, err) }()
+
+	if d == nil {
+		return nil, errNilDocRef
+	}
+	for _, fp := range fps {
+		if err := fp.validate(); err != nil {
+			return nil, err
+		}
+	}
+	if fps == nil {
+		fps = []FieldPath{}
+	}
+	docsnaps, err := d.Parent.c.getAll(ctx, []*DocumentRef{d}, nil, d.readSettings, fps)
+	if err != nil {
+		return nil, err
+	}
+	ds := docsnaps[0]
+	if !ds.Exists() {
+		return ds, status.Errorf(codes.NotFound, "%q not found", d.Path)
+	}
+	return ds, nil
+}
+
+// GetInto retrieves the fields of the document that are decoded into p, as
+// determined by StructFieldPaths, and decodes them into p with DataTo. The
+// fields of p that have no corresponding document field are left unchanged.
+func (d *DocumentRef) GetInto(ctx context.Context, p interface{}) (*DocumentSnapshot, error) {
+	fps, err := StructFieldPaths(p)
+	if err != nil {
+		return nil, err
+	}
+	ds, err := d.GetFields(ctx, fps...)
+	if err != nil {
+		return ds, err
+	}
+	return ds, ds.DataTo(p)
+}
+
 // Create creates the document with the given data.
 // It returns an error if a document with the same ID already exists.
 //
@@ -787,10 +832,13 @@ func iterFetch(pageSize int, pageToken string, pi *iterator.PageInfo, next func(
 
 // Snapshots returns an iterator over snapshots of the document. Each time the document
 // changes or is added or deleted, a new snapshot will be generated.
-func (d *DocumentRef) Snapshots(ctx context.Context) *DocumentSnapshotIterator {
+//
+// Use opts to resume listening from the state of an earlier iterator, or to
+// configure how the underlying stream is restarted after errors.
+func (d *DocumentRef) Snapshots(ctx context.Context, opts ...ListenOption) *DocumentSnapshotIterator {
 	return &DocumentSnapshotIterator{
 		docref: d,
-		ws:     newWatchStreamForDocument(ctx, d),
+		ws:     newWatchStreamForDocument(ctx, d, opts...),
 	}
 }
 
@@ -826,6 +874,13 @@ func (it *DocumentSnapshotIterator) Next() (*DocumentSnapshot, error) {
 	return snap.(*DocumentSnapshot), nil
 }
 
+// ResumeToken returns the resume token of the most recent snapshot returned
+// by Next, or nil if Next has not returned a snapshot. Persist it to resume
+// listening later with ListenResumeToken.
+func (it *DocumentSnapshotIterator) ResumeToken() []byte {
+	return it.ws.resumeToken
+}
+
 // Stop stops receiving snapshots. You should always call Stop when you are done with
 // a DocumentSnapshotIterator, to free up resources. It is not safe to call Stop
 // concurrently with Next.
@@ -91,6 +91,54 @@ func TestDocGet(t *testing.T) {
 	}
 }
 
+func TestDocGetInto(t *testing.T) {
+	ctx := context.Background()
+	c, srv, cleanup := newMock(t)
+	defer cleanup()
+
+	type address struct {
+		City string `firestore:"city"`
+	}
+	type user struct {
+		Name    string
+		Address *address `firestore:"addr"`
+		Ignored int      `firestore:"-"`
+	}
+
+	path := "projects/projectID/databases/(default)/documents/C/a"
+	pdoc := &pb.Document{
+		Name:       path,
+		CreateTime: aTimestamp,
+		UpdateTime: aTimestamp,
+		Fields: map[string]*pb.Value{
+			"Name": strval("alice"),
+			"addr": mapval(map[string]*pb.Value{"city": strval("Paris")}),
+		},
+	}
+	srv.addRPC(&pb.BatchGetDocumentsRequest{
+		Database:  c.path(),
+		Documents: []string{path},
+		Mask:      &pb.DocumentMask{FieldPaths: []string{"Name", "addr.city"}},
+	}, []interface{}{
+		&pb.BatchGetDocumentsResponse{
+			Result:   &pb.BatchGetDocumentsResponse_Found{pdoc},
+			ReadTime: aTimestamp2,
+		},
+	})
+	var got user
+	if _, err := c.Collection("C").Doc("a").GetInto(ctx, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := user{Name: "alice", Address: &address{City: "Paris"}}
+	if !testEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if _, err := c.Collection("C").Doc("a").GetFields(ctx, FieldPath{""}); err == nil {
+		t.Error("got nil, want error for invalid field path")
+	}
+}
+
 func TestDocSet(t *testing.T) {
 	// Most tests for Set are in the conformance tests.
 	ctx := context.Background()
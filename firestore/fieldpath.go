@@ -225,6 +225,58 @@ func fieldMap(t reflect.Type) (map[string]fields.Field, error) {
 	return x.(map[string]fields.Field), nil
 }
 
+// StructFieldPaths returns the paths of the Firestore fields that DataTo
+// decodes into p, which must be a struct or a pointer to a struct. Fields of
+// nested structs are returned as paths into the corresponding map fields, so
+// that only the fields needed to populate p are selected. Struct fields
+// tagged with `firestore:"-"` are skipped.
+//
+// Use the paths with DocumentRef.GetFields or Query.SelectPaths to retrieve
+// only the part of documents that is decoded into p.
+func StructFieldPaths(p interface{}) ([]FieldPath, error) {
+	t := reflect.TypeOf(p)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("firestore: StructFieldPaths needs a struct or pointer to struct, got %T", p)
+	}
+	return structFieldPaths(t, nil, map[reflect.Type]bool{})
+}
+
+// structFieldPaths returns the field paths for struct type t, prefixed by
+// prefix. Types in seen are being expanded; a field of such a type is
+// selected as a whole to avoid infinite recursion.
+func structFieldPaths(t reflect.Type, prefix FieldPath, seen map[reflect.Type]bool) ([]FieldPath, error) {
+	fieldList, err := fieldCache.Fields(t)
+	if err != nil {
+		return nil, err
+	}
+	seen[t] = true
+	defer delete(seen, t)
+	var fps []FieldPath
+	for _, f := range fieldList {
+		fp := append(append(FieldPath(nil), prefix...), f.Name)
+		ft := f.Type
+		for !isLeafType(ft) && ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() != reflect.Struct || isLeafType(ft) || seen[ft] {
+			fps = append(fps, fp)
+			continue
+		}
+		nested, err := structFieldPaths(ft, fp, seen)
+		if err != nil {
+			return nil, err
+		}
+		if len(nested) == 0 {
+			fps = append(fps, fp)
+		}
+		fps = append(fps, nested...)
+	}
+	return fps, nil
+}
+
 // toServiceFieldPath converts fp the form required by the Firestore service.
 // It assumes fp has been validated.
 func (fp FieldPath) toServiceFieldPath() string {
@@ -18,8 +18,10 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"cloud.google.com/go/internal/testutil"
+	"google.golang.org/genproto/googleapis/type/latlng"
 )
 
 func TestFieldPathValidate(t *testing.T) {
@@ -230,3 +232,41 @@ func TestToServiceFieldPathComponent(t *testing.T) {
 		}
 	}
 }
+
+func TestStructFieldPaths(t *testing.T) {
+	type inner struct {
+		A int
+		B *latlng.LatLng
+		T time.Time
+	}
+	type node struct {
+		Value int
+		Next  *node
+	}
+	type outer struct {
+		X     int `firestore:"x"`
+		Skip  int `firestore:"-"`
+		In    inner
+		InPtr *inner `firestore:"p"`
+		Node  node
+		M     map[string]int
+	}
+	got, err := StructFieldPaths(&outer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FieldPath{
+		{"x"},
+		{"In", "A"}, {"In", "B"}, {"In", "T"},
+		{"p", "A"}, {"p", "B"}, {"p", "T"},
+		{"Node", "Value"}, {"Node", "Next"},
+		{"M"},
+	}
+	if !testEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := StructFieldPaths(map[string]int{}); err == nil {
+		t.Error("got nil, want error for map")
+	}
+}
@@ -0,0 +1,371 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package firestoretest provides a harness for testing code that uses
+// Firestore against the Firestore emulator.
+//
+// A Harness connects to the emulator given by the FIRESTORE_EMULATOR_HOST
+// environment variable, or starts one with the gcloud command-line tool. It
+// can seed documents from fixtures, clear all documents between tests, take
+// checkpoints to read documents as they were at past read times, and expire
+// documents with a TTL field according to a scripted Clock, since the
+// emulator does not apply TTL policies itself.
+//
+//	h, err := firestoretest.New(ctx, "test-project")
+//	if err != nil {
+//		// TODO: Handle error.
+//	}
+//	defer h.Close()
+//	err = h.Seed(ctx, map[string]map[string]interface{}{
+//		"sessions/s1": {"user": "alice", "expireAt": h.Clock.Now().Add(time.Hour)},
+//	})
+//	if err != nil {
+//		// TODO: Handle error.
+//	}
+//	h.Clock.Advance(2 * time.Hour)
+//	n, err := h.ExpireTTL(ctx, "sessions", "expireAt") // n == 1
+//
+// This package is EXPERIMENTAL and is subject to change without notice.
+package firestoretest // import "cloud.google.com/go/firestore/firestoretest"
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// emulatorHostEnv is the environment variable with the address of a running
+// emulator.
+const emulatorHostEnv = "FIRESTORE_EMULATOR_HOST"
+
+// startTimeout is how long to wait for a started emulator to accept
+// connections.
+const startTimeout = 30 * time.Second
+
+// A Harness is a Firestore client connected to the Firestore emulator, with
+// helpers to set up the state of the emulator for tests.
+type Harness struct {
+	// Client is connected to the emulator.
+	Client *firestore.Client
+
+	// Clock is the scripted clock used by ExpireTTL. It starts at the time
+	// the Harness was created.
+	Clock *Clock
+
+	host      string
+	projectID string
+	cmd       *exec.Cmd // the emulator process, if started by the Harness
+}
+
+// New returns a Harness for projectID. It uses the emulator at the address in
+// the FIRESTORE_EMULATOR_HOST environment variable if set, and otherwise
+// starts a new emulator, which requires the gcloud command-line tool and its
+// Firestore emulator component. Call Close when done with the Harness.
+func New(ctx context.Context, projectID string) (*Harness, error) {
+	if projectID == "" {
+		return nil, errors.New("firestoretest: projectID was empty")
+	}
+	h := &Harness{
+		Clock:     NewClock(time.Now()),
+		host:      os.Getenv(emulatorHostEnv),
+		projectID: projectID,
+	}
+	if h.host == "" {
+		if err := h.startEmulator(ctx); err != nil {
+			return nil, err
+		}
+	}
+	conn, err := grpc.DialContext(ctx, h.host,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithPerRPCCredentials(emulatorCreds{}))
+	if err != nil {
+		h.stopEmulator()
+		return nil, fmt.Errorf("firestoretest: dialing emulator at %s: %w", h.host, err)
+	}
+	h.Client, err = firestore.NewClient(ctx, projectID, option.WithGRPCConn(conn))
+	if err != nil {
+		conn.Close()
+		h.stopEmulator()
+		return nil, err
+	}
+	return h, nil
+}
+
+// Host returns the address of the emulator.
+func (h *Harness) Host() string {
+	return h.host
+}
+
+// Close closes the client, and stops the emulator if it was started by New.
+func (h *Harness) Close() error {
+	err := h.Client.Close()
+	h.stopEmulator()
+	return err
+}
+
+// Seed writes the documents in fixtures, which maps document paths relative
+// to the database, like "users/alice", to document data. Existing documents
+// are overwritten.
+func (h *Harness) Seed(ctx context.Context, fixtures map[string]map[string]interface{}) error {
+	bw := h.Client.BulkWriter(ctx)
+	var jobs []*firestore.BulkWriterJob
+	for path, data := range fixtures {
+		doc := h.Client.Doc(path)
+		if doc == nil {
+			bw.End()
+			return fmt.Errorf("firestoretest: %q is not a document path", path)
+		}
+		j, err := bw.Set(doc, data)
+		if err != nil {
+			bw.End()
+			return err
+		}
+		jobs = append(jobs, j)
+	}
+	bw.End()
+	for _, j := range jobs {
+		if _, err := j.Results(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SeedJSON reads fixtures as a JSON object mapping document paths to
+// document data from r, and writes them with Seed. JSON numbers are written
+// as integers if they have no fractional part, and as doubles otherwise.
+func (h *Harness) SeedJSON(ctx context.Context, r io.Reader) error {
+	fixtures, err := decodeFixtures(r)
+	if err != nil {
+		return err
+	}
+	return h.Seed(ctx, fixtures)
+}
+
+func decodeFixtures(r io.Reader) (map[string]map[string]interface{}, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var fixtures map[string]map[string]interface{}
+	if err := dec.Decode(&fixtures); err != nil {
+		return nil, fmt.Errorf("firestoretest: decoding fixtures: %w", err)
+	}
+	for _, data := range fixtures {
+		for k, v := range data {
+			data[k] = convertJSONNumbers(v)
+		}
+	}
+	return fixtures, nil
+}
+
+// convertJSONNumbers replaces the json.Numbers in v by int64 or float64
+// values.
+func convertJSONNumbers(v interface{}) interface{} {
+	switch x := v.(type) {
+	case json.Number:
+		if i, err := x.Int64(); err == nil {
+			return i
+		}
+		f, _ := x.Float64()
+		return f
+	case map[string]interface{}:
+		for k, e := range x {
+			x[k] = convertJSONNumbers(e)
+		}
+	case []interface{}:
+		for i, e := range x {
+			x[i] = convertJSONNumbers(e)
+		}
+	}
+	return v
+}
+
+// ExpireTTL deletes the documents of all collections with ID collectionID
+// whose field is a timestamp before the current time of h.Clock, as a TTL
+// policy on that field would. It returns the number of deleted documents.
+func (h *Harness) ExpireTTL(ctx context.Context, collectionID, field string) (int, error) {
+	iter := h.Client.CollectionGroup(collectionID).Where(field, "<", h.Clock.Now()).Documents(ctx)
+	defer iter.Stop()
+	var refs []*firestore.DocumentRef
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		refs = append(refs, doc.Ref)
+	}
+	if len(refs) == 0 {
+		return 0, nil
+	}
+	bw := h.Client.BulkWriter(ctx)
+	var jobs []*firestore.BulkWriterJob
+	for _, ref := range refs {
+		j, err := bw.Delete(ref)
+		if err != nil {
+			bw.End()
+			return 0, err
+		}
+		jobs = append(jobs, j)
+	}
+	bw.End()
+	for _, j := range jobs {
+		if _, err := j.Results(); err != nil {
+			return 0, err
+		}
+	}
+	return len(refs), nil
+}
+
+// Checkpoint returns a read time of the emulator at which reads see all the
+// writes made before Checkpoint was called and none of the writes made after
+// it returns. Passing it to firestore.ReadTime, as in
+//
+//	snap, err := h.Client.Doc("users/alice").WithReadOptions(firestore.ReadTime(t)).Get(ctx)
+//
+// reads the documents as they were at the checkpoint, for testing code that
+// reads past versions of documents. Read times have a precision of a second,
+// so Checkpoint waits for up to a second before returning.
+func (h *Harness) Checkpoint(ctx context.Context) (time.Time, error) {
+	t, err := h.readTime(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	checkpoint := t.Truncate(time.Second)
+	if !checkpoint.Equal(t) {
+		checkpoint = checkpoint.Add(time.Second)
+	}
+	for !t.After(checkpoint) {
+		select {
+		case <-ctx.Done():
+			return time.Time{}, ctx.Err()
+		case <-time.After(checkpoint.Sub(t) + time.Millisecond):
+		}
+		if t, err = h.readTime(ctx); err != nil {
+			return time.Time{}, err
+		}
+	}
+	return checkpoint, nil
+}
+
+// readTime returns the current read time of the emulator, which it reports
+// even for documents that do not exist.
+func (h *Harness) readTime(ctx context.Context) (time.Time, error) {
+	snap, err := h.Client.Collection("firestoretest").Doc("checkpoint").Get(ctx)
+	if snap == nil || snap.ReadTime.IsZero() {
+		if err == nil {
+			err = errors.New("firestoretest: emulator returned no read time")
+		}
+		return time.Time{}, err
+	}
+	return snap.ReadTime, nil
+}
+
+// Reset deletes all documents of the default database of the emulator.
+func (h *Harness) Reset(ctx context.Context) error {
+	u := fmt.Sprintf("http://%s/emulator/v1/projects/%s/databases/%s/documents",
+		h.host, url.PathEscape(h.projectID), url.PathEscape(firestore.DefaultDatabaseID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u, nil)
+	if err != nil {
+		return err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("firestoretest: resetting emulator: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("firestoretest: resetting emulator: %s: %s", res.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// startEmulator starts the emulator on a free local port and waits until it
+// accepts connections.
+func (h *Harness) startEmulator(ctx context.Context) error {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("firestoretest: finding a free port: %w", err)
+	}
+	host := l.Addr().String()
+	l.Close()
+
+	cmd := exec.Command("gcloud", "emulators", "firestore", "start", "--host-port="+host)
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("firestoretest: starting emulator: %w", err)
+	}
+	h.host = host
+	h.cmd = cmd
+
+	deadline := time.Now().Add(startTimeout)
+	for {
+		conn, err := net.DialTimeout("tcp", host, time.Second)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		if time.Now().After(deadline) {
+			h.stopEmulator()
+			return fmt.Errorf("firestoretest: emulator did not start within %v: %w", startTimeout, err)
+		}
+		select {
+		case <-ctx.Done():
+			h.stopEmulator()
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// stopEmulator stops the emulator started by startEmulator, if any. gcloud
+// runs the emulator in a child process, so the whole process group is
+// killed.
+func (h *Harness) stopEmulator() {
+	if h.cmd == nil {
+		return
+	}
+	_ = killProcessGroup(h.cmd)
+	_ = h.cmd.Wait()
+	h.cmd = nil
+}
+
+// emulatorCreds configures a client to act as an admin for the emulator,
+// which accepts "Bearer owner" as admin credentials.
+type emulatorCreds struct{}
+
+func (ec emulatorCreds) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer owner"}, nil
+}
+
+func (ec emulatorCreds) RequireTransportSecurity() bool {
+	return false
+}
@@ -0,0 +1,119 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firestoretest
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewClock(start)
+	if got := c.Now(); !got.Equal(start) {
+		t.Errorf("Now: got %v, want %v", got, start)
+	}
+	if got, want := c.Advance(time.Hour), start.Add(time.Hour); !got.Equal(want) {
+		t.Errorf("Advance: got %v, want %v", got, want)
+	}
+	c.Set(start)
+	if got := c.Now(); !got.Equal(start) {
+		t.Errorf("Now after Set: got %v, want %v", got, start)
+	}
+}
+
+func TestDecodeFixtures(t *testing.T) {
+	got, err := decodeFixtures(strings.NewReader(`{
+		"users/alice": {"age": 30, "score": 1.5, "tags": ["a", 2], "address": {"zip": 12345}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]map[string]interface{}{
+		"users/alice": {
+			"age":     int64(30),
+			"score":   1.5,
+			"tags":    []interface{}{"a", int64(2)},
+			"address": map[string]interface{}{"zip": int64(12345)},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := decodeFixtures(strings.NewReader(`["users/alice"]`)); err == nil {
+		t.Error("got nil, want error for invalid fixtures")
+	}
+}
+
+func TestHarness(t *testing.T) {
+	if os.Getenv(emulatorHostEnv) == "" {
+		t.Skipf("%s not set; skipping test that requires the emulator", emulatorHostEnv)
+	}
+	ctx := context.Background()
+	h, err := New(ctx, "firestoretest-project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	if err := h.Reset(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	now := h.Clock.Now()
+	if err := h.Seed(ctx, map[string]map[string]interface{}{
+		"sessions/s1": {"expireAt": now.Add(time.Minute)},
+		"sessions/s2": {"expireAt": now.Add(time.Hour)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	h.Clock.Advance(30 * time.Minute)
+	n, err := h.ExpireTTL(ctx, "sessions", "expireAt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("ExpireTTL: got %d deleted documents, want 1", n)
+	}
+	if _, err := h.Client.Doc("sessions/s2").Get(ctx); err != nil {
+		t.Errorf("unexpired document: %v", err)
+	}
+
+	checkpoint, err := h.Checkpoint(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Client.Doc("sessions/s2").Delete(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Client.Doc("sessions/s2").WithReadOptions(firestore.ReadTime(checkpoint)).Get(ctx); err != nil {
+		t.Errorf("Get at checkpoint: %v", err)
+	}
+
+	if err := h.Reset(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Client.Doc("sessions/s2").Get(ctx); status.Code(err) != codes.NotFound {
+		t.Errorf("Get after Reset: got %v, want NotFound error", err)
+	}
+}
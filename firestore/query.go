@@ -725,9 +725,26 @@ type OrFilter struct {
 	Filters []EntityFilter
 }
 
+// OrFilters returns a filter that matches documents matching any of filters.
+// The filters may themselves be composite filters:
+//
+//	q.WhereEntity(firestore.OrFilters(
+//		firestore.PropertyFilter{Path: "state", Operator: "==", Value: "CA"},
+//		firestore.AndFilters(
+//			firestore.PropertyFilter{Path: "state", Operator: "==", Value: "WA"},
+//			firestore.PropertyFilter{Path: "population", Operator: ">", Value: 100000},
+//		),
+//	))
+func OrFilters(filters ...EntityFilter) OrFilter {
+	return OrFilter{Filters: filters}
+}
+
 func (OrFilter) isCompositeFilter() {}
 
 func (f OrFilter) toProto() (*pb.StructuredQuery_Filter, error) {
+	if len(f.Filters) == 0 {
+		return nil, errors.New("firestore: OrFilter must contain at least one filter")
+	}
 	var pbFilters []*pb.StructuredQuery_Filter
 
 	for _, filter := range f.Filters {
@@ -756,9 +773,18 @@ type AndFilter struct {
 	Filters []EntityFilter
 }
 
+// AndFilters returns a filter that matches documents matching all of filters.
+// See OrFilters for an example.
+func AndFilters(filters ...EntityFilter) AndFilter {
+	return AndFilter{Filters: filters}
+}
+
 func (AndFilter) isCompositeFilter() {}
 
 func (f AndFilter) toProto() (*pb.StructuredQuery_Filter, error) {
+	if len(f.Filters) == 0 {
+		return nil, errors.New("firestore: AndFilter must contain at least one filter")
+	}
 	var pbFilters []*pb.StructuredQuery_Filter
 
 	for _, filter := range f.Filters {
@@ -1147,8 +1173,11 @@ func (it *queryDocumentIterator) stop() {
 
 // Snapshots returns an iterator over snapshots of the query. Each time the query
 // results change, a new snapshot will be generated.
-func (q Query) Snapshots(ctx context.Context) *QuerySnapshotIterator {
-	ws, err := newWatchStreamForQuery(ctx, q)
+//
+// Use opts to resume listening from the state of an earlier iterator, or to
+// configure how the underlying stream is restarted after errors.
+func (q Query) Snapshots(ctx context.Context, opts ...ListenOption) *QuerySnapshotIterator {
+	ws, err := newWatchStreamForQuery(ctx, q, opts...)
 	if err != nil {
 		return &QuerySnapshotIterator{err: err}
 	}
@@ -1198,6 +1227,16 @@ func (it *QuerySnapshotIterator) Next() (*QuerySnapshot, error) {
 	}, nil
 }
 
+// ResumeToken returns the resume token of the most recent snapshot returned
+// by Next, or nil if Next has not returned a snapshot. Persist it to resume
+// listening later with ListenResumeToken.
+func (it *QuerySnapshotIterator) ResumeToken() []byte {
+	if it.ws == nil {
+		return nil
+	}
+	return it.ws.resumeToken
+}
+
 // Stop stops receiving snapshots. You should always call Stop when you are done with
 // a QuerySnapshotIterator, to free up resources. It is not safe to call Stop
 // concurrently with Next.
@@ -1422,4 +1461,67 @@ func (a *AggregationQuery) Get(ctx context.Context) (AggregationResult, error) {
 }
 
 // AggregationResult contains the results of an aggregation query.
+//
+// The values of the map are *firestorepb.Value. Use Count, Sum and Avg to get
+// them as Go values.
 type AggregationResult map[string]interface{}
+
+// Count returns the result of the count aggregation with the given alias.
+func (a AggregationResult) Count(alias string) (int64, error) {
+	v, err := a.value(alias)
+	if err != nil {
+		return 0, err
+	}
+	iv, ok := v.GetValueType().(*pb.Value_IntegerValue)
+	if !ok {
+		return 0, fmt.Errorf("firestore: aggregation result %q is not an integer", alias)
+	}
+	return iv.IntegerValue, nil
+}
+
+// Sum returns the result of the sum aggregation with the given alias.
+//
+// Firestore returns an integer sum if all summed values are integers and the
+// sum does not overflow, and a floating-point sum otherwise. Sum converts
+// either to a float64; use the value of the map directly to get the exact
+// integer sum.
+func (a AggregationResult) Sum(alias string) (float64, error) {
+	return a.float64Value(alias)
+}
+
+// Avg returns the result of the average aggregation with the given alias.
+//
+// The average is null if none of the documents matching the query contain
+// a numeric value for the averaged field, in which case Avg returns an error.
+func (a AggregationResult) Avg(alias string) (float64, error) {
+	return a.float64Value(alias)
+}
+
+func (a AggregationResult) float64Value(alias string) (float64, error) {
+	v, err := a.value(alias)
+	if err != nil {
+		return 0, err
+	}
+	switch x := v.GetValueType().(type) {
+	case *pb.Value_IntegerValue:
+		return float64(x.IntegerValue), nil
+	case *pb.Value_DoubleValue:
+		return x.DoubleValue, nil
+	case *pb.Value_NullValue:
+		return 0, fmt.Errorf("firestore: aggregation result %q is null", alias)
+	default:
+		return 0, fmt.Errorf("firestore: aggregation result %q is not a number", alias)
+	}
+}
+
+func (a AggregationResult) value(alias string) (*pb.Value, error) {
+	r, ok := a[alias]
+	if !ok {
+		return nil, fmt.Errorf("firestore: no aggregation result with alias %q", alias)
+	}
+	v, ok := r.(*pb.Value)
+	if !ok {
+		return nil, fmt.Errorf("firestore: aggregation result %q has unexpected type %T", alias, r)
+	}
+	return v, nil
+}
@@ -237,6 +237,36 @@ func TestFilterToProto(t *testing.T) {
 	}
 }
 
+func TestCompositeFilterBuilders(t *testing.T) {
+	got := OrFilters(
+		PropertyFilter{"b", "==", 15},
+		AndFilters(
+			PropertyPathFilter{[]string{"a"}, ">", 5},
+			PropertyFilter{"a", "<=", 12},
+		),
+	)
+	want := OrFilter{
+		Filters: []EntityFilter{
+			PropertyFilter{"b", "==", 15},
+			AndFilter{
+				Filters: []EntityFilter{
+					PropertyPathFilter{[]string{"a"}, ">", 5},
+					PropertyFilter{"a", "<=", 12},
+				},
+			},
+		},
+	}
+	if !testEqual(got, want) {
+		t.Errorf("got\n%v\nwant\n%v", pretty.Value(got), pretty.Value(want))
+	}
+
+	for _, f := range []EntityFilter{OrFilters(), AndFilters(), OrFilters(AndFilters())} {
+		if _, err := f.toProto(); err == nil {
+			t.Errorf("%+v: got nil, want error for empty composite filter", f)
+		}
+	}
+}
+
 type toProtoScenario struct {
 	desc string
 	in   Query
@@ -1359,3 +1389,51 @@ func TestWithAvgPath(t *testing.T) {
 		}
 	}
 }
+
+func TestAggregationResult_TypedAccessors(t *testing.T) {
+	ctx := context.Background()
+	c, srv, cleanup := newMock(t)
+	defer cleanup()
+
+	srv.addRPC(nil, []interface{}{
+		&pb.RunAggregationQueryResponse{
+			Result: &pb.AggregationResult{
+				AggregateFields: map[string]*pb.Value{
+					"count":    intval(3),
+					"sum":      intval(12),
+					"avg":      floatval(4.5),
+					"emptyAvg": {ValueType: &pb.Value_NullValue{}},
+				},
+			},
+		},
+	})
+
+	ar, err := c.Collection("C").NewAggregationQuery().
+		WithCount("count").
+		WithSum("a", "sum").
+		WithAvg("b", "avg").
+		WithAvg("c", "emptyAvg").
+		Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := ar.Count("count"); err != nil || got != 3 {
+		t.Errorf("Count: got (%v, %v), want (3, nil)", got, err)
+	}
+	if got, err := ar.Sum("sum"); err != nil || got != 12 {
+		t.Errorf("Sum: got (%v, %v), want (12, nil)", got, err)
+	}
+	if got, err := ar.Avg("avg"); err != nil || got != 4.5 {
+		t.Errorf("Avg: got (%v, %v), want (4.5, nil)", got, err)
+	}
+	if _, err := ar.Avg("emptyAvg"); err == nil {
+		t.Error("Avg of null result: got nil, want error")
+	}
+	if _, err := ar.Count("avg"); err == nil {
+		t.Error("Count of double result: got nil, want error")
+	}
+	if _, err := ar.Sum("missing"); err == nil {
+		t.Error("Sum of missing alias: got nil, want error")
+	}
+}
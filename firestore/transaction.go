@@ -222,7 +222,7 @@ func (t *Transaction) GetAll(drs []*DocumentRef) ([]*DocumentSnapshot, error) {
 		t.readAfterWrite = true
 		return nil, errReadAfterWrite
 	}
-	return t.c.getAll(t.ctx, drs, t.id, t.readSettings)
+	return t.c.getAll(t.ctx, drs, t.id, t.readSettings, nil)
 }
 
 // A Queryer is a Query or a CollectionRef. CollectionRefs act as queries whose
@@ -30,6 +30,7 @@ import (
 	gax "github.com/googleapis/gax-go/v2"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // LogWatchStreams controls whether watch stream status changes are logged.
@@ -76,18 +77,74 @@ var defaultBackoff = gax.Backoff{
 	Multiplier: 1.5,
 }
 
+// A ListenOption is an option passed to Query.Snapshots and
+// DocumentRef.Snapshots.
+type ListenOption interface {
+	config(s *watchStream)
+}
+
+// ListenResumeToken is a ListenOption that resumes listening from a resume
+// token returned by the ResumeToken method of a snapshot iterator, for
+// example one that was persisted before the process restarted. The first
+// snapshot then only reports the changes made after the token, instead of
+// all the current results.
+func ListenResumeToken(token []byte) ListenOption { return listenResumeToken(token) }
+
+type listenResumeToken []byte
+
+func (t listenResumeToken) config(s *watchStream) {
+	s.target.ResumeType = &pb.Target_ResumeToken{ResumeToken: []byte(t)}
+}
+
+// ListenResumeReadTime is a ListenOption that resumes listening from the
+// read time of a previous snapshot. As with ListenResumeToken, the first
+// snapshot only reports the changes made after t.
+func ListenResumeReadTime(t time.Time) ListenOption { return listenResumeReadTime(t) }
+
+type listenResumeReadTime time.Time
+
+func (t listenResumeReadTime) config(s *watchStream) {
+	s.target.ResumeType = &pb.Target_ReadTime{ReadTime: timestamppb.New(time.Time(t))}
+}
+
+// ListenBackoff is a ListenOption that configures the backoff between
+// attempts to restart the underlying stream after a retryable error.
+func ListenBackoff(bo gax.Backoff) ListenOption { return listenBackoff(bo) }
+
+type listenBackoff gax.Backoff
+
+func (bo listenBackoff) config(s *watchStream) {
+	s.initialBackoff = gax.Backoff(bo)
+	s.backoff = gax.Backoff(bo)
+}
+
+// ListenRetryable is a ListenOption that configures which errors of the
+// underlying stream are retried. The stream is restarted if f returns true
+// for an error, and the error is returned from Next otherwise. By default,
+// errors with the codes Unknown, DeadlineExceeded, ResourceExhausted,
+// Internal, Unavailable and Unauthenticated are retried. The normal end of a
+// stream is always retried.
+func ListenRetryable(f func(err error) bool) ListenOption { return listenRetryable(f) }
+
+type listenRetryable func(err error) bool
+
+func (f listenRetryable) config(s *watchStream) { s.isRetryable = f }
+
 // not goroutine-safe
 type watchStream struct {
-	ctx         context.Context
-	c           *Client
-	lc          pb.Firestore_ListenClient                 // the gRPC stream
-	target      *pb.Target                                // document or query being watched
-	backoff     gax.Backoff                               // for stream retries
-	err         error                                     // sticky permanent error
-	readTime    time.Time                                 // time of most recent snapshot
-	current     bool                                      // saw CURRENT, but not RESET; precondition for a snapshot
-	hasReturned bool                                      // have we returned a snapshot yet?
-	compare     func(a, b *DocumentSnapshot) (int, error) // compare documents according to query
+	ctx            context.Context
+	c              *Client
+	lc             pb.Firestore_ListenClient                 // the gRPC stream
+	target         *pb.Target                                // document or query being watched
+	backoff        gax.Backoff                               // for stream retries
+	initialBackoff gax.Backoff                               // backoff to reset to once the stream is healthy
+	isRetryable    func(error) bool                          // whether to retry a stream error; nil for the default
+	resumeToken    []byte                                    // resume token of most recent snapshot
+	err            error                                     // sticky permanent error
+	readTime       time.Time                                 // time of most recent snapshot
+	current        bool                                      // saw CURRENT, but not RESET; precondition for a snapshot
+	hasReturned    bool                                      // have we returned a snapshot yet?
+	compare        func(a, b *DocumentSnapshot) (int, error) // compare documents according to query
 
 	// An ordered tree where DocumentSnapshots are the keys.
 	docTree *btree.BTree
@@ -98,7 +155,7 @@ type watchStream struct {
 	changeMap map[string]*DocumentSnapshot
 }
 
-func newWatchStreamForDocument(ctx context.Context, dr *DocumentRef) *watchStream {
+func newWatchStreamForDocument(ctx context.Context, dr *DocumentRef, opts ...ListenOption) *watchStream {
 	// A single document is always equal to itself.
 	compare := func(_, _ *DocumentSnapshot) (int, error) { return 0, nil }
 	return newWatchStream(ctx, dr.Parent.c, compare, &pb.Target{
@@ -106,10 +163,10 @@ func newWatchStreamForDocument(ctx context.Context, dr *DocumentRef) *watchStrea
 			Documents: &pb.Target_DocumentsTarget{Documents: []string{dr.Path}},
 		},
 		TargetId: watchTargetID,
-	})
+	}, opts...)
 }
 
-func newWatchStreamForQuery(ctx context.Context, q Query) (*watchStream, error) {
+func newWatchStreamForQuery(ctx context.Context, q Query, opts ...ListenOption) (*watchStream, error) {
 	qp, err := q.toProto()
 	if err != nil {
 		return nil, err
@@ -123,20 +180,24 @@ func newWatchStreamForQuery(ctx context.Context, q Query) (*watchStream, error)
 		},
 		TargetId: watchTargetID,
 	}
-	return newWatchStream(ctx, q.c, q.compareFunc(), target), nil
+	return newWatchStream(ctx, q.c, q.compareFunc(), target, opts...), nil
 }
 
 const btreeDegree = 4
 
-func newWatchStream(ctx context.Context, c *Client, compare func(_, _ *DocumentSnapshot) (int, error), target *pb.Target) *watchStream {
+func newWatchStream(ctx context.Context, c *Client, compare func(_, _ *DocumentSnapshot) (int, error), target *pb.Target, opts ...ListenOption) *watchStream {
 	w := &watchStream{
-		ctx:       ctx,
-		c:         c,
-		compare:   compare,
-		target:    target,
-		backoff:   defaultBackoff,
-		docMap:    map[string]*DocumentSnapshot{},
-		changeMap: map[string]*DocumentSnapshot{},
+		ctx:            ctx,
+		c:              c,
+		compare:        compare,
+		target:         target,
+		backoff:        defaultBackoff,
+		initialBackoff: defaultBackoff,
+		docMap:         map[string]*DocumentSnapshot{},
+		changeMap:      map[string]*DocumentSnapshot{},
+	}
+	for _, opt := range opts {
+		opt.config(w)
 	}
 	w.docTree = btree.New(btreeDegree, func(a, b interface{}) bool {
 		return w.less(a.(*DocumentSnapshot), b.(*DocumentSnapshot))
@@ -250,6 +311,7 @@ func (s *watchStream) handleTargetChange(tc *pb.TargetChange) bool {
 				return true
 			}
 			s.readTime = rt
+			s.resumeToken = tc.ResumeToken
 			s.target.ResumeType = &pb.Target_ResumeToken{tc.ResumeToken}
 			return true
 		}
@@ -291,7 +353,7 @@ func (s *watchStream) handleTargetChange(tc *pb.TargetChange) bool {
 	// If we see a resume token and our watch ID is affected, we assume the stream
 	// is now healthy, so we reset our backoff time to the minimum.
 	if tc.ResumeToken != nil && (len(tc.TargetIds) == 0 || hasWatchTargetID(tc.TargetIds)) {
-		s.backoff = defaultBackoff
+		s.backoff = s.initialBackoff
 	}
 	return false // not in a consistent state, keep receiving
 }
@@ -476,7 +538,7 @@ func (s *watchStream) recv() (*pb.ListenResponse, error) {
 			}
 		}
 		res, err := s.lc.Recv()
-		if err == nil || isPermanentWatchError(err) {
+		if err == nil || !s.retryable(err) {
 			return res, err
 		}
 		// Non-permanent error. Sleep and retry.
@@ -511,6 +573,14 @@ func (s *watchStream) open() (lc pb.Firestore_ListenClient, err error) {
 	return lc, nil
 }
 
+// retryable reports whether the stream should be restarted after err.
+func (s *watchStream) retryable(err error) bool {
+	if err == io.EOF || s.isRetryable == nil {
+		return !isPermanentWatchError(err)
+	}
+	return s.isRetryable(err)
+}
+
 func isPermanentWatchError(err error) bool {
 	if err == io.EOF {
 		// Retry on normal end-of-stream.
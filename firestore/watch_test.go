@@ -226,3 +226,58 @@ func TestWatchCancel(t *testing.T) {
 	// TODO(jba): Test that we get codes.Canceled when canceling an RPC.
 	// We had a test for this in a21236af, but it was flaky for unclear reasons.
 }
+
+func TestWatchListenOptions(t *testing.T) {
+	ctx := context.Background()
+	c, srv, cleanup := newMock(t)
+	defer cleanup()
+
+	q := Query{c: c, collectionID: "x"}
+	bo := gax.Backoff{Initial: 1, Max: 1, Multiplier: 1}
+	ws, err := newWatchStreamForQuery(ctx, q,
+		ListenResumeToken([]byte("token1")),
+		ListenBackoff(bo),
+		ListenRetryable(func(err error) bool { return status.Code(err) == codes.Unavailable }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ws.target.GetResumeToken(), []byte("token1"); !testEqual(got, want) {
+		t.Errorf("resume token: got %q, want %q", got, want)
+	}
+	if ws.backoff != bo || ws.initialBackoff != bo {
+		t.Errorf("backoff: got %+v and %+v, want %+v", ws.backoff, ws.initialBackoff, bo)
+	}
+
+	request := &pb.ListenRequest{
+		Database:     "projects/projectID/databases/(default)",
+		TargetChange: &pb.ListenRequest_AddTarget{ws.target},
+	}
+	current := &pb.ListenResponse{ResponseType: &pb.ListenResponse_TargetChange{&pb.TargetChange{
+		TargetChangeType: pb.TargetChange_CURRENT,
+	}}}
+	noChange := &pb.ListenResponse{ResponseType: &pb.ListenResponse_TargetChange{&pb.TargetChange{
+		TargetChangeType: pb.TargetChange_NO_CHANGE,
+		ReadTime:         aTimestamp,
+		ResumeToken:      []byte("token2"),
+	}}}
+	// Unavailable is retried, Unknown is not.
+	srv.addRPC(request, []interface{}{status.Error(codes.Unavailable, "")})
+	srv.addRPC(request, []interface{}{current, noChange, status.Error(codes.Unknown, "")})
+	if _, _, _, err := ws.nextSnapshot(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ws.resumeToken, []byte("token2"); !testEqual(got, want) {
+		t.Errorf("resume token after snapshot: got %q, want %q", got, want)
+	}
+	_, _, _, err = ws.nextSnapshot()
+	codeEq(t, "non-retryable error", codes.Unknown, err)
+
+	ws, err = newWatchStreamForQuery(ctx, q, ListenResumeReadTime(aTime))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ws.target.GetReadTime(), aTimestamp; !proto.Equal(got, want) {
+		t.Errorf("read time: got %v, want %v", got, want)
+	}
+}
@@ -27,6 +27,15 @@
 //     You will get back the recorded responses.
 //  3. Close the Replayer when you're done.
 //
+// Clients of gRPC-based APIs that also support REST, like those returned by
+// the NewRESTClient functions of the cloud.google.com/go packages, can be
+// recorded and replayed by passing the HTTP client to option.WithHTTPClient.
+//
+// Secrets and personal data can be kept out of the log with the Clear and
+// Remove methods of the Recorder for headers and query parameters, and with
+// ScrubRequestBodies and ScrubResponseBodies for bodies; ClearJSONFields
+// returns a function for the latter that clears fields of JSON bodies.
+//
 // This package is EXPERIMENTAL and is subject to change or removal without notice.
 // It requires Go version 1.8 or higher.
 package httpreplay
@@ -96,6 +105,30 @@ func (r *Recorder) ClearQueryParams(patterns ...string) {
 	r.proxy.ClearQueryParams(patterns)
 }
 
+// ScrubRequestBodies makes the Recorder apply f to the bodies of requests,
+// or to each part of multipart bodies, before logging them. The media type
+// passed to f is that of the Content-Type header of the request, without
+// parameters. The request sent to the server is not changed.
+//
+// Unlike the other ways of removing information from the log, f is not saved
+// to the log, so it must also be passed to Replayer.ScrubRequestBodies, for
+// requests to match their recording.
+func (r *Recorder) ScrubRequestBodies(f func(mediaType string, body []byte) []byte) {
+	r.proxy.ScrubRequestBodies(f)
+}
+
+// ScrubResponseBodies makes the Recorder apply f to the bodies of responses
+// before logging them. The media type passed to f is that of the
+// Content-Type header of the response, without parameters. The response
+// returned to the client is not changed, but on replay the client gets the
+// body returned by f.
+//
+// The bodies are passed to f as they were received, so a body with a
+// Content-Encoding, such as gzip, is compressed.
+func (r *Recorder) ScrubResponseBodies(f func(mediaType string, body []byte) []byte) {
+	r.proxy.ScrubResponseBodies(f)
+}
+
 // Client returns an http.Client to be used for recording. Provide authentication options
 // like option.WithTokenSource as you normally would, or omit them to use Application Default
 // Credentials.
@@ -142,6 +175,13 @@ func (r *Replayer) Initial() []byte {
 	return r.proxy.Initial
 }
 
+// ScrubRequestBodies makes the Replayer apply f to the bodies of requests
+// before matching them to the recorded requests. It must be called with the
+// functions passed to Recorder.ScrubRequestBodies, in the same order.
+func (r *Replayer) ScrubRequestBodies(f func(mediaType string, body []byte) []byte) {
+	r.proxy.ScrubRequestBodies(f)
+}
+
 // IgnoreHeader will not use h when matching requests.
 func (r *Replayer) IgnoreHeader(h string) {
 	r.proxy.IgnoreHeader(h)
@@ -34,6 +34,24 @@ type Converter struct {
 	RemoveResponseHeaders []tRegexp // remove matching headers in responses
 	ClearParams           []tRegexp // replace matching query params with "CLEARED"
 	RemoveParams          []tRegexp // remove matching query params
+
+	// Functions applied, in order, to the bodies of requests and responses
+	// before they are logged. They cannot be saved to the log, so those for
+	// requests must be registered again for replay.
+	RequestBodyScrubbers  []BodyScrubber `json:"-"`
+	ResponseBodyScrubbers []BodyScrubber `json:"-"`
+}
+
+// A BodyScrubber returns a body, or a part of a multipart body, with secret
+// or varying information removed or replaced. The media type is that of the
+// whole body.
+type BodyScrubber func(mediaType string, body []byte) []byte
+
+func scrubBody(fs []BodyScrubber, mediaType string, body []byte) []byte {
+	for _, f := range fs {
+		body = f(mediaType, body)
+	}
+	return body
 }
 
 // A regexp that can be marshaled to and from text.
@@ -140,6 +158,9 @@ func (c *Converter) convertRequest(req *http.Request) (*Request, error) {
 	if err != nil {
 		return nil, err
 	}
+	for i, p := range parts {
+		parts[i] = scrubBody(c.RequestBodyScrubbers, mediaType, p)
+	}
 	url2 := *req.URL
 	url2.RawQuery = scrubQuery(url2.RawQuery, c.ClearParams, c.RemoveParams)
 	return &Request{
@@ -192,6 +213,10 @@ func (c *Converter) convertResponse(res *http.Response) (*Response, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(c.ResponseBodyScrubbers) > 0 {
+		mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+		data = scrubBody(c.ResponseBodyScrubbers, mediaType, data)
+	}
 	return &Response{
 		StatusCode: res.StatusCode,
 		Proto:      res.Proto,
@@ -51,6 +51,7 @@ type Proxy struct {
 	Initial []byte
 
 	mproxy        *martian.Proxy
+	conv          *Converter      // converter of the log
 	filename      string          // for log
 	logger        *Logger         // for recording only
 	ignoreHeaders map[string]bool // headers the user has asked to ignore
@@ -75,6 +76,7 @@ func ForRecording(filename string, port int) (*Proxy, error) {
 	logGroup.AddRequestModifier(skipAuth)
 	logGroup.AddResponseModifier(skipAuth)
 	p.logger = newLogger()
+	p.conv = p.logger.log.Converter
 	logGroup.AddRequestModifier(p.logger)
 	logGroup.AddResponseModifier(p.logger)
 
@@ -194,6 +196,20 @@ func (p *Proxy) ClearQueryParams(patterns []string) {
 	}
 }
 
+// ScrubRequestBodies applies f to the bodies of requests before they are
+// logged, and before they are matched on replay. Unlike the other
+// patterns, f is not saved to the log, so it must be registered both when
+// recording and when replaying.
+func (p *Proxy) ScrubRequestBodies(f BodyScrubber) {
+	p.conv.RequestBodyScrubbers = append(p.conv.RequestBodyScrubbers, f)
+}
+
+// ScrubResponseBodies applies f to the bodies of responses before they are
+// logged. It only needs to be called during recording.
+func (p *Proxy) ScrubResponseBodies(f BodyScrubber) {
+	p.conv.ResponseBodyScrubbers = append(p.conv.ResponseBodyScrubbers, f)
+}
+
 // IgnoreHeader will cause h to be ignored during matching on replay.
 // Deprecated: use RemoveRequestHeaders instead.
 func (p *Proxy) IgnoreHeader(h string) {
@@ -43,6 +43,7 @@ func ForReplaying(filename string, port int) (*Proxy, error) {
 		return nil, err
 	}
 	p.Initial = lg.Initial
+	p.conv = lg.Converter
 	p.mproxy.SetRoundTripper(&replayRoundTripper{
 		calls:         calls,
 		ignoreHeaders: p.ignoreHeaders,
@@ -0,0 +1,76 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpreplay
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// ClearJSONFields returns a function for Recorder.ScrubRequestBodies and
+// Recorder.ScrubResponseBodies that replaces the values of the fields of
+// JSON objects whose names match any of the patterns with CLEARED, at any
+// depth. Bodies that are not JSON are returned unchanged. A JSON body is
+// returned re-encoded, with the fields of its objects sorted.
+//
+// Pattern is taken literally except for *, which matches any sequence of characters.
+func ClearJSONFields(patterns ...string) func(mediaType string, body []byte) []byte {
+	var res []*regexp.Regexp
+	for _, p := range patterns {
+		q := "^" + strings.Replace(regexp.QuoteMeta(p), `\*`, `.*`, -1) + "$"
+		res = append(res, regexp.MustCompile(q))
+	}
+	match := func(name string) bool {
+		for _, re := range res {
+			if re.MatchString(name) {
+				return true
+			}
+		}
+		return false
+	}
+	return func(_ string, body []byte) []byte {
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err != nil || dec.More() {
+			return body
+		}
+		b, err := json.Marshal(clearFields(v, match))
+		if err != nil {
+			return body
+		}
+		return b
+	}
+}
+
+func clearFields(v interface{}, match func(string) bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, x := range v {
+			if match(k) {
+				v[k] = "CLEARED"
+			} else {
+				v[k] = clearFields(x, match)
+			}
+		}
+	case []interface{}:
+		for i, x := range v {
+			v[i] = clearFields(x, match)
+		}
+	}
+	return v
+}
@@ -0,0 +1,130 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpreplay_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/httpreplay"
+	"google.golang.org/api/option"
+)
+
+func TestClearJSONFields(t *testing.T) {
+	f := httpreplay.ClearJSONFields("password", "*Token")
+	for _, test := range []struct {
+		in, want string
+	}{
+		{`{"user":"u","password":"p"}`, `{"password":"CLEARED","user":"u"}`},
+		{`{"a":[{"idToken":"x","n":1.50}],"refreshToken":{"b":1}}`, `{"a":[{"idToken":"CLEARED","n":1.50}],"refreshToken":"CLEARED"}`},
+		{`not json`, `not json`},
+		{`{} {}`, `{} {}`},
+	} {
+		if got := string(f("application/json", []byte(test.in))); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.in, got, test.want)
+		}
+	}
+}
+
+func TestScrubBodies(t *testing.T) {
+	log.SetOutput(io.Discard)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		io.WriteString(w, `{"name":"n","accessToken":"secret"}`)
+	}))
+	defer srv.Close()
+
+	replayFilename := tempFilename(t, "TestScrubBodies*.replay")
+	defer os.Remove(replayFilename)
+
+	ctx := context.Background()
+	post := func(hc *http.Client, body string) (string, error) {
+		res, err := hc.Post(srv.URL, "application/json", strings.NewReader(body))
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+		if res.StatusCode != 200 {
+			return "", nil
+		}
+		b, err := io.ReadAll(res.Body)
+		return string(b), err
+	}
+
+	// Record
+	rec, err := httpreplay.NewRecorder(replayFilename, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.ScrubRequestBodies(httpreplay.ClearJSONFields("password"))
+	rec.ScrubResponseBodies(httpreplay.ClearJSONFields("*Token"))
+	hc, err := rec.Client(ctx, option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := post(hc, `{"user":"u","password":"p1"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"name":"n","accessToken":"secret"}`; got != want {
+		t.Errorf("recording: got %s, want %s", got, want)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(replayFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"p1", "secret"} {
+		if bytes.Contains(data, []byte(secret)) {
+			t.Errorf("log contains %q", secret)
+		}
+	}
+
+	// Replay
+	for _, test := range []struct {
+		body string
+		want string
+	}{
+		{`{"user":"u","password":"p2"}`, `{"accessToken":"CLEARED","name":"n"}`}, // different password is OK
+		{`{"user":"v","password":"p1"}`, ""},                                     // different user
+	} {
+		rep, err := httpreplay.NewReplayer(replayFilename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rep.ScrubRequestBodies(httpreplay.ClearJSONFields("password"))
+		hc, err := rep.Client(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := post(hc, test.body)
+		rep.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", test.body, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	pb "cloud.google.com/go/iam/apiv1/iampb"
+	gax "github.com/googleapis/gax-go/v2"
+	"google.golang.org/genproto/googleapis/type/expr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Limits of IAM conditions, see
+// https://cloud.google.com/iam/docs/conditions-overview#limitations.
+const (
+	maxConditionTitleLength       = 100
+	maxConditionDescriptionLength = 256
+	maxConditionExpressionLength  = 12800
+)
+
+// A ConditionExpr is a Common Expression Language (CEL) expression of an IAM
+// condition. ConditionExprs can be built with the functions of this package,
+// such as RequestTimeBefore and ResourceNameStartsWith, and combined with
+// AllOf, AnyOf and NotExpr. Any other CEL expression supported by IAM can be
+// used by converting its string to a ConditionExpr.
+//
+// See https://cloud.google.com/iam/docs/conditions-attribute-reference for the
+// attributes that conditions can use.
+type ConditionExpr string
+
+// RequestTimeBefore returns an expression that is true for requests made
+// before t.
+func RequestTimeBefore(t time.Time) ConditionExpr {
+	return ConditionExpr("request.time < timestamp(" + strconv.Quote(t.UTC().Format(time.RFC3339)) + ")")
+}
+
+// RequestTimeAfter returns an expression that is true for requests made after
+// t.
+func RequestTimeAfter(t time.Time) ConditionExpr {
+	return ConditionExpr("request.time > timestamp(" + strconv.Quote(t.UTC().Format(time.RFC3339)) + ")")
+}
+
+// ResourceNameStartsWith returns an expression that is true for the resources
+// whose name starts with prefix, such as
+// "projects/_/buckets/my-bucket/objects/reports/".
+func ResourceNameStartsWith(prefix string) ConditionExpr {
+	return ConditionExpr("resource.name.startsWith(" + strconv.Quote(prefix) + ")")
+}
+
+// ResourceTypeIs returns an expression that is true for the resources of type
+// t, such as "storage.googleapis.com/Object".
+func ResourceTypeIs(t string) ConditionExpr {
+	return ConditionExpr("resource.type == " + strconv.Quote(t))
+}
+
+// ResourceServiceIs returns an expression that is true for the resources of
+// the service s, such as "storage.googleapis.com".
+func ResourceServiceIs(s string) ConditionExpr {
+	return ConditionExpr("resource.service == " + strconv.Quote(s))
+}
+
+// AllOf returns an expression that is true if all of exprs are true.
+func AllOf(exprs ...ConditionExpr) ConditionExpr { return joinExprs(" && ", exprs) }
+
+// AnyOf returns an expression that is true if any of exprs is true.
+func AnyOf(exprs ...ConditionExpr) ConditionExpr { return joinExprs(" || ", exprs) }
+
+// NotExpr returns an expression that is true if e is false.
+func NotExpr(e ConditionExpr) ConditionExpr { return ConditionExpr("!(" + string(e) + ")") }
+
+func joinExprs(op string, exprs []ConditionExpr) ConditionExpr {
+	if len(exprs) == 1 {
+		return exprs[0]
+	}
+	parts := make([]string, len(exprs))
+	for i, e := range exprs {
+		parts[i] = "(" + string(e) + ")"
+	}
+	return ConditionExpr(strings.Join(parts, op))
+}
+
+// A Condition restricts a role binding to the requests for which its
+// expression is true.
+type Condition struct {
+	// Title is a short name for the condition. It is required.
+	Title string
+
+	// Description optionally describes the condition.
+	Description string
+
+	// Expression is the expression of the condition. It is required.
+	Expression ConditionExpr
+}
+
+// Validate checks that c respects the limits of IAM conditions, and that its
+// expression is well formed as far as parentheses and string literals go. A
+// Condition that passes Validate can still be rejected by IAM if its
+// expression is not valid.
+func (c Condition) Validate() error {
+	switch {
+	case c.Title == "":
+		return errors.New("iam: condition title is required")
+	case utf8.RuneCountInString(c.Title) > maxConditionTitleLength:
+		return fmt.Errorf("iam: condition title is longer than %d characters", maxConditionTitleLength)
+	case utf8.RuneCountInString(c.Description) > maxConditionDescriptionLength:
+		return fmt.Errorf("iam: condition description is longer than %d characters", maxConditionDescriptionLength)
+	case c.Expression == "":
+		return errors.New("iam: condition expression is required")
+	case len(c.Expression) > maxConditionExpressionLength:
+		return fmt.Errorf("iam: condition expression is longer than %d characters", maxConditionExpressionLength)
+	}
+	return checkExprSyntax(string(c.Expression))
+}
+
+// checkExprSyntax checks that the parentheses of e are balanced and its string
+// literals terminated.
+func checkExprSyntax(e string) error {
+	depth := 0
+	var quote rune
+	escaped := false
+	for _, r := range e {
+		switch {
+		case quote != 0:
+			switch {
+			case escaped:
+				escaped = false
+			case r == '\\':
+				escaped = true
+			case r == quote:
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+			if depth < 0 {
+				return fmt.Errorf("iam: unbalanced parentheses in condition expression %q", e)
+			}
+		}
+	}
+	if quote != 0 {
+		return fmt.Errorf("iam: unterminated string in condition expression %q", e)
+	}
+	if depth != 0 {
+		return fmt.Errorf("iam: unbalanced parentheses in condition expression %q", e)
+	}
+	return nil
+}
+
+func (c Condition) proto() *expr.Expr {
+	return &expr.Expr{
+		Title:       c.Title,
+		Description: c.Description,
+		Expression:  string(c.Expression),
+	}
+}
+
+// sameCondition reports whether the condition of a binding is c.
+func sameCondition(e *expr.Expr, c *Condition) bool {
+	if e == nil || c == nil {
+		return e == nil && c == nil
+	}
+	return e.Title == c.Title && e.Description == c.Description && e.Expression == string(c.Expression)
+}
+
+// conditionalBinding returns the binding of p for role r with condition c,
+// which is nil for an unconditional binding, or nil if there is none.
+func (p *Policy3) conditionalBinding(r RoleName, c *Condition) *pb.Binding {
+	for _, b := range p.Bindings {
+		if b.Role == string(r) && sameCondition(b.Condition, c) {
+			return b
+		}
+	}
+	return nil
+}
+
+// AddConditional grants role r to member under condition c. A new binding is
+// created if there is no binding for the role with the same condition. It
+// returns an error if c is not valid.
+func (p *Policy3) AddConditional(member string, r RoleName, c Condition) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	b := p.conditionalBinding(r, &c)
+	if b == nil {
+		p.Bindings = append(p.Bindings, &pb.Binding{
+			Role:      string(r),
+			Members:   []string{member},
+			Condition: c.proto(),
+		})
+		return nil
+	}
+	if memberIndex(member, b) < 0 {
+		b.Members = append(b.Members, member)
+	}
+	return nil
+}
+
+// RemoveConditional revokes role r from member under condition c. The binding
+// is removed if it has no members left.
+func (p *Policy3) RemoveConditional(member string, r RoleName, c Condition) {
+	for i, b := range p.Bindings {
+		if b.Role != string(r) || !sameCondition(b.Condition, &c) {
+			continue
+		}
+		mi := memberIndex(member, b)
+		if mi < 0 {
+			return
+		}
+		b.Members = append(b.Members[:mi], b.Members[mi+1:]...)
+		if len(b.Members) == 0 {
+			p.Bindings = append(p.Bindings[:i], p.Bindings[i+1:]...)
+		}
+		return
+	}
+}
+
+// maxModifyAttempts is the number of times Modify reads and writes the policy
+// before giving up on concurrent modifications.
+const maxModifyAttempts = 10
+
+// modifyBackoff is the backoff between the attempts of Modify. It is a
+// variable for testing.
+var modifyBackoff = gax.Backoff{
+	Initial:    100 * time.Millisecond,
+	Max:        10 * time.Second,
+	Multiplier: 2,
+}
+
+// Modify reads the policy of the resource, calls f to modify it, and writes
+// it back. If the policy was modified concurrently since it was read, which
+// makes the write fail because of its etag, Modify starts over by reading the
+// policy again, so f may be called several times. If f returns an error,
+// Modify returns it without writing the policy.
+func (h *Handle3) Modify(ctx context.Context, f func(*Policy3) error) error {
+	bo := modifyBackoff
+	for attempt := 1; ; attempt++ {
+		p, err := h.Policy(ctx)
+		if err != nil {
+			return err
+		}
+		if err := f(p); err != nil {
+			return err
+		}
+		err = h.SetPolicy(ctx, p)
+		if status.Code(err) != codes.Aborted || attempt == maxModifyAttempts {
+			return err
+		}
+		if err := gax.Sleep(ctx, bo.Pause()); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	pb "cloud.google.com/go/iam/apiv1/iampb"
+	"cloud.google.com/go/internal/testutil"
+	gax "github.com/googleapis/gax-go/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestConditionExpr(t *testing.T) {
+	ts := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		in   ConditionExpr
+		want string
+	}{
+		{RequestTimeBefore(ts), `request.time < timestamp("2024-06-01T00:00:00Z")`},
+		{RequestTimeAfter(ts), `request.time > timestamp("2024-06-01T00:00:00Z")`},
+		{ResourceNameStartsWith("projects/_/buckets/b/objects/a/"), `resource.name.startsWith("projects/_/buckets/b/objects/a/")`},
+		{ResourceTypeIs("storage.googleapis.com/Object"), `resource.type == "storage.googleapis.com/Object"`},
+		{ResourceServiceIs("storage.googleapis.com"), `resource.service == "storage.googleapis.com"`},
+		{AllOf(ResourceServiceIs("s")), `resource.service == "s"`},
+		{
+			AllOf(ResourceServiceIs("s"), AnyOf(ResourceTypeIs("a"), ResourceTypeIs("b"))),
+			`(resource.service == "s") && ((resource.type == "a") || (resource.type == "b"))`,
+		},
+		{NotExpr(ResourceTypeIs("a")), `!(resource.type == "a")`},
+	} {
+		if got := string(test.in); got != test.want {
+			t.Errorf("got  %s\nwant %s", got, test.want)
+		}
+	}
+}
+
+func TestConditionValidate(t *testing.T) {
+	valid := Condition{Title: "expires", Expression: RequestTimeBefore(time.Now())}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("got error %v, want nil", err)
+	}
+	for _, c := range []Condition{
+		{Expression: "true"},
+		{Title: strings.Repeat("t", 101), Expression: "true"},
+		{Title: "t", Description: strings.Repeat("d", 257), Expression: "true"},
+		{Title: "t"},
+		{Title: "t", Expression: `resource.name.startsWith("a"`},
+		{Title: "t", Expression: `resource.name.startsWith("a))`},
+		{Title: "t", Expression: `resource.type == "a")`},
+	} {
+		if err := c.Validate(); err == nil {
+			t.Errorf("%+v: got no error", c)
+		}
+	}
+	// Parentheses and escaped quotes in strings are ignored.
+	ok := Condition{Title: "t", Expression: ConditionExpr(`resource.name.startsWith(` + strconv.Quote(`a)"b`) + `)`)}
+	if err := ok.Validate(); err != nil {
+		t.Errorf("got error %v, want nil", err)
+	}
+}
+
+func TestPolicy3Conditional(t *testing.T) {
+	c1 := Condition{Title: "c1", Expression: ResourceTypeIs("a")}
+	c2 := Condition{Title: "c2", Expression: ResourceTypeIs("b")}
+	p := &Policy3{Bindings: []*pb.Binding{{Role: string(Viewer), Members: []string{"m0"}}}}
+	for _, add := range []struct {
+		member string
+		c      Condition
+	}{{"m1", c1}, {"m2", c1}, {"m1", c1}, {"m3", c2}} {
+		if err := p.AddConditional(add.member, Viewer, add.c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.AddConditional("m4", Viewer, Condition{Title: "bad"}); err == nil {
+		t.Error("got no error adding an invalid condition")
+	}
+	want := []*pb.Binding{
+		{Role: string(Viewer), Members: []string{"m0"}},
+		{Role: string(Viewer), Members: []string{"m1", "m2"}, Condition: c1.proto()},
+		{Role: string(Viewer), Members: []string{"m3"}, Condition: c2.proto()},
+	}
+	if !testutil.Equal(p.Bindings, want) {
+		t.Fatalf("got %v, want %v", p.Bindings, want)
+	}
+	p.RemoveConditional("m1", Viewer, c1)
+	p.RemoveConditional("m3", Viewer, c2)
+	p.RemoveConditional("m0", Viewer, c1)
+	want = []*pb.Binding{
+		{Role: string(Viewer), Members: []string{"m0"}},
+		{Role: string(Viewer), Members: []string{"m2"}, Condition: c1.proto()},
+	}
+	if !testutil.Equal(p.Bindings, want) {
+		t.Errorf("got %v, want %v", p.Bindings, want)
+	}
+}
+
+// fakeClient is a client that stores a policy and checks etags like IAM.
+type fakeClient struct {
+	policy *pb.Policy
+	// conflicts is the number of writes to fail as if the policy was
+	// modified concurrently.
+	conflicts int
+	sets      int
+}
+
+func (c *fakeClient) Get(ctx context.Context, resource string) (*pb.Policy, error) {
+	return c.GetWithVersion(ctx, resource, 1)
+}
+
+func (c *fakeClient) GetWithVersion(_ context.Context, _ string, _ int32) (*pb.Policy, error) {
+	return proto.Clone(c.policy).(*pb.Policy), nil
+}
+
+func (c *fakeClient) Set(_ context.Context, _ string, p *pb.Policy) error {
+	c.sets++
+	if c.conflicts > 0 {
+		c.conflicts--
+		c.policy.Etag = append(c.policy.Etag, 'x')
+	}
+	if !bytes.Equal(p.Etag, c.policy.Etag) {
+		return status.Error(codes.Aborted, "etag mismatch")
+	}
+	c.policy = proto.Clone(p).(*pb.Policy)
+	c.policy.Etag = append(c.policy.Etag, 'y')
+	return nil
+}
+
+func (c *fakeClient) Test(context.Context, string, []string) ([]string, error) {
+	return nil, nil
+}
+
+func TestModify(t *testing.T) {
+	oldBackoff := modifyBackoff
+	defer func() { modifyBackoff = oldBackoff }()
+	modifyBackoff = gax.Backoff{Initial: time.Millisecond, Max: time.Millisecond}
+
+	ctx := context.Background()
+	fc := &fakeClient{policy: &pb.Policy{Etag: []byte("e")}, conflicts: 2}
+	h := InternalNewHandleClient(fc, "resource").V3()
+	calls := 0
+	err := h.Modify(ctx, func(p *Policy3) error {
+		calls++
+		return p.AddConditional("user:a@example.com", Editor, Condition{Title: "t", Expression: ResourceTypeIs("a")})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 || fc.sets != 3 {
+		t.Errorf("got %d calls and %d writes, want 3 of each", calls, fc.sets)
+	}
+	if len(fc.policy.Bindings) != 1 || fc.policy.Version != 3 {
+		t.Errorf("got policy %v, want one binding and version 3", fc.policy)
+	}
+
+	errF := errors.New("no change")
+	fc.sets = 0
+	if err := h.Modify(ctx, func(*Policy3) error { return errF }); err != errF {
+		t.Errorf("got %v, want %v", err, errF)
+	}
+	if fc.sets != 0 {
+		t.Errorf("got %d writes, want none", fc.sets)
+	}
+
+	fc.sets, fc.conflicts = 0, maxModifyAttempts
+	err = h.Modify(ctx, func(*Policy3) error { return nil })
+	if status.Code(err) != codes.Aborted || fc.sets != maxModifyAttempts {
+		t.Errorf("got error %v after %d writes, want Aborted after %d", err, fc.sets, maxModifyAttempts)
+	}
+}
@@ -0,0 +1,313 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package kmscrypto provides implementations of crypto.Signer and
+// crypto.Decrypter backed by Cloud KMS asymmetric keys, so that code written
+// against the standard crypto interfaces, such as crypto/tls, crypto/x509 or
+// JWT libraries, can use keys held in Cloud KMS.
+//
+// The private key never leaves Cloud KMS: each call to Sign or Decrypt makes a
+// request to Cloud KMS. The public key is fetched once, when the Signer or
+// Decrypter is created. Requests and responses are checked for corruption in
+// transit with CRC32C checksums, as described in
+// https://cloud.google.com/kms/docs/data-integrity-guidelines.
+//
+// Example:
+//
+//	client, err := kms.NewKeyManagementClient(ctx)
+//	if err != nil {
+//		// TODO: Handle error.
+//	}
+//	signer, err := kmscrypto.NewSigner(ctx, client, "projects/my-project/locations/global/keyRings/my-ring/cryptoKeys/my-key/cryptoKeyVersions/1")
+//	if err != nil {
+//		// TODO: Handle error.
+//	}
+//	cert, err := x509.CreateCertificate(rand.Reader, template, template, signer.Public(), signer)
+package kmscrypto // import "cloud.google.com/go/kms/kmscrypto"
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"cloud.google.com/go/kms/apiv1/kmspb"
+	gax "github.com/googleapis/gax-go/v2"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// Client is the subset of the methods of kms.KeyManagementClient used by
+// Signer and Decrypter.
+type Client interface {
+	GetPublicKey(context.Context, *kmspb.GetPublicKeyRequest, ...gax.CallOption) (*kmspb.PublicKey, error)
+	AsymmetricSign(context.Context, *kmspb.AsymmetricSignRequest, ...gax.CallOption) (*kmspb.AsymmetricSignResponse, error)
+	AsymmetricDecrypt(context.Context, *kmspb.AsymmetricDecryptRequest, ...gax.CallOption) (*kmspb.AsymmetricDecryptResponse, error)
+}
+
+// errCorrupted is returned when a checksum shows that a request or a response
+// was corrupted in transit. The operation can be retried.
+var errCorrupted = errors.New("kmscrypto: request or response corrupted in transit")
+
+type algorithmInfo struct {
+	hash crypto.Hash
+	pss  bool
+}
+
+var signAlgorithms = map[kmspb.CryptoKeyVersion_CryptoKeyVersionAlgorithm]algorithmInfo{
+	kmspb.CryptoKeyVersion_RSA_SIGN_PSS_2048_SHA256:   {crypto.SHA256, true},
+	kmspb.CryptoKeyVersion_RSA_SIGN_PSS_3072_SHA256:   {crypto.SHA256, true},
+	kmspb.CryptoKeyVersion_RSA_SIGN_PSS_4096_SHA256:   {crypto.SHA256, true},
+	kmspb.CryptoKeyVersion_RSA_SIGN_PSS_4096_SHA512:   {crypto.SHA512, true},
+	kmspb.CryptoKeyVersion_RSA_SIGN_PKCS1_2048_SHA256: {crypto.SHA256, false},
+	kmspb.CryptoKeyVersion_RSA_SIGN_PKCS1_3072_SHA256: {crypto.SHA256, false},
+	kmspb.CryptoKeyVersion_RSA_SIGN_PKCS1_4096_SHA256: {crypto.SHA256, false},
+	kmspb.CryptoKeyVersion_RSA_SIGN_PKCS1_4096_SHA512: {crypto.SHA512, false},
+	kmspb.CryptoKeyVersion_EC_SIGN_P256_SHA256:        {crypto.SHA256, false},
+	kmspb.CryptoKeyVersion_EC_SIGN_P384_SHA384:        {crypto.SHA384, false},
+}
+
+var decryptAlgorithms = map[kmspb.CryptoKeyVersion_CryptoKeyVersionAlgorithm]crypto.Hash{
+	kmspb.CryptoKeyVersion_RSA_DECRYPT_OAEP_2048_SHA256: crypto.SHA256,
+	kmspb.CryptoKeyVersion_RSA_DECRYPT_OAEP_3072_SHA256: crypto.SHA256,
+	kmspb.CryptoKeyVersion_RSA_DECRYPT_OAEP_4096_SHA256: crypto.SHA256,
+	kmspb.CryptoKeyVersion_RSA_DECRYPT_OAEP_4096_SHA512: crypto.SHA512,
+	kmspb.CryptoKeyVersion_RSA_DECRYPT_OAEP_2048_SHA1:   crypto.SHA1,
+	kmspb.CryptoKeyVersion_RSA_DECRYPT_OAEP_3072_SHA1:   crypto.SHA1,
+	kmspb.CryptoKeyVersion_RSA_DECRYPT_OAEP_4096_SHA1:   crypto.SHA1,
+}
+
+// A Signer is a crypto.Signer that signs digests with a Cloud KMS asymmetric
+// signing key version. It is safe for concurrent use.
+type Signer struct {
+	client    Client
+	name      string
+	algorithm kmspb.CryptoKeyVersion_CryptoKeyVersionAlgorithm
+	info      algorithmInfo
+	public    crypto.PublicKey
+}
+
+// NewSigner returns a Signer for the key version with the given resource name,
+// of the form
+// "projects/*/locations/*/keyRings/*/cryptoKeys/*/cryptoKeyVersions/*". It
+// fetches the public key of the key version. The key version must have an RSA
+// or NIST elliptic curve signing algorithm.
+func NewSigner(ctx context.Context, client Client, name string) (*Signer, error) {
+	pk, pub, err := getPublicKey(ctx, client, name)
+	if err != nil {
+		return nil, err
+	}
+	info, ok := signAlgorithms[pk.Algorithm]
+	if !ok {
+		return nil, fmt.Errorf("kmscrypto: key version %s has unsupported signing algorithm %v", name, pk.Algorithm)
+	}
+	return &Signer{
+		client:    client,
+		name:      name,
+		algorithm: pk.Algorithm,
+		info:      info,
+		public:    pub,
+	}, nil
+}
+
+// Algorithm returns the algorithm of the key version.
+func (s *Signer) Algorithm() kmspb.CryptoKeyVersion_CryptoKeyVersionAlgorithm {
+	return s.algorithm
+}
+
+// Public returns the public key of the key version, an *rsa.PublicKey or an
+// *ecdsa.PublicKey.
+func (s *Signer) Public() crypto.PublicKey {
+	return s.public
+}
+
+// Sign signs digest with the key version. It calls SignContext with a
+// background context; rand is ignored.
+//
+// The hash function of opts must be the one of the algorithm of the key
+// version. For RSA-PSS algorithms, opts must be an *rsa.PSSOptions, and for
+// RSA PKCS #1 v1.5 algorithms, it must not be.
+func (s *Signer) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.SignContext(context.Background(), digest, opts)
+}
+
+// SignContext is like Sign, but uses ctx for the request to Cloud KMS.
+func (s *Signer) SignContext(ctx context.Context, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if err := s.checkOpts(digest, opts); err != nil {
+		return nil, err
+	}
+	d := &kmspb.Digest{}
+	switch s.info.hash {
+	case crypto.SHA256:
+		d.Digest = &kmspb.Digest_Sha256{Sha256: digest}
+	case crypto.SHA384:
+		d.Digest = &kmspb.Digest_Sha384{Sha384: digest}
+	case crypto.SHA512:
+		d.Digest = &kmspb.Digest_Sha512{Sha512: digest}
+	}
+	res, err := s.client.AsymmetricSign(ctx, &kmspb.AsymmetricSignRequest{
+		Name:         s.name,
+		Digest:       d,
+		DigestCrc32C: wrapperspb.Int64(checksum(digest)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Name != s.name {
+		return nil, errCorrupted
+	}
+	if !res.VerifiedDigestCrc32C || res.SignatureCrc32C.GetValue() != checksum(res.Signature) {
+		return nil, errCorrupted
+	}
+	return res.Signature, nil
+}
+
+func (s *Signer) checkOpts(digest []byte, opts crypto.SignerOpts) error {
+	if h := opts.HashFunc(); h != s.info.hash {
+		return fmt.Errorf("kmscrypto: hash function %v does not match the %v algorithm of the key version", h, s.algorithm)
+	}
+	if len(digest) != s.info.hash.Size() {
+		return fmt.Errorf("kmscrypto: digest is %d bytes long, want %d", len(digest), s.info.hash.Size())
+	}
+	pssOpts, isPSS := opts.(*rsa.PSSOptions)
+	switch {
+	case isPSS && !s.info.pss:
+		return fmt.Errorf("kmscrypto: RSA-PSS signature requested with the %v key version", s.algorithm)
+	case !isPSS && s.info.pss:
+		return fmt.Errorf("kmscrypto: the %v key version only makes RSA-PSS signatures", s.algorithm)
+	case isPSS:
+		// Cloud KMS uses a salt as long as the digest, which verifiers that
+		// detect the salt length accept too.
+		if l := pssOpts.SaltLength; l != rsa.PSSSaltLengthAuto && l != rsa.PSSSaltLengthEqualsHash && l != s.info.hash.Size() {
+			return fmt.Errorf("kmscrypto: unsupported RSA-PSS salt length %d", l)
+		}
+	}
+	return nil
+}
+
+// A Decrypter is a crypto.Decrypter that decrypts data with a Cloud KMS
+// asymmetric decryption key version, using RSA-OAEP. It is safe for
+// concurrent use.
+type Decrypter struct {
+	client    Client
+	name      string
+	algorithm kmspb.CryptoKeyVersion_CryptoKeyVersionAlgorithm
+	hash      crypto.Hash
+	public    *rsa.PublicKey
+}
+
+// NewDecrypter returns a Decrypter for the key version with the given
+// resource name, of the form
+// "projects/*/locations/*/keyRings/*/cryptoKeys/*/cryptoKeyVersions/*". It
+// fetches the public key of the key version, which must have an RSA
+// decryption algorithm.
+func NewDecrypter(ctx context.Context, client Client, name string) (*Decrypter, error) {
+	pk, pub, err := getPublicKey(ctx, client, name)
+	if err != nil {
+		return nil, err
+	}
+	hash, ok := decryptAlgorithms[pk.Algorithm]
+	if !ok {
+		return nil, fmt.Errorf("kmscrypto: key version %s has unsupported decryption algorithm %v", name, pk.Algorithm)
+	}
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("kmscrypto: key version %s has a %T public key, want an RSA key", name, pub)
+	}
+	return &Decrypter{
+		client:    client,
+		name:      name,
+		algorithm: pk.Algorithm,
+		hash:      hash,
+		public:    rsaPub,
+	}, nil
+}
+
+// Algorithm returns the algorithm of the key version.
+func (d *Decrypter) Algorithm() kmspb.CryptoKeyVersion_CryptoKeyVersionAlgorithm {
+	return d.algorithm
+}
+
+// Public returns the public key of the key version, an *rsa.PublicKey.
+func (d *Decrypter) Public() crypto.PublicKey {
+	return d.public
+}
+
+// Decrypt decrypts ciphertext, encrypted with RSA-OAEP with the public key of
+// the key version. It calls DecryptContext with a background context; rand is
+// ignored.
+//
+// opts may be nil or an *rsa.OAEPOptions whose hash function is the one of
+// the algorithm of the key version and whose label is empty.
+func (d *Decrypter) Decrypt(rand io.Reader, ciphertext []byte, opts crypto.DecrypterOpts) ([]byte, error) {
+	return d.DecryptContext(context.Background(), ciphertext, opts)
+}
+
+// DecryptContext is like Decrypt, but uses ctx for the request to Cloud KMS.
+func (d *Decrypter) DecryptContext(ctx context.Context, ciphertext []byte, opts crypto.DecrypterOpts) ([]byte, error) {
+	switch o := opts.(type) {
+	case nil:
+	case *rsa.OAEPOptions:
+		if o.Hash != d.hash {
+			return nil, fmt.Errorf("kmscrypto: hash function %v does not match the %v algorithm of the key version", o.Hash, d.algorithm)
+		}
+		if len(o.Label) > 0 {
+			return nil, errors.New("kmscrypto: RSA-OAEP labels are not supported")
+		}
+	default:
+		return nil, fmt.Errorf("kmscrypto: unsupported decrypter options %T, want *rsa.OAEPOptions", opts)
+	}
+	res, err := d.client.AsymmetricDecrypt(ctx, &kmspb.AsymmetricDecryptRequest{
+		Name:             d.name,
+		Ciphertext:       ciphertext,
+		CiphertextCrc32C: wrapperspb.Int64(checksum(ciphertext)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !res.VerifiedCiphertextCrc32C || res.PlaintextCrc32C.GetValue() != checksum(res.Plaintext) {
+		return nil, errCorrupted
+	}
+	return res.Plaintext, nil
+}
+
+// getPublicKey fetches and parses the public key of the key version name.
+func getPublicKey(ctx context.Context, client Client, name string) (*kmspb.PublicKey, crypto.PublicKey, error) {
+	pk, err := client.GetPublicKey(ctx, &kmspb.GetPublicKeyRequest{Name: name})
+	if err != nil {
+		return nil, nil, err
+	}
+	if pk.Name != name || pk.PemCrc32C.GetValue() != checksum([]byte(pk.Pem)) {
+		return nil, nil, errCorrupted
+	}
+	block, _ := pem.Decode([]byte(pk.Pem))
+	if block == nil {
+		return nil, nil, fmt.Errorf("kmscrypto: invalid PEM public key for key version %s", name)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("kmscrypto: parsing public key of key version %s: %w", name, err)
+	}
+	return pk, pub, nil
+}
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+func checksum(b []byte) int64 {
+	return int64(crc32.Checksum(b, crc32cTable))
+}
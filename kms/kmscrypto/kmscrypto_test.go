@@ -0,0 +1,206 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kmscrypto
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"cloud.google.com/go/kms/apiv1/kmspb"
+	gax "github.com/googleapis/gax-go/v2"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const keyName = "projects/p/locations/global/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1"
+
+// fakeClient implements Client with a local private key.
+type fakeClient struct {
+	key       crypto.Signer
+	algorithm kmspb.CryptoKeyVersion_CryptoKeyVersionAlgorithm
+	// corrupt makes the responses have bad checksums.
+	corrupt bool
+}
+
+func (c *fakeClient) GetPublicKey(_ context.Context, req *kmspb.GetPublicKeyRequest, _ ...gax.CallOption) (*kmspb.PublicKey, error) {
+	der, err := x509.MarshalPKIXPublicKey(c.key.Public())
+	if err != nil {
+		return nil, err
+	}
+	p := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	return &kmspb.PublicKey{
+		Name:      req.Name,
+		Pem:       p,
+		PemCrc32C: wrapperspb.Int64(checksum([]byte(p))),
+		Algorithm: c.algorithm,
+	}, nil
+}
+
+func (c *fakeClient) AsymmetricSign(_ context.Context, req *kmspb.AsymmetricSignRequest, _ ...gax.CallOption) (*kmspb.AsymmetricSignResponse, error) {
+	digest := req.Digest.GetSha256()
+	var opts crypto.SignerOpts = crypto.SHA256
+	if info := signAlgorithms[c.algorithm]; info.pss {
+		opts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256}
+	}
+	sig, err := c.key.Sign(rand.Reader, digest, opts)
+	if err != nil {
+		return nil, err
+	}
+	crc := checksum(sig)
+	if c.corrupt {
+		crc++
+	}
+	return &kmspb.AsymmetricSignResponse{
+		Name:                 req.Name,
+		Signature:            sig,
+		SignatureCrc32C:      wrapperspb.Int64(crc),
+		VerifiedDigestCrc32C: req.DigestCrc32C.GetValue() == checksum(digest),
+	}, nil
+}
+
+func (c *fakeClient) AsymmetricDecrypt(_ context.Context, req *kmspb.AsymmetricDecryptRequest, _ ...gax.CallOption) (*kmspb.AsymmetricDecryptResponse, error) {
+	pt, err := rsa.DecryptOAEP(sha256.New(), nil, c.key.(*rsa.PrivateKey), req.Ciphertext, nil)
+	if err != nil {
+		return nil, err
+	}
+	crc := checksum(pt)
+	if c.corrupt {
+		crc++
+	}
+	return &kmspb.AsymmetricDecryptResponse{
+		Plaintext:                pt,
+		PlaintextCrc32C:          wrapperspb.Int64(crc),
+		VerifiedCiphertextCrc32C: req.CiphertextCrc32C.GetValue() == checksum(req.Ciphertext),
+	}, nil
+}
+
+func TestSignerECDSA(t *testing.T) {
+	ctx := context.Background()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &fakeClient{key: key, algorithm: kmspb.CryptoKeyVersion_EC_SIGN_P256_SHA256}
+	s, err := NewSigner(ctx, c, keyName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !key.PublicKey.Equal(s.Public()) {
+		t.Errorf("got public key %v, want %v", s.Public(), key.PublicKey)
+	}
+	digest := sha256.Sum256([]byte("hello"))
+	sig, err := s.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ecdsa.VerifyASN1(&key.PublicKey, digest[:], sig) {
+		t.Error("signature does not verify")
+	}
+
+	if _, err := s.Sign(rand.Reader, digest[:], crypto.SHA384); err == nil {
+		t.Error("got no error signing with the wrong hash function")
+	}
+	if _, err := s.Sign(rand.Reader, digest[:4], crypto.SHA256); err == nil {
+		t.Error("got no error signing a digest of the wrong length")
+	}
+	c.corrupt = true
+	if _, err := s.Sign(rand.Reader, digest[:], crypto.SHA256); err != errCorrupted {
+		t.Errorf("got error %v, want %v", err, errCorrupted)
+	}
+}
+
+func TestSignerRSAPSS(t *testing.T) {
+	ctx := context.Background()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &fakeClient{key: key, algorithm: kmspb.CryptoKeyVersion_RSA_SIGN_PSS_2048_SHA256}
+	s, err := NewSigner(ctx, c, keyName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte("hello"))
+	opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256}
+	sig, err := s.Sign(rand.Reader, digest[:], opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rsa.VerifyPSS(&key.PublicKey, crypto.SHA256, digest[:], sig, opts); err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+	if _, err := s.Sign(rand.Reader, digest[:], crypto.SHA256); err == nil {
+		t.Error("got no error requesting a PKCS #1 v1.5 signature from an RSA-PSS key")
+	}
+}
+
+func TestNewSignerUnsupportedAlgorithm(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &fakeClient{key: key, algorithm: kmspb.CryptoKeyVersion_RSA_DECRYPT_OAEP_2048_SHA256}
+	if _, err := NewSigner(context.Background(), c, keyName); err == nil {
+		t.Error("got no error creating a signer for a decryption key")
+	}
+}
+
+func TestDecrypter(t *testing.T) {
+	ctx := context.Background()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &fakeClient{key: key, algorithm: kmspb.CryptoKeyVersion_RSA_DECRYPT_OAEP_2048_SHA256}
+	d, err := NewDecrypter(ctx, c, keyName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte("secret")
+	ct, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, d.Public().(*rsa.PublicKey), want, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, opts := range []crypto.DecrypterOpts{nil, &rsa.OAEPOptions{Hash: crypto.SHA256}} {
+		got, err := d.Decrypt(rand.Reader, ct, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+	for _, opts := range []crypto.DecrypterOpts{
+		&rsa.OAEPOptions{Hash: crypto.SHA1},
+		&rsa.OAEPOptions{Hash: crypto.SHA256, Label: []byte("label")},
+		&rsa.PKCS1v15DecryptOptions{},
+	} {
+		if _, err := d.Decrypt(rand.Reader, ct, opts); err == nil {
+			t.Errorf("got no error decrypting with options %+v", opts)
+		}
+	}
+	c.corrupt = true
+	if _, err := d.Decrypt(rand.Reader, ct, nil); err != errCorrupted {
+		t.Errorf("got error %v, want %v", err, errCorrupted)
+	}
+}
@@ -71,6 +71,7 @@ ingesting them to Cloud Logging service. Usually, you will use os.Stdout or os.S
 writers because Google Cloud logging agents are configured to capture logs from standard output.
 The entries will be Jsonified and wrote as one line strings following the structured logging format.
 See https://cloud.google.com/logging/docs/structured-logging#special-payload-fields for the format description.
+The common labels of the Logger are written with the labels of each entry.
 To instruct Logger to redirect log entries add RedirectAsJSON() LoggerOption`s.
 
 	// Create a logger to print structured logs formatted as a single line Json to stdout
@@ -111,6 +112,15 @@ You may want use a standard log.Logger in your program.
 	stdlg := lg.StandardLogger(logging.Info)
 	stdlg.Println("some info")
 
+# Structured Logging with slog
+
+With Go 1.21 or later, NewSlogHandler lets a log/slog Logger write to Cloud
+Logging. Attributes become fields of the JSON payload of the entries, and the
+trace of the OpenTelemetry span in the context, if any, is attached to them.
+
+	slg := slog.New(logging.NewSlogHandler(lg, logging.SlogLabels("env")))
+	slg.InfoContext(ctx, "request handled", "env", "prod", "status", 200)
+
 # Log Levels
 
 An Entry may have one of a number of severity levels associated with it.
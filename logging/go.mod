@@ -12,6 +12,7 @@ require (
 	github.com/google/go-cmp v0.6.0
 	github.com/googleapis/gax-go/v2 v2.12.1
 	go.opencensus.io v0.24.0
+	go.opentelemetry.io/otel/trace v1.23.0
 	golang.org/x/oauth2 v0.17.0
 	google.golang.org/api v0.166.0
 	google.golang.org/genproto v0.0.0-20240213162025-012b6fc9bca9
@@ -35,7 +36,6 @@ require (
 	go.opentelemetry.io/otel v1.23.0 // indirect
 	go.opentelemetry.io/otel/metric v1.23.0 // indirect
 	go.opentelemetry.io/otel/sdk v1.21.0 // indirect
-	go.opentelemetry.io/otel/trace v1.23.0 // indirect
 	golang.org/x/crypto v0.19.0 // indirect
 	golang.org/x/net v0.21.0 // indirect
 	golang.org/x/sync v0.6.0 // indirect
@@ -45,8 +45,9 @@ type loggingHandler struct {
 type configHandler struct {
 	logpb.ConfigServiceV2Server
 
-	mu    sync.Mutex
-	sinks map[string]*logpb.LogSink // indexed by (full) sink name
+	mu         sync.Mutex
+	sinks      map[string]*logpb.LogSink      // indexed by (full) sink name
+	exclusions map[string]*logpb.LogExclusion // indexed by (full) exclusion name
 }
 
 type metricHandler struct {
@@ -67,7 +68,8 @@ func NewServer() (string, error) {
 		logs: make(map[string][]*logpb.LogEntry),
 	})
 	logpb.RegisterConfigServiceV2Server(srv.Gsrv, &configHandler{
-		sinks: make(map[string]*logpb.LogSink),
+		sinks:      make(map[string]*logpb.LogSink),
+		exclusions: make(map[string]*logpb.LogExclusion),
 	})
 	logpb.RegisterMetricsServiceV2Server(srv.Gsrv, &metricHandler{
 		metrics: make(map[string]*logpb.LogMetric),
@@ -365,6 +367,82 @@ func (s sinksByName) Len() int           { return len(s) }
 func (s sinksByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 func (s sinksByName) Less(i, j int) bool { return s[i].Name < s[j].Name }
 
+// Gets an exclusion.
+func (h *configHandler) GetExclusion(_ context.Context, req *logpb.GetExclusionRequest) (*logpb.LogExclusion, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if e, ok := h.exclusions[req.Name]; ok {
+		return e, nil
+	}
+	return nil, fmt.Errorf("exclusion %q not found", req.Name)
+}
+
+// Creates an exclusion.
+func (h *configHandler) CreateExclusion(_ context.Context, req *logpb.CreateExclusionRequest) (*logpb.LogExclusion, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fullName := fmt.Sprintf("%s/exclusions/%s", req.Parent, req.Exclusion.Name)
+	if _, ok := h.exclusions[fullName]; ok {
+		return nil, fmt.Errorf("exclusion with name %q already exists", fullName)
+	}
+	h.exclusions[fullName] = req.Exclusion
+	return req.Exclusion, nil
+}
+
+// Updates an exclusion.
+func (h *configHandler) UpdateExclusion(_ context.Context, req *logpb.UpdateExclusionRequest) (*logpb.LogExclusion, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	e := h.exclusions[req.Name]
+	if e == nil {
+		return nil, fmt.Errorf("exclusion %q not found", req.Name)
+	}
+	paths := req.UpdateMask.GetPaths()
+	if len(paths) == 0 {
+		return nil, invalidArgument("empty update mask")
+	}
+	for _, p := range paths {
+		switch p {
+		case "description":
+			e.Description = req.Exclusion.Description
+		case "filter":
+			e.Filter = req.Exclusion.Filter
+		case "disabled":
+			e.Disabled = req.Exclusion.Disabled
+		default:
+			return nil, fmt.Errorf("unknown path in mask: %q", p)
+		}
+	}
+	return e, nil
+}
+
+// Deletes an exclusion.
+func (h *configHandler) DeleteExclusion(_ context.Context, req *logpb.DeleteExclusionRequest) (*emptypb.Empty, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.exclusions, req.Name)
+	return &emptypb.Empty{}, nil
+}
+
+// Lists exclusions. Like ListSinks, it ignores the Parent field of the request.
+func (h *configHandler) ListExclusions(_ context.Context, req *logpb.ListExclusionsRequest) (*logpb.ListExclusionsResponse, error) {
+	h.mu.Lock()
+	var exclusions []*logpb.LogExclusion
+	for _, e := range h.exclusions {
+		exclusions = append(exclusions, e)
+	}
+	h.mu.Unlock()
+	sort.Slice(exclusions, func(i, j int) bool { return exclusions[i].Name < exclusions[j].Name })
+	from, to, nextPageToken, err := testutil.PageBounds(int(req.PageSize), req.PageToken, len(exclusions))
+	if err != nil {
+		return nil, err
+	}
+	return &logpb.ListExclusionsResponse{
+		Exclusions:    exclusions[from:to],
+		NextPageToken: nextPageToken,
+	}, nil
+}
+
 // Gets a metric.
 func (h *metricHandler) GetLogMetric(_ context.Context, req *logpb.GetLogMetricRequest) (*logpb.LogMetric, error) {
 	h.mu.Lock()
@@ -0,0 +1,168 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logadmin
+
+import (
+	"context"
+	"fmt"
+
+	vkit "cloud.google.com/go/logging/apiv2"
+	logpb "cloud.google.com/go/logging/apiv2/loggingpb"
+	"google.golang.org/api/iterator"
+	maskpb "google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// Exclusion describes an exclusion filter. Log entries that match the filter
+// of an enabled exclusion are not stored, and are not counted towards the
+// logs ingestion quota.
+//
+// For more information, see https://cloud.google.com/logging/docs/exclusions.
+type Exclusion struct {
+	// ID is a client-assigned exclusion identifier. Example:
+	// "load-balancer-successes". Exclusion identifiers are limited to 100
+	// characters and can include only letters, digits, underscores, hyphens,
+	// and periods. The first character must be alphanumeric.
+	ID string
+
+	// Description describes this exclusion.
+	Description string
+
+	// Filter is an advanced logs filter (see
+	// https://cloud.google.com/logging/docs/view/advanced_filters) that
+	// matches the log entries to be excluded. Example:
+	// `resource.type="http_load_balancer" AND httpRequest.status<400`.
+	Filter string
+
+	// Disabled stops the exclusion from excluding log entries.
+	Disabled bool
+}
+
+// CreateExclusion creates an exclusion. It returns an error if the exclusion
+// already exists. Requires AdminScope.
+func (c *Client) CreateExclusion(ctx context.Context, e *Exclusion) (*Exclusion, error) {
+	le, err := c.sClient.CreateExclusion(ctx, &logpb.CreateExclusionRequest{
+		Parent:    c.parent,
+		Exclusion: toLogExclusion(e),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fromLogExclusion(le), nil
+}
+
+// DeleteExclusion deletes an exclusion. The provided exclusionID is the
+// exclusion's identifier, such as "load-balancer-successes".
+// Requires AdminScope.
+func (c *Client) DeleteExclusion(ctx context.Context, exclusionID string) error {
+	return c.sClient.DeleteExclusion(ctx, &logpb.DeleteExclusionRequest{
+		Name: c.exclusionPath(exclusionID),
+	})
+}
+
+// Exclusion gets an exclusion. The provided exclusionID is the exclusion's
+// identifier, such as "load-balancer-successes".
+// Requires ReadScope or AdminScope.
+func (c *Client) Exclusion(ctx context.Context, exclusionID string) (*Exclusion, error) {
+	le, err := c.sClient.GetExclusion(ctx, &logpb.GetExclusionRequest{
+		Name: c.exclusionPath(exclusionID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fromLogExclusion(le), nil
+}
+
+// UpdateExclusion updates the description, filter and disabled state of an
+// existing exclusion. Requires AdminScope.
+func (c *Client) UpdateExclusion(ctx context.Context, e *Exclusion) (*Exclusion, error) {
+	le, err := c.sClient.UpdateExclusion(ctx, &logpb.UpdateExclusionRequest{
+		Name:       c.exclusionPath(e.ID),
+		Exclusion:  toLogExclusion(e),
+		UpdateMask: &maskpb.FieldMask{Paths: []string{"description", "filter", "disabled"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fromLogExclusion(le), nil
+}
+
+func (c *Client) exclusionPath(exclusionID string) string {
+	return fmt.Sprintf("%s/exclusions/%s", c.parent, exclusionID)
+}
+
+// Exclusions returns an ExclusionIterator for iterating over all Exclusions
+// in the Client's project. Requires ReadScope or AdminScope.
+func (c *Client) Exclusions(ctx context.Context) *ExclusionIterator {
+	it := &ExclusionIterator{
+		it: c.sClient.ListExclusions(ctx, &logpb.ListExclusionsRequest{Parent: c.parent}),
+	}
+	it.pageInfo, it.nextFunc = iterator.NewPageInfo(
+		it.fetch,
+		func() int { return len(it.items) },
+		func() interface{} { b := it.items; it.items = nil; return b })
+	return it
+}
+
+// An ExclusionIterator iterates over Exclusions.
+type ExclusionIterator struct {
+	it       *vkit.LogExclusionIterator
+	pageInfo *iterator.PageInfo
+	nextFunc func() error
+	items    []*Exclusion
+}
+
+// PageInfo supports pagination. See the google.golang.org/api/iterator package for details.
+func (it *ExclusionIterator) PageInfo() *iterator.PageInfo { return it.pageInfo }
+
+// Next returns the next result. Its second return value is Done if there are
+// no more results. Once Next returns Done, all subsequent calls will return
+// Done.
+func (it *ExclusionIterator) Next() (*Exclusion, error) {
+	if err := it.nextFunc(); err != nil {
+		return nil, err
+	}
+	item := it.items[0]
+	it.items = it.items[1:]
+	return item, nil
+}
+
+func (it *ExclusionIterator) fetch(pageSize int, pageToken string) (string, error) {
+	return iterFetch(pageSize, pageToken, it.it.PageInfo(), func() error {
+		item, err := it.it.Next()
+		if err != nil {
+			return err
+		}
+		it.items = append(it.items, fromLogExclusion(item))
+		return nil
+	})
+}
+
+func toLogExclusion(e *Exclusion) *logpb.LogExclusion {
+	return &logpb.LogExclusion{
+		Name:        e.ID,
+		Description: e.Description,
+		Filter:      e.Filter,
+		Disabled:    e.Disabled,
+	}
+}
+
+func fromLogExclusion(le *logpb.LogExclusion) *Exclusion {
+	return &Exclusion{
+		ID:          le.Name,
+		Description: le.Description,
+		Filter:      le.Filter,
+		Disabled:    le.Disabled,
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logadmin
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/internal/testutil"
+	"cloud.google.com/go/internal/uid"
+	"google.golang.org/api/iterator"
+)
+
+var exclusionIDs = uid.NewSpace("GO-CLIENT-TEST-EXCLUSION", nil)
+
+func TestCreateGetUpdateDeleteExclusion(t *testing.T) {
+	ctx := context.Background()
+	exclusion := &Exclusion{
+		ID:          exclusionIDs.New(),
+		Description: "DESC",
+		Filter:      `logName:"nonexistent-log"`,
+	}
+	got, err := client.CreateExclusion(ctx, exclusion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.DeleteExclusion(ctx, exclusion.ID)
+	if want := exclusion; !testutil.Equal(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	got, err = client.Exclusion(ctx, exclusion.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := exclusion; !testutil.Equal(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	exclusion.Description = "CHANGED"
+	exclusion.Disabled = true
+	got, err = client.UpdateExclusion(ctx, exclusion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := exclusion; !testutil.Equal(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if err := client.DeleteExclusion(ctx, exclusion.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Exclusion(ctx, exclusion.ID); err == nil {
+		t.Fatal("got no error, expected one")
+	}
+}
+
+func TestListExclusions(t *testing.T) {
+	ctx := context.Background()
+	want := map[string]*Exclusion{}
+	for i := 0; i < 3; i++ {
+		e := &Exclusion{
+			ID:          exclusionIDs.New(),
+			Description: "DESC",
+			Filter:      `logName:"nonexistent-log"`,
+			Disabled:    true,
+		}
+		if _, err := client.CreateExclusion(ctx, e); err != nil {
+			t.Fatalf("Create(%q): %v", e.ID, err)
+		}
+		defer client.DeleteExclusion(ctx, e.ID)
+		want[e.ID] = e
+	}
+	got := map[string]*Exclusion{}
+	it := client.Exclusions(ctx)
+	for {
+		e, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		// If tests run simultaneously, we may have more exclusions than we
+		// created. So only check for our own.
+		if _, ok := want[e.ID]; ok {
+			got[e.ID] = e
+		}
+	}
+	if !testutil.Equal(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logadmin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/logging"
+)
+
+// A FilterExpr is an expression of the Logging query language, used as the
+// filter of entries, sinks, metrics and exclusions. FilterExprs are built with
+// the comparison functions of this package, such as Eq and Has, and combined
+// with And, Or and Not. Their String method returns the filter text.
+//
+// See https://cloud.google.com/logging/docs/view/logging-query-language for
+// the query language.
+type FilterExpr struct {
+	text string
+	// op is the operator joining the operands of the expression, "AND" or
+	// "OR", or empty for other expressions.
+	op string
+}
+
+// String returns the filter text of e.
+func (e FilterExpr) String() string { return e.text }
+
+// RawFilter returns an expression with the filter text s, for the parts of
+// the query language not covered by the other functions.
+func RawFilter(s string) FilterExpr {
+	return FilterExpr{text: s}
+}
+
+// Eq returns an expression that matches the entries whose field equals value.
+// The field is a path such as "resource.labels.zone" or
+// "jsonPayload.status". The value is a string, a number, a bool, a time.Time
+// or a logging.Severity.
+func Eq(field string, value interface{}) FilterExpr { return compare(field, "=", value) }
+
+// Ne returns an expression that matches the entries whose field does not
+// equal value. See Eq for the field and value.
+func Ne(field string, value interface{}) FilterExpr { return compare(field, "!=", value) }
+
+// Lt returns an expression that matches the entries whose field is less than
+// value. See Eq for the field and value.
+func Lt(field string, value interface{}) FilterExpr { return compare(field, "<", value) }
+
+// Le returns an expression that matches the entries whose field is less than
+// or equal to value. See Eq for the field and value.
+func Le(field string, value interface{}) FilterExpr { return compare(field, "<=", value) }
+
+// Gt returns an expression that matches the entries whose field is greater
+// than value. See Eq for the field and value.
+func Gt(field string, value interface{}) FilterExpr { return compare(field, ">", value) }
+
+// Ge returns an expression that matches the entries whose field is greater
+// than or equal to value. See Eq for the field and value.
+func Ge(field string, value interface{}) FilterExpr { return compare(field, ">=", value) }
+
+// Has returns an expression that matches the entries whose field contains
+// value as a substring, ignoring case. See Eq for the field and value.
+func Has(field string, value interface{}) FilterExpr { return compare(field, ":", value) }
+
+// Matches returns an expression that matches the entries whose field matches
+// the RE2 regular expression re.
+func Matches(field, re string) FilterExpr { return compare(field, "=~", re) }
+
+// LogID returns an expression that matches the entries of the log with the
+// given ID, such as "syslog".
+func LogID(logID string) FilterExpr {
+	return FilterExpr{text: "log_id(" + strconv.Quote(logID) + ")"}
+}
+
+// ResourceType returns an expression that matches the entries of monitored
+// resources of type t, such as "gce_instance".
+func ResourceType(t string) FilterExpr { return Eq("resource.type", t) }
+
+// SeverityAtLeast returns an expression that matches the entries with
+// severity s or higher.
+func SeverityAtLeast(s logging.Severity) FilterExpr { return Ge("severity", s) }
+
+// Since returns an expression that matches the entries with a timestamp at or
+// after t.
+func Since(t time.Time) FilterExpr { return Ge("timestamp", t) }
+
+// And returns an expression that matches the entries matched by all of
+// exprs.
+func And(exprs ...FilterExpr) FilterExpr { return join("AND", exprs) }
+
+// Or returns an expression that matches the entries matched by any of exprs.
+func Or(exprs ...FilterExpr) FilterExpr { return join("OR", exprs) }
+
+// Not returns an expression that matches the entries that e does not match.
+func Not(e FilterExpr) FilterExpr {
+	return FilterExpr{text: "NOT " + e.operand()}
+}
+
+func compare(field, op string, value interface{}) FilterExpr {
+	return FilterExpr{text: field + op + filterValue(value)}
+}
+
+func join(op string, exprs []FilterExpr) FilterExpr {
+	switch len(exprs) {
+	case 0:
+		return FilterExpr{}
+	case 1:
+		return exprs[0]
+	}
+	parts := make([]string, 0, len(exprs))
+	for _, e := range exprs {
+		if e.text == "" {
+			continue
+		}
+		// Operands joined by the same operator need no parentheses.
+		if e.op == op {
+			parts = append(parts, e.text)
+		} else {
+			parts = append(parts, e.operand())
+		}
+	}
+	switch len(parts) {
+	case 0:
+		return FilterExpr{}
+	case 1:
+		return FilterExpr{text: parts[0]}
+	}
+	return FilterExpr{text: strings.Join(parts, " "+op+" "), op: op}
+}
+
+// operand returns the text of e, parenthesized if it is an AND or OR
+// expression.
+func (e FilterExpr) operand() string {
+	if e.op != "" {
+		return "(" + e.text + ")"
+	}
+	return e.text
+}
+
+// filterValue formats v as a value of the query language.
+func filterValue(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return strconv.Quote(x)
+	case logging.Severity:
+		return strings.ToUpper(x.String())
+	case time.Time:
+		return strconv.Quote(x.UTC().Format(time.RFC3339Nano))
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(x)
+	default:
+		return strconv.Quote(fmt.Sprint(x))
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logadmin
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/logging"
+)
+
+func TestFilterExpr(t *testing.T) {
+	for _, test := range []struct {
+		in   FilterExpr
+		want string
+	}{
+		{Eq("resource.labels.zone", "us-central1-a"), `resource.labels.zone="us-central1-a"`},
+		{Ne("jsonPayload.msg", `say "hi"`), `jsonPayload.msg!="say \"hi\""`},
+		{Gt("httpRequest.status", 499), `httpRequest.status>499`},
+		{Le("jsonPayload.latency", 1.5), `jsonPayload.latency<=1.5`},
+		{Eq("jsonPayload.ok", true), `jsonPayload.ok=true`},
+		{Has("textPayload", "timeout"), `textPayload:"timeout"`},
+		{Matches("textPayload", "^err.*"), `textPayload=~"^err.*"`},
+		{SeverityAtLeast(logging.Error), `severity>=ERROR`},
+		{Since(time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)), `timestamp>="2024-03-01T10:00:00Z"`},
+		{LogID("cloudaudit.googleapis.com/activity"), `log_id("cloudaudit.googleapis.com/activity")`},
+		{ResourceType("gce_instance"), `resource.type="gce_instance"`},
+		{RawFilter(`sample(insertId, 0.1)`), `sample(insertId, 0.1)`},
+		{And(), ``},
+		{And(ResourceType("k8s_container")), `resource.type="k8s_container"`},
+		{
+			And(ResourceType("k8s_container"), SeverityAtLeast(logging.Warning)),
+			`resource.type="k8s_container" AND severity>=WARNING`,
+		},
+		{
+			And(And(LogID("a"), LogID("b")), LogID("c"), RawFilter("")),
+			`log_id("a") AND log_id("b") AND log_id("c")`,
+		},
+		{
+			And(ResourceType("gae_app"), Or(Eq("severity", logging.Error), Has("textPayload", "panic"))),
+			`resource.type="gae_app" AND (severity=ERROR OR textPayload:"panic")`,
+		},
+		{
+			Not(Or(LogID("a"), LogID("b"))),
+			`NOT (log_id("a") OR log_id("b"))`,
+		},
+		{Not(LogID("a")), `NOT log_id("a")`},
+	} {
+		if got := test.in.String(); got != test.want {
+			t.Errorf("got  %s\nwant %s", got, test.want)
+		}
+	}
+}
@@ -21,6 +21,8 @@ import (
 	vkit "cloud.google.com/go/logging/apiv2"
 	logpb "cloud.google.com/go/logging/apiv2/loggingpb"
 	"google.golang.org/api/iterator"
+	distributionpb "google.golang.org/genproto/googleapis/api/distribution"
+	metricpb "google.golang.org/genproto/googleapis/api/metric"
 )
 
 // Metric describes a logs-based metric. The value of the metric is the
@@ -44,6 +46,28 @@ type Metric struct {
 	// https://cloud.google.com/logging/docs/view/advanced_filters).
 	// Example: "logName:syslog AND severity>=ERROR".
 	Filter string
+
+	// Disabled stops the metric from being updated.
+	Disabled bool
+
+	// MetricDescriptor optionally describes the type of the metric. If
+	// omitted, the metric is a counter of the matching log entries; the
+	// server fills in the descriptor.
+	MetricDescriptor *metricpb.MetricDescriptor
+
+	// ValueExtractor extracts the values recorded by a distribution metric
+	// from the matching log entries, with an EXTRACT(field) or
+	// REGEXP_EXTRACT(field, regex) expression.
+	ValueExtractor string
+
+	// LabelExtractors maps the label keys of the MetricDescriptor to the
+	// expressions that extract their values from the matching log entries.
+	// They have the same syntax as ValueExtractor.
+	LabelExtractors map[string]string
+
+	// BucketOptions describes the histogram buckets of a distribution
+	// metric.
+	BucketOptions *distributionpb.Distribution_BucketOptions
 }
 
 // CreateMetric creates a logs-based metric.
@@ -139,16 +163,26 @@ func (it *MetricIterator) fetch(pageSize int, pageToken string) (string, error)
 
 func toLogMetric(m *Metric) *logpb.LogMetric {
 	return &logpb.LogMetric{
-		Name:        m.ID,
-		Description: m.Description,
-		Filter:      m.Filter,
+		Name:             m.ID,
+		Description:      m.Description,
+		Filter:           m.Filter,
+		Disabled:         m.Disabled,
+		MetricDescriptor: m.MetricDescriptor,
+		ValueExtractor:   m.ValueExtractor,
+		LabelExtractors:  m.LabelExtractors,
+		BucketOptions:    m.BucketOptions,
 	}
 }
 
 func fromLogMetric(lm *logpb.LogMetric) *Metric {
 	return &Metric{
-		ID:          lm.Name,
-		Description: lm.Description,
-		Filter:      lm.Filter,
+		ID:               lm.Name,
+		Description:      lm.Description,
+		Filter:           lm.Filter,
+		Disabled:         lm.Disabled,
+		MetricDescriptor: lm.MetricDescriptor,
+		ValueExtractor:   lm.ValueExtractor,
+		LabelExtractors:  lm.LabelExtractors,
+		BucketOptions:    lm.BucketOptions,
 	}
 }
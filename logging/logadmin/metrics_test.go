@@ -22,11 +22,15 @@ import (
 
 	"cloud.google.com/go/internal/testutil"
 	"cloud.google.com/go/internal/uid"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"google.golang.org/api/iterator"
 )
 
 var metricIDs = uid.NewSpace("GO-CLIENT-TEST-METRIC", nil)
 
+// The service fills in the descriptor of metrics created without one.
+var ignoreMetricDescriptor = cmpopts.IgnoreFields(Metric{}, "MetricDescriptor")
+
 // Initializes the tests before they run.
 func initMetrics(ctx context.Context) {
 	// Clean up from aborted tests.
@@ -64,7 +68,7 @@ func TestCreateDeleteMetric(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if want := metric; !testutil.Equal(got, want) {
+	if want := metric; !testutil.Equal(got, want, ignoreMetricDescriptor) {
 		t.Errorf("got %+v, want %+v", got, want)
 	}
 
@@ -94,7 +98,7 @@ func TestUpdateMetric(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if want := metric; !testutil.Equal(got, want) {
+	if want := metric; !testutil.Equal(got, want, ignoreMetricDescriptor) {
 		t.Errorf("got %+v, want %+v", got, want)
 	}
 
@@ -107,7 +111,7 @@ func TestUpdateMetric(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if want := metric; !testutil.Equal(got, want) {
+	if want := metric; !testutil.Equal(got, want, ignoreMetricDescriptor) {
 		t.Errorf("got %+v, want %+v", got, want)
 	}
 }
@@ -149,7 +153,7 @@ func TestListMetrics(t *testing.T) {
 			got[m.ID] = m
 		}
 	}
-	if !testutil.Equal(got, want) {
+	if !testutil.Equal(got, want, ignoreMetricDescriptor) {
 		t.Errorf("got %+v, want %+v", got, want)
 	}
 }
@@ -48,6 +48,7 @@ import (
 	"github.com/golang/protobuf/ptypes"
 	structpb "github.com/golang/protobuf/ptypes/struct"
 	gax "github.com/googleapis/gax-go/v2"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/api/option"
 	"google.golang.org/api/support/bundler"
 	mrpb "google.golang.org/genproto/googleapis/api/monitoredres"
@@ -148,6 +149,19 @@ type Client struct {
 	//
 	// This field should be set only once, before any method of Client is called.
 	OnError func(err error)
+
+	// OnDrop, if set, is called with the entries that a Logger of the client
+	// failed to write and the error that caused it, after the error has been
+	// reported to OnError. Entries are dropped when they do not fit in the
+	// buffer of the Logger (the error is then ErrOverflow or
+	// ErrOversizedEntry), when the call to the logging service that was
+	// writing them fails, or when they cannot be written to the writer set
+	// with RedirectAsJSON. Entries written with LogSync are not reported, as
+	// their error is returned. OnDrop may be called concurrently, and should
+	// return quickly.
+	//
+	// This field should be set only once, before any method of Client is called.
+	OnDrop func(entries []*logpb.LogEntry, err error)
 }
 
 // NewClient returns a new logging client associated with the provided parent.
@@ -255,6 +269,14 @@ func (c *Client) error(err error) {
 	c.mu.Unlock()
 }
 
+// drop reports entries that could not be written because of err.
+func (c *Client) drop(entries []*logpb.LogEntry, err error) {
+	c.error(err)
+	if c.OnDrop != nil {
+		c.OnDrop(entries, err)
+	}
+}
+
 func (c *Client) extractErrorInfo() error {
 	var err error
 	c.mu.Lock()
@@ -277,6 +299,8 @@ type Logger struct {
 
 	// Options
 	commonResource         *mrpb.MonitoredResource
+	commonResourceSet      bool // whether CommonResource was given
+	resourceDetectors      []ResourceDetector
 	commonLabels           map[string]string
 	ctxFunc                func() (context.Context, func())
 	populateSourceLocation int
@@ -321,14 +345,9 @@ func (r *loggerRetryer) Retry(err error) (pause time.Duration, shouldRetry bool)
 // characters: [A-Za-z0-9]; and punctuation characters: forward-slash,
 // underscore, hyphen, and period.
 func (c *Client) Logger(logID string, opts ...LoggerOption) *Logger {
-	r := detectResourceInternal()
-	if r == nil {
-		r = monitoredResource(c.parent)
-	}
 	l := &Logger{
 		client:                 c,
 		logName:                internal.LogPath(c.parent, logID),
-		commonResource:         r,
 		ctxFunc:                func() (context.Context, func()) { return context.Background(), nil },
 		populateSourceLocation: DoNotPopulateSourceLocation,
 		partialSuccess:         false,
@@ -344,6 +363,14 @@ func (c *Client) Logger(logID string, opts ...LoggerOption) *Logger {
 	for _, opt := range opts {
 		opt.set(l)
 	}
+	if !l.commonResourceSet {
+		r, labels := detectLoggerResource(l.resourceDetectors)
+		if r == nil {
+			r = monitoredResource(c.parent)
+		}
+		l.commonResource = r
+		l.commonLabels = mergeLabels(labels, l.commonLabels)
+	}
 	l.stdLoggers = map[Severity]*log.Logger{}
 	for s := range severityName {
 		e := Entry{Severity: s}
@@ -488,7 +515,8 @@ type Entry struct {
 
 	// Labels optionally specifies key/value labels for the log entry.
 	// The Logger.Log method takes ownership of this map. See Logger.CommonLabels
-	// for more about labels.
+	// for more about labels. A label of the entry overrides a common label with
+	// the same key.
 	Labels map[string]string
 
 	// InsertID is a unique ID for the log entry. If you provide this field,
@@ -512,12 +540,18 @@ type Entry struct {
 	// reading entries. It is an error to set it when writing entries.
 	LogName string
 
-	// Resource is the monitored resource associated with the entry.
+	// Resource is the monitored resource associated with the entry. If set, it
+	// overrides the common resource of the Logger for this entry, so that a
+	// single Logger can write entries for several resources.
 	Resource *mrpb.MonitoredResource
 
 	// Trace is the resource name of the trace associated with the log entry,
 	// if any. If it contains a relative resource name, the name is assumed to
 	// be relative to //tracing.googleapis.com.
+	//
+	// If Trace is empty, Trace, SpanID and TraceSampled are set from the
+	// OpenTelemetry span in the context passed to LogSync or in the context of
+	// HTTPRequest.Request, or else from the trace headers of HTTPRequest.Request.
 	Trace string
 
 	// ID of the span within the trace associated with the log entry.
@@ -693,7 +727,11 @@ func jsonValueToStructValue(v interface{}) *structpb.Value {
 // LogSync logs the Entry synchronously without any buffering. Because LogSync is slow
 // and will block, it is intended primarily for debugging or critical errors.
 // Prefer Log for most uses.
+//
+// If the Entry has no Trace and ctx carries an OpenTelemetry span, the Entry
+// is associated with the span.
 func (l *Logger) LogSync(ctx context.Context, e Entry) error {
+	l.populateTraceInfoFromContext(&e, ctx)
 	ent, err := toLogEntryInternal(e, l, l.client.parent, 1)
 	if err != nil {
 		return err
@@ -701,7 +739,7 @@ func (l *Logger) LogSync(ctx context.Context, e Entry) error {
 	entries, hasInstrumentation := l.instrumentLogs([]*logpb.LogEntry{ent})
 	if l.redirectOutputWriter != nil {
 		for _, ent = range entries {
-			err = serializeEntryToWriter(ent, l.redirectOutputWriter)
+			err = serializeEntryToWriter(ent, l.commonLabels, l.redirectOutputWriter)
 			if err != nil {
 				break
 			}
@@ -733,16 +771,16 @@ func (l *Logger) logInternal(e Entry, skipLevels int) {
 	entries, _ := l.instrumentLogs([]*logpb.LogEntry{ent})
 	if l.redirectOutputWriter != nil {
 		for _, ent = range entries {
-			err = serializeEntryToWriter(ent, l.redirectOutputWriter)
+			err = serializeEntryToWriter(ent, l.commonLabels, l.redirectOutputWriter)
 			if err != nil {
-				l.client.error(err)
+				l.client.drop([]*logpb.LogEntry{ent}, err)
 			}
 		}
 		return
 	}
 	for _, ent = range entries {
 		if err := l.bundler.Add(ent, proto.Size(ent)); err != nil {
-			l.client.error(err)
+			l.client.drop([]*logpb.LogEntry{ent}, err)
 		}
 	}
 }
@@ -758,6 +796,23 @@ func (l *Logger) Flush() error {
 	return l.client.extractErrorInfo()
 }
 
+// FlushContext is like Flush, but gives up waiting when ctx is done, and then
+// returns the context's error. The buffered entries are still sent in the
+// background; entries that cannot be sent are reported to Client.OnDrop.
+func (l *Logger) FlushContext(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		l.bundler.Flush()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return l.client.extractErrorInfo()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (l *Logger) writeLogEntries(entries []*logpb.LogEntry) {
 	partialSuccess := l.partialSuccess
 	if len(entries) > 1 {
@@ -776,7 +831,7 @@ func (l *Logger) writeLogEntries(entries []*logpb.LogEntry) {
 
 	_, err := l.client.client.WriteLogEntries(ctx, req, gax.WithRetry(newLoggerRetryer))
 	if err != nil {
-		l.client.error(err)
+		l.client.drop(entries, err)
 	}
 	if afterCall != nil {
 		afterCall()
@@ -812,6 +867,12 @@ func populateTraceInfo(e *Entry, req *http.Request) bool {
 			return false
 		}
 	}
+	if traceID, spanID, traceSampled, ok := traceInfoFromContext(req.Context()); ok {
+		e.Trace = traceID
+		e.SpanID = spanID
+		e.TraceSampled = e.TraceSampled || traceSampled
+		return true
+	}
 	header := req.Header.Get("Traceparent")
 	if header != "" {
 		// do not use traceSampled flag defined by traceparent because
@@ -837,6 +898,32 @@ func populateTraceInfo(e *Entry, req *http.Request) bool {
 	return false
 }
 
+// populateTraceInfoFromContext sets the trace fields of e, unless they are
+// already set, from the OpenTelemetry span in ctx, if any.
+func (l *Logger) populateTraceInfoFromContext(e *Entry, ctx context.Context) {
+	if e.Trace != "" {
+		return
+	}
+	if traceID, spanID, traceSampled, ok := traceInfoFromContext(ctx); ok {
+		e.Trace = fmt.Sprintf("%s/traces/%s", l.client.parent, traceID)
+		e.SpanID = spanID
+		e.TraceSampled = e.TraceSampled || traceSampled
+	}
+}
+
+// traceInfoFromContext returns the IDs of the OpenTelemetry span in ctx, if
+// any.
+func traceInfoFromContext(ctx context.Context) (traceID, spanID string, traceSampled, ok bool) {
+	if ctx == nil {
+		return "", "", false, false
+	}
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return "", "", false, false
+	}
+	return sc.TraceID().String(), sc.SpanID().String(), sc.IsSampled(), true
+}
+
 // As per format described at https://www.w3.org/TR/trace-context/#traceparent-header-field-values
 var validTraceParentExpression = regexp.MustCompile(`^(00)-([a-fA-F\d]{32})-([a-f\d]{16})-([a-fA-F\d]{2})$`)
 
@@ -1028,7 +1115,9 @@ func (s structuredLogEntrySourceLocation) MarshalJSON() ([]byte, error) {
 	return protojson.Marshal(s.sourceLocation)
 }
 
-func serializeEntryToWriter(entry *logpb.LogEntry, w io.Writer) error {
+// serializeEntryToWriter writes entry to w in the structured logging format,
+// with commonLabels added to the labels of the entry.
+func serializeEntryToWriter(entry *logpb.LogEntry, commonLabels map[string]string, w io.Writer) error {
 	var httpRequest *structuredLogEntryHTTPRequest
 	if entry.HttpRequest != nil {
 		httpRequest = &structuredLogEntryHTTPRequest{entry.HttpRequest}
@@ -1044,11 +1133,28 @@ func serializeEntryToWriter(entry *logpb.LogEntry, w io.Writer) error {
 		sourceLocation = &structuredLogEntrySourceLocation{entry.SourceLocation}
 	}
 
+	labels := entry.Labels
+	if len(commonLabels) > 0 {
+		labels = make(map[string]string, len(commonLabels)+len(entry.Labels))
+		for k, v := range commonLabels {
+			labels[k] = v
+		}
+		// The labels of the entry override the common labels.
+		for k, v := range entry.Labels {
+			labels[k] = v
+		}
+	}
+
+	var timestamp string
+	if entry.Timestamp != nil {
+		timestamp = entry.Timestamp.AsTime().Format(time.RFC3339Nano)
+	}
+
 	jsonifiedEntry := structuredLogEntry{
 		Severity:       entry.Severity.String(),
 		HTTPRequest:    httpRequest,
-		Timestamp:      entry.Timestamp.String(),
-		Labels:         entry.Labels,
+		Timestamp:      timestamp,
+		Labels:         labels,
 		InsertID:       entry.InsertId,
 		Operation:      operation,
 		SourceLocation: sourceLocation,
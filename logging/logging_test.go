@@ -48,6 +48,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	gax "github.com/googleapis/gax-go/v2"
+	oteltrace "go.opentelemetry.io/otel/trace"
 	"golang.org/x/oauth2"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
@@ -441,6 +442,26 @@ func TestToLogEntry(t *testing.T) {
 				SpanId: "000000000000004a",
 			},
 		},
+		{
+			name: "OpenTelemetry span in request context takes precedence over headers",
+			in: logging.Entry{
+				HTTPRequest: &logging.HTTPRequest{
+					Request: (&http.Request{
+						URL:    u,
+						Header: http.Header{"Traceparent": {"00-105445aa7843bc8bf206b12000100012-000000000000004a-01"}},
+					}).WithContext(oteltrace.ContextWithSpanContext(context.Background(), oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+						TraceID:    oteltrace.TraceID{0xab},
+						SpanID:     oteltrace.SpanID{0xcd},
+						TraceFlags: oteltrace.FlagsSampled,
+					}))),
+				},
+			},
+			want: &logpb.LogEntry{
+				Trace:        "projects/P/traces/ab000000000000000000000000000000",
+				SpanId:       "cd00000000000000",
+				TraceSampled: true,
+			},
+		},
 		{
 			name: "traceparent header with preset sampled field",
 			in: logging.Entry{
@@ -963,6 +984,38 @@ func TestPing(t *testing.T) {
 	}
 }
 
+func TestOnDrop(t *testing.T) {
+	ctx := context.Background()
+	initLogs()
+	c, a := newClients(ctx, testProjectID)
+	defer c.Close()
+	defer a.Close()
+	c.OnError = func(error) {}
+	type drop struct {
+		entries []*logpb.LogEntry
+		err     error
+	}
+	drops := make(chan drop, 1)
+	c.OnDrop = func(entries []*logpb.LogEntry, err error) { drops <- drop{entries, err} }
+
+	lg := c.Logger(testLogID, logging.BufferedByteLimit(1))
+	lg.Log(logging.Entry{Payload: "does not fit"})
+	select {
+	case d := <-drops:
+		if d.err != logging.ErrOverflow {
+			t.Errorf("got error %v, want ErrOverflow", d.err)
+		}
+		if len(d.entries) != 1 || d.entries[0].GetTextPayload() != "does not fit" {
+			t.Errorf("got dropped entries %v, want the logged entry", d.entries)
+		}
+	default:
+		t.Fatal("OnDrop was not called")
+	}
+	if err := lg.FlushContext(ctx); err == nil {
+		t.Error("FlushContext: got nil error, want the overflow error")
+	}
+}
+
 func TestDeleteLog(t *testing.T) {
 	ctx := context.Background()
 	initLogs()
@@ -1508,7 +1561,7 @@ func TestRedirectOutputFormats(t *testing.T) {
 				`"logging.googleapis.com/labels":{"key1":"value1","key2":"value2"},"logging.googleapis.com/operation":{"id":"0123456789","producer":"test"},` +
 				`"logging.googleapis.com/sourceLocation":{"file":"acme.go","function":"main","line":"100"},"logging.googleapis.com/spanId":"000000000001",` +
 				`"logging.googleapis.com/trace":"projects/P/ABCD12345678AB12345678","logging.googleapis.com/trace_sampled":true,` +
-				`"message":"this is text payload","severity":"DEBUG","timestamp":"seconds:1000"}`,
+				`"message":"this is text payload","severity":"DEBUG","timestamp":"1970-01-01T00:16:40Z"}`,
 		},
 		{
 			name: "full data redirect with json payload",
@@ -1544,7 +1597,7 @@ func TestRedirectOutputFormats(t *testing.T) {
 				`"logging.googleapis.com/labels":{"key1":"value1","key2":"value2"},"logging.googleapis.com/operation":{"id":"0123456789","producer":"test"},` +
 				`"logging.googleapis.com/sourceLocation":{"file":"acme.go","function":"main","line":"100"},"logging.googleapis.com/spanId":"000000000001",` +
 				`"logging.googleapis.com/trace":"projects/P/ABCD12345678AB12345678","logging.googleapis.com/trace_sampled":true,` +
-				`"message":{"Latency":321,"Message":"message part of the payload"},"severity":"DEBUG","timestamp":"seconds:1000"}`,
+				`"message":{"Latency":321,"Message":"message part of the payload"},"severity":"DEBUG","timestamp":"1970-01-01T00:16:40Z"}`,
 		},
 		{
 			name: "error on redirect with proto payload",
@@ -1596,6 +1649,31 @@ func TestRedirectOutputFormats(t *testing.T) {
 	}
 }
 
+func TestRedirectOutputCommonLabels(t *testing.T) {
+	buffer := &strings.Builder{}
+	logger := client.Logger("test-redirect-common-labels",
+		logging.RedirectAsJSON(buffer),
+		logging.CommonLabels(map[string]string{"env": "prod", "key": "common"}))
+	err := logger.LogSync(context.Background(), logging.Entry{
+		Timestamp: testNow().UTC(),
+		Labels:    map[string]string{"key": "entry"},
+		Payload:   "text",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Labels map[string]string `json:"logging.googleapis.com/labels"`
+	}
+	if err := json.Unmarshal([]byte(buffer.String()), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"env": "prod", "key": "entry"}
+	if !reflect.DeepEqual(got.Labels, want) {
+		t.Errorf("got labels %v, want %v", got.Labels, want)
+	}
+}
+
 func TestInstrumentationIngestion(t *testing.T) {
 	var got []*logpb.LogEntry
 
@@ -1651,9 +1729,9 @@ func TestInstrumentationIngestion(t *testing.T) {
 func TestInstrumentationWithRedirect(t *testing.T) {
 	want := []string{
 		// do not format the string to preserve expected new-line between messages
-		`{"message":"test string","severity":"INFO","timestamp":"seconds:1000"}
-{"message":{"logging.googleapis.com/diagnostic":{"instrumentation_source":[{"name":"go","version":"` + internal.Version + `"}],"runtime":"` + internal.VersionGo() + `"}},"severity":"DEFAULT","timestamp":"seconds:1000"}`,
-		`{"message":"test string","severity":"INFO","timestamp":"seconds:1000"}`,
+		`{"message":"test string","severity":"INFO","timestamp":"1970-01-01T00:16:40Z"}
+{"message":{"logging.googleapis.com/diagnostic":{"instrumentation_source":[{"name":"go","version":"` + internal.Version + `"}],"runtime":"` + internal.VersionGo() + `"}},"severity":"DEFAULT","timestamp":"1970-01-01T00:16:40Z"}`,
+		`{"message":"test string","severity":"INFO","timestamp":"1970-01-01T00:16:40Z"}`,
 	}
 	entry := &logging.Entry{Severity: logging.Info, Payload: "test string"}
 	buffer := &strings.Builder{}
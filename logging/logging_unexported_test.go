@@ -17,6 +17,7 @@
 package logging
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -29,6 +30,7 @@ import (
 	"github.com/golang/protobuf/proto"
 	durpb "github.com/golang/protobuf/ptypes/duration"
 	structpb "github.com/golang/protobuf/ptypes/struct"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/api/support/bundler"
 	mrpb "google.golang.org/genproto/googleapis/api/monitoredres"
 	logtypepb "google.golang.org/genproto/googleapis/logging/type"
@@ -331,6 +333,41 @@ func TestFromHTTPRequest(t *testing.T) {
 	}
 }
 
+func TestPopulateTraceInfoFromContext(t *testing.T) {
+	l := &Logger{client: &Client{parent: "projects/P"}}
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	var e Entry
+	l.populateTraceInfoFromContext(&e, ctx)
+	want := Entry{
+		Trace:        "projects/P/traces/01000000000000000000000000000000",
+		SpanID:       "0200000000000000",
+		TraceSampled: true,
+	}
+	if diff := testutil.Diff(e, want); diff != "" {
+		t.Errorf("got=-, want=+:\n%s", diff)
+	}
+
+	// Trace fields that are already set are kept.
+	e = Entry{Trace: "t1"}
+	l.populateTraceInfoFromContext(&e, ctx)
+	if e.Trace != "t1" || e.SpanID != "" {
+		t.Errorf("got trace %q and span %q, want the entry unchanged", e.Trace, e.SpanID)
+	}
+
+	// Contexts without a span leave the entry unchanged.
+	e = Entry{}
+	l.populateTraceInfoFromContext(&e, context.Background())
+	if e.Trace != "" {
+		t.Errorf("got trace %q, want none", e.Trace)
+	}
+}
+
 func TestMonitoredResource(t *testing.T) {
 	for _, test := range []struct {
 		parent string
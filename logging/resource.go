@@ -24,19 +24,85 @@ import (
 )
 
 // CommonResource sets the monitored resource associated with all log entries
-// written from a Logger. If not provided, the resource is automatically
-// detected based on the running environment (on GCE, GCR, GCF and GAE Standard only).
+// written from a Logger. If not provided, the resource is detected by the
+// detectors given with ResourceDetectors, if any, and otherwise automatically
+// based on the running environment (on GCE, GKE, Cloud Run services and jobs,
+// Cloud Functions and App Engine only).
 // This value can be overridden per-entry by setting an Entry's Resource field.
 func CommonResource(r *mrpb.MonitoredResource) LoggerOption { return commonResource{r} }
 
 type commonResource struct{ *mrpb.MonitoredResource }
 
-func (r commonResource) set(l *Logger) { l.commonResource = r.MonitoredResource }
+func (r commonResource) set(l *Logger) {
+	l.commonResource = r.MonitoredResource
+	l.commonResourceSet = true
+}
+
+// A ResourceDetector detects the monitored resource of the environment that
+// the program runs in.
+type ResourceDetector interface {
+	// DetectResource returns the monitored resource of the environment, or
+	// nil if the detector does not recognize the environment.
+	DetectResource() *mrpb.MonitoredResource
+}
+
+// ResourceDetectorFunc is an adapter to allow the use of an ordinary function
+// as a ResourceDetector.
+type ResourceDetectorFunc func() *mrpb.MonitoredResource
+
+// DetectResource calls f.
+func (f ResourceDetectorFunc) DetectResource() *mrpb.MonitoredResource { return f() }
+
+// ResourceDetectors sets detectors of the monitored resource associated with
+// all log entries written from a Logger, for environments that the automatic
+// detection does not recognize. The detectors are called in order when the
+// Logger is created, and the first resource detected is used. If none detects
+// a resource, the resource is detected automatically. ResourceDetectors is
+// ignored if CommonResource is given.
+func ResourceDetectors(ds ...ResourceDetector) LoggerOption { return resourceDetectors(ds) }
+
+type resourceDetectors []ResourceDetector
+
+func (d resourceDetectors) set(l *Logger) { l.resourceDetectors = d }
+
+// detectLoggerResource returns the first resource detected by ds, or the
+// resource detected automatically and its entry labels.
+func detectLoggerResource(ds []ResourceDetector) (*mrpb.MonitoredResource, map[string]string) {
+	for _, d := range ds {
+		if r := d.DetectResource(); r != nil {
+			return r, nil
+		}
+	}
+	r := detectResourceInternal()
+	if r == nil {
+		return nil, nil
+	}
+	return r, detectedLabels()
+}
+
+// mergeLabels returns the union of the detected labels and the labels set
+// with CommonLabels, which take precedence.
+func mergeLabels(detected, common map[string]string) map[string]string {
+	if len(detected) == 0 {
+		return common
+	}
+	m := make(map[string]string, len(detected)+len(common))
+	for k, v := range detected {
+		m[k] = v
+	}
+	for k, v := range common {
+		m[k] = v
+	}
+	return m
+}
 
 type resource struct {
 	pb    *mrpb.MonitoredResource
 	attrs internal.ResourceAttributesGetter
 	once  *sync.Once
+	// labels are entry labels that identify the workload within the
+	// resource, such as the execution and task of a Cloud Run job.
+	labels map[string]string
 }
 
 var detectedResource = &resource{
@@ -171,6 +237,16 @@ func detectCloudRunServiceResource() *mrpb.MonitoredResource {
 	}
 }
 
+// detectCloudRunJobLabels returns the entry labels that identify the task of
+// a Cloud Run job, as the Cloud Run logging agent sets them.
+func detectCloudRunJobLabels() map[string]string {
+	return map[string]string{
+		"run.googleapis.com/execution_name": detectedResource.attrs.EnvVar("CLOUD_RUN_EXECUTION"),
+		"run.googleapis.com/task_index":     detectedResource.attrs.EnvVar("CLOUD_RUN_TASK_INDEX"),
+		"run.googleapis.com/task_attempt":   detectedResource.attrs.EnvVar("CLOUD_RUN_TASK_ATTEMPT"),
+	}
+}
+
 func detectCloudRunJobResource() *mrpb.MonitoredResource {
 	projectID := detectedResource.metadataProjectID()
 	if projectID == "" {
@@ -265,6 +341,9 @@ func detectResource() *mrpb.MonitoredResource {
 				detectedResource.pb = detectCloudRunServiceResource()
 			case detectedResource.isCloudRunJob():
 				detectedResource.pb = detectCloudRunJobResource()
+				if detectedResource.pb != nil {
+					detectedResource.labels = detectCloudRunJobLabels()
+				}
 			// cannot use name validation for GKE and GCE because
 			// both of them set product name to "Google Compute Engine"
 			case detectedResource.isKubernetesEngine():
@@ -277,6 +356,12 @@ func detectResource() *mrpb.MonitoredResource {
 	return detectedResource.pb
 }
 
+// detectedLabels returns the entry labels of the automatically detected
+// resource.
+func detectedLabels() map[string]string {
+	return detectedResource.labels
+}
+
 // systemProductName reads resource type on the Linux-based environments such as
 // Cloud Functions, Cloud Run, GKE, GCE, GAE, etc.
 func systemProductName() string {
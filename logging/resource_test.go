@@ -86,6 +86,7 @@ func setupDetectedResource(envVars, metaVars, fsPaths map[string]string) {
 		fsPaths:  fsPaths,
 	}
 	detectedResource.pb = nil
+	detectedResource.labels = nil
 }
 
 func TestResourceDetection(t *testing.T) {
@@ -95,6 +96,8 @@ func TestResourceDetection(t *testing.T) {
 		metaVars map[string]string
 		fsPaths  map[string]string
 		want     *mrpb.MonitoredResource
+		// wantLabels are the detected entry labels
+		wantLabels map[string]string
 	}{
 		{
 			name:     "detect GAE resource",
@@ -150,6 +153,11 @@ func TestResourceDetection(t *testing.T) {
 					"job_name":   serviceName,
 				},
 			},
+			wantLabels: map[string]string{
+				"run.googleapis.com/execution_name": crConfig,
+				"run.googleapis.com/task_index":     version,
+				"run.googleapis.com/task_attempt":   instanceID,
+			},
 		},
 		{
 			name:     "detect GKE resource for a zonal cluster",
@@ -201,6 +209,12 @@ func TestResourceDetection(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:     "Kubernetes without GKE cluster attributes",
+			envVars:  map[string]string{"HOSTNAME": podName, "KUBERNETES_SERVICE_HOST": there},
+			metaVars: map[string]string{"": there, "project/project-id": projectID, "instance/region": qualifiedRegionName},
+			want:     nil,
+		},
 		{
 			name:     "detect Compute Engine resource",
 			envVars:  map[string]string{},
@@ -270,10 +284,54 @@ func TestResourceDetection(t *testing.T) {
 			if diff := cmp.Diff(got, tc.want, cmpopts.IgnoreUnexported(mrpb.MonitoredResource{})); diff != "" {
 				t.Errorf("got(-),want(+):\n%s", diff)
 			}
+			if diff := cmp.Diff(detectedLabels(), tc.wantLabels); diff != "" {
+				t.Errorf("labels: got(-),want(+):\n%s", diff)
+			}
 		})
 	}
 }
 
+func TestDetectLoggerResource(t *testing.T) {
+	detected := &mrpb.MonitoredResource{Type: "cloud_run_job"}
+	old := detectResourceInternal
+	defer func() { detectResourceInternal = old }()
+	detectResourceInternal = func() *mrpb.MonitoredResource { return detected }
+
+	custom := &mrpb.MonitoredResource{Type: "generic_task"}
+	none := ResourceDetectorFunc(func() *mrpb.MonitoredResource { return nil })
+	some := ResourceDetectorFunc(func() *mrpb.MonitoredResource { return custom })
+	for _, tc := range []struct {
+		name      string
+		detectors []ResourceDetector
+		want      *mrpb.MonitoredResource
+	}{
+		{"no detectors", nil, detected},
+		{"no resource detected", []ResourceDetector{none}, detected},
+		{"first resource detected", []ResourceDetector{none, some}, custom},
+	} {
+		got, _ := detectLoggerResource(tc.detectors)
+		if got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestMergeLabels(t *testing.T) {
+	detected := map[string]string{"a": "1", "b": "2"}
+	common := map[string]string{"b": "3", "c": "4"}
+	got := mergeLabels(detected, common)
+	want := map[string]string{"a": "1", "b": "3", "c": "4"}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("got(-),want(+):\n%s", diff)
+	}
+	if len(common) != 2 {
+		t.Errorf("common labels modified: %v", common)
+	}
+	if got := mergeLabels(nil, common); !cmp.Equal(got, common) {
+		t.Errorf("got %v, want %v", got, common)
+	}
+}
+
 var benchmarkResultHolder *mrpb.MonitoredResource
 
 func BenchmarkDetectResource(b *testing.B) {
@@ -0,0 +1,261 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.21
+
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	logpb "cloud.google.com/go/logging/apiv2/loggingpb"
+)
+
+// slogMessageKey is the key of the message of a record in the JSON payload of
+// its entry, which Cloud Logging displays as the summary of the entry.
+const slogMessageKey = "message"
+
+// SlogHandlerOption is a configuration option for a handler created with
+// NewSlogHandler.
+type SlogHandlerOption interface {
+	setSlog(*slogHandlerConfig)
+}
+
+type slogHandlerConfig struct {
+	level     slog.Leveler
+	labelKeys map[string]bool
+	addSource bool
+}
+
+// SlogLevel sets the minimum level of the records written by the handler. The
+// default is slog.LevelInfo.
+func SlogLevel(l slog.Leveler) SlogHandlerOption { return slogLevel{l} }
+
+type slogLevel struct{ slog.Leveler }
+
+func (o slogLevel) setSlog(c *slogHandlerConfig) { c.level = o.Leveler }
+
+// SlogLabels makes the top-level attributes with the given keys labels of the
+// entries, rather than fields of their JSON payload. Their values are
+// formatted as strings.
+func SlogLabels(keys ...string) SlogHandlerOption { return slogLabels(keys) }
+
+type slogLabels []string
+
+func (o slogLabels) setSlog(c *slogHandlerConfig) {
+	for _, k := range o {
+		c.labelKeys[k] = true
+	}
+}
+
+// SlogSource makes the handler set the source location of the entries to the
+// caller of the slog.Logger method that wrote the record.
+func SlogSource() SlogHandlerOption { return slogSource{} }
+
+type slogSource struct{}
+
+func (slogSource) setSlog(c *slogHandlerConfig) { c.addSource = true }
+
+// NewSlogHandler returns a slog.Handler that writes records to logger with
+// Logger.Log. The level of a record is mapped to the severity of its entry;
+// its message and attributes make up the JSON payload of the entry, with
+// groups as nested objects. If the context passed to the slog.Logger method
+// carries an OpenTelemetry span, the trace and span IDs of the entry are set
+// from it.
+//
+// NewSlogHandler requires Go 1.21 or later.
+func NewSlogHandler(logger *Logger, opts ...SlogHandlerOption) slog.Handler {
+	cfg := &slogHandlerConfig{
+		level:     slog.LevelInfo,
+		labelKeys: map[string]bool{},
+	}
+	for _, o := range opts {
+		o.setSlog(cfg)
+	}
+	return &slogHandler{logger: logger, cfg: cfg}
+}
+
+type slogHandler struct {
+	logger *Logger
+	cfg    *slogHandlerConfig
+
+	// goas holds the groups and attributes added with WithGroup and
+	// WithAttrs, in order.
+	goas []groupOrAttrs
+}
+
+// slogGroup is the JSON payload object of a group.
+type slogGroup map[string]interface{}
+
+// groupOrAttrs holds either a group name or a list of attributes.
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+func (h *slogHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.cfg.level.Level()
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	e := h.toEntry(r)
+	h.logger.populateTraceInfoFromContext(&e, ctx)
+	h.logger.Log(e)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.with(groupOrAttrs{attrs: attrs})
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.with(groupOrAttrs{group: name})
+}
+
+func (h *slogHandler) with(goa groupOrAttrs) *slogHandler {
+	h2 := *h
+	h2.goas = make([]groupOrAttrs, len(h.goas)+1)
+	copy(h2.goas, h.goas)
+	h2.goas[len(h.goas)] = goa
+	return &h2
+}
+
+// toEntry converts a record to an entry.
+func (h *slogHandler) toEntry(r slog.Record) Entry {
+	e := Entry{
+		Timestamp: r.Time,
+		Severity:  slogLevelToSeverity(r.Level),
+	}
+	payload := slogGroup{}
+	cur := payload
+	inGroup := false
+	for _, goa := range h.goas {
+		if goa.group != "" {
+			next := slogGroup{}
+			cur[goa.group] = next
+			cur, inGroup = next, true
+			continue
+		}
+		for _, a := range goa.attrs {
+			h.addAttr(&e, cur, inGroup, a)
+		}
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		h.addAttr(&e, cur, inGroup, a)
+		return true
+	})
+	pruneEmptyGroups(payload)
+	payload[slogMessageKey] = r.Message
+	e.Payload = map[string]interface{}(payload)
+
+	if h.cfg.addSource && r.PC != 0 {
+		f, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		e.SourceLocation = &logpb.LogEntrySourceLocation{
+			File:     f.File,
+			Function: f.Function,
+			Line:     int64(f.Line),
+		}
+	}
+	return e
+}
+
+// addAttr adds a to the payload object m, or to the labels of e if a is a
+// top-level attribute with a label key.
+func (h *slogHandler) addAttr(e *Entry, m slogGroup, inGroup bool, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if !inGroup && h.cfg.labelKeys[a.Key] {
+		if e.Labels == nil {
+			e.Labels = map[string]string{}
+		}
+		e.Labels[a.Key] = a.Value.String()
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return
+		}
+		// Attributes of a group with an empty key are inlined.
+		group := m
+		if a.Key != "" {
+			group = slogGroup{}
+			m[a.Key] = group
+		}
+		for _, ga := range attrs {
+			h.addAttr(e, group, true, ga)
+		}
+		return
+	}
+	m[a.Key] = slogValue(a.Value)
+}
+
+// slogValue returns the JSON payload value of a resolved non-group value.
+func slogValue(v slog.Value) interface{} {
+	switch v.Kind() {
+	case slog.KindDuration:
+		return v.Duration().String()
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return err.Error()
+		}
+	}
+	return v.Any()
+}
+
+// pruneEmptyGroups removes the groups without attributes from m, as a
+// slog.Handler must.
+func pruneEmptyGroups(m slogGroup) bool {
+	for k, v := range m {
+		if g, ok := v.(slogGroup); ok && pruneEmptyGroups(g) {
+			delete(m, k)
+		}
+	}
+	return len(m) == 0
+}
+
+// slogLevelToSeverity maps a slog level to the closest severity. The levels
+// between the standard slog levels are used for the severities that slog
+// lacks: Notice is slog.LevelInfo+2, and Critical, Alert and Emergency are
+// slog.LevelError+4, +8 and +12.
+func slogLevelToSeverity(l slog.Level) Severity {
+	switch {
+	case l < slog.LevelInfo:
+		return Debug
+	case l < slog.LevelInfo+2:
+		return Info
+	case l < slog.LevelWarn:
+		return Notice
+	case l < slog.LevelError:
+		return Warning
+	case l < slog.LevelError+4:
+		return Error
+	case l < slog.LevelError+8:
+		return Critical
+	case l < slog.LevelError+12:
+		return Alert
+	default:
+		return Emergency
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.21
+
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/internal/testutil"
+)
+
+func TestSlogHandlerToEntry(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	h := NewSlogHandler(nil, SlogLabels("env")).
+		WithAttrs([]slog.Attr{slog.String("env", "prod"), slog.Int("a", 1)}).
+		WithGroup("req").
+		WithAttrs([]slog.Attr{slog.String("method", "GET")}).
+		WithGroup("empty").(*slogHandler)
+
+	r := slog.NewRecord(now, slog.LevelWarn, "hello", 0)
+	r.AddAttrs(
+		slog.Any("err", errors.New("boom")),
+		slog.Duration("d", time.Second),
+		slog.Group("g"),
+	)
+	got := h.toEntry(r)
+	want := Entry{
+		Timestamp: now,
+		Severity:  Warning,
+		Labels:    map[string]string{"env": "prod"},
+		Payload: map[string]interface{}{
+			"message": "hello",
+			"a":       int64(1),
+			"req": slogGroup{
+				"method": "GET",
+				"empty": slogGroup{
+					"err": "boom",
+					"d":   "1s",
+				},
+			},
+		},
+	}
+	if diff := testutil.Diff(got, want); diff != "" {
+		t.Errorf("got=-, want=+:\n%s", diff)
+	}
+
+	// Empty groups are dropped.
+	r = slog.NewRecord(now, slog.LevelInfo, "bye", 0)
+	got = h.toEntry(r)
+	wantPayload := map[string]interface{}{
+		"message": "bye",
+		"a":       int64(1),
+		"req":     slogGroup{"method": "GET"},
+	}
+	if diff := testutil.Diff(got.Payload, wantPayload); diff != "" {
+		t.Errorf("got=-, want=+:\n%s", diff)
+	}
+}
+
+func TestSlogLevelToSeverity(t *testing.T) {
+	for _, tc := range []struct {
+		level slog.Level
+		want  Severity
+	}{
+		{slog.LevelDebug, Debug},
+		{slog.LevelInfo, Info},
+		{slog.LevelInfo + 2, Notice},
+		{slog.LevelWarn, Warning},
+		{slog.LevelError, Error},
+		{slog.LevelError + 4, Critical},
+		{slog.LevelError + 8, Alert},
+		{slog.LevelError + 12, Emergency},
+	} {
+		if got := slogLevelToSeverity(tc.level); got != tc.want {
+			t.Errorf("%v: got %v, want %v", tc.level, got, tc.want)
+		}
+	}
+}
+
+func TestSlogHandlerEnabled(t *testing.T) {
+	h := NewSlogHandler(nil, SlogLevel(slog.LevelWarn))
+	if h.Enabled(context.Background(), slog.LevelInfo) {
+		t.Error("Info enabled with minimum level Warn")
+	}
+	if !h.Enabled(context.Background(), slog.LevelError) {
+		t.Error("Error not enabled with minimum level Warn")
+	}
+}
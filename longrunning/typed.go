@@ -0,0 +1,220 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package longrunning
+
+import (
+	"context"
+	"time"
+
+	pb "cloud.google.com/go/longrunning/autogen/longrunningpb"
+	gax "github.com/googleapis/gax-go/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// cancelTimeout bounds the request that cancels an operation after the
+// context of Wait is done.
+const cancelTimeout = 30 * time.Second
+
+// A TypedOperation is a long-running operation whose response is of type T
+// and whose metadata is of type M, both pointers to generated protocol buffer
+// messages, such as *instancepb.Instance.
+type TypedOperation[T, M protoreflect.ProtoMessage] struct {
+	op *Operation
+}
+
+// NewTypedOperation returns a TypedOperation for op.
+func NewTypedOperation[T, M protoreflect.ProtoMessage](op *Operation) *TypedOperation[T, M] {
+	return &TypedOperation[T, M]{op: op}
+}
+
+// Operation returns the underlying operation.
+func (o *TypedOperation[T, M]) Operation() *Operation {
+	return o.op
+}
+
+// Name returns the name of the long-running operation.
+func (o *TypedOperation[T, M]) Name() string {
+	return o.op.Name()
+}
+
+// Done reports whether the long-running operation has completed.
+func (o *TypedOperation[T, M]) Done() bool {
+	return o.op.Done()
+}
+
+// Metadata returns the metadata of the operation as of the last poll. If the
+// operation contains no metadata, Metadata returns ErrNoMetadata.
+func (o *TypedOperation[T, M]) Metadata() (M, error) {
+	var zero M
+	a := o.op.proto.GetMetadata()
+	if a == nil {
+		return zero, ErrNoMetadata
+	}
+	m := newMessage[M]()
+	if err := a.UnmarshalTo(m); err != nil {
+		return zero, err
+	}
+	return m, nil
+}
+
+// Poll fetches the latest state of the operation. If the operation has
+// completed successfully, Poll returns its response; if it is still running,
+// Poll returns the zero value of T and a nil error. See Operation.Poll for
+// error handling.
+func (o *TypedOperation[T, M]) Poll(ctx context.Context, opts ...gax.CallOption) (T, error) {
+	var zero T
+	if err := o.op.Poll(ctx, nil, opts...); err != nil {
+		return zero, err
+	}
+	if !o.op.Done() {
+		return zero, nil
+	}
+	resp := newMessage[T]()
+	if err := o.op.proto.GetResponse().UnmarshalTo(resp); err != nil {
+		return zero, err
+	}
+	return resp, nil
+}
+
+// A WaitOption configures TypedOperation.Wait.
+type WaitOption func(*waitSettings)
+
+type waitSettings struct {
+	backoff         gax.Backoff
+	callOpts        []gax.CallOption
+	useWait         bool
+	cancelOnCtxDone bool
+	sleep           sleeper
+}
+
+// WithBackoff sets the backoff between polls of the operation. The default is
+// to poll after 1 second, then back off exponentially up to
+// DefaultWaitInterval.
+func WithBackoff(bo gax.Backoff) WaitOption {
+	return func(s *waitSettings) { s.backoff = bo }
+}
+
+// WithCallOptions sets the options of the calls that poll the operation.
+func WithCallOptions(opts ...gax.CallOption) WaitOption {
+	return func(s *waitSettings) { s.callOpts = opts }
+}
+
+// UseWaitOperation makes Wait call the WaitOperation method, which blocks
+// on the server until the operation is done or a timeout expires, instead of
+// polling with GetOperation. The timeout is the maximum backoff. Wait falls
+// back to polling if the service does not implement WaitOperation.
+func UseWaitOperation() WaitOption {
+	return func(s *waitSettings) { s.useWait = true }
+}
+
+// CancelOnContextDone makes Wait cancel the operation when its context is
+// done, rather than leaving it running.
+func CancelOnContextDone() WaitOption {
+	return func(s *waitSettings) { s.cancelOnCtxDone = true }
+}
+
+type operationWaiter interface {
+	WaitOperation(context.Context, *pb.WaitOperationRequest, ...gax.CallOption) (*pb.Operation, error)
+}
+
+// Wait blocks until the operation is completed and returns its response. If
+// progress is not nil, it is called with the metadata of the operation each
+// time the operation is polled and has metadata.
+//
+// See Operation.Poll for error handling. If ctx is done first, Wait returns
+// the context's error.
+func (o *TypedOperation[T, M]) Wait(ctx context.Context, progress func(M), opts ...WaitOption) (T, error) {
+	s := &waitSettings{
+		backoff: gax.Backoff{
+			Initial: 1 * time.Second,
+			Max:     DefaultWaitInterval,
+		},
+		sleep: gax.Sleep,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return o.wait(ctx, progress, s)
+}
+
+func (o *TypedOperation[T, M]) wait(ctx context.Context, progress func(M), s *waitSettings) (T, error) {
+	var zero T
+	waiter, _ := o.op.c.(operationWaiter)
+	useWait := s.useWait && waiter != nil
+	for {
+		var resp T
+		var err error
+		if useWait && !o.op.Done() {
+			var p *pb.Operation
+			p, err = waiter.WaitOperation(ctx, &pb.WaitOperationRequest{
+				Name:    o.op.Name(),
+				Timeout: durationpb.New(s.backoff.Max),
+			}, s.callOpts...)
+			if status.Code(err) == codes.Unimplemented {
+				useWait = false
+				continue
+			}
+			if err == nil {
+				o.op.proto = p
+				if p.Done {
+					// Poll does not fetch a completed operation.
+					resp, err = o.Poll(ctx)
+				}
+			}
+		} else {
+			resp, err = o.Poll(ctx, s.callOpts...)
+		}
+		if err != nil {
+			return zero, o.canceled(ctx, s, err)
+		}
+		if progress != nil {
+			if m, err := o.Metadata(); err == nil {
+				progress(m)
+			}
+		}
+		if o.op.Done() {
+			return resp, nil
+		}
+		if useWait {
+			continue
+		}
+		if err := s.sleep(ctx, s.backoff.Pause()); err != nil {
+			return zero, o.canceled(ctx, s, err)
+		}
+	}
+}
+
+// canceled cancels the operation if ctx is done and s says so, and returns
+// err.
+func (o *TypedOperation[T, M]) canceled(ctx context.Context, s *waitSettings, err error) error {
+	if ctx.Err() == nil || !s.cancelOnCtxDone {
+		return err
+	}
+	cctx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
+	defer cancel()
+	// Cancellation is best effort.
+	o.op.Cancel(cctx)
+	return err
+}
+
+// newMessage returns a new message of type P, a pointer to a generated
+// message.
+func newMessage[P protoreflect.ProtoMessage]() P {
+	var p P
+	return p.ProtoReflect().Type().New().Interface().(P)
+}
@@ -0,0 +1,172 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package longrunning
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "cloud.google.com/go/longrunning/autogen/longrunningpb"
+	gax "github.com/googleapis/gax-go/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// typedService serves an operation that completes after a number of
+// GetOperation or WaitOperation calls, with a timestamp as metadata and a
+// duration as response.
+type typedService struct {
+	operationsClient
+
+	polls, waits, cancels int
+	doneAfter             int
+	waitUnimplemented     bool
+}
+
+func (s *typedService) op() (*pb.Operation, error) {
+	n := s.polls + s.waits
+	meta, err := anypb.New(timestamppb.New(time.Unix(int64(n), 0)))
+	if err != nil {
+		return nil, err
+	}
+	op := &pb.Operation{Name: "foo", Metadata: meta}
+	if n >= s.doneAfter {
+		resp, err := anypb.New(durationpb.New(42 * time.Second))
+		if err != nil {
+			return nil, err
+		}
+		op.Done = true
+		op.Result = &pb.Operation_Response{Response: resp}
+	}
+	return op, nil
+}
+
+func (s *typedService) GetOperation(context.Context, *pb.GetOperationRequest, ...gax.CallOption) (*pb.Operation, error) {
+	s.polls++
+	return s.op()
+}
+
+func (s *typedService) WaitOperation(_ context.Context, req *pb.WaitOperationRequest, _ ...gax.CallOption) (*pb.Operation, error) {
+	if s.waitUnimplemented {
+		return nil, status.Error(codes.Unimplemented, "no WaitOperation")
+	}
+	if req.Timeout.AsDuration() != time.Minute {
+		return nil, status.Errorf(codes.InvalidArgument, "got timeout %v", req.Timeout.AsDuration())
+	}
+	s.waits++
+	return s.op()
+}
+
+func (s *typedService) CancelOperation(context.Context, *pb.CancelOperationRequest, ...gax.CallOption) error {
+	s.cancels++
+	return nil
+}
+
+func newTypedTestOperation(s *typedService) *TypedOperation[*durationpb.Duration, *timestamppb.Timestamp] {
+	return NewTypedOperation[*durationpb.Duration, *timestamppb.Timestamp](&Operation{c: s, proto: &pb.Operation{Name: "foo"}})
+}
+
+func noSleep(context.Context, time.Duration) error { return nil }
+
+func TestTypedWait(t *testing.T) {
+	s := &typedService{doneAfter: 3}
+	op := newTypedTestOperation(s)
+	if _, err := op.Metadata(); err != ErrNoMetadata {
+		t.Errorf("got error %v, want ErrNoMetadata", err)
+	}
+	var progress []int64
+	resp, err := op.wait(context.Background(), func(m *timestamppb.Timestamp) {
+		progress = append(progress, m.Seconds)
+	}, &waitSettings{sleep: noSleep})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := resp.AsDuration(), 42*time.Second; got != want {
+		t.Errorf("got response %v, want %v", got, want)
+	}
+	if got, want := len(progress), 3; got != want || progress[2] != 3 {
+		t.Errorf("got progress %v, want 3 reports ending with 3", progress)
+	}
+	if s.polls != 3 {
+		t.Errorf("got %d polls, want 3", s.polls)
+	}
+}
+
+func TestTypedWaitOperation(t *testing.T) {
+	for _, unimplemented := range []bool{false, true} {
+		s := &typedService{doneAfter: 2, waitUnimplemented: unimplemented}
+		op := newTypedTestOperation(s)
+		resp, err := op.wait(context.Background(), nil, &waitSettings{
+			backoff: gax.Backoff{Max: time.Minute},
+			useWait: true,
+			sleep:   noSleep,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.AsDuration() != 42*time.Second {
+			t.Errorf("got response %v", resp)
+		}
+		wantPolls, wantWaits := 0, 2
+		if unimplemented {
+			wantPolls, wantWaits = 2, 0
+		}
+		if s.polls != wantPolls || s.waits != wantWaits {
+			t.Errorf("unimplemented=%t: got %d polls and %d waits, want %d and %d", unimplemented, s.polls, s.waits, wantPolls, wantWaits)
+		}
+	}
+}
+
+func TestTypedWaitCancelOnContextDone(t *testing.T) {
+	for _, cancelOp := range []bool{false, true} {
+		s := &typedService{doneAfter: 100}
+		op := newTypedTestOperation(s)
+		ctx, cancel := context.WithCancel(context.Background())
+		_, err := op.wait(ctx, nil, &waitSettings{
+			cancelOnCtxDone: cancelOp,
+			sleep: func(ctx context.Context, _ time.Duration) error {
+				cancel()
+				return ctx.Err()
+			},
+		})
+		if err != context.Canceled {
+			t.Errorf("got error %v, want context.Canceled", err)
+		}
+		want := 0
+		if cancelOp {
+			want = 1
+		}
+		if s.cancels != want {
+			t.Errorf("cancelOnCtxDone=%t: got %d cancels, want %d", cancelOp, s.cancels, want)
+		}
+	}
+}
+
+func TestTypedPollNotDone(t *testing.T) {
+	s := &typedService{doneAfter: 2}
+	op := newTypedTestOperation(s)
+	resp, err := op.Poll(context.Background())
+	if err != nil || resp != nil || op.Done() {
+		t.Errorf("got %v, %v, done %t; want nil, nil, false", resp, err, op.Done())
+	}
+	m, err := op.Metadata()
+	if err != nil || m.Seconds != 1 {
+		t.Errorf("got metadata %v, %v; want 1 second", m, err)
+	}
+}
@@ -6,6 +6,7 @@ require (
 	cloud.google.com/go v0.112.0
 	cloud.google.com/go/longrunning v0.5.5
 	github.com/golang/protobuf v1.5.3
+	github.com/google/go-cmp v0.6.0
 	github.com/googleapis/gax-go/v2 v2.12.1
 	google.golang.org/api v0.166.0
 	google.golang.org/genproto v0.0.0-20240213162025-012b6fc9bca9
@@ -22,7 +23,6 @@ require (
 	github.com/go-logr/logr v1.4.1 // indirect
 	github.com/go-logr/stdr v1.2.2 // indirect
 	github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
-	github.com/google/go-cmp v0.6.0 // indirect
 	github.com/google/s2a-go v0.1.7 // indirect
 	github.com/googleapis/enterprise-certificate-proxy v0.3.2 // indirect
 	go.opencensus.io v0.24.0 // indirect
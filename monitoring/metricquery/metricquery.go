@@ -0,0 +1,435 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metricquery runs Monitoring Query Language (MQL) and PromQL queries
+// against Cloud Monitoring and returns their results as typed time series.
+//
+// MQL queries are run with the QueryTimeSeries method of the monitoring/apiv3
+// QueryClient, reading all the pages of the result. PromQL queries are run
+// against the Prometheus-compatible HTTP API of Cloud Monitoring.
+//
+// Example:
+//
+//	c, err := metricquery.NewClient(ctx, "my-project")
+//	if err != nil {
+//		// TODO: Handle error.
+//	}
+//	defer c.Close()
+//	res, err := c.QueryMQL(ctx, "fetch gce_instance::compute.googleapis.com/instance/cpu/utilization | within 1h")
+//	if err != nil {
+//		// TODO: Handle error.
+//	}
+//	for _, ts := range res.Series {
+//		fmt.Println(ts.Labels["resource.instance_id"], ts.Points[0].Values[0].Float)
+//	}
+package metricquery // import "cloud.google.com/go/monitoring/metricquery"
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	monitoring "cloud.google.com/go/monitoring/apiv3/v2"
+	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
+	"google.golang.org/genproto/googleapis/api/distribution"
+)
+
+// prometheusEndpoint is the base URL of the Prometheus-compatible API.
+const prometheusEndpoint = "https://monitoring.googleapis.com/v1"
+
+// promValueKey is the key of the value column of PromQL results.
+const promValueKey = "value"
+
+// Client runs queries against the time series of a project.
+type Client struct {
+	projectID    string
+	query        *monitoring.QueryClient
+	http         *http.Client
+	promEndpoint string
+}
+
+// NewClient returns a Client that queries the time series of the project with
+// the given ID. The options configure both the gRPC connection used for MQL
+// queries and the HTTP client used for PromQL queries.
+func NewClient(ctx context.Context, projectID string, opts ...option.ClientOption) (*Client, error) {
+	if projectID == "" {
+		return nil, errors.New("metricquery: missing project ID")
+	}
+	qc, err := monitoring.NewQueryClient(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	hopts := append([]option.ClientOption{option.WithScopes(monitoring.DefaultAuthScopes()...)}, opts...)
+	hc, _, err := htransport.NewClient(ctx, hopts...)
+	if err != nil {
+		qc.Close()
+		return nil, err
+	}
+	return &Client{
+		projectID:    projectID,
+		query:        qc,
+		http:         hc,
+		promEndpoint: prometheusEndpoint,
+	}, nil
+}
+
+// Close closes the connection of the client.
+func (c *Client) Close() error {
+	return c.query.Close()
+}
+
+// Result is the result of a query.
+type Result struct {
+	// ValueKeys are the names of the value columns of the points, in the
+	// order of Point.Values. The result of a PromQL query has a single column,
+	// "value".
+	ValueKeys []string
+
+	// Series are the time series of the result.
+	Series []*TimeSeries
+
+	// Warnings are the messages of the errors or warnings that may have caused
+	// the result to be incomplete.
+	Warnings []string
+}
+
+// TimeSeries is a time series of a query result.
+type TimeSeries struct {
+	// Labels identify the time series. The labels of the result of an MQL
+	// query are prefixed with their kind, as in "resource.zone" or
+	// "metric.response_code"; boolean and integer label values are formatted
+	// as strings.
+	Labels map[string]string
+
+	// Points are the points of the time series, in the order returned by the
+	// query: most recent first for MQL, oldest first for PromQL.
+	Points []Point
+}
+
+// Point is a point of a time series.
+type Point struct {
+	// Start is the start of the interval of the point. It is zero for gauge
+	// values and for the results of PromQL queries.
+	Start time.Time
+
+	// End is the end of the interval of the point, which is the time of the
+	// point for gauge values.
+	End time.Time
+
+	// Values are the values of the point, one for each of Result.ValueKeys.
+	Values []Value
+}
+
+// Value is a value of a point.
+type Value struct {
+	// Float is the value of a numeric column: a DOUBLE or INT64 value, or 1 or
+	// 0 for a BOOL value.
+	Float float64
+
+	// String is the value of a STRING column.
+	String string
+
+	// Distribution is the value of a DISTRIBUTION column, and nil otherwise.
+	Distribution *Distribution
+}
+
+// Distribution is a distribution value.
+type Distribution struct {
+	// Count is the number of values in the distribution.
+	Count int64
+
+	// Mean is the mean of the values, or zero if Count is zero.
+	Mean float64
+
+	// SumOfSquaredDeviation is the sum of the squared deviations of the values
+	// from their mean.
+	SumOfSquaredDeviation float64
+
+	// Bounds are the bounds of the finite buckets of the distribution, in
+	// increasing order. Bucket i counts the values in [Bounds[i-1], Bounds[i]);
+	// the first bucket is the underflow bucket and the last one the overflow
+	// bucket.
+	Bounds []float64
+
+	// BucketCounts are the number of values in each bucket. It has
+	// len(Bounds)+1 elements, or none if the distribution has no buckets.
+	BucketCounts []int64
+}
+
+// QueryMQL runs an MQL query and returns all the time series of its result.
+func (c *Client) QueryMQL(ctx context.Context, query string) (*Result, error) {
+	it := c.query.QueryTimeSeries(ctx, &monitoringpb.QueryTimeSeriesRequest{
+		Name:  "projects/" + c.projectID,
+		Query: query,
+	})
+	res := &Result{}
+	var desc *monitoringpb.TimeSeriesDescriptor
+	var lastPage *monitoringpb.QueryTimeSeriesResponse
+	for {
+		d, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Each page carries the descriptor and the partial errors of the
+		// time series it holds.
+		if resp, ok := it.Response.(*monitoringpb.QueryTimeSeriesResponse); ok && resp != lastPage {
+			lastPage = resp
+			if desc == nil && resp.TimeSeriesDescriptor != nil {
+				desc = resp.TimeSeriesDescriptor
+				for _, pd := range desc.PointDescriptors {
+					res.ValueKeys = append(res.ValueKeys, pd.Key)
+				}
+			}
+			for _, s := range resp.PartialErrors {
+				res.Warnings = append(res.Warnings, s.GetMessage())
+			}
+		}
+		ts, err := fromTimeSeriesData(desc, d)
+		if err != nil {
+			return nil, err
+		}
+		res.Series = append(res.Series, ts)
+	}
+	return res, nil
+}
+
+func fromTimeSeriesData(desc *monitoringpb.TimeSeriesDescriptor, d *monitoringpb.TimeSeriesData) (*TimeSeries, error) {
+	if desc == nil || len(d.LabelValues) != len(desc.LabelDescriptors) {
+		return nil, errors.New("metricquery: time series do not match their descriptor")
+	}
+	ts := &TimeSeries{Labels: make(map[string]string, len(d.LabelValues))}
+	for i, lv := range d.LabelValues {
+		var v string
+		switch x := lv.Value.(type) {
+		case *monitoringpb.LabelValue_BoolValue:
+			v = strconv.FormatBool(x.BoolValue)
+		case *monitoringpb.LabelValue_Int64Value:
+			v = strconv.FormatInt(x.Int64Value, 10)
+		case *monitoringpb.LabelValue_StringValue:
+			v = x.StringValue
+		}
+		ts.Labels[desc.LabelDescriptors[i].Key] = v
+	}
+	for _, pd := range d.PointData {
+		p := Point{Values: make([]Value, len(pd.Values))}
+		if iv := pd.TimeInterval; iv != nil {
+			if iv.StartTime != nil {
+				p.Start = iv.StartTime.AsTime()
+			}
+			if iv.EndTime != nil {
+				p.End = iv.EndTime.AsTime()
+			}
+		}
+		for i, tv := range pd.Values {
+			p.Values[i] = fromTypedValue(tv)
+		}
+		ts.Points = append(ts.Points, p)
+	}
+	return ts, nil
+}
+
+func fromTypedValue(tv *monitoringpb.TypedValue) Value {
+	switch x := tv.GetValue().(type) {
+	case *monitoringpb.TypedValue_BoolValue:
+		if x.BoolValue {
+			return Value{Float: 1}
+		}
+		return Value{Float: 0}
+	case *monitoringpb.TypedValue_Int64Value:
+		return Value{Float: float64(x.Int64Value)}
+	case *monitoringpb.TypedValue_DoubleValue:
+		return Value{Float: x.DoubleValue}
+	case *monitoringpb.TypedValue_StringValue:
+		return Value{String: x.StringValue}
+	case *monitoringpb.TypedValue_DistributionValue:
+		return Value{Distribution: fromDistribution(x.DistributionValue)}
+	}
+	return Value{}
+}
+
+func fromDistribution(d *distribution.Distribution) *Distribution {
+	return &Distribution{
+		Count:                 d.GetCount(),
+		Mean:                  d.GetMean(),
+		SumOfSquaredDeviation: d.GetSumOfSquaredDeviation(),
+		Bounds:                bucketBounds(d.GetBucketOptions()),
+		BucketCounts:          d.GetBucketCounts(),
+	}
+}
+
+// bucketBounds returns the bounds of the finite buckets described by o.
+func bucketBounds(o *distribution.Distribution_BucketOptions) []float64 {
+	switch {
+	case o.GetLinearBuckets() != nil:
+		l := o.GetLinearBuckets()
+		b := make([]float64, l.NumFiniteBuckets+1)
+		for i := range b {
+			b[i] = l.Offset + l.Width*float64(i)
+		}
+		return b
+	case o.GetExponentialBuckets() != nil:
+		e := o.GetExponentialBuckets()
+		b := make([]float64, e.NumFiniteBuckets+1)
+		for i := range b {
+			b[i] = e.Scale * math.Pow(e.GrowthFactor, float64(i))
+		}
+		return b
+	case o.GetExplicitBuckets() != nil:
+		return o.GetExplicitBuckets().Bounds
+	}
+	return nil
+}
+
+// QueryPromQL runs a PromQL instant query, evaluated at t, or at the current
+// time if t is zero.
+func (c *Client) QueryPromQL(ctx context.Context, query string, t time.Time) (*Result, error) {
+	v := url.Values{"query": {query}}
+	if !t.IsZero() {
+		v.Set("time", formatPromTime(t))
+	}
+	return c.queryPrometheus(ctx, "query", v)
+}
+
+// QueryPromQLRange runs a PromQL range query, evaluated every step from start
+// to end.
+func (c *Client) QueryPromQLRange(ctx context.Context, query string, start, end time.Time, step time.Duration) (*Result, error) {
+	if step <= 0 {
+		return nil, fmt.Errorf("metricquery: step %v is not positive", step)
+	}
+	v := url.Values{
+		"query": {query},
+		"start": {formatPromTime(start)},
+		"end":   {formatPromTime(end)},
+		"step":  {strconv.FormatFloat(step.Seconds(), 'f', -1, 64)},
+	}
+	return c.queryPrometheus(ctx, "query_range", v)
+}
+
+func formatPromTime(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixNano())/1e9, 'f', -1, 64)
+}
+
+// promResponse is the response of the Prometheus HTTP API.
+type promResponse struct {
+	Status    string   `json:"status"`
+	ErrorType string   `json:"errorType"`
+	Error     string   `json:"error"`
+	Warnings  []string `json:"warnings"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// promSample is an element of a vector or matrix result.
+type promSample struct {
+	Metric map[string]string `json:"metric"`
+	Value  []interface{}     `json:"value"`
+	Values [][]interface{}   `json:"values"`
+}
+
+func (c *Client) queryPrometheus(ctx context.Context, method string, v url.Values) (*Result, error) {
+	u := fmt.Sprintf("%s/projects/%s/location/global/prometheus/api/v1/%s", c.promEndpoint, url.PathEscape(c.projectID), method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, strings.NewReader(v.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var pr promResponse
+	if err := json.Unmarshal(body, &pr); err != nil {
+		return nil, fmt.Errorf("metricquery: %s: bad response: %w", resp.Status, err)
+	}
+	if pr.Status != "success" {
+		return nil, fmt.Errorf("metricquery: %s: %s: %s", resp.Status, pr.ErrorType, pr.Error)
+	}
+	res := &Result{ValueKeys: []string{promValueKey}, Warnings: pr.Warnings}
+	switch pr.Data.ResultType {
+	case "vector", "matrix":
+		var samples []promSample
+		if err := json.Unmarshal(pr.Data.Result, &samples); err != nil {
+			return nil, fmt.Errorf("metricquery: bad %s result: %w", pr.Data.ResultType, err)
+		}
+		for _, s := range samples {
+			ts := &TimeSeries{Labels: s.Metric}
+			if s.Value != nil {
+				s.Values = append(s.Values, s.Value)
+			}
+			for _, sv := range s.Values {
+				p, err := parsePromPoint(sv)
+				if err != nil {
+					return nil, err
+				}
+				ts.Points = append(ts.Points, p)
+			}
+			res.Series = append(res.Series, ts)
+		}
+	case "scalar":
+		var sv []interface{}
+		if err := json.Unmarshal(pr.Data.Result, &sv); err != nil {
+			return nil, fmt.Errorf("metricquery: bad scalar result: %w", err)
+		}
+		p, err := parsePromPoint(sv)
+		if err != nil {
+			return nil, err
+		}
+		res.Series = []*TimeSeries{{Labels: map[string]string{}, Points: []Point{p}}}
+	default:
+		return nil, fmt.Errorf("metricquery: unsupported result type %q", pr.Data.ResultType)
+	}
+	return res, nil
+}
+
+// parsePromPoint parses a [time, "value"] pair of the Prometheus API.
+func parsePromPoint(sv []interface{}) (Point, error) {
+	if len(sv) != 2 {
+		return Point{}, fmt.Errorf("metricquery: bad sample %v", sv)
+	}
+	secs, ok1 := sv[0].(float64)
+	s, ok2 := sv[1].(string)
+	if !ok1 || !ok2 {
+		return Point{}, fmt.Errorf("metricquery: bad sample %v", sv)
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return Point{}, fmt.Errorf("metricquery: bad sample value %q", s)
+	}
+	sec, frac := math.Modf(secs)
+	return Point{
+		End:    time.Unix(int64(sec), int64(math.Round(frac*1e3))*1e6).UTC(),
+		Values: []Value{{Float: f}},
+	}, nil
+}
@@ -0,0 +1,217 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metricquery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/internal/testutil"
+	monitoring "cloud.google.com/go/monitoring/apiv3/v2"
+	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/api/option"
+	"google.golang.org/genproto/googleapis/api/distribution"
+	"google.golang.org/genproto/googleapis/api/label"
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type fakeQueryServer struct {
+	monitoringpb.UnimplementedQueryServiceServer
+	pages []*monitoringpb.QueryTimeSeriesResponse
+}
+
+func (s *fakeQueryServer) QueryTimeSeries(_ context.Context, req *monitoringpb.QueryTimeSeriesRequest) (*monitoringpb.QueryTimeSeriesResponse, error) {
+	if req.Name != "projects/p" {
+		return nil, fmt.Errorf("bad name %q", req.Name)
+	}
+	i := 0
+	if req.PageToken != "" {
+		fmt.Sscan(req.PageToken, &i)
+	}
+	return s.pages[i], nil
+}
+
+func newTestClient(t *testing.T, qs *fakeQueryServer, h http.HandlerFunc) *Client {
+	t.Helper()
+	c := &Client{projectID: "p"}
+	if qs != nil {
+		srv, err := testutil.NewServer()
+		if err != nil {
+			t.Fatal(err)
+		}
+		monitoringpb.RegisterQueryServiceServer(srv.Gsrv, qs)
+		srv.Start()
+		t.Cleanup(srv.Close)
+		conn, err := grpc.Dial(srv.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.query, err = monitoring.NewQueryClient(context.Background(), option.WithGRPCConn(conn))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { c.Close() })
+	}
+	if h != nil {
+		hs := httptest.NewServer(h)
+		t.Cleanup(hs.Close)
+		c.http = hs.Client()
+		c.promEndpoint = hs.URL
+	}
+	return c
+}
+
+func TestQueryMQL(t *testing.T) {
+	t0 := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	interval := &monitoringpb.TimeInterval{StartTime: timestamppb.New(t0), EndTime: timestamppb.New(t0.Add(time.Minute))}
+	desc := &monitoringpb.TimeSeriesDescriptor{
+		LabelDescriptors: []*label.LabelDescriptor{{Key: "resource.zone"}, {Key: "metric.code"}},
+		PointDescriptors: []*monitoringpb.TimeSeriesDescriptor_ValueDescriptor{{Key: "count"}, {Key: "latency"}},
+	}
+	series := func(zone string, code int64, count int64) *monitoringpb.TimeSeriesData {
+		return &monitoringpb.TimeSeriesData{
+			LabelValues: []*monitoringpb.LabelValue{
+				{Value: &monitoringpb.LabelValue_StringValue{StringValue: zone}},
+				{Value: &monitoringpb.LabelValue_Int64Value{Int64Value: code}},
+			},
+			PointData: []*monitoringpb.TimeSeriesData_PointData{{
+				TimeInterval: interval,
+				Values: []*monitoringpb.TypedValue{
+					{Value: &monitoringpb.TypedValue_Int64Value{Int64Value: count}},
+					{Value: &monitoringpb.TypedValue_DistributionValue{DistributionValue: &distribution.Distribution{
+						Count: count,
+						Mean:  2,
+						BucketOptions: &distribution.Distribution_BucketOptions{
+							Options: &distribution.Distribution_BucketOptions_ExponentialBuckets{
+								ExponentialBuckets: &distribution.Distribution_BucketOptions_Exponential{NumFiniteBuckets: 2, GrowthFactor: 2, Scale: 1},
+							},
+						},
+						BucketCounts: []int64{0, count, 0, 0},
+					}}},
+				},
+			}},
+		}
+	}
+	qs := &fakeQueryServer{pages: []*monitoringpb.QueryTimeSeriesResponse{
+		{
+			TimeSeriesDescriptor: desc,
+			TimeSeriesData:       []*monitoringpb.TimeSeriesData{series("us-east1-b", 200, 3)},
+			NextPageToken:        "1",
+		},
+		{
+			TimeSeriesDescriptor: desc,
+			TimeSeriesData:       []*monitoringpb.TimeSeriesData{series("us-east1-c", 500, 1)},
+			PartialErrors:        []*status.Status{{Message: "incomplete"}},
+		},
+	}}
+	c := newTestClient(t, qs, nil)
+
+	got, err := c.QueryMQL(context.Background(), "fetch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	point := func(count int64) Point {
+		return Point{
+			Start: t0,
+			End:   t0.Add(time.Minute),
+			Values: []Value{
+				{Float: float64(count)},
+				{Distribution: &Distribution{Count: count, Mean: 2, Bounds: []float64{1, 2, 4}, BucketCounts: []int64{0, count, 0, 0}}},
+			},
+		}
+	}
+	want := &Result{
+		ValueKeys: []string{"count", "latency"},
+		Series: []*TimeSeries{
+			{Labels: map[string]string{"resource.zone": "us-east1-b", "metric.code": "200"}, Points: []Point{point(3)}},
+			{Labels: map[string]string{"resource.zone": "us-east1-c", "metric.code": "500"}, Points: []Point{point(1)}},
+		},
+		Warnings: []string{"incomplete"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("got(-),want(+):\n%s", diff)
+	}
+}
+
+func TestQueryPromQL(t *testing.T) {
+	var gotPath, gotQuery, gotTime string
+	c := newTestClient(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery, gotTime = r.URL.Path, r.FormValue("query"), r.FormValue("time")
+		fmt.Fprint(w, `{"status":"success","warnings":["w"],"data":{"resultType":"vector","result":[
+			{"metric":{"job":"a"},"value":[1709294400.5,"1.5"]},
+			{"metric":{"job":"b"},"value":[1709294400.5,"NaN"]}]}}`)
+	})
+	t0 := time.Unix(1709294400, 500e6)
+	got, err := c.QueryPromQL(context.Background(), "up", t0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/projects/p/location/global/prometheus/api/v1/query"; gotPath != want {
+		t.Errorf("got path %q, want %q", gotPath, want)
+	}
+	if gotQuery != "up" || gotTime != "1709294400.5" {
+		t.Errorf("got query %q at %q, want \"up\" at 1709294400.5", gotQuery, gotTime)
+	}
+	if len(got.Series) != 2 || got.Series[0].Labels["job"] != "a" || got.Warnings[0] != "w" {
+		t.Fatalf("got %+v", got)
+	}
+	if p := got.Series[0].Points[0]; !p.End.Equal(t0) || p.Values[0].Float != 1.5 {
+		t.Errorf("got point %+v, want 1.5 at %v", p, t0)
+	}
+	if f := got.Series[1].Points[0].Values[0].Float; f == f {
+		t.Errorf("got %v, want NaN", f)
+	}
+}
+
+func TestQueryPromQLRange(t *testing.T) {
+	var gotStep string
+	c := newTestClient(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		gotStep = r.FormValue("step")
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"matrix","result":[
+			{"metric":{"job":"a"},"values":[[100,"1"],[130,"2"]]}]}}`)
+	})
+	got, err := c.QueryPromQLRange(context.Background(), "up", time.Unix(100, 0), time.Unix(130, 0), 30*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotStep != "30" {
+		t.Errorf("got step %q, want 30", gotStep)
+	}
+	want := []Point{
+		{End: time.Unix(100, 0).UTC(), Values: []Value{{Float: 1}}},
+		{End: time.Unix(130, 0).UTC(), Values: []Value{{Float: 2}}},
+	}
+	if diff := cmp.Diff(want, got.Series[0].Points); diff != "" {
+		t.Errorf("got(-),want(+):\n%s", diff)
+	}
+}
+
+func TestQueryPromQLError(t *testing.T) {
+	c := newTestClient(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"status":"error","errorType":"bad_data","error":"parse error"}`)
+	})
+	if _, err := c.QueryPromQL(context.Background(), "up{", time.Time{}); err == nil {
+		t.Error("got no error")
+	}
+}
@@ -0,0 +1,415 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metricwriter writes custom metrics to Cloud Monitoring with the
+// CreateTimeSeries method of the monitoring/apiv3 MetricClient.
+//
+// A Writer accepts counter, gauge and distribution updates in-process,
+// aggregates them locally, and periodically writes one point per time series,
+// in batches of at most 200 time series per request, as Cloud Monitoring
+// requires. Counters and distributions are written as CUMULATIVE metrics and
+// gauges as GAUGE metrics.
+//
+// Example:
+//
+//	client, err := monitoring.NewMetricClient(ctx)
+//	if err != nil {
+//		// TODO: Handle error.
+//	}
+//	w, err := metricwriter.New(client, metricwriter.Config{ProjectID: "my-project"})
+//	if err != nil {
+//		// TODO: Handle error.
+//	}
+//	defer w.Close(ctx)
+//	w.Counter("custom.googleapis.com/requests", map[string]string{"method": "GET"}, 1)
+//	w.Distribution("custom.googleapis.com/latency", nil, 12.5)
+package metricwriter // import "cloud.google.com/go/monitoring/metricwriter"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	gax "github.com/googleapis/gax-go/v2"
+	"google.golang.org/genproto/googleapis/api/distribution"
+	"google.golang.org/genproto/googleapis/api/metric"
+	"google.golang.org/genproto/googleapis/api/monitoredres"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// DefaultInterval is the default interval at which a Writer writes its
+	// time series.
+	DefaultInterval = time.Minute
+
+	// maxTimeSeriesPerRequest is the maximum number of time series that a
+	// CreateTimeSeries request can write.
+	maxTimeSeriesPerRequest = 200
+
+	// minInterval is the minimum interval between two points of a time
+	// series allowed by Cloud Monitoring.
+	minInterval = 5 * time.Second
+)
+
+// DefaultDistributionBounds are the bucket bounds of distributions without
+// bounds in Config.DistributionBounds: powers of 2 from 1 to 2^20.
+var DefaultDistributionBounds = func() []float64 {
+	b := make([]float64, 21)
+	for i := range b {
+		b[i] = math.Pow(2, float64(i))
+	}
+	return b
+}()
+
+// Client is the subset of the methods of monitoring.MetricClient used by
+// Writer.
+type Client interface {
+	CreateTimeSeries(context.Context, *monitoringpb.CreateTimeSeriesRequest, ...gax.CallOption) error
+}
+
+// Config is the configuration of a Writer.
+type Config struct {
+	// ProjectID is the ID of the project to write the metrics to. It is
+	// required.
+	ProjectID string
+
+	// Resource is the monitored resource of the time series. It defaults to
+	// the "global" resource of the project.
+	Resource *monitoredres.MonitoredResource
+
+	// Interval is the interval at which the time series are written. It
+	// defaults to DefaultInterval, and must be at least 5 seconds.
+	Interval time.Duration
+
+	// DistributionBounds are the bucket bounds of distributions by metric
+	// type, in increasing order. Distributions of the other metric types use
+	// DefaultDistributionBounds.
+	DistributionBounds map[string][]float64
+
+	// OnError is called when writing time series in the background fails,
+	// or when a metric is updated as a different kind of metric than it was
+	// before. By default, errors are logged.
+	OnError func(err error)
+}
+
+type seriesKind int
+
+const (
+	counterKind seriesKind = iota
+	gaugeKind
+	distributionKind
+)
+
+func (k seriesKind) String() string {
+	return [...]string{"counter", "gauge", "distribution"}[k]
+}
+
+// series is the local aggregate of a time series.
+type series struct {
+	kind       seriesKind
+	metricType string
+	labels     map[string]string
+	start      time.Time
+	// dirty reports whether the series was updated since it was last
+	// written.
+	dirty bool
+
+	count int64   // of counters, and of the values of distributions
+	value float64 // of gauges
+
+	// Of distributions: the mean and the sum of squared deviations of the
+	// values, maintained with Welford's algorithm, and the bucket counts.
+	mean, m2     float64
+	bounds       []float64
+	bucketCounts []int64
+}
+
+// A Writer aggregates metric updates and writes them to Cloud Monitoring. It
+// is safe for concurrent use.
+type Writer struct {
+	client Client
+	cfg    Config
+
+	mu     sync.Mutex
+	series map[string]*series
+
+	// writeMu serializes writes, so that the points of a series are written
+	// in order.
+	writeMu sync.Mutex
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// For testing.
+var now = time.Now
+
+// New returns a Writer that writes metrics with client every cfg.Interval.
+func New(client Client, cfg Config) (*Writer, error) {
+	if cfg.ProjectID == "" {
+		return nil, errors.New("metricwriter: project ID is required")
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if cfg.Interval < minInterval {
+		return nil, fmt.Errorf("metricwriter: interval %v is shorter than %v", cfg.Interval, minInterval)
+	}
+	if cfg.Resource == nil {
+		cfg.Resource = &monitoredres.MonitoredResource{
+			Type:   "global",
+			Labels: map[string]string{"project_id": cfg.ProjectID},
+		}
+	}
+	w := &Writer{
+		client: client,
+		cfg:    cfg,
+		series: map[string]*series{},
+		done:   make(chan struct{}),
+	}
+	w.wg.Add(1)
+	go w.writeLoop()
+	return w, nil
+}
+
+// Counter adds delta to the counter with the given metric type and labels.
+func (w *Writer) Counter(metricType string, labels map[string]string, delta int64) {
+	w.update(counterKind, metricType, labels, func(s *series) {
+		s.count += delta
+	})
+}
+
+// Gauge sets the gauge with the given metric type and labels to value. Only
+// the last value set in each interval is written.
+func (w *Writer) Gauge(metricType string, labels map[string]string, value float64) {
+	w.update(gaugeKind, metricType, labels, func(s *series) {
+		s.value = value
+	})
+}
+
+// Distribution adds value to the distribution with the given metric type and
+// labels.
+func (w *Writer) Distribution(metricType string, labels map[string]string, value float64) {
+	w.update(distributionKind, metricType, labels, func(s *series) {
+		s.count++
+		d := value - s.mean
+		s.mean += d / float64(s.count)
+		s.m2 += d * (value - s.mean)
+		// Bucket i holds the values in [bounds[i-1], bounds[i]).
+		s.bucketCounts[sort.Search(len(s.bounds), func(i int) bool { return s.bounds[i] > value })]++
+	})
+}
+
+func (w *Writer) update(kind seriesKind, metricType string, labels map[string]string, f func(*series)) {
+	key := seriesKey(metricType, labels)
+	w.mu.Lock()
+	s, ok := w.series[key]
+	if !ok {
+		s = w.newSeries(kind, metricType, labels)
+		w.series[key] = s
+	}
+	if s.kind == kind {
+		f(s)
+		s.dirty = true
+	}
+	w.mu.Unlock()
+	if s.kind != kind {
+		w.onError(fmt.Errorf("metricwriter: %s %s updated as a %s", s.kind, metricType, kind))
+	}
+}
+
+func (w *Writer) newSeries(kind seriesKind, metricType string, labels map[string]string) *series {
+	s := &series{
+		kind:       kind,
+		metricType: metricType,
+		labels:     make(map[string]string, len(labels)),
+		// The start time of a cumulative series must be earlier than the
+		// end time of its first point.
+		start: now().Add(-time.Millisecond),
+	}
+	for k, v := range labels {
+		s.labels[k] = v
+	}
+	if kind == distributionKind {
+		s.bounds = w.cfg.DistributionBounds[metricType]
+		if s.bounds == nil {
+			s.bounds = DefaultDistributionBounds
+		}
+		// There is an underflow and an overflow bucket.
+		s.bucketCounts = make([]int64, len(s.bounds)+1)
+	}
+	return s
+}
+
+// seriesKey returns a key that identifies a time series among the series of
+// a Writer.
+func seriesKey(metricType string, labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(metricType)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "\x00%s\x00%s", k, labels[k])
+	}
+	return b.String()
+}
+
+// Flush writes the time series updated since they were last written. It
+// returns the first error encountered; the time series of the requests that
+// fail are written again by the next Flush. Cloud Monitoring rejects the
+// points of a time series written less than 5 seconds apart, so Flush should
+// not be called more often.
+func (w *Writer) Flush(ctx context.Context) error {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+
+	ss, ts := w.collect()
+	var firstErr error
+	for len(ts) > 0 {
+		n := len(ts)
+		if n > maxTimeSeriesPerRequest {
+			n = maxTimeSeriesPerRequest
+		}
+		err := w.client.CreateTimeSeries(ctx, &monitoringpb.CreateTimeSeriesRequest{
+			Name:       "projects/" + w.cfg.ProjectID,
+			TimeSeries: ts[:n],
+		})
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			w.markDirty(ss[:n])
+		}
+		ss, ts = ss[n:], ts[n:]
+	}
+	return firstErr
+}
+
+// collect returns the series to write and their time series, with one point
+// each, and marks the series as written.
+func (w *Writer) collect() ([]*series, []*monitoringpb.TimeSeries) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	end := timestamppb.New(now())
+	var ss []*series
+	var ts []*monitoringpb.TimeSeries
+	for _, s := range w.series {
+		if !s.dirty {
+			continue
+		}
+		s.dirty = false
+		ss = append(ss, s)
+		ts = append(ts, w.timeSeries(s, end))
+	}
+	return ss, ts
+}
+
+// markDirty marks series that failed to be written as not written, so that
+// the next Flush writes them again.
+func (w *Writer) markDirty(ss []*series) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, s := range ss {
+		s.dirty = true
+	}
+}
+
+func (w *Writer) timeSeries(s *series, end *timestamppb.Timestamp) *monitoringpb.TimeSeries {
+	t := &monitoringpb.TimeSeries{
+		Metric:   &metric.Metric{Type: s.metricType, Labels: s.labels},
+		Resource: w.cfg.Resource,
+	}
+	p := &monitoringpb.Point{
+		Interval: &monitoringpb.TimeInterval{EndTime: end},
+		Value:    &monitoringpb.TypedValue{},
+	}
+	switch s.kind {
+	case counterKind:
+		t.MetricKind = metric.MetricDescriptor_CUMULATIVE
+		t.ValueType = metric.MetricDescriptor_INT64
+		p.Interval.StartTime = timestamppb.New(s.start)
+		p.Value.Value = &monitoringpb.TypedValue_Int64Value{Int64Value: s.count}
+	case gaugeKind:
+		t.MetricKind = metric.MetricDescriptor_GAUGE
+		t.ValueType = metric.MetricDescriptor_DOUBLE
+		p.Value.Value = &monitoringpb.TypedValue_DoubleValue{DoubleValue: s.value}
+	case distributionKind:
+		t.MetricKind = metric.MetricDescriptor_CUMULATIVE
+		t.ValueType = metric.MetricDescriptor_DISTRIBUTION
+		p.Interval.StartTime = timestamppb.New(s.start)
+		p.Value.Value = &monitoringpb.TypedValue_DistributionValue{DistributionValue: &distribution.Distribution{
+			Count:                 s.count,
+			Mean:                  s.mean,
+			SumOfSquaredDeviation: s.m2,
+			BucketOptions: &distribution.Distribution_BucketOptions{
+				Options: &distribution.Distribution_BucketOptions_ExplicitBuckets{
+					ExplicitBuckets: &distribution.Distribution_BucketOptions_Explicit{Bounds: s.bounds},
+				},
+			},
+			BucketCounts: append([]int64(nil), s.bucketCounts...),
+		}}
+	}
+	t.Points = []*monitoringpb.Point{p}
+	return t
+}
+
+func (w *Writer) writeLoop() {
+	defer w.wg.Done()
+	t := time.NewTicker(w.cfg.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-t.C:
+			ctx, cancel := context.WithTimeout(context.Background(), w.cfg.Interval)
+			if err := w.Flush(ctx); err != nil {
+				w.onError(fmt.Errorf("metricwriter: writing time series: %w", err))
+			}
+			cancel()
+		}
+	}
+}
+
+// Close stops writing the time series in the background, and writes the
+// time series updated since they were last written. Only the first call
+// writes them; later calls wait for it to complete and return nil.
+func (w *Writer) Close(ctx context.Context) error {
+	var err error
+	w.closeOnce.Do(func() {
+		close(w.done)
+		w.wg.Wait()
+		err = w.Flush(ctx)
+	})
+	return err
+}
+
+func (w *Writer) onError(err error) {
+	if w.cfg.OnError != nil {
+		w.cfg.OnError(err)
+		return
+	}
+	log.Println(err)
+}
@@ -0,0 +1,227 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metricwriter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	gax "github.com/googleapis/gax-go/v2"
+	"google.golang.org/genproto/googleapis/api/metric"
+)
+
+type fakeClient struct {
+	mu   sync.Mutex
+	reqs []*monitoringpb.CreateTimeSeriesRequest
+	// err, if not nil, is returned by the requests, which are not recorded.
+	err error
+}
+
+func (c *fakeClient) CreateTimeSeries(_ context.Context, req *monitoringpb.CreateTimeSeriesRequest, _ ...gax.CallOption) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	c.reqs = append(c.reqs, req)
+	return nil
+}
+
+// byType returns the time series written by the last request by metric type.
+func (c *fakeClient) byType() map[string]*monitoringpb.TimeSeries {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m := map[string]*monitoringpb.TimeSeries{}
+	for _, ts := range c.reqs[len(c.reqs)-1].TimeSeries {
+		m[ts.Metric.Type] = ts
+	}
+	return m
+}
+
+func newTestWriter(t *testing.T, fc *fakeClient, cfg Config) *Writer {
+	t.Helper()
+	cfg.ProjectID = "p"
+	// Keep the background loop out of the way.
+	cfg.Interval = time.Hour
+	w, err := New(fc, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { w.Close(context.Background()) })
+	return w
+}
+
+func TestWriterAggregation(t *testing.T) {
+	ctx := context.Background()
+	fc := &fakeClient{}
+	w := newTestWriter(t, fc, Config{DistributionBounds: map[string][]float64{"dist": {10, 20}}})
+
+	w.Counter("count", nil, 2)
+	w.Counter("count", nil, 3)
+	w.Gauge("gauge", nil, 1)
+	w.Gauge("gauge", nil, 7.5)
+	for _, v := range []float64{5, 10, 15, 25} {
+		w.Distribution("dist", nil, v)
+	}
+	if err := w.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(fc.reqs) != 1 || fc.reqs[0].Name != "projects/p" {
+		t.Fatalf("got requests %v, want one for projects/p", fc.reqs)
+	}
+	got := fc.byType()
+
+	c := got["count"]
+	if c.MetricKind != metric.MetricDescriptor_CUMULATIVE || c.Points[0].Value.GetInt64Value() != 5 {
+		t.Errorf("got counter %v, want cumulative 5", c)
+	}
+	if iv := c.Points[0].Interval; !iv.StartTime.AsTime().Before(iv.EndTime.AsTime()) {
+		t.Errorf("got interval %v, want start before end", iv)
+	}
+	if c.Resource.Type != "global" || c.Resource.Labels["project_id"] != "p" {
+		t.Errorf("got resource %v, want global resource of p", c.Resource)
+	}
+
+	g := got["gauge"]
+	if g.MetricKind != metric.MetricDescriptor_GAUGE || g.Points[0].Value.GetDoubleValue() != 7.5 || g.Points[0].Interval.StartTime != nil {
+		t.Errorf("got gauge %v, want 7.5", g)
+	}
+
+	d := got["dist"].Points[0].Value.GetDistributionValue()
+	if d.Count != 4 || d.Mean != 13.75 || d.SumOfSquaredDeviation != 218.75 {
+		t.Errorf("got distribution count %d, mean %v, ssd %v; want 4, 13.75, 218.75", d.Count, d.Mean, d.SumOfSquaredDeviation)
+	}
+	if want := []int64{1, 2, 1}; fmt.Sprint(d.BucketCounts) != fmt.Sprint(want) {
+		t.Errorf("got bucket counts %v, want %v", d.BucketCounts, want)
+	}
+
+	// Only the series updated since the last write are written, counters
+	// cumulatively.
+	w.Counter("count", nil, 1)
+	if err := w.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	got = fc.byType()
+	if len(got) != 1 || got["count"].Points[0].Value.GetInt64Value() != 6 {
+		t.Errorf("got %v, want the counter only, at 6", got)
+	}
+	// Nothing to write.
+	if err := w.Flush(ctx); err != nil || len(fc.reqs) != 2 {
+		t.Errorf("got error %v and %d requests, want no new request", err, len(fc.reqs))
+	}
+}
+
+func TestWriterBatching(t *testing.T) {
+	fc := &fakeClient{}
+	w := newTestWriter(t, fc, Config{})
+	for i := 0; i < 450; i++ {
+		w.Counter("count", map[string]string{"i": fmt.Sprint(i)}, 1)
+	}
+	if err := w.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var sizes []int
+	for _, req := range fc.reqs {
+		sizes = append(sizes, len(req.TimeSeries))
+	}
+	if fmt.Sprint(sizes) != "[200 200 50]" {
+		t.Errorf("got batches of %v time series, want [200 200 50]", sizes)
+	}
+}
+
+func TestWriterRetriesFailedSeries(t *testing.T) {
+	ctx := context.Background()
+	fc := &fakeClient{err: errors.New("unavailable")}
+	w := newTestWriter(t, fc, Config{})
+	w.Counter("count", nil, 2)
+	if err := w.Flush(ctx); err == nil {
+		t.Fatal("got no error")
+	}
+
+	fc.mu.Lock()
+	fc.err = nil
+	fc.mu.Unlock()
+	w.Counter("count", nil, 1)
+	w.Gauge("gauge", nil, 1)
+	if err := w.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	got := fc.byType()
+	if len(got) != 2 || got["count"].Points[0].Value.GetInt64Value() != 3 {
+		t.Errorf("got %v, want the counter at 3 and the gauge", got)
+	}
+}
+
+func TestWriterConcurrentClose(t *testing.T) {
+	fc := &fakeClient{}
+	w, err := New(fc, Config{ProjectID: "p"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Counter("count", nil, 1)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := w.Close(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(fc.reqs) != 1 {
+		t.Errorf("got %d requests, want the counter written once", len(fc.reqs))
+	}
+}
+
+func TestWriterKindMismatch(t *testing.T) {
+	var errs []error
+	fc := &fakeClient{}
+	w := newTestWriter(t, fc, Config{OnError: func(err error) { errs = append(errs, err) }})
+	w.Counter("m", nil, 1)
+	w.Gauge("m", nil, 1)
+	if len(errs) != 1 {
+		t.Errorf("got errors %v, want one", errs)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	for _, cfg := range []Config{
+		{},
+		{ProjectID: "p", Interval: time.Second},
+	} {
+		if _, err := New(&fakeClient{}, cfg); err == nil {
+			t.Errorf("%+v: got no error", cfg)
+		}
+	}
+}
+
+func TestSeriesKey(t *testing.T) {
+	a := seriesKey("m", map[string]string{"a": "1", "b": "2"})
+	b := seriesKey("m", map[string]string{"b": "2", "a": "1"})
+	c := seriesKey("m", map[string]string{"a": "1\x00b", "": "2"})
+	if a != b {
+		t.Errorf("keys of the same labels differ: %q, %q", a, b)
+	}
+	if a == c {
+		t.Errorf("keys of different labels are equal: %q", a)
+	}
+}
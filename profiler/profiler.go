@@ -32,6 +32,11 @@
 // profiling can be enabled in the config. Note that goroutine and mutex
 // profiles are shown as "threads" and "contention" profiles in the profiler
 // UI.
+//
+// The version, zone and custom labels of the profiled deployment can be
+// changed while the profiler runs with UpdateLabels. Stop stops the profiler,
+// and Restart restarts it with a new config, for instance when a long-running
+// program reloads its configuration.
 package profiler
 
 import (
@@ -81,6 +86,11 @@ var (
 	dialGRPC         = gtransport.DialPool
 	onGCE            = gcemd.OnGCE
 	serviceRegexp    = regexp.MustCompile(`^[a-z0-9]([-a-z0-9_.]{0,253}[a-z0-9])?$`)
+	labelRegexp      = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+	// running is the profiler started by Start, or nil if it is not running.
+	// It is guarded by startOnce.m.
+	running *session
 
 	// For testing only.
 	// When the profiling loop has exited without error and this channel is
@@ -186,6 +196,13 @@ type Config struct {
 	// the metadata server is present but is flaky or otherwise misbehave.
 	Zone string
 
+	// Labels are custom labels of the deployment, which identify it among the
+	// deployments of the same service, in addition to the version and zone.
+	// Label names must match the regular expression
+	// ^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$ and must not be "zone", "version"
+	// or "language". Optional.
+	Labels map[string]string
+
 	// numProfiles is the number of profiles which should be collected before
 	// the profile collection loop exits.When numProfiles is 0, profiles will
 	// be collected for the duration of the program. For testing only.
@@ -220,7 +237,7 @@ func (o *allowUntilSuccess) do(f func() error) (err error) {
 // Start starts a goroutine to collect and upload profiles. The
 // caller must provide the service string in the config. See
 // Config for details. Start should only be called once. Any
-// additional calls will be ignored.
+// additional calls will be ignored, until Stop is called.
 func Start(cfg Config, options ...option.ClientOption) error {
 	startError := startOnce.do(func() error {
 		return start(cfg, options...)
@@ -228,6 +245,103 @@ func Start(cfg Config, options ...option.ClientOption) error {
 	return startError
 }
 
+// Stop stops the collection and upload of profiles started by Start. It waits
+// for the profile being collected, if any, to be abandoned. After Stop, Start
+// can be called again. Stop does nothing if the profiler is not running.
+func Stop() {
+	startOnce.m.Lock()
+	defer startOnce.m.Unlock()
+	stop()
+}
+
+// Restart stops the profiler, if it is running, and starts it again with the
+// given config, for instance to change the service name or the enabled
+// profile types when a program reloads its configuration. To only change the
+// labels of the deployment, use UpdateLabels.
+func Restart(cfg Config, options ...option.ClientOption) error {
+	startOnce.m.Lock()
+	defer startOnce.m.Unlock()
+	stop()
+	if err := start(cfg, options...); err != nil {
+		return err
+	}
+	startOnce.done = 1
+	return nil
+}
+
+// stop stops the running profiler. It must be called with startOnce.m held.
+func stop() {
+	if running == nil {
+		return
+	}
+	running.cancel()
+	<-running.done
+	if err := running.conn.Close(); err != nil {
+		debugLog("failed to close the connection: %v", err)
+	}
+	debugLog("profiler has stopped")
+	running = nil
+	startOnce.done = 0
+}
+
+// Labels are the labels of the profiled deployment that can be changed while
+// the profiler runs. See Config for their meaning.
+type Labels struct {
+	ServiceVersion string
+	Zone           string
+	Custom         map[string]string
+}
+
+// UpdateLabels replaces the labels of the profiled deployment. Profiles
+// collected from then on are recorded under the new labels. It returns an
+// error if the profiler is not running or a custom label is invalid.
+func UpdateLabels(l Labels) error {
+	startOnce.m.Lock()
+	defer startOnce.m.Unlock()
+	if running == nil {
+		return errors.New("profiler is not running")
+	}
+	labels, err := deploymentLabels(l.ServiceVersion, l.Zone, l.Custom)
+	if err != nil {
+		return err
+	}
+	a := running.agent
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// The deployment is replaced rather than modified, since the request
+	// being sent, if any, refers to it.
+	d := &pb.Deployment{
+		ProjectId: a.deployment.ProjectId,
+		Target:    a.deployment.Target,
+		Labels:    labels,
+	}
+	a.deployment = d
+	debugLog("updated deployment labels to %v", labels)
+	return nil
+}
+
+// deploymentLabels returns the labels of the deployment with the given
+// version, zone and custom labels.
+func deploymentLabels(version, zone string, custom map[string]string) (map[string]string, error) {
+	labels := map[string]string{languageLabel: "go"}
+	for k, v := range custom {
+		switch {
+		case k == languageLabel || k == zoneNameLabel || k == versionLabel:
+			return nil, fmt.Errorf("label %q is reserved", k)
+		case !labelRegexp.MatchString(k):
+			return nil, fmt.Errorf("label name %q does not match regular expression %v", k, labelRegexp)
+		}
+		labels[k] = v
+	}
+	if zone != "" {
+		labels[zoneNameLabel] = zone
+	}
+	if version != "" {
+		labels[versionLabel] = version
+	}
+	return labels, nil
+}
+
 func start(cfg Config, options ...option.ClientOption) error {
 	if cfg.DebugLoggingOutput == nil {
 		cfg.DebugLoggingOutput = os.Stderr
@@ -264,12 +378,28 @@ func start(cfg Config, options ...option.ClientOption) error {
 	a, err := initializeAgent(pb.NewProfilerServiceClient(connPool))
 	if err != nil {
 		debugLog("failed to start the profiling agent: %v", err)
+		connPool.Close()
 		return err
 	}
-	go pollProfilerService(withXGoogHeader(ctx), a)
+	ctx, cancel := context.WithCancel(ctx)
+	s := &session{agent: a, conn: connPool, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		pollProfilerService(withXGoogHeader(ctx), a)
+	}()
+	running = s
 	return nil
 }
 
+// session is a running profiler.
+type session struct {
+	agent  *agent
+	conn   io.Closer
+	cancel context.CancelFunc
+	// done is closed when the profiling loop has exited.
+	done chan struct{}
+}
+
 func debugLog(format string, e ...interface{}) {
 	if config.DebugLogging {
 		logger.Printf(format, e...)
@@ -280,9 +410,11 @@ func debugLog(format string, e ...interface{}) {
 // and collects and uploads profiles as requested.
 type agent struct {
 	client        pb.ProfilerServiceClient
-	deployment    *pb.Deployment
 	profileLabels map[string]string
 	profileTypes  []pb.ProfileType
+
+	mu         sync.Mutex // guards deployment
+	deployment *pb.Deployment
 }
 
 // abortedBackoffDuration retrieves the retry duration from gRPC trailing
@@ -328,9 +460,12 @@ func (r *retryer) Retry(err error) (time.Duration, bool) {
 // increasing value, bounded by maxBackoff. Special handling for
 // certificate errors is described below.
 func (a *agent) createProfile(ctx context.Context) *pb.Profile {
+	a.mu.Lock()
+	d := a.deployment
+	a.mu.Unlock()
 	req := pb.CreateProfileRequest{
-		Parent:      "projects/" + a.deployment.ProjectId,
-		Deployment:  a.deployment,
+		Parent:      "projects/" + d.ProjectId,
+		Deployment:  d,
 		ProfileType: a.profileTypes,
 	}
 
@@ -483,12 +618,9 @@ func withXGoogHeader(ctx context.Context, keyval ...string) context.Context {
 // profile collection should not be started because collection is disabled
 // for all profile types.
 func initializeAgent(c pb.ProfilerServiceClient) (*agent, error) {
-	labels := map[string]string{languageLabel: "go"}
-	if config.Zone != "" {
-		labels[zoneNameLabel] = config.Zone
-	}
-	if config.ServiceVersion != "" {
-		labels[versionLabel] = config.ServiceVersion
+	labels, err := deploymentLabels(config.ServiceVersion, config.Zone, config.Labels)
+	if err != nil {
+		return nil, err
 	}
 	d := &pb.Deployment{
 		ProjectId: config.ProjectID,
@@ -609,6 +741,10 @@ func pollProfilerService(ctx context.Context, a *agent) {
 	debugLog("profiler has started")
 	for i := 0; config.numProfiles == 0 || i < config.numProfiles; i++ {
 		p := a.createProfile(ctx)
+		if ctx.Err() != nil {
+			// The profiler was stopped.
+			return
+		}
 		a.profileAndUpload(ctx, p)
 	}
 
@@ -24,6 +24,7 @@ import (
 	"log"
 	"math/rand"
 	"os"
+	"reflect"
 	"runtime"
 	"strings"
 	"sync"
@@ -430,10 +431,20 @@ func TestInitializeAgent(t *testing.T) {
 			wantDeploymentLabels: map[string]string{languageLabel: "go"},
 			wantProfileLabels:    map[string]string{},
 		},
+		{
+			config:               Config{Zone: testZone, Labels: map[string]string{"env": "prod"}},
+			wantProfileTypes:     []pb.ProfileType{pb.ProfileType_CPU, pb.ProfileType_HEAP, pb.ProfileType_THREADS, pb.ProfileType_HEAP_ALLOC},
+			wantDeploymentLabels: map[string]string{zoneNameLabel: testZone, languageLabel: "go", "env": "prod"},
+			wantProfileLabels:    map[string]string{},
+		},
 		{
 			config:  Config{NoCPUProfiling: true, NoHeapProfiling: true, NoGoroutineProfiling: true, NoAllocProfiling: true},
 			wantErr: true,
 		},
+		{
+			config:  Config{Labels: map[string]string{versionLabel: testSvcVersion}},
+			wantErr: true,
+		},
 	} {
 
 		config = tt.config
@@ -725,7 +736,7 @@ func TestInitializeConfig(t *testing.T) {
 		if tt.wantErrorString == "" {
 			tt.wantConfig.APIAddr = apiAddress
 		}
-		if config != tt.wantConfig {
+		if !reflect.DeepEqual(config, tt.wantConfig) {
 			t.Errorf("initializeConfig(%v) got: %v, want %v", tt.config, config, tt.wantConfig)
 		}
 	}
@@ -1011,3 +1022,92 @@ type testConnPool struct{ *grpc.ClientConn }
 
 func (p testConnPool) Num() int               { return 1 }
 func (p testConnPool) Conn() *grpc.ClientConn { return p.ClientConn }
+
+func TestDeploymentLabels(t *testing.T) {
+	for _, tt := range []struct {
+		custom  map[string]string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			custom: nil,
+			want:   map[string]string{languageLabel: "go", versionLabel: testSvcVersion, zoneNameLabel: testZone},
+		},
+		{
+			custom: map[string]string{"env": "prod", "shard-1": "a"},
+			want:   map[string]string{languageLabel: "go", versionLabel: testSvcVersion, zoneNameLabel: testZone, "env": "prod", "shard-1": "a"},
+		},
+		{custom: map[string]string{languageLabel: "rust"}, wantErr: true},
+		{custom: map[string]string{"Env": "prod"}, wantErr: true},
+		{custom: map[string]string{"env-": "prod"}, wantErr: true},
+	} {
+		got, err := deploymentLabels(testSvcVersion, testZone, tt.custom)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("deploymentLabels(%v) got error %v, want error %t", tt.custom, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !testutil.Equal(got, tt.want) {
+			t.Errorf("deploymentLabels(%v) got %v, want %v", tt.custom, got, tt.want)
+		}
+	}
+}
+
+type fakeCloser struct{ closed bool }
+
+func (c *fakeCloser) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestUpdateLabelsAndStop(t *testing.T) {
+	oldRunning, oldDone := running, startOnce.done
+	defer func() {
+		running, startOnce.done = oldRunning, oldDone
+	}()
+	running = nil
+
+	if err := UpdateLabels(Labels{}); err == nil {
+		t.Error("UpdateLabels() got no error with the profiler not running, want error")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a := createTestAgent(nil)
+	conn := &fakeCloser{}
+	s := &session{agent: a, conn: conn, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		<-ctx.Done()
+		close(s.done)
+	}()
+	startOnce.m.Lock()
+	running, startOnce.done = s, 1
+	startOnce.m.Unlock()
+
+	old := a.deployment
+	if err := UpdateLabels(Labels{ServiceVersion: "v2", Custom: map[string]string{"env": "canary"}}); err != nil {
+		t.Fatalf("UpdateLabels() got error: %v", err)
+	}
+	want := &pb.Deployment{
+		ProjectId: testProjectID,
+		Target:    testService,
+		Labels:    map[string]string{languageLabel: "go", versionLabel: "v2", "env": "canary"},
+	}
+	if !testutil.Equal(a.deployment, want) {
+		t.Errorf("UpdateLabels() got deployment %v, want %v", a.deployment, want)
+	}
+	if !testutil.Equal(old, createTestDeployment()) {
+		t.Errorf("UpdateLabels() modified the previous deployment: %v", old)
+	}
+	if err := UpdateLabels(Labels{Custom: map[string]string{"Bad": "x"}}); err == nil {
+		t.Error("UpdateLabels() with an invalid label got no error, want error")
+	}
+
+	Stop()
+	if !conn.closed {
+		t.Error("Stop() did not close the connection")
+	}
+	if running != nil || startOnce.done != 0 {
+		t.Error("Stop() did not reset the profiler state")
+	}
+	// Stopping a stopped profiler does nothing.
+	Stop()
+}
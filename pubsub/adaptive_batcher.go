@@ -0,0 +1,137 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"sync"
+	"time"
+)
+
+// AdaptiveBatchingSettings configures adaptive batching for a topic's publisher.
+//
+// With adaptive batching, the publisher measures the latency of each publish
+// request, from when the oldest message in the batch was passed to Publish
+// until the server responded. While the average latency is above
+// TargetLatency, the delay and count thresholds are lowered so that batches are
+// sent sooner. While it is well below TargetLatency, they are raised back
+// towards PublishSettings.DelayThreshold and PublishSettings.CountThreshold so
+// that fewer, larger requests are sent.
+//
+// New thresholds apply to an ordering key once the messages already buffered
+// for that key have been published.
+type AdaptiveBatchingSettings struct {
+	// TargetLatency is the publish latency to aim for. If zero, adaptive
+	// batching is disabled.
+	TargetLatency time.Duration
+
+	// MinDelayThreshold is the lowest delay threshold that adaptive batching
+	// will use. Defaults to 1ms.
+	MinDelayThreshold time.Duration
+
+	// MinCountThreshold is the lowest count threshold that adaptive batching
+	// will use. Defaults to 1.
+	MinCountThreshold int
+}
+
+// adaptiveBatcherWeight is the weight given to the newest sample in the
+// moving average of publish latency.
+const adaptiveBatcherWeight = 0.2
+
+// adaptiveBatcher tunes batching thresholds from observed publish latencies.
+type adaptiveBatcher struct {
+	target             time.Duration
+	minDelay, maxDelay time.Duration
+	minCount, maxCount int
+
+	mu    sync.Mutex
+	avg   time.Duration // moving average of observed latencies
+	delay time.Duration
+	count int
+}
+
+// newAdaptiveBatcher returns an adaptiveBatcher that starts at, and never
+// exceeds, the given thresholds.
+func newAdaptiveBatcher(s AdaptiveBatchingSettings, maxDelay time.Duration, maxCount int) *adaptiveBatcher {
+	a := &adaptiveBatcher{
+		target:   s.TargetLatency,
+		minDelay: s.MinDelayThreshold,
+		maxDelay: maxDelay,
+		minCount: s.MinCountThreshold,
+		maxCount: maxCount,
+		delay:    maxDelay,
+		count:    maxCount,
+	}
+	if a.minDelay <= 0 {
+		a.minDelay = time.Millisecond
+	}
+	if a.minDelay > a.maxDelay {
+		a.minDelay = a.maxDelay
+	}
+	if a.minCount <= 0 {
+		a.minCount = 1
+	}
+	if a.minCount > a.maxCount {
+		a.minCount = a.maxCount
+	}
+	return a
+}
+
+// observe records the latency of a publish request. It returns the thresholds
+// to use from now on, and whether they differ from the previous ones.
+func (a *adaptiveBatcher) observe(latency time.Duration) (delay time.Duration, count int, changed bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.avg == 0 {
+		a.avg = latency
+	} else {
+		a.avg = time.Duration(adaptiveBatcherWeight*float64(latency) + (1-adaptiveBatcherWeight)*float64(a.avg))
+	}
+
+	delay, count = a.delay, a.count
+	switch {
+	case a.avg > a.target:
+		delay /= 2
+		count /= 2
+	case a.avg < a.target/2:
+		delay += delay/4 + time.Millisecond
+		count += count/4 + 1
+	}
+	delay = clampDuration(delay, a.minDelay, a.maxDelay)
+	count = clampInt(count, a.minCount, a.maxCount)
+
+	changed = delay != a.delay || count != a.count
+	a.delay, a.count = delay, count
+	return delay, count, changed
+}
+
+func clampDuration(d, lo, hi time.Duration) time.Duration {
+	if d < lo {
+		return lo
+	}
+	if d > hi {
+		return hi
+	}
+	return d
+}
+
+func clampInt(n, lo, hi int) int {
+	if n < lo {
+		return lo
+	}
+	if n > hi {
+		return hi
+	}
+	return n
+}
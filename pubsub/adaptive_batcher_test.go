@@ -0,0 +1,70 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdaptiveBatcher(t *testing.T) {
+	a := newAdaptiveBatcher(AdaptiveBatchingSettings{TargetLatency: 100 * time.Millisecond}, 40*time.Millisecond, 100)
+
+	// Latency above the target lowers the thresholds, down to the minimums.
+	for i := 0; i < 20; i++ {
+		a.observe(time.Second)
+	}
+	delay, count, changed := a.observe(time.Second)
+	if changed {
+		t.Error("observe() changed thresholds that were already at the minimum")
+	}
+	if delay != time.Millisecond || count != 1 {
+		t.Errorf("observe() got (%v, %d), want (%v, %d)", delay, count, time.Millisecond, 1)
+	}
+
+	// Latency well below the target raises them again, up to the maximums.
+	changed = false
+	for i := 0; i < 100; i++ {
+		var c bool
+		delay, count, c = a.observe(time.Millisecond)
+		changed = changed || c
+	}
+	if !changed {
+		t.Error("observe() did not change thresholds after latency dropped")
+	}
+	if delay != 40*time.Millisecond || count != 100 {
+		t.Errorf("observe() got (%v, %d), want (%v, %d)", delay, count, 40*time.Millisecond, 100)
+	}
+}
+
+func TestAdaptiveBatcherMinimums(t *testing.T) {
+	a := newAdaptiveBatcher(AdaptiveBatchingSettings{
+		TargetLatency:     time.Millisecond,
+		MinDelayThreshold: 5 * time.Millisecond,
+		MinCountThreshold: 500,
+	}, 10*time.Millisecond, 100)
+
+	for i := 0; i < 10; i++ {
+		a.observe(time.Second)
+	}
+	delay, count, _ := a.observe(time.Second)
+	if delay != 5*time.Millisecond {
+		t.Errorf("got delay %v, want %v", delay, 5*time.Millisecond)
+	}
+	// MinCountThreshold is capped at the maximum.
+	if count != 100 {
+		t.Errorf("got count %d, want %d", count, 100)
+	}
+}
@@ -0,0 +1,484 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// This file implements enough of the Avro specification
+// (https://avro.apache.org/docs/1.11.1/specification/) to check that a
+// message's data conforms to an Avro schema, in either the JSON or the binary
+// encoding. It does not decode values into Go types.
+
+// avroSchema is a parsed Avro schema node.
+type avroSchema struct {
+	// typ is a primitive type name, or one of "record", "enum", "array",
+	// "map", "fixed" or "union".
+	typ string
+	// name is the full name of a named type (record, enum or fixed).
+	name string
+
+	fields   []avroField   // record
+	symbols  []string      // enum
+	items    *avroSchema   // array
+	values   *avroSchema   // map
+	size     int           // fixed
+	branches []*avroSchema // union
+}
+
+type avroField struct {
+	name       string
+	schema     *avroSchema
+	hasDefault bool
+}
+
+var avroPrimitives = map[string]bool{
+	"null": true, "boolean": true, "int": true, "long": true,
+	"float": true, "double": true, "bytes": true, "string": true,
+}
+
+// parseAvroSchema parses an Avro schema definition.
+func parseAvroSchema(definition string) (*avroSchema, error) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(definition), &v); err != nil {
+		return nil, fmt.Errorf("avro: invalid schema JSON: %w", err)
+	}
+	p := &avroParser{named: map[string]*avroSchema{}}
+	return p.parse(v, "")
+}
+
+type avroParser struct {
+	named map[string]*avroSchema
+}
+
+func (p *avroParser) parse(v interface{}, namespace string) (*avroSchema, error) {
+	switch t := v.(type) {
+	case string:
+		if avroPrimitives[t] {
+			return &avroSchema{typ: t}, nil
+		}
+		if s, ok := p.named[fullAvroName(t, namespace)]; ok {
+			return s, nil
+		}
+		if s, ok := p.named[t]; ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("avro: unknown type %q", t)
+	case []interface{}:
+		u := &avroSchema{typ: "union"}
+		for _, b := range t {
+			bs, err := p.parse(b, namespace)
+			if err != nil {
+				return nil, err
+			}
+			if bs.typ == "union" {
+				return nil, errors.New("avro: unions may not immediately contain other unions")
+			}
+			u.branches = append(u.branches, bs)
+		}
+		return u, nil
+	case map[string]interface{}:
+		return p.parseComplex(t, namespace)
+	}
+	return nil, fmt.Errorf("avro: invalid schema %v", v)
+}
+
+func (p *avroParser) parseComplex(m map[string]interface{}, namespace string) (*avroSchema, error) {
+	typ, ok := m["type"].(string)
+	if !ok {
+		// A nested schema such as {"type": {"type": "array", ...}}.
+		if nested, ok := m["type"]; ok {
+			return p.parse(nested, namespace)
+		}
+		return nil, errors.New("avro: schema object has no type")
+	}
+	switch typ {
+	case "record", "error", "enum", "fixed":
+		name, _ := m["name"].(string)
+		if name == "" {
+			return nil, fmt.Errorf("avro: %s has no name", typ)
+		}
+		if ns, ok := m["namespace"].(string); ok {
+			namespace = ns
+		}
+		s := &avroSchema{typ: typ, name: fullAvroName(name, namespace)}
+		if i := strings.LastIndex(s.name, "."); i >= 0 {
+			namespace = s.name[:i]
+		}
+		// Register before parsing fields so records can be recursive.
+		p.named[s.name] = s
+		switch typ {
+		case "record", "error":
+			s.typ = "record"
+			fields, _ := m["fields"].([]interface{})
+			for _, f := range fields {
+				fm, ok := f.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("avro: invalid field in record %q", s.name)
+				}
+				fname, _ := fm["name"].(string)
+				fs, err := p.parse(fm["type"], namespace)
+				if err != nil {
+					return nil, fmt.Errorf("avro: field %q of record %q: %w", fname, s.name, err)
+				}
+				_, hasDefault := fm["default"]
+				s.fields = append(s.fields, avroField{name: fname, schema: fs, hasDefault: hasDefault})
+			}
+		case "enum":
+			syms, _ := m["symbols"].([]interface{})
+			for _, sym := range syms {
+				str, ok := sym.(string)
+				if !ok {
+					return nil, fmt.Errorf("avro: invalid symbol in enum %q", s.name)
+				}
+				s.symbols = append(s.symbols, str)
+			}
+		case "fixed":
+			size, ok := m["size"].(float64)
+			if !ok || size < 0 {
+				return nil, fmt.Errorf("avro: fixed %q has an invalid size", s.name)
+			}
+			s.size = int(size)
+		}
+		return s, nil
+	case "array":
+		items, err := p.parse(m["items"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &avroSchema{typ: "array", items: items}, nil
+	case "map":
+		values, err := p.parse(m["values"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &avroSchema{typ: "map", values: values}, nil
+	}
+	// Primitive types, possibly annotated with a logical type.
+	return p.parse(typ, namespace)
+}
+
+func fullAvroName(name, namespace string) string {
+	if strings.Contains(name, ".") || namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+// branchName is the name used to identify a union branch in the JSON encoding.
+func (s *avroSchema) branchName() string {
+	if s.name != "" {
+		return s.name
+	}
+	return s.typ
+}
+
+// validateJSON reports whether data is a valid JSON encoding of a value of s.
+func (s *avroSchema) validateJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("avro: invalid JSON: %w", err)
+	}
+	if dec.More() {
+		return errors.New("avro: trailing data after JSON value")
+	}
+	return s.checkJSON(v, "$")
+}
+
+func (s *avroSchema) checkJSON(v interface{}, path string) error {
+	mismatch := func() error {
+		return fmt.Errorf("avro: %s: value %v does not match type %s", path, v, s.branchName())
+	}
+	switch s.typ {
+	case "null":
+		if v != nil {
+			return mismatch()
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return mismatch()
+		}
+	case "int", "long":
+		n, ok := v.(json.Number)
+		if !ok {
+			return mismatch()
+		}
+		bits := 64
+		if s.typ == "int" {
+			bits = 32
+		}
+		if _, err := strconv.ParseInt(n.String(), 10, bits); err != nil {
+			return mismatch()
+		}
+	case "float", "double":
+		n, ok := v.(json.Number)
+		if !ok {
+			return mismatch()
+		}
+		if _, err := n.Float64(); err != nil {
+			return mismatch()
+		}
+	case "string":
+		if _, ok := v.(string); !ok {
+			return mismatch()
+		}
+	case "bytes", "fixed":
+		str, ok := v.(string)
+		if !ok {
+			return mismatch()
+		}
+		// Bytes are encoded as strings whose code points are the byte values.
+		n := 0
+		for _, r := range str {
+			if r > 0xff {
+				return mismatch()
+			}
+			n++
+		}
+		if s.typ == "fixed" && n != s.size {
+			return fmt.Errorf("avro: %s: fixed %s needs %d bytes, got %d", path, s.name, s.size, n)
+		}
+	case "enum":
+		str, ok := v.(string)
+		if !ok || !containsString(s.symbols, str) {
+			return mismatch()
+		}
+	case "array":
+		arr, ok := v.([]interface{})
+		if !ok {
+			return mismatch()
+		}
+		for i, e := range arr {
+			if err := s.items.checkJSON(e, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "map":
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return mismatch()
+		}
+		for k, e := range m {
+			if err := s.values.checkJSON(e, path+"."+k); err != nil {
+				return err
+			}
+		}
+	case "record":
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return mismatch()
+		}
+		known := make(map[string]bool, len(s.fields))
+		for _, f := range s.fields {
+			known[f.name] = true
+			fv, ok := m[f.name]
+			if !ok {
+				if f.hasDefault {
+					continue
+				}
+				return fmt.Errorf("avro: %s: missing field %q of record %s", path, f.name, s.name)
+			}
+			if err := f.schema.checkJSON(fv, path+"."+f.name); err != nil {
+				return err
+			}
+		}
+		for k := range m {
+			if !known[k] {
+				return fmt.Errorf("avro: %s: unknown field %q in record %s", path, k, s.name)
+			}
+		}
+	case "union":
+		if v == nil {
+			for _, b := range s.branches {
+				if b.typ == "null" {
+					return nil
+				}
+			}
+			return mismatch()
+		}
+		// Non-null union values are encoded as {"<branch name>": value}.
+		m, ok := v.(map[string]interface{})
+		if !ok || len(m) != 1 {
+			return fmt.Errorf("avro: %s: union value must be null or an object with a single key", path)
+		}
+		for k, bv := range m {
+			for _, b := range s.branches {
+				if b.branchName() == k {
+					return b.checkJSON(bv, path+"."+k)
+				}
+			}
+			return fmt.Errorf("avro: %s: %q is not a branch of the union", path, k)
+		}
+	default:
+		return fmt.Errorf("avro: %s: unsupported type %q", path, s.typ)
+	}
+	return nil
+}
+
+// validateBinary reports whether data is a valid binary encoding of a value
+// of s, with no trailing bytes.
+func (s *avroSchema) validateBinary(data []byte) error {
+	d := &avroDecoder{buf: data}
+	if err := s.skipBinary(d); err != nil {
+		return err
+	}
+	if len(d.buf) != 0 {
+		return fmt.Errorf("avro: %d trailing bytes after value", len(d.buf))
+	}
+	return nil
+}
+
+var errAvroShortBuffer = errors.New("avro: unexpected end of data")
+
+type avroDecoder struct {
+	buf []byte
+}
+
+func (d *avroDecoder) long() (int64, error) {
+	u, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		return 0, errAvroShortBuffer
+	}
+	d.buf = d.buf[n:]
+	// Zig-zag decode.
+	return int64(u>>1) ^ -int64(u&1), nil
+}
+
+func (d *avroDecoder) skip(n int64) ([]byte, error) {
+	if n < 0 || n > int64(len(d.buf)) {
+		return nil, errAvroShortBuffer
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b, nil
+}
+
+func (s *avroSchema) skipBinary(d *avroDecoder) error {
+	switch s.typ {
+	case "null":
+	case "boolean":
+		b, err := d.skip(1)
+		if err != nil {
+			return err
+		}
+		if b[0] > 1 {
+			return fmt.Errorf("avro: invalid boolean byte %d", b[0])
+		}
+	case "int", "long":
+		n, err := d.long()
+		if err != nil {
+			return err
+		}
+		if s.typ == "int" && (n < math.MinInt32 || n > math.MaxInt32) {
+			return fmt.Errorf("avro: value %d overflows int", n)
+		}
+	case "float":
+		_, err := d.skip(4)
+		return err
+	case "double":
+		_, err := d.skip(8)
+		return err
+	case "bytes", "string":
+		n, err := d.long()
+		if err != nil {
+			return err
+		}
+		b, err := d.skip(n)
+		if err != nil {
+			return err
+		}
+		if s.typ == "string" && !utf8.Valid(b) {
+			return errors.New("avro: string is not valid UTF-8")
+		}
+	case "fixed":
+		_, err := d.skip(int64(s.size))
+		return err
+	case "enum":
+		n, err := d.long()
+		if err != nil {
+			return err
+		}
+		if n < 0 || n >= int64(len(s.symbols)) {
+			return fmt.Errorf("avro: enum index %d out of range for %s", n, s.name)
+		}
+	case "record":
+		for _, f := range s.fields {
+			if err := f.schema.skipBinary(d); err != nil {
+				return err
+			}
+		}
+	case "array", "map":
+		for {
+			count, err := d.long()
+			if err != nil {
+				return err
+			}
+			if count == 0 {
+				return nil
+			}
+			if count < 0 {
+				// A negative count is followed by the block's size in bytes.
+				count = -count
+				if _, err := d.long(); err != nil {
+					return err
+				}
+			}
+			for i := int64(0); i < count; i++ {
+				if s.typ == "map" {
+					if err := (&avroSchema{typ: "string"}).skipBinary(d); err != nil {
+						return err
+					}
+					if err := s.values.skipBinary(d); err != nil {
+						return err
+					}
+				} else if err := s.items.skipBinary(d); err != nil {
+					return err
+				}
+			}
+		}
+	case "union":
+		n, err := d.long()
+		if err != nil {
+			return err
+		}
+		if n < 0 || n >= int64(len(s.branches)) {
+			return fmt.Errorf("avro: union index %d out of range", n)
+		}
+		return s.branches[n].skipBinary(d)
+	default:
+		return fmt.Errorf("avro: unsupported type %q", s.typ)
+	}
+	return nil
+}
+
+func containsString(ss []string, s string) bool {
+	for _, e := range ss {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import "testing"
+
+const testAvroSchema = `{
+	"type": "record",
+	"name": "Person",
+	"namespace": "example",
+	"fields": [
+		{"name": "name", "type": "string"},
+		{"name": "age", "type": "int"},
+		{"name": "email", "type": ["null", "string"], "default": null},
+		{"name": "tags", "type": {"type": "array", "items": "string"}, "default": []},
+		{"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["A", "B"]}, "default": "A"},
+		{"name": "friend", "type": ["null", "Person"], "default": null}
+	]
+}`
+
+func TestAvroValidateJSON(t *testing.T) {
+	s, err := parseAvroSchema(testAvroSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		msg     string
+		wantErr bool
+	}{
+		{msg: `{"name": "Al", "age": 21}`},
+		{msg: `{"name": "Al", "age": 21, "email": {"string": "al@example.com"}, "tags": ["x"], "kind": "B"}`},
+		{msg: `{"name": "Al", "age": 21, "friend": {"example.Person": {"name": "Bo", "age": 3}}}`},
+		{msg: `{"name": "Al"}`, wantErr: true},
+		{msg: `{"name": "Al", "age": 2147483648}`, wantErr: true},
+		{msg: `{"name": "Al", "age": 1.5}`, wantErr: true},
+		{msg: `{"name": "Al", "age": 21, "email": "al@example.com"}`, wantErr: true},
+		{msg: `{"name": "Al", "age": 21, "kind": "C"}`, wantErr: true},
+		{msg: `{"name": "Al", "age": 21, "extra": true}`, wantErr: true},
+		{msg: `{"name": "Al", "age": 21} {}`, wantErr: true},
+		{msg: `not json`, wantErr: true},
+	} {
+		err := s.validateJSON([]byte(tc.msg))
+		if gotErr := err != nil; gotErr != tc.wantErr {
+			t.Errorf("validateJSON(%s) got err %v, want error: %t", tc.msg, err, tc.wantErr)
+		}
+	}
+}
+
+func TestAvroValidateBinary(t *testing.T) {
+	s, err := parseAvroSchema(testAvroSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// name "Al", age 21, email null, tags ["x"], kind B, friend null.
+	valid := []byte{0x04, 'A', 'l', 0x2a, 0x00, 0x02, 0x02, 'x', 0x00, 0x02, 0x00}
+	for _, tc := range []struct {
+		desc    string
+		msg     []byte
+		wantErr bool
+	}{
+		{desc: "valid", msg: valid},
+		{desc: "negative block count", msg: []byte{0x04, 'A', 'l', 0x2a, 0x00, 0x01, 0x04, 0x02, 'x', 0x00, 0x02, 0x00}},
+		{desc: "truncated", msg: valid[:len(valid)-1], wantErr: true},
+		{desc: "trailing bytes", msg: append(append([]byte{}, valid...), 0x00), wantErr: true},
+		{desc: "bad union index", msg: []byte{0x04, 'A', 'l', 0x2a, 0x04}, wantErr: true},
+		{desc: "bad enum index", msg: []byte{0x04, 'A', 'l', 0x2a, 0x00, 0x00, 0x06, 0x00}, wantErr: true},
+		{desc: "invalid utf-8", msg: []byte{0x02, 0xff, 0x2a, 0x00, 0x00, 0x00, 0x00}, wantErr: true},
+	} {
+		err := s.validateBinary(tc.msg)
+		if gotErr := err != nil; gotErr != tc.wantErr {
+			t.Errorf("%s: validateBinary got err %v, want error: %t", tc.desc, err, tc.wantErr)
+		}
+	}
+}
+
+func TestParseAvroSchemaErrors(t *testing.T) {
+	for _, def := range []string{
+		`not json`,
+		`"Unknown"`,
+		`{"type": "record", "fields": []}`,
+		`[["null"], "string"]`,
+		`{"type": "fixed", "name": "F"}`,
+	} {
+		if _, err := parseAvroSchema(def); err == nil {
+			t.Errorf("parseAvroSchema(%s) succeeded, want error", def)
+		}
+	}
+}
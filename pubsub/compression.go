@@ -0,0 +1,102 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+// PayloadCompression is an algorithm used to compress message data before
+// it is published.
+type PayloadCompression int
+
+const (
+	// PayloadCompressionNone publishes message data as is.
+	PayloadCompressionNone PayloadCompression = iota
+	// PayloadCompressionGzip compresses message data with gzip.
+	PayloadCompressionGzip
+)
+
+// payloadEncodingAttr is the message attribute that marks compressed data.
+// Its value names the compression algorithm.
+const payloadEncodingAttr = googclientPrefix + "payload_encoding"
+
+const gzipEncoding = "gzip"
+
+// maxDecompressedBytes bounds the size of decompressed message data, to
+// protect subscribers from malicious payloads.
+const maxDecompressedBytes = 10 * MaxPublishRequestBytes
+
+// compressMessage returns a copy of msg with its data compressed, if the data
+// is at least threshold bytes long and compressing it makes it smaller.
+// Otherwise it returns msg.
+func compressMessage(msg *Message, c PayloadCompression, threshold int) *Message {
+	if c != PayloadCompressionGzip || len(msg.Data) < threshold {
+		return msg
+	}
+	if _, ok := msg.Attributes[payloadEncodingAttr]; ok {
+		// Don't compress data that is marked as compressed already.
+		return msg
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(msg.Data); err != nil {
+		return msg
+	}
+	if err := w.Close(); err != nil {
+		return msg
+	}
+	// Compression must pay for the attribute that marks it.
+	if buf.Len()+len(payloadEncodingAttr)+len(gzipEncoding) >= len(msg.Data) {
+		return msg
+	}
+	attrs := make(map[string]string, len(msg.Attributes)+1)
+	for k, v := range msg.Attributes {
+		attrs[k] = v
+	}
+	attrs[payloadEncodingAttr] = gzipEncoding
+	return &Message{
+		Data:        buf.Bytes(),
+		Attributes:  attrs,
+		OrderingKey: msg.OrderingKey,
+	}
+}
+
+// decompressMessage replaces the data of a received message that was
+// compressed by compressMessage with the original data, and removes the
+// marker attribute. Messages that cannot be decompressed are left unchanged.
+func decompressMessage(msg *Message) {
+	if msg.Attributes[payloadEncodingAttr] != gzipEncoding {
+		return
+	}
+	r, err := gzip.NewReader(bytes.NewReader(msg.Data))
+	if err != nil {
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(r, maxDecompressedBytes+1))
+	if err != nil || len(data) > maxDecompressedBytes {
+		return
+	}
+	attrs := make(map[string]string, len(msg.Attributes)-1)
+	for k, v := range msg.Attributes {
+		if k != payloadEncodingAttr {
+			attrs[k] = v
+		}
+	}
+	msg.Data = data
+	msg.Attributes = attrs
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/internal/testutil"
+)
+
+func TestCompressMessage(t *testing.T) {
+	data := bytes.Repeat([]byte(`{"key": "value"}`), 100)
+	msg := &Message{Data: data, Attributes: map[string]string{"a": "b"}, OrderingKey: "k"}
+
+	for _, tc := range []struct {
+		desc      string
+		c         PayloadCompression
+		threshold int
+		msg       *Message
+	}{
+		{desc: "disabled", c: PayloadCompressionNone, threshold: 1, msg: msg},
+		{desc: "below threshold", c: PayloadCompressionGzip, threshold: len(data) + 1, msg: msg},
+		{desc: "incompressible", c: PayloadCompressionGzip, threshold: 1, msg: &Message{Data: []byte("abc")}},
+	} {
+		if got := compressMessage(tc.msg, tc.c, tc.threshold); got != tc.msg {
+			t.Errorf("%s: compressMessage returned a new message, want the original", tc.desc)
+		}
+	}
+
+	cm := compressMessage(msg, PayloadCompressionGzip, 1)
+	if cm == msg {
+		t.Fatal("compressMessage did not compress compressible data")
+	}
+	if len(cm.Data) >= len(data) || cm.Attributes[payloadEncodingAttr] != gzipEncoding || cm.OrderingKey != "k" {
+		t.Errorf("compressMessage got %d bytes, attributes %v, ordering key %q", len(cm.Data), cm.Attributes, cm.OrderingKey)
+	}
+	if _, ok := msg.Attributes[payloadEncodingAttr]; ok || !bytes.Equal(msg.Data, data) {
+		t.Error("compressMessage modified the original message")
+	}
+
+	decompressMessage(cm)
+	if !bytes.Equal(cm.Data, data) {
+		t.Error("decompressMessage did not restore the original data")
+	}
+	if want := map[string]string{"a": "b"}; !testutil.Equal(cm.Attributes, want) {
+		t.Errorf("decompressMessage got attributes %v, want %v", cm.Attributes, want)
+	}
+
+	// Data that isn't valid gzip is left alone.
+	bad := &Message{Data: []byte("not gzip"), Attributes: map[string]string{payloadEncodingAttr: gzipEncoding}}
+	decompressMessage(bad)
+	if string(bad.Data) != "not gzip" || bad.Attributes[payloadEncodingAttr] != gzipEncoding {
+		t.Errorf("decompressMessage changed a message it could not decompress: %+v", bad)
+	}
+}
+
+func TestPublishPayloadCompression(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	client, srv := newFake(t)
+	defer client.Close()
+	defer srv.Close()
+
+	topic := mustCreateTopic(t, client, "t")
+	defer topic.Stop()
+	topic.PublishSettings.PayloadCompression = PayloadCompressionGzip
+	sub, err := client.CreateSubscription(ctx, "s", SubscriptionConfig{Topic: topic})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := bytes.Repeat([]byte("compress me "), 1000)
+	if _, err := topic.Publish(ctx, &Message{Data: data}).Get(ctx); err != nil {
+		t.Fatal(err)
+	}
+	stored := srv.Messages()
+	if len(stored) != 1 || len(stored[0].Data) >= len(data) || stored[0].Attributes[payloadEncodingAttr] != gzipEncoding {
+		t.Fatal("message was not published compressed")
+	}
+
+	msgs, err := sub.Fetch(ctx, 1, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages, want 1", len(msgs))
+	}
+	if !bytes.Equal(msgs[0].Data, data) {
+		t.Error("received data differs from published data")
+	}
+	if _, ok := msgs[0].Attributes[payloadEncodingAttr]; ok {
+		t.Errorf("received message still has attribute %q", payloadEncodingAttr)
+	}
+	msgs[0].Ack()
+}
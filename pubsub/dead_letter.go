@@ -0,0 +1,206 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Attributes that Pub/Sub adds to messages it forwards to a dead-letter topic.
+const (
+	deadLetterDeliveryCountAttr       = "CloudPubSubDeadLetterSourceDeliveryCount"
+	deadLetterSubscriptionAttr        = "CloudPubSubDeadLetterSourceSubscription"
+	deadLetterSubscriptionProjectAttr = "CloudPubSubDeadLetterSourceSubscriptionProject"
+	deadLetterPublishTimeAttr         = "CloudPubSubDeadLetterSourceTopicPublishTime"
+)
+
+// DeadLetterInfo describes where a dead-lettered message came from. Pub/Sub
+// records this information in the attributes of each message it forwards to
+// a dead-letter topic.
+type DeadLetterInfo struct {
+	// SourceSubscription is the ID of the subscription the message was
+	// dead-lettered from.
+	SourceSubscription string
+
+	// SourceSubscriptionProject is the project of SourceSubscription.
+	SourceSubscriptionProject string
+
+	// DeliveryAttempts is the number of times the message was delivered on
+	// SourceSubscription before it was dead-lettered.
+	DeliveryAttempts int
+
+	// SourceTopicPublishTime is when the message was originally published.
+	// It is the zero time if the service did not report it.
+	SourceTopicPublishTime time.Time
+}
+
+// DeadLetterInfoFromMessage returns the dead-letter information recorded on m,
+// or false if m was not forwarded to a dead-letter topic.
+func DeadLetterInfoFromMessage(m *Message) (*DeadLetterInfo, bool) {
+	sub, ok := m.Attributes[deadLetterSubscriptionAttr]
+	if !ok {
+		return nil, false
+	}
+	info := &DeadLetterInfo{
+		SourceSubscription:        sub,
+		SourceSubscriptionProject: m.Attributes[deadLetterSubscriptionProjectAttr],
+	}
+	if n, err := strconv.Atoi(m.Attributes[deadLetterDeliveryCountAttr]); err == nil {
+		info.DeliveryAttempts = n
+	}
+	if t, err := time.Parse(time.RFC3339Nano, m.Attributes[deadLetterPublishTimeAttr]); err == nil {
+		info.SourceTopicPublishTime = t
+	}
+	return info, true
+}
+
+// RedriveSettings configures RedriveDLQ.
+type RedriveSettings struct {
+	// MaxMessagesPerSecond bounds the rate at which messages are republished.
+	// If zero, DefaultRedriveSettings.MaxMessagesPerSecond is used. If
+	// negative, the rate is not bounded.
+	MaxMessagesPerSecond float64
+
+	// MaxMessages is the maximum number of messages to republish. If zero or
+	// negative, there is no limit.
+	MaxMessages int
+
+	// BatchSize is the maximum number of messages pulled from the dead-letter
+	// subscription at once. If zero, DefaultRedriveSettings.BatchSize is used.
+	BatchSize int
+
+	// MaxWait is how long to wait for messages on each pull. RedriveDLQ
+	// returns once a pull yields no new messages. If zero,
+	// DefaultRedriveSettings.MaxWait is used.
+	MaxWait time.Duration
+}
+
+// DefaultRedriveSettings holds the default values for RedriveSettings.
+var DefaultRedriveSettings = RedriveSettings{
+	MaxMessagesPerSecond: 100,
+	BatchSize:            100,
+	MaxWait:              5 * time.Second,
+}
+
+// RedriveResult reports the outcome of RedriveDLQ.
+type RedriveResult struct {
+	// Redriven is the number of messages republished to the target topic and
+	// acknowledged on the dead-letter subscription.
+	Redriven int
+
+	// Skipped is the number of messages the filter rejected. They are left
+	// on the dead-letter subscription.
+	Skipped int
+}
+
+// RedriveDLQ republishes messages from a dead-letter subscription to
+// targetTopic, usually the topic of the subscription they were dead-lettered
+// from. Each message is acknowledged on dlqSub only after it has been
+// published successfully. Messages for which filter returns false are nacked
+// and left on dlqSub. If filter is nil, all messages are republished.
+//
+// Messages keep their data and attributes. Their ordering key is kept only if
+// targetTopic.EnableMessageOrdering is set.
+//
+// RedriveDLQ returns when dlqSub has no more messages to deliver, when
+// settings.MaxMessages have been republished, when ctx is done, or on the first
+// publish failure. If settings is nil, DefaultRedriveSettings is used.
+//
+// Only one RedriveDLQ should run against a dead-letter subscription at a
+// time. It must not be used on a subscription with an active Receive.
+func RedriveDLQ(ctx context.Context, dlqSub *Subscription, targetTopic *Topic, filter func(*Message) bool, settings *RedriveSettings) (*RedriveResult, error) {
+	rs := DefaultRedriveSettings
+	if settings != nil {
+		rs.MaxMessages = settings.MaxMessages
+		if settings.MaxMessagesPerSecond != 0 {
+			rs.MaxMessagesPerSecond = settings.MaxMessagesPerSecond
+		}
+		if settings.BatchSize > 0 {
+			rs.BatchSize = settings.BatchSize
+		}
+		if settings.MaxWait > 0 {
+			rs.MaxWait = settings.MaxWait
+		}
+	}
+	limit := rate.Inf
+	if rs.MaxMessagesPerSecond > 0 {
+		limit = rate.Limit(rs.MaxMessagesPerSecond)
+	}
+	limiter := rate.NewLimiter(limit, 1)
+
+	res := &RedriveResult{}
+	// Skipped messages are nacked, so they may be redelivered. Remember them
+	// so that seeing them again is not mistaken for progress.
+	seen := map[string]bool{}
+	for rs.MaxMessages <= 0 || res.Redriven < rs.MaxMessages {
+		batch := rs.BatchSize
+		if rs.MaxMessages > 0 && rs.MaxMessages-res.Redriven < batch {
+			batch = rs.MaxMessages - res.Redriven
+		}
+		msgs, err := dlqSub.Fetch(ctx, batch, rs.MaxWait)
+		if err != nil {
+			return res, err
+		}
+		progress := false
+		for i, m := range msgs {
+			if seen[m.ID] || (filter != nil && !filter(m)) {
+				if !seen[m.ID] {
+					seen[m.ID] = true
+					res.Skipped++
+					progress = true
+				}
+				m.Nack()
+				continue
+			}
+			progress = true
+			if err := redriveMessage(ctx, limiter, targetTopic, m); err != nil {
+				m.Nack()
+				for _, rest := range msgs[i+1:] {
+					rest.Nack()
+				}
+				return res, err
+			}
+			m.Ack()
+			res.Redriven++
+		}
+		if !progress {
+			break
+		}
+	}
+	return res, nil
+}
+
+func redriveMessage(ctx context.Context, limiter *rate.Limiter, t *Topic, m *Message) error {
+	if err := limiter.Wait(ctx); err != nil {
+		return err
+	}
+	msg := &Message{
+		Data:       m.Data,
+		Attributes: m.Attributes,
+	}
+	if t.EnableMessageOrdering {
+		msg.OrderingKey = m.OrderingKey
+	}
+	_, err := t.Publish(ctx, msg).Get(ctx)
+	if err != nil {
+		return fmt.Errorf("pubsub: redrive publish failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/internal/testutil"
+)
+
+func TestDeadLetterInfoFromMessage(t *testing.T) {
+	if _, ok := DeadLetterInfoFromMessage(&Message{}); ok {
+		t.Error("DeadLetterInfoFromMessage of a plain message: got ok, want !ok")
+	}
+	publishTime := time.Date(2024, 3, 1, 12, 0, 0, 500, time.UTC)
+	m := &Message{Attributes: map[string]string{
+		"CloudPubSubDeadLetterSourceDeliveryCount":       "5",
+		"CloudPubSubDeadLetterSourceSubscription":        "s",
+		"CloudPubSubDeadLetterSourceSubscriptionProject": "p",
+		"CloudPubSubDeadLetterSourceTopicPublishTime":    publishTime.Format(time.RFC3339Nano),
+		"unrelated": "x",
+	}}
+	got, ok := DeadLetterInfoFromMessage(m)
+	if !ok {
+		t.Fatal("DeadLetterInfoFromMessage: got !ok, want ok")
+	}
+	want := &DeadLetterInfo{
+		SourceSubscription:        "s",
+		SourceSubscriptionProject: "p",
+		DeliveryAttempts:          5,
+		SourceTopicPublishTime:    publishTime,
+	}
+	if diff := testutil.Diff(got, want); diff != "" {
+		t.Errorf("DeadLetterInfoFromMessage: -got, +want:\n%s", diff)
+	}
+}
+
+func TestRedriveDLQ(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	client, srv := newFake(t)
+	defer client.Close()
+	defer srv.Close()
+
+	dlqTopic := mustCreateTopic(t, client, "dlq")
+	dlqSub, err := client.CreateSubscription(ctx, "dlq-sub", SubscriptionConfig{Topic: dlqTopic})
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := mustCreateTopic(t, client, "target")
+	defer target.Stop()
+	targetSub, err := client.CreateSubscription(ctx, "target-sub", SubscriptionConfig{Topic: target})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 4; i++ {
+		attrs := map[string]string{"CloudPubSubDeadLetterSourceSubscription": "s"}
+		if i == 2 {
+			attrs["skip"] = "true"
+		}
+		srv.Publish(dlqTopic.name, []byte{byte(i)}, attrs)
+	}
+
+	res, err := RedriveDLQ(ctx, dlqSub, target, func(m *Message) bool {
+		return m.Attributes["skip"] != "true"
+	}, &RedriveSettings{MaxWait: 200 * time.Millisecond, MaxMessagesPerSecond: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (&RedriveResult{Redriven: 3, Skipped: 1}); !testutil.Equal(res, want) {
+		t.Errorf("RedriveDLQ: got %+v, want %+v", res, want)
+	}
+
+	msgs, err := targetSub.Fetch(ctx, 10, 500*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []byte
+	for _, m := range msgs {
+		got = append(got, m.Data[0])
+		m.Ack()
+	}
+	if len(got) != 3 {
+		t.Errorf("target subscription got messages %v, want 3 messages", got)
+	}
+
+	// The skipped message stays on the dead-letter subscription.
+	msgs, err = dlqSub.Fetch(ctx, 10, 500*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 1 || msgs[0].Attributes["skip"] != "true" {
+		t.Errorf("dead-letter subscription got %d messages, want only the skipped one", len(msgs))
+	}
+}
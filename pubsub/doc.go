@@ -160,6 +160,20 @@ For use cases where message processing exceeds 30 minutes, we recommend using
 the base client in a pull model, since long-lived streams are periodically killed
 by firewalls. See the example at https://godoc.org/cloud.google.com/go/pubsub/apiv1#example-SubscriberClient-Pull-LengthyClientProcessing
 
+# Tracing
+
+OpenTelemetry tracing of publish and subscribe operations can be enabled with
+ClientConfig.EnableOpenTelemetryTracing. Spans are created with the global
+TracerProvider, and the trace context of each published message is carried in
+its attributes so that traces continue through to the subscriber:
+
+	client, err := pubsub.NewClientWithConfig(ctx, "my-project-id", &pubsub.ClientConfig{
+		EnableOpenTelemetryTracing: true,
+	})
+	if err != nil {
+		// TODO: Handle error.
+	}
+
 # Emulator
 
 To use an emulator with this library, you can set the PUBSUB_EMULATOR_HOST
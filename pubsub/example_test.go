@@ -350,6 +350,44 @@ func ExampleSubscription_Receive_maxOutstanding() {
 	}
 }
 
+func ExampleSubscription_Fetch() {
+	ctx := context.Background()
+	client, err := pubsub.NewClient(ctx, "project-id")
+	if err != nil {
+		// TODO: Handle error.
+	}
+	sub := client.Subscription("subName")
+	// Wait up to 10 seconds for a batch of at most 100 messages.
+	msgs, err := sub.Fetch(ctx, 100, 10*time.Second)
+	if err != nil {
+		// TODO: Handle error.
+	}
+	for _, m := range msgs {
+		// TODO: Handle message.
+		m.Ack()
+	}
+}
+
+func ExampleRedriveDLQ() {
+	ctx := context.Background()
+	client, err := pubsub.NewClient(ctx, "project-id")
+	if err != nil {
+		// TODO: Handle error.
+	}
+	dlqSub := client.Subscription("deadLetterSubName")
+	topic := client.Topic("topicName")
+	defer topic.Stop()
+	// Republish messages that failed on subName, at most 50 per second.
+	res, err := pubsub.RedriveDLQ(ctx, dlqSub, topic, func(m *pubsub.Message) bool {
+		info, ok := pubsub.DeadLetterInfoFromMessage(m)
+		return ok && info.SourceSubscription == "subName"
+	}, &pubsub.RedriveSettings{MaxMessagesPerSecond: 50})
+	if err != nil {
+		// TODO: Handle error.
+	}
+	fmt.Printf("redrove %d messages, skipped %d\n", res.Redriven, res.Skipped)
+}
+
 func ExampleSubscription_Update() {
 	ctx := context.Background()
 	client, err := pubsub.NewClient(ctx, "project-id")
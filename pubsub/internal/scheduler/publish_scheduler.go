@@ -17,6 +17,7 @@ package scheduler
 import (
 	"errors"
 	"reflect"
+	"sort"
 	"sync"
 	"time"
 
@@ -41,6 +42,12 @@ type PublishScheduler struct {
 	mu          sync.Mutex
 	bundlers    sync.Map // keys -> *bundler.Bundler
 	outstanding sync.Map // keys -> num outstanding messages
+	generations sync.Map // keys -> generation of the key's bundler
+	retired     sync.Map // retired bundlers still flushing -> struct{}
+
+	// generation counts the changes of thresholds by SetThresholds. A
+	// bundler of an older generation is retired by the next Add to its key.
+	generation int
 
 	keysMu sync.RWMutex
 	// keysWithErrors tracks ordering keys that cannot accept new messages.
@@ -109,50 +116,95 @@ func (s *PublishScheduler) Add(key string, item interface{}, size int) error {
 
 	if !ok {
 		s.outstanding.Store(key, 1)
-		b = bundler.NewBundler(item, func(bundle interface{}) {
-			s.workers <- struct{}{}
-			s.handle(bundle)
-			<-s.workers
-
-			nlen := reflect.ValueOf(bundle).Len()
-			s.mu.Lock()
-			outsInterface, _ := s.outstanding.Load(key)
-			s.outstanding.Store(key, outsInterface.(int)-nlen)
-			if v, _ := s.outstanding.Load(key); v == 0 {
-				s.outstanding.Delete(key)
-				s.bundlers.Delete(key)
-			}
-			s.mu.Unlock()
-		})
-		b.DelayThreshold = s.DelayThreshold
-		b.BundleCountThreshold = s.BundleCountThreshold
-		b.BundleByteThreshold = s.BundleByteThreshold
-		b.BundleByteLimit = s.BundleByteLimit
-		b.BufferedByteLimit = s.BufferedByteLimit
-
-		if b.BufferedByteLimit == 0 {
-			b.BufferedByteLimit = 1e9
-		}
-
-		if key == "" {
-			// There's no way to express "unlimited" in the bundler, so we use
-			// some high number.
-			b.HandlerLimit = 1e9
-		} else {
-			// HandlerLimit=1 causes the bundler to act as a sequential queue.
-			b.HandlerLimit = 1
-		}
-
-		s.bundlers.Store(key, b)
+		b = s.newBundler(key, item, nil)
 	} else {
 		b = bInterface.(*bundler.Bundler)
 		oi, _ := s.outstanding.Load(key)
 		s.outstanding.Store(key, oi.(int)+1)
+
+		if g, _ := s.generations.Load(key); g.(int) != s.generation {
+			// The thresholds changed since b was created, and they can't be
+			// changed on a live bundler. Retire b: it handles the items it
+			// holds while a new bundler takes the key's items.
+			flushed := make(chan struct{})
+			s.retired.Store(b, struct{}{})
+			go func(old *bundler.Bundler) {
+				old.Flush()
+				s.retired.Delete(old)
+				close(flushed)
+			}(b)
+			b = s.newBundler(key, item, flushed)
+		}
 	}
 
 	return b.Add(item, size)
 }
 
+// newBundler creates and stores the bundler of key, with the current
+// settings. If prev isn't nil, the bundler of an ordering key waits until
+// prev is closed, when the bundler it replaces has handled all of its items,
+// before it handles any bundle, so that the key's items stay in sequence.
+//
+// Must be called with s.mu held.
+func (s *PublishScheduler) newBundler(key string, item interface{}, prev <-chan struct{}) *bundler.Bundler {
+	b := bundler.NewBundler(item, func(bundle interface{}) {
+		if prev != nil && key != "" {
+			<-prev
+		}
+		s.workers <- struct{}{}
+		s.handle(bundle)
+		<-s.workers
+
+		nlen := reflect.ValueOf(bundle).Len()
+		s.mu.Lock()
+		outsInterface, _ := s.outstanding.Load(key)
+		s.outstanding.Store(key, outsInterface.(int)-nlen)
+		if v, _ := s.outstanding.Load(key); v == 0 {
+			s.outstanding.Delete(key)
+			s.bundlers.Delete(key)
+			s.generations.Delete(key)
+		}
+		s.mu.Unlock()
+	})
+	b.DelayThreshold = s.DelayThreshold
+	b.BundleCountThreshold = s.BundleCountThreshold
+	b.BundleByteThreshold = s.BundleByteThreshold
+	b.BundleByteLimit = s.BundleByteLimit
+	b.BufferedByteLimit = s.BufferedByteLimit
+
+	if b.BufferedByteLimit == 0 {
+		b.BufferedByteLimit = 1e9
+	}
+
+	if key == "" {
+		// There's no way to express "unlimited" in the bundler, so we use
+		// some high number.
+		b.HandlerLimit = 1e9
+	} else {
+		// HandlerLimit=1 causes the bundler to act as a sequential queue.
+		b.HandlerLimit = 1
+	}
+
+	s.bundlers.Store(key, b)
+	s.generations.Store(key, s.generation)
+	return b
+}
+
+// SetThresholds changes DelayThreshold and BundleCountThreshold. The next
+// item added to a key goes to a new bundler with the new values, while the
+// key's previous bundler handles the items it already holds. Items of an
+// ordering key are still handled in sequence.
+func (s *PublishScheduler) SetThresholds(delay time.Duration, count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if delay == s.DelayThreshold && count == s.BundleCountThreshold {
+		return
+	}
+	s.DelayThreshold = delay
+	s.BundleCountThreshold = count
+	s.generation++
+}
+
 // FlushAndStop begins flushing items from bundlers and from the scheduler. It
 // blocks until all items have been flushed.
 func (s *PublishScheduler) FlushAndStop() {
@@ -161,21 +213,31 @@ func (s *PublishScheduler) FlushAndStop() {
 		bi.(*bundler.Bundler).Flush()
 		return true
 	})
+	s.retired.Range(func(bi, _ interface{}) bool {
+		bi.(*bundler.Bundler).Flush()
+		return true
+	})
 }
 
 // Flush waits until all bundlers are sent.
 func (s *PublishScheduler) Flush() {
 	var wg sync.WaitGroup
-	s.bundlers.Range(func(_, bi interface{}) bool {
+	flush := func(b *bundler.Bundler) {
 		wg.Add(1)
-		go func(b *bundler.Bundler) {
+		go func() {
 			defer wg.Done()
 			b.Flush()
-		}(bi.(*bundler.Bundler))
+		}()
+	}
+	s.bundlers.Range(func(_, bi interface{}) bool {
+		flush(bi.(*bundler.Bundler))
+		return true
+	})
+	s.retired.Range(func(bi, _ interface{}) bool {
+		flush(bi.(*bundler.Bundler))
 		return true
 	})
 	wg.Wait()
-
 }
 
 // IsPaused checks if the bundler associated with an ordering keys is
@@ -190,18 +252,40 @@ func (s *PublishScheduler) IsPaused(orderingKey string) bool {
 // Pause pauses the bundler associated with the provided ordering key,
 // preventing it from accepting new messages. Any outstanding messages
 // that haven't been published will error. If orderingKey is empty,
-// this is a no-op.
-func (s *PublishScheduler) Pause(orderingKey string) {
-	if orderingKey != "" {
-		s.keysMu.Lock()
-		defer s.keysMu.Unlock()
-		s.keysWithErrors[orderingKey] = struct{}{}
+// this is a no-op. Pause reports whether the key was newly paused.
+func (s *PublishScheduler) Pause(orderingKey string) bool {
+	if orderingKey == "" {
+		return false
 	}
+	s.keysMu.Lock()
+	defer s.keysMu.Unlock()
+	if _, ok := s.keysWithErrors[orderingKey]; ok {
+		return false
+	}
+	s.keysWithErrors[orderingKey] = struct{}{}
+	return true
 }
 
 // Resume resumes accepting message with the provided ordering key.
-func (s *PublishScheduler) Resume(orderingKey string) {
+// It reports whether the key was paused.
+func (s *PublishScheduler) Resume(orderingKey string) bool {
 	s.keysMu.Lock()
 	defer s.keysMu.Unlock()
+	if _, ok := s.keysWithErrors[orderingKey]; !ok {
+		return false
+	}
 	delete(s.keysWithErrors, orderingKey)
+	return true
+}
+
+// PausedKeys returns the ordering keys that are paused, in sorted order.
+func (s *PublishScheduler) PausedKeys() []string {
+	s.keysMu.RLock()
+	defer s.keysMu.RUnlock()
+	keys := make([]string, 0, len(s.keysWithErrors))
+	for k := range s.keysWithErrors {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
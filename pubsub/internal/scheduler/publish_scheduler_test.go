@@ -16,6 +16,7 @@ package scheduler_test
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
@@ -246,3 +247,95 @@ func TestPublishScheduler_FlushAndStop(t *testing.T) {
 		})
 	}
 }
+
+func TestPublishScheduler_SetThresholds(t *testing.T) {
+	bundles := make(chan []int, 1)
+	s := scheduler.NewPublishScheduler(1, func(itemi interface{}) {
+		bundles <- itemi.([]int)
+	})
+	defer s.FlushAndStop()
+	s.DelayThreshold = time.Hour
+	s.BundleCountThreshold = 100
+	s.BundleByteThreshold = 1e6
+
+	s.SetThresholds(time.Hour, 2)
+	for _, v := range []int{1, 2} {
+		if err := s.Add("foo", v, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case got := <-bundles:
+		if len(got) != 2 {
+			t.Errorf("got bundle %v, want 2 items", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for bundle handled by count threshold")
+	}
+}
+
+func TestPublishScheduler_PausedKeys(t *testing.T) {
+	s := scheduler.NewPublishScheduler(1, func(interface{}) {})
+	defer s.FlushAndStop()
+
+	if s.Pause("") {
+		t.Error("Pause(\"\") = true, want false")
+	}
+	for _, k := range []string{"b", "a"} {
+		if !s.Pause(k) {
+			t.Errorf("Pause(%q) = false, want true", k)
+		}
+	}
+	if s.Pause("a") {
+		t.Error("Pause of an already paused key = true, want false")
+	}
+	if got := s.PausedKeys(); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("PausedKeys() = %v, want [a b]", got)
+	}
+
+	if !s.Resume("a") {
+		t.Error("Resume(\"a\") = false, want true")
+	}
+	if s.Resume("a") {
+		t.Error("Resume of a key that is not paused = true, want false")
+	}
+	if got := s.PausedKeys(); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Errorf("PausedKeys() = %v, want [b]", got)
+	}
+}
+
+func TestPublishScheduler_SetThresholdsLiveBundler(t *testing.T) {
+	bundles := make(chan []int, 2)
+	s := scheduler.NewPublishScheduler(1, func(itemi interface{}) {
+		bundles <- itemi.([]int)
+	})
+	defer s.FlushAndStop()
+	s.DelayThreshold = time.Hour
+	s.BundleCountThreshold = 100
+	s.BundleByteThreshold = 1e6
+
+	// The key's bundler holds 1 when the thresholds change.
+	if err := s.Add("foo", 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	s.SetThresholds(time.Hour, 2)
+	for _, v := range []int{2, 3} {
+		if err := s.Add("foo", v, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got [][]int
+	for len(got) < 2 {
+		select {
+		case b := <-bundles:
+			got = append(got, b)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for bundles, got %v", got)
+		}
+	}
+	if want := [][]int{{1}, {2, 3}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got bundles %v, want %v", got, want)
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"cloud.google.com/go/pubsub/internal/distribution"
 	gax "github.com/googleapis/gax-go/v2"
 	"github.com/googleapis/gax-go/v2/apierror"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -96,6 +97,14 @@ type messageIterator struct {
 	eoMu                      sync.RWMutex
 	enableExactlyOnceDelivery bool
 	sendNewAckDeadline        bool
+
+	// ackResults tracks AckResults that have not yet completed when
+	// po.waitForAckResults is set.
+	ackResults sync.WaitGroup
+
+	// stats, if not nil, collects the statistics reported by
+	// Subscription.ReceiveStats.
+	stats *receiveState
 }
 
 // newMessageIterator starts and returns a new messageIterator.
@@ -151,12 +160,17 @@ func newMessageIterator(subc *vkit.SubscriberClient, subName string, po *pullOpt
 // Stop will block until Done has been called on all Messages that have been
 // returned by Next, or until the context with which the messageIterator was created
 // is cancelled or exceeds its deadline.
+// If po.waitForAckResults is set, stop also blocks until the AckResults of
+// all acks and nacks sent on an exactly-once delivery subscription complete.
 func (it *messageIterator) stop() {
 	it.cancel()
 	it.mu.Lock()
 	it.checkDrained()
 	it.mu.Unlock()
 	it.wg.Wait()
+	if it.po.waitForAckResults {
+		it.ackResults.Wait()
+	}
 }
 
 // checkDrained closes the drained channel if the iterator has been stopped and all
@@ -191,9 +205,25 @@ func (it *messageIterator) addToDistribution(receiveTime time.Time) {
 // Called when a message is acked/nacked.
 func (it *messageIterator) done(ackID string, ack bool, r *AckResult, receiveTime time.Time) {
 	it.addToDistribution(receiveTime)
+	recordStat(it.ctx, AckLatency, int64(time.Since(receiveTime)/time.Millisecond))
 	it.mu.Lock()
 	defer it.mu.Unlock()
+	// Messages are removed from keepAliveDeadlines once MaxExtension passes.
+	_, live := it.keepAliveDeadlines[ackID]
+	if !live {
+		recordStat(it.ctx, ExpiredAckCount, 1)
+	}
+	if it.stats != nil {
+		it.stats.recordAck(receiveTime, !live)
+	}
 	delete(it.keepAliveDeadlines, ackID)
+	if r != nil {
+		if it.err != nil {
+			// The sender has stopped, so this ack or nack will never be sent.
+			setAckError(r, ackID, AcknowledgeStatusOther, "", it.err)
+		}
+		it.trackAckResult(r)
+	}
 	if ack {
 		it.pendingAcks[ackID] = r
 	} else {
@@ -202,6 +232,40 @@ func (it *messageIterator) done(ackID string, ack bool, r *AckResult, receiveTim
 	it.checkDrained()
 }
 
+// trackAckResult records r as outstanding until it completes, so that stop
+// can wait for it. AckResults are only completed when exactly-once delivery
+// is enabled, so they are not tracked otherwise.
+func (it *messageIterator) trackAckResult(r *AckResult) {
+	if !it.po.waitForAckResults {
+		return
+	}
+	it.eoMu.RLock()
+	exactlyOnceDelivery := it.enableExactlyOnceDelivery
+	it.eoMu.RUnlock()
+	if !exactlyOnceDelivery {
+		return
+	}
+	it.ackResults.Add(1)
+	go func() {
+		defer it.ackResults.Done()
+		<-r.Ready()
+	}()
+}
+
+// failPendingResults completes the AckResults of acks and nacks that will
+// not be sent because the stream failed.
+func (it *messageIterator) failPendingResults() {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	for _, m := range []map[string]*AckResult{it.pendingAcks, it.pendingNacks} {
+		for id, r := range m {
+			if r != nil {
+				setAckError(r, id, AcknowledgeStatusOther, "", it.err)
+			}
+		}
+	}
+}
+
 // fail is called when a stream method returns a permanent error.
 // fail returns it.err. This may be err, or it may be the error
 // set by an earlier call to fail.
@@ -381,6 +445,7 @@ func (it *messageIterator) sender() {
 		select {
 		case <-it.failed:
 			// Stream failed: nothing to do, so stop immediately.
+			it.failPendingResults()
 			return
 
 		case <-it.drained:
@@ -492,10 +557,15 @@ func (it *messageIterator) sendAck(m map[string]*AckResult) {
 		// want to cancel this RPC when the iterator is stopped.
 		cctx2, cancel2 := context.WithTimeout(context.Background(), 60*time.Second)
 		defer cancel2()
+		var span trace.Span
+		if it.po.enableTracing {
+			cctx2, span = startAckRPCSpan(cctx2, it.po.tracerProvider, resourceID(it.subName), "ack", len(toSend))
+		}
 		err := it.subc.Acknowledge(cctx2, &pb.AcknowledgeRequest{
 			Subscription: it.subName,
 			AckIds:       toSend,
 		})
+		endSpan(span, err)
 		if exactlyOnceDelivery {
 			resultsByAckID := make(map[string]*AckResult)
 			for _, ackID := range toSend {
@@ -541,11 +611,20 @@ func (it *messageIterator) sendModAck(m map[string]*AckResult, deadline time.Dur
 		// want to cancel this RPC when the iterator is stopped.
 		cctx, cancel2 := context.WithTimeout(context.Background(), 60*time.Second)
 		defer cancel2()
+		var span trace.Span
+		if it.po.enableTracing {
+			name := "modack"
+			if deadline == 0 {
+				name = "nack"
+			}
+			cctx, span = startAckRPCSpan(cctx, it.po.tracerProvider, resourceID(it.subName), name, len(toSend))
+		}
 		err := it.subc.ModifyAckDeadline(cctx, &pb.ModifyAckDeadlineRequest{
 			Subscription:       it.subName,
 			AckDeadlineSeconds: deadlineSec,
 			AckIds:             toSend,
 		})
+		endSpan(span, err)
 		if exactlyOnceDelivery {
 			resultsByAckID := make(map[string]*AckResult)
 			for _, ackID := range toSend {
@@ -572,8 +651,8 @@ func (it *messageIterator) retryAcks(m map[string]*AckResult) {
 	bo := newExactlyOnceBackoff()
 	for {
 		if ctx.Err() != nil {
-			for _, r := range m {
-				ipubsub.SetAckResult(r, AcknowledgeStatusOther, ctx.Err())
+			for id, r := range m {
+				setAckRetriesExhausted(r, id, ctx.Err())
 			}
 			return
 		}
@@ -611,8 +690,8 @@ func (it *messageIterator) retryModAcks(m map[string]*AckResult, deadlineSec int
 	for {
 		// If context is done, complete all AckResults with errors.
 		if ctx.Err() != nil {
-			for _, r := range m {
-				ipubsub.SetAckResult(r, AcknowledgeStatusOther, ctx.Err())
+			for id, r := range m {
+				setAckRetriesExhausted(r, id, ctx.Err())
 			}
 			return
 		}
@@ -621,7 +700,7 @@ func (it *messageIterator) retryModAcks(m map[string]*AckResult, deadlineSec int
 			ackIDs := make([]string, 0, len(m))
 			for k, ar := range m {
 				ackIDs = append(ackIDs, k)
-				ipubsub.SetAckResult(ar, AcknowledgeStatusOther, errors.New("modack retry failed"))
+				setAckRetriesExhausted(ar, k, errors.New("modack retry failed"))
 			}
 			if logOnInvalid {
 				log.Printf("automatic lease modack retry failed for following IDs: %v", ackIDs)
@@ -780,9 +859,9 @@ func processResults(errorStatus *status.Status, ackResMap map[string]*AckResult,
 				retryResults[ackID] = ar
 			} else {
 				if errAckID == permanentInvalidAckErrString {
-					ipubsub.SetAckResult(ar, AcknowledgeStatusInvalidAckID, errors.New(errAckID))
+					setAckError(ar, ackID, AcknowledgeStatusInvalidAckID, errAckID, errors.New(errAckID))
 				} else {
-					ipubsub.SetAckResult(ar, AcknowledgeStatusOther, errors.New(errAckID))
+					setAckError(ar, ackID, AcknowledgeStatusOther, errAckID, errors.New(errAckID))
 				}
 				completedResults[ackID] = ar
 			}
@@ -792,11 +871,11 @@ func processResults(errorStatus *status.Status, ackResMap map[string]*AckResult,
 			// Other gRPC errors are not retried.
 			switch errorStatus.Code() {
 			case codes.PermissionDenied:
-				ipubsub.SetAckResult(ar, AcknowledgeStatusPermissionDenied, errorStatus.Err())
+				setAckError(ar, ackID, AcknowledgeStatusPermissionDenied, "", errorStatus.Err())
 			case codes.FailedPrecondition:
-				ipubsub.SetAckResult(ar, AcknowledgeStatusFailedPrecondition, errorStatus.Err())
+				setAckError(ar, ackID, AcknowledgeStatusFailedPrecondition, "", errorStatus.Err())
 			default:
-				ipubsub.SetAckResult(ar, AcknowledgeStatusOther, errorStatus.Err())
+				setAckError(ar, ackID, AcknowledgeStatusOther, "", errorStatus.Err())
 			}
 			completedResults[ackID] = ar
 		} else if ar != nil {
@@ -137,6 +137,38 @@ func TestMaxExtensionPeriod(t *testing.T) {
 	}
 }
 
+func TestIteratorExpiredAcks(t *testing.T) {
+	srv := pstest.NewServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv.Publish(fullyQualifiedTopicName, []byte("creating a topic"), nil)
+
+	_, client, err := initConn(ctx, srv.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iter := newMessageIterator(client.subc, fullyQualifiedTopicName, &pullOptions{})
+	defer iter.stop()
+	fc := newSubscriptionFlowController(FlowControlSettings{})
+	iter.stats = newReceiveState(&fc)
+
+	// "live" is still being extended; "expired" has passed MaxExtension.
+	iter.mu.Lock()
+	iter.keepAliveDeadlines["live"] = time.Now().Add(time.Minute)
+	iter.mu.Unlock()
+	iter.done("live", true, nil, time.Now().Add(-2*time.Second))
+	iter.done("expired", true, nil, time.Now())
+
+	st := iter.stats.snapshot()
+	if st.ExpiredAcks != 1 {
+		t.Errorf("got %d expired acks, want 1", st.ExpiredAcks)
+	}
+	if st.AckLatencyP99 < 2*time.Second {
+		t.Errorf("got ack latency p99 %v, want at least 2s", st.AckLatencyP99)
+	}
+}
+
 func TestAckDistribution(t *testing.T) {
 	if testing.Short() {
 		t.SkipNow()
@@ -685,6 +717,16 @@ func TestExactlyOnceProcessRequests(t *testing.T) {
 		if s != AcknowledgeStatusInvalidAckID {
 			t.Errorf("got %v, want AcknowledgeStatusSuccess", s)
 		}
+		if !errors.Is(err, ErrAckIDInvalid) {
+			t.Errorf("AckResult err: got %v, want ErrAckIDInvalid", err)
+		}
+		var ae *AckError
+		if !errors.As(err, &ae) {
+			t.Fatalf("AckResult err: got %T, want *AckError", err)
+		}
+		if ae.AckID != "ackID1" || ae.Reason != permanentInvalidAckErrString {
+			t.Errorf("AckError: got (%q, %q), want (%q, %q)", ae.AckID, ae.Reason, "ackID1", permanentInvalidAckErrString)
+		}
 	})
 
 	t.Run("TransientErrorRetry", func(t *testing.T) {
@@ -824,3 +866,48 @@ func TestExactlyOnceProcessRequests(t *testing.T) {
 		}
 	})
 }
+
+func TestAckErrorRetriesExhausted(t *testing.T) {
+	r := ipubsub.NewAckResult()
+	setAckRetriesExhausted(r, "ackID1", context.DeadlineExceeded)
+	s, err := r.Get(context.Background())
+	if s != AcknowledgeStatusOther {
+		t.Errorf("got %v, want AcknowledgeStatusOther", s)
+	}
+	if !errors.Is(err, ErrAckRetriesExhausted) {
+		t.Errorf("got %v, want ErrAckRetriesExhausted", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want wrapped context.DeadlineExceeded", err)
+	}
+	if errors.Is(err, ErrAckIDInvalid) {
+		t.Errorf("got %v, should not match ErrAckIDInvalid", err)
+	}
+}
+
+func TestWaitForAckResults(t *testing.T) {
+	it := &messageIterator{
+		po:                        &pullOptions{waitForAckResults: true},
+		enableExactlyOnceDelivery: true,
+	}
+	r := ipubsub.NewAckResult()
+	it.trackAckResult(r)
+
+	done := make(chan struct{})
+	go func() {
+		it.ackResults.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("wait returned before AckResult completed")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	ipubsub.SetAckResult(r, AcknowledgeStatusSuccess, nil)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("wait did not return after AckResult completed")
+	}
+}
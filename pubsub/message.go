@@ -15,11 +15,13 @@
 package pubsub
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	ipubsub "cloud.google.com/go/internal/pubsub"
 	pb "cloud.google.com/go/pubsub/apiv1/pubsubpb"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Message represents a Pub/Sub message.
@@ -93,6 +95,7 @@ func toMessage(resp *pb.ReceivedMessage, receiveTime time.Time, doneFunc iterDon
 	msg.PublishTime = pubTime
 	msg.DeliveryAttempt = deliveryAttempt
 	msg.OrderingKey = resp.Message.OrderingKey
+	decompressMessage(msg)
 	ackh.receiveTime = receiveTime
 	ackh.doneFunc = doneFunc
 	ackh.ackResult = ipubsub.NewAckResult()
@@ -126,6 +129,88 @@ const (
 	AcknowledgeStatusOther
 )
 
+var (
+	// ErrAckIDInvalid is matched by errors.Is for an AckError whose ack ID was
+	// rejected by the service. This usually means the message's ack deadline
+	// expired before the request reached the service, so the message may be
+	// redelivered.
+	ErrAckIDInvalid = errors.New("pubsub: ack ID is invalid or its ack deadline has expired")
+
+	// ErrAckRetriesExhausted is matched by errors.Is for an AckError whose
+	// request was still being retried when the exactly-once delivery retry
+	// deadline passed. The outcome of the request is unknown.
+	ErrAckRetriesExhausted = errors.New("pubsub: ack or nack was not confirmed before the retry deadline")
+)
+
+// AckError is the error returned by AckResult.Get when an Ack or Nack on a
+// subscription with exactly-once delivery enabled fails. Use errors.As to
+// obtain the ack ID and failure reason, or errors.Is with ErrAckIDInvalid or
+// ErrAckRetriesExhausted to test for the common failure modes:
+//
+//	_, err := m.AckWithResult().Get(ctx)
+//	var ae *pubsub.AckError
+//	if errors.As(err, &ae) && errors.Is(err, pubsub.ErrAckIDInvalid) {
+//	    // The message will be redelivered; do not treat it as processed.
+//	}
+type AckError struct {
+	// AckID is the ack ID of the message the request was made for.
+	AckID string
+
+	// Status is the status the AckResult was completed with.
+	Status AcknowledgeStatus
+
+	// Reason is the failure reason reported by the service for this ack ID,
+	// such as "PERMANENT_FAILURE_INVALID_ACK_ID". It is empty when the
+	// failure applies to the whole request rather than a single ack ID.
+	Reason string
+
+	// Err is the underlying error.
+	Err error
+
+	retriesExhausted bool
+}
+
+func (e *AckError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *AckError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether e matches ErrAckIDInvalid or ErrAckRetriesExhausted.
+func (e *AckError) Is(target error) bool {
+	switch target {
+	case ErrAckIDInvalid:
+		return e.Status == AcknowledgeStatusInvalidAckID
+	case ErrAckRetriesExhausted:
+		return e.retriesExhausted
+	}
+	return false
+}
+
+// setAckError completes r with st and an *AckError wrapping err.
+func setAckError(r *AckResult, ackID string, st AcknowledgeStatus, reason string, err error) {
+	ipubsub.SetAckResult(r, st, &AckError{
+		AckID:  ackID,
+		Status: st,
+		Reason: reason,
+		Err:    err,
+	})
+}
+
+// setAckRetriesExhausted completes r with an *AckError that matches
+// ErrAckRetriesExhausted, wrapping err.
+func setAckRetriesExhausted(r *AckResult, ackID string, err error) {
+	ipubsub.SetAckResult(r, AcknowledgeStatusOther, &AckError{
+		AckID:            ackID,
+		Status:           AcknowledgeStatusOther,
+		Err:              err,
+		retriesExhausted: true,
+	})
+}
+
 // psAckHandler handles ack/nack for the pubsub package.
 type psAckHandler struct {
 	// ackID is the identifier to acknowledge this message.
@@ -146,6 +231,10 @@ type psAckHandler struct {
 	// exactlyOnceDelivery determines if the message needs to be delivered
 	// exactly once.
 	exactlyOnceDelivery bool
+
+	// span is the subscribe span of the message when OpenTelemetry tracing
+	// is enabled. It is ended when the message is acked or nacked.
+	span trace.Span
 }
 
 func (ah *psAckHandler) OnAck() {
@@ -179,6 +268,14 @@ func (ah *psAckHandler) done(ack bool) {
 		return
 	}
 	ah.calledDone = true
+	if ah.span != nil {
+		if ack {
+			ah.span.AddEvent("ack called")
+		} else {
+			ah.span.AddEvent("nack called")
+		}
+		ah.span.End()
+	}
 	if ah.doneFunc != nil {
 		ah.doneFunc(ah.ackID, ack, ah.ackResult, ah.receiveTime)
 	}
@@ -37,8 +37,10 @@ import (
 	"cloud.google.com/go/internal/testutil"
 	pb "cloud.google.com/go/pubsub/apiv1/pubsubpb"
 	"go.einride.tech/aip/filtering"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	durpb "google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -384,6 +386,8 @@ func (s *GServer) UpdateTopic(_ context.Context, req *pb.UpdateTopicRequest) (*p
 				t.proto.SchemaSettings = &pb.SchemaSettings{}
 			}
 			t.proto.SchemaSettings.LastRevisionId = req.Topic.SchemaSettings.LastRevisionId
+		case "ingestion_data_source_settings":
+			t.proto.IngestionDataSourceSettings = req.Topic.IngestionDataSourceSettings
 		default:
 			return nil, status.Errorf(codes.InvalidArgument, "unknown field name %q", path)
 		}
@@ -497,6 +501,11 @@ func (s *GServer) CreateSubscription(_ context.Context, ps *pb.Subscription) (*p
 	if err := checkSubMessageRetention(ps.MessageRetentionDuration); err != nil {
 		return nil, err
 	}
+	if ps.Filter != "" {
+		if _, err := parseFilter(ps.Filter); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "bad filter: %v", err)
+		}
+	}
 	if ps.PushConfig == nil {
 		ps.PushConfig = &pb.PushConfig{}
 	} else if ps.PushConfig.Wrapper == nil {
@@ -836,6 +845,14 @@ func (t *topic) deleteSub(sub *subscription) {
 
 func (t *topic) publish(pm *pb.PubsubMessage, m *Message) {
 	for _, s := range t.subs {
+		if s.exporting() {
+			// Export subscriptions write messages to their destination
+			// instead of making them available to subscribers.
+			if s.filter == nil || matchesFilter(pm.Attributes, s.filter) {
+				s.exported = append(s.exported, m)
+			}
+			continue
+		}
 		s.msgs[pm.MessageId] = &message{
 			publishTime: m.PublishTime,
 			proto: &pb.ReceivedMessage{
@@ -860,6 +877,7 @@ type subscription struct {
 	done            chan struct{}
 	timeNowFunc     func() time.Time
 	filter          *filtering.Filter
+	exported        []*Message // messages written by an export subscription
 }
 
 func newSubscription(t *topic, mu *sync.Mutex, timeNowFunc func() time.Time, deadLetterTopic *topic, ps *pb.Subscription) *subscription {
@@ -907,6 +925,33 @@ func (s *subscription) stop() {
 	close(s.done)
 }
 
+// exporting reports whether s is a BigQuery or Cloud Storage subscription
+// with an active configuration.
+func (s *subscription) exporting() bool {
+	return s.proto.GetBigqueryConfig().GetTable() != "" || s.proto.GetCloudStorageConfig().GetBucket() != ""
+}
+
+// ExportedMessages returns the messages that the BigQuery or Cloud Storage
+// subscription with the given name has written to its destination, in
+// publish order. It returns nil if there is no such subscription.
+func (s *Server) ExportedMessages(subName string) []*Message {
+	s.GServer.mu.Lock()
+	defer s.GServer.mu.Unlock()
+
+	sub := s.GServer.subs[subName]
+	if sub == nil {
+		return nil
+	}
+	var msgs []*Message
+	for _, m := range sub.exported {
+		m := *m
+		m.Deliveries = m.deliveries
+		m.Acks = m.acks
+		msgs = append(msgs, &m)
+	}
+	return msgs
+}
+
 func (s *GServer) Acknowledge(_ context.Context, req *pb.AcknowledgeRequest) (*emptypb.Empty, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -919,9 +964,18 @@ func (s *GServer) Acknowledge(_ context.Context, req *pb.AcknowledgeRequest) (*e
 	if err != nil {
 		return nil, err
 	}
-	for _, id := range req.AckIds {
+	failures := map[string]string{}
+	for _, ackID := range req.AckIds {
+		id, ok := sub.checkAckID(ackID)
+		if !ok {
+			failures[ackID] = permanentInvalidAckID
+			continue
+		}
 		sub.ack(id)
 	}
+	if len(failures) > 0 {
+		return nil, exactlyOnceAckIDFailure(failures)
+	}
 	return &emptypb.Empty{}, nil
 }
 
@@ -938,13 +992,24 @@ func (s *GServer) ModifyAckDeadline(_ context.Context, req *pb.ModifyAckDeadline
 		return nil, err
 	}
 	now := time.Now()
-	for _, id := range req.AckIds {
-		s.msgsByID[id].modacks = append(s.msgsByID[id].modacks, Modack{AckID: id, AckDeadline: req.AckDeadlineSeconds, ReceivedAt: now})
+	for _, ackID := range req.AckIds {
+		if m := s.msgsByID[msgIDFromAckID(ackID)]; m != nil {
+			m.modacks = append(m.modacks, Modack{AckID: ackID, AckDeadline: req.AckDeadlineSeconds, ReceivedAt: now})
+		}
 	}
 	dur := secsToDur(req.AckDeadlineSeconds)
-	for _, id := range req.AckIds {
+	failures := map[string]string{}
+	for _, ackID := range req.AckIds {
+		id, ok := sub.checkAckID(ackID)
+		if !ok {
+			failures[ackID] = permanentInvalidAckID
+			continue
+		}
 		sub.modifyAckDeadline(id, dur)
 	}
+	if len(failures) > 0 {
+		return nil, exactlyOnceAckIDFailure(failures)
+	}
 	return &emptypb.Empty{}, nil
 }
 
@@ -961,6 +1026,10 @@ func (s *GServer) Pull(ctx context.Context, req *pb.PullRequest) (*pb.PullRespon
 		s.mu.Unlock()
 		return nil, err
 	}
+	if sub.exporting() {
+		s.mu.Unlock()
+		return nil, status.Errorf(codes.FailedPrecondition, "subscription %q is an export subscription and cannot be pulled from", req.Subscription)
+	}
 	max := int(req.MaxMessages)
 	if max < 0 {
 		s.mu.Unlock()
@@ -999,6 +1068,9 @@ func (s *GServer) StreamingPull(sps pb.Subscriber_StreamingPullServer) error {
 	}
 	s.mu.Lock()
 	sub, err := s.findSubscription(req.Subscription)
+	if err == nil && sub.exporting() {
+		err = status.Errorf(codes.FailedPrecondition, "subscription %q is an export subscription and cannot be pulled from", req.Subscription)
+	}
 	s.mu.Unlock()
 	if err != nil {
 		return err
@@ -1047,7 +1119,7 @@ func (s *GServer) Seek(ctx context.Context, req *pb.SeekRequest) (*pb.SeekRespon
 	// Un-ack any already-acked messages after this time;
 	// redelivering them to the subscription is the closest analogue here.
 	for _, m := range s.msgs {
-		if m.PublishTime.Before(target) {
+		if m.PublishTime.Before(target) || sub.exporting() {
 			continue
 		}
 		sub.msgs[m.ID] = &message{
@@ -1097,6 +1169,7 @@ func (s *subscription) pull(max int) []*pb.ReceivedMessage {
 		if s.proto.DeadLetterPolicy != nil {
 			m.proto.DeliveryAttempt = int32(*m.deliveries)
 		}
+		m.proto.AckId = s.ackID(id, *m.deliveries)
 		m.ackDeadline = now.Add(s.ackTimeout)
 		msgs = append(msgs, m.proto)
 		if len(msgs) >= max {
@@ -1195,6 +1268,13 @@ func (s *subscription) tryDeliverMessage(m *message, start int, now time.Time) (
 	if s.proto.DeadLetterPolicy != nil {
 		m.proto.DeliveryAttempt = int32(*m.deliveries) + 1
 	}
+	// Likewise for the ack ID, which changes on every delivery when exactly
+	// once delivery is enabled.
+	var ackID string
+	if s.proto.EnableExactlyOnceDelivery {
+		ackID = m.proto.AckId
+		m.proto.AckId = s.ackID(msgIDFromAckID(ackID), *m.deliveries+1)
+	}
 
 	for i := 0; i < len(s.streams); i++ {
 		idx := (i + start) % len(s.streams)
@@ -1217,6 +1297,9 @@ func (s *subscription) tryDeliverMessage(m *message, start int, now time.Time) (
 	if s.proto.DeadLetterPolicy != nil {
 		m.proto.DeliveryAttempt = int32(*m.deliveries)
 	}
+	if s.proto.EnableExactlyOnceDelivery {
+		m.proto.AckId = ackID
+	}
 	return 0, false
 }
 
@@ -1287,7 +1370,19 @@ func (s *subscription) publishToDeadLetter(m *message) {
 	if m.deliveries != nil {
 		deliveries = *m.deliveries
 	}
-	s.deadLetterTopic.publish(m.proto.Message, &Message{
+	// Like the service, record where the message came from in its attributes.
+	pm := proto.Clone(m.proto.Message).(*pb.PubsubMessage)
+	attrs := make(map[string]string, len(pm.Attributes)+4)
+	for k, v := range pm.Attributes {
+		attrs[k] = v
+	}
+	sub := strings.Split(s.proto.Name, "/")
+	attrs["CloudPubSubDeadLetterSourceDeliveryCount"] = fmt.Sprint(deliveries)
+	attrs["CloudPubSubDeadLetterSourceSubscription"] = sub[len(sub)-1]
+	attrs["CloudPubSubDeadLetterSourceSubscriptionProject"] = sub[1]
+	attrs["CloudPubSubDeadLetterSourceTopicPublishTime"] = m.publishTime.UTC().Format(time.RFC3339Nano)
+	pm.Attributes = attrs
+	s.deadLetterTopic.publish(pm, &Message{
 		PublishTime: m.publishTime,
 		Acks:        acks,
 		Deliveries:  deliveries,
@@ -1397,11 +1492,16 @@ func (s *subscription) handleStreamingPullRequest(st *stream, req *pb.StreamingP
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	// Invalid ack IDs can't be reported on the stream, so they are ignored.
 	for _, ackID := range req.AckIds {
-		s.ack(ackID)
+		if id, ok := s.checkAckID(ackID); ok {
+			s.ack(id)
+		}
 	}
-	for i, id := range req.ModifyDeadlineAckIds {
-		s.modifyAckDeadline(id, secsToDur(req.ModifyDeadlineSeconds[i]))
+	for i, ackID := range req.ModifyDeadlineAckIds {
+		if id, ok := s.checkAckID(ackID); ok {
+			s.modifyAckDeadline(id, secsToDur(req.ModifyDeadlineSeconds[i]))
+		}
 	}
 	if req.StreamAckDeadlineSeconds > 0 {
 		st.ackTimeout = secsToDur(req.StreamAckDeadlineSeconds)
@@ -1430,6 +1530,58 @@ func (s *subscription) modifyAckDeadline(id string, d time.Duration) {
 	}
 }
 
+// permanentInvalidAckID is the ErrorInfo metadata value the service uses for
+// ack IDs that can no longer be acked or modacked.
+const permanentInvalidAckID = "PERMANENT_FAILURE_INVALID_ACK_ID"
+
+// ackID returns the ack ID for the given delivery of the message with ID id.
+// With exactly once delivery enabled every delivery gets its own ack ID, so
+// that acks for earlier deliveries can be rejected.
+func (s *subscription) ackID(id string, delivery int) string {
+	if !s.proto.EnableExactlyOnceDelivery {
+		return id
+	}
+	return fmt.Sprintf("%s-%d", id, delivery)
+}
+
+// msgIDFromAckID returns the ID of the message that ackID refers to.
+func msgIDFromAckID(ackID string) string {
+	id, _, _ := strings.Cut(ackID, "-")
+	return id
+}
+
+// checkAckID returns the ID of the message that ackID refers to, and whether
+// ackID may still be used to ack or modack it. Without exactly once delivery
+// every ack ID is accepted, and acks for unknown messages are ignored.
+//
+// Must be called with the lock held.
+func (s *subscription) checkAckID(ackID string) (string, bool) {
+	id := msgIDFromAckID(ackID)
+	if !s.proto.EnableExactlyOnceDelivery {
+		return id, true
+	}
+	m := s.msgs[id]
+	if m == nil || !m.outstanding() || m.proto.AckId != ackID || s.timeNowFunc().After(m.ackDeadline) {
+		return id, false
+	}
+	return id, true
+}
+
+// exactlyOnceAckIDFailure returns the error the service returns when acks or
+// modacks for some ack IDs fail on a subscription with exactly once delivery.
+// failures maps each failed ack ID to the reason it failed.
+func exactlyOnceAckIDFailure(failures map[string]string) error {
+	st, err := status.New(codes.InvalidArgument, "some ack IDs were invalid").WithDetails(&errdetails.ErrorInfo{
+		Reason:   "EXACTLY_ONCE_ACKID_FAILURE",
+		Domain:   "pubsub.googleapis.com",
+		Metadata: failures,
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, "building error details: %v", err)
+	}
+	return st.Err()
+}
+
 func secsToDur(secs int32) time.Duration {
 	return time.Duration(secs) * time.Second
 }
@@ -1635,8 +1787,8 @@ func (s *GServer) DeleteSchemaRevision(_ context.Context, req *pb.DeleteSchemaRe
 		}
 		for i, sc := range schemaRevisions {
 			if sc.RevisionId == revID {
-				s.schemas[schemaName] = append(schemaRevisions[:i], schemaRevisions[i+1:]...)
-				return schemaRevisions[len(schemaRevisions)-1], nil
+				s.schemas[schemaName] = append(schemaRevisions[:i:i], schemaRevisions[i+1:]...)
+				return sc, nil
 			}
 		}
 	}
@@ -29,6 +29,7 @@ import (
 
 	"cloud.google.com/go/internal/testutil"
 	pb "cloud.google.com/go/pubsub/apiv1/pubsubpb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -382,6 +383,12 @@ func TestSubscriptionDeadLetter(t *testing.T) {
 	if receivedMessage.DeliveryAttempt > 0 {
 		t.Fatalf("message sent to deadLetter should not have the deliveryAttempt value from the original subscription message")
 	}
+	if got, want := receivedMessage.Message.Attributes["CloudPubSubDeadLetterSourceSubscription"], "S"; got != want {
+		t.Errorf("CloudPubSubDeadLetterSourceSubscription attribute got %q, want %q", got, want)
+	}
+	if got, want := receivedMessage.Message.Attributes["CloudPubSubDeadLetterSourceDeliveryCount"], fmt.Sprint(retries); got != want {
+		t.Errorf("CloudPubSubDeadLetterSourceDeliveryCount attribute got %q, want %q", got, want)
+	}
 	_, err = server.GServer.Acknowledge(ctx, &pb.AcknowledgeRequest{
 		Subscription: dlSub.Name,
 		AckIds:       []string{receivedMessage.GetAckId()},
@@ -1131,6 +1138,167 @@ func TestUpdateEnableExactlyOnceDelivery(t *testing.T) {
 	}
 }
 
+func TestCreateSubscriptionBadFilter(t *testing.T) {
+	ctx := context.Background()
+	pclient, sclient, _, cleanup := newFake(ctx, t)
+	defer cleanup()
+
+	top := mustCreateTopic(ctx, t, pclient, &pb.Topic{Name: "projects/P/topics/T"})
+	_, err := sclient.CreateSubscription(ctx, &pb.Subscription{
+		Name:               "projects/P/subscriptions/S",
+		Topic:              top.Name,
+		AckDeadlineSeconds: 10,
+		Filter:             "NOT (",
+	})
+	if got, want := status.Code(err), codes.InvalidArgument; got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestExactlyOnceDelivery(t *testing.T) {
+	ctx := context.Background()
+	pclient, sclient, srv, cleanup := newFake(ctx, t)
+	defer cleanup()
+
+	top := mustCreateTopic(ctx, t, pclient, &pb.Topic{Name: "projects/P/topics/T"})
+	sub := mustCreateSubscription(ctx, t, sclient, &pb.Subscription{
+		Name:                      "projects/P/subscriptions/S",
+		Topic:                     top.Name,
+		AckDeadlineSeconds:        10,
+		EnableExactlyOnceDelivery: true,
+	})
+	srv.Publish(top.Name, []byte("hello"), nil)
+
+	pull := func() *pb.ReceivedMessage {
+		t.Helper()
+		res, err := sclient.Pull(ctx, &pb.PullRequest{Subscription: sub.Name, MaxMessages: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.ReceivedMessages) != 1 {
+			t.Fatalf("got %d messages, want 1", len(res.ReceivedMessages))
+		}
+		return res.ReceivedMessages[0]
+	}
+	first := pull()
+	// Nack so the message is redelivered with a new ack ID.
+	if _, err := sclient.ModifyAckDeadline(ctx, &pb.ModifyAckDeadlineRequest{
+		Subscription:       sub.Name,
+		AckIds:             []string{first.AckId},
+		AckDeadlineSeconds: 0,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	second := pull()
+	if first.AckId == second.AckId {
+		t.Fatalf("ack ID %q reused across deliveries", first.AckId)
+	}
+
+	// Acking with the stale ack ID fails permanently.
+	_, err := sclient.Acknowledge(ctx, &pb.AcknowledgeRequest{
+		Subscription: sub.Name,
+		AckIds:       []string{first.AckId},
+	})
+	if got, want := status.Code(err), codes.InvalidArgument; got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	var info *errdetails.ErrorInfo
+	for _, d := range status.Convert(err).Details() {
+		if ei, ok := d.(*errdetails.ErrorInfo); ok {
+			info = ei
+		}
+	}
+	if info == nil {
+		t.Fatal("missing ErrorInfo in error details")
+	}
+	if got, want := info.Metadata[first.AckId], "PERMANENT_FAILURE_INVALID_ACK_ID"; got != want {
+		t.Errorf("got metadata %q, want %q", got, want)
+	}
+
+	// The current ack ID succeeds, but only once.
+	for i, wantCode := range []codes.Code{codes.OK, codes.InvalidArgument} {
+		_, err := sclient.Acknowledge(ctx, &pb.AcknowledgeRequest{
+			Subscription: sub.Name,
+			AckIds:       []string{second.AckId},
+		})
+		if got := status.Code(err); got != wantCode {
+			t.Fatalf("ack #%d: got %v, want %v", i, got, wantCode)
+		}
+	}
+	if got, want := srv.Messages()[0].Acks, 1; got != want {
+		t.Errorf("got %d acks, want %d", got, want)
+	}
+}
+
+func TestExactlyOnceDeliveryExpiredAckID(t *testing.T) {
+	ctx := context.Background()
+	pclient, sclient, srv, cleanup := newFake(ctx, t)
+	defer cleanup()
+
+	top := mustCreateTopic(ctx, t, pclient, &pb.Topic{Name: "projects/P/topics/T"})
+	sub := mustCreateSubscription(ctx, t, sclient, &pb.Subscription{
+		Name:                      "projects/P/subscriptions/S",
+		Topic:                     top.Name,
+		AckDeadlineSeconds:        10,
+		EnableExactlyOnceDelivery: true,
+	})
+	srv.Publish(top.Name, []byte("hello"), nil)
+	res, err := sclient.Pull(ctx, &pb.PullRequest{Subscription: sub.Name, MaxMessages: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.ReceivedMessages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(res.ReceivedMessages))
+	}
+
+	srv.SetTimeNowFunc(func() time.Time { return time.Now().Add(time.Minute) })
+	defer srv.SetTimeNowFunc(time.Now)
+	_, err = sclient.ModifyAckDeadline(ctx, &pb.ModifyAckDeadlineRequest{
+		Subscription:       sub.Name,
+		AckIds:             []string{res.ReceivedMessages[0].AckId},
+		AckDeadlineSeconds: 10,
+	})
+	if got, want := status.Code(err), codes.InvalidArgument; got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestExportSubscription(t *testing.T) {
+	ctx := context.Background()
+	pclient, sclient, srv, cleanup := newFake(ctx, t)
+	defer cleanup()
+
+	top := mustCreateTopic(ctx, t, pclient, &pb.Topic{Name: "projects/P/topics/T"})
+	bqSub := mustCreateSubscription(ctx, t, sclient, &pb.Subscription{
+		Name:               "projects/P/subscriptions/bq",
+		Topic:              top.Name,
+		AckDeadlineSeconds: 10,
+		BigqueryConfig:     &pb.BigQueryConfig{Table: "projects/P/datasets/D/tables/T"},
+		Filter:             "attributes.keep = \"yes\"",
+	})
+	gcsSub := mustCreateSubscription(ctx, t, sclient, &pb.Subscription{
+		Name:               "projects/P/subscriptions/gcs",
+		Topic:              top.Name,
+		AckDeadlineSeconds: 10,
+		CloudStorageConfig: &pb.CloudStorageConfig{Bucket: "B"},
+	})
+	srv.Publish(top.Name, []byte("1"), map[string]string{"keep": "yes"})
+	srv.Publish(top.Name, []byte("2"), map[string]string{"keep": "no"})
+
+	if got, want := len(srv.ExportedMessages(bqSub.Name)), 1; got != want {
+		t.Errorf("BigQuery subscription: got %d exported messages, want %d", got, want)
+	}
+	got := srv.ExportedMessages(gcsSub.Name)
+	if len(got) != 2 || string(got[0].Data) != "1" || string(got[1].Data) != "2" {
+		t.Errorf("Cloud Storage subscription: got %v, want messages 1 and 2", got)
+	}
+
+	_, err := sclient.Pull(ctx, &pb.PullRequest{Subscription: bqSub.Name, ReturnImmediately: true})
+	if got, want := status.Code(err), codes.FailedPrecondition; got != want {
+		t.Errorf("Pull: got %v, want %v", got, want)
+	}
+}
+
 // Test Create, Get, List, and Delete methods for schema client.
 // Updating a schema is not available at this moment.
 func TestSchemaAdminClient(t *testing.T) {
@@ -208,3 +208,11 @@ func filterByAttrs[T map[K]U, U any, K comparable](items T, filter *filtering.Fi
 		filtering.Walk(walkFn, filter.CheckedExpr.Expr)
 	}
 }
+
+// matchesFilter reports whether a message with the given attributes passes
+// filter.
+func matchesFilter(attrs messageAttrs, filter *filtering.Filter) bool {
+	items := map[int]messageAttrs{0: attrs}
+	filterByAttrs(items, filter, func(a messageAttrs) messageAttrs { return a })
+	return len(items) == 1
+}
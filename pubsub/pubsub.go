@@ -28,6 +28,7 @@ import (
 	vkit "cloud.google.com/go/pubsub/apiv1"
 	"cloud.google.com/go/pubsub/internal"
 	gax "github.com/googleapis/gax-go/v2"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/api/option"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/keepalive"
@@ -48,15 +49,40 @@ const (
 // Clients should be reused rather than being created as needed.
 // A Client may be shared by multiple goroutines.
 type Client struct {
-	projectID string
-	pubc      *vkit.PublisherClient
-	subc      *vkit.SubscriberClient
+	projectID     string
+	pubc          *vkit.PublisherClient
+	subc          *vkit.SubscriberClient
+	enableTracing bool
+	// tracerProvider is the TracerProvider used for tracing, or nil for the
+	// global one.
+	tracerProvider trace.TracerProvider
 }
 
 // ClientConfig has configurations for the client.
 type ClientConfig struct {
 	PublisherCallOptions  *vkit.PublisherCallOptions
 	SubscriberCallOptions *vkit.SubscriberCallOptions
+
+	// EnableOpenTelemetryTracing enables tracing of publish and subscribe
+	// operations with the global OpenTelemetry TracerProvider.
+	//
+	// When enabled, a producer span is started for each published message and
+	// its trace context is propagated to subscribers in message attributes
+	// prefixed with "googclient_". Subscribers continue the trace with a
+	// consumer span that lasts until the message is acked or nacked, with
+	// child spans for the processing callback. Publish, Acknowledge and
+	// ModifyAckDeadline RPCs get client spans of their own.
+	//
+	// It is EXPERIMENTAL and subject to change or removal without notice.
+	EnableOpenTelemetryTracing bool
+
+	// TracerProvider, if not nil, enables tracing as described for
+	// EnableOpenTelemetryTracing, with this TracerProvider rather than the
+	// global one. It lets clients of the same process trace to different
+	// providers, and tests record the spans of a client.
+	//
+	// It is EXPERIMENTAL and subject to change or removal without notice.
+	TracerProvider trace.TracerProvider
 }
 
 // mergePublisherCallOptions merges two PublisherCallOptions into one and the first argument has
@@ -184,10 +210,16 @@ func NewClientWithConfig(ctx context.Context, projectID string, config *ClientCo
 		return nil, err
 	}
 
+	var tp trace.TracerProvider
+	if config != nil {
+		tp = config.TracerProvider
+	}
 	return &Client{
-		projectID: projectID,
-		pubc:      pubc,
-		subc:      subc,
+		projectID:      projectID,
+		pubc:           pubc,
+		subc:           subc,
+		enableTracing:  config != nil && (config.EnableOpenTelemetryTracing || tp != nil),
+		tracerProvider: tp,
 	}, nil
 }
 
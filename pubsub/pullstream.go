@@ -108,6 +108,14 @@ func (s *pullStream) get(spc *pb.Subscriber_StreamingPullClient) (*pb.Subscriber
 	return s.spc, s.err
 }
 
+// isOpen reports whether the stream has been opened and has not failed or
+// been cancelled.
+func (s *pullStream) isOpen() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.spc != nil && *s.spc != nil && s.err == nil && s.ctx.Err() == nil
+}
+
 func (s *pullStream) openWithRetry() (pb.Subscriber_StreamingPullClient, error) {
 	r := defaultRetryer{}
 	for {
@@ -0,0 +1,111 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"sync/atomic"
+	"time"
+
+	"cloud.google.com/go/pubsub/internal/distribution"
+)
+
+// ackLatencyResolution is the bucket width of the ack latency distribution
+// kept by Receive. Latencies of ten minutes or more fall in the last bucket.
+const ackLatencyResolution = 100 * time.Millisecond
+
+// ReceiveStats is a snapshot of the state of a Subscription's Receive call.
+// It is EXPERIMENTAL and subject to change or removal without notice.
+type ReceiveStats struct {
+	// Active reports whether Receive is running. If it is false, the other
+	// fields are zero.
+	Active bool
+
+	// OpenStreams is the number of streaming pull connections that are open.
+	// It is zero when ReceiveSettings.Synchronous is set.
+	OpenStreams int
+
+	// OutstandingMessages is the number of messages that have been received
+	// and whose calls to the Receive callback have not returned yet. It is
+	// zero if MaxOutstandingMessages is negative.
+	OutstandingMessages int
+
+	// OutstandingBytes is the size of the outstanding messages. It is zero if
+	// MaxOutstandingBytes is negative.
+	OutstandingBytes int
+
+	// AckLatencyP50 and AckLatencyP99 are percentiles of the time between
+	// receiving a message and acking or nacking it, with a resolution of 100ms.
+	AckLatencyP50, AckLatencyP99 time.Duration
+
+	// ExpiredAcks is the number of messages that were acked or nacked after
+	// the client had stopped extending their ack deadline, because
+	// ReceiveSettings.MaxExtension had passed. Those acks are likely to fail,
+	// and the messages to be redelivered.
+	ExpiredAcks int64
+}
+
+// receiveState holds what ReceiveStats reports about an active Receive.
+type receiveState struct {
+	fc         *flowController
+	iters      []*messageIterator
+	ackLatency *distribution.D
+
+	// expiredAcks is the number of acks and nacks of expired messages. Atomic.
+	expiredAcks int64
+}
+
+func newReceiveState(fc *flowController) *receiveState {
+	return &receiveState{
+		fc:         fc,
+		ackLatency: distribution.New(int(10*time.Minute/ackLatencyResolution) + 1),
+	}
+}
+
+func (rs *receiveState) recordAck(receiveTime time.Time, expired bool) {
+	rs.ackLatency.Record(int(time.Since(receiveTime) / ackLatencyResolution))
+	if expired {
+		atomic.AddInt64(&rs.expiredAcks, 1)
+	}
+}
+
+func (rs *receiveState) snapshot() ReceiveStats {
+	st := ReceiveStats{
+		Active:              true,
+		OutstandingMessages: rs.fc.count(),
+		OutstandingBytes:    int(atomic.LoadInt64(&rs.fc.bytesRemaining)),
+		AckLatencyP50:       time.Duration(rs.ackLatency.Percentile(.5)) * ackLatencyResolution,
+		AckLatencyP99:       time.Duration(rs.ackLatency.Percentile(.99)) * ackLatencyResolution,
+		ExpiredAcks:         atomic.LoadInt64(&rs.expiredAcks),
+	}
+	for _, it := range rs.iters {
+		if it.ps != nil && it.ps.isOpen() {
+			st.OpenStreams++
+		}
+	}
+	return st
+}
+
+// ReceiveStats returns a snapshot of the state of the Subscription's active
+// Receive call, for use in health checks and alerts.
+// It is EXPERIMENTAL and subject to change or removal without notice.
+func (s *Subscription) ReceiveStats() ReceiveStats {
+	s.mu.Lock()
+	rs := s.receiveState
+	s.mu.Unlock()
+	if rs == nil {
+		return ReceiveStats{}
+	}
+	return rs.snapshot()
+}
@@ -16,9 +16,12 @@ package pubsub
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"time"
 
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 
 	vkit "cloud.google.com/go/pubsub/apiv1"
@@ -339,3 +342,136 @@ func (c *SchemaClient) ValidateMessageWithID(ctx context.Context, msg []byte, en
 	}
 	return &ValidateMessageResult{}, nil
 }
+
+// deletedSchemaName is the value of SchemaSettings.Schema for topics whose
+// schema has been deleted.
+const deletedSchemaName = "_deleted-schema_"
+
+// SchemaValidator checks messages against the revisions of a schema that a
+// topic's SchemaSettings allow, so that invalid messages can be rejected before
+// they are published. Create one with SchemaClient.NewSchemaValidator.
+//
+// Avro schemas are validated locally, in both the JSON and binary encodings.
+// Protocol buffer schemas are validated by the service, using the
+// ValidateMessage RPC with the definition of each allowed revision.
+type SchemaValidator struct {
+	c         *SchemaClient
+	encoding  SchemaEncoding
+	revisions []*SchemaConfig
+	avro      []*avroSchema // parallel to revisions; nil for non-Avro revisions
+}
+
+// NewSchemaValidator returns a SchemaValidator for the schema revisions
+// allowed by settings, typically taken from a TopicConfig. Revisions are
+// fetched once, when the validator is created.
+func (c *SchemaClient) NewSchemaValidator(ctx context.Context, settings *SchemaSettings) (*SchemaValidator, error) {
+	if settings == nil || settings.Schema == "" {
+		return nil, errors.New("pubsub: schema settings must name a schema")
+	}
+	if settings.Schema == deletedSchemaName {
+		return nil, errors.New("pubsub: the topic's schema has been deleted")
+	}
+	it := &SchemaIterator{
+		it: c.sc.ListSchemaRevisions(ctx, &pb.ListSchemaRevisionsRequest{
+			Name: settings.Schema,
+			View: pb.SchemaView(SchemaViewFull),
+		}),
+	}
+	var revs []*SchemaConfig
+	for {
+		s, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		revs = append(revs, s)
+	}
+	sort.SliceStable(revs, func(i, j int) bool {
+		return revs[i].RevisionCreateTime.Before(revs[j].RevisionCreateTime)
+	})
+
+	first, last := 0, len(revs)-1
+	if settings.FirstRevisionID != "" {
+		if first = revisionIndex(revs, settings.FirstRevisionID); first < 0 {
+			return nil, fmt.Errorf("pubsub: first revision %q not found in schema %q", settings.FirstRevisionID, settings.Schema)
+		}
+	}
+	if settings.LastRevisionID != "" {
+		if last = revisionIndex(revs, settings.LastRevisionID); last < 0 {
+			return nil, fmt.Errorf("pubsub: last revision %q not found in schema %q", settings.LastRevisionID, settings.Schema)
+		}
+	}
+	if first > last {
+		return nil, fmt.Errorf("pubsub: no revisions of schema %q between %q and %q", settings.Schema, settings.FirstRevisionID, settings.LastRevisionID)
+	}
+
+	v := &SchemaValidator{c: c, encoding: settings.Encoding}
+	for _, r := range revs[first : last+1] {
+		var as *avroSchema
+		if r.Type == SchemaAvro {
+			var err error
+			if as, err = parseAvroSchema(r.Definition); err != nil {
+				return nil, fmt.Errorf("pubsub: schema revision %q: %w", r.RevisionID, err)
+			}
+		}
+		v.revisions = append(v.revisions, r)
+		v.avro = append(v.avro, as)
+	}
+	return v, nil
+}
+
+func revisionIndex(revs []*SchemaConfig, id string) int {
+	for i, r := range revs {
+		if r.RevisionID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Revisions returns the schema revisions that messages are validated against,
+// oldest first.
+func (v *SchemaValidator) Revisions() []*SchemaConfig {
+	return v.revisions
+}
+
+// Validate returns nil if data is valid for at least one of the allowed schema
+// revisions in the configured encoding. Otherwise it returns the error from
+// validating against the newest revision.
+func (v *SchemaValidator) Validate(ctx context.Context, data []byte) error {
+	if len(v.revisions) == 0 {
+		return errors.New("pubsub: schema has no revisions to validate against")
+	}
+	var firstErr error
+	for i := len(v.revisions) - 1; i >= 0; i-- {
+		err := v.validateRevision(ctx, i, data)
+		if err == nil {
+			return nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (v *SchemaValidator) validateRevision(ctx context.Context, i int, data []byte) error {
+	if as := v.avro[i]; as != nil {
+		switch v.encoding {
+		case EncodingJSON:
+			return as.validateJSON(data)
+		case EncodingBinary:
+			return as.validateBinary(data)
+		default:
+			return fmt.Errorf("pubsub: unsupported schema encoding %d", v.encoding)
+		}
+	}
+	rev := v.revisions[i]
+	_, err := v.c.ValidateMessageWithConfig(ctx, data, v.encoding, SchemaConfig{
+		Type:       rev.Type,
+		Definition: rev.Definition,
+	})
+	return err
+}
@@ -185,13 +185,147 @@ func TestSchema_SchemaRevisions(t *testing.T) {
 }
 
 func TestSchemaRollbackSchema(t *testing.T) {
+	ctx := context.Background()
 	admin, _ := newSchemaFake(t)
 	defer admin.Close()
+
+	schemaID := "my-schema"
+	first, err := admin.CreateSchema(ctx, schemaID, SchemaConfig{Type: SchemaAvro, Definition: "def1"})
+	if err != nil {
+		t.Fatalf("CreateSchema() got err: %v", err)
+	}
+	if _, err := admin.CommitSchema(ctx, schemaID, SchemaConfig{Type: SchemaAvro, Definition: "def2"}); err != nil {
+		t.Fatalf("CommitSchema() got err: %v", err)
+	}
+
+	rb, err := admin.RollbackSchema(ctx, schemaID, first.RevisionID)
+	if err != nil {
+		t.Fatalf("RollbackSchema() got err: %v", err)
+	}
+	if rb.RevisionID == first.RevisionID {
+		t.Errorf("RollbackSchema() reused revision ID %q, want a new revision", rb.RevisionID)
+	}
+	if rb.Definition != "def1" {
+		t.Errorf("RollbackSchema() got definition %q, want %q", rb.Definition, "def1")
+	}
+
+	got, err := admin.Schema(ctx, schemaID, SchemaViewFull)
+	if err != nil {
+		t.Fatalf("Schema() got err: %v", err)
+	}
+	if got.RevisionID != rb.RevisionID {
+		t.Errorf("Schema() got revision %q, want latest revision %q", got.RevisionID, rb.RevisionID)
+	}
+
+	_, err = admin.RollbackSchema(ctx, schemaID, "bad-revision")
+	if st, _ := status.FromError(err); st.Code() != codes.NotFound {
+		t.Errorf("RollbackSchema() with unknown revision got err %v, want NotFound", err)
+	}
 }
 
 func TestSchemaDeleteSchemaRevision(t *testing.T) {
+	ctx := context.Background()
+	admin, _ := newSchemaFake(t)
+	defer admin.Close()
+
+	schemaID := "my-schema"
+	first, err := admin.CreateSchema(ctx, schemaID, SchemaConfig{Type: SchemaAvro, Definition: "def1"})
+	if err != nil {
+		t.Fatalf("CreateSchema() got err: %v", err)
+	}
+	if _, err := admin.DeleteSchemaRevision(ctx, schemaID, first.RevisionID); err == nil {
+		t.Fatal("DeleteSchemaRevision() of the only revision succeeded, want error")
+	}
+
+	second, err := admin.CommitSchema(ctx, schemaID, SchemaConfig{Type: SchemaAvro, Definition: "def2"})
+	if err != nil {
+		t.Fatalf("CommitSchema() got err: %v", err)
+	}
+	deleted, err := admin.DeleteSchemaRevision(ctx, schemaID, first.RevisionID)
+	if err != nil {
+		t.Fatalf("DeleteSchemaRevision() got err: %v", err)
+	}
+	if deleted.RevisionID != first.RevisionID {
+		t.Errorf("DeleteSchemaRevision() returned revision %q, want %q", deleted.RevisionID, first.RevisionID)
+	}
+
+	it := admin.ListSchemaRevisions(ctx, schemaID, SchemaViewFull)
+	var got []string
+	for {
+		sc, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			t.Fatalf("SchemaIterator.Next() got err: %v", err)
+		}
+		got = append(got, sc.RevisionID)
+	}
+	if want := []string{second.RevisionID}; !testutil.Equal(got, want) {
+		t.Errorf("ListSchemaRevisions() got %v, want %v", got, want)
+	}
+}
+
+func TestSchemaValidator(t *testing.T) {
+	ctx := context.Background()
 	admin, _ := newSchemaFake(t)
 	defer admin.Close()
+
+	schemaID := "my-schema"
+	schemaPath := fmt.Sprintf("projects/my-proj/schemas/%s", schemaID)
+	v1, err := admin.CreateSchema(ctx, schemaID, SchemaConfig{
+		Type:       SchemaAvro,
+		Definition: `{"type":"record","name":"State","fields":[{"name":"name","type":"string"}]}`,
+	})
+	if err != nil {
+		t.Fatalf("CreateSchema() got err: %v", err)
+	}
+	v2, err := admin.CommitSchema(ctx, schemaID, SchemaConfig{
+		Type: SchemaAvro,
+		Definition: `{"type":"record","name":"State","fields":[
+			{"name":"name","type":"string"},
+			{"name":"post_abbr","type":"string"}]}`,
+	})
+	if err != nil {
+		t.Fatalf("CommitSchema() got err: %v", err)
+	}
+
+	for _, tc := range []struct {
+		desc        string
+		first, last string
+		msg         string
+		wantErr     bool
+	}{
+		{desc: "any revision, old shape", msg: `{"name":"Alaska"}`},
+		{desc: "any revision, new shape", msg: `{"name":"Alaska","post_abbr":"AK"}`},
+		{desc: "any revision, invalid", msg: `{"name":1}`, wantErr: true},
+		{desc: "first only", last: v1.RevisionID, msg: `{"name":"Alaska","post_abbr":"AK"}`, wantErr: true},
+		{desc: "last only", first: v2.RevisionID, msg: `{"name":"Alaska"}`, wantErr: true},
+		{desc: "last only, new shape", first: v2.RevisionID, msg: `{"name":"Alaska","post_abbr":"AK"}`},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			v, err := admin.NewSchemaValidator(ctx, &SchemaSettings{
+				Schema:          schemaPath,
+				Encoding:        EncodingJSON,
+				FirstRevisionID: tc.first,
+				LastRevisionID:  tc.last,
+			})
+			if err != nil {
+				t.Fatalf("NewSchemaValidator() got err: %v", err)
+			}
+			err = v.Validate(ctx, []byte(tc.msg))
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Errorf("Validate(%s) got err %v, want error: %t", tc.msg, err, tc.wantErr)
+			}
+		})
+	}
+
+	if _, err := admin.NewSchemaValidator(ctx, &SchemaSettings{Schema: schemaPath, FirstRevisionID: "bad"}); err == nil {
+		t.Error("NewSchemaValidator() with unknown revision succeeded, want error")
+	}
+	if _, err := admin.NewSchemaValidator(ctx, &SchemaSettings{Schema: "_deleted-schema_"}); err == nil {
+		t.Error("NewSchemaValidator() with deleted schema succeeded, want error")
+	}
 }
 
 func TestSchemaValidateSchema(t *testing.T) {
@@ -28,7 +28,9 @@ import (
 	pb "cloud.google.com/go/pubsub/apiv1/pubsubpb"
 	"cloud.google.com/go/pubsub/internal/scheduler"
 	gax "github.com/googleapis/gax-go/v2"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/durationpb"
@@ -50,6 +52,8 @@ type Subscription struct {
 
 	mu            sync.Mutex
 	receiveActive bool
+	// receiveState is the state of the active Receive, if any.
+	receiveState *receiveState
 
 	enableOrdering bool
 }
@@ -309,6 +313,11 @@ type BigQueryConfig struct {
 	// subscription's backlog.
 	DropUnknownFields bool
 
+	// When true, use the BigQuery table's schema as the columns to write to
+	// in BigQuery. UseTableSchema and UseTopicSchema cannot be enabled at
+	// the same time.
+	UseTableSchema bool
+
 	// This is an output-only field that indicates whether or not the subscription can
 	// receive messages. This field is set only in responses from the server;
 	// it is ignored if it is set in any requests.
@@ -330,6 +339,7 @@ func (bc *BigQueryConfig) toProto() *pb.BigQueryConfig {
 		WriteMetadata:     bc.WriteMetadata,
 		DropUnknownFields: bc.DropUnknownFields,
 		State:             pb.BigQueryConfig_State(bc.State),
+		UseTableSchema:    bc.UseTableSchema,
 	}
 	return pbCfg
 }
@@ -724,6 +734,7 @@ func protoToBQConfig(pbBQ *pb.BigQueryConfig) *BigQueryConfig {
 		DropUnknownFields: pbBQ.GetDropUnknownFields(),
 		WriteMetadata:     pbBQ.GetWriteMetadata(),
 		State:             BigQueryConfigState(pbBQ.State),
+		UseTableSchema:    pbBQ.GetUseTableSchema(),
 	}
 	return bq
 }
@@ -933,6 +944,19 @@ type ReceiveSettings struct {
 	// Synchronous to false.
 	// Synchronous mode does not work with exactly once delivery.
 	Synchronous bool
+
+	// WaitForAckResults makes Receive wait, before returning, until the
+	// AckResult of every message acked or nacked during the call has
+	// completed. On a subscription with exactly-once delivery enabled, this
+	// means the service has confirmed or rejected each ack and nack, so a
+	// process can exit after Receive returns without losing acknowledgements
+	// still being retried. Failed acknowledgements are reported through the
+	// AckResult as an *AckError.
+	//
+	// WaitForAckResults has no effect on subscriptions without exactly-once
+	// delivery, whose AckResults complete immediately.
+	// The default is false.
+	WaitForAckResults bool
 }
 
 // For synchronous receive, the time to wait if we are already processing
@@ -1284,6 +1308,9 @@ func (s *Subscription) Receive(ctx context.Context, f func(context.Context, *Mes
 		maxOutstandingMessages: maxCount,
 		maxOutstandingBytes:    maxBytes,
 		useLegacyFlowControl:   s.ReceiveSettings.UseLegacyFlowControl,
+		waitForAckResults:      s.ReceiveSettings.WaitForAckResults,
+		enableTracing:          s.c.enableTracing,
+		tracerProvider:         s.c.tracerProvider,
 	}
 	fc := newSubscriptionFlowController(FlowControlSettings{
 		MaxOutstandingMessages: maxCount,
@@ -1293,6 +1320,9 @@ func (s *Subscription) Receive(ctx context.Context, f func(context.Context, *Mes
 
 	sched := scheduler.NewReceiveScheduler(maxCount)
 
+	rs := newReceiveState(&fc)
+	defer func() { s.mu.Lock(); s.receiveState = nil; s.mu.Unlock() }()
+
 	// Wait for all goroutines started by Receive to return, so instead of an
 	// obscure goroutine leak we have an obvious blocked call to Receive.
 	group, gctx := errgroup.WithContext(ctx)
@@ -1315,6 +1345,8 @@ func (s *Subscription) Receive(ctx context.Context, f func(context.Context, *Mes
 		// canceling that context would immediately stop the iterator without
 		// waiting for unacked messages.
 		iter := newMessageIterator(s.c.subc, s.name, po)
+		iter.stats = rs
+		rs.iters = append(rs.iters, iter)
 
 		// We cannot use errgroup from Receive here. Receive might already be
 		// calling group.Wait, and group.Wait cannot be called concurrently with
@@ -1390,6 +1422,10 @@ func (s *Subscription) Receive(ctx context.Context, f func(context.Context, *Mes
 					iter.eoMu.RLock()
 					msgAckHandler(msg, iter.enableExactlyOnceDelivery)
 					iter.eoMu.RUnlock()
+					msgCtx := ctx2
+					if s.c.enableTracing {
+						msgCtx, _ = startSubscribeSpan(ctx2, s.c.tracerProvider, s.ID(), msg)
+					}
 
 					wg.Add(1)
 					// Make sure the subscription has ordering enabled before adding to scheduler.
@@ -1401,7 +1437,13 @@ func (s *Subscription) Receive(ctx context.Context, f func(context.Context, *Mes
 					if err := sched.Add(key, msg, func(msg interface{}) {
 						defer wg.Done()
 						defer fc.release(ctx, msgLen)
-						f(ctx2, msg.(*Message))
+						fctx := msgCtx
+						if s.c.enableTracing {
+							var span trace.Span
+							fctx, span = startProcessSpan(msgCtx, s.c.tracerProvider, s.ID())
+							defer span.End()
+						}
+						f(fctx, msg.(*Message))
 					}); err != nil {
 						wg.Done()
 						// If there are any errors with scheduling messages,
@@ -1417,6 +1459,9 @@ func (s *Subscription) Receive(ctx context.Context, f func(context.Context, *Mes
 			}
 		})
 	}
+	s.mu.Lock()
+	s.receiveState = rs
+	s.mu.Unlock()
 
 	go func() {
 		<-ctx2.Done()
@@ -1436,6 +1481,137 @@ func (s *Subscription) Receive(ctx context.Context, f func(context.Context, *Mes
 	return group.Wait()
 }
 
+var errFetchMaxMessages = errors.New("pubsub: Fetch maxMessages must be greater than 0")
+
+// Fetch pulls up to maxMessages messages from the subscription and returns
+// them. It is intended for workloads that process messages in synchronous
+// batches, such as cron jobs or Cloud Run jobs, rather than through the
+// callback passed to Receive.
+//
+// Fetch returns once maxMessages messages have been pulled or maxWait has
+// elapsed, whichever comes first. In the latter case the messages pulled so
+// far are returned, which may be none. If maxWait is zero or negative, Fetch
+// makes a single Pull call and returns whatever it yields.
+//
+// Client code must call Ack or Nack on each returned Message. Unlike Receive,
+// Fetch does not extend ack deadlines, so messages must be acknowledged
+// before the subscription's AckDeadline passes or they will be redelivered.
+// Acks and nacks are sent to the service as they are made. The AckResult
+// returned by AckWithResult or NackWithResult completes once the service has
+// responded, regardless of whether exactly-once delivery is enabled.
+//
+// If ctx is done before Fetch returns, any messages already pulled are nacked
+// and the context's error is returned.
+func (s *Subscription) Fetch(ctx context.Context, maxMessages int, maxWait time.Duration) ([]*Message, error) {
+	if maxMessages <= 0 {
+		return nil, errFetchMaxMessages
+	}
+	ctx = withSubscriptionKey(ctx, s.name)
+	wctx := ctx
+	if maxWait > 0 {
+		var cancel context.CancelFunc
+		wctx, cancel = context.WithTimeout(ctx, maxWait)
+		defer cancel()
+	}
+	nackAll := func(msgs []*Message) {
+		for _, m := range msgs {
+			m.Nack()
+		}
+	}
+
+	var msgs []*Message
+	for len(msgs) < maxMessages {
+		res, err := s.c.subc.Pull(wctx, &pb.PullRequest{
+			Subscription: s.name,
+			MaxMessages:  trunc32(int64(maxMessages - len(msgs))),
+		}, gax.WithGRPCOptions(grpc.MaxCallRecvMsgSize(maxSendRecvBytes)))
+		if err != nil {
+			if ctx.Err() != nil {
+				nackAll(msgs)
+				return nil, ctx.Err()
+			}
+			if wctx.Err() != nil {
+				// maxWait elapsed.
+				break
+			}
+			nackAll(msgs)
+			return nil, err
+		}
+		recordStat(ctx, PullCount, int64(len(res.ReceivedMessages)))
+		pulled, err := convertMessages(res.ReceivedMessages, time.Now(), s.fetchDone)
+		if err != nil {
+			nackAll(msgs)
+			return nil, err
+		}
+		for _, m := range pulled {
+			// Always report the outcome of the ack RPC in the AckResult, since
+			// there is no streaming pull response to tell us whether
+			// exactly-once delivery is enabled.
+			msgAckHandler(m, true)
+			if s.c.enableTracing {
+				startSubscribeSpan(ctx, s.c.tracerProvider, s.ID(), m)
+			}
+		}
+		msgs = append(msgs, pulled...)
+		if maxWait <= 0 {
+			break
+		}
+	}
+	return msgs, nil
+}
+
+// fetchDone acknowledges or nacks a message returned by Fetch and completes
+// its AckResult. Requests that fail with a transient error are retried with
+// backoff until exactlyOnceDeliveryRetryDeadline.
+func (s *Subscription) fetchDone(ackID string, ack bool, r *AckResult, _ time.Time) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), exactlyOnceDeliveryRetryDeadline)
+		defer cancel()
+		ctx = withSubscriptionKey(ctx, s.name)
+		m := map[string]*AckResult{ackID: r}
+		bo := newExactlyOnceBackoff()
+		for {
+			var err error
+			var span trace.Span
+			if ack {
+				recordStat(ctx, AckCount, 1)
+				rctx := ctx
+				if s.c.enableTracing {
+					rctx, span = startAckRPCSpan(ctx, s.c.tracerProvider, s.ID(), "ack", 1)
+				}
+				err = s.c.subc.Acknowledge(rctx, &pb.AcknowledgeRequest{
+					Subscription: s.name,
+					AckIds:       []string{ackID},
+				})
+			} else {
+				recordStat(ctx, NackCount, 1)
+				rctx := ctx
+				if s.c.enableTracing {
+					rctx, span = startAckRPCSpan(ctx, s.c.tracerProvider, s.ID(), "nack", 1)
+				}
+				// Nack indicated by modifying the deadline to zero.
+				err = s.c.subc.ModifyAckDeadline(rctx, &pb.ModifyAckDeadlineRequest{
+					Subscription: s.name,
+					AckIds:       []string{ackID},
+				})
+			}
+			endSpan(span, err)
+			if ctx.Err() != nil {
+				setAckRetriesExhausted(r, ackID, ctx.Err())
+				return
+			}
+			st, md := extractMetadata(err)
+			if _, toRetry := processResults(st, m, md); len(toRetry) == 0 {
+				return
+			}
+			if err := gax.Sleep(ctx, bo.Pause()); err != nil {
+				setAckRetriesExhausted(r, ackID, err)
+				return
+			}
+		}
+	}()
+}
+
 // checkOrdering calls Config to check theEnableMessageOrdering field.
 // If this call fails (e.g. because the service account doesn't have
 // the roles/viewer or roles/pubsub.viewer role) we will assume
@@ -1461,4 +1637,7 @@ type pullOptions struct {
 	maxOutstandingMessages int
 	maxOutstandingBytes    int
 	useLegacyFlowControl   bool
+	waitForAckResults      bool
+	enableTracing          bool
+	tracerProvider         trace.TracerProvider
 }
@@ -333,6 +333,109 @@ func testReceive(t *testing.T, synchronous, exactlyOnceDelivery bool) {
 	})
 }
 
+func TestReceiveStats(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	client, srv := newFake(t)
+	defer client.Close()
+	defer srv.Close()
+
+	topic := mustCreateTopic(t, client, "t")
+	sub, err := client.CreateSubscription(ctx, "s", SubscriptionConfig{Topic: topic})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st := sub.ReceiveStats(); st.Active {
+		t.Errorf("ReceiveStats() before Receive got %+v, want inactive", st)
+	}
+	srv.Publish(topic.name, []byte("hello"), nil)
+
+	sub.ReceiveSettings.NumGoroutines = 1
+	cctx, cancelReceive := context.WithCancel(ctx)
+	received := make(chan *Message)
+	release := make(chan struct{})
+	errc := make(chan error, 1)
+	go func() {
+		errc <- sub.Receive(cctx, func(_ context.Context, m *Message) {
+			received <- m
+			// The message is outstanding until the callback returns.
+			<-release
+		})
+	}()
+
+	var m *Message
+	select {
+	case m = <-received:
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for message")
+	}
+	st := sub.ReceiveStats()
+	if !st.Active || st.OpenStreams != 1 || st.OutstandingMessages != 1 || st.OutstandingBytes != len("hello") {
+		t.Errorf("ReceiveStats() with one message outstanding got %+v", st)
+	}
+	m.Ack()
+	close(release)
+	cancelReceive()
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if st := sub.ReceiveStats(); st.Active {
+		t.Errorf("ReceiveStats() after Receive got %+v, want inactive", st)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	client, srv := newFake(t)
+	defer client.Close()
+	defer srv.Close()
+
+	topic := mustCreateTopic(t, client, "t")
+	sub, err := client.CreateSubscription(ctx, "s", SubscriptionConfig{Topic: topic})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sub.Fetch(ctx, 0, time.Second); err != errFetchMaxMessages {
+		t.Fatalf("Fetch with maxMessages 0: got %v, want %v", err, errFetchMaxMessages)
+	}
+	for i := 0; i < 8; i++ {
+		srv.Publish(topic.name, []byte{byte(i)}, nil)
+	}
+
+	var seen [8]bool
+	for _, n := range []int{5, 3} {
+		msgs, err := sub.Fetch(ctx, n, 5*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(msgs) != n {
+			t.Fatalf("got %d messages, want %d", len(msgs), n)
+		}
+		for _, m := range msgs {
+			seen[m.Data[0]] = true
+			st, err := m.AckWithResult().Get(ctx)
+			if err != nil || st != AcknowledgeStatusSuccess {
+				t.Fatalf("AckResult: got (%v, %v), want (AcknowledgeStatusSuccess, nil)", st, err)
+			}
+		}
+	}
+	for i, saw := range seen {
+		if !saw {
+			t.Errorf("did not see message #%d", i)
+		}
+	}
+
+	// With no messages left, Fetch returns once maxWait elapses.
+	msgs, err := sub.Fetch(ctx, 5, 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 0 {
+		t.Errorf("got %d messages, want 0", len(msgs))
+	}
+}
+
 func (t1 *Topic) Equal(t2 *Topic) bool {
 	if t1 == nil && t2 == nil {
 		return true
@@ -33,6 +33,8 @@ import (
 	gax "github.com/googleapis/gax-go/v2"
 	"go.opencensus.io/stats"
 	"go.opencensus.io/tag"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/api/support/bundler"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -77,6 +79,8 @@ type Topic struct {
 	mu        sync.RWMutex
 	stopped   bool
 	scheduler *scheduler.PublishScheduler
+	// batcher tunes the scheduler's thresholds if adaptive batching is enabled.
+	batcher *adaptiveBatcher
 
 	flowController
 
@@ -117,6 +121,31 @@ type PublishSettings struct {
 
 	// FlowControlSettings defines publisher flow control settings.
 	FlowControlSettings FlowControlSettings
+
+	// PayloadCompression, if set, compresses the data of messages that are at
+	// least PayloadCompressionThreshold bytes long before they are published.
+	// Compressed messages carry an attribute naming the algorithm, and are
+	// decompressed transparently by Subscription.Receive and
+	// Subscription.Fetch. Subscribers that do not use this package see the
+	// compressed data.
+	PayloadCompression PayloadCompression
+
+	// PayloadCompressionThreshold is the minimum size of message data, in
+	// bytes, to compress.
+	//
+	// Defaults to DefaultPublishSettings.PayloadCompressionThreshold.
+	PayloadCompressionThreshold int
+
+	// OnOrderingKeyPaused, if set, is called when publishing for an ordering
+	// key is paused because a message with that key failed to publish. err is
+	// the error that caused the pause. It is called in its own goroutine, so it
+	// may call Topic.ResumePublish.
+	OnOrderingKeyPaused func(orderingKey string, err error)
+
+	// AdaptiveBatching configures the publisher to tune DelayThreshold and
+	// CountThreshold at runtime to meet a latency target. DelayThreshold and
+	// CountThreshold become the largest values that will be used.
+	AdaptiveBatching AdaptiveBatchingSettings
 }
 
 // DefaultPublishSettings holds the default values for topics' PublishSettings.
@@ -129,6 +158,8 @@ var DefaultPublishSettings = PublishSettings{
 	// chosen as a reasonable amount of messages in the worst case whilst still
 	// capping the number to a low enough value to not OOM users.
 	BufferedByteLimit: 10 * MaxPublishRequestBytes,
+	// Small messages rarely shrink enough to pay for the marker attribute.
+	PayloadCompressionThreshold: 1024,
 	FlowControlSettings: FlowControlSettings{
 		MaxOutstandingMessages: 1000,
 		MaxOutstandingBytes:    -1,
@@ -232,6 +263,13 @@ type TopicConfig struct {
 	//
 	// For more information, see https://cloud.google.com/pubsub/docs/replay-overview#topic_message_retention.
 	RetentionDuration optional.Duration
+
+	// State is an output-only field indicating the state of the topic.
+	State TopicState
+
+	// IngestionDataSourceSettings are settings for ingestion from a
+	// data source into this topic.
+	IngestionDataSourceSettings *IngestionDataSourceSettings
 }
 
 // String returns the printable globally unique name for the topic config.
@@ -260,15 +298,150 @@ func (tc *TopicConfig) toProto() *pb.Topic {
 		retDur = durationpb.New(optional.ToDuration(tc.RetentionDuration))
 	}
 	pbt := &pb.Topic{
-		Labels:                   tc.Labels,
-		MessageStoragePolicy:     messageStoragePolicyToProto(&tc.MessageStoragePolicy),
-		KmsKeyName:               tc.KMSKeyName,
-		SchemaSettings:           schemaSettingsToProto(tc.SchemaSettings),
-		MessageRetentionDuration: retDur,
+		Labels:                      tc.Labels,
+		MessageStoragePolicy:        messageStoragePolicyToProto(&tc.MessageStoragePolicy),
+		KmsKeyName:                  tc.KMSKeyName,
+		SchemaSettings:              schemaSettingsToProto(tc.SchemaSettings),
+		MessageRetentionDuration:    retDur,
+		State:                       pb.Topic_State(tc.State),
+		IngestionDataSourceSettings: tc.IngestionDataSourceSettings.toProto(),
 	}
 	return pbt
 }
 
+// TopicState denotes the possible states for a topic.
+type TopicState int
+
+const (
+	// TopicStateUnspecified is the default value. This value is unused.
+	TopicStateUnspecified = iota
+
+	// TopicStateActive means the topic does not have any persistent errors.
+	TopicStateActive
+
+	// TopicStateIngestionResourceError means ingestion from the data source
+	// has encountered a permanent error.
+	// See the more detailed error state in the corresponding ingestion
+	// source configuration.
+	TopicStateIngestionResourceError
+)
+
+// IngestionDataSourceSettings enables ingestion from a data source into this topic.
+type IngestionDataSourceSettings struct {
+	// Source is the data source to ingest from. The only supported source
+	// is *IngestionDataSourceAWSKinesis.
+	Source IngestionDataSource
+}
+
+// IngestionDataSource is the kind of ingestion source to be used.
+type IngestionDataSource interface {
+	isIngestionDataSource() bool
+}
+
+// AWSKinesisState denotes the possible states for ingestion from Amazon Kinesis Data Streams.
+type AWSKinesisState int
+
+const (
+	// AWSKinesisStateUnspecified is the default value. This value is unused.
+	AWSKinesisStateUnspecified = iota
+
+	// AWSKinesisStateActive means ingestion is active.
+	AWSKinesisStateActive
+
+	// AWSKinesisStatePermissionDenied means encountering an error while consuming data from Kinesis.
+	// This can happen if:
+	//   - The provided `aws_role_arn` does not exist or does not have the
+	//     appropriate permissions attached.
+	//   - The provided `aws_role_arn` is not set up properly for Identity
+	//     Federation using `gcp_service_account`.
+	//   - The Pub/Sub SA is not granted the
+	//     `iam.serviceAccounts.getOpenIdToken` permission on
+	//     `gcp_service_account`.
+	AWSKinesisStatePermissionDenied
+
+	// AWSKinesisStatePublishPermissionDenied means permission denied encountered while publishing to the topic.
+	// This can happen due to Pub/Sub SA has not been granted the appropriate publish
+	// permissions https://cloud.google.com/pubsub/docs/access-control#pubsub.publisher
+	AWSKinesisStatePublishPermissionDenied
+
+	// AWSKinesisStateStreamNotFound means the Kinesis stream does not exist.
+	AWSKinesisStateStreamNotFound
+
+	// AWSKinesisStateConsumerNotFound means the Kinesis consumer does not exist.
+	AWSKinesisStateConsumerNotFound
+)
+
+// IngestionDataSourceAWSKinesis are ingestion settings for Amazon Kinesis Data Streams.
+type IngestionDataSourceAWSKinesis struct {
+	// State is an output-only field indicating the state of the kinesis connection.
+	State AWSKinesisState
+
+	// StreamARN is the Kinesis stream ARN to ingest data from.
+	StreamARN string
+
+	// ConsumerARN is the Kinesis consumer ARN to used for ingestion in Enhanced
+	// Fan-Out mode. The consumer must be already created and ready to be used.
+	ConsumerARN string
+
+	// AWSRoleARN is the AWS role ARN to be used for Federated Identity authentication
+	// with Kinesis. Check the Pub/Sub docs for how to set up this role and the
+	// required permissions that need to be attached to it.
+	AWSRoleARN string
+
+	// GCPServiceAccount is the GCP service account to be used for Federated Identity
+	// authentication with Kinesis (via a `AssumeRoleWithWebIdentity` call for
+	// the provided role). The `awsRoleArn` must be set up with
+	// `accounts.google.com:sub` equals to this service account number.
+	GCPServiceAccount string
+}
+
+var _ IngestionDataSource = (*IngestionDataSourceAWSKinesis)(nil)
+
+func (i *IngestionDataSourceAWSKinesis) isIngestionDataSource() bool {
+	return true
+}
+
+func (i *IngestionDataSourceSettings) toProto() *pb.IngestionDataSourceSettings {
+	if i == nil {
+		return nil
+	}
+	// An empty/zero-valued config is treated the same as nil and clearing
+	// this setting.
+	if (IngestionDataSourceSettings{}) == *i {
+		return nil
+	}
+	pbs := &pb.IngestionDataSourceSettings{}
+	if k, ok := i.Source.(*IngestionDataSourceAWSKinesis); ok {
+		pbs.Source = &pb.IngestionDataSourceSettings_AwsKinesis_{
+			AwsKinesis: &pb.IngestionDataSourceSettings_AwsKinesis{
+				State:             pb.IngestionDataSourceSettings_AwsKinesis_State(k.State),
+				StreamArn:         k.StreamARN,
+				ConsumerArn:       k.ConsumerARN,
+				AwsRoleArn:        k.AWSRoleARN,
+				GcpServiceAccount: k.GCPServiceAccount,
+			},
+		}
+	}
+	return pbs
+}
+
+func protoToIngestionDataSourceSettings(pbs *pb.IngestionDataSourceSettings) *IngestionDataSourceSettings {
+	if pbs == nil {
+		return nil
+	}
+	s := &IngestionDataSourceSettings{}
+	if k := pbs.GetAwsKinesis(); k != nil {
+		s.Source = &IngestionDataSourceAWSKinesis{
+			State:             AWSKinesisState(k.State),
+			StreamARN:         k.GetStreamArn(),
+			ConsumerARN:       k.GetConsumerArn(),
+			AWSRoleARN:        k.GetAwsRoleArn(),
+			GCPServiceAccount: k.GetGcpServiceAccount(),
+		}
+	}
+	return s
+}
+
 // TopicConfigToUpdate describes how to update a topic.
 type TopicConfigToUpdate struct {
 	// If non-nil, the current set of labels is completely
@@ -296,15 +469,24 @@ type TopicConfigToUpdate struct {
 	//
 	// Use the zero value &SchemaSettings{} to remove the schema from the topic.
 	SchemaSettings *SchemaSettings
+
+	// IngestionDataSourceSettings, if non-nil, replaces the topic's ingestion
+	// settings.
+	//
+	// Use the zero value &IngestionDataSourceSettings{} to remove the
+	// ingestion settings from the topic.
+	IngestionDataSourceSettings *IngestionDataSourceSettings
 }
 
 func protoToTopicConfig(pbt *pb.Topic) TopicConfig {
 	tc := TopicConfig{
-		name:                 pbt.Name,
-		Labels:               pbt.Labels,
-		MessageStoragePolicy: protoToMessageStoragePolicy(pbt.MessageStoragePolicy),
-		KMSKeyName:           pbt.KmsKeyName,
-		SchemaSettings:       protoToSchemaSettings(pbt.SchemaSettings),
+		name:                        pbt.Name,
+		Labels:                      pbt.Labels,
+		MessageStoragePolicy:        protoToMessageStoragePolicy(pbt.MessageStoragePolicy),
+		KMSKeyName:                  pbt.KmsKeyName,
+		SchemaSettings:              protoToSchemaSettings(pbt.SchemaSettings),
+		State:                       TopicState(pbt.State),
+		IngestionDataSourceSettings: protoToIngestionDataSourceSettings(pbt.IngestionDataSourceSettings),
 	}
 	if pbt.GetMessageRetentionDuration() != nil {
 		tc.RetentionDuration = pbt.GetMessageRetentionDuration().AsDuration()
@@ -437,6 +619,10 @@ func (t *Topic) updateRequest(cfg TopicConfigToUpdate) *pb.UpdateTopicRequest {
 			pt.SchemaSettings = nil
 		}
 	}
+	if cfg.IngestionDataSourceSettings != nil {
+		pt.IngestionDataSourceSettings = cfg.IngestionDataSourceSettings.toProto()
+		paths = append(paths, "ingestion_data_source_settings")
+	}
 	return &pb.UpdateTopicRequest{
 		Topic:      pt,
 		UpdateMask: &fmpb.FieldMask{Paths: paths},
@@ -573,10 +759,25 @@ func (t *Topic) Publish(ctx context.Context, msg *Message) *PublishResult {
 	}
 
 	r := ipubsub.NewPublishResult()
-	if !t.EnableMessageOrdering && msg.OrderingKey != "" {
-		ipubsub.SetPublishResult(r, "", errTopicOrderingNotEnabled)
+	var createSpan trace.Span
+	if t.c.enableTracing {
+		ctx, createSpan = startCreateSpan(ctx, t.c.tracerProvider, t.ID(), msg)
+	}
+	fail := func(err error) *PublishResult {
+		ipubsub.SetPublishResult(r, "", err)
+		endSpan(createSpan, err)
 		return r
 	}
+	if !t.EnableMessageOrdering && msg.OrderingKey != "" {
+		return fail(errTopicOrderingNotEnabled)
+	}
+	if c := t.PublishSettings.PayloadCompression; c != PayloadCompressionNone {
+		threshold := t.PublishSettings.PayloadCompressionThreshold
+		if threshold <= 0 {
+			threshold = DefaultPublishSettings.PayloadCompressionThreshold
+		}
+		msg = compressMessage(msg, c, threshold)
+	}
 
 	// Calculate the size of the encoded proto message by accounting
 	// for the length of an individual PubSubMessage and Data/Attributes field.
@@ -590,19 +791,26 @@ func (t *Topic) Publish(ctx context.Context, msg *Message) *PublishResult {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 	if t.stopped {
-		ipubsub.SetPublishResult(r, "", ErrTopicStopped)
-		return r
+		return fail(ErrTopicStopped)
 	}
 
 	if err := t.flowController.acquire(ctx, msgSize); err != nil {
-		t.scheduler.Pause(msg.OrderingKey)
-		ipubsub.SetPublishResult(r, "", err)
-		return r
+		t.pauseOrderingKey(ctx, msg.OrderingKey, err)
+		return fail(err)
 	}
-	err = t.scheduler.Add(msg.OrderingKey, &bundledMessage{msg, r, msgSize}, msgSize)
+	bm := &bundledMessage{
+		msg:        msg,
+		res:        r,
+		size:       msgSize,
+		createSpan: createSpan,
+	}
+	if t.batcher != nil {
+		bm.enqueued = time.Now()
+	}
+	err = t.scheduler.Add(msg.OrderingKey, bm, msgSize)
 	if err != nil {
-		t.scheduler.Pause(msg.OrderingKey)
-		ipubsub.SetPublishResult(r, "", err)
+		t.pauseOrderingKey(ctx, msg.OrderingKey, err)
+		return fail(err)
 	}
 	return r
 }
@@ -633,6 +841,11 @@ type bundledMessage struct {
 	msg  *Message
 	res  *PublishResult
 	size int
+	// createSpan is the message's create span, if tracing is enabled.
+	createSpan trace.Span
+	// enqueued is when the message was passed to Publish, if adaptive
+	// batching is enabled.
+	enqueued time.Time
 }
 
 func (t *Topic) initBundler() {
@@ -675,6 +888,9 @@ func (t *Topic) initBundler() {
 		t.scheduler.BundleCountThreshold = MaxPublishRequestCount
 	}
 	t.scheduler.BundleByteThreshold = t.PublishSettings.ByteThreshold
+	if t.PublishSettings.AdaptiveBatching.TargetLatency > 0 {
+		t.batcher = newAdaptiveBatcher(t.PublishSettings.AdaptiveBatching, t.scheduler.DelayThreshold, t.scheduler.BundleCountThreshold)
+	}
 
 	fcs := DefaultPublishSettings.FlowControlSettings
 	fcs.LimitExceededBehavior = t.PublishSettings.FlowControlSettings.LimitExceededBehavior
@@ -731,6 +947,10 @@ func (t *Topic) publishMessageBundle(ctx context.Context, bms []*bundledMessage)
 		}
 		bm.msg = nil // release bm.msg for GC
 	}
+	var rpcSpan trace.Span
+	if t.c.enableTracing {
+		ctx, rpcSpan = startPublishRPCSpan(ctx, t.c.tracerProvider, t.ID(), bms)
+	}
 	var res *pb.PublishResponse
 	start := time.Now()
 	if orderingKey != "" && t.scheduler.IsPaused(orderingKey) {
@@ -752,7 +972,7 @@ func (t *Topic) publishMessageBundle(ctx context.Context, bms []*bundledMessage)
 	}
 	end := time.Now()
 	if err != nil {
-		t.scheduler.Pause(orderingKey)
+		t.pauseOrderingKey(ctx, orderingKey, err)
 		// Update context with error tag for OpenCensus,
 		// using same stats.Record() call as success case.
 		ctx, _ = tag.New(ctx, tag.Upsert(keyStatus, "ERROR"),
@@ -761,13 +981,23 @@ func (t *Topic) publishMessageBundle(ctx context.Context, bms []*bundledMessage)
 	stats.Record(ctx,
 		PublishLatency.M(float64(end.Sub(start)/time.Millisecond)),
 		PublishedMessages.M(int64(len(bms))))
+	endSpan(rpcSpan, err)
+	if t.batcher != nil && err == nil {
+		if delay, count, changed := t.batcher.observe(end.Sub(bms[0].enqueued)); changed {
+			t.scheduler.SetThresholds(delay, count)
+		}
+	}
 	for i, bm := range bms {
 		t.flowController.release(ctx, bm.size)
 		if err != nil {
 			ipubsub.SetPublishResult(bm.res, "", err)
 		} else {
 			ipubsub.SetPublishResult(bm.res, res.MessageIds[i], nil)
+			if bm.createSpan != nil {
+				bm.createSpan.SetAttributes(attribute.String(msgIDAttr, res.MessageIds[i]))
+			}
 		}
+		endSpan(bm.createSpan, err)
 	}
 }
 
@@ -783,5 +1013,37 @@ func (t *Topic) ResumePublish(orderingKey string) {
 		return
 	}
 
-	t.scheduler.Resume(orderingKey)
+	if t.scheduler.Resume(orderingKey) {
+		ctx, err := tag.New(context.Background(), tag.Upsert(keyTopic, t.name))
+		if err != nil {
+			log.Printf("pubsub: cannot create context with tag in ResumePublish: %v", err)
+		}
+		recordStat(ctx, PublisherPausedOrderingKeys, int64(len(t.scheduler.PausedKeys())))
+	}
+}
+
+// PausedOrderingKeys returns the ordering keys for which publishing is
+// paused, in sorted order. Publishing for a key is paused when publishing a
+// message with that key fails, and stays paused until ResumePublish is called.
+func (t *Topic) PausedOrderingKeys() []string {
+	t.mu.RLock()
+	noop := t.scheduler == nil
+	t.mu.RUnlock()
+	if noop {
+		return nil
+	}
+	return t.scheduler.PausedKeys()
+}
+
+// pauseOrderingKey pauses publishing for orderingKey after err, records the
+// number of paused keys, and calls PublishSettings.OnOrderingKeyPaused if the
+// key was not already paused.
+func (t *Topic) pauseOrderingKey(ctx context.Context, orderingKey string, err error) {
+	if !t.scheduler.Pause(orderingKey) {
+		return
+	}
+	recordStat(ctx, PublisherPausedOrderingKeys, int64(len(t.scheduler.PausedKeys())))
+	if f := t.PublishSettings.OnOrderingKeyPaused; f != nil {
+		go f(orderingKey, err)
+	}
 }
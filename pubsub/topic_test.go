@@ -374,6 +374,59 @@ func mustCreateTopicWithConfig(t *testing.T, c *Client, id string, tc *TopicConf
 	return topic
 }
 
+func TestUpdateTopic_IngestionDataSourceSettings(t *testing.T) {
+	ctx := context.Background()
+	client, srv := newFake(t)
+	defer client.Close()
+	defer srv.Close()
+
+	kinesis := &IngestionDataSourceAWSKinesis{
+		StreamARN:         "fake-stream-arn",
+		ConsumerARN:       "fake-consumer-arn",
+		AWSRoleARN:        "fake-aws-role-arn",
+		GCPServiceAccount: "fake-gcp-sa",
+	}
+	topic := mustCreateTopicWithConfig(t, client, "T", &TopicConfig{
+		IngestionDataSourceSettings: &IngestionDataSourceSettings{Source: kinesis},
+	})
+	config, err := topic.Config(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := TopicConfig{
+		IngestionDataSourceSettings: &IngestionDataSourceSettings{Source: kinesis},
+	}
+	opt := cmpopts.IgnoreUnexported(TopicConfig{})
+	if !testutil.Equal(config, want, opt) {
+		t.Errorf("\ngot  %+v\nwant %+v", config, want)
+	}
+
+	// Update the stream ARN.
+	kinesis2 := *kinesis
+	kinesis2.StreamARN = "fake-stream-arn-2"
+	config2, err := topic.Update(ctx, TopicConfigToUpdate{
+		IngestionDataSourceSettings: &IngestionDataSourceSettings{Source: &kinesis2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want.IngestionDataSourceSettings = &IngestionDataSourceSettings{Source: &kinesis2}
+	if !testutil.Equal(config2, want, opt) {
+		t.Errorf("\ngot  %+v\nwant %+v", config2, want)
+	}
+
+	// Clear the ingestion settings with the zero value.
+	config3, err := topic.Update(ctx, TopicConfigToUpdate{
+		IngestionDataSourceSettings: &IngestionDataSourceSettings{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config3.IngestionDataSourceSettings != nil {
+		t.Errorf("got %+v, want nil IngestionDataSourceSettings", config3.IngestionDataSourceSettings)
+	}
+}
+
 func TestDetachSubscription(t *testing.T) {
 	ctx := context.Background()
 	c, srv := newFake(t)
@@ -526,6 +579,37 @@ func TestPublishFlowControl_SignalError(t *testing.T) {
 
 }
 
+func TestPublishAdaptiveBatching(t *testing.T) {
+	ctx := context.Background()
+	c, srv := newFake(t)
+	defer c.Close()
+	defer srv.Close()
+
+	topic, err := c.CreateTopic(ctx, "some-topic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	topic.PublishSettings.DelayThreshold = 50 * time.Millisecond
+	topic.PublishSettings.CountThreshold = 10
+	// A target no publish can meet, so every publish lowers the thresholds.
+	topic.PublishSettings.AdaptiveBatching = AdaptiveBatchingSettings{TargetLatency: time.Nanosecond}
+	defer topic.Stop()
+
+	for i := 0; i < 3; i++ {
+		if _, err := publishSingleMessage(ctx, topic, "msg").Get(ctx); err != nil {
+			t.Fatalf("publishResult.Get(): got %v", err)
+		}
+	}
+
+	topic.batcher.mu.Lock()
+	delay, count := topic.batcher.delay, topic.batcher.count
+	topic.batcher.mu.Unlock()
+	if delay >= topic.PublishSettings.DelayThreshold || count >= topic.PublishSettings.CountThreshold {
+		t.Errorf("got thresholds (%v, %d), want them lowered from (%v, %d)", delay, count,
+			topic.PublishSettings.DelayThreshold, topic.PublishSettings.CountThreshold)
+	}
+}
+
 func TestPublishFlowControl_SignalErrorOrderingKey(t *testing.T) {
 	ctx := context.Background()
 	c, srv := newFake(t)
@@ -559,6 +643,72 @@ func TestPublishFlowControl_SignalErrorOrderingKey(t *testing.T) {
 	}
 }
 
+func TestPublishPausedOrderingKeys(t *testing.T) {
+	ctx := context.Background()
+	c, srv := newFake(t)
+	defer c.Close()
+	defer srv.Close()
+
+	topic, err := c.CreateTopic(ctx, "some-topic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	topic.PublishSettings.FlowControlSettings = FlowControlSettings{
+		MaxOutstandingBytes:   10,
+		LimitExceededBehavior: FlowControlSignalError,
+	}
+	type pauseEvent struct {
+		key string
+		err error
+	}
+	paused := make(chan pauseEvent, 2)
+	topic.PublishSettings.OnOrderingKeyPaused = func(key string, err error) {
+		paused <- pauseEvent{key, err}
+	}
+	topic.EnableMessageOrdering = true
+	defer topic.Stop()
+
+	if got := topic.PausedOrderingKeys(); len(got) != 0 {
+		t.Errorf("PausedOrderingKeys() before publishing got %v, want none", got)
+	}
+
+	// A message that is too large fails and pauses its ordering key.
+	r := publishSingleMessageWithKey(ctx, topic, "AAAAAAAAAAA", "a")
+	if _, err := r.Get(ctx); err != ErrFlowControllerMaxOutstandingBytes {
+		t.Fatalf("Get() got: %v, want %v", err, ErrFlowControllerMaxOutstandingBytes)
+	}
+	select {
+	case ev := <-paused:
+		if ev.key != "a" || ev.err != ErrFlowControllerMaxOutstandingBytes {
+			t.Errorf("OnOrderingKeyPaused got (%q, %v), want (%q, %v)", ev.key, ev.err, "a", ErrFlowControllerMaxOutstandingBytes)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for OnOrderingKeyPaused")
+	}
+	if got, want := topic.PausedOrderingKeys(), []string{"a"}; !testutil.Equal(got, want) {
+		t.Errorf("PausedOrderingKeys() got %v, want %v", got, want)
+	}
+
+	// Another failure for the same key does not report it again.
+	r = publishSingleMessageWithKey(ctx, topic, "AAAAAAAAAAA", "a")
+	if _, err := r.Get(ctx); err == nil {
+		t.Fatal("Get() on paused key got nil, want error")
+	}
+	select {
+	case ev := <-paused:
+		t.Errorf("OnOrderingKeyPaused called again for already paused key %q", ev.key)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	topic.ResumePublish("a")
+	if got := topic.PausedOrderingKeys(); len(got) != 0 {
+		t.Errorf("PausedOrderingKeys() after ResumePublish got %v, want none", got)
+	}
+	if _, err := publishSingleMessageWithKey(ctx, topic, "AAAA", "a").Get(ctx); err != nil {
+		t.Errorf("Get() after ResumePublish got %v", err)
+	}
+}
+
 func TestPublishFlowControl_Block(t *testing.T) {
 	ctx := context.Background()
 	c, srv := newFake(t)
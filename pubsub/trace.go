@@ -17,11 +17,19 @@ package pubsub
 import (
 	"context"
 	"log"
+	"strings"
 	"sync"
 
+	ipubsub "cloud.google.com/go/internal/pubsub"
+	"cloud.google.com/go/pubsub/internal"
 	"go.opencensus.io/stats"
 	"go.opencensus.io/stats/view"
 	"go.opencensus.io/tag"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // The following keys are used to tag requests with a specific topic/subscription ID.
@@ -100,6 +108,18 @@ var (
 	// PublisherOutstandingBytes is a measure of the number of bytes all outstanding publish messages held by the client take up.
 	// It is EXPERIMENTAL and subject to change or removal without notice.
 	PublisherOutstandingBytes = stats.Int64(statsPrefix+"publisher_outstanding_bytes", "Number of outstanding publish bytes", stats.UnitDimensionless)
+
+	// AckLatency is a measure of the time between a message being received and it being acked or nacked, in milliseconds.
+	// It is EXPERIMENTAL and subject to change or removal without notice.
+	AckLatency = stats.Int64(statsPrefix+"ack_latency", "The latency in milliseconds between receiving and acking or nacking a message", stats.UnitMilliseconds)
+
+	// ExpiredAckCount is a measure of the number of messages acked or nacked after their lease expired.
+	// It is EXPERIMENTAL and subject to change or removal without notice.
+	ExpiredAckCount = stats.Int64(statsPrefix+"expired_ack_count", "Number of PubSub messages acked or nacked after their lease expired", stats.UnitDimensionless)
+
+	// PublisherPausedOrderingKeys is a measure of the number of ordering keys for which publishing is paused.
+	// It is EXPERIMENTAL and subject to change or removal without notice.
+	PublisherPausedOrderingKeys = stats.Int64(statsPrefix+"publisher_paused_ordering_keys", "Number of paused publish ordering keys", stats.UnitDimensionless)
 )
 
 var (
@@ -162,6 +182,18 @@ var (
 	// PublisherOutstandingBytesView is the last value of OutstandingBytes
 	// It is EXPERIMENTAL and subject to change or removal without notice.
 	PublisherOutstandingBytesView *view.View
+
+	// AckLatencyView is a distribution of AckLatency.
+	// It is EXPERIMENTAL and subject to change or removal without notice.
+	AckLatencyView *view.View
+
+	// ExpiredAckCountView is a cumulative sum of ExpiredAckCount.
+	// It is EXPERIMENTAL and subject to change or removal without notice.
+	ExpiredAckCountView *view.View
+
+	// PublisherPausedOrderingKeysView is the last value of PublisherPausedOrderingKeys
+	// It is EXPERIMENTAL and subject to change or removal without notice.
+	PublisherPausedOrderingKeysView *view.View
 )
 
 func init() {
@@ -169,6 +201,7 @@ func init() {
 	PublishLatencyView = createDistView(PublishLatency, keyTopic, keyStatus, keyError)
 	PublisherOutstandingMessagesView = createLastValueView(PublisherOutstandingMessages, keyTopic)
 	PublisherOutstandingBytesView = createLastValueView(PublisherOutstandingBytes, keyTopic)
+	PublisherPausedOrderingKeysView = createLastValueView(PublisherPausedOrderingKeys, keyTopic)
 	PullCountView = createCountView(PullCount, keySubscription)
 	AckCountView = createCountView(AckCount, keySubscription)
 	NackCountView = createCountView(NackCount, keySubscription)
@@ -180,12 +213,15 @@ func init() {
 	StreamResponseCountView = createCountView(StreamResponseCount, keySubscription)
 	OutstandingMessagesView = createLastValueView(OutstandingMessages, keySubscription)
 	OutstandingBytesView = createLastValueView(OutstandingBytes, keySubscription)
+	AckLatencyView = createDistView(AckLatency, keySubscription)
+	ExpiredAckCountView = createCountView(ExpiredAckCount, keySubscription)
 
 	DefaultPublishViews = []*view.View{
 		PublishedMessagesView,
 		PublishLatencyView,
 		PublisherOutstandingMessagesView,
 		PublisherOutstandingBytesView,
+		PublisherPausedOrderingKeysView,
 	}
 
 	DefaultSubscribeViews = []*view.View{
@@ -200,6 +236,8 @@ func init() {
 		StreamResponseCountView,
 		OutstandingMessagesView,
 		OutstandingBytesView,
+		AckLatencyView,
+		ExpiredAckCountView,
 	}
 }
 
@@ -256,3 +294,178 @@ func withSubscriptionKey(ctx context.Context, subName string) context.Context {
 func recordStat(ctx context.Context, m *stats.Int64Measure, n int64) {
 	stats.Record(ctx, m.M(n))
 }
+
+// The following are used for OpenTelemetry tracing, which is enabled with
+// ClientConfig.EnableOpenTelemetryTracing.
+const (
+	// tracerName is the name of the OpenTelemetry tracer used by this package.
+	tracerName = "cloud.google.com/go/pubsub"
+
+	// googclientPrefix is prepended to the keys of the message attributes
+	// used to propagate trace context between publishers and subscribers.
+	googclientPrefix = "googclient_"
+
+	// Span attributes, following the OpenTelemetry semantic conventions for
+	// messaging systems where applicable.
+	msgSystemAttr          = "messaging.system"
+	msgDestinationAttr     = "messaging.destination.name"
+	msgIDAttr              = "messaging.message.id"
+	msgSizeAttr            = "messaging.message.body.size"
+	msgBatchCountAttr      = "messaging.batch.message_count"
+	msgOrderingKeyAttr     = "messaging.gcp_pubsub.message.ordering_key"
+	msgDeliveryAttemptAttr = "messaging.gcp_pubsub.message.delivery_attempt"
+	msgAckIDAttr           = "messaging.gcp_pubsub.message.ack_id"
+	msgSystemValue         = "gcp_pubsub"
+)
+
+// tracer returns the tracer of this package from tp, or from the global
+// TracerProvider if tp is nil.
+func tracer(tp trace.TracerProvider) trace.Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(tracerName, trace.WithInstrumentationVersion(internal.Version))
+}
+
+// messageCarrier injects and extracts trace context using the attributes of
+// a Message. It implements propagation.TextMapCarrier.
+type messageCarrier struct {
+	msg *Message
+}
+
+var _ propagation.TextMapCarrier = messageCarrier{}
+
+func (c messageCarrier) Get(key string) string {
+	return c.msg.Attributes[googclientPrefix+key]
+}
+
+func (c messageCarrier) Set(key, value string) {
+	c.msg.Attributes[googclientPrefix+key] = value
+}
+
+func (c messageCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.msg.Attributes))
+	for k := range c.msg.Attributes {
+		if strings.HasPrefix(k, googclientPrefix) {
+			keys = append(keys, strings.TrimPrefix(k, googclientPrefix))
+		}
+	}
+	return keys
+}
+
+// injectPropagation adds the trace context of ctx to the attributes of msg.
+// The attributes are copied first so that a map shared by the caller
+// between messages is not modified.
+func injectPropagation(ctx context.Context, msg *Message) {
+	attrs := make(map[string]string, len(msg.Attributes)+2)
+	for k, v := range msg.Attributes {
+		attrs[k] = v
+	}
+	msg.Attributes = attrs
+	propagation.TraceContext{}.Inject(ctx, messageCarrier{msg})
+}
+
+// startCreateSpan starts the producer span covering the lifetime of msg in
+// the publisher, from the call to Publish until its result is ready, and
+// injects it into the message's attributes.
+func startCreateSpan(ctx context.Context, tp trace.TracerProvider, topicID string, msg *Message) (context.Context, trace.Span) {
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String(msgSystemAttr, msgSystemValue),
+			attribute.String(msgDestinationAttr, topicID),
+			attribute.Int(msgSizeAttr, len(msg.Data)),
+		),
+	}
+	if msg.OrderingKey != "" {
+		opts = append(opts, trace.WithAttributes(attribute.String(msgOrderingKeyAttr, msg.OrderingKey)))
+	}
+	ctx, span := tracer(tp).Start(ctx, topicID+" create", opts...)
+	injectPropagation(ctx, msg)
+	return ctx, span
+}
+
+// startPublishRPCSpan starts the client span for a Publish RPC sending a
+// bundle of messages, linked to the create spans of those messages.
+func startPublishRPCSpan(ctx context.Context, tp trace.TracerProvider, topicID string, bms []*bundledMessage) (context.Context, trace.Span) {
+	links := make([]trace.Link, 0, len(bms))
+	for _, bm := range bms {
+		if bm.createSpan != nil {
+			links = append(links, trace.Link{SpanContext: bm.createSpan.SpanContext()})
+		}
+	}
+	return tracer(tp).Start(ctx, topicID+" publish",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithLinks(links...),
+		trace.WithAttributes(
+			attribute.String(msgSystemAttr, msgSystemValue),
+			attribute.String(msgDestinationAttr, topicID),
+			attribute.Int(msgBatchCountAttr, len(bms)),
+		))
+}
+
+// startSubscribeSpan starts the consumer span covering the lifetime of msg in
+// the subscriber, from receipt until it is acked or nacked. The span is a
+// child of the trace context propagated in the message's attributes, if any,
+// and is ended by the message's ack handler.
+func startSubscribeSpan(ctx context.Context, tp trace.TracerProvider, subID string, msg *Message) (context.Context, trace.Span) {
+	ctx = propagation.TraceContext{}.Extract(ctx, messageCarrier{msg})
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String(msgSystemAttr, msgSystemValue),
+			attribute.String(msgDestinationAttr, subID),
+			attribute.String(msgIDAttr, msg.ID),
+			attribute.Int(msgSizeAttr, len(msg.Data)),
+		),
+	}
+	if msg.OrderingKey != "" {
+		opts = append(opts, trace.WithAttributes(attribute.String(msgOrderingKeyAttr, msg.OrderingKey)))
+	}
+	if msg.DeliveryAttempt != nil {
+		opts = append(opts, trace.WithAttributes(attribute.Int(msgDeliveryAttemptAttr, *msg.DeliveryAttempt)))
+	}
+	ctx, span := tracer(tp).Start(ctx, subID+" subscribe", opts...)
+	if ackh, ok := ipubsub.MessageAckHandler(msg).(*psAckHandler); ok {
+		span.SetAttributes(attribute.String(msgAckIDAttr, ackh.ackID))
+		ackh.span = span
+	}
+	return ctx, span
+}
+
+// startProcessSpan starts the span covering the user callback processing msg,
+// as a child of the message's subscribe span in ctx.
+func startProcessSpan(ctx context.Context, tp trace.TracerProvider, subID string) (context.Context, trace.Span) {
+	return tracer(tp).Start(ctx, subID+" process",
+		trace.WithAttributes(attribute.String(msgSystemAttr, msgSystemValue)))
+}
+
+// startAckRPCSpan starts the client span for an Acknowledge or
+// ModifyAckDeadline RPC. name is one of "ack", "nack" or "modack".
+func startAckRPCSpan(ctx context.Context, tp trace.TracerProvider, subID, name string, count int) (context.Context, trace.Span) {
+	return tracer(tp).Start(ctx, subID+" "+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String(msgSystemAttr, msgSystemValue),
+			attribute.String(msgDestinationAttr, subID),
+			attribute.Int(msgBatchCountAttr, count),
+		))
+}
+
+// endSpan records err, if any, on span and ends it. span may be nil.
+func endSpan(span trace.Span, err error) {
+	if span == nil {
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+	span.End()
+}
+
+// resourceID returns the last path component of a fully qualified topic or
+// subscription name.
+func resourceID(name string) string {
+	return name[strings.LastIndex(name, "/")+1:]
+}
@@ -0,0 +1,160 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pubsub
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub/pstest"
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestMessageCarrier(t *testing.T) {
+	shared := map[string]string{"k": "v"}
+	msg := &Message{Attributes: shared}
+	tp := sdktrace.NewTracerProvider()
+	ctx, span := tp.Tracer("test").Start(context.Background(), "span")
+	defer span.End()
+
+	injectPropagation(ctx, msg)
+	if _, ok := shared[googclientPrefix+"traceparent"]; ok {
+		t.Error("injectPropagation modified the caller's attribute map")
+	}
+	if msg.Attributes["k"] != "v" {
+		t.Errorf("attribute k: got %q, want %q", msg.Attributes["k"], "v")
+	}
+	keys := messageCarrier{msg}.Keys()
+	sort.Strings(keys)
+	if diff := cmp.Diff(keys, []string{"traceparent"}); diff != "" {
+		t.Errorf("Keys() diff: -got, +want:\n%s", diff)
+	}
+}
+
+func TestTracePropagation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer tp.Shutdown(ctx)
+	old := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	defer otel.SetTracerProvider(old)
+
+	srv := pstest.NewServer()
+	defer srv.Close()
+	client, err := NewClientWithConfig(ctx, projName, &ClientConfig{EnableOpenTelemetryTracing: true},
+		option.WithEndpoint(srv.Addr),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	topic := mustCreateTopic(t, client, "t")
+	sub, err := client.CreateSubscription(ctx, "s", SubscriptionConfig{Topic: topic})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := topic.Publish(ctx, &Message{Data: []byte("hello")}).Get(ctx); err != nil {
+		t.Fatal(err)
+	}
+	topic.Stop()
+
+	rctx, rcancel := context.WithCancel(ctx)
+	var processCtx trace.SpanContext
+	err = sub.Receive(rctx, func(ctx context.Context, m *Message) {
+		processCtx = trace.SpanContextFromContext(ctx)
+		m.Ack()
+		rcancel()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spans := map[string]tracetest.SpanStub{}
+	for _, s := range exporter.GetSpans() {
+		spans[s.Name] = s
+	}
+	for _, name := range []string{"t create", "t publish", "s subscribe", "s process", "s ack"} {
+		if _, ok := spans[name]; !ok {
+			t.Errorf("missing span %q", name)
+		}
+	}
+	create, subscribe := spans["t create"], spans["s subscribe"]
+	if got, want := subscribe.Parent.SpanID(), create.SpanContext.SpanID(); got != want {
+		t.Errorf("subscribe span parent: got %v, want create span %v", got, want)
+	}
+	if got, want := processCtx.TraceID(), create.SpanContext.TraceID(); got != want {
+		t.Errorf("process span trace ID: got %v, want %v", got, want)
+	}
+}
+
+func TestTracerProvider(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// The global TracerProvider must not be used.
+	global := tracetest.NewInMemoryExporter()
+	gtp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(global))
+	defer gtp.Shutdown(ctx)
+	old := otel.GetTracerProvider()
+	otel.SetTracerProvider(gtp)
+	defer otel.SetTracerProvider(old)
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer tp.Shutdown(ctx)
+
+	srv := pstest.NewServer()
+	defer srv.Close()
+	client, err := NewClientWithConfig(ctx, projName, &ClientConfig{TracerProvider: tp},
+		option.WithEndpoint(srv.Addr),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	topic := mustCreateTopic(t, client, "t")
+	if _, err := topic.Publish(ctx, &Message{Data: []byte("hello")}).Get(ctx); err != nil {
+		t.Fatal(err)
+	}
+	topic.Stop()
+
+	var names []string
+	for _, s := range exporter.GetSpans() {
+		names = append(names, s.Name)
+	}
+	sort.Strings(names)
+	if diff := cmp.Diff(names, []string{"t create", "t publish"}); diff != "" {
+		t.Errorf("spans diff: -got, +want:\n%s", diff)
+	}
+	if n := len(global.GetSpans()); n != 0 {
+		t.Errorf("got %d spans from the global TracerProvider, want 0", n)
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package reconcile converges the Cloud Scheduler jobs of a location to a
+// desired set of jobs, with the scheduler/apiv1 CloudSchedulerClient.
+//
+// A Reconciler compares the desired jobs with the existing ones and plans the
+// jobs to create, the fields to update and, optionally, the jobs to delete.
+// The plan can be printed as a dry run, or applied.
+//
+// Example:
+//
+//	client, err := scheduler.NewCloudSchedulerClient(ctx)
+//	if err != nil {
+//		// TODO: Handle error.
+//	}
+//	r := reconcile.New(client, "projects/my-project/locations/us-central1")
+//	r.Prune = true
+//	changes, err := r.Plan(ctx, []*schedulerpb.Job{{
+//		Name:     "nightly-report",
+//		Schedule: "0 2 * * *",
+//		TimeZone: "America/New_York",
+//		Target: &schedulerpb.Job_HttpTarget{HttpTarget: &schedulerpb.HttpTarget{
+//			Uri: "https://example.com/report",
+//		}},
+//	}})
+//	if err != nil {
+//		// TODO: Handle error.
+//	}
+//	for _, c := range changes {
+//		fmt.Println(c)
+//	}
+//	if err := r.Apply(ctx, changes); err != nil {
+//		// TODO: Handle error.
+//	}
+package reconcile // import "cloud.google.com/go/scheduler/reconcile"
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	scheduler "cloud.google.com/go/scheduler/apiv1"
+	"cloud.google.com/go/scheduler/apiv1/schedulerpb"
+	"google.golang.org/api/iterator"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// managedFields are the fields of a job that a Reconciler manages. The
+// others are either output only or the name of the job.
+var managedFields = []protoreflect.Name{
+	"description",
+	"pubsub_target",
+	"app_engine_http_target",
+	"http_target",
+	"schedule",
+	"time_zone",
+	"retry_config",
+	"attempt_deadline",
+}
+
+// Action is the kind of a change.
+type Action int
+
+const (
+	// Create creates a job.
+	Create Action = iota
+	// Update updates fields of a job.
+	Update
+	// Delete deletes a job.
+	Delete
+)
+
+func (a Action) String() string {
+	switch a {
+	case Create:
+		return "create"
+	case Update:
+		return "update"
+	case Delete:
+		return "delete"
+	}
+	return fmt.Sprintf("Action(%d)", int(a))
+}
+
+// Change is a change that brings a job to its desired state.
+type Change struct {
+	Action Action
+
+	// Name is the full name of the job.
+	Name string
+
+	// Job is the desired job, with its full name. It is nil for deletions.
+	Job *schedulerpb.Job
+
+	// Current is the existing job. It is nil for creations.
+	Current *schedulerpb.Job
+
+	// UpdateMask lists the fields updated by an update.
+	UpdateMask []string
+}
+
+// String describes the change, as in
+// "update projects/p/locations/l/jobs/j (schedule, retry_config)".
+func (c Change) String() string {
+	s := c.Action.String() + " " + c.Name
+	if len(c.UpdateMask) > 0 {
+		s += " (" + strings.Join(c.UpdateMask, ", ") + ")"
+	}
+	return s
+}
+
+// A Reconciler converges the jobs of a location to a desired set.
+type Reconciler struct {
+	client *scheduler.CloudSchedulerClient
+	parent string
+
+	// Prune makes Plan delete the existing jobs of the location that are not
+	// desired. Without it, those jobs are left alone.
+	Prune bool
+}
+
+// New returns a Reconciler for the jobs of the location with the given full
+// name, of the form "projects/PROJECT_ID/locations/LOCATION_ID".
+func New(client *scheduler.CloudSchedulerClient, parent string) *Reconciler {
+	return &Reconciler{client: client, parent: parent}
+}
+
+// Plan returns the changes that bring the jobs of the location to the desired
+// ones, ordered by action, then by name. It does not change anything.
+//
+// The name of a desired job is either its full name or its ID. Only the
+// fields set in a desired job are compared and updated, so fields defaulted
+// by Cloud Scheduler, such as the retry configuration of a job without one,
+// do not cause updates; within a message field, such as the target, the
+// existing job may likewise have more fields or header entries set.
+func (r *Reconciler) Plan(ctx context.Context, desired []*schedulerpb.Job) ([]Change, error) {
+	want := map[string]*schedulerpb.Job{}
+	for _, j := range desired {
+		name := r.jobName(j.Name)
+		if name == "" {
+			return nil, fmt.Errorf("reconcile: job without a name in %s: %v", r.parent, j)
+		}
+		if _, ok := want[name]; ok {
+			return nil, fmt.Errorf("reconcile: duplicate job %s", name)
+		}
+		j = proto.Clone(j).(*schedulerpb.Job)
+		j.Name = name
+		want[name] = j
+	}
+
+	var changes []Change
+	it := r.client.ListJobs(ctx, &schedulerpb.ListJobsRequest{Parent: r.parent})
+	for {
+		cur, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		j, ok := want[cur.Name]
+		if !ok {
+			if r.Prune {
+				changes = append(changes, Change{Action: Delete, Name: cur.Name, Current: cur})
+			}
+			continue
+		}
+		delete(want, cur.Name)
+		if mask := diff(j, cur); len(mask) > 0 {
+			changes = append(changes, Change{Action: Update, Name: cur.Name, Job: j, Current: cur, UpdateMask: mask})
+		}
+	}
+	for name, j := range want {
+		changes = append(changes, Change{Action: Create, Name: name, Job: j})
+	}
+	sort.Slice(changes, func(i, k int) bool {
+		if changes[i].Action != changes[k].Action {
+			return changes[i].Action < changes[k].Action
+		}
+		return changes[i].Name < changes[k].Name
+	})
+	return changes, nil
+}
+
+// Apply applies changes, in order. It stops at the first change that fails.
+func (r *Reconciler) Apply(ctx context.Context, changes []Change) error {
+	for _, c := range changes {
+		var err error
+		switch c.Action {
+		case Create:
+			_, err = r.client.CreateJob(ctx, &schedulerpb.CreateJobRequest{Parent: r.parent, Job: c.Job})
+		case Update:
+			_, err = r.client.UpdateJob(ctx, &schedulerpb.UpdateJobRequest{
+				Job:        c.Job,
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: c.UpdateMask},
+			})
+		case Delete:
+			err = r.client.DeleteJob(ctx, &schedulerpb.DeleteJobRequest{Name: c.Name})
+		default:
+			err = fmt.Errorf("unknown action %v", c.Action)
+		}
+		if err != nil {
+			return fmt.Errorf("reconcile: %v: %w", c, err)
+		}
+	}
+	return nil
+}
+
+// Reconcile plans the changes that bring the jobs of the location to the
+// desired ones and, unless dryRun is true, applies them. It returns the
+// planned changes.
+func (r *Reconciler) Reconcile(ctx context.Context, desired []*schedulerpb.Job, dryRun bool) ([]Change, error) {
+	changes, err := r.Plan(ctx, desired)
+	if err != nil || dryRun {
+		return changes, err
+	}
+	return changes, r.Apply(ctx, changes)
+}
+
+// jobName returns the full name of a job given its full name or ID.
+func (r *Reconciler) jobName(name string) string {
+	if name == "" || strings.Contains(name, "/") {
+		return name
+	}
+	return r.parent + "/jobs/" + name
+}
+
+// diff returns the managed fields set in want that current lacks.
+func diff(want, current *schedulerpb.Job) []string {
+	wm, cm := want.ProtoReflect(), current.ProtoReflect()
+	fields := wm.Descriptor().Fields()
+	var mask []string
+	for _, name := range managedFields {
+		fd := fields.ByName(name)
+		if !wm.Has(fd) {
+			continue
+		}
+		if !cm.Has(fd) || !contains(cm.Get(fd), wm.Get(fd), fd) {
+			mask = append(mask, string(name))
+		}
+	}
+	return mask
+}
+
+// contains reports whether the value c of field fd contains the value w: it
+// is equal, or, for messages and maps, has at least the fields and entries set
+// in w, with values containing theirs.
+func contains(c, w protoreflect.Value, fd protoreflect.FieldDescriptor) bool {
+	switch {
+	case fd.IsMap():
+		cmap, wmap := c.Map(), w.Map()
+		ok := true
+		wmap.Range(func(k protoreflect.MapKey, wv protoreflect.Value) bool {
+			ok = cmap.Has(k) && contains(cmap.Get(k), wv, fd.MapValue())
+			return ok
+		})
+		return ok
+	case fd.IsList():
+		cl, wl := c.List(), w.List()
+		if cl.Len() != wl.Len() {
+			return false
+		}
+		for i := 0; i < wl.Len(); i++ {
+			if !scalarOrMessageEqual(cl.Get(i), wl.Get(i), fd) {
+				return false
+			}
+		}
+		return true
+	case fd.Message() != nil:
+		cm, wm := c.Message(), w.Message()
+		ok := true
+		wm.Range(func(fd protoreflect.FieldDescriptor, wv protoreflect.Value) bool {
+			ok = cm.Has(fd) && contains(cm.Get(fd), wv, fd)
+			return ok
+		})
+		return ok
+	}
+	return scalarOrMessageEqual(c, w, fd)
+}
+
+func scalarOrMessageEqual(a, b protoreflect.Value, fd protoreflect.FieldDescriptor) bool {
+	switch {
+	case fd.Message() != nil:
+		return proto.Equal(a.Message().Interface(), b.Message().Interface())
+	case fd.Kind() == protoreflect.BytesKind:
+		return bytes.Equal(a.Bytes(), b.Bytes())
+	}
+	return a.Interface() == b.Interface()
+}
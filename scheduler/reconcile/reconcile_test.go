@@ -0,0 +1,210 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	scheduler "cloud.google.com/go/scheduler/apiv1"
+	"cloud.google.com/go/scheduler/apiv1/schedulerpb"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const testParent = "projects/p/locations/l"
+
+type fakeScheduler struct {
+	schedulerpb.UnimplementedCloudSchedulerServer
+
+	mu   sync.Mutex
+	jobs map[string]*schedulerpb.Job
+	log  []string
+}
+
+func (s *fakeScheduler) ListJobs(_ context.Context, req *schedulerpb.ListJobsRequest) (*schedulerpb.ListJobsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resp := &schedulerpb.ListJobsResponse{}
+	for _, j := range s.jobs {
+		if strings.HasPrefix(j.Name, req.Parent+"/jobs/") {
+			resp.Jobs = append(resp.Jobs, j)
+		}
+	}
+	sort.Slice(resp.Jobs, func(i, k int) bool { return resp.Jobs[i].Name < resp.Jobs[k].Name })
+	return resp, nil
+}
+
+func (s *fakeScheduler) CreateJob(_ context.Context, req *schedulerpb.CreateJobRequest) (*schedulerpb.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.log = append(s.log, "create "+req.Job.Name)
+	s.jobs[req.Job.Name] = req.Job
+	return req.Job, nil
+}
+
+func (s *fakeScheduler) UpdateJob(_ context.Context, req *schedulerpb.UpdateJobRequest) (*schedulerpb.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[req.Job.Name]
+	if !ok {
+		return nil, status.Error(codes.NotFound, req.Job.Name)
+	}
+	s.log = append(s.log, fmt.Sprintf("update %s %v", req.Job.Name, req.UpdateMask.Paths))
+	jm, wm := j.ProtoReflect(), req.Job.ProtoReflect()
+	for _, p := range req.UpdateMask.Paths {
+		fd := jm.Descriptor().Fields().ByName(protoreflect.Name(p))
+		jm.Set(fd, wm.Get(fd))
+	}
+	return j, nil
+}
+
+func (s *fakeScheduler) DeleteJob(_ context.Context, req *schedulerpb.DeleteJobRequest) (*emptypb.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.log = append(s.log, "delete "+req.Name)
+	delete(s.jobs, req.Name)
+	return &emptypb.Empty{}, nil
+}
+
+func newTestReconciler(t *testing.T, jobs ...*schedulerpb.Job) (*Reconciler, *fakeScheduler) {
+	t.Helper()
+	fs := &fakeScheduler{jobs: map[string]*schedulerpb.Job{}}
+	for _, j := range jobs {
+		fs.jobs[j.Name] = j
+	}
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gsrv := grpc.NewServer()
+	schedulerpb.RegisterCloudSchedulerServer(gsrv, fs)
+	go gsrv.Serve(l)
+	t.Cleanup(gsrv.Stop)
+	client, err := scheduler.NewCloudSchedulerClient(context.Background(),
+		option.WithEndpoint(l.Addr().String()),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return New(client, testParent), fs
+}
+
+func httpJob(name, schedule, uri string) *schedulerpb.Job {
+	return &schedulerpb.Job{
+		Name:     name,
+		Schedule: schedule,
+		Target: &schedulerpb.Job_HttpTarget{HttpTarget: &schedulerpb.HttpTarget{
+			Uri:        uri,
+			HttpMethod: schedulerpb.HttpMethod_POST,
+		}},
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	ctx := context.Background()
+	// The server added a header and defaulted the retry configuration and
+	// attempt deadline of the existing jobs.
+	same := httpJob(testParent+"/jobs/same", "* * * * *", "https://a")
+	same.GetHttpTarget().Headers = map[string]string{"User-Agent": "Google-Cloud-Scheduler"}
+	same.RetryConfig = &schedulerpb.RetryConfig{MaxDoublings: 5}
+	same.AttemptDeadline = durationpb.New(3 * time.Minute)
+	changed := httpJob(testParent+"/jobs/changed", "* * * * *", "https://b")
+	changed.TimeZone = "Etc/UTC"
+	stale := httpJob(testParent+"/jobs/stale", "* * * * *", "https://c")
+	r, fs := newTestReconciler(t, proto.Clone(same).(*schedulerpb.Job), changed, stale)
+	r.Prune = true
+
+	desired := []*schedulerpb.Job{
+		httpJob("same", "* * * * *", "https://a"),
+		httpJob("changed", "0 * * * *", "https://b2"),
+		httpJob(testParent+"/jobs/new", "0 0 * * *", "https://d"),
+	}
+	changes, err := r.Reconcile(ctx, desired, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.String())
+	}
+	want := []string{
+		"create " + testParent + "/jobs/new",
+		"update " + testParent + "/jobs/changed (http_target, schedule)",
+		"delete " + testParent + "/jobs/stale",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got changes\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if len(fs.log) != 0 {
+		t.Fatalf("dry run changed jobs: %v", fs.log)
+	}
+
+	if _, err := r.Reconcile(ctx, desired, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(fs.log) != 3 {
+		t.Errorf("got calls %v, want 3", fs.log)
+	}
+	if j := fs.jobs[testParent+"/jobs/changed"]; j.Schedule != "0 * * * *" || j.GetHttpTarget().Uri != "https://b2" || j.TimeZone != "Etc/UTC" {
+		t.Errorf("got updated job %v", j)
+	}
+	// The jobs have converged.
+	changes, err = r.Plan(ctx, desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("got changes %v after applying them", changes)
+	}
+}
+
+func TestPlanNoPrune(t *testing.T) {
+	r, _ := newTestReconciler(t, httpJob(testParent+"/jobs/other", "* * * * *", "https://a"))
+	changes, err := r.Plan(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("got changes %v, want none", changes)
+	}
+}
+
+func TestPlanErrors(t *testing.T) {
+	r, _ := newTestReconciler(t)
+	for _, desired := range [][]*schedulerpb.Job{
+		{{}},
+		{httpJob("a", "* * * * *", "https://a"), httpJob(testParent+"/jobs/a", "* * * * *", "https://a")},
+	} {
+		if _, err := r.Plan(context.Background(), desired); err == nil {
+			t.Errorf("%v: got no error", desired)
+		}
+	}
+}
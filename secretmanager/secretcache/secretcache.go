@@ -0,0 +1,279 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package secretcache provides a cache of Secret Manager secret payloads.
+//
+// A Cache accesses secret versions with a Secret Manager client and keeps
+// their payloads for a time to live, so that programs can read secrets where
+// they need them without a request to Secret Manager each time. Secrets are
+// named by their resource name, with or without a version: a secret without a
+// version resolves to its latest version, whose rotation the cache detects
+// when it refreshes the secret, in the background if configured so.
+//
+// Example:
+//
+//	client, err := secretmanager.NewClient(ctx)
+//	if err != nil {
+//		// TODO: Handle error.
+//	}
+//	cache := secretcache.New(client, secretcache.Config{
+//		RefreshInterval: time.Minute,
+//		OnRotate: func(v *secretcache.Version) {
+//			log.Printf("secret rotated to %s", v.Name)
+//		},
+//	})
+//	defer cache.Close()
+//	password, err := cache.Get(ctx, "projects/my-project/secrets/db-password")
+package secretcache // import "cloud.google.com/go/secretmanager/secretcache"
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	gax "github.com/googleapis/gax-go/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultTTL is the default time to live of the cached payloads.
+const DefaultTTL = 5 * time.Minute
+
+// Client is the subset of the methods of secretmanager.Client used by Cache.
+type Client interface {
+	AccessSecretVersion(context.Context, *secretmanagerpb.AccessSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error)
+}
+
+// Config is the configuration of a Cache.
+type Config struct {
+	// TTL is the time after which a cached payload is accessed again when it
+	// is requested. It defaults to DefaultTTL.
+	TTL time.Duration
+
+	// RefreshInterval, if positive, makes the cache access all its secrets
+	// again at this interval in the background, so that Get rarely blocks and
+	// OnRotate is called soon after a rotation.
+	RefreshInterval time.Duration
+
+	// OnRotate, if not nil, is called when a cached secret is accessed again
+	// and its version or payload changed, with the new version. It is called
+	// from the goroutine that accessed the secret, which is a background
+	// goroutine if RefreshInterval is set.
+	OnRotate func(v *Version)
+
+	// OnError is called when refreshing a secret in the background fails. By
+	// default, errors are logged.
+	OnError func(err error)
+}
+
+// A Version is an accessed secret version.
+type Version struct {
+	// Secret is the name of the secret or secret version as requested, such
+	// as "projects/my-project/secrets/my-secret" for the latest version.
+	Secret string
+
+	// Name is the resource name of the version, with its number, such as
+	// "projects/123/secrets/my-secret/versions/4".
+	Name string
+
+	// Data is the payload of the version. It must not be modified.
+	Data []byte
+}
+
+// A Cache is a cache of secret payloads. It is safe for concurrent use.
+type Cache struct {
+	client Client
+	cfg    Config
+
+	mu      sync.Mutex
+	entries map[string]*entry
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+type entry struct {
+	version *Version
+	fetched time.Time
+}
+
+// For testing.
+var now = time.Now
+
+// New returns a cache that accesses secrets with client.
+func New(client Client, cfg Config) *Cache {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultTTL
+	}
+	c := &Cache{
+		client:  client,
+		cfg:     cfg,
+		entries: map[string]*entry{},
+		done:    make(chan struct{}),
+	}
+	if cfg.RefreshInterval > 0 {
+		c.wg.Add(1)
+		go c.refreshLoop()
+	}
+	return c
+}
+
+// Get returns the payload of the secret with the given name. The name is the
+// resource name of a secret, "projects/*/secrets/*", for its latest version,
+// or of a secret version, "projects/*/secrets/*/versions/*".
+func (c *Cache) Get(ctx context.Context, name string) ([]byte, error) {
+	v, err := c.Version(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return v.Data, nil
+}
+
+// Version is like Get, but returns the accessed version.
+func (c *Cache) Version(ctx context.Context, name string) (*Version, error) {
+	name = versionName(name)
+	c.mu.Lock()
+	e, ok := c.entries[name]
+	c.mu.Unlock()
+	if ok && now().Sub(e.fetched) < c.cfg.TTL {
+		return e.version, nil
+	}
+	return c.refresh(ctx, name, false)
+}
+
+// Invalidate removes the secret with the given name from the cache, so that
+// the next Get accesses it again.
+func (c *Cache) Invalidate(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, versionName(name))
+}
+
+// Close stops the background refresh of the secrets, and waits for a refresh
+// in progress, if any, to complete. It may be called more than once, even
+// concurrently.
+func (c *Cache) Close() {
+	c.closeOnce.Do(func() { close(c.done) })
+	c.wg.Wait()
+}
+
+// refresh accesses the secret version name and caches it. If onlyCached is
+// true, the version is not cached if it was invalidated meanwhile.
+func (c *Cache) refresh(ctx context.Context, name string, onlyCached bool) (*Version, error) {
+	v, err := c.access(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	old, ok := c.entries[name]
+	if !ok && onlyCached {
+		c.mu.Unlock()
+		return v, nil
+	}
+	c.entries[name] = &entry{version: v, fetched: now()}
+	c.mu.Unlock()
+	if ok && c.cfg.OnRotate != nil && (old.version.Name != v.Name || !bytes.Equal(old.version.Data, v.Data)) {
+		c.cfg.OnRotate(v)
+	}
+	return v, nil
+}
+
+func (c *Cache) access(ctx context.Context, name string) (*Version, error) {
+	res, err := c.client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{Name: name})
+	if err != nil {
+		return nil, err
+	}
+	p := res.GetPayload()
+	if p.DataCrc32C != nil && p.GetDataCrc32C() != int64(crc32.Checksum(p.GetData(), crc32cTable)) {
+		return nil, fmt.Errorf("secretcache: payload of %s corrupted: checksum mismatch", res.Name)
+	}
+	return &Version{
+		Secret: strings.TrimSuffix(name, latestSuffix),
+		Name:   res.Name,
+		Data:   p.GetData(),
+	}, nil
+}
+
+func (c *Cache) refreshLoop() {
+	defer c.wg.Done()
+	t := time.NewTicker(c.cfg.RefreshInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-t.C:
+			c.refreshAll()
+		}
+	}
+}
+
+// refreshAll accesses all the cached secrets again.
+func (c *Cache) refreshAll() {
+	c.mu.Lock()
+	names := make([]string, 0, len(c.entries))
+	for name := range c.entries {
+		names = append(names, name)
+	}
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-c.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	for _, name := range names {
+		if _, err := c.refresh(ctx, name, true); err != nil {
+			// Client methods report the cancellation by Close as a
+			// Canceled status.
+			if status.Code(err) == codes.Canceled || errors.Is(err, context.Canceled) {
+				return
+			}
+			c.onError(fmt.Errorf("secretcache: refreshing %s: %w", name, err))
+		}
+	}
+}
+
+func (c *Cache) onError(err error) {
+	if c.cfg.OnError != nil {
+		c.cfg.OnError(err)
+		return
+	}
+	log.Println(err)
+}
+
+const latestSuffix = "/versions/latest"
+
+// versionName returns the name of the secret version with the given name,
+// which may be the name of a secret.
+func versionName(name string) string {
+	if strings.Contains(name, "/versions/") {
+		return name
+	}
+	return name + latestSuffix
+}
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
@@ -0,0 +1,236 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secretcache
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	gax "github.com/googleapis/gax-go/v2"
+	"google.golang.org/grpc/status"
+)
+
+const secretName = "projects/p/secrets/s"
+
+// fakeClient serves the latest version of a single secret.
+type fakeClient struct {
+	mu      sync.Mutex
+	version int
+	data    string
+	badCRC  bool
+	calls   int
+
+	// blocked, if not nil, receives the accesses that then block until their
+	// context is done, and fail like gRPC calls do.
+	blocked chan<- struct{}
+}
+
+func (c *fakeClient) AccessSecretVersion(ctx context.Context, req *secretmanagerpb.AccessSecretVersionRequest, _ ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error) {
+	c.mu.Lock()
+	if blocked := c.blocked; blocked != nil {
+		c.mu.Unlock()
+		blocked <- struct{}{}
+		<-ctx.Done()
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+	defer c.mu.Unlock()
+	c.calls++
+	if req.Name != secretName+"/versions/latest" {
+		return nil, fmt.Errorf("unknown secret version %s", req.Name)
+	}
+	crc := int64(crc32.Checksum([]byte(c.data), crc32cTable))
+	if c.badCRC {
+		crc++
+	}
+	return &secretmanagerpb.AccessSecretVersionResponse{
+		Name:    fmt.Sprintf("%s/versions/%d", secretName, c.version),
+		Payload: &secretmanagerpb.SecretPayload{Data: []byte(c.data), DataCrc32C: &crc},
+	}, nil
+}
+
+func (c *fakeClient) rotate(data string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version++
+	c.data = data
+}
+
+func TestGetTTL(t *testing.T) {
+	ctx := context.Background()
+	t0 := time.Now()
+	clock := t0
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	fc := &fakeClient{version: 1, data: "one"}
+	var rotated []*Version
+	c := New(fc, Config{TTL: time.Minute, OnRotate: func(v *Version) { rotated = append(rotated, v) }})
+	defer c.Close()
+
+	get := func(want string) {
+		t.Helper()
+		got, err := c.Get(ctx, secretName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+	get("one")
+	fc.rotate("two")
+	// The payload is cached until the TTL expires.
+	get("one")
+	if fc.calls != 1 {
+		t.Errorf("got %d calls, want 1", fc.calls)
+	}
+	clock = t0.Add(time.Minute)
+	get("two")
+	if len(rotated) != 1 || rotated[0].Name != secretName+"/versions/2" || rotated[0].Secret != secretName {
+		t.Errorf("got rotations %+v, want one to version 2", rotated)
+	}
+
+	c.Invalidate(secretName)
+	get("two")
+	if fc.calls != 3 {
+		t.Errorf("got %d calls, want 3", fc.calls)
+	}
+}
+
+func TestGetChecksum(t *testing.T) {
+	fc := &fakeClient{version: 1, data: "one", badCRC: true}
+	c := New(fc, Config{})
+	defer c.Close()
+	if _, err := c.Get(context.Background(), secretName); err == nil {
+		t.Error("got no error for a corrupted payload")
+	}
+}
+
+func TestBackgroundRefresh(t *testing.T) {
+	fc := &fakeClient{version: 1, data: "one"}
+	rotated := make(chan *Version, 1)
+	c := New(fc, Config{
+		RefreshInterval: 10 * time.Millisecond,
+		OnRotate:        func(v *Version) { rotated <- v },
+	})
+	defer c.Close()
+	if _, err := c.Get(context.Background(), secretName); err != nil {
+		t.Fatal(err)
+	}
+	fc.rotate("two")
+	select {
+	case v := <-rotated:
+		if string(v.Data) != "two" {
+			t.Errorf("got %q, want %q", v.Data, "two")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the rotation")
+	}
+	got, err := c.Get(context.Background(), secretName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "two" {
+		t.Errorf("got %q, want %q", got, "two")
+	}
+}
+
+func TestBackgroundRefreshError(t *testing.T) {
+	fc := &fakeClient{version: 1, data: "one"}
+	errc := make(chan error, 1)
+	c := New(fc, Config{
+		RefreshInterval: 10 * time.Millisecond,
+		OnError: func(err error) {
+			select {
+			case errc <- err:
+			default:
+			}
+		},
+	})
+	defer c.Close()
+	if _, err := c.Get(context.Background(), secretName); err != nil {
+		t.Fatal(err)
+	}
+	fc.mu.Lock()
+	fc.badCRC = true
+	fc.mu.Unlock()
+	select {
+	case err := <-errc:
+		if err == nil {
+			t.Error("got nil error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the error")
+	}
+}
+
+func TestCloseDuringRefresh(t *testing.T) {
+	fc := &fakeClient{version: 1, data: "one"}
+	var mu sync.Mutex
+	var errs []error
+	c := New(fc, Config{
+		RefreshInterval: 10 * time.Millisecond,
+		OnError: func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		},
+	})
+	if _, err := c.Get(context.Background(), secretName); err != nil {
+		t.Fatal(err)
+	}
+	blocked := make(chan struct{}, 1)
+	fc.mu.Lock()
+	fc.blocked = blocked
+	fc.mu.Unlock()
+	select {
+	case <-blocked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a refresh")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Close()
+		}()
+	}
+	wg.Wait()
+	c.Close()
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) > 0 {
+		t.Errorf("got errors %v, want none for refreshes canceled by Close", errs)
+	}
+}
+
+func TestVersionName(t *testing.T) {
+	for _, tt := range []struct{ in, want string }{
+		{secretName, secretName + "/versions/latest"},
+		{secretName + "/versions/3", secretName + "/versions/3"},
+		{secretName + "/versions/latest", secretName + "/versions/latest"},
+	} {
+		if got := versionName(tt.in); got != tt.want {
+			t.Errorf("versionName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
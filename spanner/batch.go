@@ -71,6 +71,17 @@ type Partition struct {
 	rreq *sppb.ReadRequest
 }
 
+// DataBoostEnabled reports whether the partition will be executed with Spanner
+// Data Boost independent compute resources. Data Boost is enabled by setting
+// DataBoostEnabled in the QueryOptions or ReadOptions that are passed to
+// PartitionQueryWithOptions or PartitionReadWithOptions.
+func (p *Partition) DataBoostEnabled() bool {
+	if p.rreq != nil {
+		return p.rreq.DataBoostEnabled
+	}
+	return p.qreq.GetDataBoostEnabled()
+}
+
 // PartitionOptions specifies options for a PartitionQueryRequest and
 // PartitionReadRequest. See
 // https://godoc.org/google.golang.org/genproto/googleapis/spanner/v1#PartitionOptions
@@ -23,6 +23,7 @@ import (
 	"time"
 
 	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"google.golang.org/grpc/codes"
 
 	. "cloud.google.com/go/spanner/internal/testutil"
 )
@@ -242,3 +243,68 @@ func TestPartitionQuery_Parallel(t *testing.T) {
 		t.Errorf("Row count mismatch\nGot: %d\nWant: %d", g, w)
 	}
 }
+
+func TestPartition_DataBoost(t *testing.T) {
+	ctx := context.Background()
+	server, client, teardown := setupMockedTestServer(t)
+	defer teardown()
+
+	txn, err := client.BatchReadOnlyTransaction(ctx, StrongRead())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer txn.Cleanup(ctx)
+
+	qps, err := txn.PartitionQueryWithOptions(ctx, NewStatement(SelectSingerIDAlbumIDAlbumTitleFromAlbums), PartitionOptions{0, 3}, QueryOptions{DataBoostEnabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rps, err := txn.PartitionReadWithOptions(ctx, "Albums", KeySets(Key{"foo"}), []string{"SingerId", "AlbumId", "AlbumTitle"}, PartitionOptions{0, 3}, ReadOptions{DataBoostEnabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range append(qps, rps...) {
+		if !p.DataBoostEnabled() {
+			t.Fatal("Data Boost not enabled for partition")
+		}
+	}
+
+	server.TestSpanner.PutPartitionResult(qps[0].pt, server.CreateSingleRowSingersResult(0))
+	drainRequestsFromServer(server.TestSpanner)
+	iter := txn.Execute(ctx, qps[0])
+	if err := iter.Do(func(row *Row) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	var executed bool
+	for _, req := range drainRequestsFromServer(server.TestSpanner) {
+		if sqlReq, ok := req.(*sppb.ExecuteSqlRequest); ok {
+			executed = true
+			if !sqlReq.DataBoostEnabled {
+				t.Fatal("Data Boost not enabled for partition request")
+			}
+		}
+	}
+	if !executed {
+		t.Fatal("Missing ExecuteSqlRequest")
+	}
+}
+
+func TestDataBoost_NotPartitioned(t *testing.T) {
+	ctx := context.Background()
+	_, client, teardown := setupMockedTestServer(t)
+	defer teardown()
+
+	iter := client.Single().QueryWithOptions(ctx, NewStatement(SelectSingerIDAlbumIDAlbumTitleFromAlbums), QueryOptions{DataBoostEnabled: true})
+	_, err := iter.Next()
+	iter.Stop()
+	if g, w := ErrCode(err), codes.InvalidArgument; g != w {
+		t.Fatalf("Query error code mismatch\n Got: %v\nWant: %v", g, w)
+	}
+
+	iter = client.Single().ReadWithOptions(ctx, "Albums", AllKeys(), []string{"SingerId"}, &ReadOptions{DataBoostEnabled: true})
+	_, err = iter.Next()
+	iter.Stop()
+	if g, w := ErrCode(err), codes.InvalidArgument; g != w {
+		t.Fatalf("Read error code mismatch\n Got: %v\nWant: %v", g, w)
+	}
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package changestreams provides a reader for Cloud Spanner change streams.
+
+A change stream is read by querying its partitions. Each partition query
+returns data change records, heartbeats and, when the partition is split or
+merged, the tokens of its child partitions, which must in turn be queried.
+A Reader takes care of all of this: it starts with the initial query, follows
+child partitions once all of their parents have been read, tracks a low
+watermark across the partitions that are still being read, and hands every
+data change record to a callback.
+
+	client, err := spanner.NewClient(ctx, "projects/P/instances/I/databases/D")
+	if err != nil {
+		// TODO: Handle error.
+	}
+	reader, err := changestreams.NewReaderWithConfig(client, "SingersStream", changestreams.Config{
+		StartTimestamp: time.Now().Add(-time.Hour),
+	})
+	if err != nil {
+		// TODO: Handle error.
+	}
+	err = reader.Read(ctx, func(partitionToken string, record *changestreams.DataChangeRecord) error {
+		fmt.Println(record.TableName, record.ModType, record.CommitTimestamp)
+		return nil
+	})
+	if err != nil {
+		// TODO: Handle error.
+	}
+
+See https://cloud.google.com/spanner/docs/change-streams/details for details
+on the records returned by a change stream.
+
+This package is EXPERIMENTAL and subject to change or removal without notice.
+*/
+package changestreams // import "cloud.google.com/go/spanner/changestreams"
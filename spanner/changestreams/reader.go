@@ -0,0 +1,326 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package changestreams
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+)
+
+// DefaultHeartbeatInterval is the heartbeat interval used when
+// Config.HeartbeatInterval is not set.
+const DefaultHeartbeatInterval = 10 * time.Second
+
+var validStreamName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// Config is the configuration for a Reader.
+type Config struct {
+	// StartTimestamp is the commit timestamp to start reading changes from.
+	// It must be within the retention period of the change stream. If zero,
+	// Read starts at the time it is called.
+	StartTimestamp time.Time
+
+	// EndTimestamp is the commit timestamp, inclusive, up to which changes
+	// are read. If zero, Read runs until its context is done or an error
+	// occurs.
+	EndTimestamp time.Time
+
+	// HeartbeatInterval is how often Spanner returns a heartbeat record for a
+	// partition without changes. Heartbeats advance the watermark of idle
+	// partitions. It must be between 1 second and 5 minutes, and defaults to
+	// DefaultHeartbeatInterval.
+	HeartbeatInterval time.Duration
+
+	// Priority is the RPC priority to use for the partition queries.
+	Priority sppb.RequestOptions_Priority
+}
+
+// RecordFunc is called for each data change record read by a Reader, along
+// with the token of the partition the record was read from. Returning an
+// error stops the Reader.
+type RecordFunc func(partitionToken string, record *DataChangeRecord) error
+
+type partitionState int
+
+const (
+	partitionPending partitionState = iota
+	partitionRunning
+	partitionFinished
+)
+
+// partition is a change stream partition known to a Reader.
+type partition struct {
+	token     string // empty for the initial query
+	parents   []string
+	start     time.Time
+	watermark time.Time
+	state     partitionState
+}
+
+// Reader reads the changes of a change stream, following partition splits
+// and merges.
+type Reader struct {
+	client     *spanner.Client
+	streamName string
+	config     Config
+
+	// readPartitionFunc queries a partition and calls f for each change
+	// record. It can be replaced for testing.
+	readPartitionFunc func(ctx context.Context, p *partition, f func(*ChangeRecord) error) error
+
+	mu         sync.Mutex
+	reading    bool
+	partitions map[string]*partition // by token
+}
+
+// NewReader creates a Reader for the change stream streamName with the
+// default configuration.
+func NewReader(client *spanner.Client, streamName string) (*Reader, error) {
+	return NewReaderWithConfig(client, streamName, Config{})
+}
+
+// NewReaderWithConfig creates a Reader for the change stream streamName.
+func NewReaderWithConfig(client *spanner.Client, streamName string, config Config) (*Reader, error) {
+	if !validStreamName.MatchString(streamName) {
+		return nil, fmt.Errorf("changestreams: invalid change stream name %q", streamName)
+	}
+	if config.HeartbeatInterval == 0 {
+		config.HeartbeatInterval = DefaultHeartbeatInterval
+	}
+	if config.HeartbeatInterval < time.Second || config.HeartbeatInterval > 5*time.Minute {
+		return nil, fmt.Errorf("changestreams: heartbeat interval %v must be between 1s and 5m", config.HeartbeatInterval)
+	}
+	if !config.EndTimestamp.IsZero() && config.EndTimestamp.Before(config.StartTimestamp) {
+		return nil, errors.New("changestreams: end timestamp is before start timestamp")
+	}
+	r := &Reader{
+		client:     client,
+		streamName: streamName,
+		config:     config,
+	}
+	r.readPartitionFunc = r.readPartition
+	return r, nil
+}
+
+// Read reads the change stream and calls f for every data change record.
+//
+// Partitions are read concurrently, so f may be called from multiple
+// goroutines at the same time. Records of a single partition are passed to f
+// one at a time in commit timestamp order, and the records of a partition are
+// passed only after all the records of its parent partitions.
+//
+// Read returns nil once every partition has been read up to
+// Config.EndTimestamp. Otherwise, it returns the first error returned by f
+// or a partition query, or the context's error. Only one Read call may be in
+// progress at a time.
+func (r *Reader) Read(ctx context.Context, f RecordFunc) error {
+	start := r.config.StartTimestamp
+	if start.IsZero() {
+		start = time.Now()
+	}
+	r.mu.Lock()
+	if r.reading {
+		r.mu.Unlock()
+		return errors.New("changestreams: Read already in progress")
+	}
+	r.reading = true
+	initial := &partition{start: start, watermark: start}
+	r.partitions = map[string]*partition{"": initial}
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		r.reading = false
+		r.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		p   *partition
+		err error
+	}
+	results := make(chan result)
+	active := 0
+	run := func(p *partition) {
+		active++
+		go func() {
+			results <- result{p, r.readPartitionFunc(ctx, p, func(cr *ChangeRecord) error {
+				return r.handleChangeRecord(p, cr, f)
+			})}
+		}()
+	}
+
+	r.mu.Lock()
+	initial.state = partitionRunning
+	r.mu.Unlock()
+	run(initial)
+
+	var err error
+	for active > 0 {
+		res := <-results
+		active--
+		if err != nil {
+			continue
+		}
+		if res.err != nil {
+			err = res.err
+			cancel()
+			continue
+		}
+		r.mu.Lock()
+		res.p.state = partitionFinished
+		ready := r.readyPartitionsLocked()
+		for _, p := range ready {
+			p.state = partitionRunning
+		}
+		r.mu.Unlock()
+		for _, p := range ready {
+			run(p)
+		}
+	}
+	return err
+}
+
+// Watermark returns a timestamp before which all data change records have
+// been passed to the RecordFunc of the current or last call to Read. It
+// returns the zero time if Read has not been called.
+func (r *Reader) Watermark() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var low, high time.Time
+	for _, p := range r.partitions {
+		if p.watermark.After(high) {
+			high = p.watermark
+		}
+		if p.state == partitionFinished {
+			continue
+		}
+		if low.IsZero() || p.watermark.Before(low) {
+			low = p.watermark
+		}
+	}
+	if low.IsZero() {
+		// All known partitions have been read.
+		return high
+	}
+	return low
+}
+
+// handleChangeRecord passes the data change records in cr to f, and updates
+// the watermark of p and the set of known partitions.
+func (r *Reader) handleChangeRecord(p *partition, cr *ChangeRecord, f RecordFunc) error {
+	for _, dcr := range cr.DataChangeRecords {
+		if err := f(p.token, dcr); err != nil {
+			return err
+		}
+		r.advanceWatermark(p, dcr.CommitTimestamp)
+	}
+	for _, hr := range cr.HeartbeatRecords {
+		r.advanceWatermark(p, hr.Timestamp)
+	}
+	for _, cpr := range cr.ChildPartitionsRecords {
+		r.mu.Lock()
+		for _, child := range cpr.ChildPartitions {
+			// A child of several merged parents is reported by each of them;
+			// only the first report registers it.
+			if _, ok := r.partitions[child.Token]; ok {
+				continue
+			}
+			r.partitions[child.Token] = &partition{
+				token:     child.Token,
+				parents:   child.ParentPartitionTokens,
+				start:     cpr.StartTimestamp,
+				watermark: cpr.StartTimestamp,
+			}
+		}
+		r.mu.Unlock()
+	}
+	return nil
+}
+
+func (r *Reader) advanceWatermark(p *partition, t time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if t.After(p.watermark) {
+		p.watermark = t
+	}
+}
+
+// readyPartitionsLocked returns the pending partitions whose known parents
+// have all been read.
+//
+// r.mu must be held.
+func (r *Reader) readyPartitionsLocked() []*partition {
+	var ready []*partition
+	for _, p := range r.partitions {
+		if p.state != partitionPending {
+			continue
+		}
+		parentsDone := true
+		for _, token := range p.parents {
+			if parent, ok := r.partitions[token]; ok && parent.state != partitionFinished {
+				parentsDone = false
+				break
+			}
+		}
+		if parentsDone {
+			ready = append(ready, p)
+		}
+	}
+	return ready
+}
+
+// readPartition runs the change stream query for p.
+func (r *Reader) readPartition(ctx context.Context, p *partition, f func(*ChangeRecord) error) error {
+	stmt := spanner.Statement{
+		SQL: fmt.Sprintf("SELECT ChangeRecord FROM READ_%s("+
+			"start_timestamp => @start_timestamp, "+
+			"end_timestamp => @end_timestamp, "+
+			"partition_token => @partition_token, "+
+			"heartbeat_milliseconds => @heartbeat_milliseconds)", r.streamName),
+		Params: map[string]interface{}{
+			"start_timestamp":        p.start,
+			"end_timestamp":          spanner.NullTime{Time: r.config.EndTimestamp, Valid: !r.config.EndTimestamp.IsZero()},
+			"partition_token":        spanner.NullString{StringVal: p.token, Valid: p.token != ""},
+			"heartbeat_milliseconds": r.config.HeartbeatInterval.Milliseconds(),
+		},
+	}
+	iter := r.client.Single().QueryWithOptions(ctx, stmt, spanner.QueryOptions{Priority: r.config.Priority})
+	return iter.Do(func(row *spanner.Row) error {
+		var col struct {
+			ChangeRecords []*ChangeRecord `spanner:"ChangeRecord"`
+		}
+		if err := row.ToStructLenient(&col); err != nil {
+			return fmt.Errorf("changestreams: decoding change record of partition %q: %w", p.token, err)
+		}
+		for _, cr := range col.ChangeRecords {
+			if err := f(cr); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
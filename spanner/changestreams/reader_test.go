@@ -0,0 +1,334 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package changestreams
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	stestutil "cloud.google.com/go/spanner/internal/testutil"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestNewReaderWithConfig_Validation(t *testing.T) {
+	now := time.Now()
+	for _, tt := range []struct {
+		name       string
+		streamName string
+		config     Config
+	}{
+		{name: "empty stream name", streamName: ""},
+		{name: "invalid stream name", streamName: "Singers; DROP TABLE Singers"},
+		{name: "heartbeat too short", streamName: "S", config: Config{HeartbeatInterval: time.Millisecond}},
+		{name: "heartbeat too long", streamName: "S", config: Config{HeartbeatInterval: time.Hour}},
+		{name: "end before start", streamName: "S", config: Config{StartTimestamp: now, EndTimestamp: now.Add(-time.Second)}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewReaderWithConfig(nil, tt.streamName, tt.config); err == nil {
+				t.Fatal("got nil error, want non-nil")
+			}
+		})
+	}
+	r, err := NewReader(nil, "SingersStream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := r.config.HeartbeatInterval, DefaultHeartbeatInterval; got != want {
+		t.Errorf("HeartbeatInterval mismatch\n Got: %v\nWant: %v", got, want)
+	}
+}
+
+func TestReader_FollowsChildPartitions(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(secs int) time.Time { return t0.Add(time.Duration(secs) * time.Second) }
+	data := func(table string, ts time.Time) *ChangeRecord {
+		return &ChangeRecord{DataChangeRecords: []*DataChangeRecord{{TableName: table, CommitTimestamp: ts}}}
+	}
+	children := func(ts time.Time, cps ...*ChildPartition) *ChangeRecord {
+		return &ChangeRecord{ChildPartitionsRecords: []*ChildPartitionsRecord{{StartTimestamp: ts, ChildPartitions: cps}}}
+	}
+	// The initial query returns partitions A and B. A is split into C, and
+	// A and B are merged into D.
+	records := map[string][]*ChangeRecord{
+		"":  {children(at(0), &ChildPartition{Token: "A"}, &ChildPartition{Token: "B"})},
+		"A": {data("A", at(1)), children(at(3), &ChildPartition{Token: "C", ParentPartitionTokens: []string{"A"}}, &ChildPartition{Token: "D", ParentPartitionTokens: []string{"A", "B"}})},
+		"B": {data("B", at(2)), {HeartbeatRecords: []*HeartbeatRecord{{Timestamp: at(3)}}}, children(at(3), &ChildPartition{Token: "D", ParentPartitionTokens: []string{"A", "B"}})},
+		"C": {data("C", at(4))},
+		"D": {data("D", at(5))},
+	}
+
+	r, err := NewReaderWithConfig(nil, "S", Config{StartTimestamp: t0, EndTimestamp: at(5)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		mu     sync.Mutex
+		events []string
+		starts = map[string]time.Time{}
+	)
+	logEvent := func(e string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	}
+	r.readPartitionFunc = func(ctx context.Context, p *partition, f func(*ChangeRecord) error) error {
+		mu.Lock()
+		starts[p.token] = p.start
+		mu.Unlock()
+		for _, cr := range records[p.token] {
+			if err := f(cr); err != nil {
+				return err
+			}
+		}
+		logEvent("done " + p.token)
+		return nil
+	}
+	err = r.Read(context.Background(), func(token string, record *DataChangeRecord) error {
+		if token != record.TableName {
+			t.Errorf("record of table %s read from partition %q", record.TableName, token)
+		}
+		logEvent("data " + token)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index := func(e string) int {
+		for i, got := range events {
+			if got == e {
+				return i
+			}
+		}
+		t.Fatalf("missing event %q in %v", e, events)
+		return -1
+	}
+	if len(events) != 9 {
+		t.Errorf("got events %v, want 9 events", events)
+	}
+	for _, tt := range []struct{ child, parent string }{
+		{"C", "A"},
+		{"D", "A"},
+		{"D", "B"},
+	} {
+		if index("data "+tt.child) < index("done "+tt.parent) {
+			t.Errorf("partition %s was read before its parent %s finished: %v", tt.child, tt.parent, events)
+		}
+	}
+	if got, want := starts["D"], at(3); !got.Equal(want) {
+		t.Errorf("start timestamp of D mismatch\n Got: %v\nWant: %v", got, want)
+	}
+	if got, want := r.Watermark(), at(5); !got.Equal(want) {
+		t.Errorf("Watermark mismatch\n Got: %v\nWant: %v", got, want)
+	}
+}
+
+func TestReader_Watermark(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r, err := NewReaderWithConfig(nil, "S", Config{StartTimestamp: t0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Watermark(); !got.IsZero() {
+		t.Fatalf("Watermark before Read = %v, want zero", got)
+	}
+	r.readPartitionFunc = func(ctx context.Context, p *partition, f func(*ChangeRecord) error) error {
+		switch p.token {
+		case "":
+			return f(&ChangeRecord{ChildPartitionsRecords: []*ChildPartitionsRecord{{
+				StartTimestamp:  t0,
+				ChildPartitions: []*ChildPartition{{Token: "busy"}, {Token: "idle"}},
+			}}})
+		case "busy":
+			return f(&ChangeRecord{DataChangeRecords: []*DataChangeRecord{{CommitTimestamp: t0.Add(time.Minute)}}})
+		default:
+			if err := f(&ChangeRecord{HeartbeatRecords: []*HeartbeatRecord{{Timestamp: t0.Add(10 * time.Second)}}}); err != nil {
+				return err
+			}
+			<-ctx.Done()
+			return ctx.Err()
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- r.Read(ctx, func(string, *DataChangeRecord) error { return nil })
+	}()
+
+	// The idle partition holds the watermark back at its last heartbeat.
+	want := t0.Add(10 * time.Second)
+	deadline := time.Now().Add(10 * time.Second)
+	for !r.Watermark().Equal(want) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Watermark mismatch\n Got: %v\nWant: %v", r.Watermark(), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Read error mismatch\n Got: %v\nWant: %v", err, context.Canceled)
+	}
+}
+
+func TestReader_RecordFuncError(t *testing.T) {
+	r, err := NewReader(nil, "S")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.readPartitionFunc = func(ctx context.Context, p *partition, f func(*ChangeRecord) error) error {
+		return f(&ChangeRecord{DataChangeRecords: []*DataChangeRecord{{TableName: "Singers"}}})
+	}
+	wantErr := errors.New("failed")
+	if err := r.Read(context.Background(), func(string, *DataChangeRecord) error { return wantErr }); err != wantErr {
+		t.Fatalf("Read error mismatch\n Got: %v\nWant: %v", err, wantErr)
+	}
+}
+
+func TestReader_Query(t *testing.T) {
+	server, opts, teardown := stestutil.NewMockedSpannerInMemTestServer(t)
+	defer teardown()
+	ctx := context.Background()
+	client, err := spanner.NewClient(ctx, "projects/p/instances/i/databases/d", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// Every partition query returns the same result: one insert into
+	// Singers, and a child partition that is only read once.
+	typ := func(code sppb.TypeCode) *sppb.Type { return &sppb.Type{Code: code} }
+	arrayOf := func(t *sppb.Type) *sppb.Type { return &sppb.Type{Code: sppb.TypeCode_ARRAY, ArrayElementType: t} }
+	structOf := func(names []string, types ...*sppb.Type) *sppb.Type {
+		st := &sppb.StructType{}
+		for i, name := range names {
+			st.Fields = append(st.Fields, &sppb.StructType_Field{Name: name, Type: types[i]})
+		}
+		return &sppb.Type{Code: sppb.TypeCode_STRUCT, StructType: st}
+	}
+	changeRecordType := arrayOf(structOf(
+		[]string{"data_change_record", "heartbeat_record", "child_partitions_record"},
+		arrayOf(structOf(
+			[]string{"commit_timestamp", "table_name", "mod_type", "mods"},
+			typ(sppb.TypeCode_TIMESTAMP), typ(sppb.TypeCode_STRING), typ(sppb.TypeCode_STRING),
+			arrayOf(structOf([]string{"keys", "new_values", "old_values"}, typ(sppb.TypeCode_JSON), typ(sppb.TypeCode_JSON), typ(sppb.TypeCode_JSON))),
+		)),
+		arrayOf(structOf([]string{"timestamp"}, typ(sppb.TypeCode_TIMESTAMP))),
+		arrayOf(structOf(
+			[]string{"start_timestamp", "record_sequence", "child_partitions"},
+			typ(sppb.TypeCode_TIMESTAMP), typ(sppb.TypeCode_STRING),
+			arrayOf(structOf([]string{"token", "parent_partition_tokens"}, typ(sppb.TypeCode_STRING), arrayOf(typ(sppb.TypeCode_STRING)))),
+		)),
+	))
+	list := func(vs ...*structpb.Value) *structpb.Value {
+		return structpb.NewListValue(&structpb.ListValue{Values: vs})
+	}
+	str := structpb.NewStringValue
+	// A row with a single ChangeRecord column holding an array of one
+	// change record.
+	row := list(list(list(
+		list(list(str("2024-01-01T00:00:01Z"), str("Singers"), str("INSERT"),
+			list(list(str(`{"SingerId":"1"}`), str(`{"Name":"Alice"}`), str("{}"))))),
+		list(),
+		list(list(str("2024-01-01T00:00:02Z"), str("00000001"),
+			list(list(str("token1"), list())))),
+	)))
+	sql := "SELECT ChangeRecord FROM READ_SingersStream(" +
+		"start_timestamp => @start_timestamp, " +
+		"end_timestamp => @end_timestamp, " +
+		"partition_token => @partition_token, " +
+		"heartbeat_milliseconds => @heartbeat_milliseconds)"
+	server.TestSpanner.PutStatementResult(sql, &stestutil.StatementResult{
+		Type: stestutil.StatementResultResultSet,
+		ResultSet: &sppb.ResultSet{
+			Metadata: &sppb.ResultSetMetadata{RowType: &sppb.StructType{Fields: []*sppb.StructType_Field{
+				{Name: "ChangeRecord", Type: changeRecordType},
+			}}},
+			Rows: []*structpb.ListValue{row.GetListValue()},
+		},
+	})
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r, err := NewReaderWithConfig(client, "SingersStream", Config{StartTimestamp: start, EndTimestamp: start.Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		mu  sync.Mutex
+		got []string
+	)
+	err = r.Read(ctx, func(token string, record *DataChangeRecord) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, fmt.Sprintf("%s:%s:%s:%v", token, record.TableName, record.ModType, record.Mods[0].NewValues))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d records (%v), want 2", len(got), got)
+	}
+	want := map[string]bool{
+		`:Singers:INSERT:{"Name":"Alice"}`:       true,
+		`token1:Singers:INSERT:{"Name":"Alice"}`: true,
+	}
+	for _, g := range got {
+		if !want[g] {
+			t.Errorf("unexpected record %q", g)
+		}
+	}
+
+	var params []map[string]*structpb.Value
+	for _, req := range drainRequests(server.TestSpanner) {
+		if req, ok := req.(*sppb.ExecuteSqlRequest); ok {
+			params = append(params, req.Params.GetFields())
+		}
+	}
+	if len(params) != 2 {
+		t.Fatalf("got %d queries, want 2", len(params))
+	}
+	if _, ok := params[0]["partition_token"].GetKind().(*structpb.Value_NullValue); !ok {
+		t.Errorf("initial query partition_token = %v, want NULL", params[0]["partition_token"])
+	}
+	if got, want := params[1]["partition_token"].GetStringValue(), "token1"; got != want {
+		t.Errorf("child query partition_token mismatch\n Got: %v\nWant: %v", got, want)
+	}
+	if got, want := params[1]["start_timestamp"].GetStringValue(), "2024-01-01T00:00:02Z"; got != want {
+		t.Errorf("child query start_timestamp mismatch\n Got: %v\nWant: %v", got, want)
+	}
+}
+
+func drainRequests(server stestutil.InMemSpannerServer) []interface{} {
+	var reqs []interface{}
+loop:
+	for {
+		select {
+		case req := <-server.ReceivedRequests():
+			reqs = append(reqs, req)
+		default:
+			break loop
+		}
+	}
+	return reqs
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2024 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package changestreams
+
+import (
+	"time"
+
+	"cloud.google.com/go/spanner"
+)
+
+// ChangeRecord is a single element of the ChangeRecord column returned by a
+// change stream partition query. Each of its slices may hold zero or more
+// records.
+type ChangeRecord struct {
+	DataChangeRecords      []*DataChangeRecord      `spanner:"data_change_record"`
+	HeartbeatRecords       []*HeartbeatRecord       `spanner:"heartbeat_record"`
+	ChildPartitionsRecords []*ChildPartitionsRecord `spanner:"child_partitions_record"`
+}
+
+// DataChangeRecord contains a set of changes to a table with the same
+// modification type (insert, update, or delete) committed at the same commit
+// timestamp in one change stream partition for the same transaction.
+type DataChangeRecord struct {
+	// CommitTimestamp is the timestamp at which the change was committed.
+	CommitTimestamp time.Time `spanner:"commit_timestamp"`
+	// RecordSequence orders the records within a transaction in a
+	// partition.
+	RecordSequence string `spanner:"record_sequence"`
+	// ServerTransactionID is a globally unique ID of the transaction in which
+	// the change was committed.
+	ServerTransactionID string `spanner:"server_transaction_id"`
+	// IsLastRecordInTransactionInPartition is true if this is the last record
+	// for the transaction in the current partition.
+	IsLastRecordInTransactionInPartition bool `spanner:"is_last_record_in_transaction_in_partition"`
+	// TableName is the name of the table affected by the change.
+	TableName string `spanner:"table_name"`
+	// ColumnTypes describes the columns that appear in Mods.
+	ColumnTypes []*ColumnType `spanner:"column_types"`
+	// Mods are the changes made, one per row.
+	Mods []*Mod `spanner:"mods"`
+	// ModType is INSERT, UPDATE, or DELETE.
+	ModType string `spanner:"mod_type"`
+	// ValueCaptureType is the value capture type of the change stream, such
+	// as OLD_AND_NEW_VALUES or NEW_ROW.
+	ValueCaptureType string `spanner:"value_capture_type"`
+	// NumberOfRecordsInTransaction is the number of data change records for
+	// the transaction across all partitions.
+	NumberOfRecordsInTransaction int64 `spanner:"number_of_records_in_transaction"`
+	// NumberOfPartitionsInTransaction is the number of partitions that
+	// return data change records for the transaction.
+	NumberOfPartitionsInTransaction int64 `spanner:"number_of_partitions_in_transaction"`
+	// TransactionTag is the transaction tag of the transaction, if any.
+	TransactionTag string `spanner:"transaction_tag"`
+	// IsSystemTransaction is true if the transaction was run by Spanner
+	// itself, for example to apply a TTL policy.
+	IsSystemTransaction bool `spanner:"is_system_transaction"`
+}
+
+// ColumnType describes a column that appears in the mods of a
+// DataChangeRecord.
+type ColumnType struct {
+	Name string `spanner:"name"`
+	// Type is the JSON encoding of the column's Spanner type, for example
+	// {"code": "STRING"}.
+	Type            spanner.NullJSON `spanner:"type"`
+	IsPrimaryKey    bool             `spanner:"is_primary_key"`
+	OrdinalPosition int64            `spanner:"ordinal_position"`
+}
+
+// Mod is a change to a single row. Keys, NewValues and OldValues are JSON
+// objects keyed by column name; which of the values are set depends on the
+// modification and value capture types.
+type Mod struct {
+	Keys      spanner.NullJSON `spanner:"keys"`
+	NewValues spanner.NullJSON `spanner:"new_values"`
+	OldValues spanner.NullJSON `spanner:"old_values"`
+}
+
+// HeartbeatRecord is returned when there are no changes in a partition. It
+// indicates that all changes with a commit timestamp before Timestamp have
+// been returned.
+type HeartbeatRecord struct {
+	Timestamp time.Time `spanner:"timestamp"`
+}
+
+// ChildPartitionsRecord lists the partitions that replace the current
+// partition from StartTimestamp onwards.
+type ChildPartitionsRecord struct {
+	StartTimestamp  time.Time         `spanner:"start_timestamp"`
+	RecordSequence  string            `spanner:"record_sequence"`
+	ChildPartitions []*ChildPartition `spanner:"child_partitions"`
+}
+
+// ChildPartition is a partition of a change stream that should be queried
+// once all of its parents have been read.
+type ChildPartition struct {
+	Token                 string   `spanner:"token"`
+	ParentPartitionTokens []string `spanner:"parent_partition_tokens"`
+}
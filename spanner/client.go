@@ -23,6 +23,7 @@ import (
 	"log"
 	"os"
 	"regexp"
+	"strconv"
 	"time"
 
 	"cloud.google.com/go/internal/trace"
@@ -107,6 +108,7 @@ type Client struct {
 	disableRouteToLeader bool
 	dro                  *sppb.DirectedReadOptions
 	otConfig             *openTelemetryConfig
+	onTxAborted          func(AbortedTransactionInfo)
 }
 
 // DatabaseName returns the full name of a database, e.g.,
@@ -202,6 +204,14 @@ type ClientConfig struct {
 	DirectedReadOptions *sppb.DirectedReadOptions
 
 	OpenTelemetryMeterProvider metric.MeterProvider
+
+	// OnTransactionAborted is called each time an attempt of a read/write
+	// transaction that is run by Client.ReadWriteTransaction or
+	// Client.ReadWriteTransactionWithOptions is aborted by Spanner, before the
+	// transaction is retried. It can be used to log or count aborted
+	// transactions, for example to find lock contention hotspots. It is
+	// called synchronously and should return quickly.
+	OnTransactionAborted func(info AbortedTransactionInfo)
 }
 
 type openTelemetryConfig struct {
@@ -217,6 +227,8 @@ type openTelemetryConfig struct {
 	releasedSessionsCount   metric.Int64Counter
 	gfeLatency              metric.Int64Histogram
 	gfeHeaderMissingCount   metric.Int64Counter
+	sessionAcquireLatency   metric.Float64Histogram
+	transactionRetryCount   metric.Int64Counter
 }
 
 func contextWithOutgoingMetadata(ctx context.Context, md metadata.MD, disableRouteToLeader bool) context.Context {
@@ -319,6 +331,9 @@ func NewClientWithConfig(ctx context.Context, database string, config ClientConf
 
 	// Create a session pool.
 	config.SessionPoolConfig.sessionLabels = sessionLabels
+	if enable, err := strconv.ParseBool(os.Getenv("GOOGLE_CLOUD_SPANNER_MULTIPLEXED_SESSIONS")); err == nil {
+		config.SessionPoolConfig.enableMultiplexSession = enable
+	}
 	sp, err := newSessionPool(sc, config.SessionPoolConfig)
 	if err != nil {
 		sc.close()
@@ -338,6 +353,7 @@ func NewClientWithConfig(ctx context.Context, database string, config ClientConf
 		disableRouteToLeader: config.DisableRouteToLeader,
 		dro:                  config.DirectedReadOptions,
 		otConfig:             otConfig,
+		onTxAborted:          config.OnTransactionAborted,
 	}
 	return c, nil
 }
@@ -625,6 +641,9 @@ func (c *Client) rwTransaction(ctx context.Context, f func(context.Context, *Rea
 		var (
 			err error
 		)
+		if attempt > 0 {
+			recordTransactionRetryMetricsOT(ctx, c.otConfig)
+		}
 		if sh == nil || sh.getID() == "" || sh.getClient() == nil {
 			// Session handle hasn't been allocated or has been destroyed.
 			sh, err = c.idleSessions.take(ctx)
@@ -666,6 +685,9 @@ func (c *Client) rwTransaction(ctx context.Context, f func(context.Context, *Rea
 			"Starting transaction attempt")
 
 		resp, err = t.runInTransaction(ctx, f)
+		if err != nil && c.onTxAborted != nil && ErrCode(err) == codes.Aborted {
+			c.onTxAborted(newAbortedTransactionInfo(attempt, t.txOpts.TransactionTag, err))
+		}
 		return err
 	})
 	return resp, err
@@ -718,6 +740,45 @@ func Priority(priority sppb.RequestOptions_Priority) ApplyOption {
 	}
 }
 
+// requestOptions are the per-request options that can be set with a
+// RequestOption.
+type requestOptions struct {
+	priority   sppb.RequestOptions_Priority
+	requestTag string
+}
+
+// A RequestOption sets an option of a single request. RequestOptions can be
+// passed to the QueryWithRequestOptions, ReadWithRequestOptions and
+// UpdateWithRequestOptions methods of transactions, as an alternative to
+// filling in QueryOptions or ReadOptions.
+type RequestOption func(*requestOptions)
+
+// RequestPriority returns a RequestOption that sets the RPC priority to use
+// for the request.
+func RequestPriority(priority sppb.RequestOptions_Priority) RequestOption {
+	return func(ro *requestOptions) {
+		ro.priority = priority
+	}
+}
+
+// RequestTag returns a RequestOption that sets the request tag to use for the
+// request. Request tags are shown in Query Insights and in the statistics
+// tables of the database.
+func RequestTag(tag string) RequestOption {
+	return func(ro *requestOptions) {
+		ro.requestTag = tag
+	}
+}
+
+// newRequestOptions returns the requestOptions set by opts.
+func newRequestOptions(opts []RequestOption) requestOptions {
+	var ro requestOptions
+	for _, opt := range opts {
+		opt(&ro)
+	}
+	return ro
+}
+
 // Apply applies a list of mutations atomically to the database.
 func (c *Client) Apply(ctx context.Context, ms []*Mutation, opts ...ApplyOption) (commitTimestamp time.Time, err error) {
 	ao := &applyOption{}
@@ -750,14 +811,21 @@ type BatchWriteOptions struct {
 
 	// The transaction tag to use for this request.
 	TransactionTag string
+
+	// If excludeTxnFromChangeStreams == true, modifications from all
+	// transactions in this batch write request will not be recorded in
+	// change streams that have the DDL option
+	// allow_txn_exclusion=true.
+	ExcludeTxnFromChangeStreams bool
 }
 
 // merge combines two BatchWriteOptions such that the input parameter will have higher
 // order of precedence.
 func (bwo BatchWriteOptions) merge(opts BatchWriteOptions) BatchWriteOptions {
 	merged := BatchWriteOptions{
-		TransactionTag: bwo.TransactionTag,
-		Priority:       bwo.Priority,
+		TransactionTag:              bwo.TransactionTag,
+		Priority:                    bwo.Priority,
+		ExcludeTxnFromChangeStreams: bwo.ExcludeTxnFromChangeStreams || opts.ExcludeTxnFromChangeStreams,
 	}
 	if opts.TransactionTag != "" {
 		merged.TransactionTag = opts.TransactionTag
@@ -912,9 +980,10 @@ func (c *Client) BatchWriteWithOptions(ctx context.Context, mgs []*MutationGroup
 		var md metadata.MD
 		sh.updateLastUseTime()
 		stream, rpcErr := sh.getClient().BatchWrite(contextWithOutgoingMetadata(ct, sh.getMetadata(), c.disableRouteToLeader), &sppb.BatchWriteRequest{
-			Session:        sh.getID(),
-			MutationGroups: mgsPb,
-			RequestOptions: createRequestOptions(opts.Priority, "", opts.TransactionTag),
+			Session:                     sh.getID(),
+			MutationGroups:              mgsPb,
+			RequestOptions:              createRequestOptions(opts.Priority, "", opts.TransactionTag),
+			ExcludeTxnFromChangeStreams: opts.ExcludeTxnFromChangeStreams,
 		}, gax.WithGRPCOptions(grpc.Header(&md)))
 
 		if getGFELatencyMetricsFlag() && md != nil && c.ct != nil {
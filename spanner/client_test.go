@@ -30,11 +30,13 @@ import (
 	"cloud.google.com/go/civil"
 	itestutil "cloud.google.com/go/internal/testutil"
 	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/golang/protobuf/ptypes"
 	structpb "github.com/golang/protobuf/ptypes/struct"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/googleapis/gax-go/v2"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
+	edpb "google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/encoding/gzip"
 	"google.golang.org/grpc/status"
@@ -400,6 +402,52 @@ func TestClient_Single_DeadlineExceeded_NoErrors(t *testing.T) {
 	}
 }
 
+func TestClient_Single_StatementTimeout(t *testing.T) {
+	t.Parallel()
+	server, client, teardown := setupMockedTestServer(t)
+	defer teardown()
+	server.TestSpanner.PutExecutionTime(MethodExecuteStreamingSql,
+		SimulatedExecutionTime{
+			MinimumExecutionTime: 50 * time.Millisecond,
+		})
+	stmt := NewStatement(SelectSingerIDAlbumIDAlbumTitleFromAlbums).WithTimeout(5 * time.Millisecond)
+	iter := client.Single().Query(context.Background(), stmt)
+	defer iter.Stop()
+	_, err := iter.Next()
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("Error mismatch:\ngot: %v\nwant: %v", err, codes.DeadlineExceeded)
+	}
+}
+
+func TestClient_ReadWriteTransaction_UpdateStatementTimeout(t *testing.T) {
+	t.Parallel()
+	server, client, teardown := setupMockedTestServer(t)
+	defer teardown()
+	server.TestSpanner.PutExecutionTime(MethodExecuteSql,
+		SimulatedExecutionTime{
+			MinimumExecutionTime: 50 * time.Millisecond,
+		})
+	_, err := client.ReadWriteTransaction(context.Background(), func(ctx context.Context, tx *ReadWriteTransaction) error {
+		_, err := tx.Update(ctx, NewStatement(UpdateBarSetFoo).WithTimeout(5*time.Millisecond))
+		return err
+	})
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("Error mismatch:\ngot: %v\nwant: %v", err, codes.DeadlineExceeded)
+	}
+}
+
+func TestClient_Single_StatementRetryBackoff(t *testing.T) {
+	t.Parallel()
+	_, client, teardown := setupMockedTestServer(t)
+	defer teardown()
+	bo := gax.Backoff{Initial: time.Millisecond, Max: 10 * time.Millisecond, Multiplier: 2}
+	iter := client.Single().Query(context.Background(), NewStatement(SelectSingerIDAlbumIDAlbumTitleFromAlbums).WithRetryBackoff(bo))
+	defer iter.Stop()
+	if g, w := iter.streamd.backoff, bo; g != w {
+		t.Fatalf("Backoff mismatch\n Got: %v\nWant: %v", g, w)
+	}
+}
+
 func TestClient_Single_DeadlineExceeded_WithErrors(t *testing.T) {
 	t.Parallel()
 	server, client, teardown := setupMockedTestServer(t)
@@ -1269,6 +1317,59 @@ func TestClient_ReadWriteTransactionCommitAborted(t *testing.T) {
 	}
 }
 
+func TestClient_ReadWriteTransaction_OnAborted(t *testing.T) {
+	t.Parallel()
+
+	var infos []AbortedTransactionInfo
+	server, client, teardown := setupMockedTestServerWithConfig(t, ClientConfig{
+		OnTransactionAborted: func(info AbortedTransactionInfo) {
+			infos = append(infos, info)
+		},
+	})
+	defer teardown()
+	st, err := status.New(codes.Aborted, "Transaction was aborted due to lock conflict").WithDetails(
+		&edpb.ErrorInfo{Reason: "LOCK_CONFLICT", Metadata: map[string]string{"table": "Singers"}},
+		&edpb.RetryInfo{RetryDelay: ptypes.DurationProto(time.Millisecond)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.TestSpanner.PutExecutionTime(MethodCommitTransaction, SimulatedExecutionTime{Errors: []error{st.Err()}})
+
+	_, err = client.ReadWriteTransactionWithOptions(context.Background(), func(ctx context.Context, tx *ReadWriteTransaction) error {
+		_, err := tx.Update(ctx, NewStatement(UpdateBarSetFoo))
+		return err
+	}, TransactionOptions{TransactionTag: "tx-tag"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, w := len(infos), 1; g != w {
+		t.Fatalf("aborted attempts mismatch\n Got: %v\nWant: %v", g, w)
+	}
+	info := infos[0]
+	if g, w := info.Attempt, 1; g != w {
+		t.Errorf("attempt mismatch\n Got: %v\nWant: %v", g, w)
+	}
+	if g, w := info.TransactionTag, "tx-tag"; g != w {
+		t.Errorf("transaction tag mismatch\n Got: %v\nWant: %v", g, w)
+	}
+	if g, w := info.Reason, "Transaction was aborted due to lock conflict"; g != w {
+		t.Errorf("reason mismatch\n Got: %v\nWant: %v", g, w)
+	}
+	if g, w := info.ErrorReason, "LOCK_CONFLICT"; g != w {
+		t.Errorf("error reason mismatch\n Got: %v\nWant: %v", g, w)
+	}
+	if g, w := info.ErrorMetadata["table"], "Singers"; g != w {
+		t.Errorf("error metadata mismatch\n Got: %v\nWant: %v", g, w)
+	}
+	if g, w := info.RetryDelay, time.Millisecond; g != w {
+		t.Errorf("retry delay mismatch\n Got: %v\nWant: %v", g, w)
+	}
+	if g, w := ErrCode(info.Err), codes.Aborted; g != w {
+		t.Errorf("error code mismatch\n Got: %v\nWant: %v", g, w)
+	}
+}
+
 func TestClient_ReadWriteTransaction_BufferedWriteBeforeAbortedFirstSqlStatement(t *testing.T) {
 	ctx := context.Background()
 	server, client, teardown := setupMockedTestServer(t)
@@ -4505,6 +4606,38 @@ func TestClient_ReadWriteTransaction_Tag(t *testing.T) {
 	}
 }
 
+func TestClient_ReadWriteTransaction_RequestOptions(t *testing.T) {
+	t.Parallel()
+
+	server, client, teardown := setupMockedTestServer(t)
+	defer teardown()
+	want := sppb.RequestOptions{
+		Priority:       sppb.RequestOptions_PRIORITY_LOW,
+		RequestTag:     "request-tag-1",
+		TransactionTag: "tx-tag-1",
+	}
+	_, err := client.ReadWriteTransactionWithOptions(context.Background(), func(ctx context.Context, tx *ReadWriteTransaction) error {
+		opts := []RequestOption{RequestPriority(want.Priority), RequestTag(want.RequestTag)}
+		iter := tx.QueryWithRequestOptions(ctx, NewStatement(SelectSingerIDAlbumIDAlbumTitleFromAlbums), opts...)
+		iter.Next()
+		iter.Stop()
+
+		iter = tx.ReadWithRequestOptions(ctx, "FOO", AllKeys(), []string{"BAR"}, opts...)
+		iter.Next()
+		iter.Stop()
+
+		if _, err := tx.UpdateWithRequestOptions(ctx, NewStatement(UpdateBarSetFoo), opts...); err != nil {
+			return err
+		}
+		checkRequestsForExpectedRequestOptions(t, server.TestSpanner, 3, sppb.RequestOptions{Priority: want.Priority, RequestTag: want.RequestTag, TransactionTag: want.TransactionTag})
+		return nil
+	}, TransactionOptions{TransactionTag: want.TransactionTag})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCommitForExpectedRequestOptions(t, server.TestSpanner, sppb.RequestOptions{TransactionTag: want.TransactionTag})
+}
+
 func TestClient_StmtBasedReadWriteTransaction_Tag(t *testing.T) {
 	t.Parallel()
 
@@ -4982,6 +5115,48 @@ func TestClient_BatchWrite_Options(t *testing.T) {
 	}
 }
 
+func TestClient_BatchWrite_ExcludeTxnFromChangeStreams(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name   string
+		client BatchWriteOptions
+		write  BatchWriteOptions
+		want   bool
+	}{
+		{name: "Default"},
+		{name: "Client level", client: BatchWriteOptions{ExcludeTxnFromChangeStreams: true}, want: true},
+		{name: "Write level", write: BatchWriteOptions{ExcludeTxnFromChangeStreams: true}, want: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client, teardown := setupMockedTestServerWithConfig(t, ClientConfig{BatchWriteOptions: tt.client})
+			defer teardown()
+
+			mutationGroups := []*MutationGroup{
+				{[]*Mutation{
+					{opInsertOrUpdate, "t_test", nil, []string{"key", "val"}, []interface{}{"foo1", 1}},
+				}},
+			}
+			iter := client.BatchWriteWithOptions(context.Background(), mutationGroups, tt.write)
+			if err := iter.Do(func(r *sppb.BatchWriteResponse) error { return nil }); err != nil {
+				t.Fatal(err)
+			}
+			var got *sppb.BatchWriteRequest
+			for _, req := range drainRequestsFromServer(server.TestSpanner) {
+				if request, ok := req.(*sppb.BatchWriteRequest); ok {
+					got = request
+				}
+			}
+			if got == nil {
+				t.Fatal("Missing BatchWriteRequest")
+			}
+			if g, w := got.ExcludeTxnFromChangeStreams, tt.want; g != w {
+				t.Fatalf("ExcludeTxnFromChangeStreams mismatch\n Got: %v\nWant: %v", g, w)
+			}
+		})
+	}
+}
+
 func checkBatchWriteSpan(t *testing.T, errors []error, code codes.Code) {
 	// This test cannot be parallel, as the TestExporter does not support that.
 	te := itestutil.NewTestExporter()
@@ -672,8 +672,8 @@ func TestIntegration_SingleUse(t *testing.T) {
 			got, err := readAll(su.Query(
 				ctx,
 				Statement{
-					singersQuery,
-					map[string]interface{}{"p1": int64(1), "p2": int64(3), "p3": int64(4)},
+					SQL:    singersQuery,
+					Params: map[string]interface{}{"p1": int64(1), "p2": int64(3), "p3": int64(4)},
 				}))
 			if err != nil {
 				t.Fatalf("%d: SingleUse.Query returns error %v, want nil", i, err)
@@ -835,8 +835,8 @@ func TestIntegration_SingleUse_WithQueryOptions(t *testing.T) {
 		OptimizerStatisticsPackage: "latest",
 	}}
 	got, err := readAll(client.Single().QueryWithOptions(ctx, Statement{
-		singersQuery,
-		map[string]interface{}{"p1": int64(1), "p2": int64(3), "p3": int64(4)},
+		SQL:    singersQuery,
+		Params: map[string]interface{}{"p1": int64(1), "p2": int64(3), "p3": int64(4)},
 	}, qo))
 
 	if err != nil {
@@ -970,8 +970,8 @@ func TestIntegration_BatchWrite(t *testing.T) {
 		OptimizerStatisticsPackage: "latest",
 	}}
 	got, err := readAll(client.Single().QueryWithOptions(ctx, Statement{
-		singersQuery,
-		map[string]interface{}{"p1": int64(1), "p2": int64(3), "p3": int64(4)},
+		SQL:    singersQuery,
+		Params: map[string]interface{}{"p1": int64(1), "p2": int64(3), "p3": int64(4)},
 	}, qo))
 
 	if err != nil {
@@ -1120,8 +1120,8 @@ func TestIntegration_ReadOnlyTransaction(t *testing.T) {
 		got, err := readAll(ro.Query(
 			ctx,
 			Statement{
-				singersQuery,
-				map[string]interface{}{"p1": int64(1), "p2": int64(3), "p3": int64(4)},
+				SQL:    singersQuery,
+				Params: map[string]interface{}{"p1": int64(1), "p2": int64(3), "p3": int64(4)},
 			}))
 		if err != nil {
 			t.Errorf("%d: ReadOnlyTransaction.Query returns error %v, want nil", i, err)
@@ -1331,7 +1331,7 @@ func TestIntegration_ReadWriteTransaction(t *testing.T) {
 			_, err := client.ReadWriteTransaction(ctx, func(ctx context.Context, tx *ReadWriteTransaction) error {
 				// Query Foo's balance and Bar's balance.
 				bf, e := readBalance(tx.Query(ctx,
-					Statement{queryAccountByID, map[string]interface{}{"p1": int64(1)}}))
+					Statement{SQL: queryAccountByID, Params: map[string]interface{}{"p1": int64(1)}}))
 				if e != nil {
 					return e
 				}
@@ -1439,7 +1439,7 @@ func TestIntegration_ReadWriteTransactionWithOptions(t *testing.T) {
 			queryAccountByID = "SELECT Balance FROM Accounts WHERE AccountId = $1"
 		}
 		bf, e := readBalance(tx.Query(ctx,
-			Statement{queryAccountByID, map[string]interface{}{"p1": int64(1)}}))
+			Statement{SQL: queryAccountByID, Params: map[string]interface{}{"p1": int64(1)}}))
 		if e != nil {
 			return e
 		}
@@ -2466,7 +2466,7 @@ func TestIntegration_QueryStats(t *testing.T) {
 	}
 	const sql = "SELECT Balance FROM Accounts"
 
-	qp, err := client.Single().AnalyzeQuery(ctx, Statement{sql, nil})
+	qp, err := client.Single().AnalyzeQuery(ctx, Statement{SQL: sql})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -2474,7 +2474,7 @@ func TestIntegration_QueryStats(t *testing.T) {
 		t.Error("got zero plan nodes, expected at least one")
 	}
 
-	iter := client.Single().QueryWithStats(ctx, Statement{sql, nil})
+	iter := client.Single().QueryWithStats(ctx, Statement{SQL: sql})
 	defer iter.Stop()
 	for {
 		_, err := iter.Next()
@@ -4582,8 +4582,8 @@ func TestIntegration_Foreign_Key_Delete_Cascade_Action(t *testing.T) {
 					constraintName = `'FKShoppingCartsCustomerName'`
 				}
 				got, err := readAll(client.Single().Query(ctx, Statement{
-					fmt.Sprintf(`SELECT 1, '', DELETE_RULE FROM INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS WHERE CONSTRAINT_NAME = %s`, constraintName),
-					map[string]interface{}{}}))
+					SQL:    fmt.Sprintf(`SELECT 1, '', DELETE_RULE FROM INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS WHERE CONSTRAINT_NAME = %s`, constraintName),
+					Params: map[string]interface{}{}}))
 				if err != nil {
 					t.Fatalf("Expect to read the delete_rule from information_schema, got: %v", err)
 				}
@@ -4618,8 +4618,8 @@ func TestIntegration_Foreign_Key_Delete_Cascade_Action(t *testing.T) {
 					constraintName = `'FKShoppingCartsCustomerName'`
 				}
 				got, err := readAll(client.Single().Query(ctx, Statement{
-					fmt.Sprintf(`SELECT 1, '', DELETE_RULE FROM INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS WHERE CONSTRAINT_NAME = %s`, constraintName),
-					map[string]interface{}{}}))
+					SQL:    fmt.Sprintf(`SELECT 1, '', DELETE_RULE FROM INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS WHERE CONSTRAINT_NAME = %s`, constraintName),
+					Params: map[string]interface{}{}}))
 				if err != nil {
 					t.Fatalf("Expect to read the delete_rule from information_schema, got: %v", err)
 				}
@@ -5100,8 +5100,8 @@ func TestIntegration_WithDirectedReadOptions_ReadOnlyTransaction(t *testing.T) {
 	// Test DirectedReadOptions for ReadOnlyTransaction.QueryWithOptions
 	singersQuery := "SELECT SingerId, FirstName, LastName FROM Singers WHERE SingerId IN (@p1, @p2, @p3) ORDER BY SingerId"
 	got, err = readAll(client.Single().QueryWithOptions(ctx, Statement{
-		singersQuery,
-		map[string]interface{}{"p1": int64(1), "p2": int64(3), "p3": int64(4)},
+		SQL:    singersQuery,
+		Params: map[string]interface{}{"p1": int64(1), "p2": int64(3), "p3": int64(4)},
 	}, QueryOptions{DirectedReadOptions: directedReadOptions}))
 
 	if err != nil {
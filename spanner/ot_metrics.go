@@ -40,6 +40,8 @@ var (
 	attributeKeyType       = attribute.Key("type")
 	attributeKeyMethod     = attribute.Key("grpc_client_method")
 
+	attributeKeyIsMultiplexed = attribute.Key("is_multiplexed")
+
 	attributeNumInUseSessions = attributeKeyType.String("num_in_use_sessions")
 	attributeNumSessions      = attributeKeyType.String("num_sessions")
 	// openTelemetryMetricsEnabled is used to track if OpenTelemetry Metrics need to be recorded
@@ -181,6 +183,28 @@ func initializeMetricInstruments(config *openTelemetryConfig, logger *log.Logger
 		logf(logger, "Error during registering instrument for metric spanner/gfe_header_missing_count, error: %v", err)
 	}
 	config.gfeHeaderMissingCount = gfeHeaderMissingCountInstrument
+
+	sessionAcquireLatencyInstrument, err := meter.Float64Histogram(
+		metricsPrefix+"session_acquire_latency",
+		metric.WithDescription("The time it took to acquire a session from the session pool."),
+		metric.WithUnit("ms"),
+		metric.WithExplicitBucketBoundaries(0.0, 0.1, 0.5, 1.0, 2.0, 5.0, 10.0, 20.0, 50.0, 100.0, 200.0, 500.0,
+			1000.0, 2000.0, 5000.0, 10000.0, 30000.0, 60000.0),
+	)
+	if err != nil {
+		logf(logger, "Error during registering instrument for metric spanner/session_acquire_latency, error: %v", err)
+	}
+	config.sessionAcquireLatency = sessionAcquireLatencyInstrument
+
+	transactionRetryCountInstrument, err := meter.Int64Counter(
+		metricsPrefix+"transaction_retry_count",
+		metric.WithDescription("The number of times a read/write transaction was retried, for example because it was aborted."),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		logf(logger, "Error during registering instrument for metric spanner/transaction_retry_count, error: %v", err)
+	}
+	config.transactionRetryCount = transactionRetryCountInstrument
 }
 
 func registerSessionPoolOTMetrics(pool *sessionPool) error {
@@ -216,6 +240,9 @@ func registerSessionPoolOTMetrics(pool *sessionPool) error {
 }
 
 // EnableOpenTelemetryMetrics enables OpenTelemetery metrics
+//
+// The recommended views for these metrics are returned by the Views function
+// of package cloud.google.com/go/spanner/otelviews.
 func EnableOpenTelemetryMetrics() {
 	setOpenTelemetryMetricsFlag(true)
 }
@@ -253,3 +280,10 @@ func recordGFELatencyMetricsOT(ctx context.Context, md metadata.MD, keyMethod st
 	}
 	return nil
 }
+
+func recordTransactionRetryMetricsOT(ctx context.Context, otConfig *openTelemetryConfig) {
+	if !IsOpenTelemetryMetricsEnabled() || otConfig == nil || otConfig.transactionRetryCount == nil {
+		return
+	}
+	otConfig.transactionRetryCount.Add(ctx, 1, metric.WithAttributes(otConfig.attributeMap...))
+}
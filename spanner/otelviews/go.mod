@@ -0,0 +1,15 @@
+module cloud.google.com/go/spanner/otelviews
+
+go 1.20
+
+require go.opentelemetry.io/otel/sdk/metric v1.23.1
+
+require (
+	github.com/go-logr/logr v1.4.1 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	go.opentelemetry.io/otel v1.23.1 // indirect
+	go.opentelemetry.io/otel/metric v1.23.1 // indirect
+	go.opentelemetry.io/otel/sdk v1.23.1 // indirect
+	go.opentelemetry.io/otel/trace v1.23.1 // indirect
+	golang.org/x/sys v0.17.0 // indirect
+)
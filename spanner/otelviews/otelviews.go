@@ -0,0 +1,60 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package otelviews provides the recommended OpenTelemetry views for the
+// metrics of the Cloud Spanner client.
+//
+// It is a module of its own so that the Spanner client doesn't depend on the
+// OpenTelemetry SDK.
+package otelviews
+
+import (
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+const metricsPrefix = "spanner/"
+
+// The bucket boundaries, in milliseconds, of the latency histograms. They are
+// the ones the Spanner client advises for its instruments.
+var (
+	gfeLatencyBuckets = []float64{0.0, 0.01, 0.05, 0.1, 0.3, 0.6, 0.8, 1.0, 2.0, 3.0, 4.0, 5.0, 6.0, 8.0, 10.0, 13.0,
+		16.0, 20.0, 25.0, 30.0, 40.0, 50.0, 65.0, 80.0, 100.0, 130.0, 160.0, 200.0, 250.0,
+		300.0, 400.0, 500.0, 650.0, 800.0, 1000.0, 2000.0, 5000.0, 10000.0, 20000.0, 50000.0,
+		100000.0}
+	sessionAcquireLatencyBuckets = []float64{0.0, 0.1, 0.5, 1.0, 2.0, 5.0, 10.0, 20.0, 50.0, 100.0, 200.0, 500.0,
+		1000.0, 2000.0, 5000.0, 10000.0, 30000.0, 60000.0}
+)
+
+// Views returns the recommended views for the OpenTelemetry metrics of the
+// Spanner client. The views configure the histogram buckets of the latency
+// metrics, also for SDKs that ignore the buckets advised by the instruments.
+// Install them all when creating the MeterProvider that is passed to the
+// client:
+//
+//	provider := sdkmetric.NewMeterProvider(
+//		sdkmetric.WithReader(reader),
+//		sdkmetric.WithView(otelviews.Views()...),
+//	)
+func Views() []sdkmetric.View {
+	return []sdkmetric.View{
+		sdkmetric.NewView(
+			sdkmetric.Instrument{Name: metricsPrefix + "gfe_latency"},
+			sdkmetric.Stream{Aggregation: sdkmetric.AggregationExplicitBucketHistogram{Boundaries: gfeLatencyBuckets}},
+		),
+		sdkmetric.NewView(
+			sdkmetric.Instrument{Name: metricsPrefix + "session_acquire_latency"},
+			sdkmetric.Stream{Aggregation: sdkmetric.AggregationExplicitBucketHistogram{Boundaries: sessionAcquireLatencyBuckets}},
+		),
+	}
+}
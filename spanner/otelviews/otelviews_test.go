@@ -0,0 +1,64 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otelviews
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestViews(t *testing.T) {
+	ctx := context.Background()
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader), sdkmetric.WithView(Views()...))
+	defer mp.Shutdown(ctx)
+
+	meter := mp.Meter("cloud.google.com/go")
+	want := map[string][]float64{
+		"spanner/gfe_latency":             gfeLatencyBuckets,
+		"spanner/session_acquire_latency": sessionAcquireLatencyBuckets,
+		"spanner/other_latency":           nil,
+	}
+	for name := range want {
+		h, err := meter.Float64Histogram(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.Record(ctx, 1)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		data, ok := m.Data.(metricdata.Histogram[float64])
+		if !ok {
+			t.Fatalf("%s: got data of type %T, want a histogram", m.Name, m.Data)
+		}
+		got := data.DataPoints[0].Bounds
+		if want := want[m.Name]; want == nil {
+			if reflect.DeepEqual(got, gfeLatencyBuckets) || reflect.DeepEqual(got, sessionAcquireLatencyBuckets) {
+				t.Errorf("%s: got the bounds of a Spanner latency metric", m.Name)
+			}
+		} else if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got bounds %v, want %v", m.Name, got, want)
+		}
+	}
+}
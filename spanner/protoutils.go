@@ -65,6 +65,14 @@ func floatType() *sppb.Type {
 	return &sppb.Type{Code: sppb.TypeCode_FLOAT64}
 }
 
+func float32Proto(n float32) *proto3.Value {
+	return &proto3.Value{Kind: &proto3.Value_NumberValue{NumberValue: float64(n)}}
+}
+
+func float32Type() *sppb.Type {
+	return &sppb.Type{Code: sppb.TypeCode_FLOAT32}
+}
+
 func numericProto(n *big.Rat) *proto3.Value {
 	return &proto3.Value{Kind: &proto3.Value_StringValue{StringValue: NumericString(n)}}
 }
@@ -117,6 +125,14 @@ func listValueProto(p ...*proto3.Value) *proto3.ListValue {
 	return &proto3.ListValue{Values: p}
 }
 
+func protoMessageType(fqn string) *sppb.Type {
+	return &sppb.Type{Code: sppb.TypeCode_PROTO, ProtoTypeFqn: fqn}
+}
+
+func protoEnumType(fqn string) *sppb.Type {
+	return &sppb.Type{Code: sppb.TypeCode_ENUM, ProtoTypeFqn: fqn}
+}
+
 func listType(t *sppb.Type) *sppb.Type {
 	return &sppb.Type{Code: sppb.TypeCode_ARRAY, ArrayElementType: t}
 }
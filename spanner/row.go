@@ -59,6 +59,8 @@ import (
 //	*[]bool, *[]NullBool - BOOL ARRAY
 //	*float64(not NULL), *NullFloat64 - FLOAT64
 //	*[]float64, *[]NullFloat64 - FLOAT64 ARRAY
+//	*float32(not NULL), *NullFloat32 - FLOAT32
+//	*[]float32, *[]NullFloat32 - FLOAT32 ARRAY
 //	*big.Rat(not NULL), *NullNumeric - NUMERIC
 //	*[]big.Rat, *[]NullNumeric - NUMERIC ARRAY
 //	*time.Time(not NULL), *NullTime - TIMESTAMP
@@ -68,6 +70,10 @@ import (
 //	*[]*some_go_struct, *[]NullRow - STRUCT ARRAY
 //	*NullJSON - JSON
 //	*[]NullJSON - JSON ARRAY
+//	*some_proto_message(not NULL), **some_proto_message - PROTO
+//	*[]*some_proto_message - PROTO ARRAY
+//	*some_proto_enum(not NULL), **some_proto_enum - ENUM
+//	*[]some_proto_enum, *[]*some_proto_enum - ENUM ARRAY
 //	*GenericColumnValue - any Cloud Spanner type
 //
 // For TIMESTAMP columns, the returned time.Time object will be in UTC.
@@ -25,6 +25,7 @@ import (
 	"math"
 	"math/rand"
 	"runtime/debug"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -36,6 +37,7 @@ import (
 	"go.opencensus.io/stats"
 	"go.opencensus.io/tag"
 	octrace "go.opencensus.io/trace"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -43,6 +45,10 @@ import (
 
 const healthCheckIntervalMins = 50
 
+// multiplexSessionRefreshInterval is the age after which the multiplexed
+// session of a pool is replaced by a new one.
+const multiplexSessionRefreshInterval = 7 * 24 * time.Hour
+
 // ActionOnInactiveTransactionKind describes the kind of action taken when there are inactive transactions.
 type ActionOnInactiveTransactionKind int
 
@@ -111,6 +117,16 @@ func (sh *sessionHandle) recycle() {
 		sh.mu.Unlock()
 		return
 	}
+	if sh.session.isMultiplexed {
+		// Multiplexed sessions are shared and never return to the idle list.
+		p := sh.session.pool
+		sh.session = nil
+		sh.mu.Unlock()
+		if p.otConfig != nil {
+			p.recordOTStat(context.Background(), p.otConfig.releasedSessionsCount, 1, true)
+		}
+		return
+	}
 	p := sh.session.pool
 	tracked := sh.trackedSessionHandle
 	s := sh.session
@@ -191,6 +207,15 @@ func (sh *sessionHandle) destroy() {
 	sh.stack = nil
 	sh.mu.Unlock()
 
+	if s.isMultiplexed {
+		// Multiplexed sessions cannot be deleted. Drop the pool's reference to
+		// it so that the next transaction creates a new one.
+		s.pool.removeMultiplexedSession(s)
+		if s.pool.otConfig != nil {
+			s.pool.recordOTStat(context.Background(), s.pool.otConfig.releasedSessionsCount, 1, true)
+		}
+		return
+	}
 	if tracked != nil {
 		p := s.pool
 		p.mu.Lock()
@@ -252,6 +277,9 @@ type session struct {
 	tx transactionID
 	// firstHCDone indicates whether the first health check is done or not.
 	firstHCDone bool
+	// isMultiplexed is true if the session is a multiplexed session. It is
+	// set only once during session's creation.
+	isMultiplexed bool
 }
 
 // isValid returns true if the session is still valid for use.
@@ -491,6 +519,13 @@ type SessionPoolConfig struct {
 	// sessionLabels for the sessions created in the session pool.
 	sessionLabels map[string]string
 
+	// enableMultiplexSession makes read-only transactions use a single
+	// multiplexed session instead of sessions from the pool. It is set if the
+	// environment variable GOOGLE_CLOUD_SPANNER_MULTIPLEXED_SESSIONS is true.
+	//
+	// Defaults to false.
+	enableMultiplexSession bool
+
 	InactiveTransactionRemovalOptions
 }
 
@@ -615,6 +650,16 @@ type sessionPool struct {
 	// This is valid only when ActionOnInactiveTransaction is WarnAndClose or ActionOnInactiveTransaction is Close in InactiveTransactionRemovalOptions.
 	numOfLeakedSessionsRemoved uint64
 
+	// multiplexedSession is the session shared by all read-only transactions
+	// if enableMultiplexSession is set. It is nil until it has been created.
+	multiplexedSession *session
+	// multiplexedSessionCreating is closed when the ongoing creation of a
+	// multiplexed session finishes. It is nil if no creation is in progress.
+	multiplexedSessionCreating chan struct{}
+	// multiplexedSessionCreationError is the error of the last attempt to
+	// create a multiplexed session.
+	multiplexedSessionCreationError error
+
 	otConfig *openTelemetryConfig
 }
 
@@ -693,6 +738,11 @@ func newSessionPool(sc *sessionClient, config SessionPoolConfig) (*sessionPool,
 			return nil, err
 		}
 	}
+	if config.enableMultiplexSession {
+		pool.mu.Lock()
+		pool.startCreatingMultiplexedSessionLocked()
+		pool.mu.Unlock()
+	}
 	pool.recordStat(context.Background(), MaxAllowedSessionsCount, int64(config.MaxOpened))
 
 	err = registerSessionPoolOTMetrics(pool)
@@ -717,10 +767,25 @@ func (p *sessionPool) recordStat(ctx context.Context, m *stats.Int64Measure, n i
 	recordStat(ctx, m, n)
 }
 
-func (p *sessionPool) recordOTStat(ctx context.Context, m metric.Int64Counter, val int64) {
+func (p *sessionPool) recordOTStat(ctx context.Context, m metric.Int64Counter, val int64, isMultiplexed bool) {
 	if m != nil {
-		m.Add(ctx, val, metric.WithAttributes(p.otConfig.attributeMap...))
+		attrs := make([]attribute.KeyValue, 0, len(p.otConfig.attributeMap)+1)
+		attrs = append(attrs, p.otConfig.attributeMap...)
+		attrs = append(attrs, attributeKeyIsMultiplexed.String(strconv.FormatBool(isMultiplexed)))
+		m.Add(ctx, val, metric.WithAttributes(attrs...))
+	}
+}
+
+// recordOTAcquireLatency records the time it took to acquire a session from
+// the pool, starting at start.
+func (p *sessionPool) recordOTAcquireLatency(ctx context.Context, start time.Time, isMultiplexed bool) {
+	if p.otConfig == nil || p.otConfig.sessionAcquireLatency == nil {
+		return
 	}
+	attrs := make([]attribute.KeyValue, 0, len(p.otConfig.attributeMap)+1)
+	attrs = append(attrs, p.otConfig.attributeMap...)
+	attrs = append(attrs, attributeKeyIsMultiplexed.String(strconv.FormatBool(isMultiplexed)))
+	p.otConfig.sessionAcquireLatency.Record(ctx, float64(time.Since(start))/float64(time.Millisecond), metric.WithAttributes(attrs...))
 }
 
 func (p *sessionPool) getRatioOfSessionsInUseLocked() float64 {
@@ -1034,6 +1099,7 @@ func (p *sessionPool) isHealthy(s *session) bool {
 // any, it tries to allocate a new one.
 func (p *sessionPool) take(ctx context.Context) (*sessionHandle, error) {
 	trace.TracePrintf(ctx, nil, "Acquiring a session")
+	start := time.Now()
 	for {
 		var s *session
 
@@ -1064,6 +1130,7 @@ func (p *sessionPool) take(ctx context.Context) (*sessionHandle, error) {
 				continue
 			}
 			p.incNumInUse(ctx)
+			p.recordOTAcquireLatency(ctx, start, false)
 			return p.newSessionHandle(s), nil
 		}
 
@@ -1086,7 +1153,7 @@ func (p *sessionPool) take(ctx context.Context) (*sessionHandle, error) {
 			trace.TracePrintf(ctx, nil, "Context done waiting for session")
 			p.recordStat(ctx, GetSessionTimeoutsCount, 1)
 			if p.otConfig != nil {
-				p.recordOTStat(ctx, p.otConfig.getSessionTimeoutsCount, 1)
+				p.recordOTStat(ctx, p.otConfig.getSessionTimeoutsCount, 1, false)
 			}
 			p.mu.Lock()
 			p.numWaiters--
@@ -1106,6 +1173,101 @@ func (p *sessionPool) take(ctx context.Context) (*sessionHandle, error) {
 	}
 }
 
+// takeMultiplexed returns a handle to the multiplexed session of the pool,
+// creating the session if it does not exist yet. It falls back to take if
+// multiplexed sessions are not enabled.
+//
+// The returned handle may be recycled or destroyed like any other handle, but
+// the session is shared with other transactions and never returns to the
+// idle list.
+func (p *sessionPool) takeMultiplexed(ctx context.Context) (*sessionHandle, error) {
+	if !p.enableMultiplexSession {
+		return p.take(ctx)
+	}
+	trace.TracePrintf(ctx, nil, "Acquiring a multiplexed session")
+	start := time.Now()
+	for {
+		p.mu.Lock()
+		if !p.valid {
+			p.mu.Unlock()
+			return nil, errInvalidSessionPool
+		}
+		if s := p.multiplexedSession; s != nil {
+			if time.Since(s.createTime) > multiplexSessionRefreshInterval {
+				// Keep using the current session until its replacement is
+				// ready.
+				p.startCreatingMultiplexedSessionLocked()
+			}
+			p.mu.Unlock()
+			trace.TracePrintf(ctx, map[string]interface{}{"sessionID": s.getID()},
+				"Acquired multiplexed session")
+			if p.otConfig != nil {
+				p.recordOTStat(ctx, p.otConfig.acquiredSessionsCount, 1, true)
+			}
+			p.recordOTAcquireLatency(ctx, start, true)
+			return &sessionHandle{session: s, checkoutTime: time.Now(), lastUseTime: time.Now()}, nil
+		}
+		creating := p.startCreatingMultiplexedSessionLocked()
+		p.mu.Unlock()
+		trace.TracePrintf(ctx, nil, "Waiting for multiplexed session to become available")
+		select {
+		case <-ctx.Done():
+			trace.TracePrintf(ctx, nil, "Context done waiting for multiplexed session")
+			p.recordStat(ctx, GetSessionTimeoutsCount, 1)
+			if p.otConfig != nil {
+				p.recordOTStat(ctx, p.otConfig.getSessionTimeoutsCount, 1, true)
+			}
+			return nil, p.errGetSessionTimeout(ctx)
+		case <-creating:
+			p.mu.Lock()
+			err := p.multiplexedSessionCreationError
+			p.mu.Unlock()
+			if err != nil {
+				trace.TracePrintf(ctx, nil, "Error creating multiplexed session: %v", err)
+				return nil, err
+			}
+		}
+	}
+}
+
+// startCreatingMultiplexedSessionLocked starts the creation of a new
+// multiplexed session in the background, unless one is already being created.
+// It returns a channel that is closed when the creation finishes.
+//
+// p.mu must be held.
+func (p *sessionPool) startCreatingMultiplexedSessionLocked() chan struct{} {
+	if p.multiplexedSessionCreating != nil {
+		return p.multiplexedSessionCreating
+	}
+	done := make(chan struct{})
+	p.multiplexedSessionCreating = done
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), p.sc.batchTimeout)
+		defer cancel()
+		s, err := p.sc.createMultiplexedSession(ctx)
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if err == nil {
+			s.pool = p
+			p.multiplexedSession = s
+		}
+		p.multiplexedSessionCreationError = err
+		p.multiplexedSessionCreating = nil
+		close(done)
+	}()
+	return done
+}
+
+// removeMultiplexedSession removes s as the multiplexed session of the pool,
+// for example because Spanner no longer knows the session.
+func (p *sessionPool) removeMultiplexedSession(s *session) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.multiplexedSession == s {
+		p.multiplexedSession = nil
+	}
+}
+
 // recycle puts session s back to the session pool's idle list, it returns true
 // if the session pool successfully recycles session s.
 func (p *sessionPool) recycle(s *session) bool {
@@ -1178,7 +1340,7 @@ func (p *sessionPool) incNumInUseLocked(ctx context.Context) {
 	p.recordStat(ctx, SessionsCount, int64(p.numInUse), tagNumInUseSessions)
 	p.recordStat(ctx, AcquiredSessionsCount, 1)
 	if p.otConfig != nil {
-		p.recordOTStat(ctx, p.otConfig.acquiredSessionsCount, 1)
+		p.recordOTStat(ctx, p.otConfig.acquiredSessionsCount, 1, false)
 	}
 	if p.numInUse > p.maxNumInUse {
 		p.maxNumInUse = p.numInUse
@@ -1191,7 +1353,7 @@ func (p *sessionPool) decNumInUseLocked(ctx context.Context) {
 	p.recordStat(ctx, SessionsCount, int64(p.numInUse), tagNumInUseSessions)
 	p.recordStat(ctx, ReleasedSessionsCount, 1)
 	if p.otConfig != nil {
-		p.recordOTStat(ctx, p.otConfig.releasedSessionsCount, 1)
+		p.recordOTStat(ctx, p.otConfig.releasedSessionsCount, 1, false)
 	}
 }
 
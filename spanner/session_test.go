@@ -189,6 +189,85 @@ func TestLIFOSessionOrder(t *testing.T) {
 }
 
 // TestTakeFromIdleList tests taking sessions from session pool's idle list.
+func TestMultiplexedSession(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	server, client, teardown := setupMockedTestServerWithConfig(t, ClientConfig{
+		SessionPoolConfig: SessionPoolConfig{
+			MinOpened:              0,
+			enableMultiplexSession: true,
+		},
+	})
+	defer teardown()
+	sp := client.idleSessions
+
+	for i := 0; i < 3; i++ {
+		iter := client.Single().Query(ctx, NewStatement(SelectFooFromBar))
+		if err := iter.Do(func(*Row) error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ro := client.ReadOnlyTransaction()
+	if err := ro.Query(ctx, NewStatement(SelectFooFromBar)).Do(func(*Row) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	ro.Close()
+
+	var sessionIDs []string
+	for _, req := range drainRequestsFromServer(server.TestSpanner) {
+		switch req := req.(type) {
+		case *sppb.CreateSessionRequest:
+			if !req.Session.GetMultiplexed() {
+				t.Errorf("CreateSessionRequest for a regular session: %v", req)
+			}
+		case *sppb.BatchCreateSessionsRequest:
+			t.Errorf("unexpected BatchCreateSessionsRequest: %v", req)
+		case *sppb.ExecuteSqlRequest:
+			sessionIDs = append(sessionIDs, req.Session)
+		}
+	}
+	if len(sessionIDs) != 4 {
+		t.Fatalf("got %d queries, want 4", len(sessionIDs))
+	}
+	for _, id := range sessionIDs {
+		if id != sessionIDs[0] {
+			t.Fatalf("queries used sessions %v, want one multiplexed session", sessionIDs)
+		}
+	}
+	if got, want := server.TestSpanner.TotalSessionsCreated(), uint(1); got != want {
+		t.Errorf("sessions created mismatch\n Got: %v\nWant: %v", got, want)
+	}
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	if sp.numInUse != 0 || sp.numOpened != 0 {
+		t.Errorf("session pool has %d sessions in use and %d opened, want 0 and 0", sp.numInUse, sp.numOpened)
+	}
+}
+
+func TestMultiplexedSession_SessionNotFound(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	server, client, teardown := setupMockedTestServerWithConfig(t, ClientConfig{
+		SessionPoolConfig: SessionPoolConfig{
+			MinOpened:              0,
+			enableMultiplexSession: true,
+		},
+	})
+	defer teardown()
+
+	server.TestSpanner.PutExecutionTime(MethodExecuteStreamingSql,
+		SimulatedExecutionTime{Errors: []error{newSessionNotFoundError("projects/p/instances/i/databases/d/sessions/s")}},
+	)
+	// The query is retried on a new multiplexed session.
+	iter := client.Single().Query(ctx, NewStatement(SelectFooFromBar))
+	if err := iter.Do(func(*Row) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := server.TestSpanner.TotalSessionsCreated(), uint(2); got != want {
+		t.Errorf("sessions created mismatch\n Got: %v\nWant: %v", got, want)
+	}
+}
+
 func TestTakeFromIdleList(t *testing.T) {
 	t.Parallel()
 	ctx := context.Background()
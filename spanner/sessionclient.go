@@ -129,6 +129,17 @@ func (sc *sessionClient) close() error {
 // createSession creates one session for the database of the sessionClient. The
 // session is created using one synchronous RPC.
 func (sc *sessionClient) createSession(ctx context.Context) (*session, error) {
+	return sc.executeCreateSession(ctx, false)
+}
+
+// createMultiplexedSession creates one multiplexed session for the database of
+// the sessionClient. A multiplexed session can be used by any number of
+// read-only transactions at the same time.
+func (sc *sessionClient) createMultiplexedSession(ctx context.Context) (*session, error) {
+	return sc.executeCreateSession(ctx, true)
+}
+
+func (sc *sessionClient) executeCreateSession(ctx context.Context, multiplexed bool) (*session, error) {
 	sc.mu.Lock()
 	if sc.closed {
 		sc.mu.Unlock()
@@ -143,7 +154,7 @@ func (sc *sessionClient) createSession(ctx context.Context) (*session, error) {
 	var md metadata.MD
 	sid, err := client.CreateSession(contextWithOutgoingMetadata(ctx, sc.md, sc.disableRouteToLeader), &sppb.CreateSessionRequest{
 		Database: sc.database,
-		Session:  &sppb.Session{Labels: sc.sessionLabels, CreatorRole: sc.databaseRole},
+		Session:  &sppb.Session{Labels: sc.sessionLabels, CreatorRole: sc.databaseRole, Multiplexed: multiplexed},
 	}, gax.WithGRPCOptions(grpc.Header(&md)))
 
 	if getGFELatencyMetricsFlag() && md != nil {
@@ -171,7 +182,7 @@ func (sc *sessionClient) createSession(ctx context.Context) (*session, error) {
 	if err != nil {
 		return nil, ToSpannerError(err)
 	}
-	return &session{valid: true, client: client, id: sid.Name, createTime: time.Now(), md: sc.md, logger: sc.logger}, nil
+	return &session{valid: true, client: client, id: sid.Name, createTime: time.Now(), md: sc.md, logger: sc.logger, isMultiplexed: multiplexed}, nil
 }
 
 // batchCreateSessions creates a batch of sessions for the database of the
@@ -17,11 +17,14 @@ limitations under the License.
 package spanner
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
 	proto3 "github.com/golang/protobuf/ptypes/struct"
 	structpb "github.com/golang/protobuf/ptypes/struct"
+	"github.com/googleapis/gax-go/v2"
 	"google.golang.org/grpc/codes"
 )
 
@@ -40,6 +43,13 @@ import (
 type Statement struct {
 	SQL    string
 	Params map[string]interface{}
+
+	// timeout is the maximum execution time of the statement. See
+	// WithTimeout.
+	timeout time.Duration
+	// retryBackoff is the backoff used for retrying the statement. See
+	// WithRetryBackoff.
+	retryBackoff *gax.Backoff
 }
 
 // NewStatement returns a Statement with the given SQL and an empty Params map.
@@ -47,6 +57,52 @@ func NewStatement(sql string) Statement {
 	return Statement{SQL: sql, Params: map[string]interface{}{}}
 }
 
+// WithTimeout returns a copy of the statement that must be executed within
+// timeout. The timeout covers the whole execution of the statement by Query
+// or Update, including retries and, for queries, reading all the results. It
+// is applied in addition to the deadline of the context that is passed to
+// Query or Update, so a statement never runs longer than either of them.
+//
+// For example, to make sure that a single slow query cannot use up the
+// deadline of a request handler:
+//
+//	iter := client.Single().Query(ctx, spanner.NewStatement("SELECT ...").WithTimeout(2*time.Second))
+func (s Statement) WithTimeout(timeout time.Duration) Statement {
+	s.timeout = timeout
+	return s
+}
+
+// WithRetryBackoff returns a copy of the statement that is retried with the
+// given backoff settings instead of the client defaults when Spanner returns
+// a retryable error. For queries, bo is used when a stream of results is
+// resumed; for DML statements, it is used when the ExecuteSql RPC is retried.
+func (s Statement) WithRetryBackoff(bo gax.Backoff) Statement {
+	s.retryBackoff = &bo
+	return s
+}
+
+// withTimeout returns a context with the timeout of the statement applied, and
+// its cancel function.
+func (s *Statement) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, s.timeout)
+}
+
+// backoff returns a new backoff for retrying the statement, or false if the
+// statement uses the default backoff.
+func (s *Statement) backoff() (gax.Backoff, bool) {
+	if s.retryBackoff == nil {
+		return gax.Backoff{}, false
+	}
+	return gax.Backoff{
+		Initial:    s.retryBackoff.Initial,
+		Max:        s.retryBackoff.Max,
+		Multiplier: s.retryBackoff.Multiplier,
+	}, true
+}
+
 // convertParams converts a statement's parameters into proto Param and
 // ParamTypes.
 func (s *Statement) convertParams() (*structpb.Struct, map[string]*sppb.Type, error) {
@@ -5,16 +5,19 @@ go 1.20
 replace (
 	cloud.google.com/go => ../../../../
 	cloud.google.com/go/spanner => ../../..
+	cloud.google.com/go/spanner/otelviews => ../../../otelviews
 )
 
 require (
 	cloud.google.com/go v0.112.0
 	cloud.google.com/go/spanner v1.57.0
+	cloud.google.com/go/spanner/otelviews v0.0.0-00010101000000-000000000000
 	github.com/golang/protobuf v1.5.3
 	go.opentelemetry.io/otel v1.23.1
 	go.opentelemetry.io/otel/sdk v1.23.1
 	go.opentelemetry.io/otel/sdk/metric v1.23.1
 	google.golang.org/api v0.166.0
+	google.golang.org/grpc v1.61.1
 )
 
 require (
@@ -55,6 +58,5 @@ require (
 	google.golang.org/genproto v0.0.0-20240213162025-012b6fc9bca9 // indirect
 	google.golang.org/genproto/googleapis/api v0.0.0-20240221002015-b0ce06bbee7c // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20240221002015-b0ce06bbee7c // indirect
-	google.golang.org/grpc v1.61.1 // indirect
 	google.golang.org/protobuf v1.32.0 // indirect
 )
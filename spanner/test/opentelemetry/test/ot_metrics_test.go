@@ -21,6 +21,7 @@ package test
 import (
 	"context"
 	"errors"
+	"strconv"
 	"testing"
 	"time"
 
@@ -28,11 +29,15 @@ import (
 	"cloud.google.com/go/spanner/apiv1/spannerpb"
 	"cloud.google.com/go/spanner/internal"
 	stestutil "cloud.google.com/go/spanner/internal/testutil"
+	"cloud.google.com/go/spanner/otelviews"
 	structpb "github.com/golang/protobuf/ptypes/struct"
 	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 	"go.opentelemetry.io/otel/sdk/metric/metricdata/metricdatatest"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestOTMetrics_InstrumentationScope(t *testing.T) {
@@ -134,7 +139,7 @@ func TestOTMetrics_SessionPool(t *testing.T) {
 				Data: metricdata.Sum[int64]{
 					DataPoints: []metricdata.DataPoint[int64]{
 						{
-							Attributes: attribute.NewSet(getAttributes(client.ClientID())...),
+							Attributes: attribute.NewSet(getSessionPoolCounterAttributes(client.ClientID(), false)...),
 							Value:      1,
 						},
 					},
@@ -152,7 +157,7 @@ func TestOTMetrics_SessionPool(t *testing.T) {
 				Data: metricdata.Sum[int64]{
 					DataPoints: []metricdata.DataPoint[int64]{
 						{
-							Attributes: attribute.NewSet(getAttributes(client.ClientID())...),
+							Attributes: attribute.NewSet(getSessionPoolCounterAttributes(client.ClientID(), false)...),
 							Value:      1,
 						},
 					},
@@ -242,7 +247,7 @@ func TestOTMetrics_SessionPool_GetSessionTimeoutsCount(t *testing.T) {
 		Data: metricdata.Sum[int64]{
 			DataPoints: []metricdata.DataPoint[int64]{
 				{
-					Attributes: attribute.NewSet(getAttributes(client.ClientID())...),
+					Attributes: attribute.NewSet(getSessionPoolCounterAttributes(client.ClientID(), false)...),
 					Value:      1,
 				},
 			},
@@ -352,6 +357,113 @@ func TestOTMetrics_GFELatency(t *testing.T) {
 	metricdatatest.AssertEqual(t, expectedMetricData, resourceMetrics.ScopeMetrics[0].Metrics[idx1], metricdatatest.IgnoreTimestamp(), metricdatatest.IgnoreExemplars())
 }
 
+func TestOTMetrics_SessionAcquireLatency(t *testing.T) {
+	ctx := context.Background()
+	te := newOpenTelemetryTestExporter(false, false)
+	t.Cleanup(func() {
+		te.Unregister(ctx)
+	})
+	spanner.EnableOpenTelemetryMetrics()
+	_, client, teardown := setupMockedTestServerWithConfig(t, spanner.ClientConfig{OpenTelemetryMeterProvider: te.mp})
+	defer teardown()
+
+	client.Single().ReadRow(context.Background(), "Users", spanner.Key{"alice"}, []string{"email"})
+
+	resourceMetrics, err := te.metrics(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metricName := "spanner/session_acquire_latency"
+	idx := getMetricIndex(resourceMetrics.ScopeMetrics[0].Metrics, metricName)
+	if idx == -1 {
+		t.Fatalf("Metric Name %s not found", metricName)
+	}
+	m := resourceMetrics.ScopeMetrics[0].Metrics[idx]
+	if got, want := m.Unit, "ms"; got != want {
+		t.Fatalf("Unit mismatch, got %v, want %v", got, want)
+	}
+	data, ok := m.Data.(metricdata.Histogram[float64])
+	if !ok {
+		t.Fatal("session acquire latency metric data not of type metricdata.Histogram[float64]")
+	}
+	if got, want := data.DataPoints[0].Count, uint64(1); got != want {
+		t.Fatalf("Count mismatch, got %d, want %d", got, want)
+	}
+	metricdatatest.AssertHasAttributes[metricdata.HistogramDataPoint[float64]](t, data.DataPoints[0], getSessionPoolCounterAttributes(client.ClientID(), false)...)
+}
+
+func TestOTMetrics_TransactionRetryCount(t *testing.T) {
+	ctx := context.Background()
+	te := newOpenTelemetryTestExporter(false, false)
+	t.Cleanup(func() {
+		te.Unregister(ctx)
+	})
+	spanner.EnableOpenTelemetryMetrics()
+	server, client, teardown := setupMockedTestServerWithConfig(t, spanner.ClientConfig{OpenTelemetryMeterProvider: te.mp})
+	defer teardown()
+
+	server.TestSpanner.PutExecutionTime(stestutil.MethodCommitTransaction,
+		stestutil.SimulatedExecutionTime{
+			Errors: []error{status.Error(codes.Aborted, "Transaction aborted")},
+		})
+	if _, err := client.ReadWriteTransaction(ctx, func(ctx context.Context, tx *spanner.ReadWriteTransaction) error {
+		return tx.BufferWrite([]*spanner.Mutation{spanner.Insert("Users", []string{"email"}, []interface{}{"alice@example.com"})})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedMetricData := metricdata.Metrics{
+		Name:        "spanner/transaction_retry_count",
+		Description: "The number of times a read/write transaction was retried, for example because it was aborted.",
+		Unit:        "1",
+		Data: metricdata.Sum[int64]{
+			DataPoints: []metricdata.DataPoint[int64]{
+				{
+					Attributes: attribute.NewSet(getAttributes(client.ClientID())...),
+					Value:      1,
+				},
+			},
+			Temporality: metricdata.CumulativeTemporality,
+			IsMonotonic: true,
+		},
+	}
+	validateOTMetric(ctx, t, te, expectedMetricData.Name, expectedMetricData)
+}
+
+func TestOTMetrics_OpenTelemetryViews(t *testing.T) {
+	ctx := context.Background()
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(reader),
+		sdkmetric.WithView(otelviews.Views()...),
+	)
+	t.Cleanup(func() {
+		mp.Shutdown(ctx)
+	})
+	spanner.EnableOpenTelemetryMetrics()
+	_, client, teardown := setupMockedTestServerWithConfig(t, spanner.ClientConfig{OpenTelemetryMeterProvider: mp})
+	defer teardown()
+
+	client.Single().ReadRow(context.Background(), "Users", spanner.Key{"alice"}, []string{"email"})
+
+	rm := metricdata.ResourceMetrics{}
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatal(err)
+	}
+	metricName := "spanner/session_acquire_latency"
+	idx := getMetricIndex(rm.ScopeMetrics[0].Metrics, metricName)
+	if idx == -1 {
+		t.Fatalf("Metric Name %s not found", metricName)
+	}
+	data, ok := rm.ScopeMetrics[0].Metrics[idx].Data.(metricdata.Histogram[float64])
+	if !ok {
+		t.Fatal("session acquire latency metric data not of type metricdata.Histogram[float64]")
+	}
+	if got := len(data.DataPoints[0].Bounds); got == 0 {
+		t.Fatal("session acquire latency metric has no bucket boundaries")
+	}
+}
+
 func getMetricIndex(metrics []metricdata.Metrics, metricName string) int64 {
 	for i, metric := range metrics {
 		if metric.Name == metricName {
@@ -370,6 +482,12 @@ func getAttributes(clientID string) []attribute.KeyValue {
 	}
 }
 
+// getSessionPoolCounterAttributes returns the attributes of the counters
+// recorded when sessions are acquired and released.
+func getSessionPoolCounterAttributes(clientID string, isMultiplexed bool) []attribute.KeyValue {
+	return append(getAttributes(clientID), attribute.Key("is_multiplexed").String(strconv.FormatBool(isMultiplexed)))
+}
+
 func validateOTMetric(ctx context.Context, t *testing.T, te *openTelemetryTestExporter, metricName string, expectedMetric metricdata.Metrics) {
 	resourceMetrics, err := te.metrics(ctx)
 	if err != nil {
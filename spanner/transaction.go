@@ -26,6 +26,7 @@ import (
 	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
 	"github.com/golang/protobuf/proto"
 	"github.com/googleapis/gax-go/v2"
+	"github.com/googleapis/gax-go/v2/apierror"
 	"google.golang.org/api/iterator"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -117,6 +118,54 @@ type TransactionOptions struct {
 	ReadLockMode sppb.TransactionOptions_ReadWrite_ReadLockMode
 }
 
+// AbortedTransactionInfo describes an attempt of a read/write transaction that
+// was aborted by Spanner.
+type AbortedTransactionInfo struct {
+	// Attempt is the number of the attempt that was aborted. The first
+	// attempt of a transaction is 1.
+	Attempt int
+
+	// TransactionTag is the tag of the transaction, if any.
+	TransactionTag string
+
+	// Reason is the description of the abort returned by Spanner. If the
+	// transaction was aborted because of a lock conflict, it describes the
+	// conflicting lock.
+	Reason string
+
+	// ErrorReason and ErrorMetadata are the reason and metadata of the
+	// google.rpc.ErrorInfo detail of the error, if any.
+	ErrorReason   string
+	ErrorMetadata map[string]string
+
+	// RetryDelay is the delay that Spanner requested before the transaction
+	// is retried. It is zero if Spanner did not request a delay.
+	RetryDelay time.Duration
+
+	// Err is the Aborted error that was returned by Spanner.
+	Err error
+}
+
+// newAbortedTransactionInfo returns the AbortedTransactionInfo for attempt of
+// a transaction that was aborted with err.
+func newAbortedTransactionInfo(attempt int, transactionTag string, err error) AbortedTransactionInfo {
+	info := AbortedTransactionInfo{
+		Attempt:        attempt,
+		TransactionTag: transactionTag,
+		Reason:         ErrDesc(err),
+		Err:            err,
+	}
+	var apiErr *apierror.APIError
+	if errorAs(err, &apiErr) {
+		info.ErrorReason = apiErr.Reason()
+		info.ErrorMetadata = apiErr.Metadata()
+	}
+	if delay, ok := ExtractRetryDelay(err); ok {
+		info.RetryDelay = delay
+	}
+	return info
+}
+
 // merge combines two TransactionOptions that the input parameter will have higher
 // order of precedence.
 func (to TransactionOptions) merge(opts TransactionOptions) TransactionOptions {
@@ -143,6 +192,13 @@ func errSessionClosed(sh *sessionHandle) error {
 		"session is already recycled / destroyed: session_id = %q, rpc_client = %v", sh.getID(), sh.getClient())
 }
 
+// errDataBoostNotPartitioned returns error for enabling Data Boost on a
+// request that does not execute a partition.
+func errDataBoostNotPartitioned() error {
+	return spannerErrorf(codes.InvalidArgument,
+		"DataBoostEnabled can only be used for partitioned reads and queries, use BatchReadOnlyTransaction.PartitionRead or PartitionQuery instead")
+}
+
 // Read returns a RowIterator for reading multiple rows from the database.
 func (t *txReadOnly) Read(ctx context.Context, table string, keys KeySet, columns []string) *RowIterator {
 	return t.ReadWithOptions(ctx, table, keys, columns, nil)
@@ -171,7 +227,8 @@ type ReadOptions struct {
 	RequestTag string
 
 	// If this is for a partitioned read and DataBoostEnabled field is set to true, the request will be executed
-	// via Spanner independent compute resources. Setting this option for regular read operations has no effect.
+	// via Spanner independent compute resources. Setting this option for regular read operations returns an
+	// InvalidArgument error.
 	DataBoostEnabled bool
 
 	// ReadOptions option used to set the DirectedReadOptions for all ReadRequests which indicate
@@ -211,6 +268,14 @@ func (ro ReadOptions) merge(opts ReadOptions) ReadOptions {
 	return merged
 }
 
+// ReadWithRequestOptions returns a RowIterator for reading multiple rows from
+// the database, using the priority and request tag set by opts.
+func (t *txReadOnly) ReadWithRequestOptions(ctx context.Context, table string, keys KeySet, columns []string, opts ...RequestOption) *RowIterator {
+	ro := newRequestOptions(opts)
+	readOpts := t.ro.merge(ReadOptions{Priority: ro.priority, RequestTag: ro.requestTag})
+	return t.ReadWithOptions(ctx, table, keys, columns, &readOpts)
+}
+
 // ReadWithOptions returns a RowIterator for reading multiple rows from the
 // database. Pass a ReadOptions to modify the read operation.
 func (t *txReadOnly) ReadWithOptions(ctx context.Context, table string, keys KeySet, columns []string, opts *ReadOptions) (ri *RowIterator) {
@@ -221,6 +286,9 @@ func (t *txReadOnly) ReadWithOptions(ctx context.Context, table string, keys Key
 		ts  *sppb.TransactionSelector
 		err error
 	)
+	if t.ro.DataBoostEnabled || (opts != nil && opts.DataBoostEnabled) {
+		return &RowIterator{err: errDataBoostNotPartitioned()}
+	}
 	kset, err := keys.keySetProto()
 	if err != nil {
 		return &RowIterator{err: err}
@@ -395,7 +463,8 @@ type QueryOptions struct {
 	RequestTag string
 
 	// If this is for a partitioned query and DataBoostEnabled field is set to true, the request will be executed
-	// via Spanner independent compute resources. Setting this option for regular query operations has no effect.
+	// via Spanner independent compute resources. Setting this option for regular query operations returns an
+	// InvalidArgument error.
 	DataBoostEnabled bool
 
 	// QueryOptions option used to set the DirectedReadOptions for all ExecuteSqlRequests which indicate
@@ -471,6 +540,18 @@ func (t *txReadOnly) QueryWithOptions(ctx context.Context, statement Statement,
 	return t.query(ctx, statement, t.qo.merge(opts))
 }
 
+// QueryWithRequestOptions executes a query against the database, using the
+// priority and request tag set by opts. It returns a RowIterator for
+// retrieving the resulting rows.
+//
+// For example:
+//
+//	iter := txn.QueryWithRequestOptions(ctx, stmt, spanner.RequestPriority(sppb.RequestOptions_PRIORITY_LOW), spanner.RequestTag("app=concert,env=dev"))
+func (t *txReadOnly) QueryWithRequestOptions(ctx context.Context, statement Statement, opts ...RequestOption) *RowIterator {
+	ro := newRequestOptions(opts)
+	return t.QueryWithOptions(ctx, statement, QueryOptions{Priority: ro.priority, RequestTag: ro.requestTag})
+}
+
 // QueryWithStats executes a SQL statement against the database. It returns
 // a RowIterator for retrieving the resulting rows. The RowIterator will also
 // be populated with a query plan and execution statistics.
@@ -512,6 +593,22 @@ func (t *txReadOnly) AnalyzeQuery(ctx context.Context, statement Statement) (*sp
 func (t *txReadOnly) query(ctx context.Context, statement Statement, options QueryOptions) (ri *RowIterator) {
 	ctx = trace.StartSpan(ctx, "cloud.google.com/go/spanner.Query")
 	defer func() { trace.EndSpan(ctx, ri.err) }()
+	ctx, cancel := statement.withTimeout(ctx)
+	defer func() {
+		if ri.err != nil {
+			cancel()
+			return
+		}
+		// Release the timeout of the statement when the iterator is stopped.
+		stop := ri.cancel
+		ri.cancel = func() {
+			stop()
+			cancel()
+		}
+		if bo, ok := statement.backoff(); ok {
+			ri.streamd.backoff = bo
+		}
+	}()
 	req, sh, err := t.prepareExecuteSQL(ctx, statement, options)
 	if err != nil {
 		return &RowIterator{err: err}
@@ -558,6 +655,9 @@ func (t *txReadOnly) query(ctx context.Context, statement Statement, options Que
 }
 
 func (t *txReadOnly) prepareExecuteSQL(ctx context.Context, stmt Statement, options QueryOptions) (*sppb.ExecuteSqlRequest, *sessionHandle, error) {
+	if options.DataBoostEnabled {
+		return nil, nil, errDataBoostNotPartitioned()
+	}
 	sh, ts, err := t.acquire(ctx)
 	if err != nil {
 		return nil, nil, err
@@ -710,7 +810,7 @@ func (t *ReadOnlyTransaction) begin(ctx context.Context) error {
 	}()
 	// Retry the BeginTransaction call if a 'Session not found' is returned.
 	for {
-		sh, err = t.sp.take(ctx)
+		sh, err = t.sp.takeMultiplexed(ctx)
 		if err != nil {
 			return err
 		}
@@ -800,7 +900,7 @@ func (t *ReadOnlyTransaction) acquireSingleUse(ctx context.Context) (*sessionHan
 				},
 			},
 		}
-		sh, err := t.sp.take(ctx)
+		sh, err := t.sp.takeMultiplexed(ctx)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -1107,9 +1207,19 @@ func (t *ReadWriteTransaction) UpdateWithOptions(ctx context.Context, stmt State
 	return t.update(ctx, stmt, t.qo.merge(opts))
 }
 
+// UpdateWithRequestOptions executes a DML statement against the database,
+// using the priority and request tag set by opts. It returns the number of
+// affected rows.
+func (t *ReadWriteTransaction) UpdateWithRequestOptions(ctx context.Context, stmt Statement, opts ...RequestOption) (rowCount int64, err error) {
+	ro := newRequestOptions(opts)
+	return t.UpdateWithOptions(ctx, stmt, QueryOptions{Priority: ro.priority, RequestTag: ro.requestTag})
+}
+
 func (t *ReadWriteTransaction) update(ctx context.Context, stmt Statement, opts QueryOptions) (rowCount int64, err error) {
 	ctx = trace.StartSpan(ctx, "cloud.google.com/go/spanner.Update")
 	defer func() { trace.EndSpan(ctx, err) }()
+	ctx, cancel := stmt.withTimeout(ctx)
+	defer cancel()
 	req, sh, err := t.prepareExecuteSQL(ctx, stmt, opts)
 	if err != nil {
 		return 0, err
@@ -1121,7 +1231,13 @@ func (t *ReadWriteTransaction) update(ctx context.Context, stmt Statement, opts
 
 	sh.updateLastUseTime()
 	var md metadata.MD
-	resultSet, err := sh.getClient().ExecuteSql(contextWithOutgoingMetadata(ctx, sh.getMetadata(), t.disableRouteToLeader), req, gax.WithGRPCOptions(grpc.Header(&md)))
+	callOpts := []gax.CallOption{gax.WithGRPCOptions(grpc.Header(&md))}
+	if bo, ok := stmt.backoff(); ok {
+		callOpts = append(callOpts, gax.WithRetry(func() gax.Retryer {
+			return onCodes(bo, codes.Unavailable)
+		}))
+	}
+	resultSet, err := sh.getClient().ExecuteSql(contextWithOutgoingMetadata(ctx, sh.getMetadata(), t.disableRouteToLeader), req, callOpts...)
 
 	if getGFELatencyMetricsFlag() && md != nil && t.ct != nil {
 		if err := createContextAndCaptureGFELatencyMetrics(ctx, t.ct, md, "update"); err != nil {
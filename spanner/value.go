@@ -27,6 +27,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"cloud.google.com/go/civil"
@@ -36,6 +37,7 @@ import (
 	proto3 "github.com/golang/protobuf/ptypes/struct"
 	jsoniter "github.com/json-iterator/go"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 const (
@@ -176,6 +178,72 @@ type Decoder interface {
 	DecodeSpanner(input interface{}) error
 }
 
+// codec holds the encode and decode functions registered for a Go type with
+// RegisterCodec.
+type codec struct {
+	encode func(v interface{}) (interface{}, error)
+	decode func(input interface{}, ptr interface{}) error
+}
+
+// codecs maps a reflect.Type to the *codec registered for it.
+var codecs sync.Map
+
+// RegisterCodec registers functions that encode and decode values of type T.
+// It is meant for types that cannot implement Encoder and Decoder, such as
+// types defined in other packages like uuid.UUID or decimal types.
+//
+// encode converts a T to a value of a type that is supported by Spanner,
+// like the EncodeSpanner method of an Encoder. decode converts a value read
+// from Spanner to a T, like the DecodeSpanner method of a Decoder. Once
+// registered, values of type T can be used as Statement parameters and in
+// mutations, and can be decoded with Row.Column and Row.ToStruct. Types that
+// implement Encoder or Decoder use these interfaces instead.
+//
+// For example:
+//
+//	spanner.RegisterCodec(
+//	    func(id uuid.UUID) (interface{}, error) {
+//	        return id.String(), nil
+//	    },
+//	    func(val interface{}) (uuid.UUID, error) {
+//	        s, ok := val.(string)
+//	        if !ok {
+//	            return uuid.Nil, fmt.Errorf("failed to decode uuid: %v", val)
+//	        }
+//	        return uuid.Parse(s)
+//	    })
+//
+// RegisterCodec is typically called from an init function. Registering a
+// codec for a type replaces any codec previously registered for that type.
+func RegisterCodec[T any](encode func(T) (interface{}, error), decode func(input interface{}) (T, error)) {
+	c := &codec{
+		encode: func(v interface{}) (interface{}, error) {
+			return encode(v.(T))
+		},
+		decode: func(input interface{}, ptr interface{}) error {
+			v, err := decode(input)
+			if err != nil {
+				return err
+			}
+			*ptr.(*T) = v
+			return nil
+		},
+	}
+	codecs.Store(reflect.TypeOf((*T)(nil)).Elem(), c)
+}
+
+// lookupCodec returns the codec registered for values of type t.
+func lookupCodec(t reflect.Type) (*codec, bool) {
+	if t == nil {
+		return nil, false
+	}
+	c, ok := codecs.Load(t)
+	if !ok {
+		return nil, false
+	}
+	return c.(*codec), true
+}
+
 // NullableValue is the interface implemented by all null value wrapper types.
 type NullableValue interface {
 	// IsNull returns true if the underlying database value is null.
@@ -507,6 +575,86 @@ func (n NullBool) GormDataType() string {
 	return "BOOL"
 }
 
+// NullFloat32 represents a Cloud Spanner FLOAT32 that may be NULL.
+type NullFloat32 struct {
+	Float32 float32 // Float32 contains the value when it is non-NULL, and zero when NULL.
+	Valid   bool    // Valid is true if Float32 is not NULL.
+}
+
+// IsNull implements NullableValue.IsNull for NullFloat32.
+func (n NullFloat32) IsNull() bool {
+	return !n.Valid
+}
+
+// String implements Stringer.String for NullFloat32
+func (n NullFloat32) String() string {
+	if !n.Valid {
+		return nullString
+	}
+	return fmt.Sprintf("%v", n.Float32)
+}
+
+// MarshalJSON implements json.Marshaler.MarshalJSON for NullFloat32.
+func (n NullFloat32) MarshalJSON() ([]byte, error) {
+	return nulljson(n.Valid, n.Float32)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.UnmarshalJSON for NullFloat32.
+func (n *NullFloat32) UnmarshalJSON(payload []byte) error {
+	if payload == nil {
+		return fmt.Errorf("payload should not be nil")
+	}
+	if bytes.Equal(payload, jsonNullBytes) {
+		n.Float32 = float32(0)
+		n.Valid = false
+		return nil
+	}
+	num, err := strconv.ParseFloat(string(payload), 32)
+	if err != nil {
+		return fmt.Errorf("payload cannot be converted to float32: got %v", string(payload))
+	}
+	n.Float32 = float32(num)
+	n.Valid = true
+	return nil
+}
+
+// Value implements the driver.Valuer interface.
+func (n NullFloat32) Value() (driver.Value, error) {
+	if n.IsNull() {
+		return nil, nil
+	}
+	return float64(n.Float32), nil
+}
+
+// Scan implements the sql.Scanner interface.
+func (n *NullFloat32) Scan(value interface{}) error {
+	if value == nil {
+		n.Float32, n.Valid = 0, false
+		return nil
+	}
+	n.Valid = true
+	switch p := value.(type) {
+	default:
+		return spannerErrorf(codes.InvalidArgument, "invalid type for NullFloat32: %v", p)
+	case *float32:
+		n.Float32 = *p
+	case float32:
+		n.Float32 = p
+	case *NullFloat32:
+		n.Float32 = p.Float32
+		n.Valid = p.Valid
+	case NullFloat32:
+		n.Float32 = p.Float32
+		n.Valid = p.Valid
+	}
+	return nil
+}
+
+// GormDataType is used by gorm to determine the default data type for fields with this type.
+func (n NullFloat32) GormDataType() string {
+	return "FLOAT32"
+}
+
 // NullTime represents a Cloud Spanner TIMESTAMP that may be null.
 type NullTime struct {
 	Time  time.Time // Time contains the value when it is non-NULL, and a zero time.Time when NULL.
@@ -1498,6 +1646,102 @@ func decodeValue(v *proto3.Value, t *sppb.Type, ptr interface{}, opts ...DecodeO
 			return err
 		}
 		*p = y
+	case *float32:
+		if p == nil {
+			return errNilDst(p)
+		}
+		if code != sppb.TypeCode_FLOAT32 {
+			return errTypeMismatch(code, acode, ptr)
+		}
+		if isNull {
+			return errDstNotForNull(ptr)
+		}
+		x, err := getFloat32Value(v)
+		if err != nil {
+			return err
+		}
+		*p = x
+	case *NullFloat32, **float32:
+		if p == nil {
+			return errNilDst(p)
+		}
+		if code != sppb.TypeCode_FLOAT32 {
+			return errTypeMismatch(code, acode, ptr)
+		}
+		if isNull {
+			switch sp := ptr.(type) {
+			case *NullFloat32:
+				*sp = NullFloat32{}
+			case **float32:
+				*sp = nil
+			}
+			break
+		}
+		x, err := getFloat32Value(v)
+		if err != nil {
+			return err
+		}
+		switch sp := ptr.(type) {
+		case *NullFloat32:
+			sp.Valid = true
+			sp.Float32 = x
+		case **float32:
+			*sp = &x
+		}
+	case *[]NullFloat32, *[]*float32:
+		if p == nil {
+			return errNilDst(p)
+		}
+		if acode != sppb.TypeCode_FLOAT32 {
+			return errTypeMismatch(code, acode, ptr)
+		}
+		if isNull {
+			switch sp := ptr.(type) {
+			case *[]NullFloat32:
+				*sp = nil
+			case *[]*float32:
+				*sp = nil
+			}
+			break
+		}
+		x, err := getListValue(v)
+		if err != nil {
+			return err
+		}
+		switch sp := ptr.(type) {
+		case *[]NullFloat32:
+			y, err := decodeNullFloat32Array(x)
+			if err != nil {
+				return err
+			}
+			*sp = y
+		case *[]*float32:
+			y, err := decodeFloat32PointerArray(x)
+			if err != nil {
+				return err
+			}
+			*sp = y
+		}
+	case *[]float32:
+		if p == nil {
+			return errNilDst(p)
+		}
+		if acode != sppb.TypeCode_FLOAT32 {
+			return errTypeMismatch(code, acode, ptr)
+		}
+		if isNull {
+			*p = nil
+			break
+		}
+		x, err := getListValue(v)
+		if err != nil {
+			return err
+		}
+		y, err := decodeFloat32Array(x)
+		if err != nil {
+			return err
+		}
+		*p = y
 	case *big.Rat:
 		if code != sppb.TypeCode_NUMERIC {
 			return errTypeMismatch(code, acode, ptr)
@@ -1939,6 +2183,30 @@ func decodeValue(v *proto3.Value, t *sppb.Type, ptr interface{}, opts ...DecodeO
 			return decodedVal.DecodeSpanner(x)
 		}
 
+		// Check if a codec is registered for the type that the pointer
+		// points to.
+		if pt := reflect.TypeOf(ptr); pt.Kind() == reflect.Ptr {
+			if c, ok := lookupCodec(pt.Elem()); ok {
+				if reflect.ValueOf(ptr).IsNil() {
+					return errNilDst(ptr)
+				}
+				x, err := getGenericValue(t, v)
+				if err != nil {
+					return err
+				}
+				return c.decode(x, ptr)
+			}
+		}
+
+		// Check if the proto encoding is for a protocol buffer message or
+		// enum.
+		if code == sppb.TypeCode_PROTO || code == sppb.TypeCode_ENUM {
+			return decodeProtoValue(v, t, isNull, ptr)
+		}
+		if acode == sppb.TypeCode_PROTO || acode == sppb.TypeCode_ENUM {
+			return decodeProtoArray(v, t, isNull, ptr)
+		}
+
 		// Check if the pointer is a variant of a base type.
 		decodableType := getDecodableSpannerType(ptr, true)
 		if decodableType != spannerTypeUnknown {
@@ -1948,6 +2216,18 @@ func decodeValue(v *proto3.Value, t *sppb.Type, ptr interface{}, opts ...DecodeO
 			return decodableType.decodeValueToCustomType(v, t, acode, atypeAnnotation, ptr)
 		}
 
+		s := decodeSetting{
+			Lenient: false,
+		}
+		for _, opt := range opts {
+			opt.Apply(&s)
+		}
+
+		// Check if the proto encoding is for a struct.
+		if code == sppb.TypeCode_STRUCT {
+			return decodeNestedStruct(t.StructType, v, isNull, ptr, s.Lenient)
+		}
+
 		// Check if the proto encoding is for an array of structs.
 		if !(code == sppb.TypeCode_ARRAY && acode == sppb.TypeCode_STRUCT) {
 			return errTypeMismatch(code, acode, ptr)
@@ -1975,15 +2255,125 @@ func decodeValue(v *proto3.Value, t *sppb.Type, ptr interface{}, opts ...DecodeO
 		if err != nil {
 			return err
 		}
-		s := decodeSetting{
-			Lenient: false,
+		if err = decodeStructArray(t.ArrayElementType.StructType, x, p, s.Lenient); err != nil {
+			return err
 		}
-		for _, opt := range opts {
-			opt.Apply(&s)
+	}
+	return nil
+}
+
+var (
+	typeOfProtoMessage = reflect.TypeOf((*proto.Message)(nil)).Elem()
+	typeOfProtoEnum    = reflect.TypeOf((*protoreflect.Enum)(nil)).Elem()
+)
+
+// decodeProtoValue decodes the PROTO or ENUM value v into the protocol buffer
+// message or enum referenced by pointer ptr. ptr may also point to a pointer
+// to a message or enum, which is set to nil for a NULL value.
+func decodeProtoValue(v *proto3.Value, t *sppb.Type, isNull bool, ptr interface{}) error {
+	vp := reflect.ValueOf(ptr)
+	if vp.Kind() != reflect.Ptr || vp.IsNil() {
+		return errNilDst(ptr)
+	}
+	et := vp.Type().Elem()
+	if et.Kind() == reflect.Ptr &&
+		((t.Code == sppb.TypeCode_PROTO && et.Implements(typeOfProtoMessage)) ||
+			(t.Code == sppb.TypeCode_ENUM && et.Elem().Implements(typeOfProtoEnum))) {
+		if isNull {
+			vp.Elem().Set(reflect.Zero(et))
+			return nil
 		}
-		if err = decodeStructArray(t.ArrayElementType.StructType, x, p, s.Lenient); err != nil {
+		nv := reflect.New(et.Elem())
+		if err := decodeProtoValue(v, t, false, nv.Interface()); err != nil {
 			return err
 		}
+		vp.Elem().Set(nv)
+		return nil
+	}
+	switch t.Code {
+	case sppb.TypeCode_PROTO:
+		m, ok := ptr.(proto.Message)
+		if !ok {
+			return errTypeMismatch(t.Code, sppb.TypeCode_TYPE_CODE_UNSPECIFIED, ptr)
+		}
+		if isNull {
+			return errDstNotForNull(ptr)
+		}
+		x, err := getStringValue(v)
+		if err != nil {
+			return err
+		}
+		y, err := base64.StdEncoding.DecodeString(x)
+		if err != nil {
+			return errBadEncoding(v, err)
+		}
+		return proto.Unmarshal(y, m)
+	default:
+		if !et.Implements(typeOfProtoEnum) || et.Kind() != reflect.Int32 {
+			return errTypeMismatch(t.Code, sppb.TypeCode_TYPE_CODE_UNSPECIFIED, ptr)
+		}
+		if isNull {
+			return errDstNotForNull(ptr)
+		}
+		x, err := getStringValue(v)
+		if err != nil {
+			return err
+		}
+		y, err := strconv.ParseInt(x, 10, 32)
+		if err != nil {
+			return errBadEncoding(v, err)
+		}
+		vp.Elem().SetInt(y)
+		return nil
+	}
+}
+
+// decodeProtoArray decodes the ARRAY<PROTO> or ARRAY<ENUM> value v into the
+// slice of protocol buffer messages or enums, or of pointers to enums,
+// referenced by pointer ptr.
+func decodeProtoArray(v *proto3.Value, t *sppb.Type, isNull bool, ptr interface{}) error {
+	vp := reflect.ValueOf(ptr)
+	if vp.Kind() != reflect.Ptr || vp.IsNil() {
+		return errNilDst(ptr)
+	}
+	st := vp.Type().Elem()
+	if st.Kind() != reflect.Slice || protoElementType(st.Elem()) == nil {
+		return errTypeMismatch(t.Code, t.ArrayElementType.Code, ptr)
+	}
+	if isNull {
+		vp.Elem().Set(reflect.Zero(st))
+		return nil
+	}
+	x, err := getListValue(v)
+	if err != nil {
+		return err
+	}
+	a := reflect.MakeSlice(st, len(x.Values), len(x.Values))
+	for i, e := range x.Values {
+		_, isNull := e.Kind.(*proto3.Value_NullValue)
+		if err := decodeProtoValue(e, t.ArrayElementType, isNull, a.Index(i).Addr().Interface()); err != nil {
+			return errDecodeArrayElement(i, e, t.ArrayElementType.Code.String(), err)
+		}
+	}
+	vp.Elem().Set(a)
+	return nil
+}
+
+// protoElementType returns the PROTO or ENUM type of the elements of slices
+// with element type et, which is a pointer to a protocol buffer message, or a
+// protocol buffer enum or a pointer to one. It returns nil for other types.
+func protoElementType(et reflect.Type) *sppb.Type {
+	switch {
+	case et.Kind() == reflect.Int32 && et.Implements(typeOfProtoEnum):
+		e := reflect.Zero(et).Interface().(protoreflect.Enum)
+		return protoEnumType(string(e.Descriptor().FullName()))
+	case et.Kind() != reflect.Ptr:
+		return nil
+	case et.Elem().Kind() == reflect.Int32 && et.Elem().Implements(typeOfProtoEnum):
+		return protoElementType(et.Elem())
+	case et.Implements(typeOfProtoMessage):
+		m := reflect.Zero(et).Interface().(proto.Message)
+		return protoMessageType(string(proto.MessageReflect(m).Descriptor().FullName()))
 	}
 	return nil
 }
@@ -2660,7 +3050,7 @@ func getGenericValue(t *sppb.Type, v *proto3.Value) (interface{}, error) {
 
 func getTypedNil(t *sppb.Type) (interface{}, error) {
 	switch t.Code {
-	case sppb.TypeCode_FLOAT64:
+	case sppb.TypeCode_FLOAT64, sppb.TypeCode_FLOAT32:
 		var f *float64
 		return f, nil
 	case sppb.TypeCode_BOOL:
@@ -2714,6 +3104,17 @@ func getFloat64Value(v *proto3.Value) (float64, error) {
 	return 0, errSrcVal(v, "Number")
 }
 
+// getFloat32Value returns the float32 value encoded in proto3.Value v whose
+// kind is proto3.Value_NumberValue / proto3.Value_StringValue.
+// Cloud Spanner uses string to encode NaN, Infinity and -Infinity.
+func getFloat32Value(v *proto3.Value) (float32, error) {
+	x, err := getFloat64Value(v)
+	if err != nil {
+		return 0, err
+	}
+	return float32(x), nil
+}
+
 // errNilListValue returns error for unexpected nil ListValue in decoding Cloud Spanner ARRAYs.
 func errNilListValue(sqlType string) error {
 	return spannerErrorf(codes.FailedPrecondition, "unexpected nil ListValue in decoding %v array", sqlType)
@@ -2914,6 +3315,48 @@ func decodeFloat64Array(pb *proto3.ListValue) ([]float64, error) {
 	return a, nil
 }
 
+// decodeNullFloat32Array decodes proto3.ListValue pb into a NullFloat32 slice.
+func decodeNullFloat32Array(pb *proto3.ListValue) ([]NullFloat32, error) {
+	if pb == nil {
+		return nil, errNilListValue("FLOAT32")
+	}
+	a := make([]NullFloat32, len(pb.Values))
+	for i, v := range pb.Values {
+		if err := decodeValue(v, float32Type(), &a[i]); err != nil {
+			return nil, errDecodeArrayElement(i, v, "FLOAT32", err)
+		}
+	}
+	return a, nil
+}
+
+// decodeFloat32PointerArray decodes proto3.ListValue pb into a *float32 slice.
+func decodeFloat32PointerArray(pb *proto3.ListValue) ([]*float32, error) {
+	if pb == nil {
+		return nil, errNilListValue("FLOAT32")
+	}
+	a := make([]*float32, len(pb.Values))
+	for i, v := range pb.Values {
+		if err := decodeValue(v, float32Type(), &a[i]); err != nil {
+			return nil, errDecodeArrayElement(i, v, "FLOAT32", err)
+		}
+	}
+	return a, nil
+}
+
+// decodeFloat32Array decodes proto3.ListValue pb into a float32 slice.
+func decodeFloat32Array(pb *proto3.ListValue) ([]float32, error) {
+	if pb == nil {
+		return nil, errNilListValue("FLOAT32")
+	}
+	a := make([]float32, len(pb.Values))
+	for i, v := range pb.Values {
+		if err := decodeValue(v, float32Type(), &a[i]); err != nil {
+			return nil, errDecodeArrayElement(i, v, "FLOAT32", err)
+		}
+	}
+	return a, nil
+}
+
 // decodeNullNumericArray decodes proto3.ListValue pb into a NullNumeric slice.
 func decodeNullNumericArray(pb *proto3.ListValue) ([]NullNumeric, error) {
 	if pb == nil {
@@ -3269,6 +3712,47 @@ func decodeStruct(ty *sppb.StructType, pb *proto3.ListValue, ptr interface{}, le
 	return nil
 }
 
+// decodeNestedStruct decodes the STRUCT value v into the struct or struct
+// pointer referenced by pointer ptr, according to the structural information
+// given in sppb.StructType ty.
+func decodeNestedStruct(ty *sppb.StructType, v *proto3.Value, isNull bool, ptr interface{}, lenient bool) error {
+	vp := reflect.ValueOf(ptr)
+	if vp.Kind() != reflect.Ptr || vp.IsNil() {
+		return errNilDst(ptr)
+	}
+	et := vp.Type().Elem()
+	switch {
+	case et.Kind() == reflect.Struct:
+		if isNull {
+			return errDstNotForNull(ptr)
+		}
+		x, err := getListValue(v)
+		if err != nil {
+			return err
+		}
+		return decodeStruct(ty, x, ptr, lenient)
+	case et.Kind() == reflect.Ptr && et.Elem().Kind() == reflect.Struct:
+		if isNull {
+			// The proto Value is encoding NULL, set the struct pointer to nil
+			// as well.
+			vp.Elem().Set(reflect.Zero(et))
+			return nil
+		}
+		x, err := getListValue(v)
+		if err != nil {
+			return err
+		}
+		sv := reflect.New(et.Elem())
+		if err := decodeStruct(ty, x, sv.Interface(), lenient); err != nil {
+			return err
+		}
+		vp.Elem().Set(sv)
+		return nil
+	default:
+		return errTypeMismatch(sppb.TypeCode_STRUCT, sppb.TypeCode_TYPE_CODE_UNSPECIFIED, ptr)
+	}
+}
+
 // isPtrStructPtrSlice returns true if ptr is a pointer to a slice of struct pointers.
 func isPtrStructPtrSlice(t reflect.Type) bool {
 	if t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Slice {
@@ -3543,6 +4027,43 @@ func encodeValue(v interface{}) (*proto3.Value, *sppb.Type, error) {
 			}
 		}
 		pt = listType(floatType())
+	case float32:
+		pb.Kind = &proto3.Value_NumberValue{NumberValue: float64(v)}
+		pt = float32Type()
+	case []float32:
+		if v != nil {
+			pb, err = encodeArray(len(v), func(i int) interface{} { return v[i] })
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		pt = listType(float32Type())
+	case NullFloat32:
+		if v.Valid {
+			return encodeValue(v.Float32)
+		}
+		pt = float32Type()
+	case []NullFloat32:
+		if v != nil {
+			pb, err = encodeArray(len(v), func(i int) interface{} { return v[i] })
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		pt = listType(float32Type())
+	case *float32:
+		if v != nil {
+			return encodeValue(*v)
+		}
+		pt = float32Type()
+	case []*float32:
+		if v != nil {
+			pb, err = encodeArray(len(v), func(i int) interface{} { return v[i] })
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		pt = listType(float32Type())
 	case big.Rat:
 		switch LossOfPrecisionHandling {
 		case NumericError:
@@ -3730,6 +4251,30 @@ func encodeValue(v interface{}) (*proto3.Value, *sppb.Type, error) {
 		pt = proto.Clone(v.Type).(*sppb.Type)
 	case []GenericColumnValue:
 		return nil, nil, errEncoderUnsupportedType(v)
+	case protoreflect.Enum:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				// nil pointer to an enum, representing a NULL ENUM value.
+				e := reflect.Zero(rv.Type().Elem()).Interface().(protoreflect.Enum)
+				pt = protoEnumType(string(e.Descriptor().FullName()))
+				break
+			}
+			return encodeValue(rv.Elem().Interface())
+		}
+		pb.Kind = stringKind(strconv.FormatInt(int64(v.Number()), 10))
+		pt = protoEnumType(string(v.Descriptor().FullName()))
+	case proto.Message:
+		pt = protoMessageType(string(proto.MessageReflect(v).Descriptor().FullName()))
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			// nil message pointer, representing a NULL PROTO value.
+			break
+		}
+		b, err := proto.Marshal(v)
+		if err != nil {
+			return nil, nil, err
+		}
+		pb.Kind = stringKind(base64.StdEncoding.EncodeToString(b))
 	default:
 		// Check if the value is a custom type that implements spanner.Encoder
 		// interface.
@@ -3741,6 +4286,15 @@ func encodeValue(v interface{}) (*proto3.Value, *sppb.Type, error) {
 			return encodeValue(nv)
 		}
 
+		// Check if a codec is registered for the type of the value.
+		if c, ok := lookupCodec(reflect.TypeOf(v)); ok {
+			nv, err := c.encode(v)
+			if err != nil {
+				return nil, nil, err
+			}
+			return encodeValue(nv)
+		}
+
 		// Check if the value is a variant of a base type.
 		decodableType := getDecodableSpannerType(v, false)
 		if decodableType != spannerTypeUnknown && decodableType != spannerTypeInvalid {
@@ -3751,6 +4305,20 @@ func encodeValue(v interface{}) (*proto3.Value, *sppb.Type, error) {
 			return encodeValue(converted)
 		}
 
+		// Check if the value is a slice of protocol buffer messages or
+		// enums.
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice {
+			if et := protoElementType(rv.Type().Elem()); et != nil {
+				if !rv.IsNil() {
+					pb, err = encodeArray(rv.Len(), func(i int) interface{} { return rv.Index(i).Interface() })
+					if err != nil {
+						return nil, nil, err
+					}
+				}
+				return pb, listType(et), nil
+			}
+		}
+
 		if !isStructOrArrayOfStructValue(v) {
 			return nil, nil, errEncoderUnsupportedType(v)
 		}
@@ -4042,16 +4610,25 @@ func isSupportedMutationType(v interface{}) bool {
 		int, []int, int64, *int64, []int64, []*int64, NullInt64, []NullInt64,
 		bool, *bool, []bool, []*bool, NullBool, []NullBool,
 		float64, *float64, []float64, []*float64, NullFloat64, []NullFloat64,
+		float32, *float32, []float32, []*float32, NullFloat32, []NullFloat32,
 		time.Time, *time.Time, []time.Time, []*time.Time, NullTime, []NullTime,
 		civil.Date, *civil.Date, []civil.Date, []*civil.Date, NullDate, []NullDate,
 		big.Rat, *big.Rat, []big.Rat, []*big.Rat, NullNumeric, []NullNumeric,
-		GenericColumnValue:
+		GenericColumnValue, protoreflect.Enum, proto.Message:
 		return true
 	default:
 		// Check if the custom type implements spanner.Encoder interface.
 		if _, ok := v.(Encoder); ok {
 			return true
 		}
+		// Check if a codec is registered for the type of the value.
+		if _, ok := lookupCodec(reflect.TypeOf(v)); ok {
+			return true
+		}
+		// Check if the value is a slice of protocol buffer messages or enums.
+		if typ := reflect.TypeOf(v); typ != nil && typ.Kind() == reflect.Slice && protoElementType(typ.Elem()) != nil {
+			return true
+		}
 
 		decodableType := getDecodableSpannerType(v, false)
 		return decodableType != spannerTypeUnknown && decodableType != spannerTypeInvalid
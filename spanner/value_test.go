@@ -264,6 +264,8 @@ func TestEncodeValue(t *testing.T) {
 	var bNilPtr *bool
 	fValue := 3.14
 	var fNilPtr *float64
+	f32Value := float32(3.5)
+	var f32NilPtr *float32
 	tValue := t1
 	var tNilPtr *time.Time
 	dValue := d1
@@ -341,6 +343,16 @@ func TestEncodeValue(t *testing.T) {
 		{[]float64{3.141, 0.618, math.Inf(-1)}, listProto(floatProto(3.141), floatProto(0.618), floatProto(math.Inf(-1))), listType(tFloat), "[]float64"},
 		{[]NullFloat64{{3.141, true}, {0.618, false}}, listProto(floatProto(3.141), nullProto()), listType(tFloat), "[]NullFloat64"},
 		{[]*float64{&fValue, fNilPtr}, listProto(floatProto(3.14), nullProto()), listType(tFloat), "[]NullFloat64"},
+		// FLOAT32 / FLOAT32 ARRAY
+		{float32(3.5), float32Proto(3.5), float32Type(), "float32"},
+		{NullFloat32{0.25, true}, float32Proto(0.25), float32Type(), "NullFloat32 with value"},
+		{NullFloat32{0.25, false}, nullProto(), float32Type(), "NullFloat32 with null"},
+		{&f32Value, float32Proto(3.5), float32Type(), "*float32 with value"},
+		{f32NilPtr, nullProto(), float32Type(), "*float32 with null"},
+		{[]float32(nil), nullProto(), listType(float32Type()), "null []float32"},
+		{[]float32{3.5, 0.25}, listProto(float32Proto(3.5), float32Proto(0.25)), listType(float32Type()), "[]float32"},
+		{[]NullFloat32{{3.5, true}, {0.25, false}}, listProto(float32Proto(3.5), nullProto()), listType(float32Type()), "[]NullFloat32"},
+		{[]*float32{&f32Value, f32NilPtr}, listProto(float32Proto(3.5), nullProto()), listType(float32Type()), "[]*float32"},
 		// NUMERIC / NUMERIC ARRAY
 		{*numValuePtr, numericProto(numValuePtr), tNumeric, "big.Rat"},
 		{numValuePtr, numericProto(numValuePtr), tNumeric, "*big.Rat"},
@@ -1419,6 +1431,8 @@ func TestDecodeValue(t *testing.T) {
 
 	fValue := 3.14
 	var fNilPtr *float64
+	f32Value := float32(3.5)
+	var f32NilPtr *float32
 	f2Value := 6.626
 
 	numValuePtr := big.NewRat(12345, 1e3)
@@ -1506,6 +1520,19 @@ func TestDecodeValue(t *testing.T) {
 		// FLOAT64 ARRAY with []*float64
 		{desc: "decode ARRAY<FLOAT64> to []*float64", proto: listProto(floatProto(fValue), nullProto(), floatProto(f2Value)), protoType: listType(floatType()), want: []*float64{&fValue, nil, &f2Value}},
 		{desc: "decode NULL to []*float64", proto: nullProto(), protoType: listType(floatType()), want: []*float64(nil)},
+		// FLOAT32
+		{desc: "decode FLOAT32 to float32", proto: float32Proto(3.5), protoType: float32Type(), want: float32(3.5)},
+		{desc: "decode NULL to float32", proto: nullProto(), protoType: float32Type(), want: float32(0), wantErr: true},
+		{desc: "decode FLOAT64 to float32", proto: floatProto(3.5), protoType: floatType(), want: float32(0), wantErr: true},
+		{desc: "decode FLOAT32 to *float32", proto: float32Proto(3.5), protoType: float32Type(), want: &f32Value},
+		{desc: "decode NULL to *float32", proto: nullProto(), protoType: float32Type(), want: f32NilPtr},
+		{desc: "decode FLOAT32 to NullFloat32", proto: float32Proto(3.5), protoType: float32Type(), want: NullFloat32{3.5, true}},
+		{desc: "decode NULL to NullFloat32", proto: nullProto(), protoType: float32Type(), want: NullFloat32{}},
+		// FLOAT32 ARRAY
+		{desc: "decode ARRAY<FLOAT32> to []NullFloat32", proto: listProto(float32Proto(3.5), nullProto()), protoType: listType(float32Type()), want: []NullFloat32{{3.5, true}, {}}},
+		{desc: "decode ARRAY<FLOAT32> to []float32", proto: listProto(float32Proto(3.5), float32Proto(0.25)), protoType: listType(float32Type()), want: []float32{3.5, 0.25}},
+		{desc: "decode ARRAY<FLOAT32> to []*float32", proto: listProto(float32Proto(3.5), nullProto()), protoType: listType(float32Type()), want: []*float32{&f32Value, nil}},
+		{desc: "decode NULL to []*float32", proto: nullProto(), protoType: listType(float32Type()), want: []*float32(nil)},
 		// NUMERIC
 		{desc: "decode NUMERIC to big.Rat", proto: numericProto(numValuePtr), protoType: numericType(), want: *numValuePtr},
 		{desc: "decode NUMERIC to NullNumeric", proto: numericProto(numValuePtr), protoType: numericType(), want: NullNumeric{*numValuePtr, true}},
@@ -2315,6 +2342,276 @@ func TestDecodeStructWithPointers(t *testing.T) {
 	}
 }
 
+func TestEncodeDecodeProtoAndEnum(t *testing.T) {
+	msg := &sppb.Type{Code: sppb.TypeCode_STRING}
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgProto := bytesProto(b)
+	msgType := protoMessageType("google.spanner.v1.Type")
+	enumType := protoEnumType("google.spanner.v1.TypeCode")
+	var nilMsg *sppb.Type
+	var nilEnum *sppb.TypeCode
+
+	for _, test := range []struct {
+		desc      string
+		in        interface{}
+		wantValue *proto3.Value
+		wantType  *sppb.Type
+	}{
+		{"message", msg, msgProto, msgType},
+		{"nil message", nilMsg, nullProto(), msgType},
+		{"enum", sppb.TypeCode_JSON, intProto(int64(sppb.TypeCode_JSON)), enumType},
+		{"nil enum pointer", nilEnum, nullProto(), enumType},
+	} {
+		gotValue, gotType, err := encodeValue(test.in)
+		if err != nil {
+			t.Errorf("%s: cannot encode: %v", test.desc, err)
+			continue
+		}
+		if !proto.Equal(gotValue, test.wantValue) {
+			t.Errorf("%s: value mismatch\n Got: %v\nWant: %v", test.desc, gotValue, test.wantValue)
+		}
+		if !proto.Equal(gotType, test.wantType) {
+			t.Errorf("%s: type mismatch\n Got: %v\nWant: %v", test.desc, gotType, test.wantType)
+		}
+	}
+
+	var gotMsg sppb.Type
+	if err := decodeValue(msgProto, msgType, &gotMsg); err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(&gotMsg, msg) {
+		t.Errorf("message mismatch\n Got: %v\nWant: %v", &gotMsg, msg)
+	}
+	gotMsgPtr := &sppb.Type{}
+	if err := decodeValue(nullProto(), msgType, &gotMsgPtr); err != nil {
+		t.Fatal(err)
+	}
+	if gotMsgPtr != nil {
+		t.Errorf("NULL message mismatch\n Got: %v\nWant: nil", gotMsgPtr)
+	}
+	var gotEnum sppb.TypeCode
+	if err := decodeValue(intProto(int64(sppb.TypeCode_JSON)), enumType, &gotEnum); err != nil {
+		t.Fatal(err)
+	}
+	if g, w := gotEnum, sppb.TypeCode_JSON; g != w {
+		t.Errorf("enum mismatch\n Got: %v\nWant: %v", g, w)
+	}
+	var gotEnumPtr *sppb.TypeCode
+	if err := decodeValue(intProto(int64(sppb.TypeCode_JSON)), enumType, &gotEnumPtr); err != nil {
+		t.Fatal(err)
+	}
+	if gotEnumPtr == nil || *gotEnumPtr != sppb.TypeCode_JSON {
+		t.Errorf("enum pointer mismatch\n Got: %v\nWant: %v", gotEnumPtr, sppb.TypeCode_JSON)
+	}
+	if err := decodeValue(nullProto(), enumType, &gotEnum); err == nil {
+		t.Error("missing expected decode failure for NULL enum")
+	}
+	var s string
+	if err := decodeValue(msgProto, msgType, &s); err == nil {
+		t.Error("missing expected decode failure for PROTO to string")
+	}
+}
+
+func TestEncodeDecodeProtoAndEnumArray(t *testing.T) {
+	msg := &sppb.Type{Code: sppb.TypeCode_STRING}
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgArrayType := listType(protoMessageType("google.spanner.v1.Type"))
+	enumArrayType := listType(protoEnumType("google.spanner.v1.TypeCode"))
+	msgs := []*sppb.Type{msg, nil}
+	msgsProto := listProto(bytesProto(b), nullProto())
+	enum := sppb.TypeCode_JSON
+	enumsProto := listProto(intProto(int64(sppb.TypeCode_JSON)), intProto(int64(sppb.TypeCode_BOOL)))
+
+	for _, test := range []struct {
+		desc      string
+		in        interface{}
+		wantValue *proto3.Value
+		wantType  *sppb.Type
+	}{
+		{"messages", msgs, msgsProto, msgArrayType},
+		{"nil messages", []*sppb.Type(nil), nullProto(), msgArrayType},
+		{"enums", []sppb.TypeCode{sppb.TypeCode_JSON, sppb.TypeCode_BOOL}, enumsProto, enumArrayType},
+		{"enum pointers", []*sppb.TypeCode{&enum, nil}, listProto(intProto(int64(sppb.TypeCode_JSON)), nullProto()), enumArrayType},
+		{"nil enums", []sppb.TypeCode(nil), nullProto(), enumArrayType},
+	} {
+		gotValue, gotType, err := encodeValue(test.in)
+		if err != nil {
+			t.Errorf("%s: cannot encode: %v", test.desc, err)
+			continue
+		}
+		if !proto.Equal(gotValue, test.wantValue) {
+			t.Errorf("%s: value mismatch\n Got: %v\nWant: %v", test.desc, gotValue, test.wantValue)
+		}
+		if !proto.Equal(gotType, test.wantType) {
+			t.Errorf("%s: type mismatch\n Got: %v\nWant: %v", test.desc, gotType, test.wantType)
+		}
+		if !isSupportedMutationType(test.in) {
+			t.Errorf("%s: not supported in mutations", test.desc)
+		}
+	}
+
+	var gotMsgs []*sppb.Type
+	if err := decodeValue(msgsProto, msgArrayType, &gotMsgs); err != nil {
+		t.Fatal(err)
+	}
+	if len(gotMsgs) != 2 || !proto.Equal(gotMsgs[0], msg) || gotMsgs[1] != nil {
+		t.Errorf("messages mismatch\n Got: %v\nWant: %v", gotMsgs, msgs)
+	}
+	if err := decodeValue(nullProto(), msgArrayType, &gotMsgs); err != nil {
+		t.Fatal(err)
+	}
+	if gotMsgs != nil {
+		t.Errorf("NULL messages mismatch\n Got: %v\nWant: nil", gotMsgs)
+	}
+	var gotEnums []sppb.TypeCode
+	if err := decodeValue(enumsProto, enumArrayType, &gotEnums); err != nil {
+		t.Fatal(err)
+	}
+	if !testEqual(gotEnums, []sppb.TypeCode{sppb.TypeCode_JSON, sppb.TypeCode_BOOL}) {
+		t.Errorf("enums mismatch\n Got: %v", gotEnums)
+	}
+	var gotEnumPtrs []*sppb.TypeCode
+	if err := decodeValue(listProto(intProto(int64(sppb.TypeCode_JSON)), nullProto()), enumArrayType, &gotEnumPtrs); err != nil {
+		t.Fatal(err)
+	}
+	if len(gotEnumPtrs) != 2 || gotEnumPtrs[0] == nil || *gotEnumPtrs[0] != sppb.TypeCode_JSON || gotEnumPtrs[1] != nil {
+		t.Errorf("enum pointers mismatch\n Got: %v", gotEnumPtrs)
+	}
+	if err := decodeValue(listProto(nullProto()), enumArrayType, &gotEnums); err == nil {
+		t.Error("missing expected decode failure for NULL enum element")
+	}
+	var strs []string
+	if err := decodeValue(msgsProto, msgArrayType, &strs); err == nil {
+		t.Error("missing expected decode failure for ARRAY<PROTO> to []string")
+	}
+}
+
+func TestDecodeNestedStruct(t *testing.T) {
+	type (
+		Inner struct {
+			Name string
+			Age  NullInt64
+		}
+		Outer struct {
+			ID    int64
+			Inner Inner
+		}
+		OuterPtr struct {
+			ID    int64
+			Inner *Inner
+		}
+	)
+	stype := &sppb.StructType{Fields: []*sppb.StructType_Field{
+		{Name: "ID", Type: intType()},
+		{Name: "Inner", Type: structType(mkField("Name", stringType()), mkField("Age", intType()))},
+	}}
+	value := listValueProto(intProto(1), listProto(stringProto("foo"), intProto(42)))
+	null := listValueProto(intProto(2), nullProto())
+
+	for _, test := range []struct {
+		desc string
+		lv   *proto3.ListValue
+		ptr  interface{}
+		want interface{}
+		fail bool
+	}{
+		{
+			desc: "decode STRUCT to struct",
+			lv:   value,
+			ptr:  &Outer{},
+			want: &Outer{ID: 1, Inner: Inner{Name: "foo", Age: NullInt64{42, true}}},
+		},
+		{
+			desc: "decode STRUCT to struct pointer",
+			lv:   value,
+			ptr:  &OuterPtr{},
+			want: &OuterPtr{ID: 1, Inner: &Inner{Name: "foo", Age: NullInt64{42, true}}},
+		},
+		{
+			desc: "decode NULL STRUCT to struct pointer",
+			lv:   null,
+			ptr:  &OuterPtr{Inner: &Inner{Name: "bar"}},
+			want: &OuterPtr{ID: 2},
+		},
+		{
+			desc: "decode NULL STRUCT to struct",
+			lv:   null,
+			ptr:  &Outer{},
+			fail: true,
+		},
+	} {
+		err := decodeStruct(stype, test.lv, test.ptr, false)
+		if (err != nil) != test.fail {
+			t.Errorf("%s: got error %v, wanted fail: %v", test.desc, err, test.fail)
+		}
+		if err == nil {
+			if !testutil.Equal(test.ptr, test.want) {
+				t.Errorf("%s: got %+v, want %+v", test.desc, test.ptr, test.want)
+			}
+		}
+	}
+}
+
+// codecTestPoint is a struct that is encoded as a STRING using a codec
+// registered in TestRegisterCodec.
+type codecTestPoint struct {
+	X, Y int64
+}
+
+func TestRegisterCodec(t *testing.T) {
+	RegisterCodec(
+		func(p codecTestPoint) (interface{}, error) {
+			return fmt.Sprintf("%d,%d", p.X, p.Y), nil
+		},
+		func(val interface{}) (codecTestPoint, error) {
+			var p codecTestPoint
+			s, ok := val.(string)
+			if !ok {
+				return p, fmt.Errorf("failed to decode codecTestPoint: %v", val)
+			}
+			_, err := fmt.Sscanf(s, "%d,%d", &p.X, &p.Y)
+			return p, err
+		})
+
+	want := codecTestPoint{X: 1, Y: 2}
+	gotVal, gotType, err := encodeValue(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !testutil.Equal(gotVal, stringProto("1,2")) || !testutil.Equal(gotType, stringType()) {
+		t.Fatalf("encoded value mismatch\n Got: %v %v\nWant: %v %v", gotVal, gotType, stringProto("1,2"), stringType())
+	}
+	if !isSupportedMutationType(want) {
+		t.Fatal("codecTestPoint is not a supported mutation type")
+	}
+
+	// Values of the type are also encoded when used as a struct field.
+	gotVal, gotType, err = encodeValue(struct{ P codecTestPoint }{want})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, w := gotType, structType(mkField("P", stringType())); !testutil.Equal(g, w) {
+		t.Fatalf("encoded struct type mismatch\n Got: %v\nWant: %v", g, w)
+	}
+
+	var got codecTestPoint
+	if err := decodeValue(stringProto("1,2"), stringType(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("decoded value mismatch\n Got: %v\nWant: %v", got, want)
+	}
+	if err := decodeValue(intProto(1), intType(), &got); err == nil {
+		t.Fatal("missing expected decode failure for INT64")
+	}
+}
+
 func TestDecodeStructArray(t *testing.T) {
 	stype := &sppb.StructType{Fields: []*sppb.StructType_Field{
 		{Name: "C", Type: &sppb.Type{Code: sppb.TypeCode_ARRAY,
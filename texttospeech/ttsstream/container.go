@@ -0,0 +1,227 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ttsstream
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+)
+
+// audioWriter joins the audio of successive responses into one stream.
+type audioWriter interface {
+	write(audio []byte) error
+	close() error
+}
+
+func newAudioWriter(enc texttospeechpb.AudioEncoding, w io.Writer) audioWriter {
+	switch enc {
+	case texttospeechpb.AudioEncoding_LINEAR16, texttospeechpb.AudioEncoding_MULAW, texttospeechpb.AudioEncoding_ALAW:
+		return &wavWriter{w: w}
+	case texttospeechpb.AudioEncoding_OGG_OPUS:
+		return &oggWriter{w: w}
+	}
+	return rawWriter{w}
+}
+
+// rawWriter concatenates audio, as for MP3 frames.
+type rawWriter struct{ w io.Writer }
+
+func (r rawWriter) write(audio []byte) error {
+	_, err := r.w.Write(audio)
+	return err
+}
+
+func (rawWriter) close() error { return nil }
+
+// unknownSize is the size of the RIFF and data chunks of a WAV stream of
+// unknown length.
+const unknownSize = 0xFFFFFFFF
+
+// wavWriter writes the samples of WAV files as a single WAV file.
+type wavWriter struct {
+	w       io.Writer
+	started bool
+	fmtLen  int
+	dataLen int64
+}
+
+func (ww *wavWriter) write(audio []byte) error {
+	fmtChunk, data, err := parseWAV(audio)
+	if err != nil {
+		return err
+	}
+	if !ww.started {
+		hdr := make([]byte, 0, 28+len(fmtChunk))
+		hdr = append(hdr, "RIFF"...)
+		hdr = binary.LittleEndian.AppendUint32(hdr, unknownSize)
+		hdr = append(hdr, "WAVEfmt "...)
+		hdr = binary.LittleEndian.AppendUint32(hdr, uint32(len(fmtChunk)))
+		hdr = append(hdr, fmtChunk...)
+		hdr = append(hdr, "data"...)
+		hdr = binary.LittleEndian.AppendUint32(hdr, unknownSize)
+		if _, err := ww.w.Write(hdr); err != nil {
+			return err
+		}
+		ww.started = true
+		ww.fmtLen = len(fmtChunk)
+	}
+	n, err := ww.w.Write(data)
+	ww.dataLen += int64(n)
+	return err
+}
+
+// close patches the sizes in the header if the writer can seek.
+func (ww *wavWriter) close() error {
+	ws, ok := ww.w.(io.WriteSeeker)
+	if !ok || !ww.started {
+		return nil
+	}
+	riffLen := 4 + 8 + int64(ww.fmtLen) + 8 + ww.dataLen
+	if riffLen > unknownSize {
+		return nil
+	}
+	end, err := ws.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	start := end - (riffLen + 8)
+	for _, f := range []struct {
+		off int64
+		v   uint32
+	}{
+		{4, uint32(riffLen)},
+		{int64(24 + ww.fmtLen), uint32(ww.dataLen)},
+	} {
+		if _, err := ws.Seek(start+f.off, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := ws.Write(binary.LittleEndian.AppendUint32(nil, f.v)); err != nil {
+			return err
+		}
+	}
+	_, err = ws.Seek(end, io.SeekStart)
+	return err
+}
+
+// parseWAV returns the body of the fmt chunk and the samples of a WAV file.
+func parseWAV(b []byte) (fmtChunk, data []byte, err error) {
+	if len(b) < 12 || string(b[:4]) != "RIFF" || string(b[8:12]) != "WAVE" {
+		return nil, nil, errors.New("ttsstream: audio is not a WAV file")
+	}
+	b = b[12:]
+	for len(b) >= 8 {
+		id, size := string(b[:4]), int64(binary.LittleEndian.Uint32(b[4:8]))
+		b = b[8:]
+		if size > int64(len(b)) {
+			// The data chunk of a stream may have an unknown or
+			// truncated size.
+			size = int64(len(b))
+		}
+		switch id {
+		case "fmt ":
+			fmtChunk = b[:size]
+		case "data":
+			if fmtChunk == nil {
+				return nil, nil, errors.New("ttsstream: WAV data chunk before fmt chunk")
+			}
+			return fmtChunk, b[:size], nil
+		}
+		b = b[size+size%2:]
+	}
+	return nil, nil, errors.New("ttsstream: WAV file without data chunk")
+}
+
+// oggWriter writes Ogg streams as a chained Ogg stream. A chained stream
+// requires the serial numbers of its logical streams to be distinct, so the
+// serial number of the pages of each stream is rewritten.
+type oggWriter struct {
+	w      io.Writer
+	serial uint32
+	n      int
+}
+
+func (ow *oggWriter) write(audio []byte) error {
+	if ow.n == 0 && len(audio) >= 18 {
+		ow.serial = binary.LittleEndian.Uint32(audio[14:18])
+	}
+	serial := ow.serial + uint32(ow.n)
+	ow.n++
+	for len(audio) > 0 {
+		n, err := oggPageLen(audio)
+		if err != nil {
+			return err
+		}
+		page := append([]byte(nil), audio[:n]...)
+		binary.LittleEndian.PutUint32(page[14:18], serial)
+		binary.LittleEndian.PutUint32(page[22:26], 0)
+		binary.LittleEndian.PutUint32(page[22:26], oggCRC(page))
+		if _, err := ow.w.Write(page); err != nil {
+			return err
+		}
+		audio = audio[n:]
+	}
+	return nil
+}
+
+func (*oggWriter) close() error { return nil }
+
+// oggPageLen returns the length of the Ogg page at the start of b.
+func oggPageLen(b []byte) (int, error) {
+	if len(b) < 27 || string(b[:4]) != "OggS" {
+		return 0, errors.New("ttsstream: audio is not an Ogg stream")
+	}
+	nseg := int(b[26])
+	n := 27 + nseg
+	if len(b) < n {
+		return 0, fmt.Errorf("ttsstream: truncated Ogg page")
+	}
+	for _, s := range b[27 : 27+nseg] {
+		n += int(s)
+	}
+	if len(b) < n {
+		return 0, fmt.Errorf("ttsstream: truncated Ogg page")
+	}
+	return n, nil
+}
+
+// oggCRCTable is the table of the CRC-32 of Ogg pages, which uses the
+// polynomial 0x04c11db7 without bit reflection.
+var oggCRCTable = func() (t [256]uint32) {
+	for i := range t {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = r<<1 ^ 0x04c11db7
+			} else {
+				r <<= 1
+			}
+		}
+		t[i] = r
+	}
+	return t
+}()
+
+// oggCRC returns the checksum of an Ogg page whose checksum field is zero.
+func oggCRC(page []byte) uint32 {
+	var crc uint32
+	for _, b := range page {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	return crc
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ttsstream synthesizes a stream of text chunks into a single audio
+// stream with the texttospeech/apiv1 Client.
+//
+// Synthesize reads text chunks from a channel, as produced for instance by a
+// language model or a speech recognition pipeline, synthesizes each of them
+// with SynthesizeSpeech, and writes the audio to an io.Writer as soon as it
+// is available. The audio of the chunks is joined into one well-formed
+// stream:
+//
+//   - LINEAR16, MULAW and ALAW audio is written as a single WAV file: the WAV
+//     header of the first chunk is written once, with the sizes patched at the
+//     end if the writer is an io.WriteSeeker, and marked unknown otherwise.
+//   - OGG_OPUS audio is written as a chained Ogg stream, with a distinct
+//     serial number for the logical stream of each chunk.
+//   - MP3 audio frames are concatenated.
+//
+// Example:
+//
+//	client, err := texttospeech.NewClient(ctx)
+//	if err != nil {
+//		// TODO: Handle error.
+//	}
+//	text := make(chan string)
+//	go func() {
+//		defer close(text)
+//		text <- "Hello."
+//		text <- "How can I help you today?"
+//	}()
+//	err = ttsstream.Synthesize(ctx, client, ttsstream.Config{
+//		Voice:       &texttospeechpb.VoiceSelectionParams{LanguageCode: "en-US"},
+//		AudioConfig: &texttospeechpb.AudioConfig{AudioEncoding: texttospeechpb.AudioEncoding_LINEAR16},
+//	}, text, w)
+//	if err != nil {
+//		// TODO: Handle error.
+//	}
+package ttsstream // import "cloud.google.com/go/texttospeech/ttsstream"
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	gax "github.com/googleapis/gax-go/v2"
+)
+
+// Client is the subset of the methods of the texttospeech/apiv1 Client used
+// by Synthesize.
+type Client interface {
+	SynthesizeSpeech(context.Context, *texttospeechpb.SynthesizeSpeechRequest, ...gax.CallOption) (*texttospeechpb.SynthesizeSpeechResponse, error)
+}
+
+// Config configures Synthesize.
+type Config struct {
+	// Voice is the voice of the audio. It is required.
+	Voice *texttospeechpb.VoiceSelectionParams
+
+	// AudioConfig configures the audio. It is required.
+	AudioConfig *texttospeechpb.AudioConfig
+
+	// SSML makes Synthesize treat the text chunks as SSML documents rather
+	// than plain text.
+	SSML bool
+}
+
+// Synthesize synthesizes the text chunks received from text, in order, and
+// writes their audio to w, until text is closed or ctx is done. Empty and
+// blank chunks are skipped.
+//
+// If ctx is done before text is closed, Synthesize returns ctx.Err(), after
+// finishing the audio written so far when possible.
+func Synthesize(ctx context.Context, client Client, cfg Config, text <-chan string, w io.Writer) error {
+	if cfg.Voice == nil || cfg.AudioConfig == nil {
+		return errors.New("ttsstream: Config.Voice and Config.AudioConfig are required")
+	}
+	aw := newAudioWriter(cfg.AudioConfig.AudioEncoding, w)
+	for {
+		var chunk string
+		var ok bool
+		select {
+		case <-ctx.Done():
+			if err := aw.close(); err != nil {
+				return err
+			}
+			return ctx.Err()
+		case chunk, ok = <-text:
+		}
+		if !ok {
+			return aw.close()
+		}
+		if strings.TrimSpace(chunk) == "" {
+			continue
+		}
+		input := &texttospeechpb.SynthesisInput{InputSource: &texttospeechpb.SynthesisInput_Text{Text: chunk}}
+		if cfg.SSML {
+			input.InputSource = &texttospeechpb.SynthesisInput_Ssml{Ssml: chunk}
+		}
+		resp, err := client.SynthesizeSpeech(ctx, &texttospeechpb.SynthesizeSpeechRequest{
+			Input:       input,
+			Voice:       cfg.Voice,
+			AudioConfig: cfg.AudioConfig,
+		})
+		if err != nil {
+			return err
+		}
+		if err := aw.write(resp.AudioContent); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ttsstream
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	gax "github.com/googleapis/gax-go/v2"
+)
+
+// fakeClient returns the audio given by audio for the text of a request.
+type fakeClient struct {
+	audio func(text string) []byte
+	texts []string
+}
+
+func (c *fakeClient) SynthesizeSpeech(_ context.Context, req *texttospeechpb.SynthesizeSpeechRequest, _ ...gax.CallOption) (*texttospeechpb.SynthesizeSpeechResponse, error) {
+	text := req.Input.GetText() + req.Input.GetSsml()
+	c.texts = append(c.texts, text)
+	return &texttospeechpb.SynthesizeSpeechResponse{AudioContent: c.audio(text)}, nil
+}
+
+func chunks(texts ...string) <-chan string {
+	ch := make(chan string, len(texts))
+	for _, t := range texts {
+		ch <- t
+	}
+	close(ch)
+	return ch
+}
+
+var testFmt = []byte("0123456789abcdef")
+
+// wavFile returns a WAV file of the given samples, with an extra chunk before
+// the data chunk.
+func wavFile(data string) []byte {
+	var b []byte
+	b = append(b, "RIFF"...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(4+8+len(testFmt)+8+3+1+8+len(data)))
+	b = append(b, "WAVEfmt "...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(testFmt)))
+	b = append(b, testFmt...)
+	b = append(b, "LIST"...)
+	b = binary.LittleEndian.AppendUint32(b, 3)
+	b = append(b, "abc\x00"...)
+	b = append(b, "data"...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(data)))
+	return append(b, data...)
+}
+
+func testConfig(enc texttospeechpb.AudioEncoding) Config {
+	return Config{
+		Voice:       &texttospeechpb.VoiceSelectionParams{LanguageCode: "en-US"},
+		AudioConfig: &texttospeechpb.AudioConfig{AudioEncoding: enc},
+	}
+}
+
+func TestSynthesizeWAV(t *testing.T) {
+	c := &fakeClient{audio: func(text string) []byte { return wavFile(text) }}
+	var buf bytes.Buffer
+	if err := Synthesize(context.Background(), c, testConfig(texttospeechpb.AudioEncoding_LINEAR16), chunks("ab", " ", "cd"), &buf); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.texts) != 2 {
+		t.Errorf("got requests for %q, want blank chunks skipped", c.texts)
+	}
+	fmtChunk, data, err := parseWAV(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(fmtChunk, testFmt) || string(data) != "abcd" {
+		t.Errorf("got fmt %q and data %q, want %q and \"abcd\"", fmtChunk, data, testFmt)
+	}
+	if got := binary.LittleEndian.Uint32(buf.Bytes()[4:8]); got != unknownSize {
+		t.Errorf("got RIFF size %#x, want unknown", got)
+	}
+}
+
+func TestSynthesizeWAVSeeker(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out.wav"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	c := &fakeClient{audio: func(text string) []byte { return wavFile(text) }}
+	if err := Synthesize(context.Background(), c, testConfig(texttospeechpb.AudioEncoding_MULAW), chunks("abc", "d"), f); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := binary.LittleEndian.Uint32(b[4:8]), uint32(len(b)-8); got != want {
+		t.Errorf("got RIFF size %d, want %d", got, want)
+	}
+	if got := binary.LittleEndian.Uint32(b[24+len(testFmt) : 28+len(testFmt)]); got != 4 {
+		t.Errorf("got data size %d, want 4", got)
+	}
+}
+
+// oggPage returns an Ogg page of the given serial number and payload.
+func oggPage(serial uint32, payload string) []byte {
+	b := []byte("OggS\x00\x02")
+	b = binary.LittleEndian.AppendUint64(b, 0)
+	b = binary.LittleEndian.AppendUint32(b, serial)
+	b = binary.LittleEndian.AppendUint32(b, 0)
+	b = binary.LittleEndian.AppendUint32(b, 0)
+	b = append(b, 1, byte(len(payload)))
+	b = append(b, payload...)
+	binary.LittleEndian.PutUint32(b[22:26], oggCRC(b))
+	return b
+}
+
+// bitwiseOggCRC computes the checksum of an Ogg page bit by bit.
+func bitwiseOggCRC(page []byte) uint32 {
+	p := append([]byte(nil), page...)
+	binary.LittleEndian.PutUint32(p[22:26], 0)
+	var crc uint32
+	for _, b := range p {
+		crc ^= uint32(b) << 24
+		for i := 0; i < 8; i++ {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04c11db7
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func TestSynthesizeOgg(t *testing.T) {
+	// Every response uses the same serial number.
+	c := &fakeClient{audio: func(text string) []byte {
+		return append(oggPage(7, text), oggPage(7, text+"!")...)
+	}}
+	var buf bytes.Buffer
+	if err := Synthesize(context.Background(), c, testConfig(texttospeechpb.AudioEncoding_OGG_OPUS), chunks("a", "b"), &buf); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	var serials []uint32
+	var payloads string
+	for len(b) > 0 {
+		n, err := oggPageLen(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		page := b[:n]
+		if got, want := binary.LittleEndian.Uint32(page[22:26]), bitwiseOggCRC(page); got != want {
+			t.Errorf("got CRC %#x, want %#x", got, want)
+		}
+		serials = append(serials, binary.LittleEndian.Uint32(page[14:18]))
+		payloads += string(page[28:])
+		b = b[n:]
+	}
+	if want := []uint32{7, 7, 8, 8}; !equalUint32s(serials, want) {
+		t.Errorf("got serial numbers %v, want %v", serials, want)
+	}
+	if payloads != "aa!bb!" {
+		t.Errorf("got payloads %q, want \"aa!bb!\"", payloads)
+	}
+}
+
+func equalUint32s(a, b []uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSynthesizeMP3SSML(t *testing.T) {
+	c := &fakeClient{audio: func(text string) []byte { return []byte(text) }}
+	cfg := testConfig(texttospeechpb.AudioEncoding_MP3)
+	cfg.SSML = true
+	var buf bytes.Buffer
+	if err := Synthesize(context.Background(), c, cfg, chunks("<speak>a</speak>", "<speak>b</speak>"), &buf); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "<speak>a</speak><speak>b</speak>"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSynthesizeCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := &fakeClient{audio: func(string) []byte { return nil }}
+	err := Synthesize(ctx, c, testConfig(texttospeechpb.AudioEncoding_MP3), make(chan string), io.Discard)
+	if err != context.Canceled {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+}
+
+func TestParseWAVErrors(t *testing.T) {
+	for _, b := range [][]byte{nil, []byte("RIFF\x00\x00\x00\x00WAVE"), []byte("OggS")} {
+		if _, _, err := parseWAV(b); err == nil {
+			t.Errorf("%q: got no error", b)
+		}
+	}
+}
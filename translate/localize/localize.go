@@ -0,0 +1,274 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package localize runs batch document translations and manages glossaries
+// with the translate/apiv3 TranslationClient, for localization pipelines.
+//
+// TranslateDocuments translates the documents under a Cloud Storage prefix
+// and waits for the translation, reporting its progress. CreateGlossary and
+// ReplaceGlossary create a glossary from a CSV, TSV or TMX file in Cloud
+// Storage and wait until it is ready to use.
+//
+// Example:
+//
+//	client, err := translate.NewTranslationClient(ctx)
+//	if err != nil {
+//		// TODO: Handle error.
+//	}
+//	g, err := localize.ReplaceGlossary(ctx, client, &localize.GlossarySpec{
+//		Name:          "projects/my-project/locations/us-central1/glossaries/product-terms",
+//		InputURI:      "gs://my-bucket/glossaries/product-terms.csv",
+//		LanguageCodes: []string{"en", "fr", "de"},
+//	})
+//	if err != nil {
+//		// TODO: Handle error.
+//	}
+//	resp, err := localize.TranslateDocuments(ctx, client, &localize.DocumentBatch{
+//		Parent:          "projects/my-project/locations/us-central1",
+//		SourceLanguage:  "en",
+//		TargetLanguages: []string{"fr", "de"},
+//		InputPrefix:     "gs://my-bucket/docs/",
+//		OutputPrefix:    "gs://my-bucket/translated/",
+//		Glossaries:      map[string]string{"fr": g.Name, "de": g.Name},
+//	}, func(m *translatepb.BatchTranslateDocumentMetadata) {
+//		log.Printf("%d/%d pages translated", m.TranslatedPages, m.TotalPages)
+//	})
+package localize // import "cloud.google.com/go/translate/localize"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	translate "cloud.google.com/go/translate/apiv3"
+	"cloud.google.com/go/translate/apiv3/translatepb"
+	gax "github.com/googleapis/gax-go/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// pollBackoff is the backoff between polls of an operation.
+var pollBackoff = gax.Backoff{
+	Initial:    5 * time.Second,
+	Max:        time.Minute,
+	Multiplier: 1.5,
+}
+
+// DocumentBatch describes the translation of the documents under a Cloud
+// Storage prefix.
+type DocumentBatch struct {
+	// Parent is the location of the translation, of the form
+	// "projects/PROJECT_ID/locations/LOCATION_ID". Batch translations require
+	// a regional location, such as us-central1.
+	Parent string
+
+	// SourceLanguage is the language code of the documents.
+	SourceLanguage string
+
+	// TargetLanguages are the language codes to translate the documents to.
+	TargetLanguages []string
+
+	// InputPrefix is the Cloud Storage prefix of the documents, as in
+	// "gs://bucket/docs/".
+	InputPrefix string
+
+	// OutputPrefix is the Cloud Storage prefix the translated documents are
+	// written under. It must be empty.
+	OutputPrefix string
+
+	// Glossaries, if not nil, maps target language codes to the full names of
+	// the glossaries to use for them.
+	Glossaries map[string]string
+
+	// Models, if not nil, maps target language codes to the models to use
+	// for them.
+	Models map[string]string
+}
+
+func (b *DocumentBatch) request() (*translatepb.BatchTranslateDocumentRequest, error) {
+	switch {
+	case b.Parent == "":
+		return nil, errors.New("localize: missing DocumentBatch.Parent")
+	case b.SourceLanguage == "" || len(b.TargetLanguages) == 0:
+		return nil, errors.New("localize: missing source or target languages")
+	case !strings.HasPrefix(b.InputPrefix, "gs://") || !strings.HasPrefix(b.OutputPrefix, "gs://"):
+		return nil, fmt.Errorf("localize: input and output prefixes must be Cloud Storage URIs, got %q and %q", b.InputPrefix, b.OutputPrefix)
+	}
+	req := &translatepb.BatchTranslateDocumentRequest{
+		Parent:              b.Parent,
+		SourceLanguageCode:  b.SourceLanguage,
+		TargetLanguageCodes: b.TargetLanguages,
+		InputConfigs: []*translatepb.BatchDocumentInputConfig{{
+			Source: &translatepb.BatchDocumentInputConfig_GcsSource{
+				GcsSource: &translatepb.GcsSource{InputUri: b.InputPrefix},
+			},
+		}},
+		OutputConfig: &translatepb.BatchDocumentOutputConfig{
+			Destination: &translatepb.BatchDocumentOutputConfig_GcsDestination{
+				GcsDestination: &translatepb.GcsDestination{OutputUriPrefix: b.OutputPrefix},
+			},
+		},
+		Models: b.Models,
+	}
+	if len(b.Glossaries) > 0 {
+		req.Glossaries = map[string]*translatepb.TranslateTextGlossaryConfig{}
+		for lang, name := range b.Glossaries {
+			req.Glossaries[lang] = &translatepb.TranslateTextGlossaryConfig{Glossary: name}
+		}
+	}
+	return req, nil
+}
+
+// TranslateDocuments starts the translation of the documents of b and waits
+// for it to complete. If progress is not nil, it is called with the metadata
+// of the operation each time the operation is polled before it completes.
+//
+// The returned response holds the page and character counts of the
+// translation; documents that failed to translate are listed in the
+// error_details file written under the output prefix.
+func TranslateDocuments(ctx context.Context, client *translate.TranslationClient, b *DocumentBatch, progress func(*translatepb.BatchTranslateDocumentMetadata)) (*translatepb.BatchTranslateDocumentResponse, error) {
+	req, err := b.request()
+	if err != nil {
+		return nil, err
+	}
+	op, err := client.BatchTranslateDocument(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return wait(ctx, op.Poll, op.Metadata, progress)
+}
+
+// wait polls an operation until it completes, calling progress with its
+// metadata after each poll that finds it running.
+func wait[R, M any](ctx context.Context, poll func(context.Context, ...gax.CallOption) (*R, error), metadata func() (M, error), progress func(M)) (*R, error) {
+	bo := pollBackoff
+	for {
+		resp, err := poll(ctx)
+		if err != nil || resp != nil {
+			return resp, err
+		}
+		if progress != nil {
+			if m, err := metadata(); err == nil {
+				progress(m)
+			}
+		}
+		if err := gax.Sleep(ctx, bo.Pause()); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// GlossarySpec describes a glossary.
+type GlossarySpec struct {
+	// Name is the full name of the glossary, of the form
+	// "projects/PROJECT_ID/locations/LOCATION_ID/glossaries/GLOSSARY_ID".
+	Name string
+
+	// DisplayName is the display name of the glossary.
+	DisplayName string
+
+	// InputURI is the Cloud Storage URI of the CSV, TSV or TMX file of the
+	// glossary entries.
+	InputURI string
+
+	// SourceLanguage and TargetLanguage are the language codes of a
+	// unidirectional glossary, whose file has one source term and one target
+	// term per row.
+	SourceLanguage, TargetLanguage string
+
+	// LanguageCodes are the language codes of an equivalent term sets
+	// glossary, whose file has a header row of language codes and one set of
+	// equivalent terms per row. It is exclusive with SourceLanguage and
+	// TargetLanguage.
+	LanguageCodes []string
+}
+
+func (s *GlossarySpec) glossary() (*translatepb.Glossary, error) {
+	i := strings.Index(s.Name, "/glossaries/")
+	if i < 0 {
+		return nil, fmt.Errorf("localize: glossary name %q is not of the form projects/P/locations/L/glossaries/G", s.Name)
+	}
+	if !strings.HasPrefix(s.InputURI, "gs://") {
+		return nil, fmt.Errorf("localize: glossary input %q is not a Cloud Storage URI", s.InputURI)
+	}
+	g := &translatepb.Glossary{
+		Name:        s.Name,
+		DisplayName: s.DisplayName,
+		InputConfig: &translatepb.GlossaryInputConfig{
+			Source: &translatepb.GlossaryInputConfig_GcsSource{
+				GcsSource: &translatepb.GcsSource{InputUri: s.InputURI},
+			},
+		},
+	}
+	pair := s.SourceLanguage != "" || s.TargetLanguage != ""
+	switch {
+	case pair && len(s.LanguageCodes) > 0:
+		return nil, errors.New("localize: a glossary has either a language pair or language codes")
+	case pair && (s.SourceLanguage == "" || s.TargetLanguage == ""):
+		return nil, errors.New("localize: a glossary language pair needs both languages")
+	case pair:
+		g.Languages = &translatepb.Glossary_LanguagePair{LanguagePair: &translatepb.Glossary_LanguageCodePair{
+			SourceLanguageCode: s.SourceLanguage,
+			TargetLanguageCode: s.TargetLanguage,
+		}}
+	case len(s.LanguageCodes) > 0:
+		g.Languages = &translatepb.Glossary_LanguageCodesSet_{LanguageCodesSet: &translatepb.Glossary_LanguageCodesSet{
+			LanguageCodes: s.LanguageCodes,
+		}}
+	default:
+		return nil, errors.New("localize: missing glossary languages")
+	}
+	return g, nil
+}
+
+// CreateGlossary creates the glossary described by s and waits until it is
+// ready to use. It returns the glossary, whose EntryCount is the number of
+// entries read from the input file.
+func CreateGlossary(ctx context.Context, client *translate.TranslationClient, s *GlossarySpec) (*translatepb.Glossary, error) {
+	g, err := s.glossary()
+	if err != nil {
+		return nil, err
+	}
+	op, err := client.CreateGlossary(ctx, &translatepb.CreateGlossaryRequest{
+		Parent:   s.Name[:strings.Index(s.Name, "/glossaries/")],
+		Glossary: g,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return wait(ctx, op.Poll, op.Metadata, nil)
+}
+
+// ReplaceGlossary replaces the glossary described by s with one created from
+// its input file, as glossaries cannot be updated: it deletes the glossary if
+// it exists, waits for the deletion, then creates the glossary with
+// CreateGlossary. The glossary is unavailable between the two steps.
+func ReplaceGlossary(ctx context.Context, client *translate.TranslationClient, s *GlossarySpec) (*translatepb.Glossary, error) {
+	if _, err := s.glossary(); err != nil {
+		return nil, err
+	}
+	op, err := client.DeleteGlossary(ctx, &translatepb.DeleteGlossaryRequest{Name: s.Name})
+	switch {
+	case status.Code(err) == codes.NotFound:
+	case err != nil:
+		return nil, err
+	default:
+		if _, err := wait(ctx, op.Poll, op.Metadata, nil); err != nil {
+			return nil, err
+		}
+	}
+	return CreateGlossary(ctx, client, s)
+}
@@ -0,0 +1,254 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package localize
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/internal/testutil"
+	longrunningpb "cloud.google.com/go/longrunning/autogen/longrunningpb"
+	translate "cloud.google.com/go/translate/apiv3"
+	"cloud.google.com/go/translate/apiv3/translatepb"
+	gax "github.com/googleapis/gax-go/v2"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// fakeServer runs operations that complete after a given number of polls.
+type fakeServer struct {
+	translatepb.UnimplementedTranslationServiceServer
+	longrunningpb.UnimplementedOperationsServer
+
+	mu        sync.Mutex
+	polls     int
+	ops       map[string]*fakeOp
+	glossary  map[string]*translatepb.Glossary
+	batchReq  *translatepb.BatchTranslateDocumentRequest
+	deletions int
+}
+
+type fakeOp struct {
+	polls    int
+	metadata []proto.Message
+	resp     proto.Message
+	err      error
+}
+
+func (s *fakeServer) start(name string, op *fakeOp) (*longrunningpb.Operation, error) {
+	s.ops[name] = op
+	return s.GetOperation(context.Background(), &longrunningpb.GetOperationRequest{Name: name})
+}
+
+func (s *fakeServer) GetOperation(_ context.Context, req *longrunningpb.GetOperationRequest) (*longrunningpb.Operation, error) {
+	op := s.ops[req.Name]
+	lop := &longrunningpb.Operation{Name: req.Name}
+	if op.polls < len(op.metadata) {
+		md, err := anypb.New(op.metadata[op.polls])
+		if err != nil {
+			return nil, err
+		}
+		lop.Metadata = md
+		op.polls++
+		return lop, nil
+	}
+	lop.Done = true
+	if op.err != nil {
+		lop.Result = &longrunningpb.Operation_Error{Error: status.Convert(op.err).Proto()}
+		return lop, nil
+	}
+	resp, err := anypb.New(op.resp)
+	if err != nil {
+		return nil, err
+	}
+	lop.Result = &longrunningpb.Operation_Response{Response: resp}
+	return lop, nil
+}
+
+func (s *fakeServer) BatchTranslateDocument(_ context.Context, req *translatepb.BatchTranslateDocumentRequest) (*longrunningpb.Operation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batchReq = req
+	return s.start("batch", &fakeOp{
+		metadata: []proto.Message{
+			&translatepb.BatchTranslateDocumentMetadata{TotalPages: 10, TranslatedPages: 0},
+			&translatepb.BatchTranslateDocumentMetadata{TotalPages: 10, TranslatedPages: 4},
+			&translatepb.BatchTranslateDocumentMetadata{TotalPages: 10, TranslatedPages: 8},
+		},
+		resp: &translatepb.BatchTranslateDocumentResponse{TotalPages: 10, TranslatedPages: 10},
+	})
+}
+
+func (s *fakeServer) CreateGlossary(_ context.Context, req *translatepb.CreateGlossaryRequest) (*longrunningpb.Operation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.glossary[req.Glossary.Name]; ok {
+		return nil, status.Error(codes.AlreadyExists, req.Glossary.Name)
+	}
+	g := proto.Clone(req.Glossary).(*translatepb.Glossary)
+	g.EntryCount = 3
+	s.glossary[g.Name] = g
+	return s.start("create", &fakeOp{
+		metadata: []proto.Message{&translatepb.CreateGlossaryMetadata{Name: g.Name}},
+		resp:     g,
+	})
+}
+
+func (s *fakeServer) DeleteGlossary(_ context.Context, req *translatepb.DeleteGlossaryRequest) (*longrunningpb.Operation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.glossary[req.Name]; !ok {
+		return nil, status.Error(codes.NotFound, req.Name)
+	}
+	delete(s.glossary, req.Name)
+	s.deletions++
+	return s.start("delete", &fakeOp{
+		metadata: []proto.Message{&translatepb.DeleteGlossaryMetadata{Name: req.Name}},
+		resp:     &translatepb.DeleteGlossaryResponse{Name: req.Name},
+	})
+}
+
+func newTestClient(t *testing.T) (*translate.TranslationClient, *fakeServer) {
+	t.Helper()
+	pb := pollBackoff
+	pollBackoff = gax.Backoff{Initial: 1, Max: 1}
+	t.Cleanup(func() { pollBackoff = pb })
+
+	fs := &fakeServer{ops: map[string]*fakeOp{}, glossary: map[string]*translatepb.Glossary{}}
+	srv, err := testutil.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	translatepb.RegisterTranslationServiceServer(srv.Gsrv, fs)
+	longrunningpb.RegisterOperationsServer(srv.Gsrv, fs)
+	srv.Start()
+	t.Cleanup(srv.Close)
+	conn, err := grpc.Dial(srv.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := translate.NewTranslationClient(context.Background(), option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, fs
+}
+
+func TestTranslateDocuments(t *testing.T) {
+	client, fs := newTestClient(t)
+	var progress []int64
+	resp, err := TranslateDocuments(context.Background(), client, &DocumentBatch{
+		Parent:          "projects/p/locations/us-central1",
+		SourceLanguage:  "en",
+		TargetLanguages: []string{"fr"},
+		InputPrefix:     "gs://b/in/",
+		OutputPrefix:    "gs://b/out/",
+		Glossaries:      map[string]string{"fr": "g"},
+	}, func(m *translatepb.BatchTranslateDocumentMetadata) {
+		progress = append(progress, m.TranslatedPages)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.TranslatedPages != 10 {
+		t.Errorf("got %d translated pages, want 10", resp.TranslatedPages)
+	}
+	// The operation returned by BatchTranslateDocument carries the first
+	// metadata; the following polls report the others.
+	if len(progress) != 2 || progress[0] != 4 || progress[1] != 8 {
+		t.Errorf("got progress %v, want [4 8]", progress)
+	}
+	req := fs.batchReq
+	if req.InputConfigs[0].GetGcsSource().InputUri != "gs://b/in/" ||
+		req.OutputConfig.GetGcsDestination().OutputUriPrefix != "gs://b/out/" ||
+		req.Glossaries["fr"].Glossary != "g" {
+		t.Errorf("got request %v", req)
+	}
+}
+
+func TestDocumentBatchErrors(t *testing.T) {
+	valid := DocumentBatch{
+		Parent:          "projects/p/locations/l",
+		SourceLanguage:  "en",
+		TargetLanguages: []string{"fr"},
+		InputPrefix:     "gs://b/in/",
+		OutputPrefix:    "gs://b/out/",
+	}
+	for _, f := range []func(*DocumentBatch){
+		func(b *DocumentBatch) { b.Parent = "" },
+		func(b *DocumentBatch) { b.TargetLanguages = nil },
+		func(b *DocumentBatch) { b.InputPrefix = "/local/in" },
+	} {
+		b := valid
+		f(&b)
+		if _, err := b.request(); err == nil {
+			t.Errorf("%+v: got no error", b)
+		}
+	}
+	if _, err := valid.request(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGlossaryLifecycle(t *testing.T) {
+	ctx := context.Background()
+	client, fs := newTestClient(t)
+	spec := &GlossarySpec{
+		Name:          "projects/p/locations/l/glossaries/g",
+		InputURI:      "gs://b/g.csv",
+		LanguageCodes: []string{"en", "fr"},
+	}
+	// Replacing a glossary that does not exist creates it.
+	g, err := ReplaceGlossary(ctx, client, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.EntryCount != 3 || g.GetLanguageCodesSet().LanguageCodes[1] != "fr" {
+		t.Errorf("got glossary %v", g)
+	}
+	if _, err := CreateGlossary(ctx, client, spec); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("got %v, want AlreadyExists", err)
+	}
+	spec.LanguageCodes = nil
+	spec.SourceLanguage, spec.TargetLanguage = "en", "de"
+	g, err = ReplaceGlossary(ctx, client, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fs.deletions != 1 || g.GetLanguagePair().TargetLanguageCode != "de" {
+		t.Errorf("got glossary %v after %d deletions, want a replaced en-de glossary", g, fs.deletions)
+	}
+}
+
+func TestGlossarySpecErrors(t *testing.T) {
+	for _, s := range []*GlossarySpec{
+		{Name: "g", InputURI: "gs://b/g.csv", LanguageCodes: []string{"en"}},
+		{Name: "projects/p/locations/l/glossaries/g", InputURI: "g.csv", LanguageCodes: []string{"en"}},
+		{Name: "projects/p/locations/l/glossaries/g", InputURI: "gs://b/g.csv"},
+		{Name: "projects/p/locations/l/glossaries/g", InputURI: "gs://b/g.csv", SourceLanguage: "en"},
+		{Name: "projects/p/locations/l/glossaries/g", InputURI: "gs://b/g.csv", SourceLanguage: "en", TargetLanguage: "fr", LanguageCodes: []string{"en"}},
+	} {
+		if _, err := s.glossary(); err == nil {
+			t.Errorf("%+v: got no error", s)
+		}
+	}
+}
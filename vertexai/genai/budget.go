@@ -0,0 +1,170 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"errors"
+	"sort"
+)
+
+// ErrTokenBudgetExceeded is returned by TokenBudget methods when the content
+// to send does not fit in the budget, even without any history.
+var ErrTokenBudgetExceeded = errors.New("genai: content exceeds the token budget")
+
+// Pricing is the price of the use of a model, in any currency. Vertex AI
+// prices models either by character or by token.
+type Pricing struct {
+	// InputPer1KCharacters is the price of 1,000 billable input characters.
+	InputPer1KCharacters float64
+	// OutputPer1KCharacters is the price of 1,000 output characters.
+	OutputPer1KCharacters float64
+	// InputPer1KTokens is the price of 1,000 input tokens.
+	InputPer1KTokens float64
+	// OutputPer1KTokens is the price of 1,000 output tokens.
+	OutputPer1KTokens float64
+}
+
+// A TokenBudget keeps the requests of a model within its context window.
+// Create one with [GenerativeModel.TokenBudget].
+type TokenBudget struct {
+	m *GenerativeModel
+
+	// ContextWindow is the maximum number of tokens of a request and its
+	// response, as documented for the model.
+	ContextWindow int32
+
+	// Pricing, if not nil, is used by EstimateCost.
+	Pricing *Pricing
+
+	// count counts the tokens of contents. It is replaced in tests.
+	count func(context.Context, []*Content) (*CountTokensResponse, error)
+}
+
+// TokenBudget returns a TokenBudget for requests of m with the given context
+// window, in tokens.
+func (m *GenerativeModel) TokenBudget(contextWindow int32) *TokenBudget {
+	return &TokenBudget{m: m, ContextWindow: contextWindow, count: m.countContents}
+}
+
+func (m *GenerativeModel) countContents(ctx context.Context, contents []*Content) (*CountTokensResponse, error) {
+	res, err := m.c.c.CountTokens(ctx, m.newCountTokensRequest(contents...))
+	if err != nil {
+		return nil, err
+	}
+	return (CountTokensResponse{}).fromProto(res), nil
+}
+
+// InputLimit returns the maximum number of input tokens of a request: the
+// context window, less the maximum number of output tokens of the model.
+func (b *TokenBudget) InputLimit() int32 {
+	out := int32(defaultMaxOutputTokens)
+	if b.m.MaxOutputTokens != nil {
+		out = *b.m.MaxOutputTokens
+	}
+	return b.ContextWindow - out
+}
+
+// Count counts the tokens of contents with CountTokens.
+func (b *TokenBudget) Count(ctx context.Context, contents ...*Content) (*CountTokensResponse, error) {
+	return b.count(ctx, contents)
+}
+
+// FitHistory returns the longest suffix of history that fits within
+// InputLimit together with a user message made of parts. The suffix starts
+// with a user turn, so that the turns keep alternating. It returns
+// ErrTokenBudgetExceeded if the message alone does not fit.
+//
+// FitHistory calls CountTokens a number of times logarithmic in the length
+// of history.
+func (b *TokenBudget) FitHistory(ctx context.Context, history []*Content, parts ...Part) ([]*Content, error) {
+	msg := newUserContent(parts)
+	limit := b.InputLimit()
+	fits := func(h []*Content) (bool, error) {
+		res, err := b.count(ctx, append(h[:len(h):len(h)], msg))
+		if err != nil {
+			return false, err
+		}
+		return res.TotalTokens <= limit, nil
+	}
+
+	// starts are the indexes of the user turns, and len(history) for no
+	// history at all.
+	var starts []int
+	for i, c := range history {
+		if c.Role == roleUser {
+			starts = append(starts, i)
+		}
+	}
+	starts = append(starts, len(history))
+
+	if ok, err := fits(history[starts[0]:]); err != nil || ok {
+		return history[starts[0]:], err
+	}
+	if ok, err := fits(nil); err != nil || !ok {
+		if err == nil {
+			err = ErrTokenBudgetExceeded
+		}
+		return nil, err
+	}
+	// The longer the history, the more tokens: find the first start that
+	// fits, knowing that the first one does not and the last one does.
+	var searchErr error
+	i := sort.Search(len(starts)-2, func(i int) bool {
+		if searchErr != nil {
+			return true
+		}
+		ok, err := fits(history[starts[i+1]:])
+		searchErr = err
+		return ok
+	})
+	if searchErr != nil {
+		return nil, searchErr
+	}
+	return history[starts[i+1]:], nil
+}
+
+// TrimHistory removes the oldest turns of the history of cs, so that sending
+// a message made of parts fits within InputLimit. It returns the number of
+// turns removed. See FitHistory for details.
+func (b *TokenBudget) TrimHistory(ctx context.Context, cs *ChatSession, parts ...Part) (int, error) {
+	h, err := b.FitHistory(ctx, cs.History, parts...)
+	if err != nil {
+		return 0, err
+	}
+	n := len(cs.History) - len(h)
+	cs.History = h
+	return n, nil
+}
+
+// EstimateCost estimates the cost of a request of contents, assuming that
+// the response has the maximum number of output tokens of the model, and that
+// an output token is four characters. It requires Pricing.
+func (b *TokenBudget) EstimateCost(ctx context.Context, contents ...*Content) (float64, error) {
+	if b.Pricing == nil {
+		return 0, errors.New("genai: TokenBudget.Pricing is not set")
+	}
+	res, err := b.count(ctx, contents)
+	if err != nil {
+		return 0, err
+	}
+	out := float64(b.ContextWindow - b.InputLimit())
+	p := b.Pricing
+	cost := float64(res.TotalBillableCharacters)/1000*p.InputPer1KCharacters +
+		float64(res.TotalTokens)/1000*p.InputPer1KTokens +
+		out*4/1000*p.OutputPer1KCharacters +
+		out/1000*p.OutputPer1KTokens
+	return cost, nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+// newTestBudget returns a budget whose tokens are the characters of the text
+// parts, with an input limit of limit.
+func newTestBudget(limit int32) (*TokenBudget, *int) {
+	calls := 0
+	m := &GenerativeModel{}
+	m.SetMaxOutputTokens(10)
+	b := &TokenBudget{m: m, ContextWindow: limit + 10}
+	b.count = func(_ context.Context, contents []*Content) (*CountTokensResponse, error) {
+		calls++
+		var n int32
+		for _, c := range contents {
+			for _, p := range c.Parts {
+				if t, ok := p.(Text); ok {
+					n += int32(len(t))
+				}
+			}
+		}
+		return &CountTokensResponse{TotalTokens: n, TotalBillableCharacters: 2 * n}, nil
+	}
+	return b, &calls
+}
+
+func turns(texts ...string) []*Content {
+	var h []*Content
+	for i, t := range texts {
+		role := roleUser
+		if i%2 == 1 {
+			role = roleModel
+		}
+		h = append(h, &Content{Role: role, Parts: []Part{Text(t)}})
+	}
+	return h
+}
+
+func TestFitHistory(t *testing.T) {
+	ctx := context.Background()
+	history := turns("aaaa", "bbbb", "cc", "dd", "e", "f")
+	for _, test := range []struct {
+		limit int32
+		want  int // number of turns kept
+	}{
+		{20, 6},
+		{19, 4},
+		{18, 4},
+		{12, 4},
+		{11, 2},
+		{8, 2},
+		{7, 0},
+		{6, 0},
+	} {
+		b, calls := newTestBudget(test.limit)
+		got, err := b.FitHistory(ctx, history, Text("msg!!!"))
+		if err != nil {
+			t.Fatalf("limit %d: %v", test.limit, err)
+		}
+		if len(got) != test.want {
+			t.Errorf("limit %d: kept %d turns, want %d", test.limit, len(got), test.want)
+		}
+		if len(got) > 0 && got[0].Role != roleUser {
+			t.Errorf("limit %d: history starts with a %s turn", test.limit, got[0].Role)
+		}
+		if *calls > 4 {
+			t.Errorf("limit %d: %d calls to CountTokens", test.limit, *calls)
+		}
+	}
+
+	b, _ := newTestBudget(5)
+	if _, err := b.FitHistory(ctx, history, Text("msg!!!")); !errors.Is(err, ErrTokenBudgetExceeded) {
+		t.Errorf("got %v, want ErrTokenBudgetExceeded", err)
+	}
+}
+
+func TestTrimHistory(t *testing.T) {
+	b, _ := newTestBudget(12)
+	cs := &ChatSession{History: turns("aaaa", "bbbb", "cc", "dd")}
+	n, err := b.TrimHistory(context.Background(), cs, Text("xx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || len(cs.History) != 2 || cs.History[0].Parts[0] != Text("cc") {
+		t.Errorf("removed %d turns, leaving %v", n, cs.History)
+	}
+}
+
+func TestEstimateCost(t *testing.T) {
+	b, _ := newTestBudget(100)
+	if _, err := b.EstimateCost(context.Background(), turns("x")...); err == nil {
+		t.Error("got no error without pricing")
+	}
+	b.Pricing = &Pricing{InputPer1KCharacters: 1, OutputPer1KCharacters: 2, InputPer1KTokens: 10, OutputPer1KTokens: 20}
+	got, err := b.EstimateCost(context.Background(), turns("abcde")...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 10 input characters, 5 input tokens, 10 output tokens and 40 output
+	// characters.
+	want := 0.010 + 0.050 + 0.200 + 0.080
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,326 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	// outputFunctionName is the name of the function that GenerateInto asks
+	// the model to call with its output.
+	outputFunctionName = "record_output"
+
+	// generateIntoAttempts is the number of times GenerateInto asks for
+	// output before giving up on invalid output.
+	generateIntoAttempts = 3
+)
+
+// An InvalidOutputError is returned by GenerateInto when the model did not
+// produce output matching the schema of the result.
+type InvalidOutputError struct {
+	// Response is the last response of the model.
+	Response *GenerateContentResponse
+	// Err describes the problem with the output.
+	Err error
+}
+
+func (e *InvalidOutputError) Error() string {
+	return "genai: invalid structured output: " + e.Err.Error()
+}
+
+func (e *InvalidOutputError) Unwrap() error { return e.Err }
+
+// GenerateInto asks the model for output matching the type of out, which
+// must be a non-nil pointer to a struct, and stores the output in out.
+//
+// The schema of the output is derived from the type with [SchemaFor] and
+// given to the model as the parameters of a function that it is asked to
+// call, as the model has no JSON output mode. Output given as JSON text,
+// rather than as a function call, is accepted too. If the output does not
+// match the schema, the request is sent again, up to three times in all; the
+// error is then an *InvalidOutputError.
+func (m *GenerativeModel) GenerateInto(ctx context.Context, out any, parts ...Part) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("genai: GenerateInto needs a non-nil pointer to a struct, got %T", out)
+	}
+	schema, err := SchemaFor(v.Elem().Interface())
+	if err != nil {
+		return err
+	}
+	m2 := *m
+	m2.Tools = []*Tool{{FunctionDeclarations: []*FunctionDeclaration{{
+		Name:        outputFunctionName,
+		Description: "Records the answer to the request, in structured form.",
+		Parameters:  schema,
+	}}}}
+	parts = append(parts[:len(parts):len(parts)],
+		Text("Answer by calling the "+outputFunctionName+" function with all its required parameters."))
+
+	var invalid *InvalidOutputError
+	for i := 0; i < generateIntoAttempts; i++ {
+		resp, err := m2.GenerateContent(ctx, parts...)
+		if err != nil {
+			return err
+		}
+		if err := decodeOutput(resp, schema, out); err != nil {
+			invalid = &InvalidOutputError{Response: resp, Err: err}
+			continue
+		}
+		return nil
+	}
+	return invalid
+}
+
+// decodeOutput stores in out the output of the first candidate of resp,
+// given either as a call of the output function or as JSON text.
+func decodeOutput(resp *GenerateContentResponse, schema *Schema, out any) error {
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		return errors.New("no candidates")
+	}
+	var data []byte
+	var text strings.Builder
+	for _, p := range resp.Candidates[0].Content.Parts {
+		switch p := p.(type) {
+		case FunctionCall:
+			if p.Name != outputFunctionName {
+				return fmt.Errorf("call of unknown function %q", p.Name)
+			}
+			b, err := json.Marshal(p.Args)
+			if err != nil {
+				return err
+			}
+			data = b
+		case Text:
+			text.WriteString(string(p))
+		}
+	}
+	if data == nil {
+		data = []byte(stripCodeFence(text.String()))
+	}
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("output is not JSON: %w", err)
+	}
+	if err := validate(raw, schema, "output"); err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(out)
+}
+
+// stripCodeFence removes the Markdown code fence that models often put
+// around JSON text.
+func stripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	s = strings.TrimPrefix(s, "```")
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:] // drop the language, as in ```json
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "```"))
+}
+
+// validate checks that the decoded JSON value v matches s. path names v in
+// errors.
+func validate(v any, s *Schema, path string) error {
+	if v == nil {
+		if s.Nullable || s.Type == TypeUnspecified {
+			return nil
+		}
+		return fmt.Errorf("%s is null", path)
+	}
+	switch s.Type {
+	case TypeObject:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s is not an object", path)
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				return fmt.Errorf("%s lacks required property %q", path, name)
+			}
+		}
+		for name, pv := range obj {
+			if ps, ok := s.Properties[name]; ok {
+				if err := validate(pv, ps, path+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	case TypeArray:
+		arr, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("%s is not an array", path)
+		}
+		for i, ev := range arr {
+			if err := validate(ev, s.Items, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case TypeString:
+		str, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s is not a string", path)
+		}
+		if len(s.Enum) > 0 && !containsString(s.Enum, str) {
+			return fmt.Errorf("%s is %q, not one of %q", path, str, s.Enum)
+		}
+	case TypeInteger:
+		f, ok := v.(float64)
+		if !ok || f != float64(int64(f)) {
+			return fmt.Errorf("%s is not an integer", path)
+		}
+	case TypeNumber:
+		if _, ok := v.(float64); !ok {
+			return fmt.Errorf("%s is not a number", path)
+		}
+	case TypeBoolean:
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s is not a boolean", path)
+		}
+	}
+	return nil
+}
+
+func containsString(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// SchemaFor returns the schema of the JSON encoding of v, which is used by
+// GenerateInto.
+//
+// Structs are objects whose properties are named after the json tags of
+// their exported fields; the fields without the omitempty option are
+// required. The description of a property is taken from the "genai" tag of
+// its field, and the allowed values of a string property from the "enum" tag,
+// as a comma-separated list. Pointers are nullable. time.Time values are
+// strings in RFC 3339 format. Recursive types are not supported.
+func SchemaFor(v any) (*Schema, error) {
+	return schemaFor(reflect.TypeOf(v), map[reflect.Type]bool{})
+}
+
+func schemaFor(t reflect.Type, visiting map[reflect.Type]bool) (*Schema, error) {
+	if t == nil {
+		return nil, errors.New("genai: no schema for nil")
+	}
+	if t == timeType {
+		return &Schema{Type: TypeString, Description: "RFC 3339 date and time"}, nil
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: TypeBoolean}, nil
+	case reflect.String:
+		return &Schema{Type: TypeString}, nil
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: TypeInteger, Format: "int64"}, nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: TypeInteger, Format: "int32"}, nil
+	case reflect.Float32:
+		return &Schema{Type: TypeNumber, Format: "float"}, nil
+	case reflect.Float64:
+		return &Schema{Type: TypeNumber, Format: "double"}, nil
+	case reflect.Pointer:
+		s, err := schemaFor(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		s.Nullable = true
+		return s, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: TypeString, Description: "base64-encoded bytes"}, nil
+		}
+		items, err := schemaFor(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: TypeArray, Items: items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			break
+		}
+		return &Schema{Type: TypeObject}, nil
+	case reflect.Struct:
+		if visiting[t] {
+			return nil, fmt.Errorf("genai: recursive type %v has no schema", t)
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		s := &Schema{Type: TypeObject, Properties: map[string]*Schema{}}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			// As in encoding/json, the fields of embedded structs are
+			// used even if the struct type is unexported.
+			if !f.IsExported() && !(f.Anonymous && ft.Kind() == reflect.Struct) {
+				continue
+			}
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" && opts == "" {
+				continue
+			}
+			fs, err := schemaFor(f.Type, visiting)
+			if err != nil {
+				return nil, err
+			}
+			if name == "" && f.Anonymous && ft.Kind() == reflect.Struct {
+				// encoding/json inlines the fields of embedded structs.
+				for n, ps := range fs.Properties {
+					s.Properties[n] = ps
+				}
+				s.Required = append(s.Required, fs.Required...)
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			if d := f.Tag.Get("genai"); d != "" {
+				fs.Description = d
+			}
+			if enum := f.Tag.Get("enum"); enum != "" && fs.Type == TypeString {
+				fs.Format = "enum"
+				fs.Enum = strings.Split(enum, ",")
+			}
+			s.Properties[name] = fs
+			if !strings.Contains(","+opts+",", ",omitempty,") {
+				s.Required = append(s.Required, name)
+			}
+		}
+		return s, nil
+	}
+	return nil, fmt.Errorf("genai: no schema for type %v", t)
+}
@@ -0,0 +1,132 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genai
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+type testBase struct {
+	ID int64 `json:"id"`
+}
+
+type testRecipe struct {
+	testBase
+	Name        string            `json:"name" genai:"name of the recipe"`
+	Course      string            `json:"course" enum:"starter,main,dessert"`
+	Servings    int32             `json:"servings,omitempty"`
+	Ingredients []testIngredient  `json:"ingredients"`
+	Rating      *float64          `json:"rating"`
+	Created     time.Time         `json:"created,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Ignored     string            `json:"-"`
+	internal    int
+}
+
+type testIngredient struct {
+	Name     string  `json:"name"`
+	Quantity float32 `json:"quantity"`
+}
+
+func TestSchemaFor(t *testing.T) {
+	got, err := SchemaFor(testRecipe{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got.Required)
+	want := &Schema{
+		Type: TypeObject,
+		Properties: map[string]*Schema{
+			"id":       {Type: TypeInteger, Format: "int64"},
+			"name":     {Type: TypeString, Description: "name of the recipe"},
+			"course":   {Type: TypeString, Format: "enum", Enum: []string{"starter", "main", "dessert"}},
+			"servings": {Type: TypeInteger, Format: "int32"},
+			"ingredients": {Type: TypeArray, Items: &Schema{
+				Type: TypeObject,
+				Properties: map[string]*Schema{
+					"name":     {Type: TypeString},
+					"quantity": {Type: TypeNumber, Format: "float"},
+				},
+				Required: []string{"name", "quantity"},
+			}},
+			"rating":  {Type: TypeNumber, Format: "double", Nullable: true},
+			"created": {Type: TypeString, Description: "RFC 3339 date and time"},
+			"tags":    {Type: TypeObject},
+		},
+		Required: []string{"course", "id", "ingredients", "name", "rating"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+
+	type node struct{ Next *node }
+	for _, v := range []any{node{}, make(chan int), map[int]string{}} {
+		if _, err := SchemaFor(v); err == nil {
+			t.Errorf("%T: got no error", v)
+		}
+	}
+}
+
+func TestDecodeOutput(t *testing.T) {
+	schema, err := SchemaFor(testIngredient{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := func(parts ...Part) *GenerateContentResponse {
+		return &GenerateContentResponse{Candidates: []*Candidate{{Content: &Content{Role: roleModel, Parts: parts}}}}
+	}
+	want := testIngredient{Name: "flour", Quantity: 0.5}
+	for _, r := range []*GenerateContentResponse{
+		resp(FunctionCall{Name: outputFunctionName, Args: map[string]any{"name": "flour", "quantity": 0.5}}),
+		resp(Text(`{"name": "flour", "quantity": 0.5}`)),
+		resp(Text("```json\n{\"name\": \"flour\",\n"), Text("\"quantity\": 0.5}\n```")),
+	} {
+		var got testIngredient
+		if err := decodeOutput(r, schema, &got); err != nil {
+			t.Errorf("%v: %v", r.Candidates[0].Content.Parts, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	}
+
+	for _, r := range []*GenerateContentResponse{
+		{},
+		resp(Text("I don't know.")),
+		resp(Text(`{"name": "flour"}`)),
+		resp(Text(`{"name": "flour", "quantity": "half"}`)),
+		resp(Text(`{"name": "flour", "quantity": 1, "unit": "kg"}`)),
+		resp(FunctionCall{Name: "other", Args: map[string]any{}}),
+	} {
+		var got testIngredient
+		if err := decodeOutput(r, schema, &got); err == nil {
+			t.Errorf("%v: got no error", r.Candidates)
+		}
+	}
+}
+
+func TestValidateEnum(t *testing.T) {
+	s := &Schema{Type: TypeString, Format: "enum", Enum: []string{"a", "b"}}
+	if err := validate("a", s, "v"); err != nil {
+		t.Error(err)
+	}
+	if err := validate("c", s, "v"); err == nil {
+		t.Error("got no error")
+	}
+}
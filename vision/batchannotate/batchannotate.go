@@ -0,0 +1,268 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package batchannotate annotates any number of images with the
+// BatchAnnotateImages method of the vision/apiv1 ImageAnnotatorClient.
+//
+// Annotate partitions the images into requests of at most 16 images, the
+// limit of the Vision API, sends the requests concurrently, retries the
+// requests that fail with a transient error, and returns a result for each
+// image, in the order of the images.
+//
+// Example:
+//
+//	client, err := vision.NewImageAnnotatorClient(ctx)
+//	if err != nil {
+//		// TODO: Handle error.
+//	}
+//	images := []batchannotate.Input{
+//		batchannotate.FromFile("cat.jpg"),
+//		batchannotate.FromURI("gs://my-bucket/dog.png"),
+//	}
+//	results := batchannotate.Annotate(ctx, client, images, batchannotate.Options{
+//		Features: []*visionpb.Feature{{Type: visionpb.Feature_LABEL_DETECTION}},
+//	})
+//	for i, r := range results {
+//		if r.Err != nil {
+//			log.Printf("image %d: %v", i, r.Err)
+//			continue
+//		}
+//		fmt.Println(r.Response.LabelAnnotations)
+//	}
+package batchannotate // import "cloud.google.com/go/vision/v2/batchannotate"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/vision/v2/apiv1/visionpb"
+	gax "github.com/googleapis/gax-go/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// MaxImagesPerRequest is the maximum number of images in a
+	// BatchAnnotateImages request.
+	MaxImagesPerRequest = 16
+
+	// DefaultMaxConcurrency is the default number of requests that Annotate
+	// has in flight at a time.
+	DefaultMaxConcurrency = 4
+
+	// maxAttempts is the number of times a request is sent before its error
+	// is returned.
+	maxAttempts = 4
+)
+
+// retryBackoff is the backoff between attempts at sending a request.
+var retryBackoff = gax.Backoff{
+	Initial:    time.Second,
+	Max:        30 * time.Second,
+	Multiplier: 2,
+}
+
+// Client is the subset of the methods of the vision/apiv1
+// ImageAnnotatorClient used by Annotate.
+type Client interface {
+	BatchAnnotateImages(context.Context, *visionpb.BatchAnnotateImagesRequest, ...gax.CallOption) (*visionpb.BatchAnnotateImagesResponse, error)
+}
+
+// Input is an image to annotate.
+type Input interface {
+	image() (*visionpb.Image, error)
+}
+
+type fileInput string
+
+func (f fileInput) image() (*visionpb.Image, error) {
+	b, err := os.ReadFile(string(f))
+	if err != nil {
+		return nil, err
+	}
+	return &visionpb.Image{Content: b}, nil
+}
+
+// FromFile returns an Input for the image in the file at path. The file is
+// read when its request is built.
+func FromFile(path string) Input { return fileInput(path) }
+
+type readerInput struct{ r io.Reader }
+
+func (r readerInput) image() (*visionpb.Image, error) {
+	b, err := io.ReadAll(r.r)
+	if err != nil {
+		return nil, err
+	}
+	return &visionpb.Image{Content: b}, nil
+}
+
+// FromReader returns an Input for the image read from r. It is read when its
+// request is built.
+func FromReader(r io.Reader) Input { return readerInput{r} }
+
+type uriInput string
+
+func (u uriInput) image() (*visionpb.Image, error) {
+	return &visionpb.Image{Source: &visionpb.ImageSource{ImageUri: string(u)}}, nil
+}
+
+// FromURI returns an Input for the image at uri, which is either a Cloud
+// Storage URI of the form "gs://bucket/object" or a public HTTP or HTTPS URL.
+func FromURI(uri string) Input { return uriInput(uri) }
+
+type imageInput struct{ img *visionpb.Image }
+
+func (i imageInput) image() (*visionpb.Image, error) { return i.img, nil }
+
+// FromImage returns an Input for img.
+func FromImage(img *visionpb.Image) Input { return imageInput{img} }
+
+// Options configures Annotate.
+type Options struct {
+	// Features are the features to detect in each image. At least one is
+	// required.
+	Features []*visionpb.Feature
+
+	// ImageContext, if not nil, is the context of every image.
+	ImageContext *visionpb.ImageContext
+
+	// Parent, if not empty, is the project and location that annotate the
+	// images, of the form "projects/PROJECT_ID/locations/LOCATION_ID".
+	Parent string
+
+	// MaxConcurrency is the maximum number of requests in flight at a time.
+	// If it is not positive, DefaultMaxConcurrency is used.
+	MaxConcurrency int
+}
+
+// Result is the result of the annotation of an image.
+type Result struct {
+	// Response is the annotation of the image, or nil if Err is set.
+	Response *visionpb.AnnotateImageResponse
+
+	// Err is the error that prevented the annotation of the image: an error
+	// reading the image, the error of its request, or the error of its
+	// response, as a gRPC status error.
+	Err error
+}
+
+// Annotate annotates images and returns their results, in the order of
+// images. The requests that fail with an Unavailable, DeadlineExceeded or
+// ResourceExhausted error are retried with exponential backoff.
+func Annotate(ctx context.Context, client Client, images []Input, opts Options) []Result {
+	results := make([]Result, len(images))
+	if len(opts.Features) == 0 {
+		for i := range results {
+			results[i].Err = errors.New("batchannotate: no features to detect")
+		}
+		return results
+	}
+	n := opts.MaxConcurrency
+	if n <= 0 {
+		n = DefaultMaxConcurrency
+	}
+	sem := make(chan struct{}, n)
+	var wg sync.WaitGroup
+	for start := 0; start < len(images); start += MaxImagesPerRequest {
+		end := start + MaxImagesPerRequest
+		if end > len(images) {
+			end = len(images)
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			for i := start; i < len(images); i++ {
+				results[i].Err = ctx.Err()
+			}
+			wg.Wait()
+			return results
+		}
+		wg.Add(1)
+		go func(start int, images []Input) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			annotate(ctx, client, images, opts, results[start:start+len(images)])
+		}(start, images[start:end])
+	}
+	wg.Wait()
+	return results
+}
+
+// annotate annotates images with a single request, storing their results in
+// results.
+func annotate(ctx context.Context, client Client, images []Input, opts Options, results []Result) {
+	req := &visionpb.BatchAnnotateImagesRequest{Parent: opts.Parent}
+	// index maps the requests to the images that could be read.
+	var index []int
+	for i, in := range images {
+		img, err := in.image()
+		if err != nil {
+			results[i].Err = fmt.Errorf("batchannotate: reading image: %w", err)
+			continue
+		}
+		req.Requests = append(req.Requests, &visionpb.AnnotateImageRequest{
+			Image:        img,
+			Features:     opts.Features,
+			ImageContext: opts.ImageContext,
+		})
+		index = append(index, i)
+	}
+	if len(index) == 0 {
+		return
+	}
+	resp, err := batchAnnotate(ctx, client, req)
+	if err == nil && len(resp.Responses) != len(index) {
+		err = fmt.Errorf("batchannotate: got %d responses for %d images", len(resp.Responses), len(index))
+	}
+	for j, i := range index {
+		switch {
+		case err != nil:
+			results[i].Err = err
+		case resp.Responses[j].GetError().GetCode() != int32(codes.OK):
+			results[i].Err = status.ErrorProto(resp.Responses[j].Error)
+		default:
+			results[i].Response = resp.Responses[j]
+		}
+	}
+}
+
+// batchAnnotate sends req, retrying it on transient errors.
+func batchAnnotate(ctx context.Context, client Client, req *visionpb.BatchAnnotateImagesRequest) (*visionpb.BatchAnnotateImagesResponse, error) {
+	bo := retryBackoff
+	for attempt := 1; ; attempt++ {
+		resp, err := client.BatchAnnotateImages(ctx, req)
+		if err == nil || attempt == maxAttempts || !retryable(err) {
+			return resp, err
+		}
+		if err := gax.Sleep(ctx, bo.Pause()); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batchannotate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/vision/v2/apiv1/visionpb"
+	gax "github.com/googleapis/gax-go/v2"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeClient labels each image with its URI or content. Images with the
+// content "bad" get an error response.
+type fakeClient struct {
+	mu       sync.Mutex
+	sizes    []int
+	failures int // number of Unavailable errors to return first
+}
+
+func (c *fakeClient) BatchAnnotateImages(_ context.Context, req *visionpb.BatchAnnotateImagesRequest, _ ...gax.CallOption) (*visionpb.BatchAnnotateImagesResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failures > 0 {
+		c.failures--
+		return nil, status.Error(codes.Unavailable, "try again")
+	}
+	c.sizes = append(c.sizes, len(req.Requests))
+	resp := &visionpb.BatchAnnotateImagesResponse{}
+	for _, r := range req.Requests {
+		desc := r.Image.GetSource().GetImageUri() + string(r.Image.Content)
+		if desc == "bad" {
+			resp.Responses = append(resp.Responses, &visionpb.AnnotateImageResponse{
+				Error: &spb.Status{Code: int32(codes.InvalidArgument), Message: "bad image"},
+			})
+			continue
+		}
+		resp.Responses = append(resp.Responses, &visionpb.AnnotateImageResponse{
+			LabelAnnotations: []*visionpb.EntityAnnotation{{Description: desc}},
+		})
+	}
+	return resp, nil
+}
+
+var labelFeatures = []*visionpb.Feature{{Type: visionpb.Feature_LABEL_DETECTION}}
+
+func setRetryBackoff(t *testing.T) {
+	bo := retryBackoff
+	retryBackoff = gax.Backoff{Initial: 1, Max: 1}
+	t.Cleanup(func() { retryBackoff = bo })
+}
+
+func TestAnnotatePartitions(t *testing.T) {
+	c := &fakeClient{}
+	var images []Input
+	for i := 0; i < 35; i++ {
+		images = append(images, FromURI(fmt.Sprintf("gs://b/%d", i)))
+	}
+	results := Annotate(context.Background(), c, images, Options{Features: labelFeatures, MaxConcurrency: 2})
+	sort.Ints(c.sizes)
+	if fmt.Sprint(c.sizes) != "[3 16 16]" {
+		t.Errorf("got requests of %v images, want [3 16 16]", c.sizes)
+	}
+	for i, r := range results {
+		if r.Err != nil {
+			t.Fatalf("image %d: %v", i, r.Err)
+		}
+		if got, want := r.Response.LabelAnnotations[0].Description, fmt.Sprintf("gs://b/%d", i); got != want {
+			t.Errorf("image %d: got result for %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestAnnotateInputs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "img")
+	if err := os.WriteFile(path, []byte("file"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	images := []Input{
+		FromFile(path),
+		FromFile(filepath.Join(t.TempDir(), "missing")),
+		FromReader(strings.NewReader("reader")),
+		FromImage(&visionpb.Image{Content: []byte("bad")}),
+		FromImage(&visionpb.Image{Content: []byte("image")}),
+	}
+	results := Annotate(context.Background(), &fakeClient{}, images, Options{Features: labelFeatures})
+	for i, want := range []string{"file", "", "reader", "", "image"} {
+		r := results[i]
+		if want == "" {
+			if r.Err == nil || r.Response != nil {
+				t.Errorf("image %d: got %v, %v; want an error", i, r.Response, r.Err)
+			}
+			continue
+		}
+		if r.Err != nil || r.Response.LabelAnnotations[0].Description != want {
+			t.Errorf("image %d: got %v, %v; want %q", i, r.Response, r.Err, want)
+		}
+	}
+	if !errors.Is(results[1].Err, os.ErrNotExist) {
+		t.Errorf("got %v, want a file not found error", results[1].Err)
+	}
+	if status.Code(results[3].Err) != codes.InvalidArgument {
+		t.Errorf("got %v, want InvalidArgument", results[3].Err)
+	}
+}
+
+func TestAnnotateRetries(t *testing.T) {
+	setRetryBackoff(t)
+	c := &fakeClient{failures: maxAttempts - 1}
+	results := Annotate(context.Background(), c, []Input{FromURI("gs://b/o")}, Options{Features: labelFeatures})
+	if results[0].Err != nil {
+		t.Errorf("got %v, want success after retries", results[0].Err)
+	}
+
+	c = &fakeClient{failures: maxAttempts}
+	results = Annotate(context.Background(), c, []Input{FromURI("gs://b/o")}, Options{Features: labelFeatures})
+	if status.Code(results[0].Err) != codes.Unavailable {
+		t.Errorf("got %v, want Unavailable", results[0].Err)
+	}
+}
+
+func TestAnnotateNoFeatures(t *testing.T) {
+	results := Annotate(context.Background(), &fakeClient{}, []Input{FromURI("gs://b/o")}, Options{})
+	if results[0].Err == nil {
+		t.Error("got no error")
+	}
+}